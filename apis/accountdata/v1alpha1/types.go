@@ -0,0 +1,150 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// AccountDataParameters define the desired state of an entry of a Matrix
+// user's account data
+type AccountDataParameters struct {
+	// UserID is the user whose account data this is. Together with Type it
+	// identifies the account data, so changing either manages other account
+	// data and leaves the previous one as it was.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern="^@[a-zA-Z0-9._=/-]+:[a-zA-Z0-9.-]+$"
+	UserID string `json:"userID"`
+
+	// Type is the type of the account data, such as m.ignored_user_list or a
+	// custom namespaced key like com.example.settings
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Type string `json:"type"`
+
+	// Content is the account data. An empty object is what deleted account
+	// data is left as, so it can't be managed.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:validation:MinProperties=1
+	Content runtime.RawExtension `json:"content"`
+
+	// ObserveOnlyFields lists fields of forProvider that are only used to
+	// set the account data. Afterwards they are observed, but never updated
+	// or reported as drift, so that the user's client can change them.
+	// +listType=set
+	// +kubebuilder:validation:items:Enum=content
+	ObserveOnlyFields []string `json:"observeOnlyFields,omitempty"`
+}
+
+// AccountDataObservation reflects the observed state of an entry of a Matrix
+// user's account data. The content isn't copied to the status, as account
+// data can hold settings the user wouldn't share.
+type AccountDataObservation struct {
+	// ManagedBy is Created if the provider set the account data, or Adopted
+	// if it already existed when the provider started managing it
+	ManagedBy string `json:"managedBy,omitempty"`
+
+	// AdoptedAt is when the provider first observed the account data, if it
+	// adopted it
+	AdoptedAt *metav1.Time `json:"adoptedAt,omitempty"`
+}
+
+// An AccountDataSpec defines the desired state of an AccountData.
+type AccountDataSpec struct {
+	xpv1.ManagedResourceSpec `json:",inline"`
+	ForProvider              AccountDataParameters `json:"forProvider"`
+}
+
+// An AccountDataStatus represents the observed state of an AccountData.
+type AccountDataStatus struct {
+	xpv1.ManagedResourceStatus `json:",inline"`
+	AtProvider                 AccountDataObservation `json:"atProvider,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// An AccountData is a managed resource that represents an entry of a Matrix
+// user's account data, such as the users they ignore or a custom namespaced
+// key an integration reads. Account data of users other than the provider's
+// own requires the Synapse admin API.
+// +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="USER",type="string",JSONPath=".spec.forProvider.userID"
+// +kubebuilder:printcolumn:name="TYPE",type="string",JSONPath=".spec.forProvider.type"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories={crossplane,managed,matrix}
+type AccountData struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AccountDataSpec   `json:"spec"`
+	Status AccountDataStatus `json:"status,omitempty"`
+}
+
+// GetProviderConfigReference returns the provider config reference.
+func (r *AccountData) GetProviderConfigReference() *xpv1.ProviderConfigReference {
+	return r.Spec.ProviderConfigReference
+}
+
+// SetProviderConfigReference sets the provider config reference.
+func (r *AccountData) SetProviderConfigReference(ref *xpv1.ProviderConfigReference) {
+	r.Spec.ProviderConfigReference = ref
+}
+
+// GetCondition returns the condition with the given type.
+func (r *AccountData) GetCondition(ct xpv1.ConditionType) xpv1.Condition {
+	return r.Status.GetCondition(ct)
+}
+
+// SetConditions sets the conditions.
+func (r *AccountData) SetConditions(c ...xpv1.Condition) {
+	r.Status.SetConditions(c...)
+}
+
+// GetManagementPolicies returns the management policies.
+func (r *AccountData) GetManagementPolicies() xpv1.ManagementPolicies {
+	return r.Spec.ManagementPolicies
+}
+
+// SetManagementPolicies sets the management policies.
+func (r *AccountData) SetManagementPolicies(p xpv1.ManagementPolicies) {
+	r.Spec.ManagementPolicies = p
+}
+
+// GetWriteConnectionSecretToReference returns the write connection secret to reference.
+func (r *AccountData) GetWriteConnectionSecretToReference() *xpv1.LocalSecretReference {
+	return r.Spec.WriteConnectionSecretToReference
+}
+
+// SetWriteConnectionSecretToReference sets the write connection secret to reference.
+func (r *AccountData) SetWriteConnectionSecretToReference(s *xpv1.LocalSecretReference) {
+	r.Spec.WriteConnectionSecretToReference = s
+}
+
+// +kubebuilder:object:root=true
+
+// AccountDataList contains a list of AccountData
+type AccountDataList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AccountData `json:"items"`
+}
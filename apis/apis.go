@@ -18,11 +18,25 @@ limitations under the License.
 package apis
 
 import (
+	accountdatav1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/accountdata/v1alpha1"
+	devicev1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/device/v1alpha1"
+	mediav1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/media/v1alpha1"
+	namespacedpowerlevelv1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/namespaced/powerlevel/v1alpha1"
+	namespacedroomv1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/namespaced/room/v1alpha1"
+	namespacedroomaliasv1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/namespaced/roomalias/v1alpha1"
+	namespaceduserv1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/namespaced/user/v1alpha1"
+	namespacedv1beta1 "github.com/crossplane-contrib/provider-matrix/apis/namespaced/v1beta1"
 	powerlevelv1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/powerlevel/v1alpha1"
+	registrationtokenv1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/registrationtoken/v1alpha1"
 	roomv1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/room/v1alpha1"
 	roomaliasv1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/roomalias/v1alpha1"
+	roomhistorypurgev1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/roomhistorypurge/v1alpha1"
+	roommembershipv1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/roommembership/v1alpha1"
+	roomtagv1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/roomtag/v1alpha1"
+	servernoticev1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/servernotice/v1alpha1"
 	spacev1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/space/v1alpha1"
 	userv1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/user/v1alpha1"
+	userratelimitv1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/userratelimit/v1alpha1"
 	"github.com/crossplane-contrib/provider-matrix/apis/v1beta1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
@@ -35,6 +49,20 @@ func init() {
 		spacev1alpha1.SchemeBuilder.AddToScheme,
 		powerlevelv1alpha1.SchemeBuilder.AddToScheme,
 		roomaliasv1alpha1.SchemeBuilder.AddToScheme,
+		roommembershipv1alpha1.SchemeBuilder.AddToScheme,
+		userratelimitv1alpha1.SchemeBuilder.AddToScheme,
+		devicev1alpha1.SchemeBuilder.AddToScheme,
+		roomhistorypurgev1alpha1.SchemeBuilder.AddToScheme,
+		mediav1alpha1.SchemeBuilder.AddToScheme,
+		servernoticev1alpha1.SchemeBuilder.AddToScheme,
+		registrationtokenv1alpha1.SchemeBuilder.AddToScheme,
+		accountdatav1alpha1.SchemeBuilder.AddToScheme,
+		roomtagv1alpha1.SchemeBuilder.AddToScheme,
+		namespacedv1beta1.SchemeBuilder.AddToScheme,
+		namespaceduserv1alpha1.SchemeBuilder.AddToScheme,
+		namespacedroomv1alpha1.SchemeBuilder.AddToScheme,
+		namespacedroomaliasv1alpha1.SchemeBuilder.AddToScheme,
+		namespacedpowerlevelv1alpha1.SchemeBuilder.AddToScheme,
 	)
 }
 
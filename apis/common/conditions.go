@@ -0,0 +1,231 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"time"
+)
+
+// Condition types and reasons shared by every managed resource.
+const (
+	// TypeServerUnavailable indicates whether the homeserver has said it
+	// cannot serve requests for now, for example during maintenance.
+	TypeServerUnavailable xpv1.ConditionType = "ServerUnavailable"
+
+	ReasonServerInMaintenance xpv1.ConditionReason = "ServerInMaintenance"
+	ReasonServerAvailable     xpv1.ConditionReason = "ServerAvailable"
+
+	// TypeRoomUpgraded indicates whether the room a resource refers to has
+	// been replaced by a room upgrade.
+	TypeRoomUpgraded xpv1.ConditionType = "RoomUpgraded"
+
+	ReasonRoomTombstoned xpv1.ConditionReason = "RoomTombstoned"
+	ReasonRoomCurrent    xpv1.ConditionReason = "RoomCurrent"
+
+	// TypeProviderNotJoined indicates whether the provider's user is missing
+	// from a room it has to be joined to, for example after being kicked.
+	TypeProviderNotJoined xpv1.ConditionType = "ProviderNotJoined"
+
+	ReasonProviderLeft   xpv1.ConditionReason = "ProviderLeft"
+	ReasonProviderJoined xpv1.ConditionReason = "ProviderJoined"
+
+	// TypeRemoteRoom indicates whether the room a resource refers to was
+	// created on another homeserver, so that only the state shared over
+	// federation can be observed.
+	TypeRemoteRoom xpv1.ConditionType = "RemoteRoom"
+
+	ReasonFederatedRoom xpv1.ConditionReason = "FederatedRoom"
+	ReasonLocalRoom     xpv1.ConditionReason = "LocalRoom"
+
+	// TypeForbidden indicates whether the homeserver refused the last change
+	// to a resource because the provider's user isn't allowed to make it.
+	TypeForbidden xpv1.ConditionType = "Forbidden"
+
+	ReasonInsufficientPower xpv1.ConditionReason = "InsufficientPower"
+	ReasonPermitted         xpv1.ConditionReason = "Permitted"
+
+	// TypeConnectionFailed indicates whether the provider could not connect
+	// to the homeserver as its user, because the homeserver couldn't be
+	// reached or didn't accept the access token.
+	TypeConnectionFailed xpv1.ConditionType = "ConnectionFailed"
+
+	ReasonAuthenticationFailed xpv1.ConditionReason = "AuthenticationFailed"
+	ReasonUnreachable          xpv1.ConditionReason = "Unreachable"
+	ReasonConnected            xpv1.ConditionReason = "Connected"
+)
+
+// ServerUnavailable returns a condition indicating that the homeserver is
+// unavailable and will not be retried for the given duration.
+func ServerUnavailable(backoff time.Duration) xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeServerUnavailable,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonServerInMaintenance,
+		Message:            fmt.Sprintf("Homeserver is unavailable, retrying in %s", backoff),
+	}
+}
+
+// ServerAvailable returns a condition indicating that the homeserver is
+// serving requests again.
+func ServerAvailable() xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeServerUnavailable,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonServerAvailable,
+	}
+}
+
+// RoomUpgraded returns a condition indicating that a room has been replaced
+// by an upgrade, and whether the resource follows the replacement.
+func RoomUpgraded(roomID, replacementRoomID string, followed bool) xpv1.Condition {
+	message := fmt.Sprintf("Room %s was upgraded to %s; set followRoomUpgrades to manage the new room", roomID, replacementRoomID)
+	if followed {
+		message = fmt.Sprintf("Room %s was upgraded to %s, which is managed instead", roomID, replacementRoomID)
+	}
+	return xpv1.Condition{
+		Type:               TypeRoomUpgraded,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonRoomTombstoned,
+		Message:            message,
+	}
+}
+
+// RoomNotUpgraded returns a condition indicating that a room has not been
+// replaced by an upgrade.
+func RoomNotUpgraded() xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeRoomUpgraded,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonRoomCurrent,
+	}
+}
+
+// ProviderNotJoined returns a condition indicating that the provider's user
+// is not joined to a room, and its membership of the room instead.
+func ProviderNotJoined(roomID, membership string) xpv1.Condition {
+	if membership == "" {
+		membership = "none"
+	}
+	return xpv1.Condition{
+		Type:               TypeProviderNotJoined,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonProviderLeft,
+		Message:            fmt.Sprintf("The provider's user is not joined to room %s (membership: %s)", roomID, membership),
+	}
+}
+
+// ProviderJoined returns a condition indicating that the provider's user is
+// joined to the room.
+func ProviderJoined() xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeProviderNotJoined,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonProviderJoined,
+	}
+}
+
+// RemoteRoom returns a condition indicating that a room was created on
+// another homeserver, whose admin API the provider can't use for it.
+func RemoteRoom(roomID, serverName string) xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeRemoteRoom,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonFederatedRoom,
+		Message:            fmt.Sprintf("Room %s was created on homeserver %s, so only state shared over federation is observed", roomID, serverName),
+	}
+}
+
+// LocalRoom returns a condition indicating that a room was created on the
+// provider's own homeserver.
+func LocalRoom() xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeRemoteRoom,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonLocalRoom,
+	}
+}
+
+// InsufficientPower returns a condition indicating that the homeserver
+// refused to create or update a resource because the provider's user lacks
+// the power level or privileges to.
+func InsufficientPower(err error) xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeForbidden,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonInsufficientPower,
+		Message:            fmt.Sprintf("The provider's user is not allowed to make this change: %s", err),
+	}
+}
+
+// Permitted returns a condition indicating that the homeserver accepted the
+// last change the provider made to a resource.
+func Permitted() xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeForbidden,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonPermitted,
+	}
+}
+
+// AuthenticationFailed returns a condition indicating that the homeserver
+// didn't accept the provider's access token.
+func AuthenticationFailed(err error) xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeConnectionFailed,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonAuthenticationFailed,
+		Message:            fmt.Sprintf("The homeserver did not accept the access token; check that it is valid and has not expired: %s", err),
+	}
+}
+
+// Unreachable returns a condition indicating that the homeserver could not be
+// reached.
+func Unreachable(err error) xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeConnectionFailed,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonUnreachable,
+		Message:            fmt.Sprintf("The homeserver could not be reached: %s", err),
+	}
+}
+
+// Connected returns a condition indicating that the provider connected to the
+// homeserver as its user.
+func Connected() xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeConnectionFailed,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonConnected,
+	}
+}
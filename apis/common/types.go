@@ -26,3 +26,14 @@ import (
 type JSON struct {
 	runtime.RawExtension `json:",inline"`
 }
+
+// How the provider came to manage an external resource, as recorded in the
+// managedBy field of a resource's status.
+const (
+	// ManagedByCreated means the provider created the external resource
+	ManagedByCreated = "Created"
+
+	// ManagedByAdopted means the external resource existed before the
+	// provider started managing it
+	ManagedByAdopted = "Adopted"
+)
@@ -0,0 +1,146 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DeviceParameters define the desired state of a Matrix user's device
+type DeviceParameters struct {
+	// UserID is the Matrix user ID of the user the device belongs to. The
+	// user must be local to the homeserver.
+	// +kubebuilder:validation:Pattern="^@[a-zA-Z0-9._=/-]+:[a-zA-Z0-9.-]+$"
+	// +kubebuilder:validation:Required
+	UserID string `json:"userID"`
+
+	// DeviceID is the ID of the device. The device must already exist, as
+	// devices are only created by the user logging in.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Required
+	DeviceID string `json:"deviceID"`
+
+	// DisplayName is the name the device is shown with in the user's
+	// device list. Omitted, the device keeps its current name.
+	DisplayName *string `json:"displayName,omitempty"`
+
+	// ObserveOnlyFields lists fields of forProvider that are only applied
+	// until the device first has them, since a device is never created.
+	// Afterwards they are observed, but never updated or reported as drift,
+	// so that the user can rename the device.
+	// +listType=set
+	// +kubebuilder:validation:items:Enum=displayName
+	ObserveOnlyFields []string `json:"observeOnlyFields,omitempty"`
+}
+
+// DeviceObservation reflects the observed state of a Matrix user's device
+type DeviceObservation struct {
+	// DisplayName is the name the device is shown with
+	DisplayName string `json:"displayName,omitempty"`
+
+	// LastSeenIP is the last IP address the device was seen from
+	LastSeenIP string `json:"lastSeenIP,omitempty"`
+
+	// LastSeenTime is when the device was last seen
+	LastSeenTime *metav1.Time `json:"lastSeenTime,omitempty"`
+
+	// SettledFields are the observe-only fields of forProvider that the
+	// device has reached, and that are only observed from then on
+	SettledFields []string `json:"settledFields,omitempty"`
+}
+
+// A DeviceSpec defines the desired state of a Device.
+type DeviceSpec struct {
+	xpv1.ManagedResourceSpec `json:",inline"`
+	ForProvider              DeviceParameters `json:"forProvider"`
+}
+
+// A DeviceStatus represents the observed state of a Device.
+type DeviceStatus struct {
+	xpv1.ManagedResourceStatus `json:",inline"`
+	AtProvider                 DeviceObservation `json:"atProvider,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// A Device is a managed resource that represents a device of a Matrix
+// user. It requires the Synapse admin API.
+// +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="USER-ID",type="string",JSONPath=".spec.forProvider.userID"
+// +kubebuilder:printcolumn:name="DEVICE-ID",type="string",JSONPath=".spec.forProvider.deviceID"
+// +kubebuilder:printcolumn:name="DISPLAY-NAME",type="string",JSONPath=".status.atProvider.displayName"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories={crossplane,managed,matrix}
+type Device struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DeviceSpec   `json:"spec"`
+	Status DeviceStatus `json:"status,omitempty"`
+}
+
+// GetProviderConfigReference returns the provider config reference.
+func (r *Device) GetProviderConfigReference() *xpv1.ProviderConfigReference {
+	return r.Spec.ProviderConfigReference
+}
+
+// SetProviderConfigReference sets the provider config reference.
+func (r *Device) SetProviderConfigReference(ref *xpv1.ProviderConfigReference) {
+	r.Spec.ProviderConfigReference = ref
+}
+
+// GetCondition returns the condition with the given type.
+func (r *Device) GetCondition(ct xpv1.ConditionType) xpv1.Condition {
+	return r.Status.GetCondition(ct)
+}
+
+// SetConditions sets the conditions.
+func (r *Device) SetConditions(c ...xpv1.Condition) {
+	r.Status.SetConditions(c...)
+}
+
+// GetManagementPolicies returns the management policies.
+func (r *Device) GetManagementPolicies() xpv1.ManagementPolicies {
+	return r.Spec.ManagementPolicies
+}
+
+// SetManagementPolicies sets the management policies.
+func (r *Device) SetManagementPolicies(p xpv1.ManagementPolicies) {
+	r.Spec.ManagementPolicies = p
+}
+
+// GetWriteConnectionSecretToReference returns the write connection secret to reference.
+func (r *Device) GetWriteConnectionSecretToReference() *xpv1.LocalSecretReference {
+	return r.Spec.WriteConnectionSecretToReference
+}
+
+// SetWriteConnectionSecretToReference sets the write connection secret to reference.
+func (r *Device) SetWriteConnectionSecretToReference(s *xpv1.LocalSecretReference) {
+	r.Spec.WriteConnectionSecretToReference = s
+}
+
+// +kubebuilder:object:root=true
+
+// DeviceList contains a list of Device
+type DeviceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Device `json:"items"`
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MediaParameters define the desired state of a piece of Matrix media
+type MediaParameters struct {
+	// MXCURI is the mxc://<server-name>/<media-id> URI of the media. Media
+	// from other homeservers can only be managed once the homeserver has
+	// cached it.
+	// +kubebuilder:validation:Pattern="^mxc://[^/]+/[a-zA-Z0-9_-]+$"
+	// +kubebuilder:validation:Required
+	MXCURI string `json:"mxcURI"`
+
+	// Quarantined quarantines the media, so that the homeserver no longer
+	// serves it, or lifts its quarantine. Omitted, the media's quarantine
+	// is left as it is.
+	Quarantined *bool `json:"quarantined,omitempty"`
+
+	// DeleteMediaOnDelete deletes the media from the homeserver when the
+	// Media is deleted. Only media uploaded to the homeserver itself can be
+	// deleted. By default the media is kept, as is its quarantine.
+	// +kubebuilder:default=false
+	DeleteMediaOnDelete *bool `json:"deleteMediaOnDelete,omitempty"`
+
+	// ObserveOnlyFields lists fields of forProvider that are only applied
+	// when the media is first managed. Afterwards they are observed, but
+	// never updated or reported as drift, so that server admins can lift or
+	// impose a quarantine.
+	// +listType=set
+	// +kubebuilder:validation:items:Enum=quarantined
+	ObserveOnlyFields []string `json:"observeOnlyFields,omitempty"`
+}
+
+// MediaObservation reflects the observed state of a piece of Matrix media
+type MediaObservation struct {
+	// Quarantined is whether the media is quarantined
+	Quarantined bool `json:"quarantined,omitempty"`
+
+	// QuarantinedBy is the user ID of the admin who quarantined the media
+	QuarantinedBy string `json:"quarantinedBy,omitempty"`
+
+	// SafeFromQuarantine is whether the media is protected from being
+	// quarantined
+	SafeFromQuarantine bool `json:"safeFromQuarantine,omitempty"`
+
+	// MediaType is the content type of the media
+	MediaType string `json:"mediaType,omitempty"`
+
+	// MediaLength is the size of the media in bytes
+	MediaLength int64 `json:"mediaLength,omitempty"`
+
+	// UploadName is the file name the media was uploaded with
+	UploadName string `json:"uploadName,omitempty"`
+
+	// UserID is the user who uploaded the media, for local media
+	UserID string `json:"userID,omitempty"`
+
+	// CreationTime is when the media was uploaded or cached
+	CreationTime *metav1.Time `json:"creationTime,omitempty"`
+}
+
+// A MediaSpec defines the desired state of a Media.
+type MediaSpec struct {
+	xpv1.ManagedResourceSpec `json:",inline"`
+	ForProvider              MediaParameters `json:"forProvider"`
+}
+
+// A MediaStatus represents the observed state of a Media.
+type MediaStatus struct {
+	xpv1.ManagedResourceStatus `json:",inline"`
+	AtProvider                 MediaObservation `json:"atProvider,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// A Media is a managed resource that represents a piece of media uploaded
+// to a Matrix homeserver, or cached from another one, for quarantining and
+// deleting it. It requires the Synapse admin API.
+// +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="MXC-URI",type="string",JSONPath=".spec.forProvider.mxcURI"
+// +kubebuilder:printcolumn:name="QUARANTINED",type="boolean",JSONPath=".status.atProvider.quarantined"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories={crossplane,managed,matrix}
+type Media struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MediaSpec   `json:"spec"`
+	Status MediaStatus `json:"status,omitempty"`
+}
+
+// GetProviderConfigReference returns the provider config reference.
+func (r *Media) GetProviderConfigReference() *xpv1.ProviderConfigReference {
+	return r.Spec.ProviderConfigReference
+}
+
+// SetProviderConfigReference sets the provider config reference.
+func (r *Media) SetProviderConfigReference(ref *xpv1.ProviderConfigReference) {
+	r.Spec.ProviderConfigReference = ref
+}
+
+// GetCondition returns the condition with the given type.
+func (r *Media) GetCondition(ct xpv1.ConditionType) xpv1.Condition {
+	return r.Status.GetCondition(ct)
+}
+
+// SetConditions sets the conditions.
+func (r *Media) SetConditions(c ...xpv1.Condition) {
+	r.Status.SetConditions(c...)
+}
+
+// GetManagementPolicies returns the management policies.
+func (r *Media) GetManagementPolicies() xpv1.ManagementPolicies {
+	return r.Spec.ManagementPolicies
+}
+
+// SetManagementPolicies sets the management policies.
+func (r *Media) SetManagementPolicies(p xpv1.ManagementPolicies) {
+	r.Spec.ManagementPolicies = p
+}
+
+// GetWriteConnectionSecretToReference returns the write connection secret to reference.
+func (r *Media) GetWriteConnectionSecretToReference() *xpv1.LocalSecretReference {
+	return r.Spec.WriteConnectionSecretToReference
+}
+
+// SetWriteConnectionSecretToReference sets the write connection secret to reference.
+func (r *Media) SetWriteConnectionSecretToReference(s *xpv1.LocalSecretReference) {
+	r.Spec.WriteConnectionSecretToReference = s
+}
+
+// +kubebuilder:object:root=true
+
+// MediaList contains a list of Media
+type MediaList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Media `json:"items"`
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/crossplane-contrib/provider-matrix/apis/powerlevel/v1alpha1"
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +kubebuilder:object:root=true
+
+// A PowerLevel is a namespaced managed resource that represents Matrix room power levels.
+// It is configured like the cluster scoped PowerLevel, but uses
+// a ProviderConfig of its own namespace and references Rooms in it.
+// +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="ROOM-ID",type="string",JSONPath=".spec.forProvider.roomID"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced,categories={crossplane,managed,matrix}
+type PowerLevel struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   v1alpha1.PowerLevelSpec   `json:"spec"`
+	Status v1alpha1.PowerLevelStatus `json:"status,omitempty"`
+}
+
+// GetProviderConfigReference returns the provider config reference.
+func (p *PowerLevel) GetProviderConfigReference() *xpv1.ProviderConfigReference {
+	return p.Spec.ProviderConfigReference
+}
+
+// SetProviderConfigReference sets the provider config reference.
+func (p *PowerLevel) SetProviderConfigReference(ref *xpv1.ProviderConfigReference) {
+	p.Spec.ProviderConfigReference = ref
+}
+
+// GetCondition returns the condition with the given type.
+func (p *PowerLevel) GetCondition(ct xpv1.ConditionType) xpv1.Condition {
+	return p.Status.GetCondition(ct)
+}
+
+// SetConditions sets the conditions.
+func (p *PowerLevel) SetConditions(c ...xpv1.Condition) {
+	p.Status.SetConditions(c...)
+}
+
+// GetManagementPolicies returns the management policies.
+func (p *PowerLevel) GetManagementPolicies() xpv1.ManagementPolicies {
+	return p.Spec.ManagementPolicies
+}
+
+// SetManagementPolicies sets the management policies.
+func (p *PowerLevel) SetManagementPolicies(mp xpv1.ManagementPolicies) {
+	p.Spec.ManagementPolicies = mp
+}
+
+// GetWriteConnectionSecretToReference returns the write connection secret to reference.
+func (p *PowerLevel) GetWriteConnectionSecretToReference() *xpv1.LocalSecretReference {
+	return p.Spec.WriteConnectionSecretToReference
+}
+
+// SetWriteConnectionSecretToReference sets the write connection secret to reference.
+func (p *PowerLevel) SetWriteConnectionSecretToReference(s *xpv1.LocalSecretReference) {
+	p.Spec.WriteConnectionSecretToReference = s
+}
+
+// +kubebuilder:object:root=true
+
+// PowerLevelList contains a list of PowerLevel
+type PowerLevelList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PowerLevel `json:"items"`
+}
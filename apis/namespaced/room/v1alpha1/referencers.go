@@ -0,0 +1,54 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/room/v1alpha1"
+	spacev1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/space/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reference"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ResolveReferences resolves the Spaces referenced by the Room's join rule
+// allow conditions to their room IDs, like the cluster scoped Room does.
+// Spaces are cluster scoped, so they are looked up outside the Room's
+// namespace.
+func (mg *Room) ResolveReferences(ctx context.Context, c client.Reader) error {
+	r := reference.NewAPIResolver(c, mg)
+
+	for i := range mg.Spec.ForProvider.JoinRuleAllow {
+		allow := &mg.Spec.ForProvider.JoinRuleAllow[i]
+		if allow.SpaceRef == nil {
+			continue
+		}
+
+		rsp, err := r.Resolve(ctx, reference.ResolutionRequest{
+			Reference: allow.SpaceRef,
+			To:        reference.To{Managed: &spacev1alpha1.Space{}},
+			Extract:   v1alpha1.SpaceID(),
+		})
+		if err != nil {
+			return errors.Wrapf(err, "spec.forProvider.joinRuleAllow[%d].spaceRef", i)
+		}
+		allow.RoomID = reference.ToPtrValue(rsp.ResolvedValue)
+		allow.SpaceRef = rsp.ResolvedReference
+	}
+
+	return nil
+}
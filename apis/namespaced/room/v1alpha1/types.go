@@ -0,0 +1,105 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/crossplane-contrib/provider-matrix/apis/room/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +kubebuilder:object:root=true
+
+// A Room is a namespaced managed resource that represents a Matrix Room.
+// It is configured like the cluster scoped Room, but uses a
+// ProviderConfig of its own namespace. The Spaces its join rule references
+// are cluster scoped, as Spaces have no namespaced kind.
+// +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="EXTERNAL-NAME",type="string",JSONPath=".metadata.annotations.crossplane.io/external-name"
+// +kubebuilder:printcolumn:name="ALIAS",type="string",JSONPath=".status.atProvider.alias",priority=1
+// +kubebuilder:printcolumn:name="STATE-EVENTS",type="integer",JSONPath=".status.atProvider.stateEventCount",priority=1
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced,categories={crossplane,managed,matrix}
+type Room struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   v1alpha1.RoomSpec   `json:"spec"`
+	Status v1alpha1.RoomStatus `json:"status,omitempty"`
+}
+
+// GetProviderConfigReference returns the provider config reference.
+func (r *Room) GetProviderConfigReference() *xpv1.ProviderConfigReference {
+	return r.Spec.ProviderConfigReference
+}
+
+// SetProviderConfigReference sets the provider config reference.
+func (r *Room) SetProviderConfigReference(ref *xpv1.ProviderConfigReference) {
+	r.Spec.ProviderConfigReference = ref
+}
+
+// GetCondition returns the condition with the given type.
+func (r *Room) GetCondition(ct xpv1.ConditionType) xpv1.Condition {
+	return r.Status.GetCondition(ct)
+}
+
+// SetConditions sets the conditions.
+func (r *Room) SetConditions(c ...xpv1.Condition) {
+	r.Status.SetConditions(c...)
+}
+
+// GetManagementPolicies returns the management policies.
+func (r *Room) GetManagementPolicies() xpv1.ManagementPolicies {
+	return r.Spec.ManagementPolicies
+}
+
+// SetManagementPolicies sets the management policies.
+func (r *Room) SetManagementPolicies(mp xpv1.ManagementPolicies) {
+	r.Spec.ManagementPolicies = mp
+}
+
+// GetWriteConnectionSecretToReference returns the write connection secret to reference.
+func (r *Room) GetWriteConnectionSecretToReference() *xpv1.LocalSecretReference {
+	return r.Spec.WriteConnectionSecretToReference
+}
+
+// SetWriteConnectionSecretToReference sets the write connection secret to reference.
+func (r *Room) SetWriteConnectionSecretToReference(s *xpv1.LocalSecretReference) {
+	r.Spec.WriteConnectionSecretToReference = s
+}
+
+// +kubebuilder:object:root=true
+
+// RoomList contains a list of Room
+type RoomList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Room `json:"items"`
+}
+
+// GetItems returns the Rooms of the list as managed resources, so that
+// other resources can select a Room by its labels.
+func (l *RoomList) GetItems() []resource.Managed {
+	items := make([]resource.Managed, len(l.Items))
+	for i := range l.Items {
+		items[i] = &l.Items[i]
+	}
+	return items
+}
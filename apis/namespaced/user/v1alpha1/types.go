@@ -0,0 +1,94 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/crossplane-contrib/provider-matrix/apis/user/v1alpha1"
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +kubebuilder:object:root=true
+
+// A User is a namespaced managed resource that represents a Matrix User.
+// It is configured like the cluster scoped User, but uses a
+// ProviderConfig of its own namespace.
+// +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="ADMIN",type="boolean",JSONPath=".status.atProvider.admin"
+// +kubebuilder:printcolumn:name="DEACTIVATED",type="boolean",JSONPath=".status.atProvider.deactivated"
+// +kubebuilder:printcolumn:name="LOCKED",type="boolean",JSONPath=".status.atProvider.locked",priority=1
+// +kubebuilder:printcolumn:name="EXTERNAL-NAME",type="string",JSONPath=".metadata.annotations.crossplane.io/external-name"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced,categories={crossplane,managed,matrix}
+type User struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   v1alpha1.UserSpec   `json:"spec"`
+	Status v1alpha1.UserStatus `json:"status,omitempty"`
+}
+
+// GetProviderConfigReference returns the provider config reference.
+func (u *User) GetProviderConfigReference() *xpv1.ProviderConfigReference {
+	return u.Spec.ProviderConfigReference
+}
+
+// SetProviderConfigReference sets the provider config reference.
+func (u *User) SetProviderConfigReference(ref *xpv1.ProviderConfigReference) {
+	u.Spec.ProviderConfigReference = ref
+}
+
+// GetCondition returns the condition with the given type.
+func (u *User) GetCondition(ct xpv1.ConditionType) xpv1.Condition {
+	return u.Status.GetCondition(ct)
+}
+
+// SetConditions sets the conditions.
+func (u *User) SetConditions(c ...xpv1.Condition) {
+	u.Status.SetConditions(c...)
+}
+
+// GetManagementPolicies returns the management policies.
+func (u *User) GetManagementPolicies() xpv1.ManagementPolicies {
+	return u.Spec.ManagementPolicies
+}
+
+// SetManagementPolicies sets the management policies.
+func (u *User) SetManagementPolicies(mp xpv1.ManagementPolicies) {
+	u.Spec.ManagementPolicies = mp
+}
+
+// GetWriteConnectionSecretToReference returns the write connection secret to reference.
+func (u *User) GetWriteConnectionSecretToReference() *xpv1.LocalSecretReference {
+	return u.Spec.WriteConnectionSecretToReference
+}
+
+// SetWriteConnectionSecretToReference sets the write connection secret to reference.
+func (u *User) SetWriteConnectionSecretToReference(s *xpv1.LocalSecretReference) {
+	u.Spec.WriteConnectionSecretToReference = s
+}
+
+// +kubebuilder:object:root=true
+
+// UserList contains a list of User
+type UserList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []User `json:"items"`
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"github.com/crossplane-contrib/provider-matrix/apis/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +kubebuilder:object:root=true
+
+// A ProviderConfig configures a Matrix provider for the namespaced managed
+// resources of its namespace. It is configured like the cluster scoped
+// ProviderConfig, but the Secrets it references are always read from its
+// own namespace, and its credentials can only be read from a Secret.
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="SERVER",type="string",JSONPath=".status.observedServerType"
+// +kubebuilder:printcolumn:name="VERSION",type="string",JSONPath=".status.serverVersion"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:printcolumn:name="SECRET-NAME",type="string",JSONPath=".spec.credentials.secretRef.name",priority=1
+// +kubebuilder:resource:scope=Namespaced
+type ProviderConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   v1beta1.ProviderConfigSpec   `json:"spec"`
+	Status v1beta1.ProviderConfigStatus `json:"status,omitempty"`
+}
+
+// GetProviderConfigSpec returns the spec of the ProviderConfig.
+func (pc *ProviderConfig) GetProviderConfigSpec() *v1beta1.ProviderConfigSpec {
+	return &pc.Spec
+}
+
+// GetProviderConfigStatus returns the status of the ProviderConfig.
+func (pc *ProviderConfig) GetProviderConfigStatus() *v1beta1.ProviderConfigStatus {
+	return &pc.Status
+}
+
+// +kubebuilder:object:root=true
+
+// ProviderConfigList contains a list of ProviderConfig.
+type ProviderConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ProviderConfig `json:"items"`
+}
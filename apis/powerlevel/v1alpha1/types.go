@@ -17,69 +17,123 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"fmt"
 	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-// PowerLevelParameters define the desired state of room power levels
+// PowerLevelParameters define the desired state of room power levels.
+// Fields that are not set keep whatever value the room already has; fields
+// that are set, including to zero, are enforced.
 type PowerLevelParameters struct {
-	// RoomID is the Matrix room ID to manage power levels for
-	// +kubebuilder:validation:Pattern="^![a-zA-Z0-9]+:[a-zA-Z0-9.-]+$"
-	// +kubebuilder:validation:Required
-	RoomID string `json:"roomID"`
+	// RoomID is the Matrix room ID to manage power levels for. Either it,
+	// RoomIDRef or RoomIDSelector is required.
+	// +kubebuilder:validation:Pattern="^![a-zA-Z0-9_-]+(:[a-zA-Z0-9.-]+)?$"
+	RoomID string `json:"roomID,omitempty"`
 
-	// Users maps user IDs to their power levels in the room
+	// RoomIDRef references the Room to manage power levels for, filling in
+	// RoomID once the Room has been created
+	RoomIDRef *xpv1.Reference `json:"roomIDRef,omitempty"`
+
+	// RoomIDSelector selects the Room to manage power levels for by its
+	// labels, filling in RoomIDRef
+	RoomIDSelector *xpv1.Selector `json:"roomIDSelector,omitempty"`
+
+	// FollowRoomUpgrades manages the power levels of the room that replaced
+	// RoomID when it is upgraded, instead of those of the old room. Either
+	// way an upgrade is reported by the RoomUpgraded condition.
+	// +kubebuilder:default=false
+	FollowRoomUpgrades *bool `json:"followRoomUpgrades,omitempty"`
+
+	// EnsureJoined keeps the provider's user joined to the room, rejoining
+	// it before making changes if it has been kicked or has left. A user who
+	// can't join by itself is added with the admin API when that is
+	// available. When the user isn't joined the ProviderNotJoined condition
+	// is set.
+	// +kubebuilder:default=false
+	EnsureJoined *bool `json:"ensureJoined,omitempty"`
+
+	// UsersOnly manages only the room's user power levels, given by Users
+	// and Roles. Every other field of the room's power levels keeps its
+	// current value, even when it is set in this spec, for example by a
+	// Composition's defaults.
+	// +kubebuilder:default=false
+	UsersOnly *bool `json:"usersOnly,omitempty"`
+
+	// Users maps user IDs to their power levels in the room. When set, it
+	// replaces the room's user power levels. Power levels that would leave
+	// the provider's own user unable to change the room's state are not
+	// applied, which is reported by the SelfLockoutBlocked condition.
 	Users map[string]int `json:"users,omitempty"`
 
+	// Roles maps user IDs to named roles, as an alternative to giving their
+	// power levels in Users. The built-in roles are admin (100), moderator
+	// (50) and user (0). A user listed in both gets the level from Users.
+	// When set, together with Users it replaces the room's user power levels.
+	Roles map[string]string `json:"roles,omitempty"`
+
+	// RoleLevels maps role names to power levels, adding roles or changing
+	// the level of built-in ones
+	RoleLevels map[string]int `json:"roleLevels,omitempty"`
+
 	// Events maps event types to required power levels
 	Events map[string]int `json:"events,omitempty"`
 
 	// EventsDefault is the default power level required to send events
 	// +kubebuilder:validation:Minimum=0
 	// +kubebuilder:validation:Maximum=100
-	// +kubebuilder:default=0
 	EventsDefault *int `json:"eventsDefault,omitempty"`
 
-	// StateDefault is the default power level required to send state events
+	// StateDefault is the default power level required to send state
+	// events. It is not applied if it would rise above the provider's own
+	// user's power level.
 	// +kubebuilder:validation:Minimum=0
 	// +kubebuilder:validation:Maximum=100
-	// +kubebuilder:default=50
 	StateDefault *int `json:"stateDefault,omitempty"`
 
 	// UsersDefault is the default power level for users in the room
 	// +kubebuilder:validation:Minimum=0
 	// +kubebuilder:validation:Maximum=100
-	// +kubebuilder:default=0
 	UsersDefault *int `json:"usersDefault,omitempty"`
 
 	// Ban is the power level required to ban users
 	// +kubebuilder:validation:Minimum=0
 	// +kubebuilder:validation:Maximum=100
-	// +kubebuilder:default=50
 	Ban *int `json:"ban,omitempty"`
 
 	// Kick is the power level required to kick users
 	// +kubebuilder:validation:Minimum=0
 	// +kubebuilder:validation:Maximum=100
-	// +kubebuilder:default=50
 	Kick *int `json:"kick,omitempty"`
 
 	// Redact is the power level required to redact events
 	// +kubebuilder:validation:Minimum=0
 	// +kubebuilder:validation:Maximum=100
-	// +kubebuilder:default=50
 	Redact *int `json:"redact,omitempty"`
 
-	// Invite is the power level required to invite users
+	// Invite is the power level required to invite users. In a room that can
+	// only be joined by invitation, an invite level above every user's power
+	// level is reported by the MembershipFrozen condition.
 	// +kubebuilder:validation:Minimum=0
 	// +kubebuilder:validation:Maximum=100
-	// +kubebuilder:default=0
 	Invite *int `json:"invite,omitempty"`
+
+	// ObserveOnlyFields lists fields of forProvider that are only applied
+	// when the PowerLevel is created. Afterwards they are observed, but never
+	// updated or reported as drift, so that room admins can change them.
+	// Users and roles together make up the user power levels, so they can
+	// only be listed together.
+	// +listType=set
+	// +kubebuilder:validation:items:Enum=users;roles;events;eventsDefault;stateDefault;usersDefault;ban;kick;redact;invite
+	// +kubebuilder:validation:XValidation:rule="('users' in self) == ('roles' in self)",message="users and roles must be listed together"
+	ObserveOnlyFields []string `json:"observeOnlyFields,omitempty"`
 }
 
 // PowerLevelObservation reflects the observed state of room power levels
 type PowerLevelObservation struct {
-	// RoomID is the Matrix room ID
+	// RoomID is the Matrix room ID whose power levels are managed, which is
+	// the room that replaced the spec's room if room upgrades are followed
 	RoomID string `json:"roomID,omitempty"`
 
 	// Users contains the current user power levels
@@ -88,7 +142,8 @@ type PowerLevelObservation struct {
 	// Events contains the current event type power levels
 	Events map[string]int `json:"events,omitempty"`
 
-	// EventsDefault is the current default power level for events
+	// EventsDefault is the current default power level for events. This and
+	// the levels below are Matrix's defaults when the room leaves them out.
 	EventsDefault int `json:"eventsDefault,omitempty"`
 
 	// StateDefault is the current default power level for state events
@@ -109,8 +164,90 @@ type PowerLevelObservation struct {
 	// Invite is the current power level required to invite users
 	Invite int `json:"invite,omitempty"`
 
-	// LastModified is when the power levels were last modified
-	LastModified *metav1.Time `json:"lastModified,omitempty"`
+	// PrivilegedCreators are the users with unlimited power in the room
+	// because they created it. From room version 12 they cannot be given a
+	// power level, so entries for them in Users are ignored.
+	PrivilegedCreators []string `json:"privilegedCreators,omitempty"`
+
+	// JoinRules is the room's current join rule
+	JoinRules string `json:"joinRules,omitempty"`
+
+	// ManagedBy is always Adopted, since every room has power levels before
+	// the provider starts managing them
+	ManagedBy string `json:"managedBy,omitempty"`
+
+	// AdoptedAt is when the provider first observed the power levels
+	AdoptedAt *metav1.Time `json:"adoptedAt,omitempty"`
+
+	// Reset is set once the power levels have been reset because the
+	// PowerLevel was deleted
+	Reset bool `json:"reset,omitempty"`
+}
+
+// Condition types and reasons for PowerLevel resources.
+const (
+	// TypeMembershipFrozen indicates whether nobody in an invite-only room
+	// has enough power to invite new members.
+	TypeMembershipFrozen xpv1.ConditionType = "MembershipFrozen"
+
+	ReasonInviteLevelUnreachable xpv1.ConditionReason = "InviteLevelUnreachable"
+	ReasonInviteLevelReachable   xpv1.ConditionReason = "InviteLevelReachable"
+
+	// TypeSelfLockoutBlocked indicates whether the provider refused to apply
+	// power levels that would stop it from changing the room's state.
+	TypeSelfLockoutBlocked xpv1.ConditionType = "SelfLockoutBlocked"
+
+	ReasonSelfLockout        xpv1.ConditionReason = "ProviderUser"
+	ReasonLockoutNotRequired xpv1.ConditionReason = "NotRequired"
+)
+
+// MembershipFrozen returns a condition indicating that the invite level of an
+// invite-only room is above every user's power level.
+func MembershipFrozen(joinRules string, invite, highest int) xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeMembershipFrozen,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonInviteLevelUnreachable,
+		Message: fmt.Sprintf("Room has join rule %s, but its invite level %d is above the highest user power level %d, so nobody can invite new members",
+			joinRules, invite, highest),
+	}
+}
+
+// MembershipOpen returns a condition indicating that someone in the room has
+// enough power to invite new members.
+func MembershipOpen() xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeMembershipFrozen,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonInviteLevelReachable,
+	}
+}
+
+// SelfLockoutBlocked returns a condition indicating that the provider refused
+// to apply power levels that would leave its own user below the level it
+// needs to keep changing the room.
+func SelfLockoutBlocked(userID, field string, level, required int) xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeSelfLockoutBlocked,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonSelfLockout,
+		Message: fmt.Sprintf("Refusing to apply power levels that would leave the provider's user %s at level %d, below the %s level %d it needs to keep changing the room",
+			userID, level, field, required),
+	}
+}
+
+// SelfLockoutNotBlocked returns a condition indicating that no power levels
+// are being withheld to keep the provider in control of the room.
+func SelfLockoutNotBlocked() xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeSelfLockoutBlocked,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonLockoutNotRequired,
+	}
 }
 
 // A PowerLevelSpec defines the desired state of a PowerLevel.
@@ -21,6 +21,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -100,8 +101,13 @@ func (in *PowerLevelObservation) DeepCopyInto(out *PowerLevelObservation) {
 			(*out)[key] = val
 		}
 	}
-	if in.LastModified != nil {
-		in, out := &in.LastModified, &out.LastModified
+	if in.PrivilegedCreators != nil {
+		in, out := &in.PrivilegedCreators, &out.PrivilegedCreators
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AdoptedAt != nil {
+		in, out := &in.AdoptedAt, &out.AdoptedAt
 		*out = (*in).DeepCopy()
 	}
 }
@@ -119,6 +125,31 @@ func (in *PowerLevelObservation) DeepCopy() *PowerLevelObservation {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PowerLevelParameters) DeepCopyInto(out *PowerLevelParameters) {
 	*out = *in
+	if in.RoomIDRef != nil {
+		in, out := &in.RoomIDRef, &out.RoomIDRef
+		*out = new(xpv1.Reference)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RoomIDSelector != nil {
+		in, out := &in.RoomIDSelector, &out.RoomIDSelector
+		*out = new(xpv1.Selector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FollowRoomUpgrades != nil {
+		in, out := &in.FollowRoomUpgrades, &out.FollowRoomUpgrades
+		*out = new(bool)
+		**out = **in
+	}
+	if in.EnsureJoined != nil {
+		in, out := &in.EnsureJoined, &out.EnsureJoined
+		*out = new(bool)
+		**out = **in
+	}
+	if in.UsersOnly != nil {
+		in, out := &in.UsersOnly, &out.UsersOnly
+		*out = new(bool)
+		**out = **in
+	}
 	if in.Users != nil {
 		in, out := &in.Users, &out.Users
 		*out = make(map[string]int, len(*in))
@@ -126,6 +157,20 @@ func (in *PowerLevelParameters) DeepCopyInto(out *PowerLevelParameters) {
 			(*out)[key] = val
 		}
 	}
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.RoleLevels != nil {
+		in, out := &in.RoleLevels, &out.RoleLevels
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.Events != nil {
 		in, out := &in.Events, &out.Events
 		*out = make(map[string]int, len(*in))
@@ -168,6 +213,11 @@ func (in *PowerLevelParameters) DeepCopyInto(out *PowerLevelParameters) {
 		*out = new(int)
 		**out = **in
 	}
+	if in.ObserveOnlyFields != nil {
+		in, out := &in.ObserveOnlyFields, &out.ObserveOnlyFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PowerLevelParameters.
@@ -0,0 +1,144 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RegistrationTokenParameters define the desired state of a Matrix
+// registration token
+type RegistrationTokenParameters struct {
+	// Token is the token people register with. Omitted, the homeserver
+	// generates one. It can't be changed once the token has been created.
+	// +kubebuilder:validation:Pattern="^[A-Za-z0-9._~-]{1,64}$"
+	Token *string `json:"token,omitempty"`
+
+	// UsesAllowed is how many accounts the token can register. New tokens
+	// can register any number of accounts when omitted, and the limit of
+	// an existing token is then left as it is.
+	// +kubebuilder:validation:Minimum=0
+	UsesAllowed *int `json:"usesAllowed,omitempty"`
+
+	// ExpiryTime is when the token stops being valid. New tokens don't
+	// expire when omitted, and the expiry time of an existing token is then
+	// left as it is.
+	ExpiryTime *metav1.Time `json:"expiryTime,omitempty"`
+
+	// ObserveOnlyFields lists fields of forProvider that are only used to
+	// create the token. Afterwards they are observed, but never updated or
+	// reported as drift, so that server admins can extend or limit it.
+	// +listType=set
+	// +kubebuilder:validation:items:Enum=usesAllowed;expiryTime
+	ObserveOnlyFields []string `json:"observeOnlyFields,omitempty"`
+}
+
+// RegistrationTokenObservation reflects the observed state of a Matrix
+// registration token
+type RegistrationTokenObservation struct {
+	// Pending is how many registrations using the token are under way
+	Pending int `json:"pending,omitempty"`
+
+	// Completed is how many accounts the token has registered
+	Completed int `json:"completed,omitempty"`
+
+	// Valid is whether the token can still be used to register: it has uses
+	// left and hasn't expired
+	Valid bool `json:"valid,omitempty"`
+}
+
+// A RegistrationTokenSpec defines the desired state of a RegistrationToken.
+type RegistrationTokenSpec struct {
+	xpv1.ManagedResourceSpec `json:",inline"`
+	ForProvider              RegistrationTokenParameters `json:"forProvider"`
+}
+
+// A RegistrationTokenStatus represents the observed state of a RegistrationToken.
+type RegistrationTokenStatus struct {
+	xpv1.ManagedResourceStatus `json:",inline"`
+	AtProvider                 RegistrationTokenObservation `json:"atProvider,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// A RegistrationToken is a managed resource that represents a token that
+// lets people register accounts on a Matrix homeserver that requires one.
+// It requires the Synapse admin API.
+// +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="VALID",type="boolean",JSONPath=".status.atProvider.valid"
+// +kubebuilder:printcolumn:name="COMPLETED",type="integer",JSONPath=".status.atProvider.completed"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories={crossplane,managed,matrix}
+type RegistrationToken struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RegistrationTokenSpec   `json:"spec"`
+	Status RegistrationTokenStatus `json:"status,omitempty"`
+}
+
+// GetProviderConfigReference returns the provider config reference.
+func (r *RegistrationToken) GetProviderConfigReference() *xpv1.ProviderConfigReference {
+	return r.Spec.ProviderConfigReference
+}
+
+// SetProviderConfigReference sets the provider config reference.
+func (r *RegistrationToken) SetProviderConfigReference(ref *xpv1.ProviderConfigReference) {
+	r.Spec.ProviderConfigReference = ref
+}
+
+// GetCondition returns the condition with the given type.
+func (r *RegistrationToken) GetCondition(ct xpv1.ConditionType) xpv1.Condition {
+	return r.Status.GetCondition(ct)
+}
+
+// SetConditions sets the conditions.
+func (r *RegistrationToken) SetConditions(c ...xpv1.Condition) {
+	r.Status.SetConditions(c...)
+}
+
+// GetManagementPolicies returns the management policies.
+func (r *RegistrationToken) GetManagementPolicies() xpv1.ManagementPolicies {
+	return r.Spec.ManagementPolicies
+}
+
+// SetManagementPolicies sets the management policies.
+func (r *RegistrationToken) SetManagementPolicies(p xpv1.ManagementPolicies) {
+	r.Spec.ManagementPolicies = p
+}
+
+// GetWriteConnectionSecretToReference returns the write connection secret to reference.
+func (r *RegistrationToken) GetWriteConnectionSecretToReference() *xpv1.LocalSecretReference {
+	return r.Spec.WriteConnectionSecretToReference
+}
+
+// SetWriteConnectionSecretToReference sets the write connection secret to reference.
+func (r *RegistrationToken) SetWriteConnectionSecretToReference(s *xpv1.LocalSecretReference) {
+	r.Spec.WriteConnectionSecretToReference = s
+}
+
+// +kubebuilder:object:root=true
+
+// RegistrationTokenList contains a list of RegistrationToken
+type RegistrationTokenList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RegistrationToken `json:"items"`
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	spacev1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/space/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reference"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+)
+
+// RoomID extracts the room ID of a Room from its external name. A Room that
+// hasn't been created yet has no room ID, even though its external name
+// defaults to its own name until then.
+func RoomID() reference.ExtractValueFn {
+	return func(mg resource.Managed) string {
+		roomID := meta.GetExternalName(mg)
+		if !strings.HasPrefix(roomID, "!") {
+			return ""
+		}
+		return roomID
+	}
+}
+
+// SpaceID extracts the observed room ID of a Space
+func SpaceID() reference.ExtractValueFn {
+	return func(mg resource.Managed) string {
+		space, ok := mg.(*spacev1alpha1.Space)
+		if !ok {
+			return ""
+		}
+		return space.Status.AtProvider.SpaceID
+	}
+}
+
+// ResolveReferences resolves the Spaces referenced by the Room's join rule
+// allow conditions to their room IDs. References are resolved again on every
+// reconcile, rather than only until they first resolve, so that a condition
+// follows a Space that has been recreated with a new room ID.
+func (mg *Room) ResolveReferences(ctx context.Context, c client.Reader) error {
+	r := reference.NewAPIResolver(c, mg)
+
+	for i := range mg.Spec.ForProvider.JoinRuleAllow {
+		allow := &mg.Spec.ForProvider.JoinRuleAllow[i]
+		if allow.SpaceRef == nil {
+			continue
+		}
+
+		rsp, err := r.Resolve(ctx, reference.ResolutionRequest{
+			Reference: allow.SpaceRef,
+			To:        reference.To{Managed: &spacev1alpha1.Space{}},
+			Extract:   SpaceID(),
+			Namespace: mg.GetNamespace(),
+		})
+		if err != nil {
+			return errors.Wrapf(err, "spec.forProvider.joinRuleAllow[%d].spaceRef", i)
+		}
+		allow.RoomID = reference.ToPtrValue(rsp.ResolvedValue)
+		allow.SpaceRef = rsp.ResolvedReference
+	}
+
+	return nil
+}
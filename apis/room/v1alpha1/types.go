@@ -17,9 +17,14 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"encoding/json"
+	"fmt"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
 	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"strings"
 )
 
 // RoomParameters define the desired state of a Matrix Room
@@ -30,10 +35,42 @@ type RoomParameters struct {
 	// Topic is the topic/description for the room
 	Topic *string `json:"topic,omitempty"`
 
+	// RichTopic also writes the topic as an MSC3765 m.topic content block,
+	// which newer clients prefer, keeping it in sync with the plain topic.
+	// When false, the topic is written as a plain string only.
+	RichTopic *bool `json:"richTopic,omitempty"`
+
+	// TopicHTML is an HTML representation of the topic, offered alongside
+	// the plain text in the m.topic block. Only used when RichTopic is true.
+	TopicHTML *string `json:"topicHTML,omitempty"`
+
+	// TruncateOverLength shortens a Name longer than the 255 bytes Matrix
+	// allows, or a Topic longer than 16384 bytes, to fit, and reports the
+	// fields it shortened in TruncatedFields. When unset or false, such a
+	// Name or Topic is refused instead.
+	TruncateOverLength *bool `json:"truncateOverLength,omitempty"`
+
 	// Alias is the room alias (e.g., #example:matrix.org)
 	// +kubebuilder:validation:Pattern="^#[a-zA-Z0-9._=/-]+:[a-zA-Z0-9.-]+$"
 	Alias *string `json:"alias,omitempty"`
 
+	// AdoptExisting adopts the room Alias already points at, if there is one,
+	// instead of creating a new room, so that existing rooms can be imported.
+	// It only applies until the Room has a room ID as its external name.
+	// +kubebuilder:default=false
+	AdoptExisting *bool `json:"adoptExisting,omitempty"`
+
+	// SetAliasAsCanonical makes Alias the room's canonical alias, advertised
+	// in its m.room.canonical_alias event, so that clients show it. When
+	// false, Alias only points at the room and is kept out of the canonical
+	// alias.
+	// +kubebuilder:default=true
+	SetAliasAsCanonical *bool `json:"setAliasAsCanonical,omitempty"`
+
+	// AltAliases are the alternative aliases advertised in the room's
+	// m.room.canonical_alias event. Each alias must already point at the room.
+	AltAliases []string `json:"altAliases,omitempty"`
+
 	// Preset determines the room's configuration template
 	// +kubebuilder:validation:Enum=private_chat;public_chat;trusted_private_chat
 	// +kubebuilder:default="private_chat"
@@ -44,11 +81,19 @@ type RoomParameters struct {
 	// +kubebuilder:default="private"
 	Visibility *string `json:"visibility,omitempty"`
 
+	// DirectoryNetworks are the third-party networks whose room directory
+	// lists the room, for rooms bridged by an application service. The
+	// provider must authenticate as that application service. Networks
+	// removed from the list have the room unlisted again.
+	DirectoryNetworks []string `json:"directoryNetworks,omitempty"`
+
 	// RoomVersion specifies the Matrix room version to use
 	// +kubebuilder:validation:Pattern="^[0-9]+$|^[0-9]+.[0-9]+$"
 	RoomVersion *string `json:"roomVersion,omitempty"`
 
-	// CreationContent is additional content for the m.room.create event
+	// CreationContent is additional content for the m.room.create event.
+	// It is sent as given; keys set here take precedence over the
+	// homeserver's defaults.
 	// +kubebuilder:pruning:PreserveUnknownFields
 	// +kubebuilder:validation:Type=object
 	CreationContent *runtime.RawExtension `json:"creationContent,omitempty"`
@@ -56,18 +101,40 @@ type RoomParameters struct {
 	// InitialState is a list of state events to set in the new room
 	InitialState []StateEvent `json:"initialState,omitempty"`
 
-	// Invite is a list of user IDs to invite to the room
+	// Invite is a list of user IDs to invite to the room. Users that have
+	// never been in the room are invited, up to InviteBatchSize of them per
+	// reconcile, so that a long list is worked through despite the
+	// homeserver's rate limits. Users that left or were banned are not
+	// invited again.
 	Invite []string `json:"invite,omitempty"`
 
-	// PowerLevelOverrides allows customizing power levels for the room
+	// InviteBatchSize is the most users of Invite invited per reconcile.
+	// Invites the homeserver rate limits are retried as the ProviderConfig's
+	// maxRetries and retryMaxWait allow, then left for a later reconcile.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=20
+	InviteBatchSize *int `json:"inviteBatchSize,omitempty"`
+
+	// Invite3PID is a list of third-party identifiers (e.g. email addresses)
+	// to invite to the room when it is created
+	Invite3PID []ThirdPartyInvite `json:"invite3PID,omitempty"`
+
+	// PowerLevelOverrides allows customizing power levels for the room. The
+	// users, events and levels it sets are kept as given; a change made
+	// elsewhere to one of them is reverted, while the rest of the room's
+	// power levels are left alone.
 	PowerLevelOverrides *PowerLevelContent `json:"powerLevelOverrides,omitempty"`
 
-	// GuestAccess controls whether guests can join the room
+	// GuestAccess controls whether guests can join the room. Setting it to
+	// forbidden removes guest access from a room that allowed it.
 	// +kubebuilder:validation:Enum=can_join;forbidden
 	// +kubebuilder:default="forbidden"
 	GuestAccess *string `json:"guestAccess,omitempty"`
 
-	// HistoryVisibility controls message history visibility
+	// HistoryVisibility controls message history visibility. Every preset
+	// creates rooms with shared history. World readable history can be read
+	// by anyone, even if the join rule doesn't let them join, which is
+	// reported by the WorldReadableHistory condition.
 	// +kubebuilder:validation:Enum=invited;joined;shared;world_readable
 	// +kubebuilder:default="shared"
 	HistoryVisibility *string `json:"historyVisibility,omitempty"`
@@ -77,13 +144,86 @@ type RoomParameters struct {
 	// +kubebuilder:default="invite"
 	JoinRules *string `json:"joinRules,omitempty"`
 
-	// EncryptionEnabled indicates if the room should be encrypted
-	// +kubebuilder:default=false
+	// JoinRuleAllow are the rooms and spaces whose members may join the room
+	// under a restricted join rule. Allow conditions of other types are left
+	// alone. When omitted, the allow conditions are not managed.
+	JoinRuleAllow []JoinRuleAllowCondition `json:"joinRuleAllow,omitempty"`
+
+	// EncryptionEnabled indicates if the room should be encrypted. Matrix
+	// rooms can't be unencrypted again, so setting it to false on an
+	// encrypted room is reported by the EncryptionConflict condition, while
+	// the rest of the spec is still applied. It has no default, so that a
+	// room its members chose to encrypt isn't in conflict; leave it unset to
+	// accept whatever they choose.
 	EncryptionEnabled *bool `json:"encryptionEnabled,omitempty"`
 
 	// AvatarURL is the room's avatar image URL (mxc:// URL)
 	// +kubebuilder:validation:Pattern="^mxc://.*"
 	AvatarURL *string `json:"avatarURL,omitempty"`
+
+	// KnockAutoAccept is a list of user IDs whose pending knocks are accepted
+	// (by inviting them) on each reconcile. Only meaningful when JoinRules is
+	// knock. The provider's user must have sufficient power to invite.
+	KnockAutoAccept []string `json:"knockAutoAccept,omitempty"`
+
+	// ForwardExtremitiesThreshold enables cleanup of the room's forward
+	// extremities once their count exceeds this value. Requires admin API access.
+	// +kubebuilder:validation:Minimum=1
+	ForwardExtremitiesThreshold *int `json:"forwardExtremitiesThreshold,omitempty"`
+
+	// Blocked blocks the homeserver's users from joining the room, or lifts
+	// the block. Requires admin API access; on homeservers other than
+	// Synapse, which can't block rooms, it is ignored. When omitted, the
+	// block is not managed.
+	Blocked *bool `json:"blocked,omitempty"`
+
+	// ObserveOnlyFields lists fields of forProvider that are only used to
+	// create the room. Afterwards they are observed, but never updated or
+	// reported as drift, so that room admins can change them.
+	// +listType=set
+	// +kubebuilder:validation:items:Enum=name;topic;richTopic;topicHTML;alias;altAliases;guestAccess;historyVisibility;joinRules;joinRuleAllow;encryptionEnabled;avatarURL;powerLevelOverrides;pinnedEvents;knockAutoAccept;directoryNetworks;blocked;grantAdminTo;invite;ensureJoined
+	ObserveOnlyFields []string `json:"observeOnlyFields,omitempty"`
+
+	// PurgeOnDelete removes the room's history from the homeserver's database
+	// when the Room is deleted. This cannot be undone. When false, deleting the
+	// Room kicks all local members and delists it but keeps its history, so
+	// an admin can recover it. Requires admin API access.
+	// +kubebuilder:default=false
+	PurgeOnDelete *bool `json:"purgeOnDelete,omitempty"`
+
+	// RemoveDanglingAllowReferences removes rooms that no longer exist from
+	// the allow conditions of the room's restricted join rule. Without it
+	// dangling references are only reported. It has no effect when
+	// JoinRuleAllow is set, since the allow conditions are then those listed.
+	RemoveDanglingAllowReferences *bool `json:"removeDanglingAllowReferences,omitempty"`
+
+	// PinnedEvents are the IDs of the events pinned in the room, in order.
+	// Events that aren't in the room or have been redacted are not pinned
+	// and are reported in status.atProvider.invalidPinnedEvents instead.
+	// Pins are only applied once the room exists. When omitted, the room's
+	// pinned events are not managed.
+	PinnedEvents []string `json:"pinnedEvents,omitempty"`
+
+	// EnsureJoined keeps the provider's user joined to the room, rejoining
+	// it before making changes if it has been kicked or has left. A user who
+	// can't join by itself is added with the admin API when that is
+	// available. When the user isn't joined the ProviderNotJoined condition
+	// is set.
+	// +kubebuilder:default=false
+	EnsureJoined *bool `json:"ensureJoined,omitempty"`
+
+	// GrantAdminTo are local users to give admin power in the room, such as
+	// the provider's user to recover a room whose admins have all left. Users
+	// who can't send power levels and other state events are given the
+	// highest power level of the room's local members, and invited if they
+	// aren't in the room. Requires admin API access to a Synapse homeserver.
+	GrantAdminTo []string `json:"grantAdminTo,omitempty"`
+
+	// ObservedStateTypes are the state event types whose events are recorded,
+	// with their content, in status.atProvider.state. Settings the provider
+	// manages, such as the join rules and guest access, are always observed
+	// in their own status fields.
+	ObservedStateTypes []string `json:"observedStateTypes,omitempty"`
 }
 
 // StateEvent represents a Matrix state event
@@ -102,6 +242,44 @@ type StateEvent struct {
 	Content runtime.RawExtension `json:"content"`
 }
 
+// DecodeContent decodes the content of the state event into v, which is left
+// untouched if the event has no content.
+func (e *StateEvent) DecodeContent(v interface{}) error {
+	if len(e.Content.Raw) == 0 {
+		return nil
+	}
+	return json.Unmarshal(e.Content.Raw, v)
+}
+
+// JoinRuleAllowCondition names a room or space whose members may join a room
+// with a restricted join rule, either by its ID or by referencing a Space
+type JoinRuleAllowCondition struct {
+	// RoomID is the ID of the room or space whose members may join. It is
+	// filled in from SpaceRef when that is set.
+	RoomID *string `json:"roomID,omitempty"`
+
+	// SpaceRef references the Space whose members may join. It is resolved
+	// to the Space's observed room ID on every reconcile, so the condition
+	// follows the Space if it is recreated. The Room waits for a Space that
+	// hasn't been created yet.
+	SpaceRef *xpv1.Reference `json:"spaceRef,omitempty"`
+}
+
+// ThirdPartyInvite identifies a user to invite by a third-party identifier
+type ThirdPartyInvite struct {
+	// Medium is the type of identifier (email, msisdn)
+	// +kubebuilder:validation:Enum=email;msisdn
+	Medium string `json:"medium"`
+
+	// Address is the identifier value, e.g. an email address
+	// +kubebuilder:validation:Required
+	Address string `json:"address"`
+
+	// IDServer is the hostname of the identity server to look the identifier up on
+	// +kubebuilder:validation:Required
+	IDServer string `json:"idServer"`
+}
+
 // PowerLevelContent defines power levels for room events and users
 type PowerLevelContent struct {
 	// Users maps user IDs to their power levels
@@ -143,9 +321,23 @@ type RoomObservation struct {
 	// Topic is the current room topic
 	Topic string `json:"topic,omitempty"`
 
+	// RichTopic reports whether the topic carries an m.topic block whose
+	// plain text matches Topic
+	RichTopic bool `json:"richTopic,omitempty"`
+
+	// TopicHTML is the HTML representation of the topic, if any
+	TopicHTML string `json:"topicHTML,omitempty"`
+
+	// TruncatedFields are the spec fields that were too long, and were
+	// truncated as TruncateOverLength allows
+	TruncatedFields []string `json:"truncatedFields,omitempty"`
+
 	// Alias is the canonical room alias
 	Alias string `json:"alias,omitempty"`
 
+	// AltAliases are the alternative aliases of the room
+	AltAliases []string `json:"altAliases,omitempty"`
+
 	// AvatarURL is the current room avatar URL
 	AvatarURL string `json:"avatarURL,omitempty"`
 
@@ -164,6 +356,10 @@ type RoomObservation struct {
 	// InvitedMembers is the number of invited members
 	InvitedMembers int `json:"invitedMembers,omitempty"`
 
+	// StateEventCount is the number of state events in the room, as a
+	// measure of its size. It is only observed with the admin API.
+	StateEventCount int `json:"stateEventCount,omitempty"`
+
 	// Visibility is the current room visibility
 	Visibility string `json:"visibility,omitempty"`
 
@@ -176,14 +372,264 @@ type RoomObservation struct {
 	// JoinRules is the current join rules setting
 	JoinRules string `json:"joinRules,omitempty"`
 
+	// PubliclyJoinable reports whether the room is published in the room
+	// directory and anyone may join it, combining Visibility and JoinRules
+	PubliclyJoinable bool `json:"publiclyJoinable,omitempty"`
+
 	// EncryptionEnabled indicates if the room is encrypted
 	EncryptionEnabled bool `json:"encryptionEnabled,omitempty"`
 
-	// State contains current room state events
+	// State contains the room's current state events of the types listed in
+	// ObservedStateTypes
 	State []StateEvent `json:"state,omitempty"`
 
 	// PowerLevels contains current power level settings
 	PowerLevels *PowerLevelContent `json:"powerLevels,omitempty"`
+
+	// PrivilegedCreators are the users with unlimited power in the room
+	// because they created it. Only rooms of version 12 or later have them.
+	PrivilegedCreators []string `json:"privilegedCreators,omitempty"`
+
+	// EffectivePowerLevels is the power level each user in PowerLevels
+	// actually has. Privileged creators are left out, as their entries in
+	// the power levels are ignored.
+	EffectivePowerLevels map[string]int `json:"effectivePowerLevels,omitempty"`
+
+	// Admins are the users of GrantAdminTo that hold admin power in the room.
+	// Only observed when GrantAdminTo is set.
+	Admins []string `json:"admins,omitempty"`
+
+	// PendingKnocks is the list of user IDs with a pending knock on the room
+	PendingKnocks []string `json:"pendingKnocks,omitempty"`
+
+	// Predecessor is the room this room was upgraded from, if any
+	Predecessor *RoomPredecessor `json:"predecessor,omitempty"`
+
+	// Blocked is whether the room is blocked from being joined. Only
+	// observed when Blocked is set and the homeserver is Synapse.
+	Blocked *bool `json:"blocked,omitempty"`
+
+	// ForwardExtremities is the number of forward extremities in the room.
+	// Only observed when ForwardExtremitiesThreshold is set.
+	ForwardExtremities *int `json:"forwardExtremities,omitempty"`
+
+	// InviteProgress reports how far the users of Invite have been invited.
+	// Only observed when Invite is set.
+	InviteProgress *InviteProgress `json:"inviteProgress,omitempty"`
+
+	// JoinRuleAllow are the rooms whose members may join the room under a
+	// restricted join rule
+	JoinRuleAllow []string `json:"joinRuleAllow,omitempty"`
+
+	// DanglingAllowReferences are the rooms in JoinRuleAllow that no longer
+	// exist
+	DanglingAllowReferences []string `json:"danglingAllowReferences,omitempty"`
+
+	// PinnedEvents are the IDs of the events currently pinned in the room
+	PinnedEvents []string `json:"pinnedEvents,omitempty"`
+
+	// InvalidPinnedEvents are the events in PinnedEvents of the spec that
+	// can't be pinned, because they can't be fetched from the room or have
+	// been redacted
+	InvalidPinnedEvents []string `json:"invalidPinnedEvents,omitempty"`
+
+	// DirectoryNetworks are the third-party networks the provider has listed
+	// the room in. Homeservers can't be asked for these, so this is the
+	// provider's record rather than an observation.
+	DirectoryNetworks []string `json:"directoryNetworks,omitempty"`
+
+	// ManagedBy is Created if the provider created the room, or Adopted if
+	// the room already existed when the provider started managing it
+	ManagedBy string `json:"managedBy,omitempty"`
+
+	// AdoptedAt is when the provider first observed the room, if it adopted
+	// it
+	AdoptedAt *metav1.Time `json:"adoptedAt,omitempty"`
+}
+
+// FindState returns the observed state event with the given type and state
+// key, or nil if it wasn't observed.
+func (o *RoomObservation) FindState(eventType, stateKey string) *StateEvent {
+	for i := range o.State {
+		if o.State[i].Type == eventType && o.State[i].StateKey == stateKey {
+			return &o.State[i]
+		}
+	}
+	return nil
+}
+
+// InviteProgress reports how many of the users a Room invites have been
+// invited
+type InviteProgress struct {
+	// Invited is the number of users that have been invited, or have
+	// otherwise been in the room
+	Invited int `json:"invited"`
+
+	// Total is the number of users to invite
+	Total int `json:"total"`
+}
+
+// RoomPredecessor identifies the room that a room was upgraded from
+type RoomPredecessor struct {
+	// RoomID is the Matrix room ID of the old room
+	RoomID string `json:"roomID"`
+
+	// EventID is the ID of the last known event in the old room
+	EventID string `json:"eventID,omitempty"`
+}
+
+// Condition types and reasons for Room resources.
+const (
+	// TypeAliasConflict indicates whether the desired alias is already in use
+	// by another room.
+	TypeAliasConflict xpv1.ConditionType = "AliasConflict"
+
+	ReasonAliasInUse     xpv1.ConditionReason = "AliasInUse"
+	ReasonAliasAvailable xpv1.ConditionReason = "AliasAvailable"
+
+	// TypeDanglingAllowReference indicates whether the room's join rule allows
+	// members of rooms that no longer exist to join.
+	TypeDanglingAllowReference xpv1.ConditionType = "DanglingAllowReference"
+
+	ReasonAllowReferenceMissing   xpv1.ConditionReason = "AllowReferenceMissing"
+	ReasonAllowReferencesResolved xpv1.ConditionReason = "AllowReferencesResolved"
+
+	// TypeInvalidPinnedEvent indicates whether any of the events to pin can't
+	// be pinned.
+	TypeInvalidPinnedEvent xpv1.ConditionType = "InvalidPinnedEvent"
+
+	ReasonPinnedEventInvalid xpv1.ConditionReason = "PinnedEventInvalid"
+	ReasonPinnedEventsValid  xpv1.ConditionReason = "PinnedEventsValid"
+
+	// TypeWorldReadableHistory indicates whether the room's history can be
+	// read by anyone although only some users can join it.
+	TypeWorldReadableHistory xpv1.ConditionType = "WorldReadableHistory"
+
+	ReasonHistoryExposed    xpv1.ConditionReason = "HistoryExposed"
+	ReasonHistoryNotExposed xpv1.ConditionReason = "HistoryNotExposed"
+
+	// TypeEncryptionConflict indicates whether the spec asks for an
+	// encrypted room to be unencrypted, which Matrix doesn't allow.
+	TypeEncryptionConflict xpv1.ConditionType = "EncryptionConflict"
+
+	ReasonEncryptionCannotBeDisabled xpv1.ConditionReason = "EncryptionCannotBeDisabled"
+	ReasonEncryptionAsDesired        xpv1.ConditionReason = "EncryptionAsDesired"
+)
+
+// AliasConflict returns a condition indicating that the desired alias
+// already points at another room.
+func AliasConflict(alias, roomID string) xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeAliasConflict,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonAliasInUse,
+		Message:            fmt.Sprintf("Alias %s already points at room %s", alias, roomID),
+	}
+}
+
+// AliasAvailable returns a condition indicating that the desired alias is
+// not in use by another room.
+func AliasAvailable() xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeAliasConflict,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonAliasAvailable,
+	}
+}
+
+// DanglingAllowReference returns a condition indicating that the room's join
+// rule references rooms that no longer exist.
+func DanglingAllowReference(roomIDs []string) xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeDanglingAllowReference,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonAllowReferenceMissing,
+		Message:            fmt.Sprintf("Join rule allows members of rooms that no longer exist: %s", strings.Join(roomIDs, ", ")),
+	}
+}
+
+// AllowReferencesResolved returns a condition indicating that every room the
+// room's join rule references exists.
+func AllowReferencesResolved() xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeDanglingAllowReference,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonAllowReferencesResolved,
+	}
+}
+
+// InvalidPinnedEvent returns a condition indicating that some of the events to
+// pin can't be fetched from the room or have been redacted.
+func InvalidPinnedEvent(eventIDs []string) xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeInvalidPinnedEvent,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonPinnedEventInvalid,
+		Message:            fmt.Sprintf("Events can't be fetched from the room or have been redacted, and are not pinned: %s", strings.Join(eventIDs, ", ")),
+	}
+}
+
+// PinnedEventsValid returns a condition indicating that every event to pin is
+// in the room.
+func PinnedEventsValid() xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeInvalidPinnedEvent,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonPinnedEventsValid,
+	}
+}
+
+// EncryptionConflict returns a condition indicating that the spec asks for an
+// encrypted room to be unencrypted.
+func EncryptionConflict() xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeEncryptionConflict,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonEncryptionCannotBeDisabled,
+		Message:            "encryptionEnabled is false but the room is encrypted, and encryption cannot be disabled once a room is encrypted",
+	}
+}
+
+// EncryptionAsDesired returns a condition indicating that the room's
+// encryption doesn't conflict with the spec.
+func EncryptionAsDesired() xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeEncryptionConflict,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonEncryptionAsDesired,
+	}
+}
+
+// WorldReadableHistory returns a condition indicating that the room's history
+// is world readable although its join rule doesn't let everyone join.
+func WorldReadableHistory(joinRules string) xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeWorldReadableHistory,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonHistoryExposed,
+		Message: fmt.Sprintf("History visibility is world_readable but join rule is %s, so anyone can read the room's history without being able to join it",
+			joinRules),
+	}
+}
+
+// HistoryNotExposed returns a condition indicating that the room's history is
+// not readable by users who can't join it.
+func HistoryNotExposed() xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeWorldReadableHistory,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonHistoryNotExposed,
+	}
 }
 
 // A RoomSpec defines the desired state of a Room.
@@ -204,6 +650,8 @@ type RoomStatus struct {
 // +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
 // +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
 // +kubebuilder:printcolumn:name="EXTERNAL-NAME",type="string",JSONPath=".metadata.annotations.crossplane.io/external-name"
+// +kubebuilder:printcolumn:name="ALIAS",type="string",JSONPath=".status.atProvider.alias",priority=1
+// +kubebuilder:printcolumn:name="STATE-EVENTS",type="integer",JSONPath=".status.atProvider.stateEventCount",priority=1
 // +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:scope=Cluster,categories={crossplane,managed,matrix}
@@ -263,3 +711,13 @@ type RoomList struct {
 	metav1.ListMeta `json:"metadata,omitempty"`
 	Items           []Room `json:"items"`
 }
+
+// GetItems returns the Rooms of the list as managed resources, so that
+// other resources can select a Room by its labels.
+func (l *RoomList) GetItems() []resource.Managed {
+	items := make([]resource.Managed, len(l.Items))
+	for i := range l.Items {
+		items[i] = &l.Items[i]
+	}
+	return items
+}
@@ -21,9 +21,50 @@ limitations under the License.
 package v1alpha1
 
 import (
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InviteProgress) DeepCopyInto(out *InviteProgress) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InviteProgress.
+func (in *InviteProgress) DeepCopy() *InviteProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(InviteProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JoinRuleAllowCondition) DeepCopyInto(out *JoinRuleAllowCondition) {
+	*out = *in
+	if in.RoomID != nil {
+		in, out := &in.RoomID, &out.RoomID
+		*out = new(string)
+		**out = **in
+	}
+	if in.SpaceRef != nil {
+		in, out := &in.SpaceRef, &out.SpaceRef
+		*out = new(xpv1.Reference)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JoinRuleAllowCondition.
+func (in *JoinRuleAllowCondition) DeepCopy() *JoinRuleAllowCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(JoinRuleAllowCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PowerLevelContent) DeepCopyInto(out *PowerLevelContent) {
 	*out = *in
@@ -150,6 +191,16 @@ func (in *RoomList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RoomObservation) DeepCopyInto(out *RoomObservation) {
 	*out = *in
+	if in.TruncatedFields != nil {
+		in, out := &in.TruncatedFields, &out.TruncatedFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AltAliases != nil {
+		in, out := &in.AltAliases, &out.AltAliases
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.CreationTime != nil {
 		in, out := &in.CreationTime, &out.CreationTime
 		*out = (*in).DeepCopy()
@@ -166,6 +217,77 @@ func (in *RoomObservation) DeepCopyInto(out *RoomObservation) {
 		*out = new(PowerLevelContent)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.PrivilegedCreators != nil {
+		in, out := &in.PrivilegedCreators, &out.PrivilegedCreators
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.EffectivePowerLevels != nil {
+		in, out := &in.EffectivePowerLevels, &out.EffectivePowerLevels
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Admins != nil {
+		in, out := &in.Admins, &out.Admins
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PendingKnocks != nil {
+		in, out := &in.PendingKnocks, &out.PendingKnocks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Predecessor != nil {
+		in, out := &in.Predecessor, &out.Predecessor
+		*out = new(RoomPredecessor)
+		**out = **in
+	}
+	if in.Blocked != nil {
+		in, out := &in.Blocked, &out.Blocked
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ForwardExtremities != nil {
+		in, out := &in.ForwardExtremities, &out.ForwardExtremities
+		*out = new(int)
+		**out = **in
+	}
+	if in.InviteProgress != nil {
+		in, out := &in.InviteProgress, &out.InviteProgress
+		*out = new(InviteProgress)
+		**out = **in
+	}
+	if in.JoinRuleAllow != nil {
+		in, out := &in.JoinRuleAllow, &out.JoinRuleAllow
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DanglingAllowReferences != nil {
+		in, out := &in.DanglingAllowReferences, &out.DanglingAllowReferences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PinnedEvents != nil {
+		in, out := &in.PinnedEvents, &out.PinnedEvents
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.InvalidPinnedEvents != nil {
+		in, out := &in.InvalidPinnedEvents, &out.InvalidPinnedEvents
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DirectoryNetworks != nil {
+		in, out := &in.DirectoryNetworks, &out.DirectoryNetworks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AdoptedAt != nil {
+		in, out := &in.AdoptedAt, &out.AdoptedAt
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RoomObservation.
@@ -191,11 +313,41 @@ func (in *RoomParameters) DeepCopyInto(out *RoomParameters) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.RichTopic != nil {
+		in, out := &in.RichTopic, &out.RichTopic
+		*out = new(bool)
+		**out = **in
+	}
+	if in.TopicHTML != nil {
+		in, out := &in.TopicHTML, &out.TopicHTML
+		*out = new(string)
+		**out = **in
+	}
+	if in.TruncateOverLength != nil {
+		in, out := &in.TruncateOverLength, &out.TruncateOverLength
+		*out = new(bool)
+		**out = **in
+	}
 	if in.Alias != nil {
 		in, out := &in.Alias, &out.Alias
 		*out = new(string)
 		**out = **in
 	}
+	if in.AdoptExisting != nil {
+		in, out := &in.AdoptExisting, &out.AdoptExisting
+		*out = new(bool)
+		**out = **in
+	}
+	if in.SetAliasAsCanonical != nil {
+		in, out := &in.SetAliasAsCanonical, &out.SetAliasAsCanonical
+		*out = new(bool)
+		**out = **in
+	}
+	if in.AltAliases != nil {
+		in, out := &in.AltAliases, &out.AltAliases
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Preset != nil {
 		in, out := &in.Preset, &out.Preset
 		*out = new(string)
@@ -206,6 +358,11 @@ func (in *RoomParameters) DeepCopyInto(out *RoomParameters) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.DirectoryNetworks != nil {
+		in, out := &in.DirectoryNetworks, &out.DirectoryNetworks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.RoomVersion != nil {
 		in, out := &in.RoomVersion, &out.RoomVersion
 		*out = new(string)
@@ -228,6 +385,16 @@ func (in *RoomParameters) DeepCopyInto(out *RoomParameters) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.InviteBatchSize != nil {
+		in, out := &in.InviteBatchSize, &out.InviteBatchSize
+		*out = new(int)
+		**out = **in
+	}
+	if in.Invite3PID != nil {
+		in, out := &in.Invite3PID, &out.Invite3PID
+		*out = make([]ThirdPartyInvite, len(*in))
+		copy(*out, *in)
+	}
 	if in.PowerLevelOverrides != nil {
 		in, out := &in.PowerLevelOverrides, &out.PowerLevelOverrides
 		*out = new(PowerLevelContent)
@@ -248,6 +415,13 @@ func (in *RoomParameters) DeepCopyInto(out *RoomParameters) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.JoinRuleAllow != nil {
+		in, out := &in.JoinRuleAllow, &out.JoinRuleAllow
+		*out = make([]JoinRuleAllowCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.EncryptionEnabled != nil {
 		in, out := &in.EncryptionEnabled, &out.EncryptionEnabled
 		*out = new(bool)
@@ -258,6 +432,56 @@ func (in *RoomParameters) DeepCopyInto(out *RoomParameters) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.KnockAutoAccept != nil {
+		in, out := &in.KnockAutoAccept, &out.KnockAutoAccept
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ForwardExtremitiesThreshold != nil {
+		in, out := &in.ForwardExtremitiesThreshold, &out.ForwardExtremitiesThreshold
+		*out = new(int)
+		**out = **in
+	}
+	if in.Blocked != nil {
+		in, out := &in.Blocked, &out.Blocked
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ObserveOnlyFields != nil {
+		in, out := &in.ObserveOnlyFields, &out.ObserveOnlyFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PurgeOnDelete != nil {
+		in, out := &in.PurgeOnDelete, &out.PurgeOnDelete
+		*out = new(bool)
+		**out = **in
+	}
+	if in.RemoveDanglingAllowReferences != nil {
+		in, out := &in.RemoveDanglingAllowReferences, &out.RemoveDanglingAllowReferences
+		*out = new(bool)
+		**out = **in
+	}
+	if in.PinnedEvents != nil {
+		in, out := &in.PinnedEvents, &out.PinnedEvents
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.EnsureJoined != nil {
+		in, out := &in.EnsureJoined, &out.EnsureJoined
+		*out = new(bool)
+		**out = **in
+	}
+	if in.GrantAdminTo != nil {
+		in, out := &in.GrantAdminTo, &out.GrantAdminTo
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ObservedStateTypes != nil {
+		in, out := &in.ObservedStateTypes, &out.ObservedStateTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RoomParameters.
@@ -270,6 +494,21 @@ func (in *RoomParameters) DeepCopy() *RoomParameters {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RoomPredecessor) DeepCopyInto(out *RoomPredecessor) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RoomPredecessor.
+func (in *RoomPredecessor) DeepCopy() *RoomPredecessor {
+	if in == nil {
+		return nil
+	}
+	out := new(RoomPredecessor)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RoomSpec) DeepCopyInto(out *RoomSpec) {
 	*out = *in
@@ -319,3 +558,18 @@ func (in *StateEvent) DeepCopy() *StateEvent {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ThirdPartyInvite) DeepCopyInto(out *ThirdPartyInvite) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ThirdPartyInvite.
+func (in *ThirdPartyInvite) DeepCopy() *ThirdPartyInvite {
+	if in == nil {
+		return nil
+	}
+	out := new(ThirdPartyInvite)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	roomv1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/room/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reference"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ResolveReferences resolves the Room referenced or selected by the
+// RoomAlias's roomIDRef or roomIDSelector to its room ID
+func (mg *RoomAlias) ResolveReferences(ctx context.Context, c client.Reader) error {
+	r := reference.NewAPIResolver(c, mg)
+
+	rsp, err := r.Resolve(ctx, reference.ResolutionRequest{
+		CurrentValue: mg.Spec.ForProvider.RoomID,
+		Reference:    mg.Spec.ForProvider.RoomIDRef,
+		Selector:     mg.Spec.ForProvider.RoomIDSelector,
+		To:           reference.To{Managed: &roomv1alpha1.Room{}, List: &roomv1alpha1.RoomList{}},
+		Extract:      roomv1alpha1.RoomID(),
+		Namespace:    mg.GetNamespace(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "spec.forProvider.roomID")
+	}
+	mg.Spec.ForProvider.RoomID = rsp.ResolvedValue
+	mg.Spec.ForProvider.RoomIDRef = rsp.ResolvedReference
+
+	return nil
+}
@@ -28,10 +28,24 @@ type RoomAliasParameters struct {
 	// +kubebuilder:validation:Required
 	Alias string `json:"alias"`
 
-	// RoomID is the Matrix room ID that this alias should point to
-	// +kubebuilder:validation:Pattern="^![a-zA-Z0-9]+:[a-zA-Z0-9.-]+$"
-	// +kubebuilder:validation:Required
-	RoomID string `json:"roomID"`
+	// RoomID is the Matrix room ID that this alias should point to. Either
+	// it, RoomIDRef or RoomIDSelector is required.
+	// +kubebuilder:validation:Pattern="^![a-zA-Z0-9_-]+(:[a-zA-Z0-9.-]+)?$"
+	RoomID string `json:"roomID,omitempty"`
+
+	// RoomIDRef references the Room this alias should point to, filling in
+	// RoomID once the Room has been created
+	RoomIDRef *xpv1.Reference `json:"roomIDRef,omitempty"`
+
+	// RoomIDSelector selects the Room this alias should point to by its
+	// labels, filling in RoomIDRef
+	RoomIDSelector *xpv1.Selector `json:"roomIDSelector,omitempty"`
+
+	// FollowRoomUpgrades points the alias at the room that replaced RoomID
+	// when it is upgraded, instead of at the old room. Either way an upgrade
+	// is reported by the RoomUpgraded condition.
+	// +kubebuilder:default=false
+	FollowRoomUpgrades *bool `json:"followRoomUpgrades,omitempty"`
 
 	// SetAsCanonical determines if this alias should be set as the canonical alias for the room
 	// +kubebuilder:default=false
@@ -39,6 +53,13 @@ type RoomAliasParameters struct {
 
 	// AltAliases is a list of alternative aliases to publish for the room
 	AltAliases []string `json:"altAliases,omitempty"`
+
+	// ObserveOnlyFields lists fields of forProvider that are only used to
+	// create the alias. Afterwards they are observed, but never updated or
+	// reported as drift, so that room admins can point the alias elsewhere.
+	// +listType=set
+	// +kubebuilder:validation:items:Enum=roomID
+	ObserveOnlyFields []string `json:"observeOnlyFields,omitempty"`
 }
 
 // RoomAliasObservation reflects the observed state of a Matrix Room Alias
@@ -55,11 +76,16 @@ type RoomAliasObservation struct {
 	// IsPublished indicates if this alias is published in the room directory
 	IsPublished bool `json:"isPublished,omitempty"`
 
-	// CreationTime is when the alias was created
-	CreationTime *metav1.Time `json:"creationTime,omitempty"`
-
 	// Servers is a list of servers that know about this alias
 	Servers []string `json:"servers,omitempty"`
+
+	// ManagedBy is Created if the provider created the alias, or Adopted if
+	// the alias already existed when the provider started managing it
+	ManagedBy string `json:"managedBy,omitempty"`
+
+	// AdoptedAt is when the provider first observed the alias, if it adopted
+	// it
+	AdoptedAt *metav1.Time `json:"adoptedAt,omitempty"`
 }
 
 // A RoomAliasSpec defines the desired state of a RoomAlias.
@@ -21,6 +21,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -86,15 +87,15 @@ func (in *RoomAliasList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RoomAliasObservation) DeepCopyInto(out *RoomAliasObservation) {
 	*out = *in
-	if in.CreationTime != nil {
-		in, out := &in.CreationTime, &out.CreationTime
-		*out = (*in).DeepCopy()
-	}
 	if in.Servers != nil {
 		in, out := &in.Servers, &out.Servers
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.AdoptedAt != nil {
+		in, out := &in.AdoptedAt, &out.AdoptedAt
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RoomAliasObservation.
@@ -110,6 +111,21 @@ func (in *RoomAliasObservation) DeepCopy() *RoomAliasObservation {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RoomAliasParameters) DeepCopyInto(out *RoomAliasParameters) {
 	*out = *in
+	if in.RoomIDRef != nil {
+		in, out := &in.RoomIDRef, &out.RoomIDRef
+		*out = new(xpv1.Reference)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RoomIDSelector != nil {
+		in, out := &in.RoomIDSelector, &out.RoomIDSelector
+		*out = new(xpv1.Selector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FollowRoomUpgrades != nil {
+		in, out := &in.FollowRoomUpgrades, &out.FollowRoomUpgrades
+		*out = new(bool)
+		**out = **in
+	}
 	if in.SetAsCanonical != nil {
 		in, out := &in.SetAsCanonical, &out.SetAsCanonical
 		*out = new(bool)
@@ -120,6 +136,11 @@ func (in *RoomAliasParameters) DeepCopyInto(out *RoomAliasParameters) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ObserveOnlyFields != nil {
+		in, out := &in.ObserveOnlyFields, &out.ObserveOnlyFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RoomAliasParameters.
@@ -0,0 +1,142 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RoomHistoryPurgeParameters define the desired purge of a Matrix room's
+// history
+type RoomHistoryPurgeParameters struct {
+	// RoomID is the Matrix room ID whose history to purge
+	// +kubebuilder:validation:Pattern="^![a-zA-Z0-9_-]+(:[a-zA-Z0-9.-]+)?$"
+	// +kubebuilder:validation:Required
+	RoomID string `json:"roomID"`
+
+	// PurgeUpToTs purges the events sent before this time, in milliseconds
+	// since the Unix epoch. The room's latest event and current state are
+	// always kept. Changing it purges the history again, up to the new time.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Required
+	PurgeUpToTs *int64 `json:"purgeUpToTs"`
+
+	// DeleteLocalEvents also purges the events sent by the homeserver's own
+	// users. By default only events from other homeservers are purged,
+	// since local events can't be fetched again over federation.
+	// +kubebuilder:default=false
+	DeleteLocalEvents *bool `json:"deleteLocalEvents,omitempty"`
+}
+
+// RoomHistoryPurgeObservation reflects the observed state of a purge of a
+// Matrix room's history
+type RoomHistoryPurgeObservation struct {
+	// PurgeID is the homeserver's ID of the latest purge
+	PurgeID string `json:"purgeID,omitempty"`
+
+	// Status of the latest purge: active, complete or failed
+	Status string `json:"status,omitempty"`
+
+	// Error is why the latest purge failed
+	Error string `json:"error,omitempty"`
+
+	// PurgedUpToTs is the time the latest purge purged events up to, in
+	// milliseconds since the Unix epoch
+	PurgedUpToTs *int64 `json:"purgedUpToTs,omitempty"`
+}
+
+// A RoomHistoryPurgeSpec defines the desired state of a RoomHistoryPurge.
+type RoomHistoryPurgeSpec struct {
+	xpv1.ManagedResourceSpec `json:",inline"`
+	ForProvider              RoomHistoryPurgeParameters `json:"forProvider"`
+}
+
+// A RoomHistoryPurgeStatus represents the observed state of a RoomHistoryPurge.
+type RoomHistoryPurgeStatus struct {
+	xpv1.ManagedResourceStatus `json:",inline"`
+	AtProvider                 RoomHistoryPurgeObservation `json:"atProvider,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// A RoomHistoryPurge is a managed resource that purges the history of a
+// Matrix room up to a point in time, keeping the room itself. It becomes
+// ready once the purge has finished. It requires the Synapse admin API.
+// +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="ROOM-ID",type="string",JSONPath=".spec.forProvider.roomID"
+// +kubebuilder:printcolumn:name="STATUS",type="string",JSONPath=".status.atProvider.status"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories={crossplane,managed,matrix}
+type RoomHistoryPurge struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RoomHistoryPurgeSpec   `json:"spec"`
+	Status RoomHistoryPurgeStatus `json:"status,omitempty"`
+}
+
+// GetProviderConfigReference returns the provider config reference.
+func (r *RoomHistoryPurge) GetProviderConfigReference() *xpv1.ProviderConfigReference {
+	return r.Spec.ProviderConfigReference
+}
+
+// SetProviderConfigReference sets the provider config reference.
+func (r *RoomHistoryPurge) SetProviderConfigReference(ref *xpv1.ProviderConfigReference) {
+	r.Spec.ProviderConfigReference = ref
+}
+
+// GetCondition returns the condition with the given type.
+func (r *RoomHistoryPurge) GetCondition(ct xpv1.ConditionType) xpv1.Condition {
+	return r.Status.GetCondition(ct)
+}
+
+// SetConditions sets the conditions.
+func (r *RoomHistoryPurge) SetConditions(c ...xpv1.Condition) {
+	r.Status.SetConditions(c...)
+}
+
+// GetManagementPolicies returns the management policies.
+func (r *RoomHistoryPurge) GetManagementPolicies() xpv1.ManagementPolicies {
+	return r.Spec.ManagementPolicies
+}
+
+// SetManagementPolicies sets the management policies.
+func (r *RoomHistoryPurge) SetManagementPolicies(p xpv1.ManagementPolicies) {
+	r.Spec.ManagementPolicies = p
+}
+
+// GetWriteConnectionSecretToReference returns the write connection secret to reference.
+func (r *RoomHistoryPurge) GetWriteConnectionSecretToReference() *xpv1.LocalSecretReference {
+	return r.Spec.WriteConnectionSecretToReference
+}
+
+// SetWriteConnectionSecretToReference sets the write connection secret to reference.
+func (r *RoomHistoryPurge) SetWriteConnectionSecretToReference(s *xpv1.LocalSecretReference) {
+	r.Spec.WriteConnectionSecretToReference = s
+}
+
+// +kubebuilder:object:root=true
+
+// RoomHistoryPurgeList contains a list of RoomHistoryPurge
+type RoomHistoryPurgeList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RoomHistoryPurge `json:"items"`
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Memberships a user can be given in a room.
+const (
+	MembershipInvite = "invite"
+	MembershipJoin   = "join"
+	MembershipLeave  = "leave"
+	MembershipBan    = "ban"
+)
+
+// RoomMembershipParameters define the desired membership of a user in a
+// Matrix room
+type RoomMembershipParameters struct {
+	// RoomID is the Matrix room ID of the room
+	// +kubebuilder:validation:Pattern="^![a-zA-Z0-9_-]+(:[a-zA-Z0-9.-]+)?$"
+	// +kubebuilder:validation:Required
+	RoomID string `json:"roomID"`
+
+	// UserID is the Matrix user ID of the user whose membership is managed
+	// +kubebuilder:validation:Pattern="^@[a-zA-Z0-9._=/-]+:[a-zA-Z0-9.-]+$"
+	// +kubebuilder:validation:Required
+	UserID string `json:"userID"`
+
+	// Membership is the user's desired membership of the room. An invited
+	// user who has joined is left joined. Users other than the provider's
+	// own can only be joined to a room with the admin API, and must be local
+	// to its homeserver.
+	// +kubebuilder:validation:Enum=invite;join;leave;ban
+	// +kubebuilder:validation:Required
+	Membership string `json:"membership"`
+
+	// Reason is given for invites, kicks and bans, and shown to the user
+	Reason *string `json:"reason,omitempty"`
+
+	// LeaveOnDelete removes the user from the room when the RoomMembership
+	// is deleted, kicking a member, revoking an invite or lifting a ban. By
+	// default the membership is left as it is.
+	// +kubebuilder:default=false
+	LeaveOnDelete *bool `json:"leaveOnDelete,omitempty"`
+
+	// PowerLevel is the power level the user should have in the room. It is
+	// given to the user once they have joined, so an invited moderator gets
+	// their power when they accept the invite. The provider's user must have
+	// the power to change the room's power levels.
+	PowerLevel *int `json:"powerLevel,omitempty"`
+
+	// ObserveOnlyFields lists fields of forProvider that are only applied
+	// until the membership first reaches them. Afterwards they are observed,
+	// but never updated or reported as drift, so that the user can leave an
+	// invited room, or room admins can change the user's power level.
+	// +listType=set
+	// +kubebuilder:validation:items:Enum=membership;powerLevel
+	ObserveOnlyFields []string `json:"observeOnlyFields,omitempty"`
+}
+
+// RoomMembershipObservation reflects the observed membership of a user in a
+// Matrix room
+type RoomMembershipObservation struct {
+	// Membership is the user's current membership of the room. It is empty
+	// if the user has never been in the room.
+	Membership string `json:"membership,omitempty"`
+
+	// PowerLevel is the user's current power level in the room. Only
+	// observed when the spec sets a PowerLevel.
+	PowerLevel *int `json:"powerLevel,omitempty"`
+
+	// PendingPowerLevel is the power level the user will be given once they
+	// join the room, while they haven't yet
+	PendingPowerLevel *int `json:"pendingPowerLevel,omitempty"`
+
+	// SettledFields are the observe-only fields of forProvider that the
+	// membership has reached, and that are only observed from then on
+	SettledFields []string `json:"settledFields,omitempty"`
+
+	// ManagedBy is always Adopted, since a user has some membership of a
+	// room, if only having left it, before the provider starts managing it
+	ManagedBy string `json:"managedBy,omitempty"`
+
+	// AdoptedAt is when the provider first observed the membership
+	AdoptedAt *metav1.Time `json:"adoptedAt,omitempty"`
+}
+
+// A RoomMembershipSpec defines the desired state of a RoomMembership.
+type RoomMembershipSpec struct {
+	xpv1.ManagedResourceSpec `json:",inline"`
+	ForProvider              RoomMembershipParameters `json:"forProvider"`
+}
+
+// A RoomMembershipStatus represents the observed state of a RoomMembership.
+type RoomMembershipStatus struct {
+	xpv1.ManagedResourceStatus `json:",inline"`
+	AtProvider                 RoomMembershipObservation `json:"atProvider,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// A RoomMembership is a managed resource that represents the membership of a
+// user in a Matrix room
+// +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="ROOM-ID",type="string",JSONPath=".spec.forProvider.roomID"
+// +kubebuilder:printcolumn:name="USER-ID",type="string",JSONPath=".spec.forProvider.userID"
+// +kubebuilder:printcolumn:name="MEMBERSHIP",type="string",JSONPath=".status.atProvider.membership"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories={crossplane,managed,matrix}
+type RoomMembership struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RoomMembershipSpec   `json:"spec"`
+	Status RoomMembershipStatus `json:"status,omitempty"`
+}
+
+// GetProviderConfigReference returns the provider config reference.
+func (r *RoomMembership) GetProviderConfigReference() *xpv1.ProviderConfigReference {
+	return r.Spec.ProviderConfigReference
+}
+
+// SetProviderConfigReference sets the provider config reference.
+func (r *RoomMembership) SetProviderConfigReference(ref *xpv1.ProviderConfigReference) {
+	r.Spec.ProviderConfigReference = ref
+}
+
+// GetCondition returns the condition with the given type.
+func (r *RoomMembership) GetCondition(ct xpv1.ConditionType) xpv1.Condition {
+	return r.Status.GetCondition(ct)
+}
+
+// SetConditions sets the conditions.
+func (r *RoomMembership) SetConditions(c ...xpv1.Condition) {
+	r.Status.SetConditions(c...)
+}
+
+// GetManagementPolicies returns the management policies.
+func (r *RoomMembership) GetManagementPolicies() xpv1.ManagementPolicies {
+	return r.Spec.ManagementPolicies
+}
+
+// SetManagementPolicies sets the management policies.
+func (r *RoomMembership) SetManagementPolicies(p xpv1.ManagementPolicies) {
+	r.Spec.ManagementPolicies = p
+}
+
+// GetWriteConnectionSecretToReference returns the write connection secret to reference.
+func (r *RoomMembership) GetWriteConnectionSecretToReference() *xpv1.LocalSecretReference {
+	return r.Spec.WriteConnectionSecretToReference
+}
+
+// SetWriteConnectionSecretToReference sets the write connection secret to reference.
+func (r *RoomMembership) SetWriteConnectionSecretToReference(s *xpv1.LocalSecretReference) {
+	r.Spec.WriteConnectionSecretToReference = s
+}
+
+// +kubebuilder:object:root=true
+
+// RoomMembershipList contains a list of RoomMembership
+type RoomMembershipList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RoomMembership `json:"items"`
+}
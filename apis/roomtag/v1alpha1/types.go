@@ -0,0 +1,156 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RoomTagParameters define the desired state of a tag a Matrix user puts on a
+// room
+type RoomTagParameters struct {
+	// UserID is the user whose tag this is. It defaults to the provider's
+	// own user, and tagging rooms for other users requires the Synapse admin
+	// API.
+	// +optional
+	// +kubebuilder:validation:Pattern="^@[a-zA-Z0-9._=/-]+:[a-zA-Z0-9.-]+$"
+	UserID string `json:"userID,omitempty"`
+
+	// RoomID is the Matrix room ID of the room to tag
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern="^![a-zA-Z0-9_-]+(:[a-zA-Z0-9.-]+)?$"
+	RoomID string `json:"roomID"`
+
+	// Tag is the tag to put on the room, such as m.favourite,
+	// m.lowpriority or a custom tag like u.work
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=255
+	Tag string `json:"tag"`
+
+	// Order is where the room sorts among the rooms with the same tag, from
+	// 0 to 1. Rooms without an order sort after those with one.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1
+	Order *float64 `json:"order,omitempty"`
+
+	// ObserveOnlyFields lists fields of forProvider that are only used to
+	// create the tag. Afterwards they are observed, but never updated or
+	// reported as drift, so that the user can reorder their rooms.
+	// +listType=set
+	// +kubebuilder:validation:items:Enum=order
+	ObserveOnlyFields []string `json:"observeOnlyFields,omitempty"`
+}
+
+// RoomTagObservation reflects the observed state of a tag a Matrix user put
+// on a room
+type RoomTagObservation struct {
+	// Order is the order the room has among the rooms with the same tag
+	Order *float64 `json:"order,omitempty"`
+
+	// ManagedBy is Created if the provider put the tag on the room, or
+	// Adopted if the room already had it when the provider started managing
+	// it
+	ManagedBy string `json:"managedBy,omitempty"`
+
+	// AdoptedAt is when the provider first observed the tag, if it adopted
+	// it
+	AdoptedAt *metav1.Time `json:"adoptedAt,omitempty"`
+}
+
+// A RoomTagSpec defines the desired state of a RoomTag.
+type RoomTagSpec struct {
+	xpv1.ManagedResourceSpec `json:",inline"`
+	ForProvider              RoomTagParameters `json:"forProvider"`
+}
+
+// A RoomTagStatus represents the observed state of a RoomTag.
+type RoomTagStatus struct {
+	xpv1.ManagedResourceStatus `json:",inline"`
+	AtProvider                 RoomTagObservation `json:"atProvider,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// A RoomTag is a managed resource that represents a tag a Matrix user puts
+// on a room, such as m.favourite, which clients use to group and sort the
+// user's rooms.
+// +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="ROOM",type="string",JSONPath=".spec.forProvider.roomID"
+// +kubebuilder:printcolumn:name="TAG",type="string",JSONPath=".spec.forProvider.tag"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories={crossplane,managed,matrix}
+type RoomTag struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RoomTagSpec   `json:"spec"`
+	Status RoomTagStatus `json:"status,omitempty"`
+}
+
+// GetProviderConfigReference returns the provider config reference.
+func (r *RoomTag) GetProviderConfigReference() *xpv1.ProviderConfigReference {
+	return r.Spec.ProviderConfigReference
+}
+
+// SetProviderConfigReference sets the provider config reference.
+func (r *RoomTag) SetProviderConfigReference(ref *xpv1.ProviderConfigReference) {
+	r.Spec.ProviderConfigReference = ref
+}
+
+// GetCondition returns the condition with the given type.
+func (r *RoomTag) GetCondition(ct xpv1.ConditionType) xpv1.Condition {
+	return r.Status.GetCondition(ct)
+}
+
+// SetConditions sets the conditions.
+func (r *RoomTag) SetConditions(c ...xpv1.Condition) {
+	r.Status.SetConditions(c...)
+}
+
+// GetManagementPolicies returns the management policies.
+func (r *RoomTag) GetManagementPolicies() xpv1.ManagementPolicies {
+	return r.Spec.ManagementPolicies
+}
+
+// SetManagementPolicies sets the management policies.
+func (r *RoomTag) SetManagementPolicies(p xpv1.ManagementPolicies) {
+	r.Spec.ManagementPolicies = p
+}
+
+// GetWriteConnectionSecretToReference returns the write connection secret to reference.
+func (r *RoomTag) GetWriteConnectionSecretToReference() *xpv1.LocalSecretReference {
+	return r.Spec.WriteConnectionSecretToReference
+}
+
+// SetWriteConnectionSecretToReference sets the write connection secret to reference.
+func (r *RoomTag) SetWriteConnectionSecretToReference(s *xpv1.LocalSecretReference) {
+	r.Spec.WriteConnectionSecretToReference = s
+}
+
+// +kubebuilder:object:root=true
+
+// RoomTagList contains a list of RoomTag
+type RoomTagList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RoomTag `json:"items"`
+}
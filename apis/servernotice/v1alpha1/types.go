@@ -0,0 +1,149 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ServerNoticeParameters define a server notice to send to a Matrix user.
+// They are only used when the notice is sent: changing them afterwards does
+// nothing, as a notice can't be edited.
+type ServerNoticeParameters struct {
+	// UserID is the Matrix user ID of the user to send the notice to. The
+	// user must be local to the homeserver.
+	// +kubebuilder:validation:Pattern="^@[a-zA-Z0-9._=/-]+:[a-zA-Z0-9.-]+$"
+	// +kubebuilder:validation:Required
+	UserID string `json:"userID"`
+
+	// Content of the notice
+	// +kubebuilder:validation:Required
+	Content ServerNoticeContent `json:"content"`
+
+	// RedactOnDelete redacts the notice when the ServerNotice is deleted,
+	// removing its content from the user's server notices room. By default
+	// the notice is kept.
+	// +kubebuilder:default=false
+	RedactOnDelete *bool `json:"redactOnDelete,omitempty"`
+}
+
+// ServerNoticeContent is the content of a server notice
+type ServerNoticeContent struct {
+	// MsgType is the type of message the notice is sent as
+	// +kubebuilder:validation:Enum=m.text;m.notice
+	// +kubebuilder:default="m.text"
+	MsgType string `json:"msgtype,omitempty"`
+
+	// Body is the text of the notice
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Required
+	Body string `json:"body"`
+}
+
+// ServerNoticeObservation reflects the observed state of a server notice
+type ServerNoticeObservation struct {
+	// EventID is the ID of the event the notice was sent as
+	EventID string `json:"eventID,omitempty"`
+
+	// Redacted is set once the notice has been redacted because the
+	// ServerNotice was deleted
+	Redacted bool `json:"redacted,omitempty"`
+}
+
+// A ServerNoticeSpec defines the desired state of a ServerNotice.
+type ServerNoticeSpec struct {
+	xpv1.ManagedResourceSpec `json:",inline"`
+	ForProvider              ServerNoticeParameters `json:"forProvider"`
+}
+
+// A ServerNoticeStatus represents the observed state of a ServerNotice.
+type ServerNoticeStatus struct {
+	xpv1.ManagedResourceStatus `json:",inline"`
+	AtProvider                 ServerNoticeObservation `json:"atProvider,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// A ServerNotice is a managed resource that sends a server notice to a
+// Matrix user. A notice is sent once, when the ServerNotice is created:
+// editing its content afterwards does nothing, and sending another notice
+// takes another ServerNotice. It requires the Synapse admin API, with
+// server notices enabled on the homeserver.
+// +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="USER-ID",type="string",JSONPath=".spec.forProvider.userID"
+// +kubebuilder:printcolumn:name="EVENT-ID",type="string",JSONPath=".status.atProvider.eventID"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories={crossplane,managed,matrix}
+type ServerNotice struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ServerNoticeSpec   `json:"spec"`
+	Status ServerNoticeStatus `json:"status,omitempty"`
+}
+
+// GetProviderConfigReference returns the provider config reference.
+func (r *ServerNotice) GetProviderConfigReference() *xpv1.ProviderConfigReference {
+	return r.Spec.ProviderConfigReference
+}
+
+// SetProviderConfigReference sets the provider config reference.
+func (r *ServerNotice) SetProviderConfigReference(ref *xpv1.ProviderConfigReference) {
+	r.Spec.ProviderConfigReference = ref
+}
+
+// GetCondition returns the condition with the given type.
+func (r *ServerNotice) GetCondition(ct xpv1.ConditionType) xpv1.Condition {
+	return r.Status.GetCondition(ct)
+}
+
+// SetConditions sets the conditions.
+func (r *ServerNotice) SetConditions(c ...xpv1.Condition) {
+	r.Status.SetConditions(c...)
+}
+
+// GetManagementPolicies returns the management policies.
+func (r *ServerNotice) GetManagementPolicies() xpv1.ManagementPolicies {
+	return r.Spec.ManagementPolicies
+}
+
+// SetManagementPolicies sets the management policies.
+func (r *ServerNotice) SetManagementPolicies(p xpv1.ManagementPolicies) {
+	r.Spec.ManagementPolicies = p
+}
+
+// GetWriteConnectionSecretToReference returns the write connection secret to reference.
+func (r *ServerNotice) GetWriteConnectionSecretToReference() *xpv1.LocalSecretReference {
+	return r.Spec.WriteConnectionSecretToReference
+}
+
+// SetWriteConnectionSecretToReference sets the write connection secret to reference.
+func (r *ServerNotice) SetWriteConnectionSecretToReference(s *xpv1.LocalSecretReference) {
+	r.Spec.WriteConnectionSecretToReference = s
+}
+
+// +kubebuilder:object:root=true
+
+// ServerNoticeList contains a list of ServerNotice
+type ServerNoticeList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ServerNotice `json:"items"`
+}
@@ -22,6 +22,14 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
+// Space type metadata.
+var (
+	SpaceKind             = reflect.TypeOf(Space{}).Name()
+	SpaceGroupKind        = schema.GroupKind{Group: Group, Kind: SpaceKind}
+	SpaceKindAPIVersion   = SpaceKind + "." + SchemeGroupVersion.String()
+	SpaceGroupVersionKind = SchemeGroupVersion.WithKind(SpaceKind)
+)
+
 // StateEvent type metadata.
 var (
 	StateEventKind             = reflect.TypeOf(StateEvent{}).Name()
@@ -43,7 +43,9 @@ type SpaceParameters struct {
 	// +kubebuilder:validation:Pattern="^[0-9]+$|^[0-9]+.[0-9]+$"
 	RoomVersion *string `json:"roomVersion,omitempty"`
 
-	// CreationContent is additional content for the m.room.create event
+	// CreationContent is additional content for the m.room.create event.
+	// Keys set here take precedence over the homeserver's defaults, except
+	// for type, which the provider always sets to m.space.
 	// +kubebuilder:pruning:PreserveUnknownFields
 	// +kubebuilder:validation:Type=object
 	CreationContent *runtime.RawExtension `json:"creationContent,omitempty"`
@@ -78,6 +80,13 @@ type SpaceParameters struct {
 
 	// Children defines the child rooms and spaces within this space
 	Children []SpaceChild `json:"children,omitempty"`
+
+	// ObserveOnlyFields lists fields of forProvider that are only used to
+	// create the space. Afterwards they are observed, but never updated or
+	// reported as drift, so that space admins can change them.
+	// +listType=set
+	// +kubebuilder:validation:items:Enum=name;topic;alias;guestAccess;historyVisibility;joinRules;avatarURL;children
+	ObserveOnlyFields []string `json:"observeOnlyFields,omitempty"`
 }
 
 // StateEvent represents a Matrix state event
@@ -129,7 +138,7 @@ type PowerLevelContent struct {
 // SpaceChild represents a child room or space within a space
 type SpaceChild struct {
 	// RoomID is the Matrix room or space ID to include as a child
-	// +kubebuilder:validation:Pattern="^![a-zA-Z0-9]+:[a-zA-Z0-9.-]+$"
+	// +kubebuilder:validation:Pattern="^![a-zA-Z0-9_-]+(:[a-zA-Z0-9.-]+)?$"
 	// +kubebuilder:validation:Required
 	RoomID string `json:"roomID"`
 
@@ -196,6 +205,14 @@ type SpaceObservation struct {
 
 	// PowerLevels contains current power level settings
 	PowerLevels *PowerLevelContent `json:"powerLevels,omitempty"`
+
+	// ManagedBy is Created if the provider created the space, or Adopted if
+	// the space already existed when the provider started managing it
+	ManagedBy string `json:"managedBy,omitempty"`
+
+	// AdoptedAt is when the provider first observed the space, if it adopted
+	// it
+	AdoptedAt *metav1.Time `json:"adoptedAt,omitempty"`
 }
 
 // A SpaceSpec defines the desired state of a Space.
@@ -227,6 +244,46 @@ type Space struct {
 	Status SpaceStatus `json:"status,omitempty"`
 }
 
+// GetProviderConfigReference returns the provider config reference.
+func (s *Space) GetProviderConfigReference() *xpv1.ProviderConfigReference {
+	return s.Spec.ProviderConfigReference
+}
+
+// SetProviderConfigReference sets the provider config reference.
+func (s *Space) SetProviderConfigReference(ref *xpv1.ProviderConfigReference) {
+	s.Spec.ProviderConfigReference = ref
+}
+
+// GetCondition returns the condition with the given type.
+func (s *Space) GetCondition(ct xpv1.ConditionType) xpv1.Condition {
+	return s.Status.GetCondition(ct)
+}
+
+// SetConditions sets the conditions.
+func (s *Space) SetConditions(c ...xpv1.Condition) {
+	s.Status.SetConditions(c...)
+}
+
+// GetManagementPolicies returns the management policies.
+func (s *Space) GetManagementPolicies() xpv1.ManagementPolicies {
+	return s.Spec.ManagementPolicies
+}
+
+// SetManagementPolicies sets the management policies.
+func (s *Space) SetManagementPolicies(p xpv1.ManagementPolicies) {
+	s.Spec.ManagementPolicies = p
+}
+
+// GetWriteConnectionSecretToReference returns the write connection secret to reference.
+func (s *Space) GetWriteConnectionSecretToReference() *xpv1.LocalSecretReference {
+	return s.Spec.WriteConnectionSecretToReference
+}
+
+// SetWriteConnectionSecretToReference sets the write connection secret to reference.
+func (s *Space) SetWriteConnectionSecretToReference(ref *xpv1.LocalSecretReference) {
+	s.Spec.WriteConnectionSecretToReference = ref
+}
+
 // +kubebuilder:object:root=true
 
 // SpaceList contains a list of Space
@@ -203,6 +203,10 @@ func (in *SpaceObservation) DeepCopyInto(out *SpaceObservation) {
 		*out = new(PowerLevelContent)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.AdoptedAt != nil {
+		in, out := &in.AdoptedAt, &out.AdoptedAt
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpaceObservation.
@@ -292,6 +296,11 @@ func (in *SpaceParameters) DeepCopyInto(out *SpaceParameters) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ObserveOnlyFields != nil {
+		in, out := &in.ObserveOnlyFields, &out.ObserveOnlyFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpaceParameters.
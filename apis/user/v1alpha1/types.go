@@ -17,8 +17,12 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"fmt"
 	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"strings"
+	"time"
 )
 
 // UserParameters define the desired state of a Matrix User
@@ -43,6 +47,13 @@ type UserParameters struct {
 	// DisplayName is the user's display name
 	DisplayName *string `json:"displayName,omitempty"`
 
+	// NormalizeDisplayName compares display names after trimming them and
+	// collapsing runs of whitespace. Enable it on homeservers that normalize
+	// display names when storing them, where a name like "Alice " would
+	// otherwise never stop drifting.
+	// +kubebuilder:default=false
+	NormalizeDisplayName *bool `json:"normalizeDisplayName,omitempty"`
+
 	// AvatarURL is the user's avatar URL (mxc:// URL)
 	// +kubebuilder:validation:Pattern="^mxc://.*"
 	AvatarURL *string `json:"avatarURL,omitempty"`
@@ -51,10 +62,21 @@ type UserParameters struct {
 	// +kubebuilder:default=false
 	Admin *bool `json:"admin,omitempty"`
 
+	// AllowSelfDemotion permits setting admin to false on the provider's own
+	// configured user. Doing so can lock the provider out of admin operations.
+	// +kubebuilder:default=false
+	AllowSelfDemotion *bool `json:"allowSelfDemotion,omitempty"`
+
 	// Deactivated indicates if the user account should be deactivated
 	// +kubebuilder:default=false
 	Deactivated *bool `json:"deactivated,omitempty"`
 
+	// ShadowBanned shadow-bans the user: the homeserver accepts what they
+	// send but doesn't deliver it to anyone else, so they don't notice they
+	// have been banned. When omitted, the shadow-ban is not managed.
+	// Requires admin API access to a Synapse homeserver.
+	ShadowBanned *bool `json:"shadowBanned,omitempty"`
+
 	// ExternalIDs are third-party identifiers (3PIDs) associated with the user
 	ExternalIDs []ExternalID `json:"externalIDs,omitempty"`
 
@@ -65,6 +87,143 @@ type UserParameters struct {
 
 	// ExpireTime is when the user account expires (for guest users)
 	ExpireTime *metav1.Time `json:"expireTime,omitempty"`
+
+	// DeactivationGracePeriod delays deactivating the account when the User
+	// is deleted. The account is locked straight away and only deactivated
+	// once the grace period has passed, so an accidental deletion can be
+	// caught by setting deletionPolicy to Orphan in the meantime. Requires
+	// admin API access.
+	DeactivationGracePeriod *metav1.Duration `json:"deactivationGracePeriod,omitempty"`
+
+	// DeviceDisplayNames maps device IDs to the display names the user's
+	// devices should have, so that sessions such as a bot's can be told
+	// apart. Devices that aren't listed are left alone, and listed devices
+	// the user doesn't have are ignored. Requires admin API access.
+	DeviceDisplayNames map[string]string `json:"deviceDisplayNames,omitempty"`
+
+	// ReportConnections observes the IP addresses and user agents the user
+	// has connected from in status.atProvider.connections, for investigating
+	// abuse. It costs an extra request on every reconcile, so it is off by
+	// default. Requires admin API access to a Synapse homeserver.
+	ReportConnections *bool `json:"reportConnections,omitempty"`
+
+	// ObserveOnlyFields lists fields of forProvider that are only used to
+	// create the user. Afterwards they are observed, but never updated or
+	// reported as drift, so that the user or server admins can change them.
+	// +listType=set
+	// +kubebuilder:validation:items:Enum=displayName;avatarURL;admin;deactivated;userType;shadowBanned;deviceDisplayNames
+	ObserveOnlyFields []string `json:"observeOnlyFields,omitempty"`
+}
+
+// Condition types and reasons for User resources.
+const (
+	// TypeAdminDemotionBlocked indicates whether the provider refused to
+	// demote its own user from admin.
+	TypeAdminDemotionBlocked xpv1.ConditionType = "AdminDemotionBlocked"
+
+	ReasonSelfDemotion        xpv1.ConditionReason = "ProviderUser"
+	ReasonDemotionNotRequired xpv1.ConditionReason = "NotRequired"
+
+	// TypeDeactivated indicates whether the observed user account is
+	// deactivated.
+	TypeDeactivated xpv1.ConditionType = "Deactivated"
+
+	ReasonUserDeactivated xpv1.ConditionReason = "UserDeactivated"
+	ReasonUserActive      xpv1.ConditionReason = "UserActive"
+
+	// TypeRemoteUserImmutable indicates whether the user belongs to another
+	// homeserver, so that the provider can't change it.
+	TypeRemoteUserImmutable xpv1.ConditionType = "RemoteUserImmutable"
+
+	ReasonRemoteUser xpv1.ConditionReason = "RemoteUser"
+	ReasonLocalUser  xpv1.ConditionReason = "LocalUser"
+
+	// TypeDeactivationPending indicates that a deleted User's account has
+	// been locked and is waiting out its grace period before deactivation.
+	TypeDeactivationPending xpv1.ConditionType = "DeactivationPending"
+
+	ReasonGracePeriod xpv1.ConditionReason = "GracePeriod"
+)
+
+// AdminDemotionBlocked returns a condition indicating that the provider
+// refused to remove admin privileges from its own user.
+func AdminDemotionBlocked() xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeAdminDemotionBlocked,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonSelfDemotion,
+		Message:            "Refusing to remove admin privileges from the provider's own user; set allowSelfDemotion to override",
+	}
+}
+
+// AdminDemotionNotBlocked returns a condition indicating that no admin
+// demotion is being blocked.
+func AdminDemotionNotBlocked() xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeAdminDemotionBlocked,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonDemotionNotRequired,
+	}
+}
+
+// UserDeactivated returns a condition indicating that the user account is
+// deactivated on the homeserver.
+func UserDeactivated() xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeDeactivated,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonUserDeactivated,
+	}
+}
+
+// UserActive returns a condition indicating that the user account is no
+// longer deactivated.
+func UserActive() xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeDeactivated,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonUserActive,
+	}
+}
+
+// RemoteUserImmutable returns a condition indicating that the user belongs to
+// another homeserver, so the given fields can't be brought in line with the
+// spec.
+func RemoteUserImmutable(serverName string, fields []string) xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeRemoteUserImmutable,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonRemoteUser,
+		Message:            fmt.Sprintf("User belongs to another homeserver than %s, so %s can't be updated", serverName, strings.Join(fields, ", ")),
+	}
+}
+
+// RemoteUserMutable returns a condition indicating that nothing is being
+// withheld from a user because it belongs to another homeserver.
+func RemoteUserMutable() xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeRemoteUserImmutable,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonLocalUser,
+	}
+}
+
+// DeactivationPending returns a condition indicating that the user's account
+// is locked and will be deactivated at the given time.
+func DeactivationPending(deactivateAt time.Time) xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeDeactivationPending,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonGracePeriod,
+		Message:            fmt.Sprintf("Account is locked and will be deactivated at %s", deactivateAt.UTC().Format(time.RFC3339)),
+	}
 }
 
 // ExternalID represents a third-party identifier associated with a user
@@ -94,10 +253,13 @@ type UserObservation struct {
 	AvatarURL string `json:"avatarURL,omitempty"`
 
 	// Admin indicates if the user has admin privileges
-	Admin bool `json:"admin,omitempty"`
+	Admin bool `json:"admin"`
 
 	// Deactivated indicates if the user is deactivated
-	Deactivated bool `json:"deactivated,omitempty"`
+	Deactivated bool `json:"deactivated"`
+
+	// Locked indicates if the user is locked out of their account
+	Locked bool `json:"locked,omitempty"`
 
 	// CreationTime is when the user was created
 	CreationTime *metav1.Time `json:"creationTime,omitempty"`
@@ -105,9 +267,14 @@ type UserObservation struct {
 	// LastSeenTime is when the user was last seen
 	LastSeenTime *metav1.Time `json:"lastSeenTime,omitempty"`
 
-	// Devices is a list of devices associated with the user
+	// Devices is a list of devices associated with the user. Only observed
+	// when DeviceDisplayNames is set.
 	Devices []Device `json:"devices,omitempty"`
 
+	// Connections are the user's recent connections to the homeserver. Only
+	// observed when ReportConnections is set.
+	Connections []Connection `json:"connections,omitempty"`
+
 	// ExternalIDs are the validated external identifiers
 	ExternalIDs []ExternalID `json:"externalIDs,omitempty"`
 
@@ -116,6 +283,43 @@ type UserObservation struct {
 
 	// ShadowBanned indicates if the user is shadow banned
 	ShadowBanned bool `json:"shadowBanned,omitempty"`
+
+	// RateLimit is the user's rate-limit override, if the homeserver has
+	// one. Only observed with admin API access.
+	RateLimit *RateLimit `json:"rateLimit,omitempty"`
+
+	// ManagedBy is Created if the provider created the user, or Adopted if
+	// the user already existed when the provider started managing it
+	ManagedBy string `json:"managedBy,omitempty"`
+
+	// AdoptedAt is when the provider first observed the user, if it adopted
+	// it
+	AdoptedAt *metav1.Time `json:"adoptedAt,omitempty"`
+}
+
+// RateLimit is a homeserver's rate-limit override for a user
+type RateLimit struct {
+	// MessagesPerSecond is the number of messages the user may send per
+	// second. Zero disables rate limiting.
+	MessagesPerSecond int `json:"messagesPerSecond"`
+
+	// BurstCount is the number of messages the user may send at once
+	BurstCount int `json:"burstCount"`
+}
+
+// Connection is a connection a user made to the homeserver
+type Connection struct {
+	// DeviceID is the device the connection was made with, if known
+	DeviceID string `json:"deviceID,omitempty"`
+
+	// IP is the IP address the connection was made from
+	IP string `json:"ip,omitempty"`
+
+	// UserAgent is the user agent of the client that connected
+	UserAgent string `json:"userAgent,omitempty"`
+
+	// LastSeenTime is when the connection was last used
+	LastSeenTime *metav1.Time `json:"lastSeenTime,omitempty"`
 }
 
 // Device represents a Matrix device
@@ -150,6 +354,9 @@ type UserStatus struct {
 // A User is a managed resource that represents a Matrix User
 // +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
 // +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="ADMIN",type="boolean",JSONPath=".status.atProvider.admin"
+// +kubebuilder:printcolumn:name="DEACTIVATED",type="boolean",JSONPath=".status.atProvider.deactivated"
+// +kubebuilder:printcolumn:name="LOCKED",type="boolean",JSONPath=".status.atProvider.locked",priority=1
 // +kubebuilder:printcolumn:name="EXTERNAL-NAME",type="string",JSONPath=".metadata.annotations.crossplane.io/external-name"
 // +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
 // +kubebuilder:subresource:status
@@ -29,6 +29,25 @@ import (
 
 var _ = metav1.Time{} // ensure import used
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Connection) DeepCopyInto(out *Connection) {
+	*out = *in
+	if in.LastSeenTime != nil {
+		in, out := &in.LastSeenTime, &out.LastSeenTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Connection.
+func (in *Connection) DeepCopy() *Connection {
+	if in == nil {
+		return nil
+	}
+	out := new(Connection)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Device) DeepCopyInto(out *Device) {
 	*out = *in
@@ -68,6 +87,21 @@ func (in *ExternalID) DeepCopy() *ExternalID {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RateLimit) DeepCopyInto(out *RateLimit) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RateLimit.
+func (in *RateLimit) DeepCopy() *RateLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(RateLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *User) DeepCopyInto(out *User) {
 	*out = *in
@@ -145,6 +179,13 @@ func (in *UserObservation) DeepCopyInto(out *UserObservation) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Connections != nil {
+		in, out := &in.Connections, &out.Connections
+		*out = make([]Connection, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.ExternalIDs != nil {
 		in, out := &in.ExternalIDs, &out.ExternalIDs
 		*out = make([]ExternalID, len(*in))
@@ -152,6 +193,15 @@ func (in *UserObservation) DeepCopyInto(out *UserObservation) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.RateLimit != nil {
+		in, out := &in.RateLimit, &out.RateLimit
+		*out = new(RateLimit)
+		**out = **in
+	}
+	if in.AdoptedAt != nil {
+		in, out := &in.AdoptedAt, &out.AdoptedAt
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserObservation.
@@ -192,6 +242,11 @@ func (in *UserParameters) DeepCopyInto(out *UserParameters) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.NormalizeDisplayName != nil {
+		in, out := &in.NormalizeDisplayName, &out.NormalizeDisplayName
+		*out = new(bool)
+		**out = **in
+	}
 	if in.AvatarURL != nil {
 		in, out := &in.AvatarURL, &out.AvatarURL
 		*out = new(string)
@@ -202,11 +257,21 @@ func (in *UserParameters) DeepCopyInto(out *UserParameters) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.AllowSelfDemotion != nil {
+		in, out := &in.AllowSelfDemotion, &out.AllowSelfDemotion
+		*out = new(bool)
+		**out = **in
+	}
 	if in.Deactivated != nil {
 		in, out := &in.Deactivated, &out.Deactivated
 		*out = new(bool)
 		**out = **in
 	}
+	if in.ShadowBanned != nil {
+		in, out := &in.ShadowBanned, &out.ShadowBanned
+		*out = new(bool)
+		**out = **in
+	}
 	if in.ExternalIDs != nil {
 		in, out := &in.ExternalIDs, &out.ExternalIDs
 		*out = make([]ExternalID, len(*in))
@@ -223,6 +288,28 @@ func (in *UserParameters) DeepCopyInto(out *UserParameters) {
 		in, out := &in.ExpireTime, &out.ExpireTime
 		*out = (*in).DeepCopy()
 	}
+	if in.DeactivationGracePeriod != nil {
+		in, out := &in.DeactivationGracePeriod, &out.DeactivationGracePeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.DeviceDisplayNames != nil {
+		in, out := &in.DeviceDisplayNames, &out.DeviceDisplayNames
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ReportConnections != nil {
+		in, out := &in.ReportConnections, &out.ReportConnections
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ObserveOnlyFields != nil {
+		in, out := &in.ObserveOnlyFields, &out.ObserveOnlyFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserParameters.
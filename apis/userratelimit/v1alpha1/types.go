@@ -0,0 +1,150 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// UserRateLimitParameters define the desired rate-limit override of a Matrix
+// user
+type UserRateLimitParameters struct {
+	// UserID is the Matrix user ID of the user whose rate limits are
+	// overridden. The user must be local to the homeserver.
+	// +kubebuilder:validation:Pattern="^@[a-zA-Z0-9._=/-]+:[a-zA-Z0-9.-]+$"
+	// +kubebuilder:validation:Required
+	UserID string `json:"userID"`
+
+	// MessagesPerSecond is how many messages per second the user may send.
+	// Setting it and BurstCount to 0 exempts the user from rate limiting.
+	// Omitted, the override keeps its current value, or 0 when created.
+	// +kubebuilder:validation:Minimum=0
+	MessagesPerSecond *int `json:"messagesPerSecond,omitempty"`
+
+	// BurstCount is how many messages the user may send at once before being
+	// limited. Omitted, the override keeps its current value, or 0 when
+	// created.
+	// +kubebuilder:validation:Minimum=0
+	BurstCount *int `json:"burstCount,omitempty"`
+
+	// ObserveOnlyFields lists fields of forProvider that are only used to
+	// create the override. Afterwards they are observed, but never updated
+	// or reported as drift, so that server admins can change them.
+	// +listType=set
+	// +kubebuilder:validation:items:Enum=messagesPerSecond;burstCount
+	ObserveOnlyFields []string `json:"observeOnlyFields,omitempty"`
+}
+
+// UserRateLimitObservation reflects the observed rate-limit override of a
+// Matrix user
+type UserRateLimitObservation struct {
+	// MessagesPerSecond is how many messages per second the user may send
+	MessagesPerSecond int `json:"messagesPerSecond,omitempty"`
+
+	// BurstCount is how many messages the user may send at once
+	BurstCount int `json:"burstCount,omitempty"`
+
+	// ManagedBy is Created if the provider created the override, or Adopted
+	// if the override already existed when the provider started managing it
+	ManagedBy string `json:"managedBy,omitempty"`
+
+	// AdoptedAt is when the provider first observed the override, if it
+	// adopted it
+	AdoptedAt *metav1.Time `json:"adoptedAt,omitempty"`
+}
+
+// A UserRateLimitSpec defines the desired state of a UserRateLimit.
+type UserRateLimitSpec struct {
+	xpv1.ManagedResourceSpec `json:",inline"`
+	ForProvider              UserRateLimitParameters `json:"forProvider"`
+}
+
+// A UserRateLimitStatus represents the observed state of a UserRateLimit.
+type UserRateLimitStatus struct {
+	xpv1.ManagedResourceStatus `json:",inline"`
+	AtProvider                 UserRateLimitObservation `json:"atProvider,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// A UserRateLimit is a managed resource that represents the rate-limit
+// override of a Matrix user. It requires the Synapse admin API.
+// +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="USER-ID",type="string",JSONPath=".spec.forProvider.userID"
+// +kubebuilder:printcolumn:name="MESSAGES-PER-SECOND",type="integer",JSONPath=".status.atProvider.messagesPerSecond"
+// +kubebuilder:printcolumn:name="BURST-COUNT",type="integer",JSONPath=".status.atProvider.burstCount"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories={crossplane,managed,matrix}
+type UserRateLimit struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   UserRateLimitSpec   `json:"spec"`
+	Status UserRateLimitStatus `json:"status,omitempty"`
+}
+
+// GetProviderConfigReference returns the provider config reference.
+func (r *UserRateLimit) GetProviderConfigReference() *xpv1.ProviderConfigReference {
+	return r.Spec.ProviderConfigReference
+}
+
+// SetProviderConfigReference sets the provider config reference.
+func (r *UserRateLimit) SetProviderConfigReference(ref *xpv1.ProviderConfigReference) {
+	r.Spec.ProviderConfigReference = ref
+}
+
+// GetCondition returns the condition with the given type.
+func (r *UserRateLimit) GetCondition(ct xpv1.ConditionType) xpv1.Condition {
+	return r.Status.GetCondition(ct)
+}
+
+// SetConditions sets the conditions.
+func (r *UserRateLimit) SetConditions(c ...xpv1.Condition) {
+	r.Status.SetConditions(c...)
+}
+
+// GetManagementPolicies returns the management policies.
+func (r *UserRateLimit) GetManagementPolicies() xpv1.ManagementPolicies {
+	return r.Spec.ManagementPolicies
+}
+
+// SetManagementPolicies sets the management policies.
+func (r *UserRateLimit) SetManagementPolicies(p xpv1.ManagementPolicies) {
+	r.Spec.ManagementPolicies = p
+}
+
+// GetWriteConnectionSecretToReference returns the write connection secret to reference.
+func (r *UserRateLimit) GetWriteConnectionSecretToReference() *xpv1.LocalSecretReference {
+	return r.Spec.WriteConnectionSecretToReference
+}
+
+// SetWriteConnectionSecretToReference sets the write connection secret to reference.
+func (r *UserRateLimit) SetWriteConnectionSecretToReference(s *xpv1.LocalSecretReference) {
+	r.Spec.WriteConnectionSecretToReference = s
+}
+
+// +kubebuilder:object:root=true
+
+// UserRateLimitList contains a list of UserRateLimit
+type UserRateLimitList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []UserRateLimit `json:"items"`
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"fmt"
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Condition types and reasons for ProviderConfigs.
+const (
+	// TypeCredentialMismatch indicates whether the access token belongs to
+	// another user than the ProviderConfig's UserID.
+	TypeCredentialMismatch xpv1.ConditionType = "CredentialMismatch"
+
+	ReasonUserIDMismatch xpv1.ConditionReason = "UserIDMismatch"
+	ReasonUserIDMatches  xpv1.ConditionReason = "UserIDMatches"
+
+	// TypeInsecureTLS indicates whether verification of the homeserver's TLS
+	// certificate is disabled by InsecureSkipVerify.
+	TypeInsecureTLS xpv1.ConditionType = "InsecureTLS"
+
+	ReasonTLSVerificationDisabled xpv1.ConditionReason = "TLSVerificationDisabled"
+	ReasonTLSVerificationEnabled  xpv1.ConditionReason = "TLSVerificationEnabled"
+)
+
+// CredentialMismatch returns a condition indicating that the access token
+// belongs to tokenUserID rather than the configured userID.
+func CredentialMismatch(userID, tokenUserID string) xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeCredentialMismatch,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonUserIDMismatch,
+		Message:            fmt.Sprintf("The access token belongs to %s, not to the configured userID %s", tokenUserID, userID),
+	}
+}
+
+// InsecureTLS returns a condition indicating that the homeserver's TLS
+// certificate isn't verified.
+func InsecureTLS() xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeInsecureTLS,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonTLSVerificationDisabled,
+		Message:            "insecureSkipVerify disables verification of the homeserver's TLS certificate; do not use it in production",
+	}
+}
+
+// SecureTLS returns a condition indicating that the homeserver's TLS
+// certificate is verified.
+func SecureTLS() xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeInsecureTLS,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonTLSVerificationEnabled,
+	}
+}
+
+// CredentialsMatch returns a condition indicating that the access token
+// belongs to the configured user.
+func CredentialsMatch() xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeCredentialMismatch,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonUserIDMatches,
+	}
+}
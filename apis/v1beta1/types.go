@@ -19,11 +19,14 @@ package v1beta1
 import (
 	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // A ProviderConfigSpec defines the desired state of a ProviderConfig.
 type ProviderConfigSpec struct {
-	// Credentials required to authenticate to this provider.
+	// Credentials required to authenticate to this provider: either an
+	// access token, or a JSON object with the username and password to log
+	// in with, such as {"username": "bot", "password": "..."}.
 	Credentials ProviderCredentials `json:"credentials"`
 
 	// HomeserverURL is the base URL for the Matrix homeserver.
@@ -37,15 +40,41 @@ type ProviderConfigSpec struct {
 	// +kubebuilder:validation:Pattern="^https?://.*"
 	AdminAPIURL *string `json:"adminAPIURL,omitempty"`
 
+	// AdminAPIPathPrefix is prepended to the path of every admin API request,
+	// for reverse proxies that mount the admin API under a custom path. For
+	// example, a prefix of /internal sends user lookups to
+	// /internal/_synapse/admin/v2/users/<userID>.
+	// +kubebuilder:validation:Pattern="^/.*"
+	AdminAPIPathPrefix *string `json:"adminAPIPathPrefix,omitempty"`
+
 	// UserID is the Matrix user ID for the provider.
 	// Format: @localpart:domain
 	// +kubebuilder:validation:Pattern="^@[a-zA-Z0-9._=/-]+:[a-zA-Z0-9.-]+$"
 	UserID *string `json:"userID,omitempty"`
 
-	// DeviceID is the device ID to use for authentication.
+	// DeviceID is the device ID to use for authentication. When the
+	// credentials hold a username and password, it is the device logged in
+	// to; otherwise each login reuses the device of the provider's first.
 	DeviceID *string `json:"deviceID,omitempty"`
 
+	// InitialDeviceDisplayName is the display name given to the device the
+	// provider creates when it logs in, so its session can be identified in
+	// the user's device list. Defaults to "Crossplane provider-matrix".
+	InitialDeviceDisplayName *string `json:"initialDeviceDisplayName,omitempty"`
+
+	// BotDisplayName is the display name to give the provider's own user.
+	// Requires UserID to be set.
+	BotDisplayName *string `json:"botDisplayName,omitempty"`
+
+	// BotAvatarURL is the mxc:// URL of the avatar to give the provider's own
+	// user. Requires UserID to be set.
+	// +kubebuilder:validation:Pattern="^mxc://.*"
+	BotAvatarURL *string `json:"botAvatarURL,omitempty"`
+
 	// ServerType indicates the type of Matrix server (for API compatibility).
+	// With auto, the homeserver is asked which it is when admin mode is
+	// enabled. Admin operations only Synapse offers are refused on other
+	// homeservers.
 	// +kubebuilder:validation:Enum=synapse;dendrite;conduit;auto
 	// +kubebuilder:default="auto"
 	ServerType *string `json:"serverType,omitempty"`
@@ -53,8 +82,82 @@ type ProviderConfigSpec struct {
 	// AdminMode enables administrative operations when supported.
 	// +kubebuilder:default=false
 	AdminMode *bool `json:"adminMode,omitempty"`
+
+	// RegistrationSharedSecretRef references a Secret key holding Synapse's
+	// registration shared secret. When set, users are created through the
+	// shared-secret registration API, which doesn't need an admin access
+	// token.
+	RegistrationSharedSecretRef *xpv1.SecretKeySelector `json:"registrationSharedSecretRef,omitempty"`
+
+	// UserExternalNameFormat controls the external name given to the Users
+	// this provider creates: the full user ID, or only its localpart. Users
+	// are found by either form whatever this is set to.
+	// +kubebuilder:validation:Enum=UserID;Localpart
+	// +kubebuilder:default="UserID"
+	UserExternalNameFormat *string `json:"userExternalNameFormat,omitempty"`
+
+	// CaseInsensitiveLocalparts looks a User up by its lowercased localpart
+	// when it isn't found as given. Localparts are case-sensitive, but
+	// homeservers such as Synapse lowercase them at registration, so a user
+	// asked for as @Alice:example.com may exist as @alice:example.com. The
+	// User's external name is corrected to the stored user ID.
+	// +kubebuilder:default=false
+	CaseInsensitiveLocalparts *bool `json:"caseInsensitiveLocalparts,omitempty"`
+
+	// ResyncInterval is how often resources using this ProviderConfig are
+	// re-observed to check them for drift, overriding the provider's --poll
+	// interval. Set a longer interval to resync slow homeservers less often.
+	ResyncInterval *metav1.Duration `json:"resyncInterval,omitempty"`
+
+	// MaxRetries is how often a request the homeserver rate limited or failed
+	// with a transient server error is retried. Set it to 0 to never retry.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=3
+	MaxRetries *int `json:"maxRetries,omitempty"`
+
+	// RetryMaxWait bounds the total time a request waits between retries,
+	// such as 30s. A request the homeserver asks to wait longer fails instead.
+	RetryMaxWait *metav1.Duration `json:"retryMaxWait,omitempty"`
+
+	// RequestTimeout bounds each attempt at a request to the homeserver,
+	// such as 1m, and must be positive. Waiting between retries isn't
+	// counted. A request can't outlast its reconcile, which is limited to
+	// a minute. Defaults to 30s.
+	RequestTimeout *metav1.Duration `json:"requestTimeout,omitempty"`
+
+	// ClientCertificateSecretRef references a Secret holding a TLS client
+	// certificate and its private key, PEM encoded under the tls.crt and
+	// tls.key keys of a kubernetes.io/tls Secret. The certificate is
+	// presented to homeservers, or reverse proxies in front of them, that
+	// require mutual TLS.
+	ClientCertificateSecretRef *xpv1.SecretReference `json:"clientCertificateSecretRef,omitempty"`
+
+	// CACertificateSecretRef references a Secret key holding PEM encoded CA
+	// certificates to trust in addition to the system's, for homeservers
+	// whose certificates are issued by an internal CA.
+	CACertificateSecretRef *xpv1.SecretKeySelector `json:"caCertificateSecretRef,omitempty"`
+
+	// InsecureSkipVerify disables verification of the homeserver's TLS
+	// certificate. Only use it for testing: anyone who can intercept the
+	// connection can read the provider's access token. A warning is logged
+	// whenever a client is created with it, and the ProviderConfig reports
+	// an InsecureTLS condition.
+	// +kubebuilder:default=false
+	InsecureSkipVerify *bool `json:"insecureSkipVerify,omitempty"`
+
+	// ProxyURL is an HTTP, HTTPS or SOCKS5 proxy, such as
+	// http://proxy.example.com:3128, that requests to the homeserver are
+	// sent through. When unset, the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	// environment variables of the provider are honored.
+	ProxyURL *string `json:"proxyURL,omitempty"`
 }
 
+// External name formats for Users.
+const (
+	UserExternalNameUserID    = "UserID"
+	UserExternalNameLocalpart = "Localpart"
+)
+
 // ProviderCredentials required to authenticate.
 type ProviderCredentials struct {
 	// Source of the provider credentials.
@@ -67,14 +170,25 @@ type ProviderCredentials struct {
 // A ProviderConfigStatus reflects the observed state of a ProviderConfig.
 type ProviderConfigStatus struct {
 	xpv1.ProviderConfigStatus `json:",inline"`
+
+	// ObservedServerType is the type of homeserver the provider is talking
+	// to, such as synapse or dendrite, if the homeserver says
+	ObservedServerType string `json:"observedServerType,omitempty"`
+
+	// ServerVersion is the version of the homeserver software, if the
+	// homeserver says
+	ServerVersion string `json:"serverVersion,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 
 // A ProviderConfig configures a Matrix provider.
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="SERVER",type="string",JSONPath=".status.observedServerType"
+// +kubebuilder:printcolumn:name="VERSION",type="string",JSONPath=".status.serverVersion"
 // +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
 // +kubebuilder:printcolumn:name="SECRET-NAME",type="string",JSONPath=".spec.credentials.secretRef.name",priority=1
+// +kubebuilder:resource:scope=Cluster
 type ProviderConfig struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -83,6 +197,27 @@ type ProviderConfig struct {
 	Status ProviderConfigStatus `json:"status,omitempty"`
 }
 
+// GetProviderConfigSpec returns the spec of the ProviderConfig.
+func (pc *ProviderConfig) GetProviderConfigSpec() *ProviderConfigSpec {
+	return &pc.Spec
+}
+
+// GetProviderConfigStatus returns the status of the ProviderConfig.
+func (pc *ProviderConfig) GetProviderConfigStatus() *ProviderConfigStatus {
+	return &pc.Status
+}
+
+// A ProviderConfigObject is a ProviderConfig of either scope: the cluster
+// scoped ProviderConfig of this package, or the namespaced ProviderConfig
+// that namespaced managed resources use.
+type ProviderConfigObject interface {
+	metav1.Object
+	runtime.Object
+
+	GetProviderConfigSpec() *ProviderConfigSpec
+	GetProviderConfigStatus() *ProviderConfigStatus
+}
+
 // +kubebuilder:object:root=true
 
 // ProviderConfigList contains a list of ProviderConfig.
@@ -21,6 +21,9 @@ limitations under the License.
 package v1beta1
 
 import (
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -92,6 +95,11 @@ func (in *ProviderConfigSpec) DeepCopyInto(out *ProviderConfigSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.AdminAPIPathPrefix != nil {
+		in, out := &in.AdminAPIPathPrefix, &out.AdminAPIPathPrefix
+		*out = new(string)
+		**out = **in
+	}
 	if in.UserID != nil {
 		in, out := &in.UserID, &out.UserID
 		*out = new(string)
@@ -102,6 +110,21 @@ func (in *ProviderConfigSpec) DeepCopyInto(out *ProviderConfigSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.InitialDeviceDisplayName != nil {
+		in, out := &in.InitialDeviceDisplayName, &out.InitialDeviceDisplayName
+		*out = new(string)
+		**out = **in
+	}
+	if in.BotDisplayName != nil {
+		in, out := &in.BotDisplayName, &out.BotDisplayName
+		*out = new(string)
+		**out = **in
+	}
+	if in.BotAvatarURL != nil {
+		in, out := &in.BotAvatarURL, &out.BotAvatarURL
+		*out = new(string)
+		**out = **in
+	}
 	if in.ServerType != nil {
 		in, out := &in.ServerType, &out.ServerType
 		*out = new(string)
@@ -112,6 +135,61 @@ func (in *ProviderConfigSpec) DeepCopyInto(out *ProviderConfigSpec) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.RegistrationSharedSecretRef != nil {
+		in, out := &in.RegistrationSharedSecretRef, &out.RegistrationSharedSecretRef
+		*out = new(xpv1.SecretKeySelector)
+		**out = **in
+	}
+	if in.UserExternalNameFormat != nil {
+		in, out := &in.UserExternalNameFormat, &out.UserExternalNameFormat
+		*out = new(string)
+		**out = **in
+	}
+	if in.CaseInsensitiveLocalparts != nil {
+		in, out := &in.CaseInsensitiveLocalparts, &out.CaseInsensitiveLocalparts
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ResyncInterval != nil {
+		in, out := &in.ResyncInterval, &out.ResyncInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.MaxRetries != nil {
+		in, out := &in.MaxRetries, &out.MaxRetries
+		*out = new(int)
+		**out = **in
+	}
+	if in.RetryMaxWait != nil {
+		in, out := &in.RetryMaxWait, &out.RetryMaxWait
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.RequestTimeout != nil {
+		in, out := &in.RequestTimeout, &out.RequestTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.ClientCertificateSecretRef != nil {
+		in, out := &in.ClientCertificateSecretRef, &out.ClientCertificateSecretRef
+		*out = new(xpv1.SecretReference)
+		**out = **in
+	}
+	if in.CACertificateSecretRef != nil {
+		in, out := &in.CACertificateSecretRef, &out.CACertificateSecretRef
+		*out = new(xpv1.SecretKeySelector)
+		**out = **in
+	}
+	if in.InsecureSkipVerify != nil {
+		in, out := &in.InsecureSkipVerify, &out.InsecureSkipVerify
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ProxyURL != nil {
+		in, out := &in.ProxyURL, &out.ProxyURL
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProviderConfigSpec.
@@ -0,0 +1,81 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/powerlevel/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/apis/v1beta1"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	"github.com/pkg/errors"
+	"io"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+// exportPowerLevels writes a PowerLevel manifest holding the current power
+// levels of a room, as a starting point for adopting them
+func exportPowerLevels(ctx context.Context, w io.Writer, c clients.Client, roomID, name, providerConfig string) error {
+	content, err := c.ExportPowerLevels(ctx, roomID)
+	if err != nil {
+		return err
+	}
+
+	pl := &v1alpha1.PowerLevel{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+			Kind:       v1alpha1.PowerLevelKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v1alpha1.PowerLevelSpec{
+			ForProvider: v1alpha1.PowerLevelParameters{
+				RoomID:        roomID,
+				Users:         content.Users,
+				Events:        content.Events,
+				EventsDefault: content.EventsDefault,
+				StateDefault:  content.StateDefault,
+				UsersDefault:  content.UsersDefault,
+				Ban:           content.Ban,
+				Kick:          content.Kick,
+				Redact:        content.Redact,
+				Invite:        content.Invite,
+			},
+		},
+	}
+	pl.SetProviderConfigReference(&xpv1.ProviderConfigReference{
+		Kind: v1beta1.ProviderConfigKind,
+		Name: providerConfig,
+	})
+
+	// Leave out the empty status and creation timestamp a typed object
+	// would be written with
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pl)
+	if err != nil {
+		return errors.Wrap(err, "cannot convert PowerLevel")
+	}
+	delete(obj, "status")
+	delete(obj["metadata"].(map[string]interface{}), "creationTimestamp")
+
+	out, err := yaml.Marshal(obj)
+	if err != nil {
+		return errors.Wrap(err, "cannot marshal PowerLevel")
+	}
+	_, err = w.Write(out)
+	return err
+}
@@ -20,11 +20,23 @@ import (
 	"context"
 	"github.com/crossplane-contrib/provider-matrix/apis"
 	"github.com/crossplane-contrib/provider-matrix/apis/v1beta1"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane-contrib/provider-matrix/internal/controller/accountdata"
+	"github.com/crossplane-contrib/provider-matrix/internal/controller/device"
+	"github.com/crossplane-contrib/provider-matrix/internal/controller/media"
 	"github.com/crossplane-contrib/provider-matrix/internal/controller/powerlevel"
+	"github.com/crossplane-contrib/provider-matrix/internal/controller/registrationtoken"
 	"github.com/crossplane-contrib/provider-matrix/internal/controller/room"
 	"github.com/crossplane-contrib/provider-matrix/internal/controller/roomalias"
+	"github.com/crossplane-contrib/provider-matrix/internal/controller/roomhistorypurge"
+	"github.com/crossplane-contrib/provider-matrix/internal/controller/roommembership"
+	"github.com/crossplane-contrib/provider-matrix/internal/controller/roomtag"
+	"github.com/crossplane-contrib/provider-matrix/internal/controller/servernotice"
+	"github.com/crossplane-contrib/provider-matrix/internal/controller/space"
 	"github.com/crossplane-contrib/provider-matrix/internal/controller/user"
+	"github.com/crossplane-contrib/provider-matrix/internal/controller/userratelimit"
 	"github.com/crossplane-contrib/provider-matrix/internal/features"
+	"github.com/crossplane-contrib/provider-matrix/internal/forcedelete"
 	"github.com/crossplane-contrib/provider-matrix/internal/tracing"
 	"github.com/crossplane-contrib/provider-matrix/internal/version"
 	"github.com/crossplane/crossplane-runtime/v2/pkg/controller"
@@ -32,6 +44,7 @@ import (
 	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
 	"github.com/crossplane/crossplane-runtime/v2/pkg/ratelimiter"
 	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	"github.com/pkg/errors"
 	"gopkg.in/alecthomas/kingpin.v2"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -43,6 +56,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -56,8 +71,24 @@ func main() {
 		leaderElection             = app.Flag("leader-election", "Use leader election for the controller manager.").Short('l').Default("false").OverrideDefaultFromEnvar("LEADER_ELECTION").Bool()
 		namespace                  = app.Flag("namespace", "Namespace used to set as default scope in default secret store config.").Default("crossplane-system").Envar("POD_NAMESPACE").String()
 		enableExternalSecretStores = app.Flag("enable-external-secret-stores", "Enable support for ExternalSecretStores.").Default("false").Envar("ENABLE_EXTERNAL_SECRET_STORES").Bool()
+		enableManagementPolicies   = app.Flag("enable-management-policies", "Enable support for Management Policies.").Default("true").Envar("ENABLE_MANAGEMENT_POLICIES").Bool()
+		forceDeleteOnUnreachable   = app.Flag("force-delete-on-unreachable", "Remove a deleted resource's finalizer after this many failed attempts to reach its homeserver, leaving anything on the homeserver behind. 0 never gives up.").Default("0").Envar("FORCE_DELETE_ON_UNREACHABLE").Int()
+		enableControllers          = app.Flag("enable-controllers", "Comma-separated list of the controllers to run: "+strings.Join(controllerNames(), ", ")+".").Default(strings.Join(controllerNames(), ",")).Envar("ENABLE_CONTROLLERS").String()
+
+		_                    = app.Command("run", "Run the provider.").Default()
+		exportCmd            = app.Command("export-power-levels", "Print a PowerLevel manifest holding the current power levels of a room.")
+		exportRoomID         = exportCmd.Arg("room-id", "ID of the room to export the power levels of.").Required().String()
+		exportHomeserverURL  = exportCmd.Flag("homeserver-url", "URL of the room's homeserver.").Required().String()
+		exportAccessToken    = exportCmd.Flag("access-token", "Access token of a user in the room.").Envar("MATRIX_ACCESS_TOKEN").Required().String()
+		exportName           = exportCmd.Flag("name", "Name of the PowerLevel.").Required().String()
+		exportProviderConfig = exportCmd.Flag("provider-config", "Name of the ProviderConfig the PowerLevel uses.").Default("default").String()
 	)
-	kingpin.MustParse(app.Parse(os.Args[1:]))
+	if kingpin.MustParse(app.Parse(os.Args[1:])) == exportCmd.FullCommand() {
+		c, err := clients.NewClient(&clients.Config{HomeserverURL: *exportHomeserverURL, AccessToken: *exportAccessToken})
+		kingpin.FatalIfError(err, "Cannot create Matrix client")
+		kingpin.FatalIfError(exportPowerLevels(context.Background(), os.Stdout, c, *exportRoomID, *exportName, *exportProviderConfig), "Cannot export power levels")
+		return
+	}
 
 	zl := zap.New(zap.UseDevMode(*debug))
 	log := logging.NewLogrLogger(zl.WithName("provider-matrix"))
@@ -82,6 +113,8 @@ func main() {
 		"leader-election", *leaderElection,
 		"namespace", *namespace,
 		"external-secret-stores", *enableExternalSecretStores,
+		"management-policies", *enableManagementPolicies,
+		"controllers", *enableControllers,
 		"debug-mode", *debug)
 
 	cfg, err := ctrl.GetConfig()
@@ -99,6 +132,10 @@ func main() {
 		o.Features.Enable(features.EnableAlphaExternalSecretStores)
 		log.Info("Alpha feature enabled", "flag", features.EnableAlphaExternalSecretStores)
 	}
+	if *enableManagementPolicies {
+		o.Features.Enable(feature.EnableBetaManagementPolicies)
+		log.Info("Beta feature enabled", "flag", feature.EnableBetaManagementPolicies)
+	}
 
 	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
 		LeaderElection:             *leaderElection,
@@ -119,10 +156,18 @@ func main() {
 		log.Debug("Cannot create default ProviderConfig", "error", err)
 	}
 
-	kingpin.FatalIfError(user.Setup(mgr, o), "Cannot setup User controller")
-	kingpin.FatalIfError(room.Setup(mgr, o), "Cannot setup Room controller")
-	kingpin.FatalIfError(powerlevel.Setup(mgr, o), "Cannot setup PowerLevel controller")
-	kingpin.FatalIfError(roomalias.Setup(mgr, o), "Cannot setup RoomAlias controller")
+	if *forceDeleteOnUnreachable > 0 {
+		forcedelete.SetMaxAttempts(*forceDeleteOnUnreachable)
+		log.Info("Deleted resources will be released after their homeserver is unreachable", "attempts", *forceDeleteOnUnreachable)
+	}
+
+	enabled, err := enabledControllers(*enableControllers)
+	kingpin.FatalIfError(err, "Cannot parse enabled controllers")
+	for _, c := range controllers {
+		if enabled[c.name] {
+			kingpin.FatalIfError(c.setup(mgr, o), "Cannot setup %s controller", c.kind)
+		}
+	}
 
 	kingpin.FatalIfError(mgr.AddHealthzCheck("healthz", healthz.Ping), "Cannot add health check")
 	kingpin.FatalIfError(mgr.AddReadyzCheck("readyz", healthz.Ping), "Cannot add ready check")
@@ -130,6 +175,61 @@ func main() {
 	kingpin.FatalIfError(mgr.Start(ctrl.SetupSignalHandler()), "Cannot start controller manager")
 }
 
+// controllers are the controllers the provider can run, in the order they are
+// set up
+var controllers = []struct {
+	name  string
+	kind  string
+	setup func(ctrl.Manager, controller.Options) error
+}{
+	{name: "user", kind: "User", setup: user.Setup},
+	{name: "room", kind: "Room", setup: room.Setup},
+	{name: "space", kind: "Space", setup: space.Setup},
+	{name: "powerlevel", kind: "PowerLevel", setup: powerlevel.Setup},
+	{name: "roomalias", kind: "RoomAlias", setup: roomalias.Setup},
+	{name: "roommembership", kind: "RoomMembership", setup: roommembership.Setup},
+	{name: "userratelimit", kind: "UserRateLimit", setup: userratelimit.Setup},
+	{name: "device", kind: "Device", setup: device.Setup},
+	{name: "roomhistorypurge", kind: "RoomHistoryPurge", setup: roomhistorypurge.Setup},
+	{name: "media", kind: "Media", setup: media.Setup},
+	{name: "servernotice", kind: "ServerNotice", setup: servernotice.Setup},
+	{name: "registrationtoken", kind: "RegistrationToken", setup: registrationtoken.Setup},
+	{name: "accountdata", kind: "AccountData", setup: accountdata.Setup},
+	{name: "roomtag", kind: "RoomTag", setup: roomtag.Setup},
+	{name: "namespaced-user", kind: "namespaced User", setup: user.SetupNamespaced},
+	{name: "namespaced-room", kind: "namespaced Room", setup: room.SetupNamespaced},
+	{name: "namespaced-powerlevel", kind: "namespaced PowerLevel", setup: powerlevel.SetupNamespaced},
+	{name: "namespaced-roomalias", kind: "namespaced RoomAlias", setup: roomalias.SetupNamespaced},
+}
+
+// controllerNames returns the names of the controllers the provider can run
+func controllerNames() []string {
+	names := make([]string, len(controllers))
+	for i, c := range controllers {
+		names[i] = c.name
+	}
+	return names
+}
+
+// enabledControllers parses a comma-separated list of controller names
+func enabledControllers(list string) (map[string]bool, error) {
+	enabled := map[string]bool{}
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !slices.Contains(controllerNames(), name) {
+			return nil, errors.Errorf("unknown controller %q, expected one of %s", name, strings.Join(controllerNames(), ", "))
+		}
+		enabled[name] = true
+	}
+	if len(enabled) == 0 {
+		return nil, errors.New("no controllers enabled")
+	}
+	return enabled, nil
+}
+
 func createDefaultProviderConfig(ctx context.Context, mgr ctrl.Manager, namespace string) error {
 	pc := &v1beta1.ProviderConfig{
 		ObjectMeta: metav1.ObjectMeta{
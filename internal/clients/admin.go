@@ -18,14 +18,33 @@ package clients
 
 import (
 	"bytes"
+	"cmp"
 	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"github.com/pkg/errors"
 	"io"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Admin API endpoints a user's admin status can be set through.
+const (
+	// adminEndpointV1 is the dedicated /_synapse/admin/v1/users/<userID>/admin
+	// toggle
+	adminEndpointV1 = "v1"
+
+	// adminEndpointV2 is the admin field of the /_synapse/admin/v2/users
+	// PUT
+	adminEndpointV2 = "v2"
 )
 
 // adminClient handles Matrix admin API operations (primarily for Synapse)
@@ -33,6 +52,11 @@ type adminClient struct {
 	config     *Config
 	httpClient *http.Client
 	baseURL    string
+	pathPrefix string
+
+	// preferAdminV2 is set once the v2 users PUT has set a user's admin
+	// status, so that it is tried before the v1 toggle from then on
+	preferAdminV2 atomic.Bool
 }
 
 // newAdminClient creates a new admin API client
@@ -46,6 +70,7 @@ func newAdminClient(config *Config) *adminClient {
 		config:     config,
 		httpClient: config.HTTPClient,
 		baseURL:    baseURL,
+		pathPrefix: strings.TrimSuffix(config.AdminAPIPathPrefix, "/"),
 	}
 }
 
@@ -60,7 +85,7 @@ func (c *adminClient) makeRequest(ctx context.Context, method, path string, body
 		bodyReader = bytes.NewReader(jsonBody)
 	}
 
-	url := fmt.Sprintf("%s%s", strings.TrimSuffix(c.baseURL, "/"), path)
+	url := fmt.Sprintf("%s%s%s", strings.TrimSuffix(c.baseURL, "/"), c.pathPrefix, path)
 	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create request")
@@ -87,9 +112,15 @@ func (c *adminClient) handleResponse(resp *http.Response, target interface{}) er
 		}
 	}()
 
+	if resp.StatusCode == http.StatusServiceUnavailable {
+		d, _ := retryAfter(resp)
+		body, _ := io.ReadAll(resp.Body)
+		return &UnavailableError{RetryAfter: d, Err: newAPIError(resp.StatusCode, body)}
+	}
+
 	if resp.StatusCode >= 400 {
 		body, _ := io.ReadAll(resp.Body)
-		return errors.Errorf("admin API request failed with status %d: %s", resp.StatusCode, string(body))
+		return newAPIError(resp.StatusCode, body)
 	}
 
 	if target != nil {
@@ -101,6 +132,55 @@ func (c *adminClient) handleResponse(resp *http.Response, target interface{}) er
 	return nil
 }
 
+// newAPIError returns the error for an admin API response with the given
+// status and body, picking the Matrix error code out of the body if it has one
+func newAPIError(statusCode int, body []byte) *APIError {
+	var content struct {
+		ErrCode string `json:"errcode"`
+	}
+	_ = json.Unmarshal(body, &content)
+	return &APIError{StatusCode: statusCode, ErrCode: content.ErrCode, Body: string(body)}
+}
+
+// serverVersion returns the version of Synapse the homeserver runs, failing
+// if it doesn't serve Synapse's admin API
+func (c *adminClient) serverVersion(ctx context.Context) (string, error) {
+	resp, err := c.makeRequest(ctx, "GET", "/_synapse/admin/v1/server_version", nil)
+	if err != nil {
+		return "", err
+	}
+
+	var version struct {
+		ServerVersion string `json:"server_version"`
+	}
+	if err := c.handleResponse(resp, &version); err != nil {
+		return "", err
+	}
+	return version.ServerVersion, nil
+}
+
+// synapseUser is a user as Synapse's admin API reports it, with when the user
+// was created in seconds and last seen in milliseconds
+type synapseUser struct {
+	User
+	CreationTS *int64 `json:"creation_ts"`
+	LastSeenTS *int64 `json:"last_seen_ts"`
+}
+
+// user returns the user with its timestamps converted
+func (u *synapseUser) user() *User {
+	user := u.User
+	if u.CreationTS != nil {
+		created := time.Unix(*u.CreationTS, 0)
+		user.CreationTime = &created
+	}
+	if u.LastSeenTS != nil {
+		lastSeen := time.UnixMilli(*u.LastSeenTS)
+		user.LastSeenTime = &lastSeen
+	}
+	return &user
+}
+
 // User admin operations
 
 // createUser creates a new user via admin API
@@ -112,12 +192,93 @@ func (c *adminClient) createUser(ctx context.Context, userSpec *UserSpec) (*User
 		return nil, err
 	}
 
-	var user User
+	var user synapseUser
 	if err := c.handleResponse(resp, &user); err != nil {
 		return nil, err
 	}
 
-	return &user, nil
+	return user.user(), nil
+}
+
+// registerUser creates a user with Synapse's shared-secret registration API.
+// A random password is chosen if the spec doesn't give one, since the API
+// requires it.
+func (c *adminClient) registerUser(ctx context.Context, userSpec *UserSpec, sharedSecret string) (*User, error) {
+	username := userSpec.Localpart
+	if username == "" {
+		username, _, _ = strings.Cut(strings.TrimPrefix(userSpec.UserID, "@"), ":")
+	}
+	if username == "" {
+		return nil, errors.New("registering a user requires a user ID or localpart")
+	}
+
+	password := userSpec.Password
+	if password == "" {
+		random := make([]byte, 32)
+		if _, err := rand.Read(random); err != nil {
+			return nil, errors.Wrap(err, "failed to generate password")
+		}
+		password = hex.EncodeToString(random)
+	}
+
+	resp, err := c.makeRequest(ctx, "GET", "/_synapse/admin/v1/register", nil)
+	if err != nil {
+		return nil, err
+	}
+	var nonce struct {
+		Nonce string `json:"nonce"`
+	}
+	if err := c.handleResponse(resp, &nonce); err != nil {
+		return nil, errors.Wrap(err, "failed to get registration nonce")
+	}
+
+	request := map[string]interface{}{
+		"nonce":    nonce.Nonce,
+		"username": username,
+		"password": password,
+		"admin":    userSpec.Admin,
+		"mac":      registrationMAC(sharedSecret, nonce.Nonce, username, password, userSpec.Admin, userSpec.UserType),
+	}
+	if userSpec.DisplayName != "" {
+		request["displayname"] = userSpec.DisplayName
+	}
+	if userSpec.UserType != "" {
+		request["user_type"] = userSpec.UserType
+	}
+
+	resp, err = c.makeRequest(ctx, "POST", "/_synapse/admin/v1/register", request)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		UserID string `json:"user_id"`
+	}
+	if err := c.handleResponse(resp, &result); err != nil {
+		return nil, errors.Wrap(err, "failed to register user")
+	}
+
+	return &User{
+		UserID:      result.UserID,
+		DisplayName: userSpec.DisplayName,
+		Admin:       userSpec.Admin,
+		UserType:    userSpec.UserType,
+	}, nil
+}
+
+// registrationMAC returns the HMAC-SHA1 that authenticates a shared-secret
+// registration request
+func registrationMAC(sharedSecret, nonce, username, password string, admin bool, userType string) string {
+	role := "notadmin"
+	if admin {
+		role = "admin"
+	}
+
+	mac := hmac.New(sha1.New, []byte(sharedSecret))
+	mac.Write([]byte(nonce + "\x00" + username + "\x00" + password + "\x00" + role))
+	if userType != "" {
+		mac.Write([]byte("\x00" + userType))
+	}
+	return hex.EncodeToString(mac.Sum(nil))
 }
 
 // getUser retrieves user information via admin API
@@ -129,29 +290,349 @@ func (c *adminClient) getUser(ctx context.Context, userID string) (*User, error)
 		return nil, err
 	}
 
-	var user User
+	var user synapseUser
 	if err := c.handleResponse(resp, &user); err != nil {
 		return nil, err
 	}
 
-	return &user, nil
+	return user.user(), nil
 }
 
-// updateUser updates user information via admin API
+// updateUser updates user information via admin API. Some Synapse versions
+// refuse the admin field in the users PUT, so admin status is left out of it
+// and set through setUserAdmin if it differs.
 func (c *adminClient) updateUser(ctx context.Context, userID string, userSpec *UserSpec) (*User, error) {
 	path := fmt.Sprintf("/_synapse/admin/v2/users/%s", url.PathEscape(userID))
 
-	resp, err := c.makeRequest(ctx, "PUT", path, userSpec)
+	body, err := withoutAdmin(userSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.makeRequest(ctx, "PUT", path, body)
 	if err != nil {
 		return nil, err
 	}
 
-	var user User
+	var user synapseUser
 	if err := c.handleResponse(resp, &user); err != nil {
 		return nil, err
 	}
 
-	return &user, nil
+	if user.Admin != userSpec.Admin {
+		if err := c.setUserAdmin(ctx, userID, userSpec.Admin); err != nil {
+			return nil, err
+		}
+		user.Admin = userSpec.Admin
+	}
+
+	return user.user(), nil
+}
+
+// withoutAdmin returns the users PUT body for a user spec, without its admin
+// field
+func withoutAdmin(userSpec *UserSpec) (map[string]interface{}, error) {
+	jsonSpec, err := json.Marshal(userSpec)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal user")
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(jsonSpec, &body); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal user")
+	}
+	delete(body, "admin")
+	return body, nil
+}
+
+// setUserAdmin sets whether a user is a server admin. Synapse versions differ
+// in which of the v1 admin toggle and the admin field of the v2 users PUT they
+// allow, so the other is tried if the homeserver refuses one. The endpoint
+// that worked is logged, and tried first next time.
+func (c *adminClient) setUserAdmin(ctx context.Context, userID string, admin bool) error {
+	endpoints := []string{adminEndpointV1, adminEndpointV2}
+	if c.preferAdminV2.Load() {
+		endpoints = []string{adminEndpointV2, adminEndpointV1}
+	}
+
+	var refused []string
+	for _, endpoint := range endpoints {
+		path := fmt.Sprintf("/_synapse/admin/v1/users/%s/admin", url.PathEscape(userID))
+		if endpoint == adminEndpointV2 {
+			path = fmt.Sprintf("/_synapse/admin/v2/users/%s", url.PathEscape(userID))
+		}
+
+		resp, err := c.makeRequest(ctx, "PUT", path, map[string]interface{}{
+			"admin": admin,
+		})
+		if err == nil {
+			err = c.handleResponse(resp, nil)
+		}
+		if err == nil {
+			c.preferAdminV2.Store(endpoint == adminEndpointV2)
+			if c.config.Logger != nil {
+				c.config.Logger.Debug("Set admin status", "user", userID, "admin", admin, "endpoint", endpoint)
+			}
+			return nil
+		}
+
+		// Only a refusal is worth trying the other endpoint for
+		if _, unavailable := IsServerUnavailable(err); unavailable || IsUnreachable(err) {
+			return err
+		}
+		refused = append(refused, fmt.Sprintf("%s: %s", endpoint, err))
+	}
+
+	return errors.Errorf("cannot set admin status of %s: %s", userID, strings.Join(refused, "; "))
+}
+
+// lockUser sets whether a user is locked. Only the locked field is sent, so
+// the user's other settings are left alone.
+func (c *adminClient) lockUser(ctx context.Context, userID string, locked bool) error {
+	path := fmt.Sprintf("/_synapse/admin/v2/users/%s", url.PathEscape(userID))
+
+	body := map[string]interface{}{
+		"locked": locked,
+	}
+
+	resp, err := c.makeRequest(ctx, "PUT", path, body)
+	if err != nil {
+		return err
+	}
+
+	return c.handleResponse(resp, nil)
+}
+
+// shadowBanUser shadow-bans a user: their messages are accepted but never
+// delivered to anyone else
+func (c *adminClient) shadowBanUser(ctx context.Context, userID string) error {
+	path := fmt.Sprintf("/_synapse/admin/v1/users/%s/shadow_ban", url.PathEscape(userID))
+
+	resp, err := c.makeRequest(ctx, "POST", path, map[string]interface{}{})
+	if err != nil {
+		return err
+	}
+
+	return c.handleResponse(resp, nil)
+}
+
+// unshadowBanUser lifts a user's shadow-ban
+func (c *adminClient) unshadowBanUser(ctx context.Context, userID string) error {
+	path := fmt.Sprintf("/_synapse/admin/v1/users/%s/shadow_ban", url.PathEscape(userID))
+
+	resp, err := c.makeRequest(ctx, "DELETE", path, nil)
+	if err != nil {
+		return err
+	}
+
+	return c.handleResponse(resp, nil)
+}
+
+// listDevices lists a user's devices via admin API
+func (c *adminClient) listDevices(ctx context.Context, userID string) ([]Device, error) {
+	path := fmt.Sprintf("/_synapse/admin/v2/users/%s/devices", url.PathEscape(userID))
+
+	resp, err := c.makeRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Synapse reports when a device was last seen in milliseconds
+	var result struct {
+		Devices []struct {
+			DeviceID    string `json:"device_id"`
+			DisplayName string `json:"display_name"`
+			LastSeenIP  string `json:"last_seen_ip"`
+			LastSeenTS  *int64 `json:"last_seen_ts"`
+		} `json:"devices"`
+	}
+	if err := c.handleResponse(resp, &result); err != nil {
+		return nil, err
+	}
+
+	devices := make([]Device, 0, len(result.Devices))
+	for _, d := range result.Devices {
+		device := Device{
+			DeviceID:    d.DeviceID,
+			DisplayName: d.DisplayName,
+			LastSeenIP:  d.LastSeenIP,
+		}
+		if d.LastSeenTS != nil {
+			lastSeen := time.UnixMilli(*d.LastSeenTS)
+			device.LastSeenTime = &lastSeen
+		}
+		devices = append(devices, device)
+	}
+	return devices, nil
+}
+
+// updateDevice sets the display name of a user's device via admin API
+func (c *adminClient) updateDevice(ctx context.Context, userID, deviceID, displayName string) error {
+	path := fmt.Sprintf("/_synapse/admin/v2/users/%s/devices/%s", url.PathEscape(userID), url.PathEscape(deviceID))
+
+	body := map[string]interface{}{
+		"display_name": displayName,
+	}
+
+	resp, err := c.makeRequest(ctx, "PUT", path, body)
+	if err != nil {
+		return err
+	}
+
+	return c.handleResponse(resp, nil)
+}
+
+// deleteDevice deletes a user's device via admin API. Homeservers that ask
+// for interactive authentication even so, or refuse the access token, are
+// reported with a DeviceDeletionRefusedError.
+func (c *adminClient) deleteDevice(ctx context.Context, userID, deviceID string) error {
+	path := fmt.Sprintf("/_synapse/admin/v2/users/%s/devices/%s", url.PathEscape(userID), url.PathEscape(deviceID))
+
+	resp, err := c.makeRequest(ctx, "DELETE", path, nil)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+
+		// An interactive authentication challenge lists the flows that
+		// would complete it
+		var content struct {
+			ErrCode string            `json:"errcode"`
+			Flows   []json.RawMessage `json:"flows"`
+		}
+		if json.Unmarshal(body, &content) == nil {
+			switch {
+			case len(content.Flows) > 0:
+				return &DeviceDeletionRefusedError{UserID: userID, DeviceID: deviceID}
+			case content.ErrCode == "M_FORBIDDEN":
+				return &DeviceDeletionRefusedError{UserID: userID, DeviceID: deviceID, ErrCode: content.ErrCode}
+			}
+		}
+		return newAPIError(resp.StatusCode, body)
+	}
+
+	return c.handleResponse(resp, nil)
+}
+
+// getWhois lists the connections a user has made to the homeserver, device
+// by device, most recently seen first
+func (c *adminClient) getWhois(ctx context.Context, userID string) ([]Connection, error) {
+	path := fmt.Sprintf("/_synapse/admin/v1/whois/%s", url.PathEscape(userID))
+
+	resp, err := c.makeRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Connections are grouped by device, then by session. Synapse reports
+	// when a connection was last seen in milliseconds.
+	var result struct {
+		Devices map[string]struct {
+			Sessions []struct {
+				Connections []struct {
+					IP        string `json:"ip"`
+					UserAgent string `json:"user_agent"`
+					LastSeen  *int64 `json:"last_seen"`
+				} `json:"connections"`
+			} `json:"sessions"`
+		} `json:"devices"`
+	}
+	if err := c.handleResponse(resp, &result); err != nil {
+		return nil, err
+	}
+
+	var connections []Connection
+	for deviceID, device := range result.Devices {
+		for _, session := range device.Sessions {
+			for _, conn := range session.Connections {
+				connection := Connection{
+					DeviceID:  deviceID,
+					IP:        conn.IP,
+					UserAgent: conn.UserAgent,
+				}
+				if conn.LastSeen != nil {
+					lastSeen := time.UnixMilli(*conn.LastSeen)
+					connection.LastSeenTime = &lastSeen
+				}
+				connections = append(connections, connection)
+			}
+		}
+	}
+
+	// Map iteration order is random, so connections are ordered to keep
+	// the observation stable
+	slices.SortFunc(connections, func(a, b Connection) int {
+		return cmp.Or(
+			lastSeen(b).Compare(lastSeen(a)),
+			cmp.Compare(a.DeviceID, b.DeviceID),
+			cmp.Compare(a.IP, b.IP),
+			cmp.Compare(a.UserAgent, b.UserAgent),
+		)
+	})
+	return connections, nil
+}
+
+// lastSeen returns when a connection was last seen, the zero time if unknown
+func lastSeen(connection Connection) time.Time {
+	if connection.LastSeenTime == nil {
+		return time.Time{}
+	}
+	return *connection.LastSeenTime
+}
+
+// getRateLimit gets the rate-limit override of a user. Synapse returns an
+// empty object when the user has none.
+func (c *adminClient) getRateLimit(ctx context.Context, userID string) (*RateLimit, error) {
+	path := fmt.Sprintf("/_synapse/admin/v1/users/%s/override_ratelimit", url.PathEscape(userID))
+
+	resp, err := c.makeRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		MessagesPerSecond *int `json:"messages_per_second"`
+		BurstCount        *int `json:"burst_count"`
+	}
+	if err := c.handleResponse(resp, &result); err != nil {
+		return nil, err
+	}
+
+	if result.MessagesPerSecond == nil && result.BurstCount == nil {
+		return nil, nil
+	}
+	return &RateLimit{
+		MessagesPerSecond: getIntValue(result.MessagesPerSecond, 0),
+		BurstCount:        getIntValue(result.BurstCount, 0),
+	}, nil
+}
+
+// setRateLimit sets the rate-limit override of a user via admin API
+func (c *adminClient) setRateLimit(ctx context.Context, userID string, rateLimit *RateLimit) error {
+	path := fmt.Sprintf("/_synapse/admin/v1/users/%s/override_ratelimit", url.PathEscape(userID))
+
+	resp, err := c.makeRequest(ctx, "POST", path, rateLimit)
+	if err != nil {
+		return err
+	}
+
+	return c.handleResponse(resp, nil)
+}
+
+// deleteRateLimit removes the rate-limit override of a user via admin API,
+// restoring the homeserver's default limits
+func (c *adminClient) deleteRateLimit(ctx context.Context, userID string) error {
+	path := fmt.Sprintf("/_synapse/admin/v1/users/%s/override_ratelimit", url.PathEscape(userID))
+
+	resp, err := c.makeRequest(ctx, "DELETE", path, nil)
+	if err != nil {
+		return err
+	}
+
+	return c.handleResponse(resp, nil)
 }
 
 // deactivateUser deactivates a user via admin API
@@ -199,15 +680,16 @@ func (c *adminClient) listUsers(ctx context.Context, from string, limit int) (*L
 
 // Room admin operations
 
-// deleteRoom deletes a room via admin API
+// deleteRoom starts deleting a room via admin API. Synapse deletes the room in
+// the background; follow it with getRoomDeleteStatus.
 func (c *adminClient) deleteRoom(ctx context.Context, roomID string, options map[string]interface{}) error {
-	path := fmt.Sprintf("/_synapse/admin/v1/rooms/%s/delete", url.PathEscape(roomID))
+	path := fmt.Sprintf("/_synapse/admin/v2/rooms/%s", url.PathEscape(roomID))
 
 	if options == nil {
 		options = make(map[string]interface{})
 	}
 
-	resp, err := c.makeRequest(ctx, "POST", path, options)
+	resp, err := c.makeRequest(ctx, "DELETE", path, options)
 	if err != nil {
 		return err
 	}
@@ -215,6 +697,31 @@ func (c *adminClient) deleteRoom(ctx context.Context, roomID string, options map
 	return c.handleResponse(resp, nil)
 }
 
+// getRoomDeleteStatus gets the progress of the latest deletion of a room.
+// Synapse forgets deletions some time after they finish, and when it
+// restarts.
+func (c *adminClient) getRoomDeleteStatus(ctx context.Context, roomID string) (*RoomDeletion, error) {
+	path := fmt.Sprintf("/_synapse/admin/v2/rooms/%s/delete_status", url.PathEscape(roomID))
+
+	resp, err := c.makeRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Results []RoomDeletion `json:"results"`
+	}
+	if err := c.handleResponse(resp, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Results) == 0 {
+		return nil, nil
+	}
+
+	// Synapse lists a room's deletions in the order they were started
+	return &result.Results[len(result.Results)-1], nil
+}
+
 // getRoomDetails gets detailed room information via admin API
 func (c *adminClient) getRoomDetails(ctx context.Context, roomID string) (*Room, error) {
 	path := fmt.Sprintf("/_synapse/admin/v1/rooms/%s", url.PathEscape(roomID))
@@ -261,6 +768,372 @@ func (c *adminClient) listRooms(ctx context.Context, from string, limit int) (*L
 	return &result, nil
 }
 
+// getForwardExtremities lists the forward extremities of a room
+func (c *adminClient) getForwardExtremities(ctx context.Context, roomID string) (*ForwardExtremities, error) {
+	path := fmt.Sprintf("/_synapse/admin/v1/rooms/%s/forward_extremities", url.PathEscape(roomID))
+
+	resp, err := c.makeRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result ForwardExtremities
+	if err := c.handleResponse(resp, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// deleteForwardExtremities removes the forward extremities of a room
+func (c *adminClient) deleteForwardExtremities(ctx context.Context, roomID string) (int, error) {
+	path := fmt.Sprintf("/_synapse/admin/v1/rooms/%s/forward_extremities", url.PathEscape(roomID))
+
+	resp, err := c.makeRequest(ctx, "DELETE", path, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var result struct {
+		Deleted int `json:"deleted"`
+	}
+	if err := c.handleResponse(resp, &result); err != nil {
+		return 0, err
+	}
+
+	return result.Deleted, nil
+}
+
+// purgeHistory starts purging the events of a room sent before a time, in
+// milliseconds since the epoch, and returns the ID to follow the purge by
+func (c *adminClient) purgeHistory(ctx context.Context, roomID string, upToTS int64, deleteLocalEvents bool) (string, error) {
+	path := fmt.Sprintf("/_synapse/admin/v1/purge_history/%s", url.PathEscape(roomID))
+
+	body := map[string]interface{}{
+		"purge_up_to_ts":      upToTS,
+		"delete_local_events": deleteLocalEvents,
+	}
+
+	resp, err := c.makeRequest(ctx, "POST", path, body)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		PurgeID string `json:"purge_id"`
+	}
+	if err := c.handleResponse(resp, &result); err != nil {
+		return "", err
+	}
+
+	return result.PurgeID, nil
+}
+
+// getPurgeHistoryStatus gets the progress of a purge of a room's history.
+// Synapse forgets purges some time after they finish, and when it restarts.
+func (c *adminClient) getPurgeHistoryStatus(ctx context.Context, purgeID string) (*HistoryPurge, error) {
+	path := fmt.Sprintf("/_synapse/admin/v1/purge_history_status/%s", url.PathEscape(purgeID))
+
+	resp, err := c.makeRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var purge HistoryPurge
+	if err := c.handleResponse(resp, &purge); err != nil {
+		return nil, err
+	}
+
+	return &purge, nil
+}
+
+// getMedia gets a piece of media uploaded to the homeserver, or cached from
+// another one
+func (c *adminClient) getMedia(ctx context.Context, serverName, mediaID string) (*Media, error) {
+	path := fmt.Sprintf("/_synapse/admin/v1/media/%s/%s", url.PathEscape(serverName), url.PathEscape(mediaID))
+
+	resp, err := c.makeRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Synapse reports when the media was uploaded in milliseconds
+	var result struct {
+		MediaInfo struct {
+			MediaID            string  `json:"media_id"`
+			MediaType          string  `json:"media_type"`
+			MediaLength        int64   `json:"media_length"`
+			UploadName         string  `json:"upload_name"`
+			UserID             string  `json:"user_id"`
+			CreatedTS          *int64  `json:"created_ts"`
+			QuarantinedBy      *string `json:"quarantined_by"`
+			SafeFromQuarantine bool    `json:"safe_from_quarantine"`
+		} `json:"media_info"`
+	}
+	if err := c.handleResponse(resp, &result); err != nil {
+		return nil, err
+	}
+
+	info := result.MediaInfo
+	media := &Media{
+		MediaID:            info.MediaID,
+		MediaType:          info.MediaType,
+		MediaLength:        info.MediaLength,
+		UploadName:         info.UploadName,
+		UserID:             info.UserID,
+		SafeFromQuarantine: info.SafeFromQuarantine,
+	}
+	if info.CreatedTS != nil {
+		created := time.UnixMilli(*info.CreatedTS)
+		media.CreationTime = &created
+	}
+	if info.QuarantinedBy != nil {
+		media.QuarantinedBy = *info.QuarantinedBy
+	}
+	return media, nil
+}
+
+// quarantineMedia quarantines a piece of media, or lifts its quarantine
+func (c *adminClient) quarantineMedia(ctx context.Context, serverName, mediaID string, quarantined bool) error {
+	action := "quarantine"
+	if !quarantined {
+		action = "unquarantine"
+	}
+	path := fmt.Sprintf("/_synapse/admin/v1/media/%s/%s/%s", action, url.PathEscape(serverName), url.PathEscape(mediaID))
+
+	resp, err := c.makeRequest(ctx, "POST", path, map[string]interface{}{})
+	if err != nil {
+		return err
+	}
+
+	return c.handleResponse(resp, nil)
+}
+
+// deleteMedia deletes a piece of media uploaded to the homeserver
+func (c *adminClient) deleteMedia(ctx context.Context, serverName, mediaID string) error {
+	path := fmt.Sprintf("/_synapse/admin/v1/media/%s/%s", url.PathEscape(serverName), url.PathEscape(mediaID))
+
+	resp, err := c.makeRequest(ctx, "DELETE", path, nil)
+	if err != nil {
+		return err
+	}
+
+	return c.handleResponse(resp, nil)
+}
+
+// sendServerNotice sends a server notice to a user and returns the notice's
+// event ID. A notice sent again with the same transaction ID isn't repeated.
+func (c *adminClient) sendServerNotice(ctx context.Context, txnID, userID, msgType, body string) (string, error) {
+	method, path := "POST", "/_synapse/admin/v1/send_server_notice"
+	if txnID != "" {
+		method, path = "PUT", path+"/"+url.PathEscape(txnID)
+	}
+
+	notice := map[string]interface{}{
+		"user_id": userID,
+		"content": map[string]interface{}{
+			"msgtype": msgType,
+			"body":    body,
+		},
+	}
+
+	resp, err := c.makeRequest(ctx, method, path, notice)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		EventID string `json:"event_id"`
+	}
+	if err := c.handleResponse(resp, &result); err != nil {
+		return "", err
+	}
+
+	return result.EventID, nil
+}
+
+// fetchedEvent is the part of an event fetched by its ID that is needed to act
+// on it
+type fetchedEvent struct {
+	EventID  string `json:"event_id"`
+	RoomID   string `json:"room_id"`
+	Sender   string `json:"sender"`
+	Unsigned struct {
+		RedactedBecause json.RawMessage `json:"redacted_because,omitempty"`
+	} `json:"unsigned"`
+}
+
+// fetchEvent gets an event by its ID alone, in whichever room it was sent
+func (c *adminClient) fetchEvent(ctx context.Context, eventID string) (*fetchedEvent, error) {
+	path := fmt.Sprintf("/_synapse/admin/v1/fetch_event/%s", url.PathEscape(eventID))
+
+	resp, err := c.makeRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Event *fetchedEvent `json:"event"`
+	}
+	if err := c.handleResponse(resp, &result); err != nil {
+		return nil, err
+	}
+	if result.Event == nil {
+		return nil, &APIError{StatusCode: http.StatusNotFound, ErrCode: "M_NOT_FOUND", Body: fmt.Sprintf("event %s not found", eventID)}
+	}
+
+	return result.Event, nil
+}
+
+// loginAsUser returns an access token of a user that expires at validUntil,
+// so that the admin can act on the user's behalf
+func (c *adminClient) loginAsUser(ctx context.Context, userID string, validUntil time.Time) (string, error) {
+	path := fmt.Sprintf("/_synapse/admin/v1/users/%s/login", url.PathEscape(userID))
+
+	body := map[string]interface{}{
+		"valid_until_ms": validUntil.UnixMilli(),
+	}
+
+	resp, err := c.makeRequest(ctx, "POST", path, body)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := c.handleResponse(resp, &result); err != nil {
+		return "", err
+	}
+
+	return result.AccessToken, nil
+}
+
+// adminRegistrationToken is a registration token as the admin API reports it,
+// with its expiry time in milliseconds
+type adminRegistrationToken struct {
+	Token       string `json:"token"`
+	UsesAllowed *int   `json:"uses_allowed"`
+	Pending     int    `json:"pending"`
+	Completed   int    `json:"completed"`
+	ExpiryTime  *int64 `json:"expiry_time"`
+}
+
+func (t *adminRegistrationToken) toRegistrationToken() *RegistrationToken {
+	token := &RegistrationToken{
+		Token:       t.Token,
+		UsesAllowed: t.UsesAllowed,
+		Pending:     t.Pending,
+		Completed:   t.Completed,
+	}
+	if t.ExpiryTime != nil {
+		expiry := time.UnixMilli(*t.ExpiryTime)
+		token.ExpiryTime = &expiry
+	}
+	return token
+}
+
+// registrationTokenBody returns the request body that creates or updates a
+// registration token. Fields that aren't set are left out.
+func registrationTokenBody(spec *RegistrationTokenSpec) map[string]interface{} {
+	body := map[string]interface{}{}
+	if spec.Token != "" {
+		body["token"] = spec.Token
+	}
+	if spec.UsesAllowed != nil {
+		body["uses_allowed"] = *spec.UsesAllowed
+	}
+	if spec.ExpiryTime != nil {
+		body["expiry_time"] = spec.ExpiryTime.UnixMilli()
+	}
+	return body
+}
+
+// listRegistrationTokens lists the homeserver's registration tokens
+func (c *adminClient) listRegistrationTokens(ctx context.Context) ([]RegistrationToken, error) {
+	resp, err := c.makeRequest(ctx, "GET", "/_synapse/admin/v1/registration_tokens", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		RegistrationTokens []adminRegistrationToken `json:"registration_tokens"`
+	}
+	if err := c.handleResponse(resp, &result); err != nil {
+		return nil, err
+	}
+
+	tokens := make([]RegistrationToken, 0, len(result.RegistrationTokens))
+	for i := range result.RegistrationTokens {
+		tokens = append(tokens, *result.RegistrationTokens[i].toRegistrationToken())
+	}
+	return tokens, nil
+}
+
+// getRegistrationToken gets a registration token
+func (c *adminClient) getRegistrationToken(ctx context.Context, token string) (*RegistrationToken, error) {
+	path := fmt.Sprintf("/_synapse/admin/v1/registration_tokens/%s", url.PathEscape(token))
+
+	resp, err := c.makeRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result adminRegistrationToken
+	if err := c.handleResponse(resp, &result); err != nil {
+		return nil, err
+	}
+
+	return result.toRegistrationToken(), nil
+}
+
+// createRegistrationToken creates a registration token, generated by the
+// homeserver unless the spec gives one
+func (c *adminClient) createRegistrationToken(ctx context.Context, spec *RegistrationTokenSpec) (*RegistrationToken, error) {
+	resp, err := c.makeRequest(ctx, "POST", "/_synapse/admin/v1/registration_tokens/new", registrationTokenBody(spec))
+	if err != nil {
+		return nil, err
+	}
+
+	var result adminRegistrationToken
+	if err := c.handleResponse(resp, &result); err != nil {
+		return nil, err
+	}
+
+	return result.toRegistrationToken(), nil
+}
+
+// updateRegistrationToken changes how many times a registration token can be
+// used and when it expires
+func (c *adminClient) updateRegistrationToken(ctx context.Context, token string, spec *RegistrationTokenSpec) (*RegistrationToken, error) {
+	path := fmt.Sprintf("/_synapse/admin/v1/registration_tokens/%s", url.PathEscape(token))
+
+	body := registrationTokenBody(&RegistrationTokenSpec{UsesAllowed: spec.UsesAllowed, ExpiryTime: spec.ExpiryTime})
+	resp, err := c.makeRequest(ctx, "PUT", path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result adminRegistrationToken
+	if err := c.handleResponse(resp, &result); err != nil {
+		return nil, err
+	}
+
+	return result.toRegistrationToken(), nil
+}
+
+// deleteRegistrationToken deletes a registration token
+func (c *adminClient) deleteRegistrationToken(ctx context.Context, token string) error {
+	path := fmt.Sprintf("/_synapse/admin/v1/registration_tokens/%s", url.PathEscape(token))
+
+	resp, err := c.makeRequest(ctx, "DELETE", path, nil)
+	if err != nil {
+		return err
+	}
+
+	return c.handleResponse(resp, nil)
+}
+
 // makeRoomAdmin grants admin privileges to a user in a room
 func (c *adminClient) makeRoomAdmin(ctx context.Context, roomID, userID string) error {
 	path := fmt.Sprintf("/_synapse/admin/v1/rooms/%s/make_room_admin", url.PathEscape(roomID))
@@ -277,6 +1150,69 @@ func (c *adminClient) makeRoomAdmin(ctx context.Context, roomID, userID string)
 	return c.handleResponse(resp, nil)
 }
 
+// adminStateEvent is a state event of a room as the admin API lists it
+type adminStateEvent struct {
+	Type     string          `json:"type"`
+	StateKey string          `json:"state_key"`
+	Sender   string          `json:"sender"`
+	Content  json.RawMessage `json:"content"`
+}
+
+// getRoomState lists the current state events of a room, whether or not the
+// admin is in it
+func (c *adminClient) getRoomState(ctx context.Context, roomID string) ([]adminStateEvent, error) {
+	path := fmt.Sprintf("/_synapse/admin/v1/rooms/%s/state", url.PathEscape(roomID))
+
+	resp, err := c.makeRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		State []adminStateEvent `json:"state"`
+	}
+	if err := c.handleResponse(resp, &result); err != nil {
+		return nil, err
+	}
+
+	return result.State, nil
+}
+
+// joinRoom adds a local user to a room
+func (c *adminClient) joinRoom(ctx context.Context, roomID, userID string) error {
+	path := fmt.Sprintf("/_synapse/admin/v1/join/%s", url.PathEscape(roomID))
+
+	body := map[string]interface{}{
+		"user_id": userID,
+	}
+
+	resp, err := c.makeRequest(ctx, "POST", path, body)
+	if err != nil {
+		return err
+	}
+
+	return c.handleResponse(resp, nil)
+}
+
+// getRoomBlocked returns whether a room is blocked from being joined
+func (c *adminClient) getRoomBlocked(ctx context.Context, roomID string) (bool, error) {
+	path := fmt.Sprintf("/_synapse/admin/v1/rooms/%s/block", url.PathEscape(roomID))
+
+	resp, err := c.makeRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return false, err
+	}
+
+	var result struct {
+		Block bool `json:"block"`
+	}
+	if err := c.handleResponse(resp, &result); err != nil {
+		return false, err
+	}
+
+	return result.Block, nil
+}
+
 // blockRoom blocks a room from being joined
 func (c *adminClient) blockRoom(ctx context.Context, roomID string, block bool) error {
 	path := fmt.Sprintf("/_synapse/admin/v1/rooms/%s/block", url.PathEscape(roomID))
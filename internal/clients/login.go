@@ -0,0 +1,220 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io"
+	"maunium.net/go/mautrix"
+	"maunium.net/go/mautrix/id"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// loginTimeout bounds logging in with a password
+const loginTimeout = 30 * time.Second
+
+// loginDevices remembers the device each user last logged in with, keyed by
+// homeserver and username, so that a client created for a changed
+// ProviderConfig logs in to the same device rather than adding a new one
+var loginDevices sync.Map
+
+// passwordCredentials are the contents of a credentials source that holds a
+// username and password rather than an access token
+type passwordCredentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// parseCredentials returns the access token held by a credentials source, or
+// the username and password to log in with if it holds a JSON object
+func parseCredentials(data []byte) (string, *passwordCredentials, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return string(data), nil, nil
+	}
+
+	creds := &passwordCredentials{}
+	if err := json.Unmarshal(data, creds); err != nil {
+		return "", nil, errors.Wrap(err, "cannot decode username and password")
+	}
+	if creds.Username == "" || creds.Password == "" {
+		return "", nil, errors.New("credentials must hold both a username and a password")
+	}
+	return "", creds, nil
+}
+
+// loginTransport authenticates requests with an access token obtained by
+// logging in with a password, and logs in again when the homeserver reports
+// the token expired or was revoked with M_UNKNOWN_TOKEN
+type loginTransport struct {
+	next   http.RoundTripper
+	config *Config
+
+	mu       sync.Mutex
+	token    string
+	deviceID string
+	userID   string
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *loginTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Only requests the client authenticates carry a token, which leaves
+	// out the login itself
+	if req.Header.Get("Authorization") == "" {
+		return t.next.RoundTrip(req)
+	}
+
+	token := t.currentToken()
+	resp, err := t.next.RoundTrip(withToken(req, token))
+	if err != nil || !unknownToken(resp) {
+		return resp, err
+	}
+
+	// The request's body was consumed by the attempt, so it can only be
+	// made again if it can be read again
+	retry := req
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return resp, nil
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		retry = req.Clone(req.Context())
+		retry.Body = body
+	}
+
+	token, err = t.login(req.Context(), token)
+	if err != nil {
+		return resp, nil
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	return t.next.RoundTrip(withToken(retry, token))
+}
+
+// currentToken returns the access token of the latest login
+func (t *loginTransport) currentToken() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.token
+}
+
+// login logs in with the configured password, unless another request
+// already replaced the stale token. The device of the previous login is
+// reused, so that logging in again doesn't leave a device behind each time.
+func (t *loginTransport) login(ctx context.Context, stale string) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.token != "" && t.token != stale {
+		return t.token, nil
+	}
+
+	client, err := mautrix.NewClient(t.config.HomeserverURL, "", "")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create mautrix client")
+	}
+	client.Client = &http.Client{Transport: t.next}
+	if _, ok := t.next.(*retryTransport); !ok {
+		client.Client.Timeout = t.config.requestTimeout()
+	}
+
+	resp, err := client.Login(ctx, &mautrix.ReqLogin{
+		Type: mautrix.AuthTypePassword,
+		Identifier: mautrix.UserIdentifier{
+			Type: mautrix.IdentifierTypeUser,
+			User: t.config.Username,
+		},
+		Password:                 t.config.Password,
+		DeviceID:                 id.DeviceID(t.deviceID),
+		InitialDeviceDisplayName: t.config.InitialDeviceDisplayName,
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to log in as %s", t.config.Username)
+	}
+
+	t.token, t.deviceID, t.userID = resp.AccessToken, string(resp.DeviceID), string(resp.UserID)
+	loginDevices.Store(t.config.loginKey(), t.deviceID)
+	return t.token, nil
+}
+
+// loginKey identifies the user a Config logs in as
+func (c *Config) loginKey() string {
+	return strings.Join([]string{c.HomeserverURL, c.Username}, "\x00")
+}
+
+// withToken returns a copy of a request authenticated with token
+func withToken(req *http.Request, token string) *http.Request {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+// unknownToken reports whether a response rejected the request's access
+// token. The body is left readable for the caller.
+func unknownToken(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusUnauthorized || resp.Body == nil {
+		return false
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRateLimitBody))
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	var content struct {
+		ErrCode string `json:"errcode"`
+	}
+	return err == nil && json.Unmarshal(body, &content) == nil && content.ErrCode == mautrix.MUnknownToken.ErrCode
+}
+
+// withPasswordLogin logs in with the Config's username and password, and
+// returns a copy of its HTTP client that authenticates requests with the
+// token obtained, logging in again whenever it stops being accepted. The
+// Config's access token, device ID and, if unset, user ID are filled in from
+// the login.
+func withPasswordLogin(ctx context.Context, config *Config) (*http.Client, error) {
+	next := config.HTTPClient.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	t := &loginTransport{next: next, config: config, deviceID: config.DeviceID}
+	if t.deviceID == "" {
+		if deviceID, ok := loginDevices.Load(config.loginKey()); ok {
+			t.deviceID = deviceID.(string)
+		}
+	}
+
+	token, err := t.login(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	config.AccessToken, config.DeviceID = token, t.deviceID
+	if config.UserID == "" {
+		config.UserID = t.userID
+	}
+
+	wrapped := *config.HTTPClient
+	wrapped.Transport = t
+	return &wrapped, nil
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseCredentials(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		wantToken string
+		wantLogin *passwordCredentials
+		wantErr   bool
+	}{
+		{
+			name:      "access token",
+			data:      "syt_token",
+			wantToken: "syt_token",
+		},
+		{
+			name:      "username and password",
+			data:      `{"username": "bot", "password": "secret"}`,
+			wantLogin: &passwordCredentials{Username: "bot", Password: "secret"},
+		},
+		{
+			name:    "password missing",
+			data:    `{"username": "bot"}`,
+			wantErr: true,
+		},
+		{
+			name:    "malformed JSON",
+			data:    `{"username": `,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, login, err := parseCredentials([]byte(tt.data))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantToken, token)
+			assert.Equal(t, tt.wantLogin, login)
+		})
+	}
+}
+
+func TestPasswordLogin(t *testing.T) {
+	type loginRequest struct {
+		Identifier struct {
+			User string `json:"user"`
+		} `json:"identifier"`
+		Password string `json:"password"`
+		DeviceID string `json:"device_id"`
+	}
+
+	var logins []loginRequest
+	valid := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_matrix/client/v3/login":
+			var req loginRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			logins = append(logins, req)
+			valid = []string{"first_token", "second_token"}[len(logins)-1]
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"access_token": valid,
+				"device_id":    "PROVIDER",
+				"user_id":      "@bot:example.com",
+			})
+		case "/_matrix/client/v3/rooms/!room:example.com/state/m.room.member/@bot:example.com":
+			if r.Header.Get("Authorization") != "Bearer "+valid {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"errcode":"M_UNKNOWN_TOKEN","error":"Access token has expired","soft_logout":true}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"membership":"join"}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	config := &Config{
+		HomeserverURL: server.URL,
+		Username:      "bot",
+		Password:      "secret",
+	}
+	c, err := NewClient(config)
+	require.NoError(t, err)
+	require.Len(t, logins, 1)
+	assert.Equal(t, "bot", logins[0].Identifier.User)
+	assert.Equal(t, "secret", logins[0].Password)
+	assert.Equal(t, "first_token", config.AccessToken)
+	assert.Equal(t, "PROVIDER", config.DeviceID)
+	assert.Equal(t, "@bot:example.com", config.UserID)
+
+	// The token expires, so the request logs in again to the same device
+	// and is made again with the new token
+	valid = "expired"
+	membership, err := c.GetMembership(context.Background(), "!room:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "join", membership)
+	require.Len(t, logins, 2)
+	assert.Equal(t, "PROVIDER", logins[1].DeviceID)
+}
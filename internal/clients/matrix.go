@@ -18,45 +18,150 @@ package clients
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	namespacedv1beta1 "github.com/crossplane-contrib/provider-matrix/apis/namespaced/v1beta1"
 	"github.com/crossplane-contrib/provider-matrix/apis/v1beta1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
 	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
 	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
 	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
 	"github.com/pkg/errors"
+	"golang.org/x/sync/singleflight"
+	"io"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"maunium.net/go/mautrix"
+	"maunium.net/go/mautrix/event"
 	"maunium.net/go/mautrix/id"
+	"net"
 	"net/http"
 	"net/url"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 const (
 	// DefaultTimeout for Matrix API operations
 	defaultTimeout = 30 * time.Second
+
+	// defaultDeviceDisplayName is the display name of devices created by login
+	defaultDeviceDisplayName = "Crossplane provider-matrix"
+
+	// detectServerTypeTimeout bounds probing the homeserver for its type
+	detectServerTypeTimeout = 10 * time.Second
+)
+
+// Types of homeserver a ProviderConfig can be for.
+const (
+	ServerTypeAuto     = "auto"
+	ServerTypeSynapse  = "synapse"
+	ServerTypeDendrite = "dendrite"
+	ServerTypeConduit  = "conduit"
 )
 
+// ErrUnsupportedByServer is returned for admin operations that only Synapse
+// offers when the homeserver is known to be another implementation
+var ErrUnsupportedByServer = errors.New("operation is not supported by the homeserver")
+
 // Client interface for Matrix API operations
 type Client interface {
 	// User operations
 	CreateUser(ctx context.Context, user *UserSpec) (*User, error)
 	GetUser(ctx context.Context, userID string) (*User, error)
 	UpdateUser(ctx context.Context, userID string, user *UserSpec) (*User, error)
+	GetRateLimit(ctx context.Context, userID string) (*RateLimit, error)
+	SetRateLimit(ctx context.Context, userID string, rateLimit *RateLimit) error
+	DeleteRateLimit(ctx context.Context, userID string) error
 	DeactivateUser(ctx context.Context, userID string) error
+	LockUser(ctx context.Context, userID string, locked bool) error
+	ShadowBanUser(ctx context.Context, userID string, shadowBanned bool) error
+	GetDevices(ctx context.Context, userID string) ([]Device, error)
+	SetDeviceDisplayName(ctx context.Context, userID, deviceID, displayName string) error
+	DeleteDevice(ctx context.Context, userID, deviceID string) error
+	GetConnections(ctx context.Context, userID string) ([]Connection, error)
 
 	// Room operations
 	CreateRoom(ctx context.Context, room *RoomSpec) (*Room, error)
 	GetRoom(ctx context.Context, roomID string) (*Room, error)
+	GetRoomState(ctx context.Context, roomID string, eventTypes []string) ([]StateEvent, error)
 	UpdateRoom(ctx context.Context, roomID string, room *RoomSpec) (*Room, error)
-	DeleteRoom(ctx context.Context, roomID string) error
+	DeleteRoom(ctx context.Context, roomID string, opts DeleteRoomOptions) error
+	GetRoomDeletion(ctx context.Context, roomID string) (*RoomDeletion, error)
+	GetPrivilegedCreators(ctx context.Context, roomID string) ([]string, error)
+	GetJoinRules(ctx context.Context, roomID string) (string, error)
+	GetReplacementRoom(ctx context.Context, roomID string) (string, error)
+	RoomExists(ctx context.Context, roomID string) (bool, error)
+	EventPinnable(ctx context.Context, roomID, eventID string) (bool, error)
+	GetMembership(ctx context.Context, roomID string) (string, error)
+	JoinRoom(ctx context.Context, roomID string) error
+	GetUserMembership(ctx context.Context, roomID, userID string) (string, error)
+	SetMembership(ctx context.Context, roomID, userID, membership, reason string) error
+	GetMemberships(ctx context.Context, roomID string) (map[string]string, error)
+	InviteUsers(ctx context.Context, roomID string, userIDs []string) (int, error)
+	RemoveJoinRuleAllow(ctx context.Context, roomID string, allowRoomIDs []string) error
+	SetDirectoryNetworkVisibility(ctx context.Context, networkID, roomID, visibility string) error
+
+	// Knock operations
+	GetKnocks(ctx context.Context, roomID string) ([]string, error)
+	AcceptKnock(ctx context.Context, roomID, userID string) error
+
+	// Room maintenance operations
+	GetForwardExtremities(ctx context.Context, roomID string) (*ForwardExtremities, error)
+	DeleteForwardExtremities(ctx context.Context, roomID string) (int, error)
+	PurgeHistory(ctx context.Context, roomID string, upTo time.Time, deleteLocalEvents bool) (string, error)
+	GetHistoryPurge(ctx context.Context, purgeID string) (*HistoryPurge, error)
+
+	// Media operations
+	GetMedia(ctx context.Context, serverName, mediaID string) (*Media, error)
+	QuarantineMedia(ctx context.Context, serverName, mediaID string, quarantined bool) error
+	DeleteMedia(ctx context.Context, serverName, mediaID string) error
+
+	// Server notice operations
+	SendServerNotice(ctx context.Context, txnID, userID, msgType, body string) (string, error)
+	RedactServerNotice(ctx context.Context, eventID, reason string) error
+
+	// Registration token operations
+	ListRegistrationTokens(ctx context.Context) ([]RegistrationToken, error)
+	GetRegistrationToken(ctx context.Context, token string) (*RegistrationToken, error)
+	CreateRegistrationToken(ctx context.Context, spec *RegistrationTokenSpec) (*RegistrationToken, error)
+	UpdateRegistrationToken(ctx context.Context, token string, spec *RegistrationTokenSpec) (*RegistrationToken, error)
+	DeleteRegistrationToken(ctx context.Context, token string) error
+
+	// Account data operations
+	GetAccountData(ctx context.Context, userID, dataType string) (json.RawMessage, error)
+	SetAccountData(ctx context.Context, userID, dataType string, content json.RawMessage) error
+
+	// Room tag operations
+	GetRoomTags(ctx context.Context, userID, roomID string) (map[string]RoomTag, error)
+	SetRoomTag(ctx context.Context, userID, roomID, tag string, order *float64) error
+	DeleteRoomTag(ctx context.Context, userID, roomID, tag string) error
+
+	// Profile operations
+	SyncProfile(ctx context.Context) error
+	WhoAmI(ctx context.Context) (string, error)
+
+	// Server operations
+	ServerInfo(ctx context.Context) (*ServerInfo, error)
+
+	// Space operations
+	CreateSpace(ctx context.Context, space *SpaceSpec) (*Space, error)
+	GetSpace(ctx context.Context, spaceID string) (*Space, error)
+	UpdateSpace(ctx context.Context, spaceID string, space *SpaceSpec) (*Space, error)
+	DeleteSpace(ctx context.Context, spaceID string) error
 
 	// Power level operations
 	SetPowerLevels(ctx context.Context, roomID string, powerLevels *PowerLevelSpec) error
 	GetPowerLevels(ctx context.Context, roomID string) (*PowerLevelContent, error)
+	ExportPowerLevels(ctx context.Context, roomID string) (*PowerLevelContent, error)
+	ResetPowerLevels(ctx context.Context, roomID string, usersOnly bool) error
 
 	// Room alias operations
 	CreateRoomAlias(ctx context.Context, alias string, roomID string) error
@@ -67,6 +172,8 @@ type Client interface {
 	ListUsers(ctx context.Context, from string, limit int) (*ListUsersResponse, error)
 	ListRooms(ctx context.Context, from string, limit int) (*ListRoomsResponse, error)
 	MakeRoomAdmin(ctx context.Context, roomID, userID string) error
+	RoomAdmins(ctx context.Context, roomID string, userIDs []string) ([]string, error)
+	GetRoomBlocked(ctx context.Context, roomID string) (bool, error)
 	BlockRoom(ctx context.Context, roomID string, block bool) error
 }
 
@@ -77,9 +184,75 @@ type Config struct {
 	AccessToken   string
 	UserID        string
 	DeviceID      string
-	ServerType    string
 	AdminMode     bool
 	HTTPClient    *http.Client
+
+	// ServerType is the type of homeserver. With ServerTypeAuto the
+	// homeserver is asked for its type when the client is created in admin
+	// mode. Empty assumes Synapse without asking.
+	ServerType string
+
+	// AdminAPIPathPrefix is prepended to the path of admin API requests
+	AdminAPIPathPrefix string
+
+	// MaxRetries is how often a rate-limited or transiently failed request
+	// is retried. Zero makes every request once.
+	MaxRetries int
+
+	// RetryMaxWait bounds the total time a request waits between retries.
+	// Zero waits at most DefaultRetryMaxWait.
+	RetryMaxWait time.Duration
+
+	// RequestTimeout bounds each attempt at a request to the homeserver when
+	// HTTPClient isn't set. Zero uses the default of 30 seconds.
+	RequestTimeout time.Duration
+
+	// InitialDeviceDisplayName names the device created when logging in
+	InitialDeviceDisplayName string
+
+	// Username and Password are logged in with to obtain an access token
+	// when AccessToken is empty
+	Username string
+	Password string
+
+	// RegistrationSharedSecret, if set, is used to create users through
+	// Synapse's shared-secret registration API
+	RegistrationSharedSecret string
+
+	// BotDisplayName and BotAvatarURL are the desired profile of the
+	// provider's own user. Empty values leave the profile alone.
+	BotDisplayName string
+	BotAvatarURL   string
+
+	// ClientCertificate and ClientKey, if set, are the PEM encoded TLS
+	// client certificate and private key presented to the homeserver
+	ClientCertificate string
+	ClientKey         string
+
+	// CACertificate, if set, holds PEM encoded CA certificates trusted in
+	// addition to the system's
+	CACertificate string
+
+	// InsecureSkipVerify disables verification of the homeserver's TLS
+	// certificate
+	InsecureSkipVerify bool
+
+	// ProxyURL, if set, is the proxy requests to the homeserver are sent
+	// through. Empty takes the proxy from the environment.
+	ProxyURL string
+
+	// Logger, if set, is told about insecure settings when the client is
+	// created
+	Logger logging.Logger
+
+	// ProviderConfigName is the name of the ProviderConfig the
+	// configuration was read from, if any, qualified by its namespace if
+	// it is namespaced
+	ProviderConfigName string
+
+	// userHTTPClient is HTTPClient without the provider's own
+	// authentication, to make requests with other users' access tokens
+	userHTTPClient *http.Client
 }
 
 // matrixClient implements the Client interface using mautrix-go
@@ -87,6 +260,29 @@ type matrixClient struct {
 	config      *Config
 	client      *mautrix.Client
 	adminClient *adminClient
+
+	// serverType is the configured or detected type of homeserver, empty
+	// if it couldn't be detected
+	serverType string
+
+	// tokenUserID is the user the access token belongs to, or
+	// tokenUserIDErr why the homeserver couldn't say, at tokenUserIDAt
+	tokenUserIDMu  sync.Mutex
+	tokenUserID    string
+	tokenUserIDErr error
+	tokenUserIDAt  time.Time
+
+	// serverInfo is what the homeserver last said about its software, or
+	// serverInfoErr why it couldn't be asked, at serverInfoAt
+	serverInfoMu  sync.Mutex
+	serverInfo    *ServerInfo
+	serverInfoErr error
+	serverInfoAt  time.Time
+
+	// userTokens are the access tokens clientAs logged in as other users
+	// with, by user ID
+	userTokensMu sync.Mutex
+	userTokens   map[string]userToken
 }
 
 // NewClient creates a new Matrix client
@@ -108,7 +304,28 @@ func NewClient(config *Config) (Client, error) {
 
 	if config.HTTPClient == nil {
 		config.HTTPClient = &http.Client{
-			Timeout: defaultTimeout,
+			Timeout: config.requestTimeout(),
+		}
+	}
+	if config.customTransport() {
+		config.HTTPClient, err = withTransportConfig(config.HTTPClient, config)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if config.InsecureSkipVerify && config.Logger != nil {
+		config.Logger.Info("WARNING: TLS certificate verification of the homeserver is disabled by insecureSkipVerify; do not use it in production",
+			"homeserver", config.HomeserverURL)
+	}
+	config.HTTPClient = withRetries(withRateLimitMetrics(config.HTTPClient), config.MaxRetries, config.RetryMaxWait)
+	config.userHTTPClient = config.HTTPClient
+
+	if config.AccessToken == "" && config.Password != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), loginTimeout)
+		config.HTTPClient, err = withPasswordLogin(ctx, config)
+		cancel()
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -125,20 +342,242 @@ func NewClient(config *Config) (Client, error) {
 
 	// Create admin client if admin mode is enabled
 	var adminClient *adminClient
+	serverType := config.ServerType
 	if config.AdminMode {
 		adminClient = newAdminClient(config)
+		if serverType == ServerTypeAuto {
+			ctx, cancel := context.WithTimeout(context.Background(), detectServerTypeTimeout)
+			serverType = detectServerType(ctx, adminClient, config)
+			cancel()
+		}
 	}
 
 	return &matrixClient{
 		config:      config,
 		client:      client,
 		adminClient: adminClient,
+		serverType:  serverType,
 	}, nil
 }
 
+// DetectedServerType returns the type of homeserver the client was configured
+// for or detected, or an empty string if it couldn't be detected.
+func (c *matrixClient) DetectedServerType() string {
+	return c.serverType
+}
+
+// detectServerType asks the homeserver which implementation it is. Synapse is
+// recognised by its admin API's version endpoint, other homeservers by the
+// name they give in the federation API. It returns an empty string if the
+// homeserver doesn't say.
+func detectServerType(ctx context.Context, admin *adminClient, config *Config) string {
+	if _, err := admin.serverVersion(ctx); err == nil {
+		return ServerTypeSynapse
+	}
+
+	name, _, err := federationVersion(ctx, config)
+	if err != nil {
+		return ""
+	}
+	return serverTypeFromName(name)
+}
+
+// federationVersion returns the name and version of the homeserver's software
+// as the federation API gives them
+func federationVersion(ctx context.Context, config *Config) (name, version string, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(config.HomeserverURL, "/")+"/_matrix/federation/v1/version", nil)
+	if err != nil {
+		return "", "", err
+	}
+	resp, err := config.HTTPClient.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", "", newAPIError(resp.StatusCode, body)
+	}
+
+	var content struct {
+		Server struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"server"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&content); err != nil {
+		return "", "", errors.Wrap(err, "failed to decode the federation version")
+	}
+	return content.Server.Name, content.Server.Version, nil
+}
+
+// serverTypeFromName returns the type of homeserver whose software has the
+// given name, or an empty string if it isn't one the provider knows
+func serverTypeFromName(name string) string {
+	switch strings.ToLower(name) {
+	case "synapse":
+		return ServerTypeSynapse
+	case "dendrite":
+		return ServerTypeDendrite
+	case "conduit", "conduwuit":
+		return ServerTypeConduit
+	}
+	return ""
+}
+
+// serverInfoTTL is how long what the homeserver said about its software is
+// remembered, so that connecting every resource doesn't ask it again.
+// serverInfoErrorTTL is how long failing to ask it is remembered, which is
+// shorter, so that a homeserver that was briefly unreachable is soon asked
+// again.
+const (
+	serverInfoTTL      = time.Hour
+	serverInfoErrorTTL = 30 * time.Second
+)
+
+// ServerInfo returns the type and version of the homeserver's software. In
+// admin mode Synapse's admin API is asked for the version if the federation
+// API doesn't give one, as Synapse often serves the federation API only to
+// other homeservers.
+func (c *matrixClient) ServerInfo(ctx context.Context) (*ServerInfo, error) {
+	c.serverInfoMu.Lock()
+	defer c.serverInfoMu.Unlock()
+	ttl := serverInfoTTL
+	if c.serverInfoErr != nil {
+		ttl = serverInfoErrorTTL
+	}
+	if !c.serverInfoAt.IsZero() && time.Since(c.serverInfoAt) < ttl {
+		return c.serverInfo, c.serverInfoErr
+	}
+
+	info := &ServerInfo{}
+	var err error
+	info.Name, info.Version, err = federationVersion(ctx, c.config)
+	if info.Version == "" && c.adminClient != nil && c.synapseAdminAPI() {
+		if version, adminErr := c.adminClient.serverVersion(ctx); adminErr == nil {
+			info.Name, info.Version, err = "Synapse", version, nil
+		}
+	}
+
+	if err != nil {
+		info, err = nil, errors.Wrap(err, "failed to get the homeserver's version")
+	} else {
+		info.Type = c.serverType
+		if info.Type == "" || info.Type == ServerTypeAuto {
+			info.Type = serverTypeFromName(info.Name)
+		}
+		if info.Type == "" {
+			info.Type = strings.ToLower(info.Name)
+		}
+	}
+
+	c.serverInfo, c.serverInfoErr, c.serverInfoAt = info, err, time.Now()
+	return info, err
+}
+
+// synapseAdminAPI reports whether the homeserver may offer Synapse's admin
+// API, which is assumed unless it is known to be another implementation
+func (c *matrixClient) synapseAdminAPI() bool {
+	return c.serverType != ServerTypeDendrite && c.serverType != ServerTypeConduit
+}
+
+// unsupportedByServer returns ErrUnsupportedByServer for an operation the
+// homeserver doesn't offer
+func (c *matrixClient) unsupportedByServer(operation string) error {
+	return errors.Wrapf(ErrUnsupportedByServer, "%s on %s", operation, c.serverType)
+}
+
+var (
+	cachedClientsMu sync.Mutex
+	cachedClients   = map[string]cachedClient{}
+
+	// newCachedClients creates each configuration's client once, however
+	// many resources connect with it at the same time. Creating a client may
+	// log in and detect the server type, so it happens outside
+	// cachedClientsMu, where it can't hold up clients of other configurations.
+	newCachedClients singleflight.Group
+)
+
+// cachedClient is a client together with the hash of the configuration it
+// was created from
+type cachedClient struct {
+	hash   string
+	client Client
+
+	// userID and deviceID are those the client logged in as, if it logged
+	// in with a password
+	userID   string
+	deviceID string
+}
+
+// CachedClient returns a client for config, reusing the one created for the
+// same ProviderConfig as long as its configuration is unchanged. Rotating the
+// access token in the ProviderConfig's secret thus creates a new client on
+// the next Connect, without restarting the provider, and the new client
+// replaces the old one in the cache. Configurations that don't come from a
+// ProviderConfig are cached by homeserver and user.
+func CachedClient(config *Config) (Client, error) {
+	key := "providerconfig\x00" + config.ProviderConfigName
+	if config.ProviderConfigName == "" {
+		key = strings.Join([]string{config.HomeserverURL, config.UserID, config.DeviceID, config.Username}, "\x00")
+	}
+	hash := config.hash()
+
+	cachedClientsMu.Lock()
+	cached, ok := cachedClients[key]
+	cachedClientsMu.Unlock()
+
+	if !ok || cached.hash != hash {
+		v, err, _ := newCachedClients.Do(key+"\x00"+hash, func() (interface{}, error) {
+			c, err := NewClient(config)
+			if err != nil {
+				return nil, err
+			}
+			created := cachedClient{hash: hash, client: c, userID: config.UserID, deviceID: config.DeviceID}
+
+			cachedClientsMu.Lock()
+			defer cachedClientsMu.Unlock()
+			cachedClients[key] = created
+			return created, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		cached = v.(cachedClient)
+	}
+
+	config.UserID, config.DeviceID = cached.userID, cached.deviceID
+	return cached.client, nil
+}
+
+// hash returns a digest of the settings a client is created from. The HTTP
+// client and logger aren't included.
+func (c *Config) hash() string {
+	h := sha256.New()
+	for _, s := range []string{
+		c.HomeserverURL, c.AdminAPIURL, c.AdminAPIPathPrefix, c.AccessToken, c.UserID, c.DeviceID,
+		c.ServerType, strconv.FormatBool(c.AdminMode), c.InitialDeviceDisplayName,
+		c.Username, c.Password, c.RegistrationSharedSecret, c.BotDisplayName, c.BotAvatarURL,
+		strconv.Itoa(c.MaxRetries), c.RetryMaxWait.String(), c.RequestTimeout.String(), c.ClientCertificate, c.ClientKey,
+		c.CACertificate, strconv.FormatBool(c.InsecureSkipVerify), c.ProxyURL,
+	} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// requestTimeout returns how long each request to the homeserver may take
+func (c *Config) requestTimeout() time.Duration {
+	if c.RequestTimeout > 0 {
+		return c.RequestTimeout
+	}
+	return defaultTimeout
+}
+
 // GetConfig extracts the configuration from the provider config
 func GetConfig(ctx context.Context, c client.Client, mg resource.Managed) (*Config, error) {
-	if pcr, ok := mg.(resource.ProviderConfigReferencer); ok {
+	if pcr, ok := mg.(resource.TypedProviderConfigReferencer); ok {
 		switch {
 		case pcr.GetProviderConfigReference() != nil:
 			return UseProviderConfig(ctx, c, mg)
@@ -149,18 +588,102 @@ func GetConfig(ctx context.Context, c client.Client, mg resource.Managed) (*Conf
 	return nil, errors.New("managed resource does not support provider config references")
 }
 
-// UseProviderConfig extracts configuration from a ProviderConfig
-func UseProviderConfig(ctx context.Context, c client.Client, mg resource.Managed) (*Config, error) {
-	pcr, ok := mg.(resource.ProviderConfigReferencer)
+// clusterProviderConfigKind is the kind namespaced managed resources
+// reference the cluster scoped ProviderConfig by, which is also the kind
+// their providerConfigRef defaults to
+const clusterProviderConfigKind = "ClusterProviderConfig"
+
+// ProviderConfigNamespace returns the namespace of the ProviderConfig mg
+// references through ref: none for cluster scoped resources and for
+// namespaced resources that reference a ClusterProviderConfig, and mg's own
+// namespace for namespaced resources that reference a ProviderConfig.
+func ProviderConfigNamespace(mg resource.Managed, ref *xpv1.ProviderConfigReference) string {
+	if ref.Kind == clusterProviderConfigKind {
+		return ""
+	}
+	return mg.GetNamespace()
+}
+
+// ProviderConfigName returns the name a ProviderConfig is known by: its name
+// if it is cluster scoped, or its namespace and name if it is namespaced, so
+// that ProviderConfigs of either scope can't be mistaken for each other.
+func ProviderConfigName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+// GetProviderConfig returns the ProviderConfig mg references. Cluster scoped
+// resources use the cluster scoped ProviderConfig of the referenced name,
+// whatever kind they reference it by. Namespaced resources use it if they
+// reference a ClusterProviderConfig, and the namespaced ProviderConfig of
+// their own namespace if they reference a ProviderConfig.
+func GetProviderConfig(ctx context.Context, c client.Client, mg resource.Managed) (v1beta1.ProviderConfigObject, error) {
+	pcr, ok := mg.(resource.TypedProviderConfigReferencer)
 	if !ok {
 		return nil, errors.New("managed resource does not support provider config references")
 	}
+	ref := pcr.GetProviderConfigReference()
+	if ref == nil {
+		return nil, errors.New("no credentials specified")
+	}
+
+	namespace := ProviderConfigNamespace(mg, ref)
+	if namespace == "" {
+		pc := &v1beta1.ProviderConfig{}
+		if err := c.Get(ctx, types.NamespacedName{Name: ref.Name}, pc); err != nil {
+			return nil, err
+		}
+		return pc, nil
+	}
+
+	if ref.Kind != namespacedv1beta1.ProviderConfigKind {
+		return nil, errors.Errorf("a namespaced resource can't reference a ProviderConfig of kind %q, only %s or %s", ref.Kind, namespacedv1beta1.ProviderConfigKind, clusterProviderConfigKind)
+	}
+	pc := &namespacedv1beta1.ProviderConfig{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ref.Name}, pc); err != nil {
+		return nil, err
+	}
+	return pc, nil
+}
+
+// namespacedSpec returns the spec of a ProviderConfig in namespace with
+// every Secret it references read from namespace, so that the tenants of a
+// namespace can't use the Secrets of another. Its credentials must be read
+// from a Secret, as the provider's environment and filesystem aren't theirs
+// to use either.
+func namespacedSpec(spec *v1beta1.ProviderConfigSpec, namespace string) (*v1beta1.ProviderConfigSpec, error) {
+	if spec.Credentials.Source != xpv1.CredentialsSourceSecret {
+		return nil, errors.Errorf("a namespaced ProviderConfig can only read its credentials from a Secret, not from %s", spec.Credentials.Source)
+	}
+
+	spec = spec.DeepCopy()
+	for _, ref := range []*xpv1.SecretKeySelector{spec.Credentials.SecretRef, spec.RegistrationSharedSecretRef, spec.CACertificateSecretRef} {
+		if ref != nil {
+			ref.Namespace = namespace
+		}
+	}
+	if spec.ClientCertificateSecretRef != nil {
+		spec.ClientCertificateSecretRef.Namespace = namespace
+	}
+	return spec, nil
+}
 
-	pc := &v1beta1.ProviderConfig{}
-	if err := c.Get(ctx, types.NamespacedName{Name: pcr.GetProviderConfigReference().Name}, pc); err != nil {
+// UseProviderConfig extracts configuration from a ProviderConfig
+func UseProviderConfig(ctx context.Context, c client.Client, mg resource.Managed) (*Config, error) {
+	pc, err := GetProviderConfig(ctx, c, mg)
+	if err != nil {
 		return nil, errors.Wrap(err, "cannot get referenced ProviderConfig")
 	}
 
+	spec := pc.GetProviderConfigSpec()
+	if pc.GetNamespace() != "" {
+		if spec, err = namespacedSpec(spec, pc.GetNamespace()); err != nil {
+			return nil, err
+		}
+	}
+
 	t := newProviderConfigUsageTracker(c)
 	modernManaged, ok := mg.(resource.ModernManaged)
 	if !ok {
@@ -170,7 +693,7 @@ func UseProviderConfig(ctx context.Context, c client.Client, mg resource.Managed
 		return nil, errors.Wrap(err, "cannot track ProviderConfig usage")
 	}
 
-	credBytes, err := resource.CommonCredentialExtractor(ctx, pc.Spec.Credentials.Source, c, pc.Spec.Credentials.CommonCredentialSelectors)
+	credBytes, err := resource.CommonCredentialExtractor(ctx, spec.Credentials.Source, c, spec.Credentials.CommonCredentialSelectors)
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot get credentials")
 	}
@@ -178,63 +701,405 @@ func UseProviderConfig(ctx context.Context, c client.Client, mg resource.Managed
 	if len(credBytes) == 0 {
 		return nil, errors.New("matrix access token not found in credentials")
 	}
-	accessToken := string(credBytes)
+	accessToken, login, err := parseCredentials(credBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot parse credentials")
+	}
+	username, password := "", ""
+	if login != nil {
+		username, password = login.Username, login.Password
+	}
+
+	adminAPIURL := spec.HomeserverURL
+	if spec.AdminAPIURL != nil {
+		adminAPIURL = *spec.AdminAPIURL
+	}
 
-	adminAPIURL := pc.Spec.HomeserverURL
-	if pc.Spec.AdminAPIURL != nil {
-		adminAPIURL = *pc.Spec.AdminAPIURL
+	adminAPIPathPrefix := ""
+	if spec.AdminAPIPathPrefix != nil {
+		adminAPIPathPrefix = *spec.AdminAPIPathPrefix
 	}
 
-	serverType := "auto"
-	if pc.Spec.ServerType != nil {
-		serverType = *pc.Spec.ServerType
+	serverType := ServerTypeAuto
+	if spec.ServerType != nil {
+		serverType = *spec.ServerType
 	}
 
 	adminMode := false
-	if pc.Spec.AdminMode != nil {
-		adminMode = *pc.Spec.AdminMode
+	if spec.AdminMode != nil {
+		adminMode = *spec.AdminMode
 	}
 
 	userID := ""
-	if pc.Spec.UserID != nil {
-		userID = *pc.Spec.UserID
+	if spec.UserID != nil {
+		userID = *spec.UserID
 	}
 
 	deviceID := ""
-	if pc.Spec.DeviceID != nil {
-		deviceID = *pc.Spec.DeviceID
+	if spec.DeviceID != nil {
+		deviceID = *spec.DeviceID
+	}
+
+	deviceDisplayName := defaultDeviceDisplayName
+	if spec.InitialDeviceDisplayName != nil {
+		deviceDisplayName = *spec.InitialDeviceDisplayName
+	}
+
+	botDisplayName := ""
+	if spec.BotDisplayName != nil {
+		botDisplayName = *spec.BotDisplayName
+	}
+
+	botAvatarURL := ""
+	if spec.BotAvatarURL != nil {
+		botAvatarURL = *spec.BotAvatarURL
+	}
+
+	maxRetries := DefaultMaxRetries
+	if spec.MaxRetries != nil {
+		maxRetries = *spec.MaxRetries
+	}
+
+	retryMaxWait := DefaultRetryMaxWait
+	if spec.RetryMaxWait != nil {
+		retryMaxWait = spec.RetryMaxWait.Duration
+	}
+
+	requestTimeout := defaultTimeout
+	if spec.RequestTimeout != nil {
+		if spec.RequestTimeout.Duration <= 0 {
+			return nil, errors.Errorf("requestTimeout must be positive, got %s", spec.RequestTimeout.Duration)
+		}
+		requestTimeout = spec.RequestTimeout.Duration
+	}
+
+	registrationSharedSecret := ""
+	if spec.RegistrationSharedSecretRef != nil {
+		secret, err := resource.CommonCredentialExtractor(ctx, xpv1.CredentialsSourceSecret, c, xpv1.CommonCredentialSelectors{
+			SecretRef: spec.RegistrationSharedSecretRef,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot get registration shared secret")
+		}
+		registrationSharedSecret = string(secret)
+	}
+
+	clientCertificate, clientKey := "", ""
+	if ref := spec.ClientCertificateSecretRef; ref != nil {
+		cert, err := resource.CommonCredentialExtractor(ctx, xpv1.CredentialsSourceSecret, c, xpv1.CommonCredentialSelectors{
+			SecretRef: &xpv1.SecretKeySelector{SecretReference: *ref, Key: corev1.TLSCertKey},
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot get client certificate")
+		}
+		key, err := resource.CommonCredentialExtractor(ctx, xpv1.CredentialsSourceSecret, c, xpv1.CommonCredentialSelectors{
+			SecretRef: &xpv1.SecretKeySelector{SecretReference: *ref, Key: corev1.TLSPrivateKeyKey},
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot get client certificate key")
+		}
+		clientCertificate, clientKey = string(cert), string(key)
+	}
+
+	caCertificate := ""
+	if spec.CACertificateSecretRef != nil {
+		ca, err := resource.CommonCredentialExtractor(ctx, xpv1.CredentialsSourceSecret, c, xpv1.CommonCredentialSelectors{
+			SecretRef: spec.CACertificateSecretRef,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot get CA certificate")
+		}
+		caCertificate = string(ca)
+	}
+
+	insecureSkipVerify := false
+	if spec.InsecureSkipVerify != nil {
+		insecureSkipVerify = *spec.InsecureSkipVerify
+	}
+
+	proxyURL := ""
+	if spec.ProxyURL != nil {
+		proxyURL = *spec.ProxyURL
 	}
 
 	return &Config{
-		HomeserverURL: pc.Spec.HomeserverURL,
+		HomeserverURL: spec.HomeserverURL,
 		AdminAPIURL:   adminAPIURL,
 		AccessToken:   accessToken,
 		UserID:        userID,
 		DeviceID:      deviceID,
 		ServerType:    serverType,
 		AdminMode:     adminMode,
+
+		AdminAPIPathPrefix:       adminAPIPathPrefix,
+		MaxRetries:               maxRetries,
+		RetryMaxWait:             retryMaxWait,
+		RequestTimeout:           requestTimeout,
+		InitialDeviceDisplayName: deviceDisplayName,
+		Username:                 username,
+		Password:                 password,
+		BotDisplayName:           botDisplayName,
+		BotAvatarURL:             botAvatarURL,
+		RegistrationSharedSecret: registrationSharedSecret,
+		ClientCertificate:        clientCertificate,
+		ClientKey:                clientKey,
+		CACertificate:            caCertificate,
+		InsecureSkipVerify:       insecureSkipVerify,
+		ProxyURL:                 proxyURL,
+		ProviderConfigName:       ProviderConfigName(pc.GetNamespace(), pc.GetName()),
 	}, nil
 }
 
-// IsNotFound checks if an error represents a "not found" condition
-func IsNotFound(err error) bool {
+// AliasConflictError is returned when a room alias is already in use. RoomID
+// is the room it points at, which may be the room it was wanted for.
+type AliasConflictError struct {
+	Alias  string
+	RoomID string
+}
+
+func (e *AliasConflictError) Error() string {
+	return fmt.Sprintf("alias %s is already in use by room %s", e.Alias, e.RoomID)
+}
+
+// ConcurrentModificationError is returned when a state event was changed by
+// someone else between being observed and being updated
+type ConcurrentModificationError struct {
+	RoomID    string
+	EventType string
+}
+
+func (e *ConcurrentModificationError) Error() string {
+	return fmt.Sprintf("%s in room %s was modified concurrently", e.EventType, e.RoomID)
+}
+
+// DeviceDeletionRefusedError is returned when the homeserver refuses to
+// delete a device through the admin API, either because it asks for
+// user-interactive authentication, which the provider can't complete, or
+// because the access token isn't allowed to
+type DeviceDeletionRefusedError struct {
+	UserID   string
+	DeviceID string
+
+	// ErrCode is the error the homeserver refused with, empty if it asked
+	// for interactive authentication
+	ErrCode string
+}
+
+func (e *DeviceDeletionRefusedError) Error() string {
+	if e.ErrCode == "" {
+		return fmt.Sprintf("deleting device %s of %s requires interactive authentication, which the provider cannot complete", e.DeviceID, e.UserID)
+	}
+	return fmt.Sprintf("the homeserver refused to delete device %s of %s with %s", e.DeviceID, e.UserID, e.ErrCode)
+}
+
+// PowerLevelLockoutError is returned when power levels would leave a user
+// below the level it takes to change a room's state, so that it couldn't
+// change the room, or undo the change, afterwards
+type PowerLevelLockoutError struct {
+	UserID string
+	Level  int
+
+	// Field is the power level the user would fall below, and Required its
+	// value
+	Field    string
+	Required int
+}
+
+func (e *PowerLevelLockoutError) Error() string {
+	return fmt.Sprintf("power levels would leave %s at level %d, below the %s level %d needed to keep changing the room", e.UserID, e.Level, e.Field, e.Required)
+}
+
+// RemoteUserError is returned when asked to change a user that belongs to
+// another homeserver, which only that homeserver can do
+type RemoteUserError struct {
+	UserID     string
+	ServerName string
+}
+
+func (e *RemoteUserError) Error() string {
+	return fmt.Sprintf("user %s does not belong to homeserver %s and can only be changed by its own homeserver", e.UserID, e.ServerName)
+}
+
+// UnavailableError is returned when the homeserver reports that it is
+// temporarily unable to handle requests, such as during maintenance
+type UnavailableError struct {
+	// RetryAfter is how long the homeserver asked clients to wait, if it
+	// said
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *UnavailableError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// APIError is returned when the admin API answers a request with an error
+type APIError struct {
+	StatusCode int
+
+	// ErrCode is the Matrix error code the homeserver answered with, such as
+	// M_NOT_FOUND, empty if the response didn't carry one
+	ErrCode string
+
+	// Body is the body of the response
+	Body string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("admin API request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// IsServerUnavailable checks if an error means the homeserver is up but has
+// said it cannot serve requests for now, because it is in maintenance or
+// shutting down. It also returns how long the homeserver asked clients to
+// wait, or zero if it did not say.
+func IsServerUnavailable(err error) (time.Duration, bool) {
+	if err == nil {
+		return 0, false
+	}
+
+	var unavailableErr *UnavailableError
+	if errors.As(err, &unavailableErr) {
+		return unavailableErr.RetryAfter, true
+	}
+
+	var httpErr mautrix.HTTPError
+	if !errors.As(err, &httpErr) {
+		return 0, false
+	}
+	if httpErr.Response != nil && httpErr.Response.StatusCode == http.StatusServiceUnavailable {
+		d, _ := retryAfterHeader(httpErr.Response.Header)
+		return d, true
+	}
+	if httpErr.RespError != nil && httpErr.RespError.ErrCode == "M_UNKNOWN" &&
+		strings.Contains(strings.ToLower(httpErr.RespError.Err), "shutting down") {
+		return 0, true
+	}
+	return 0, false
+}
+
+// IsUnreachable checks if an error means the homeserver could not be reached
+// at all, as opposed to it answering with an error. A homeserver in
+// maintenance, as told by IsServerUnavailable, is not unreachable: it is
+// expected back.
+func IsUnreachable(err error) bool {
 	if err == nil {
 		return false
 	}
+	if _, ok := IsServerUnavailable(err); ok {
+		return false
+	}
 
-	// Check for Matrix-specific not found errors
-	if mautrixErr, ok := err.(mautrix.HTTPError); ok {
-		return mautrixErr.RespError != nil && mautrixErr.RespError.ErrCode == "M_NOT_FOUND"
+	// A request the provider gave up on says nothing about the homeserver
+	if errors.Is(err, context.Canceled) {
+		return false
 	}
 
-	// Check for HTTP 404
-	if strings.Contains(err.Error(), "404") || strings.Contains(strings.ToLower(err.Error()), "not found") {
+	// Connection refused, DNS failures and timeouts
+	var netErr net.Error
+	if errors.As(err, &netErr) {
 		return true
 	}
 
+	// A reverse proxy in front of a homeserver that is gone
+	var httpErr mautrix.HTTPError
+	if errors.As(err, &httpErr) && httpErr.Response != nil {
+		switch httpErr.Response.StatusCode {
+		case http.StatusBadGateway, http.StatusGatewayTimeout:
+			return true
+		}
+	}
+
 	return false
 }
 
+// IsRateLimited checks if an error means the homeserver refused a request
+// because too many were made, even after retrying it
+func IsRateLimited(err error) bool {
+	return errors.Is(err, mautrix.MLimitExceeded)
+}
+
+// IsNotFound checks if an error represents a "not found" condition: an
+// M_NOT_FOUND error from the Matrix or the admin API, or a 404 response that
+// carried no Matrix error code at all
+func IsNotFound(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrCode == mautrix.MNotFound.ErrCode ||
+			(apiErr.ErrCode == "" && apiErr.StatusCode == http.StatusNotFound)
+	}
+
+	var httpErr mautrix.HTTPError
+	if errors.As(err, &httpErr) {
+		if httpErr.RespError != nil {
+			return httpErr.RespError.ErrCode == mautrix.MNotFound.ErrCode
+		}
+		return httpErr.IsStatus(http.StatusNotFound)
+	}
+
+	return errors.Is(err, mautrix.MNotFound)
+}
+
+// IsForbidden checks if an error means the homeserver refused a request
+// because the provider's user isn't allowed to make it, such as when it lacks
+// the power level to change a room's state: an M_FORBIDDEN error from the
+// Matrix or the admin API, or a 403 response that carried no Matrix error code
+func IsForbidden(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrCode == mautrix.MForbidden.ErrCode ||
+			(apiErr.ErrCode == "" && apiErr.StatusCode == http.StatusForbidden)
+	}
+
+	var httpErr mautrix.HTTPError
+	if errors.As(err, &httpErr) {
+		if httpErr.RespError != nil {
+			return httpErr.RespError.ErrCode == mautrix.MForbidden.ErrCode
+		}
+		return httpErr.IsStatus(http.StatusForbidden)
+	}
+
+	return errors.Is(err, mautrix.MForbidden)
+}
+
+// IsAuthenticationFailed checks if an error means the homeserver didn't
+// accept the provider's access token, because it is invalid, expired or
+// missing: an M_UNKNOWN_TOKEN or M_MISSING_TOKEN error from the Matrix or the
+// admin API, or a 401 response that carried no Matrix error code
+func IsAuthenticationFailed(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrCode == mautrix.MUnknownToken.ErrCode || apiErr.ErrCode == mautrix.MMissingToken.ErrCode ||
+			(apiErr.ErrCode == "" && apiErr.StatusCode == http.StatusUnauthorized)
+	}
+
+	var httpErr mautrix.HTTPError
+	if errors.As(err, &httpErr) {
+		if httpErr.RespError != nil {
+			return httpErr.RespError.ErrCode == mautrix.MUnknownToken.ErrCode || httpErr.RespError.ErrCode == mautrix.MMissingToken.ErrCode
+		}
+		return httpErr.IsStatus(http.StatusUnauthorized)
+	}
+
+	return errors.Is(err, mautrix.MUnknownToken) || errors.Is(err, mautrix.MMissingToken)
+}
+
 // Admin operations - delegate to adminClient
 func (c *matrixClient) ListUsers(ctx context.Context, from string, limit int) (*ListUsersResponse, error) {
 	return c.adminClient.listUsers(ctx, from, limit)
@@ -244,14 +1109,6 @@ func (c *matrixClient) ListRooms(ctx context.Context, from string, limit int) (*
 	return c.adminClient.listRooms(ctx, from, limit)
 }
 
-func (c *matrixClient) MakeRoomAdmin(ctx context.Context, roomID, userID string) error {
-	return c.adminClient.makeRoomAdmin(ctx, roomID, userID)
-}
-
-func (c *matrixClient) BlockRoom(ctx context.Context, roomID string, block bool) error {
-	return c.adminClient.blockRoom(ctx, roomID, block)
-}
-
 // Helper method to validate Matrix IDs
 func validateMatrixID(matrixID, idType string) error {
 	switch idType {
@@ -269,6 +1126,12 @@ func validateMatrixID(matrixID, idType string) error {
 		}
 	}
 
+	// From room version 12, a room ID is the hash of the room's create event,
+	// without a server name
+	if idType == "room" && len(matrixID) > 1 && !strings.Contains(matrixID, ":") {
+		return nil
+	}
+
 	parts := strings.Split(matrixID[1:], ":")
 	if len(parts) != 2 {
 		return fmt.Errorf("invalid Matrix ID format: %s", matrixID)
@@ -277,6 +1140,100 @@ func validateMatrixID(matrixID, idType string) error {
 	return nil
 }
 
+// RemoteRoom returns the server name of a room ID, and whether it differs from
+// that of userID, which makes the room one created on another homeserver.
+// Rooms are assumed to be local when either server name isn't known, as with
+// the room IDs of room version 12 and later.
+func RemoteRoom(roomID, userID string) (string, bool) {
+	_, roomServerName, _ := strings.Cut(roomID, ":")
+	_, serverName, _ := strings.Cut(userID, ":")
+	return roomServerName, roomServerName != "" && serverName != "" && roomServerName != serverName
+}
+
+// ParseMXCURI splits an mxc://<server-name>/<media-id> URI into the server
+// name of the homeserver the media was uploaded to and its media ID
+func ParseMXCURI(uri string) (string, string, error) {
+	rest, ok := strings.CutPrefix(uri, "mxc://")
+	if !ok {
+		return "", "", errors.Errorf("media URI must start with mxc://: %s", uri)
+	}
+	serverName, mediaID, ok := strings.Cut(rest, "/")
+	if !ok || serverName == "" || mediaID == "" {
+		return "", "", errors.Errorf("media URI must have the form mxc://<server-name>/<media-id>: %s", uri)
+	}
+	// Media IDs are opaque, but only ever made of URL-safe characters
+	for _, r := range mediaID {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return "", "", errors.Errorf("invalid media ID in media URI: %s", uri)
+		}
+	}
+	return serverName, mediaID, nil
+}
+
+// isRemoteRoom reports whether a room was created on another homeserver than
+// the provider's, whose admin API doesn't know about it
+func (c *matrixClient) isRemoteRoom(roomID string) bool {
+	_, remote := RemoteRoom(roomID, c.config.UserID)
+	return remote
+}
+
+// Helper method to validate third-party invites
+func validateThirdPartyInvite(invite ThirdPartyInvite) error {
+	switch invite.Medium {
+	case "email":
+		if !strings.Contains(invite.Address, "@") {
+			return fmt.Errorf("invalid email address: %s", invite.Address)
+		}
+	case "msisdn":
+		if invite.Address == "" {
+			return fmt.Errorf("phone number must not be empty")
+		}
+	default:
+		return fmt.Errorf("unsupported third-party medium: %s", invite.Medium)
+	}
+
+	if invite.IDServer == "" {
+		return fmt.Errorf("identity server must be specified for %s", invite.Address)
+	}
+
+	return nil
+}
+
+// validateSpaceSpec rejects settings that only make sense for ordinary rooms,
+// and state values that are not valid for a space
+func validateSpaceSpec(spaceSpec *SpaceSpec) error {
+	if spaceSpec.EncryptionEnabled {
+		return fmt.Errorf("spaces cannot be encrypted")
+	}
+	for _, state := range spaceSpec.InitialState {
+		if state.Type == event.StateEncryption.Type {
+			return fmt.Errorf("spaces cannot be encrypted: initial state must not contain %s", state.Type)
+		}
+	}
+
+	switch event.GuestAccess(spaceSpec.GuestAccess) {
+	case "", event.GuestAccessCanJoin, event.GuestAccessForbidden:
+	default:
+		return fmt.Errorf("unsupported guest access for a space: %s", spaceSpec.GuestAccess)
+	}
+
+	switch event.HistoryVisibility(spaceSpec.HistoryVisibility) {
+	case "", event.HistoryVisibilityWorldReadable, event.HistoryVisibilityShared,
+		event.HistoryVisibilityInvited, event.HistoryVisibilityJoined:
+	default:
+		return fmt.Errorf("unsupported history visibility for a space: %s", spaceSpec.HistoryVisibility)
+	}
+
+	switch event.JoinRule(spaceSpec.JoinRules) {
+	case "", event.JoinRulePublic, event.JoinRuleInvite, event.JoinRuleKnock,
+		event.JoinRuleRestricted, event.JoinRuleKnockRestricted:
+	default:
+		return fmt.Errorf("unsupported join rule for a space: %s", spaceSpec.JoinRules)
+	}
+
+	return nil
+}
+
 // Helper method to extract domain from Matrix ID
 func extractDomain(matrixID string) string {
 	parts := strings.Split(matrixID, ":")
@@ -286,6 +1243,120 @@ func extractDomain(matrixID string) string {
 	return ""
 }
 
+// UserIDMismatchError is returned when the access token of a ProviderConfig
+// belongs to another user than its UserID, so that operations would act as
+// one user while observations are made for the other
+type UserIDMismatchError struct {
+	UserID      string
+	TokenUserID string
+}
+
+func (e *UserIDMismatchError) Error() string {
+	return fmt.Sprintf("the access token belongs to %s, not to the configured userID %s", e.TokenUserID, e.UserID)
+}
+
+// VerifyConnection checks that service can act for the ProviderConfig pc
+// before a managed resource is connected to it: that the homeserver accepts
+// its access token, and that the token belongs to the ProviderConfig's user.
+// Every connector calls it, so that the checks and their errors are the same
+// for every kind of resource.
+func VerifyConnection(ctx context.Context, kube client.Client, pc v1beta1.ProviderConfigObject, service Client) error {
+	if err := recordInsecureTLS(ctx, kube, pc); err != nil {
+		return err
+	}
+	tokenUserID, err := service.WhoAmI(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot verify the access token")
+	}
+	if err := checkUserID(ctx, kube, pc, tokenUserID); err != nil {
+		return errors.Wrap(err, "cannot verify the ProviderConfig's userID")
+	}
+	if err := recordServerInfo(ctx, kube, pc, service); err != nil {
+		return errors.Wrap(err, "cannot record the homeserver's version")
+	}
+	return nil
+}
+
+// updateProviderConfigStatus writes the status of pc. Every resource that
+// uses pc connects to it, so a write that conflicts with another is dropped
+// rather than failing the connection; the next one writes it again.
+func updateProviderConfigStatus(ctx context.Context, kube client.Client, pc v1beta1.ProviderConfigObject) error {
+	if err := kube.Status().Update(ctx, pc); err != nil && !kerrors.IsConflict(err) {
+		return errors.Wrap(err, "cannot update ProviderConfig status")
+	}
+	return nil
+}
+
+// recordInsecureTLS reports in the ProviderConfig's InsecureTLS condition
+// whether it disables verification of the homeserver's TLS certificate, so
+// that doing so is visible to operators without the provider's logs
+func recordInsecureTLS(ctx context.Context, kube client.Client, pc v1beta1.ProviderConfigObject) error {
+	spec, status := pc.GetProviderConfigSpec(), pc.GetProviderConfigStatus()
+	previous := status.GetCondition(v1beta1.TypeInsecureTLS)
+	switch {
+	case spec.InsecureSkipVerify != nil && *spec.InsecureSkipVerify:
+		if previous.Status == corev1.ConditionTrue {
+			return nil
+		}
+		status.SetConditions(v1beta1.InsecureTLS())
+	case previous.Status == corev1.ConditionTrue:
+		status.SetConditions(v1beta1.SecureTLS())
+	default:
+		return nil
+	}
+	return updateProviderConfigStatus(ctx, kube, pc)
+}
+
+// checkUserID checks that the access token, which belongs to tokenUserID,
+// belongs to the user the ProviderConfig names, returning a
+// UserIDMismatchError if it doesn't. The outcome is reported in the
+// ProviderConfig's CredentialMismatch condition. ProviderConfigs without a
+// UserID aren't checked.
+func checkUserID(ctx context.Context, kube client.Client, pc v1beta1.ProviderConfigObject, tokenUserID string) error {
+	spec, status := pc.GetProviderConfigSpec(), pc.GetProviderConfigStatus()
+	if spec.UserID == nil {
+		return nil
+	}
+
+	userID := *spec.UserID
+	previous := status.GetCondition(v1beta1.TypeCredentialMismatch)
+	if tokenUserID != userID {
+		cond := v1beta1.CredentialMismatch(userID, tokenUserID)
+		if previous.Status != corev1.ConditionTrue || previous.Message != cond.Message {
+			status.SetConditions(cond)
+			if err := updateProviderConfigStatus(ctx, kube, pc); err != nil {
+				return err
+			}
+		}
+		return &UserIDMismatchError{UserID: userID, TokenUserID: tokenUserID}
+	}
+
+	if previous.Status == corev1.ConditionTrue {
+		status.SetConditions(v1beta1.CredentialsMatch())
+		return updateProviderConfigStatus(ctx, kube, pc)
+	}
+	return nil
+}
+
+// recordServerInfo records the type and version of the homeserver service
+// talks to in the ProviderConfig's status, so that operators can confirm that
+// the provider talks to the intended homeserver. A homeserver that doesn't
+// say leaves the status as it was, as not knowing its version doesn't keep
+// the provider from working.
+func recordServerInfo(ctx context.Context, kube client.Client, pc v1beta1.ProviderConfigObject, service Client) error {
+	info, err := service.ServerInfo(ctx)
+	if err != nil {
+		return nil
+	}
+	status := pc.GetProviderConfigStatus()
+	if status.ObservedServerType == info.Type && status.ServerVersion == info.Version {
+		return nil
+	}
+
+	status.ObservedServerType, status.ServerVersion = info.Type, info.Version
+	return updateProviderConfigStatus(ctx, kube, pc)
+}
+
 // providerConfigUsageTracker is a custom tracker that ensures ProviderConfigUsage
 // resources are created in the correct namespace and works with fake clients in tests.
 type providerConfigUsageTracker struct {
@@ -314,8 +1385,14 @@ func (t *providerConfigUsageTracker) Track(ctx context.Context, mg resource.Mode
 
 	pcRef := mg.GetProviderConfigReference()
 	if pcRef != nil {
+		// Namespaced resources may use a ProviderConfig of either scope,
+		// which their reference's kind tells apart
+		kind := "ProviderConfig"
+		if mg.GetNamespace() != "" {
+			kind = pcRef.Kind
+		}
 		pcu.ProviderConfigReference = xpv1.ProviderConfigReference{
-			Kind: "ProviderConfig",
+			Kind: kind,
 			Name: pcRef.Name,
 		}
 	}
@@ -17,10 +17,202 @@ limitations under the License.
 package clients
 
 import (
+	"context"
+	namespacedroomv1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/namespaced/room/v1alpha1"
+	namespacedv1beta1 "github.com/crossplane-contrib/provider-matrix/apis/namespaced/v1beta1"
+	roomv1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/room/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/apis/v1beta1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"maunium.net/go/mautrix"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
+func TestRequestTimeout(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, v1beta1.SchemeBuilder.AddToScheme(scheme))
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "crossplane-system", Name: "matrix-creds"},
+		Data:       map[string][]byte{"token": []byte("token")},
+	}
+	pc := &v1beta1.ProviderConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "timeout"},
+		Spec: v1beta1.ProviderConfigSpec{
+			HomeserverURL:  "https://timeout.example.com",
+			RequestTimeout: &metav1.Duration{Duration: time.Minute},
+			Credentials: v1beta1.ProviderCredentials{
+				Source: xpv1.CredentialsSourceSecret,
+				CommonCredentialSelectors: xpv1.CommonCredentialSelectors{
+					SecretRef: &xpv1.SecretKeySelector{
+						SecretReference: xpv1.SecretReference{Namespace: "crossplane-system", Name: "matrix-creds"},
+						Key:             "token",
+					},
+				},
+			},
+		},
+	}
+	kube := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret, pc).Build()
+
+	mg := &roomv1alpha1.Room{ObjectMeta: metav1.ObjectMeta{Name: "room", UID: "room-uid"}}
+	mg.SetProviderConfigReference(&xpv1.ProviderConfigReference{Kind: "ProviderConfig", Name: "timeout"})
+
+	config, err := GetConfig(context.Background(), kube, mg)
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, config.RequestTimeout)
+
+	config.AdminMode = true
+	c, err := NewClient(config)
+	require.NoError(t, err)
+	// The timeout bounds each attempt, not a request's retries together
+	assert.Equal(t, time.Minute, c.(*matrixClient).client.Client.Transport.(*retryTransport).timeout)
+
+	pc.Spec.RequestTimeout.Duration = 0
+	require.NoError(t, kube.Update(context.Background(), pc))
+	_, err = GetConfig(context.Background(), kube, mg)
+	assert.ErrorContains(t, err, "requestTimeout must be positive")
+}
+
+func TestGetProviderConfig(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1beta1.SchemeBuilder.AddToScheme(scheme))
+	require.NoError(t, namespacedv1beta1.SchemeBuilder.AddToScheme(scheme))
+
+	kube := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&v1beta1.ProviderConfig{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		&namespacedv1beta1.ProviderConfig{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "default"}},
+	).Build()
+
+	tests := []struct {
+		name          string
+		mg            resource.ModernManaged
+		kind          string
+		wantNamespace string
+		wantErr       bool
+	}{
+		{
+			name: "cluster scoped resource",
+			mg:   &roomv1alpha1.Room{ObjectMeta: metav1.ObjectMeta{Name: "room"}},
+			kind: "ProviderConfig",
+		},
+		{
+			name: "cluster scoped resource referencing a ClusterProviderConfig",
+			mg:   &roomv1alpha1.Room{ObjectMeta: metav1.ObjectMeta{Name: "room"}},
+			kind: "ClusterProviderConfig",
+		},
+		{
+			name:          "namespaced resource referencing a ProviderConfig",
+			mg:            &namespacedroomv1alpha1.Room{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "room"}},
+			kind:          "ProviderConfig",
+			wantNamespace: "team-a",
+		},
+		{
+			name: "namespaced resource referencing a ClusterProviderConfig",
+			mg:   &namespacedroomv1alpha1.Room{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "room"}},
+			kind: "ClusterProviderConfig",
+		},
+		{
+			name:    "namespaced resource without a ProviderConfig in its namespace",
+			mg:      &namespacedroomv1alpha1.Room{ObjectMeta: metav1.ObjectMeta{Namespace: "team-b", Name: "room"}},
+			kind:    "ProviderConfig",
+			wantErr: true,
+		},
+		{
+			name:    "namespaced resource referencing an unknown kind",
+			mg:      &namespacedroomv1alpha1.Room{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "room"}},
+			kind:    "OtherConfig",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mg.SetProviderConfigReference(&xpv1.ProviderConfigReference{Kind: tt.kind, Name: "default"})
+			pc, err := GetProviderConfig(context.Background(), kube, tt.mg)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantNamespace, pc.GetNamespace())
+		})
+	}
+}
+
+func TestNamespacedProviderConfigSecrets(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, v1beta1.SchemeBuilder.AddToScheme(scheme))
+	require.NoError(t, namespacedv1beta1.SchemeBuilder.AddToScheme(scheme))
+
+	pc := &namespacedv1beta1.ProviderConfig{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "default"},
+		Spec: v1beta1.ProviderConfigSpec{
+			HomeserverURL: "https://matrix.example.com",
+			Credentials: v1beta1.ProviderCredentials{
+				Source: xpv1.CredentialsSourceSecret,
+				CommonCredentialSelectors: xpv1.CommonCredentialSelectors{
+					SecretRef: &xpv1.SecretKeySelector{
+						// The Secret of another namespace isn't read
+						SecretReference: xpv1.SecretReference{Namespace: "crossplane-system", Name: "matrix-creds"},
+						Key:             "token",
+					},
+				},
+			},
+		},
+	}
+	kube := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "crossplane-system", Name: "matrix-creds"},
+			Data:       map[string][]byte{"token": []byte("admin-token")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "matrix-creds"},
+			Data:       map[string][]byte{"token": []byte("team-token")},
+		},
+		pc,
+	).Build()
+
+	mg := &namespacedroomv1alpha1.Room{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "room", UID: "room-uid"}}
+	mg.SetProviderConfigReference(&xpv1.ProviderConfigReference{Kind: "ProviderConfig", Name: "default"})
+
+	config, err := GetConfig(context.Background(), kube, mg)
+	require.NoError(t, err)
+	assert.Equal(t, "team-token", config.AccessToken)
+	assert.Equal(t, "team-a/default", config.ProviderConfigName)
+	assert.Equal(t, "crossplane-system", pc.Spec.Credentials.SecretRef.Namespace, "the ProviderConfig itself isn't changed")
+
+	// The provider's own environment isn't the namespace's to use
+	pc.Spec.Credentials = v1beta1.ProviderCredentials{
+		Source:                    xpv1.CredentialsSourceEnvironment,
+		CommonCredentialSelectors: xpv1.CommonCredentialSelectors{Env: &xpv1.EnvSelector{Name: "MATRIX_TOKEN"}},
+	}
+	require.NoError(t, kube.Update(context.Background(), pc))
+	_, err = GetConfig(context.Background(), kube, mg)
+	assert.ErrorContains(t, err, "can only read its credentials from a Secret")
+}
+
 func TestNewClient(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -92,6 +284,18 @@ func TestValidateMatrixID(t *testing.T) {
 			idType:   "room",
 			wantErr:  false,
 		},
+		{
+			name:     "valid room version 12 room ID",
+			matrixID: "!31hneApxJ_1o-63DmFrpeqnkFfWppnzWso1JvH3ogLM",
+			idType:   "room",
+			wantErr:  false,
+		},
+		{
+			name:     "empty room ID",
+			matrixID: "!",
+			idType:   "room",
+			wantErr:  true,
+		},
 		{
 			name:     "valid alias",
 			matrixID: "#room:example.com",
@@ -191,14 +395,45 @@ func TestIsNotFound(t *testing.T) {
 			want: false,
 		},
 		{
-			name: "404 in error message",
-			err:  assert.AnError,
-			want: false, // AnError doesn't contain "404"
+			name: "admin API not found",
+			err:  &APIError{StatusCode: http.StatusNotFound, ErrCode: "M_NOT_FOUND"},
+			want: true,
+		},
+		{
+			name: "admin API 404 without error code",
+			err:  &APIError{StatusCode: http.StatusNotFound},
+			want: true,
+		},
+		{
+			name: "admin API 404 for an unknown endpoint",
+			err:  &APIError{StatusCode: http.StatusNotFound, ErrCode: "M_UNRECOGNIZED"},
+			want: false,
+		},
+		{
+			name: "wrapped admin API not found",
+			err:  errors.Wrap(&APIError{StatusCode: http.StatusNotFound, ErrCode: "M_NOT_FOUND"}, "failed to get user"),
+			want: true,
+		},
+		{
+			name: "Matrix API not found",
+			err: errors.Wrap(mautrix.HTTPError{
+				Response:  &http.Response{StatusCode: http.StatusNotFound},
+				RespError: &mautrix.RespError{ErrCode: "M_NOT_FOUND", Err: "Room alias not found"},
+			}, "failed to resolve alias"),
+			want: true,
+		},
+		{
+			name: "Matrix API forbidden",
+			err: mautrix.HTTPError{
+				Response:  &http.Response{StatusCode: http.StatusForbidden},
+				RespError: &mautrix.RespError{ErrCode: "M_FORBIDDEN"},
+			},
+			want: false,
 		},
 		{
 			name: "not found in error message",
-			err:  assert.AnError,
-			want: false, // AnError doesn't contain "not found"
+			err:  errors.New("room 404 not found"),
+			want: false,
 		},
 	}
 
@@ -209,3 +444,873 @@ func TestIsNotFound(t *testing.T) {
 		})
 	}
 }
+
+func TestIsForbidden(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "nil error",
+			err:  nil,
+			want: false,
+		},
+		{
+			name: "admin API forbidden",
+			err:  &APIError{StatusCode: http.StatusForbidden, ErrCode: "M_FORBIDDEN"},
+			want: true,
+		},
+		{
+			name: "admin API 403 without error code",
+			err:  &APIError{StatusCode: http.StatusForbidden},
+			want: true,
+		},
+		{
+			name: "admin API not found",
+			err:  &APIError{StatusCode: http.StatusNotFound, ErrCode: "M_NOT_FOUND"},
+			want: false,
+		},
+		{
+			name: "Matrix API forbidden",
+			err: errors.Wrap(mautrix.HTTPError{
+				Response:  &http.Response{StatusCode: http.StatusForbidden},
+				RespError: &mautrix.RespError{ErrCode: "M_FORBIDDEN", Err: "You don't have permission to post that to the room"},
+			}, "failed to set room name"),
+			want: true,
+		},
+		{
+			name: "Matrix API unknown token",
+			err: mautrix.HTTPError{
+				Response:  &http.Response{StatusCode: http.StatusUnauthorized},
+				RespError: &mautrix.RespError{ErrCode: "M_UNKNOWN_TOKEN"},
+			},
+			want: false,
+		},
+		{
+			name: "forbidden in error message",
+			err:  errors.New("403 forbidden"),
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsForbidden(tt.err))
+		})
+	}
+}
+
+func TestIsAuthenticationFailed(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "nil error",
+			err:  nil,
+			want: false,
+		},
+		{
+			name: "admin API unknown token",
+			err:  &APIError{StatusCode: http.StatusUnauthorized, ErrCode: "M_UNKNOWN_TOKEN"},
+			want: true,
+		},
+		{
+			name: "admin API 401 without error code",
+			err:  &APIError{StatusCode: http.StatusUnauthorized},
+			want: true,
+		},
+		{
+			name: "admin API forbidden",
+			err:  &APIError{StatusCode: http.StatusForbidden, ErrCode: "M_FORBIDDEN"},
+			want: false,
+		},
+		{
+			name: "Matrix API unknown token",
+			err: errors.Wrap(mautrix.HTTPError{
+				Response:  &http.Response{StatusCode: http.StatusUnauthorized},
+				RespError: &mautrix.RespError{ErrCode: "M_UNKNOWN_TOKEN", Err: "Invalid access token passed."},
+			}, "failed to ask whom the access token belongs to"),
+			want: true,
+		},
+		{
+			name: "Matrix API missing token",
+			err: mautrix.HTTPError{
+				Response:  &http.Response{StatusCode: http.StatusUnauthorized},
+				RespError: &mautrix.RespError{ErrCode: "M_MISSING_TOKEN"},
+			},
+			want: true,
+		},
+		{
+			name: "unauthorized in error message",
+			err:  errors.New("401 unauthorized"),
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsAuthenticationFailed(tt.err))
+		})
+	}
+}
+
+func TestIsNotFoundFromHomeserver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_synapse/admin/v2/users/@alice:example.com":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errcode":"M_NOT_FOUND","error":"User not found"}`))
+		case "/_synapse/admin/v2/users/@bob:example.com":
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errcode":"M_FORBIDDEN","error":"You are not a server admin"}`))
+		case "/_matrix/client/v3/directory/room/#missing:example.com":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errcode":"M_NOT_FOUND","error":"Room alias #missing:example.com not found"}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@admin:example.com",
+		AdminMode:     true,
+	})
+	require.NoError(t, err)
+
+	_, err = c.GetUser(context.Background(), "@alice:example.com")
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "M_NOT_FOUND", apiErr.ErrCode)
+	assert.True(t, IsNotFound(err))
+
+	_, err = c.GetUser(context.Background(), "@bob:example.com")
+	require.Error(t, err)
+	assert.False(t, IsNotFound(err))
+
+	_, err = c.GetRoomAlias(context.Background(), "#missing:example.com")
+	require.Error(t, err)
+	assert.True(t, IsNotFound(err))
+}
+
+func TestValidateThirdPartyInvite(t *testing.T) {
+	tests := []struct {
+		name    string
+		invite  ThirdPartyInvite
+		wantErr bool
+	}{
+		{
+			name:   "valid email",
+			invite: ThirdPartyInvite{Medium: "email", Address: "alice@example.com", IDServer: "vector.im"},
+		},
+		{
+			name:   "valid msisdn",
+			invite: ThirdPartyInvite{Medium: "msisdn", Address: "447700900000", IDServer: "vector.im"},
+		},
+		{
+			name:    "invalid email address",
+			invite:  ThirdPartyInvite{Medium: "email", Address: "alice", IDServer: "vector.im"},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported medium",
+			invite:  ThirdPartyInvite{Medium: "fax", Address: "12345", IDServer: "vector.im"},
+			wantErr: true,
+		},
+		{
+			name:    "missing identity server",
+			invite:  ThirdPartyInvite{Medium: "email", Address: "alice@example.com"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateThirdPartyInvite(tt.invite)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateSpaceSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    SpaceSpec
+		wantErr bool
+	}{
+		{
+			name: "space settings",
+			spec: SpaceSpec{RoomSpec: RoomSpec{
+				GuestAccess:       "can_join",
+				HistoryVisibility: "world_readable",
+				JoinRules:         "public",
+			}},
+			wantErr: false,
+		},
+		{
+			name:    "encryption is room only",
+			spec:    SpaceSpec{RoomSpec: RoomSpec{EncryptionEnabled: true}},
+			wantErr: true,
+		},
+		{
+			name: "encryption in initial state",
+			spec: SpaceSpec{RoomSpec: RoomSpec{InitialState: []StateEvent{
+				{Type: "m.room.encryption", Content: map[string]interface{}{"algorithm": "m.megolm.v1.aes-sha2"}},
+			}}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown guest access",
+			spec:    SpaceSpec{RoomSpec: RoomSpec{GuestAccess: "sometimes"}},
+			wantErr: true,
+		},
+		{
+			name:    "private join rule",
+			spec:    SpaceSpec{RoomSpec: RoomSpec{JoinRules: "private"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSpaceSpec(&tt.spec)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestIsUnreachable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "nil error",
+			err:  nil,
+			want: false,
+		},
+		{
+			name: "connection refused",
+			err:  errors.Wrap(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, "failed to get user"),
+			want: true,
+		},
+		{
+			name: "bad gateway from a proxy",
+			err:  mautrix.HTTPError{Response: &http.Response{StatusCode: http.StatusBadGateway}},
+			want: true,
+		},
+		{
+			name: "homeserver error",
+			err:  mautrix.HTTPError{Response: &http.Response{StatusCode: http.StatusForbidden}, RespError: &mautrix.RespError{ErrCode: "M_FORBIDDEN"}},
+			want: false,
+		},
+		{
+			name: "maintenance",
+			err:  mautrix.HTTPError{Response: &http.Response{StatusCode: http.StatusServiceUnavailable}},
+			want: false,
+		},
+		{
+			name: "shutting down",
+			err:  mautrix.HTTPError{RespError: &mautrix.RespError{ErrCode: "M_UNKNOWN", Err: "Server is shutting down"}},
+			want: false,
+		},
+		{
+			name: "admin API in maintenance",
+			err:  &UnavailableError{RetryAfter: time.Minute},
+			want: false,
+		},
+		{
+			name: "cancelled request",
+			err:  &url.Error{Op: "Get", URL: "https://matrix.example.com", Err: context.Canceled},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsUnreachable(tt.err))
+		})
+	}
+}
+
+func TestIsServerUnavailable(t *testing.T) {
+	maintenance := &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{"Retry-After": []string{"120"}}}
+
+	tests := []struct {
+		name       string
+		err        error
+		want       bool
+		retryAfter time.Duration
+	}{
+		{
+			name: "nil error",
+			err:  nil,
+			want: false,
+		},
+		{
+			name:       "maintenance with retry-after",
+			err:        errors.Wrap(mautrix.HTTPError{Response: maintenance}, "failed to get room"),
+			want:       true,
+			retryAfter: 2 * time.Minute,
+		},
+		{
+			name: "maintenance without retry-after",
+			err:  mautrix.HTTPError{Response: &http.Response{StatusCode: http.StatusServiceUnavailable}},
+			want: true,
+		},
+		{
+			name: "shutting down",
+			err:  mautrix.HTTPError{Response: &http.Response{StatusCode: http.StatusInternalServerError}, RespError: &mautrix.RespError{ErrCode: "M_UNKNOWN", Err: "Server is shutting down"}},
+			want: true,
+		},
+		{
+			name: "other unknown error",
+			err:  mautrix.HTTPError{Response: &http.Response{StatusCode: http.StatusInternalServerError}, RespError: &mautrix.RespError{ErrCode: "M_UNKNOWN", Err: "Internal server error"}},
+			want: false,
+		},
+		{
+			name: "bad gateway from a proxy",
+			err:  mautrix.HTTPError{Response: &http.Response{StatusCode: http.StatusBadGateway}},
+			want: false,
+		},
+		{
+			name:       "admin API",
+			err:        errors.Wrap(&UnavailableError{RetryAfter: time.Minute, Err: errors.New("admin API request failed with status 503")}, "failed to get user"),
+			want:       true,
+			retryAfter: time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retryAfter, got := IsServerUnavailable(tt.err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.retryAfter, retryAfter)
+		})
+	}
+}
+
+func TestAdminHandleResponseUnavailable(t *testing.T) {
+	c := &adminClient{}
+	err := c.handleResponse(&http.Response{
+		StatusCode: http.StatusServiceUnavailable,
+		Header:     http.Header{"Retry-After": []string{"30"}},
+		Body:       io.NopCloser(strings.NewReader("down for maintenance")),
+	}, nil)
+	require.Error(t, err)
+
+	retryAfter, ok := IsServerUnavailable(err)
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, retryAfter)
+	assert.Contains(t, err.Error(), "down for maintenance")
+}
+
+func TestCachedClientTokenRotation(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, v1beta1.SchemeBuilder.AddToScheme(scheme))
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "crossplane-system", Name: "matrix-creds"},
+		Data:       map[string][]byte{"token": []byte("old_token")},
+	}
+	pc := &v1beta1.ProviderConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "rotation"},
+		Spec: v1beta1.ProviderConfigSpec{
+			HomeserverURL: "https://rotation.example.com",
+			Credentials: v1beta1.ProviderCredentials{
+				Source: xpv1.CredentialsSourceSecret,
+				CommonCredentialSelectors: xpv1.CommonCredentialSelectors{
+					SecretRef: &xpv1.SecretKeySelector{
+						SecretReference: xpv1.SecretReference{Namespace: "crossplane-system", Name: "matrix-creds"},
+						Key:             "token",
+					},
+				},
+			},
+		},
+	}
+	kube := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret, pc).Build()
+
+	mg := &roomv1alpha1.Room{ObjectMeta: metav1.ObjectMeta{Name: "room", UID: "room-uid"}}
+	mg.SetProviderConfigReference(&xpv1.ProviderConfigReference{Kind: "ProviderConfig", Name: "rotation"})
+
+	connect := func() Client {
+		config, err := GetConfig(context.Background(), kube, mg)
+		require.NoError(t, err)
+		c, err := CachedClient(config)
+		require.NoError(t, err)
+		return c
+	}
+
+	first := connect()
+	assert.Same(t, first, connect())
+
+	secret.Data["token"] = []byte("new_token")
+	require.NoError(t, kube.Update(context.Background(), secret))
+
+	rotated := connect()
+	assert.NotSame(t, first, rotated)
+	assert.Equal(t, "new_token", rotated.(*matrixClient).client.AccessToken)
+}
+
+func TestCachedClientEvictsOldConfig(t *testing.T) {
+	config := func(homeserver string) *Config {
+		return &Config{HomeserverURL: homeserver, AccessToken: "token", UserID: "@bot:example.com", ProviderConfigName: "eviction"}
+	}
+
+	cachedClientsMu.Lock()
+	before := len(cachedClients)
+	cachedClientsMu.Unlock()
+
+	first, err := CachedClient(config("https://old.example.com"))
+	require.NoError(t, err)
+	// Moving the ProviderConfig to another homeserver replaces its client
+	// rather than adding one
+	moved, err := CachedClient(config("https://new.example.com"))
+	require.NoError(t, err)
+	assert.NotSame(t, first, moved)
+
+	cachedClientsMu.Lock()
+	defer cachedClientsMu.Unlock()
+	assert.Len(t, cachedClients, before+1)
+	assert.Same(t, moved, cachedClients["providerconfig\x00eviction"].client)
+}
+
+func TestCachedClientCreatedOnce(t *testing.T) {
+	release := make(chan struct{})
+	var detections atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/_synapse/admin/v1/server_version" {
+			detections.Add(1)
+			<-release
+			_, _ = w.Write([]byte(`{"server_version": "1.120.0"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	slow := func() *Config {
+		return &Config{HomeserverURL: server.URL, AccessToken: "token", UserID: "@bot:example.com", AdminMode: true, ServerType: ServerTypeAuto, ProviderConfigName: "slow"}
+	}
+
+	// Resources connecting with the same configuration wait for one client
+	results := make(chan Client, 2)
+	for range 2 {
+		go func() {
+			c, err := CachedClient(slow())
+			assert.NoError(t, err)
+			results <- c
+		}()
+	}
+	require.Eventually(t, func() bool { return detections.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	// while other configurations aren't held up
+	_, err := CachedClient(&Config{HomeserverURL: "https://fast.example.com", AccessToken: "token", UserID: "@bot:example.com", ProviderConfigName: "fast"})
+	require.NoError(t, err)
+
+	close(release)
+	first, second := <-results, <-results
+	assert.Same(t, first, second)
+	assert.Equal(t, int32(1), detections.Load())
+}
+
+func TestDetectServerType(t *testing.T) {
+	cases := map[string]struct {
+		serverType string
+		handler    http.HandlerFunc
+		want       string
+	}{
+		"Synapse": {
+			serverType: ServerTypeAuto,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/_synapse/admin/v1/server_version" {
+					_, _ = w.Write([]byte(`{"server_version": "1.120.0"}`))
+					return
+				}
+				http.NotFound(w, r)
+			},
+			want: ServerTypeSynapse,
+		},
+		"Dendrite": {
+			serverType: ServerTypeAuto,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/_matrix/federation/v1/version" {
+					_, _ = w.Write([]byte(`{"server": {"name": "Dendrite", "version": "0.13.8"}}`))
+					return
+				}
+				http.NotFound(w, r)
+			},
+			want: ServerTypeDendrite,
+		},
+		"Unknown": {
+			serverType: ServerTypeAuto,
+			handler:    http.NotFound,
+			want:       "",
+		},
+		"Configured": {
+			serverType: ServerTypeConduit,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				t.Errorf("unexpected request to %s", r.URL.Path)
+			},
+			want: ServerTypeConduit,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(tc.handler)
+			defer server.Close()
+
+			c, err := NewClient(&Config{
+				HomeserverURL: server.URL,
+				AccessToken:   "test_token",
+				UserID:        "@admin:example.com",
+				AdminMode:     true,
+				ServerType:    tc.serverType,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, c.(*matrixClient).DetectedServerType())
+		})
+	}
+}
+
+func TestUnsupportedByServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/_matrix/federation/v1/version" {
+			_, _ = w.Write([]byte(`{"server": {"name": "Conduit", "version": "0.9.0"}}`))
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, "/_synapse/") && r.URL.Path != "/_synapse/admin/v1/server_version" {
+			t.Errorf("unexpected admin API request to %s", r.URL.Path)
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@admin:example.com",
+		AdminMode:     true,
+		ServerType:    ServerTypeAuto,
+	})
+	require.NoError(t, err)
+
+	err = c.DeactivateUser(context.Background(), "@alice:example.com")
+	assert.True(t, errors.Is(err, ErrUnsupportedByServer))
+	assert.False(t, IsNotFound(err))
+
+	err = c.DeleteRoom(context.Background(), "!room:example.com", DeleteRoomOptions{})
+	assert.True(t, errors.Is(err, ErrUnsupportedByServer))
+
+	err = c.MakeRoomAdmin(context.Background(), "!room:example.com", "@alice:example.com")
+	assert.True(t, errors.Is(err, ErrUnsupportedByServer))
+
+	_, err = c.GetRoomBlocked(context.Background(), "!room:example.com")
+	assert.True(t, errors.Is(err, ErrUnsupportedByServer))
+}
+
+func TestCheckUserID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_matrix/client/v3/account/whoami" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"user_id":"@bot:example.com"}`))
+	}))
+	defer server.Close()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1beta1.SchemeBuilder.AddToScheme(scheme))
+
+	other, bot := "@other:example.com", "@bot:example.com"
+	pc := &v1beta1.ProviderConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "whoami"},
+		Spec:       v1beta1.ProviderConfigSpec{HomeserverURL: server.URL, UserID: &other},
+	}
+	kube := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pc).WithStatusSubresource(pc).Build()
+
+	service, err := NewClient(&Config{HomeserverURL: server.URL, AccessToken: "token"})
+	require.NoError(t, err)
+
+	err = VerifyConnection(context.Background(), kube, pc, service)
+	var mismatch *UserIDMismatchError
+	require.ErrorAs(t, err, &mismatch)
+	assert.Equal(t, bot, mismatch.TokenUserID)
+
+	got := &v1beta1.ProviderConfig{}
+	require.NoError(t, kube.Get(context.Background(), types.NamespacedName{Name: "whoami"}, got))
+	assert.Equal(t, corev1.ConditionTrue, got.Status.GetCondition(v1beta1.TypeCredentialMismatch).Status)
+
+	// Correcting the userID clears the condition
+	got.Spec.UserID = &bot
+	require.NoError(t, VerifyConnection(context.Background(), kube, got, service))
+	require.NoError(t, kube.Get(context.Background(), types.NamespacedName{Name: "whoami"}, got))
+	assert.Equal(t, corev1.ConditionFalse, got.Status.GetCondition(v1beta1.TypeCredentialMismatch).Status)
+
+	// Without a userID there is nothing to check against
+	got.Spec.UserID = nil
+	assert.NoError(t, checkUserID(context.Background(), kube, got, "@anyone:example.com"))
+}
+
+func TestVerifyConnectionInsecureTLS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"user_id":"@bot:example.com"}`))
+	}))
+	defer server.Close()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1beta1.SchemeBuilder.AddToScheme(scheme))
+
+	insecure := true
+	pc := &v1beta1.ProviderConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "insecure"},
+		Spec:       v1beta1.ProviderConfigSpec{HomeserverURL: server.URL, InsecureSkipVerify: &insecure},
+	}
+	kube := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pc).WithStatusSubresource(pc).Build()
+
+	service, err := NewClient(&Config{HomeserverURL: server.URL, AccessToken: "token"})
+	require.NoError(t, err)
+	require.NoError(t, VerifyConnection(context.Background(), kube, pc, service))
+
+	got := &v1beta1.ProviderConfig{}
+	require.NoError(t, kube.Get(context.Background(), types.NamespacedName{Name: "insecure"}, got))
+	assert.Equal(t, corev1.ConditionTrue, got.Status.GetCondition(v1beta1.TypeInsecureTLS).Status)
+
+	// Verifying certificates again clears the condition
+	got.Spec.InsecureSkipVerify = nil
+	require.NoError(t, VerifyConnection(context.Background(), kube, got, service))
+	require.NoError(t, kube.Get(context.Background(), types.NamespacedName{Name: "insecure"}, got))
+	assert.Equal(t, corev1.ConditionFalse, got.Status.GetCondition(v1beta1.TypeInsecureTLS).Status)
+}
+
+func TestVerifyConnectionStatusConflict(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/_matrix/federation/v1/version" {
+			_, _ = w.Write([]byte(`{"server": {"name": "Dendrite", "version": "0.13.8"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"user_id":"@bot:example.com"}`))
+	}))
+	defer server.Close()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1beta1.SchemeBuilder.AddToScheme(scheme))
+
+	other := "@other:example.com"
+	pc := &v1beta1.ProviderConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "conflict"},
+		Spec:       v1beta1.ProviderConfigSpec{HomeserverURL: server.URL, UserID: &other},
+	}
+	// Another resource connecting at the same time updated the status first
+	kube := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pc).WithStatusSubresource(pc).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourceUpdate: func(ctx context.Context, c client.Client, subResource string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+				return kerrors.NewConflict(schema.GroupResource{Resource: "providerconfigs"}, obj.GetName(), errors.New("changed"))
+			},
+		}).Build()
+
+	service, err := NewClient(&Config{HomeserverURL: server.URL, AccessToken: "token"})
+	require.NoError(t, err)
+
+	// The mismatch is still reported, rather than the conflict
+	var mismatch *UserIDMismatchError
+	require.ErrorAs(t, VerifyConnection(context.Background(), kube, pc, service), &mismatch)
+	assert.False(t, kerrors.IsConflict(VerifyConnection(context.Background(), kube, pc, service)))
+
+	// Recording the homeserver's version is left for the next connection
+	pc.Spec.UserID = nil
+	assert.NoError(t, VerifyConnection(context.Background(), kube, pc, service))
+}
+
+func TestWhoAmI(t *testing.T) {
+	requests := 0
+	token := "valid_token"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"errcode":"M_UNKNOWN_TOKEN","error":"Invalid access token passed."}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"user_id":"@bot:example.com"}`))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{HomeserverURL: server.URL, AccessToken: "valid_token"})
+	require.NoError(t, err)
+
+	// Connecting many resources asks the homeserver once
+	for range 3 {
+		userID, err := c.WhoAmI(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "@bot:example.com", userID)
+	}
+	assert.Equal(t, 1, requests)
+
+	// A revoked token is noticed once the answer is forgotten, and so is
+	// the failure remembered
+	token = "new_token"
+	c.(*matrixClient).tokenUserIDAt = time.Now().Add(-whoAmITTL)
+	for range 3 {
+		_, err = c.WhoAmI(context.Background())
+		assert.True(t, IsAuthenticationFailed(err))
+	}
+	assert.Equal(t, 2, requests)
+
+	// A cancelled request says nothing about the token, so it isn't
+	// remembered
+	token = "valid_token"
+	c.(*matrixClient).tokenUserIDAt = time.Now().Add(-whoAmITTL)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.WhoAmI(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	userID, err := c.WhoAmI(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "@bot:example.com", userID)
+}
+
+func TestServerInfo(t *testing.T) {
+	cases := map[string]struct {
+		adminMode bool
+		handler   http.HandlerFunc
+		want      *ServerInfo
+		wantErr   bool
+	}{
+		"Federation": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/_matrix/federation/v1/version" {
+					_, _ = w.Write([]byte(`{"server": {"name": "Dendrite", "version": "0.13.8"}}`))
+					return
+				}
+				http.NotFound(w, r)
+			},
+			want: &ServerInfo{Type: ServerTypeDendrite, Name: "Dendrite", Version: "0.13.8"},
+		},
+		"UnknownSoftware": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/_matrix/federation/v1/version" {
+					_, _ = w.Write([]byte(`{"server": {"name": "Tuwunel", "version": "1.0.0"}}`))
+					return
+				}
+				http.NotFound(w, r)
+			},
+			want: &ServerInfo{Type: "tuwunel", Name: "Tuwunel", Version: "1.0.0"},
+		},
+		"SynapseAdminAPI": {
+			adminMode: true,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/_synapse/admin/v1/server_version" {
+					_, _ = w.Write([]byte(`{"server_version": "1.120.0"}`))
+					return
+				}
+				http.NotFound(w, r)
+			},
+			want: &ServerInfo{Type: ServerTypeSynapse, Name: "Synapse", Version: "1.120.0"},
+		},
+		"Unknown": {
+			handler: http.NotFound,
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				tc.handler(w, r)
+			}))
+			defer server.Close()
+
+			c, err := NewClient(&Config{
+				HomeserverURL: server.URL,
+				AccessToken:   "test_token",
+				UserID:        "@admin:example.com",
+				AdminMode:     tc.adminMode,
+			})
+			require.NoError(t, err)
+
+			info, err := c.ServerInfo(context.Background())
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tc.want, info)
+
+			// The homeserver is asked once, whatever it said
+			asked := requests
+			_, _ = c.ServerInfo(context.Background())
+			assert.Equal(t, asked, requests)
+
+			// but asked again soon if it couldn't say
+			c.(*matrixClient).serverInfoAt = time.Now().Add(-serverInfoErrorTTL)
+			_, _ = c.ServerInfo(context.Background())
+			assert.Equal(t, tc.wantErr, requests > asked)
+		})
+	}
+}
+
+func TestRecordServerInfo(t *testing.T) {
+	version := "0.13.8"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_matrix/federation/v1/version" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"server": {"name": "Dendrite", "version": "` + version + `"}}`))
+	}))
+	defer server.Close()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1beta1.SchemeBuilder.AddToScheme(scheme))
+
+	pc := &v1beta1.ProviderConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "version"},
+		Spec:       v1beta1.ProviderConfigSpec{HomeserverURL: server.URL},
+	}
+	kube := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pc).WithStatusSubresource(pc).Build()
+
+	service, err := NewClient(&Config{HomeserverURL: server.URL, AccessToken: "token"})
+	require.NoError(t, err)
+	require.NoError(t, recordServerInfo(context.Background(), kube, pc, service))
+
+	got := &v1beta1.ProviderConfig{}
+	require.NoError(t, kube.Get(context.Background(), types.NamespacedName{Name: "version"}, got))
+	assert.Equal(t, ServerTypeDendrite, got.Status.ObservedServerType)
+	assert.Equal(t, "0.13.8", got.Status.ServerVersion)
+
+	// A homeserver that is upgraded is noticed once what it said before is
+	// forgotten
+	version = "0.14.0"
+	service.(*matrixClient).serverInfoAt = time.Now().Add(-serverInfoTTL)
+	require.NoError(t, recordServerInfo(context.Background(), kube, got, service))
+	require.NoError(t, kube.Get(context.Background(), types.NamespacedName{Name: "version"}, got))
+	assert.Equal(t, "0.14.0", got.Status.ServerVersion)
+
+	// A homeserver that doesn't say leaves the status as it was
+	server.Config.Handler = http.NotFoundHandler()
+	service.(*matrixClient).serverInfoAt = time.Now().Add(-serverInfoTTL)
+	require.NoError(t, recordServerInfo(context.Background(), kube, got, service))
+	require.NoError(t, kube.Get(context.Background(), types.NamespacedName{Name: "version"}, got))
+	assert.Equal(t, "0.14.0", got.Status.ServerVersion)
+}
@@ -17,11 +17,22 @@ limitations under the License.
 package clients
 
 import (
+	"cmp"
 	"context"
+	"encoding/json"
 	"github.com/pkg/errors"
+	"maps"
+	"math"
 	"maunium.net/go/mautrix"
 	"maunium.net/go/mautrix/event"
 	"maunium.net/go/mautrix/id"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 // getIntValue returns the value of an int pointer or a default value
@@ -36,6 +47,15 @@ func getIntValue(ptr *int, defaultValue int) int {
 
 // CreateUser creates a new Matrix user
 func (c *matrixClient) CreateUser(ctx context.Context, userSpec *UserSpec) (*User, error) {
+	// Shared-secret registration works without an admin access token
+	if c.config.RegistrationSharedSecret != "" {
+		admin := c.adminClient
+		if admin == nil {
+			admin = newAdminClient(c.config)
+		}
+		return admin.registerUser(ctx, userSpec, c.config.RegistrationSharedSecret)
+	}
+
 	// Use admin API if available and enabled
 	if c.adminClient != nil {
 		return c.adminClient.createUser(ctx, userSpec)
@@ -78,6 +98,11 @@ func (c *matrixClient) UpdateUser(ctx context.Context, userID string, userSpec *
 		return nil, errors.Wrap(err, "invalid user ID")
 	}
 
+	// Neither API can change a user that another homeserver owns
+	if server := c.client.UserID.Homeserver(); server != "" && id.UserID(userID).Homeserver() != server {
+		return nil, &RemoteUserError{UserID: userID, ServerName: server}
+	}
+
 	// Use admin API if available
 	if c.adminClient != nil {
 		return c.adminClient.updateUser(ctx, userID, userSpec)
@@ -105,11 +130,61 @@ func (c *matrixClient) UpdateUser(ctx context.Context, userID string, userSpec *
 	return c.GetUser(ctx, userID)
 }
 
+// GetRateLimit returns the rate-limit override of a user, or nil if the user
+// is subject to the homeserver's default limits
+func (c *matrixClient) GetRateLimit(ctx context.Context, userID string) (*RateLimit, error) {
+	if c.adminClient == nil {
+		return nil, errors.New("rate-limit overrides require admin API access")
+	}
+
+	if err := validateMatrixID(userID, "user"); err != nil {
+		return nil, errors.Wrap(err, "invalid user ID")
+	}
+
+	return c.adminClient.getRateLimit(ctx, userID)
+}
+
+// SetRateLimit overrides the rate limits of a user
+func (c *matrixClient) SetRateLimit(ctx context.Context, userID string, rateLimit *RateLimit) error {
+	if c.adminClient == nil {
+		return errors.New("rate-limit overrides require admin API access")
+	}
+	if !c.synapseAdminAPI() {
+		return c.unsupportedByServer("rate-limit overrides")
+	}
+
+	if err := validateMatrixID(userID, "user"); err != nil {
+		return errors.Wrap(err, "invalid user ID")
+	}
+
+	return c.adminClient.setRateLimit(ctx, userID, rateLimit)
+}
+
+// DeleteRateLimit removes the rate-limit override of a user, subjecting them
+// to the homeserver's default limits again
+func (c *matrixClient) DeleteRateLimit(ctx context.Context, userID string) error {
+	if c.adminClient == nil {
+		return errors.New("rate-limit overrides require admin API access")
+	}
+	if !c.synapseAdminAPI() {
+		return c.unsupportedByServer("rate-limit overrides")
+	}
+
+	if err := validateMatrixID(userID, "user"); err != nil {
+		return errors.Wrap(err, "invalid user ID")
+	}
+
+	return c.adminClient.deleteRateLimit(ctx, userID)
+}
+
 // DeactivateUser deactivates a user account
 func (c *matrixClient) DeactivateUser(ctx context.Context, userID string) error {
 	if c.adminClient == nil {
 		return errors.New("user deactivation requires admin API access")
 	}
+	if !c.synapseAdminAPI() {
+		return c.unsupportedByServer("user deactivation")
+	}
 
 	if err := validateMatrixID(userID, "user"); err != nil {
 		return errors.Wrap(err, "invalid user ID")
@@ -118,15 +193,194 @@ func (c *matrixClient) DeactivateUser(ctx context.Context, userID string) error
 	return c.adminClient.deactivateUser(ctx, userID)
 }
 
+// LockUser locks a user out of their account, or unlocks them. Unlike
+// deactivation, locking can be undone and keeps the user's rooms.
+func (c *matrixClient) LockUser(ctx context.Context, userID string, locked bool) error {
+	if c.adminClient == nil {
+		return errors.New("locking users requires admin API access")
+	}
+
+	if err := validateMatrixID(userID, "user"); err != nil {
+		return errors.Wrap(err, "invalid user ID")
+	}
+
+	return c.adminClient.lockUser(ctx, userID, locked)
+}
+
+// ShadowBanUser shadow-bans a user, or lifts their shadow-ban. A shadow-banned
+// user can keep using their account, but what they send reaches no one else.
+func (c *matrixClient) ShadowBanUser(ctx context.Context, userID string, shadowBanned bool) error {
+	if c.adminClient == nil {
+		return errors.New("shadow-banning users requires admin API access")
+	}
+	if !c.synapseAdminAPI() {
+		return c.unsupportedByServer("shadow-banning users")
+	}
+
+	if err := validateMatrixID(userID, "user"); err != nil {
+		return errors.Wrap(err, "invalid user ID")
+	}
+
+	if shadowBanned {
+		return c.adminClient.shadowBanUser(ctx, userID)
+	}
+	return c.adminClient.unshadowBanUser(ctx, userID)
+}
+
+// GetDevices lists a user's devices
+func (c *matrixClient) GetDevices(ctx context.Context, userID string) ([]Device, error) {
+	if c.adminClient == nil {
+		return nil, errors.New("listing devices requires admin API access")
+	}
+
+	if err := validateMatrixID(userID, "user"); err != nil {
+		return nil, errors.Wrap(err, "invalid user ID")
+	}
+
+	return c.adminClient.listDevices(ctx, userID)
+}
+
+// SetDeviceDisplayName renames one of a user's devices
+func (c *matrixClient) SetDeviceDisplayName(ctx context.Context, userID, deviceID, displayName string) error {
+	if c.adminClient == nil {
+		return errors.New("renaming devices requires admin API access")
+	}
+
+	if err := validateMatrixID(userID, "user"); err != nil {
+		return errors.Wrap(err, "invalid user ID")
+	}
+
+	return c.adminClient.updateDevice(ctx, userID, deviceID, displayName)
+}
+
+// DeleteDevice deletes one of a user's devices, signing it out
+func (c *matrixClient) DeleteDevice(ctx context.Context, userID, deviceID string) error {
+	if c.adminClient == nil {
+		return errors.New("deleting devices requires admin API access")
+	}
+
+	if err := validateMatrixID(userID, "user"); err != nil {
+		return errors.Wrap(err, "invalid user ID")
+	}
+	if deviceID == "" {
+		return errors.New("device ID cannot be empty")
+	}
+
+	return c.adminClient.deleteDevice(ctx, userID, deviceID)
+}
+
+// GetConnections lists the connections a user has recently made to the
+// homeserver, with the IP addresses and user agents they came from
+func (c *matrixClient) GetConnections(ctx context.Context, userID string) ([]Connection, error) {
+	if c.adminClient == nil {
+		return nil, errors.New("listing connections requires admin API access")
+	}
+	if !c.synapseAdminAPI() {
+		return nil, c.unsupportedByServer("listing connections")
+	}
+
+	if err := validateMatrixID(userID, "user"); err != nil {
+		return nil, errors.Wrap(err, "invalid user ID")
+	}
+
+	return c.adminClient.getWhois(ctx, userID)
+}
+
+// Profile operations
+
+// syncedProfiles records the bot profiles this process has already applied,
+// so the homeserver is only contacted once for each desired profile
+var syncedProfiles sync.Map
+
+// SyncProfile sets the display name and avatar of the provider's own user to
+// the ones configured in the ProviderConfig, changing only what differs.
+func (c *matrixClient) SyncProfile(ctx context.Context) error {
+	if c.config.BotDisplayName == "" && c.config.BotAvatarURL == "" {
+		return nil
+	}
+	if c.config.UserID == "" {
+		return errors.New("userID must be set to manage the provider's profile")
+	}
+
+	key := strings.Join([]string{c.config.HomeserverURL, c.config.UserID, c.config.BotDisplayName, c.config.BotAvatarURL}, "\x00")
+	if _, ok := syncedProfiles.Load(key); ok {
+		return nil
+	}
+
+	if c.config.BotDisplayName != "" {
+		current, err := c.client.GetOwnDisplayName(ctx)
+		if err != nil && !IsNotFound(err) {
+			return errors.Wrap(err, "failed to get display name")
+		}
+		if current == nil || current.DisplayName != c.config.BotDisplayName {
+			if err := c.client.SetDisplayName(ctx, c.config.BotDisplayName); err != nil {
+				return errors.Wrap(err, "failed to set display name")
+			}
+		}
+	}
+
+	if c.config.BotAvatarURL != "" {
+		avatarURL, err := id.ParseContentURI(c.config.BotAvatarURL)
+		if err != nil {
+			return errors.Wrap(err, "invalid avatar URL")
+		}
+		current, err := c.client.GetOwnAvatarURL(ctx)
+		if err != nil && !IsNotFound(err) {
+			return errors.Wrap(err, "failed to get avatar URL")
+		}
+		if current != avatarURL {
+			if err := c.client.SetAvatarURL(ctx, avatarURL); err != nil {
+				return errors.Wrap(err, "failed to set avatar URL")
+			}
+		}
+	}
+
+	syncedProfiles.Store(key, struct{}{})
+	return nil
+}
+
+// whoAmITTL is how long the answer to whom the access token belongs to is
+// remembered, so that connecting many resources asks the homeserver once
+// while a revoked token or an unreachable homeserver is still noticed soon
+const whoAmITTL = 30 * time.Second
+
+// WhoAmI returns the user the client's access token belongs to, failing if
+// the homeserver can't be reached or doesn't accept the token. The answer,
+// either way, is remembered for a short while. Other failures, such as a
+// cancelled request, say nothing about the token and aren't remembered.
+func (c *matrixClient) WhoAmI(ctx context.Context) (string, error) {
+	c.tokenUserIDMu.Lock()
+	defer c.tokenUserIDMu.Unlock()
+	if !c.tokenUserIDAt.IsZero() && time.Since(c.tokenUserIDAt) < whoAmITTL {
+		return c.tokenUserID, c.tokenUserIDErr
+	}
+
+	resp, err := c.client.Whoami(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "failed to ask whom the access token belongs to")
+		if !IsAuthenticationFailed(err) && !IsUnreachable(err) {
+			return "", err
+		}
+		c.tokenUserID, c.tokenUserIDErr = "", err
+	} else {
+		c.tokenUserID, c.tokenUserIDErr = string(resp.UserID), nil
+	}
+	c.tokenUserIDAt = time.Now()
+	return c.tokenUserID, c.tokenUserIDErr
+}
+
 // Room operations
 
 // CreateRoom creates a new Matrix room
 func (c *matrixClient) CreateRoom(ctx context.Context, roomSpec *RoomSpec) (*Room, error) {
+	if err := c.checkAliasAvailable(ctx, roomSpec.Alias); err != nil {
+		return nil, err
+	}
+
 	// Build mautrix room creation request
 	req := &mautrix.ReqCreateRoom{
-		Name:            roomSpec.Name,
 		Topic:           roomSpec.Topic,
-		RoomAliasName:   roomSpec.Alias,
+		RoomAliasName:   aliasLocalpart(roomSpec.Alias),
 		Preset:          roomSpec.Preset,
 		Visibility:      roomSpec.Visibility,
 		RoomVersion:     id.RoomVersion(roomSpec.RoomVersion),
@@ -134,11 +388,27 @@ func (c *matrixClient) CreateRoom(ctx context.Context, roomSpec *RoomSpec) (*Roo
 		Invite:          make([]id.UserID, len(roomSpec.Invite)),
 	}
 
+	if roomSpec.Name != nil {
+		req.Name = *roomSpec.Name
+	}
+
 	// Convert invite list
 	for i, userID := range roomSpec.Invite {
 		req.Invite[i] = id.UserID(userID)
 	}
 
+	// Convert third-party invite list
+	for _, invite := range roomSpec.Invite3PID {
+		if err := validateThirdPartyInvite(invite); err != nil {
+			return nil, errors.Wrap(err, "invalid third-party invite")
+		}
+		req.Invite3PID = append(req.Invite3PID, mautrix.ReqInvite3PID{
+			IDServer: invite.IDServer,
+			Medium:   invite.Medium,
+			Address:  invite.Address,
+		})
+	}
+
 	// Convert initial state
 	for _, state := range roomSpec.InitialState {
 		req.InitialState = append(req.InitialState, &event.Event{
@@ -150,9 +420,15 @@ func (c *matrixClient) CreateRoom(ctx context.Context, roomSpec *RoomSpec) (*Roo
 
 	// Set power level overrides if provided
 	if roomSpec.PowerLevelOverrides != nil {
-		// Convert user IDs in power levels
+		// Convert user IDs in power levels, leaving out the creators of rooms
+		// whose version gives them unlimited power, as listing them makes the
+		// homeserver reject the room
+		creators := c.newRoomCreators(roomSpec)
 		userLevels := make(map[id.UserID]int)
 		for userID, level := range roomSpec.PowerLevelOverrides.Users {
+			if slices.Contains(creators, userID) {
+				continue
+			}
 			userLevels[id.UserID(userID)] = level
 		}
 
@@ -178,6 +454,22 @@ func (c *matrixClient) CreateRoom(ctx context.Context, roomSpec *RoomSpec) (*Roo
 	// Set additional room state if needed
 	roomID := resp.RoomID.String()
 
+	// The creation request only carries a plain topic
+	if roomSpec.RichTopic && roomSpec.Topic != "" {
+		_, err = c.client.SendStateEvent(ctx, resp.RoomID, event.StateTopic, "", topicContent(roomSpec))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to set room topic")
+		}
+	}
+
+	// Homeservers differ in whether an alias the room is created with
+	// becomes its canonical alias
+	if roomSpec.Alias != "" {
+		if err := c.updateCanonicalAlias(ctx, resp.RoomID, roomSpec); err != nil {
+			return nil, errors.Wrap(err, "failed to set canonical alias")
+		}
+	}
+
 	if roomSpec.GuestAccess != "" {
 		_, err = c.client.SendStateEvent(ctx, resp.RoomID, event.StateGuestAccess, "", &event.GuestAccessEventContent{
 			GuestAccess: event.GuestAccess(roomSpec.GuestAccess),
@@ -187,7 +479,8 @@ func (c *matrixClient) CreateRoom(ctx context.Context, roomSpec *RoomSpec) (*Roo
 		}
 	}
 
-	if roomSpec.HistoryVisibility != "" {
+	// The preset has already applied its history visibility
+	if roomSpec.HistoryVisibility != "" && roomSpec.HistoryVisibility != presetHistoryVisibility[roomSpec.Preset] {
 		_, err = c.client.SendStateEvent(ctx, resp.RoomID, event.StateHistoryVisibility, "", &event.HistoryVisibilityEventContent{
 			HistoryVisibility: event.HistoryVisibility(roomSpec.HistoryVisibility),
 		})
@@ -197,9 +490,14 @@ func (c *matrixClient) CreateRoom(ctx context.Context, roomSpec *RoomSpec) (*Roo
 	}
 
 	if roomSpec.JoinRules != "" {
-		_, err = c.client.SendStateEvent(ctx, resp.RoomID, event.StateJoinRules, "", &event.JoinRulesEventContent{
-			JoinRule: event.JoinRule(roomSpec.JoinRules),
-		})
+		content := &event.JoinRulesEventContent{JoinRule: event.JoinRule(roomSpec.JoinRules)}
+		for _, allowRoomID := range roomSpec.JoinRuleAllow {
+			content.Allow = append(content.Allow, event.JoinRuleAllow{
+				RoomID: id.RoomID(allowRoomID),
+				Type:   event.JoinRuleAllowRoomMembership,
+			})
+		}
+		_, err = c.client.SendStateEvent(ctx, resp.RoomID, event.StateJoinRules, "", content)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to set join rules")
 		}
@@ -215,13 +513,11 @@ func (c *matrixClient) CreateRoom(ctx context.Context, roomSpec *RoomSpec) (*Roo
 	}
 
 	if roomSpec.AvatarURL != "" {
-		avatarURL, err := id.ParseContentURI(roomSpec.AvatarURL)
+		content, err := roomAvatarContent(roomSpec.AvatarURL)
 		if err != nil {
-			return nil, errors.Wrap(err, "invalid avatar URL")
+			return nil, err
 		}
-		_, err = c.client.SendStateEvent(ctx, resp.RoomID, event.StateRoomAvatar, "", &event.RoomAvatarEventContent{
-			URL: avatarURL.CUString(),
-		})
+		_, err = c.client.SendStateEvent(ctx, resp.RoomID, event.StateRoomAvatar, "", content)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to set avatar")
 		}
@@ -230,6 +526,53 @@ func (c *matrixClient) CreateRoom(ctx context.Context, roomSpec *RoomSpec) (*Roo
 	return c.GetRoom(ctx, roomID)
 }
 
+// presetHistoryVisibility is the history visibility each room creation preset
+// applies. Without a preset the homeserver picks one from the room's
+// visibility, so every room starts out with shared history.
+var presetHistoryVisibility = map[string]string{
+	"":                     "shared",
+	"private_chat":         "shared",
+	"trusted_private_chat": "shared",
+	"public_chat":          "shared",
+}
+
+// newRoomCreators returns the users that will have unlimited power in a room
+// created from roomSpec: the provider's user and any additional creators, but
+// only if the requested room version gives creators that power
+func (c *matrixClient) newRoomCreators(roomSpec *RoomSpec) []string {
+	roomVersion := id.RoomVersion(roomSpec.RoomVersion)
+	if roomVersion == "" || !roomVersion.PrivilegedRoomCreators() {
+		return nil
+	}
+
+	creators := []string{c.config.UserID}
+	if additional, ok := roomSpec.CreationContent["additional_creators"].([]interface{}); ok {
+		for _, creator := range additional {
+			if userID, ok := creator.(string); ok {
+				creators = append(creators, userID)
+			}
+		}
+	}
+	return creators
+}
+
+// checkAliasAvailable returns an AliasConflictError if the alias already
+// resolves to a room
+func (c *matrixClient) checkAliasAvailable(ctx context.Context, aliasName string) error {
+	alias := c.fullAlias(aliasName)
+	if alias == "" {
+		return nil
+	}
+
+	resp, err := c.client.ResolveAlias(ctx, alias)
+	if err != nil {
+		// The alias is free, or we can't tell; let room creation decide
+		return nil
+	}
+
+	return &AliasConflictError{Alias: alias.String(), RoomID: resp.RoomID.String()}
+}
+
 // GetRoom retrieves room information
 func (c *matrixClient) GetRoom(ctx context.Context, roomID string) (*Room, error) {
 	if err := validateMatrixID(roomID, "room"); err != nil {
@@ -238,10 +581,24 @@ func (c *matrixClient) GetRoom(ctx context.Context, roomID string) (*Room, error
 
 	roomIDObj := id.RoomID(roomID)
 
-	// Try admin API first for comprehensive info
-	if c.adminClient != nil {
+	// The state of a room on another homeserver can only be read once the
+	// provider has joined it. Until then, the summary shared over federation
+	// is all there is to observe.
+	if c.isRemoteRoom(roomID) {
+		if membership, err := c.GetMembership(ctx, roomID); err == nil && membership != string(event.MembershipJoin) {
+			return c.roomSummary(ctx, roomID)
+		}
+	}
+
+	// Try admin API first for comprehensive info. It only knows the rooms
+	// of this homeserver.
+	if c.adminClient != nil && c.synapseAdminAPI() && !c.isRemoteRoom(roomID) {
 		room, err := c.adminClient.getRoomDetails(ctx, roomID)
 		if err == nil {
+			c.readCreateEvent(ctx, room)
+			c.readCanonicalAlias(ctx, room)
+			c.readEncryption(ctx, room)
+			c.readDirectoryVisibility(ctx, room)
 			return room, nil
 		}
 		// Fall back to standard API if admin fails
@@ -252,6 +609,8 @@ func (c *matrixClient) GetRoom(ctx context.Context, roomID string) (*Room, error
 		RoomID: roomID,
 	}
 
+	c.readCreateEvent(ctx, room)
+
 	// Get room name
 	var nameContent event.RoomNameEventContent
 	err := c.client.StateEvent(ctx, roomIDObj, event.StateRoomName, "", &nameContent)
@@ -260,17 +619,49 @@ func (c *matrixClient) GetRoom(ctx context.Context, roomID string) (*Room, error
 	}
 
 	// Get room topic
-	var topicContent event.TopicEventContent
-	err = c.client.StateEvent(ctx, roomIDObj, event.StateTopic, "", &topicContent)
+	var topic event.TopicEventContent
+	err = c.client.StateEvent(ctx, roomIDObj, event.StateTopic, "", &topic)
 	if err == nil {
-		room.Topic = topicContent.Topic
+		room.Topic = topic.Topic
+		readExtensibleTopic(room, topic.ExtensibleTopic)
 	}
 
-	// Get canonical alias
-	var aliasContent event.CanonicalAliasEventContent
-	err = c.client.StateEvent(ctx, roomIDObj, event.StateCanonicalAlias, "", &aliasContent)
-	if err == nil && aliasContent.Alias != "" {
-		room.Alias = aliasContent.Alias.String()
+	c.readCanonicalAlias(ctx, room)
+	c.readEncryption(ctx, room)
+	c.readDirectoryVisibility(ctx, room)
+
+	// Get join rules
+	rawJoinRules, err := c.joinRulesContent(ctx, roomIDObj)
+	if err == nil {
+		room.JoinRulesContent = rawJoinRules
+		joinRulesContent := parseJoinRules(rawJoinRules)
+		room.JoinRules = string(joinRulesContent.JoinRule)
+		for _, allow := range joinRulesContent.Allow {
+			if allow.Type == event.JoinRuleAllowRoomMembership {
+				room.JoinRuleAllow = append(room.JoinRuleAllow, allow.RoomID.String())
+			}
+		}
+	}
+
+	// Get guest access
+	if guestAccess, err := c.guestAccess(ctx, roomIDObj); err == nil {
+		room.GuestAccess = guestAccess
+	}
+
+	// Get pinned events
+	var pinnedContent event.PinnedEventsEventContent
+	err = c.client.StateEvent(ctx, roomIDObj, event.StatePinnedEvents, "", &pinnedContent)
+	if err == nil {
+		for _, eventID := range pinnedContent.Pinned {
+			room.PinnedEvents = append(room.PinnedEvents, eventID.String())
+		}
+	}
+
+	// Get history visibility
+	var historyContent event.HistoryVisibilityEventContent
+	err = c.client.StateEvent(ctx, roomIDObj, event.StateHistoryVisibility, "", &historyContent)
+	if err == nil {
+		room.HistoryVisibility = string(historyContent.HistoryVisibility)
 	}
 
 	// Get avatar
@@ -306,129 +697,2030 @@ func (c *matrixClient) GetRoom(ctx context.Context, roomID string) (*Room, error
 	return room, nil
 }
 
-// UpdateRoom updates room information
-func (c *matrixClient) UpdateRoom(ctx context.Context, roomID string, roomSpec *RoomSpec) (*Room, error) {
+// roomSummary returns the summary of a room that the room hierarchy shares
+// over federation, which is all that can be seen of a room without joining
+func (c *matrixClient) roomSummary(ctx context.Context, roomID string) (*Room, error) {
+	maxDepth := 0
+	resp, err := c.client.Hierarchy(ctx, id.RoomID(roomID), &mautrix.ReqHierarchy{Limit: 1, MaxDepth: &maxDepth})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get room summary")
+	}
+	if len(resp.Rooms) == 0 || resp.Rooms[0].RoomID != id.RoomID(roomID) {
+		return nil, errors.Errorf("homeserver returned no summary of room %s", roomID)
+	}
+
+	summary := resp.Rooms[0]
+	guestAccess := string(event.GuestAccessForbidden)
+	if summary.GuestCanJoin {
+		guestAccess = string(event.GuestAccessCanJoin)
+	}
+	return &Room{
+		RoomID:        roomID,
+		Name:          summary.Name,
+		Topic:         summary.Topic,
+		Alias:         summary.CanonicalAlias.String(),
+		AvatarURL:     string(summary.AvatarURL),
+		RoomVersion:   string(summary.RoomVersion),
+		RoomType:      string(summary.RoomType),
+		JoinedMembers: summary.NumJoinedMembers,
+		GuestAccess:   guestAccess,
+		JoinRules:     string(summary.JoinRule),
+		Summary:       true,
+	}, nil
+}
+
+// GetRoomState returns a room's current state events of the given types,
+// ordered by type and state key. The whole state is fetched at once, so this
+// is cheaper than reading the events one by one.
+func (c *matrixClient) GetRoomState(ctx context.Context, roomID string, eventTypes []string) ([]StateEvent, error) {
 	if err := validateMatrixID(roomID, "room"); err != nil {
 		return nil, errors.Wrap(err, "invalid room ID")
 	}
 
-	roomIDObj := id.RoomID(roomID)
+	var events []StateEvent
+	urlPath := c.client.BuildClientURL("v3", "rooms", roomID, "state")
+	if _, err := c.client.MakeRequest(ctx, http.MethodGet, urlPath, nil, &events); err != nil {
+		return nil, errors.Wrap(err, "failed to get room state")
+	}
 
-	// Update room name
-	if roomSpec.Name != "" {
-		_, err := c.client.SendStateEvent(ctx, roomIDObj, event.StateRoomName, "", &event.RoomNameEventContent{
-			Name: roomSpec.Name,
-		})
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to update room name")
-		}
+	state := slices.DeleteFunc(events, func(e StateEvent) bool {
+		return !slices.Contains(eventTypes, e.Type)
+	})
+	slices.SortFunc(state, func(a, b StateEvent) int {
+		return cmp.Or(strings.Compare(a.Type, b.Type), strings.Compare(a.StateKey, b.StateKey))
+	})
+	return state, nil
+}
+
+// readEncryption records whether a room is encrypted, which it is once it
+// has an m.room.encryption event naming an algorithm
+func (c *matrixClient) readEncryption(ctx context.Context, room *Room) {
+	var encryption event.EncryptionEventContent
+	if err := c.client.StateEvent(ctx, id.RoomID(room.RoomID), event.StateEncryption, "", &encryption); err != nil {
+		return
 	}
+	room.EncryptionEnabled = encryption.Algorithm != ""
+}
 
-	// Update room topic
-	if roomSpec.Topic != "" {
-		_, err := c.client.SendStateEvent(ctx, roomIDObj, event.StateTopic, "", &event.TopicEventContent{
-			Topic: roomSpec.Topic,
-		})
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to update room topic")
-		}
+// readDirectoryVisibility records whether a room is published in the
+// homeserver's room directory
+func (c *matrixClient) readDirectoryVisibility(ctx context.Context, room *Room) {
+	var resp struct {
+		Visibility string `json:"visibility"`
+	}
+	urlPath := c.client.BuildClientURL("v3", "directory", "list", "room", room.RoomID)
+	if _, err := c.client.MakeRequest(ctx, http.MethodGet, urlPath, nil, &resp); err != nil {
+		return
 	}
+	room.Visibility = resp.Visibility
+}
 
-	// Update other room settings as needed...
-	// (Similar pattern for other state events)
+// readCanonicalAlias fills in the canonical and alternative aliases of a room
+// from its m.room.canonical_alias event
+func (c *matrixClient) readCanonicalAlias(ctx context.Context, room *Room) {
+	var aliasContent event.CanonicalAliasEventContent
+	if err := c.client.StateEvent(ctx, id.RoomID(room.RoomID), event.StateCanonicalAlias, "", &aliasContent); err != nil {
+		return
+	}
 
-	return c.GetRoom(ctx, roomID)
+	if aliasContent.Alias != "" {
+		room.Alias = aliasContent.Alias.String()
+	}
+	room.AltAliases = nil
+	for _, alias := range aliasContent.AltAliases {
+		room.AltAliases = append(room.AltAliases, alias.String())
+	}
 }
 
-// DeleteRoom deletes a room
-func (c *matrixClient) DeleteRoom(ctx context.Context, roomID string) error {
-	if c.adminClient == nil {
-		return errors.New("room deletion requires admin API access")
-	}
+// fullAlias returns the full form of an alias, qualifying a bare local alias
+// name with the provider's homeserver
+func (c *matrixClient) fullAlias(alias string) id.RoomAlias {
+	return id.RoomAlias(FullAlias(alias, c.client.UserID.String()))
+}
 
-	if err := validateMatrixID(roomID, "room"); err != nil {
-		return errors.Wrap(err, "invalid room ID")
+// FullAlias returns the full form of an alias, qualifying a bare local alias
+// name with the server name of userID. It is empty if a bare name can't be
+// qualified.
+func FullAlias(alias, userID string) string {
+	if alias == "" || strings.HasPrefix(alias, "#") {
+		return alias
 	}
 
-	options := map[string]interface{}{
-		"block": false,
-		"purge": true,
+	domain := id.UserID(userID).Homeserver()
+	if domain == "" {
+		return ""
 	}
+	return id.NewRoomAlias(alias, domain).String()
+}
 
-	return c.adminClient.deleteRoom(ctx, roomID, options)
+// aliasLocalpart returns the local alias name of an alias, which may be given
+// in full (#name:server) or as the bare name
+func aliasLocalpart(alias string) string {
+	if !strings.HasPrefix(alias, "#") {
+		return alias
+	}
+	localpart, _, _ := strings.Cut(alias[1:], ":")
+	return localpart
 }
 
-// Power level operations
+// topicContent builds the m.room.topic content for a room spec. With
+// RichTopic set it also carries the MSC3765 m.topic block, listing the HTML
+// representation first as clients pick the first one they support.
+func topicContent(roomSpec *RoomSpec) *event.TopicEventContent {
+	content := &event.TopicEventContent{Topic: roomSpec.Topic}
+	if !roomSpec.RichTopic {
+		return content
+	}
 
-// SetPowerLevels sets power levels in a room
-func (c *matrixClient) SetPowerLevels(ctx context.Context, roomID string, powerLevels *PowerLevelSpec) error {
-	if err := validateMatrixID(roomID, "room"); err != nil {
-		return errors.Wrap(err, "invalid room ID")
+	content.ExtensibleTopic = &event.ExtensibleTopic{}
+	if roomSpec.TopicHTML != "" {
+		content.ExtensibleTopic.Text = append(content.ExtensibleTopic.Text, event.ExtensibleText{
+			MimeType: "text/html",
+			Body:     roomSpec.TopicHTML,
+		})
 	}
+	content.ExtensibleTopic.Text = append(content.ExtensibleTopic.Text, event.ExtensibleText{
+		MimeType: "text/plain",
+		Body:     roomSpec.Topic,
+	})
+	return content
+}
 
-	roomIDObj := id.RoomID(roomID)
+// readExtensibleTopic records a room's MSC3765 m.topic block. The topic only
+// counts as rich while the block's plain text matches the legacy topic, as
+// clients that don't know m.topic leave it stale when changing the topic.
+func readExtensibleTopic(room *Room, topic *event.ExtensibleTopic) {
+	if topic == nil {
+		return
+	}
 
-	// Convert user IDs to mautrix format
-	users := make(map[id.UserID]int)
-	for userID, level := range powerLevels.PowerLevels.Users {
-		users[id.UserID(userID)] = level
+	for _, text := range topic.Text {
+		switch text.MimeType {
+		case "text/html":
+			room.TopicHTML = text.Body
+		case "", "text/plain":
+			if text.Body == room.Topic {
+				room.RichTopic = true
+			}
+		}
 	}
+}
 
-	content := &event.PowerLevelsEventContent{
-		Users:           users,
-		Events:          powerLevels.PowerLevels.Events,
-		EventsDefault:   getIntValue(powerLevels.PowerLevels.EventsDefault, 0),
-		StateDefaultPtr: powerLevels.PowerLevels.StateDefault,
-		UsersDefault:    getIntValue(powerLevels.PowerLevels.UsersDefault, 0),
-		BanPtr:          powerLevels.PowerLevels.Ban,
-		KickPtr:         powerLevels.PowerLevels.Kick,
-		RedactPtr:       powerLevels.PowerLevels.Redact,
-		InvitePtr:       powerLevels.PowerLevels.Invite,
+// readCreateEvent fills in the fields of a room that come from its
+// m.room.create event. Errors are ignored as the event is informational.
+func (c *matrixClient) readCreateEvent(ctx context.Context, room *Room) {
+	var createContent event.CreateEventContent
+	if err := c.client.StateEvent(ctx, id.RoomID(room.RoomID), event.StateCreate, "", &createContent); err != nil {
+		return
 	}
 
-	_, err := c.client.SendStateEvent(ctx, roomIDObj, event.StatePowerLevels, "", content)
-	if err != nil {
-		return errors.Wrap(err, "failed to set power levels")
+	if room.Creator == "" {
+		room.Creator = createContent.Creator.String()
+	}
+	if room.RoomVersion == "" {
+		room.RoomVersion = string(createContent.RoomVersion)
+	}
+	if room.RoomType == "" {
+		room.RoomType = string(createContent.Type)
+	}
+	if createContent.Predecessor != nil {
+		room.Predecessor = &RoomPredecessor{
+			RoomID:  createContent.Predecessor.RoomID.String(),
+			EventID: createContent.Predecessor.EventID.String(),
+		}
 	}
 
-	return nil
+	room.PrivilegedCreators = c.privilegedCreators(ctx, id.RoomID(room.RoomID), &createContent)
+	if room.Creator == "" && len(room.PrivilegedCreators) > 0 {
+		room.Creator = room.PrivilegedCreators[0]
+	}
 }
 
-// GetPowerLevels retrieves power levels from a room
-func (c *matrixClient) GetPowerLevels(ctx context.Context, roomID string) (*PowerLevelContent, error) {
+// GetPrivilegedCreators returns the users with unlimited power in a room
+// because they created it
+func (c *matrixClient) GetPrivilegedCreators(ctx context.Context, roomID string) ([]string, error) {
 	if err := validateMatrixID(roomID, "room"); err != nil {
 		return nil, errors.Wrap(err, "invalid room ID")
 	}
 
 	roomIDObj := id.RoomID(roomID)
-	var powerContent event.PowerLevelsEventContent
-	err := c.client.StateEvent(ctx, roomIDObj, event.StatePowerLevels, "", &powerContent)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to get power levels")
+	var createContent event.CreateEventContent
+	if err := c.client.StateEvent(ctx, roomIDObj, event.StateCreate, "", &createContent); err != nil {
+		return nil, errors.Wrap(err, "failed to get room create event")
 	}
 
-	// Convert user IDs from mautrix format to our format
-	users := make(map[string]int)
-	for userID, level := range powerContent.Users {
-		users[string(userID)] = level
+	return c.privilegedCreators(ctx, roomIDObj, &createContent), nil
+}
+
+// privilegedCreators returns the creators of a room whose version gives them
+// unlimited power, which is the case from room version 12. Such users cannot
+// appear in m.room.power_levels.
+func (c *matrixClient) privilegedCreators(ctx context.Context, roomID id.RoomID, createContent *event.CreateEventContent) []string {
+	if createContent.RoomVersion == "" || !createContent.SupportsCreatorPower() {
+		return nil
 	}
 
-	return &PowerLevelContent{
-		Users:         users,
-		Events:        powerContent.Events,
-		EventsDefault: &powerContent.EventsDefault,
-		StateDefault:  powerContent.StateDefaultPtr,
-		UsersDefault:  &powerContent.UsersDefault,
-		Ban:           powerContent.BanPtr,
+	var creators []string
+	sender := c.roomCreator(ctx, roomID, createContent)
+	if sender != "" {
+		creators = append(creators, sender.String())
+	}
+	for _, creator := range createContent.AdditionalCreators {
+		creators = append(creators, creator.String())
+	}
+	return creators
+}
+
+// roomCreator returns the user that created a room, or an empty user ID if it
+// can't be told
+func (c *matrixClient) roomCreator(ctx context.Context, roomID id.RoomID, createContent *event.CreateEventContent) id.UserID {
+	if createContent.Creator != "" {
+		return createContent.Creator
+	}
+
+	// The creator field is gone from room versions 11 and later, so the
+	// sender of the create event has to be looked up
+	evt, err := c.client.FullStateEvent(ctx, roomID, event.StateCreate, "")
+	if err != nil || evt == nil {
+		return ""
+	}
+	return evt.Sender
+}
+
+// GetJoinRules returns the join rule of a room
+func (c *matrixClient) GetJoinRules(ctx context.Context, roomID string) (string, error) {
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return "", errors.Wrap(err, "invalid room ID")
+	}
+
+	var content event.JoinRulesEventContent
+	if err := c.client.StateEvent(ctx, id.RoomID(roomID), event.StateJoinRules, "", &content); err != nil {
+		return "", errors.Wrap(err, "failed to get join rules")
+	}
+
+	return string(content.JoinRule), nil
+}
+
+// maxUpgradeHops bounds how many room upgrades are followed, in case
+// tombstones point at each other
+const maxUpgradeHops = 16
+
+// GetReplacementRoom follows the tombstones that room upgrades leave behind
+// and returns the room that replaced a room, or the room itself if it hasn't
+// been upgraded
+func (c *matrixClient) GetReplacementRoom(ctx context.Context, roomID string) (string, error) {
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return "", errors.Wrap(err, "invalid room ID")
+	}
+
+	current := id.RoomID(roomID)
+	for range maxUpgradeHops {
+		var content event.TombstoneEventContent
+		err := c.client.StateEvent(ctx, current, event.StateTombstone, "", &content)
+		if IsNotFound(err) {
+			break
+		}
+		if err != nil {
+			if current.String() == roomID {
+				return "", errors.Wrap(err, "failed to get tombstone")
+			}
+			// The provider may not be able to see into a replacement room
+			// yet, so stop at the newest room it knows about
+			break
+		}
+		if content.ReplacementRoom == "" {
+			break
+		}
+		current = content.ReplacementRoom
+	}
+
+	return current.String(), nil
+}
+
+// RoomExists reports whether a room exists, by asking for its summary. The
+// summary is refused rather than missing for rooms the provider can't see,
+// and is shared over federation for rooms of other homeservers. Only an
+// M_NOT_FOUND error means the room is gone; the admin API isn't asked, as it
+// doesn't know rooms the homeserver is no longer in.
+func (c *matrixClient) RoomExists(ctx context.Context, roomID string) (bool, error) {
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return false, errors.Wrap(err, "invalid room ID")
+	}
+
+	maxDepth := 0
+	_, err := c.client.Hierarchy(ctx, id.RoomID(roomID), &mautrix.ReqHierarchy{Limit: 1, MaxDepth: &maxDepth})
+	switch {
+	case err == nil, errors.Is(err, mautrix.MForbidden):
+		return true, nil
+	case errors.Is(err, mautrix.MNotFound):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// RemoveJoinRuleAllow removes the allow conditions referencing the given rooms
+// from a room's join rules
+func (c *matrixClient) RemoveJoinRuleAllow(ctx context.Context, roomID string, allowRoomIDs []string) error {
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return errors.Wrap(err, "invalid room ID")
+	}
+
+	roomIDObj := id.RoomID(roomID)
+	content, err := c.joinRulesContent(ctx, roomIDObj)
+	if err != nil {
+		return errors.Wrap(err, "failed to get join rules")
+	}
+
+	allow, _ := content["allow"].([]interface{})
+	content["allow"] = slices.DeleteFunc(allow, func(entry interface{}) bool {
+		condition, _ := entry.(map[string]interface{})
+		roomID, _ := condition["room_id"].(string)
+		return slices.Contains(allowRoomIDs, roomID)
+	})
+
+	_, err = c.client.SendStateEvent(ctx, roomIDObj, event.StateJoinRules, "", content)
+	return errors.Wrap(err, "failed to update join rules")
+}
+
+// joinRulesContent returns the raw content of a room's join rules. Join rules
+// are written back from their raw content so that fields this provider
+// doesn't model are never dropped.
+func (c *matrixClient) joinRulesContent(ctx context.Context, roomID id.RoomID) (map[string]interface{}, error) {
+	content := map[string]interface{}{}
+	if err := c.client.StateEvent(ctx, roomID, event.StateJoinRules, "", &content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// parseJoinRules decodes the fields of raw join rules content that this
+// provider models
+func parseJoinRules(raw map[string]interface{}) event.JoinRulesEventContent {
+	var content event.JoinRulesEventContent
+	if data, err := json.Marshal(raw); err == nil {
+		_ = json.Unmarshal(data, &content)
+	}
+	return content
+}
+
+// guestAccess returns a room's guest access. A room without an
+// m.room.guest_access event forbids guests.
+func (c *matrixClient) guestAccess(ctx context.Context, roomID id.RoomID) (string, error) {
+	var content event.GuestAccessEventContent
+	err := c.client.StateEvent(ctx, roomID, event.StateGuestAccess, "", &content)
+	if IsNotFound(err) || (err == nil && content.GuestAccess == "") {
+		return string(event.GuestAccessForbidden), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(content.GuestAccess), nil
+}
+
+// updateGuestAccess sets a room's guest access if it differs
+func (c *matrixClient) updateGuestAccess(ctx context.Context, roomID id.RoomID, guestAccess string) error {
+	current, err := c.guestAccess(ctx, roomID)
+	if err == nil && current == guestAccess {
+		return nil
+	}
+
+	_, err = c.client.SendStateEvent(ctx, roomID, event.StateGuestAccess, "", &event.GuestAccessEventContent{
+		GuestAccess: event.GuestAccess(guestAccess),
+	})
+	return err
+}
+
+// updateName sets a room's name if it differs
+func (c *matrixClient) updateName(ctx context.Context, roomID id.RoomID, name string) error {
+	var current event.RoomNameEventContent
+	if err := c.client.StateEvent(ctx, roomID, event.StateRoomName, "", &current); err == nil && current.Name == name {
+		return nil
+	}
+
+	_, err := c.client.SendStateEvent(ctx, roomID, event.StateRoomName, "", &event.RoomNameEventContent{
+		Name: name,
+	})
+	return err
+}
+
+// updateTopic sets a room's topic if it differs, including its rich text
+// representations
+func (c *matrixClient) updateTopic(ctx context.Context, roomID id.RoomID, roomSpec *RoomSpec) error {
+	content := topicContent(roomSpec)
+
+	var current event.TopicEventContent
+	if err := c.client.StateEvent(ctx, roomID, event.StateTopic, "", &current); err == nil && current.Topic == content.Topic && reflect.DeepEqual(current.ExtensibleTopic, content.ExtensibleTopic) {
+		return nil
+	}
+
+	_, err := c.client.SendStateEvent(ctx, roomID, event.StateTopic, "", content)
+	return err
+}
+
+// updateHistoryVisibility sets a room's history visibility if it differs
+func (c *matrixClient) updateHistoryVisibility(ctx context.Context, roomID id.RoomID, historyVisibility string) error {
+	var current event.HistoryVisibilityEventContent
+	if err := c.client.StateEvent(ctx, roomID, event.StateHistoryVisibility, "", &current); err == nil && string(current.HistoryVisibility) == historyVisibility {
+		return nil
+	}
+
+	_, err := c.client.SendStateEvent(ctx, roomID, event.StateHistoryVisibility, "", &event.HistoryVisibilityEventContent{
+		HistoryVisibility: event.HistoryVisibility(historyVisibility),
+	})
+	return err
+}
+
+// updateAvatar sets a room's avatar if it differs
+func (c *matrixClient) updateAvatar(ctx context.Context, roomID id.RoomID, avatarURL string) error {
+	content, err := roomAvatarContent(avatarURL)
+	if err != nil {
+		return err
+	}
+
+	var current event.RoomAvatarEventContent
+	if err := c.client.StateEvent(ctx, roomID, event.StateRoomAvatar, "", &current); err == nil && current.URL == content.URL {
+		return nil
+	}
+
+	_, err = c.client.SendStateEvent(ctx, roomID, event.StateRoomAvatar, "", content)
+	return err
+}
+
+// roomAvatarContent builds the m.room.avatar content for an mxc:// URL
+func roomAvatarContent(avatarURL string) (*event.RoomAvatarEventContent, error) {
+	uri, err := id.ParseContentURI(avatarURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid avatar URL")
+	}
+	return &event.RoomAvatarEventContent{URL: uri.CUString()}, nil
+}
+
+// updateJoinRules sets a room's join rule, unless joinRule is empty, and the
+// rooms its room membership allow conditions name, unless allowRoomIDs is
+// nil, keeping the rest of the current join rules content
+func (c *matrixClient) updateJoinRules(ctx context.Context, roomID id.RoomID, joinRule string, allowRoomIDs []string) error {
+	content, err := c.joinRulesContent(ctx, roomID)
+	if err != nil {
+		content = map[string]interface{}{}
+	}
+
+	changed := false
+	if joinRule != "" && content["join_rule"] != joinRule {
+		content["join_rule"] = joinRule
+		changed = true
+	}
+	if allowRoomIDs != nil {
+		if allow, ok := withAllowRooms(content["allow"], allowRoomIDs); ok {
+			content["allow"] = allow
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	_, err = c.client.SendStateEvent(ctx, roomID, event.StateJoinRules, "", content)
+	return err
+}
+
+// withAllowRooms returns raw allow conditions whose room membership
+// conditions name exactly the given rooms, and whether that differs from the
+// current conditions. Conditions of other types are kept as they are.
+func withAllowRooms(current interface{}, roomIDs []string) ([]interface{}, bool) {
+	conditions, _ := current.([]interface{})
+
+	var kept []interface{}
+	var existing []string
+	for _, entry := range conditions {
+		condition, _ := entry.(map[string]interface{})
+		if condition["type"] != string(event.JoinRuleAllowRoomMembership) {
+			kept = append(kept, entry)
+			continue
+		}
+		roomID, _ := condition["room_id"].(string)
+		existing = append(existing, roomID)
+	}
+	if sameRooms(existing, roomIDs) {
+		return conditions, false
+	}
+
+	for _, roomID := range roomIDs {
+		kept = append(kept, map[string]interface{}{
+			"type":    string(event.JoinRuleAllowRoomMembership),
+			"room_id": roomID,
+		})
+	}
+	return kept, true
+}
+
+// sameRooms reports whether two lists name the same rooms, in any order
+func sameRooms(a, b []string) bool {
+	return slices.Equal(slices.Sorted(slices.Values(a)), slices.Sorted(slices.Values(b)))
+}
+
+// updatePinnedEvents sets the events pinned in a room if they differ
+func (c *matrixClient) updatePinnedEvents(ctx context.Context, roomID id.RoomID, eventIDs []string) error {
+	desired := make([]id.EventID, len(eventIDs))
+	for i, eventID := range eventIDs {
+		desired[i] = id.EventID(eventID)
+	}
+
+	var current event.PinnedEventsEventContent
+	if err := c.client.StateEvent(ctx, roomID, event.StatePinnedEvents, "", &current); err == nil && slices.Equal(current.Pinned, desired) {
+		return nil
+	}
+
+	_, err := c.client.SendStateEvent(ctx, roomID, event.StatePinnedEvents, "", &event.PinnedEventsEventContent{Pinned: desired})
+	return err
+}
+
+// enableEncryption sends an m.room.encryption event, unless the room already
+// has one. Sending another would only add a redundant state event, and could
+// change the encryption settings clients already use.
+func (c *matrixClient) enableEncryption(ctx context.Context, roomID id.RoomID) error {
+	var current event.EncryptionEventContent
+	if err := c.client.StateEvent(ctx, roomID, event.StateEncryption, "", &current); err == nil && current.Algorithm != "" {
+		return nil
+	}
+
+	_, err := c.client.SendStateEvent(ctx, roomID, event.StateEncryption, "", &event.EncryptionEventContent{
+		Algorithm: id.AlgorithmMegolmV1,
+	})
+	return err
+}
+
+// SetDirectoryNetworkVisibility publishes a room in, or removes it from, the
+// room directory of a third-party network. Homeservers only accept this from
+// the application service bridging the network.
+func (c *matrixClient) SetDirectoryNetworkVisibility(ctx context.Context, networkID, roomID, visibility string) error {
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return errors.Wrap(err, "invalid room ID")
+	}
+
+	urlPath := c.client.BuildClientURL("v3", "directory", "list", "appservice", networkID, roomID)
+	_, err := c.client.MakeRequest(ctx, http.MethodPut, urlPath, map[string]string{"visibility": visibility}, nil)
+	return errors.Wrapf(err, "failed to set room visibility in network %s", networkID)
+}
+
+// UpdateRoom updates room information
+func (c *matrixClient) UpdateRoom(ctx context.Context, roomID string, roomSpec *RoomSpec) (*Room, error) {
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return nil, errors.Wrap(err, "invalid room ID")
+	}
+
+	roomIDObj := id.RoomID(roomID)
+
+	// Update room name. An empty name removes it.
+	if roomSpec.Name != nil {
+		if err := c.updateName(ctx, roomIDObj, *roomSpec.Name); err != nil {
+			return nil, errors.Wrap(err, "failed to update room name")
+		}
+	}
+
+	// Update room topic
+	if roomSpec.Topic != "" {
+		if err := c.updateTopic(ctx, roomIDObj, roomSpec); err != nil {
+			return nil, errors.Wrap(err, "failed to update room topic")
+		}
+	}
+
+	// Update canonical alias
+	if roomSpec.Alias != "" || roomSpec.AltAliases != nil {
+		if err := c.updateCanonicalAlias(ctx, roomIDObj, roomSpec); err != nil {
+			return nil, errors.Wrap(err, "failed to update canonical alias")
+		}
+	}
+
+	// Update guest access. Guest access is removed by forbidding it.
+	if roomSpec.GuestAccess != "" {
+		if err := c.updateGuestAccess(ctx, roomIDObj, roomSpec.GuestAccess); err != nil {
+			return nil, errors.Wrap(err, "failed to update guest access")
+		}
+	}
+
+	// Update history visibility
+	if roomSpec.HistoryVisibility != "" {
+		if err := c.updateHistoryVisibility(ctx, roomIDObj, roomSpec.HistoryVisibility); err != nil {
+			return nil, errors.Wrap(err, "failed to update history visibility")
+		}
+	}
+
+	// Update join rules
+	if roomSpec.JoinRules != "" || roomSpec.JoinRuleAllow != nil {
+		if err := c.updateJoinRules(ctx, roomIDObj, roomSpec.JoinRules, roomSpec.JoinRuleAllow); err != nil {
+			return nil, errors.Wrap(err, "failed to update join rules")
+		}
+	}
+
+	// Update pinned events. An empty list unpins everything.
+	if roomSpec.PinnedEvents != nil {
+		if err := c.updatePinnedEvents(ctx, roomIDObj, roomSpec.PinnedEvents); err != nil {
+			return nil, errors.Wrap(err, "failed to update pinned events")
+		}
+	}
+
+	// Enable encryption. Encryption can't be turned off again, so a room
+	// that is already encrypted is left alone.
+	if roomSpec.EncryptionEnabled {
+		if err := c.enableEncryption(ctx, roomIDObj); err != nil {
+			return nil, errors.Wrap(err, "failed to enable encryption")
+		}
+	}
+
+	// Update avatar
+	if roomSpec.AvatarURL != "" {
+		if err := c.updateAvatar(ctx, roomIDObj, roomSpec.AvatarURL); err != nil {
+			return nil, errors.Wrap(err, "failed to update avatar")
+		}
+	}
+
+	// Put back the power levels the room was created with
+	if roomSpec.PowerLevelOverrides != nil {
+		if err := c.updatePowerLevelOverrides(ctx, roomID, roomSpec.PowerLevelOverrides); err != nil {
+			return nil, errors.Wrap(err, "failed to update power levels")
+		}
+	}
+
+	return c.GetRoom(ctx, roomID)
+}
+
+// updatePowerLevelOverrides applies a room's power level overrides to its
+// current power levels. Only the users and events the overrides list are
+// changed, so other users keep the levels they were given since, and nothing
+// is sent if the overrides already hold.
+func (c *matrixClient) updatePowerLevelOverrides(ctx context.Context, roomID string, overrides *PowerLevelContent) error {
+	current, err := c.GetPowerLevels(ctx, roomID)
+	if err != nil {
+		return err
+	}
+
+	desired := *overrides
+	if overrides.Users != nil {
+		desired.Users = maps.Clone(current.Users)
+		if desired.Users == nil {
+			desired.Users = make(map[string]int, len(overrides.Users))
+		}
+		maps.Copy(desired.Users, overrides.Users)
+	}
+	if overrides.Events != nil {
+		desired.Events = maps.Clone(current.Events)
+		if desired.Events == nil {
+			desired.Events = make(map[string]int, len(overrides.Events))
+		}
+		maps.Copy(desired.Events, overrides.Events)
+	}
+
+	content := &event.PowerLevelsEventContent{}
+	applyPowerLevels(content, current)
+	applyPowerLevels(content, &desired)
+	if reflect.DeepEqual(powerLevelContentFromEvent(content), current) {
+		return nil
+	}
+
+	return c.SetPowerLevels(ctx, roomID, &PowerLevelSpec{RoomID: roomID, PowerLevels: &desired, Previous: current})
+}
+
+// updateCanonicalAlias sends an m.room.canonical_alias event for the aliases
+// set in the spec, keeping any part of the current event the spec leaves unset.
+// An alias that isn't to be canonical is removed from the event if it is the
+// canonical alias.
+func (c *matrixClient) updateCanonicalAlias(ctx context.Context, roomID id.RoomID, roomSpec *RoomSpec) error {
+	var current event.CanonicalAliasEventContent
+	_ = c.client.StateEvent(ctx, roomID, event.StateCanonicalAlias, "", &current)
+
+	desired := current
+	if alias := c.fullAlias(roomSpec.Alias); alias != "" {
+		if roomSpec.aliasIsCanonical() {
+			desired.Alias = alias
+		} else if desired.Alias == alias {
+			desired.Alias = ""
+		}
+	}
+	if roomSpec.AltAliases != nil {
+		desired.AltAliases = make([]id.RoomAlias, len(roomSpec.AltAliases))
+		for i, alias := range roomSpec.AltAliases {
+			desired.AltAliases[i] = c.fullAlias(alias)
+		}
+	}
+
+	if desired.Alias == current.Alias && slices.Equal(desired.AltAliases, current.AltAliases) {
+		return nil
+	}
+
+	_, err := c.client.SendStateEvent(ctx, roomID, event.StateCanonicalAlias, "", &desired)
+	return err
+}
+
+// DeleteRoom starts deleting a room, purging its history only if requested.
+// The homeserver deletes the room in the background; follow it with
+// GetRoomDeletion. A deletion already in progress isn't started again.
+func (c *matrixClient) DeleteRoom(ctx context.Context, roomID string, opts DeleteRoomOptions) error {
+	if c.adminClient == nil {
+		return errors.New("room deletion requires admin API access")
+	}
+	if !c.synapseAdminAPI() {
+		return c.unsupportedByServer("room deletion")
+	}
+
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return errors.Wrap(err, "invalid room ID")
+	}
+
+	deletion, err := c.GetRoomDeletion(ctx, roomID)
+	if err != nil {
+		return err
+	}
+	if deletion != nil && deletion.Status != RoomDeletionComplete && deletion.Status != RoomDeletionFailed {
+		return nil
+	}
+
+	options := map[string]interface{}{
+		"block": false,
+		"purge": opts.Purge,
+	}
+
+	return c.adminClient.deleteRoom(ctx, roomID, options)
+}
+
+// GetRoomDeletion returns the progress of the latest deletion of a room
+// started by DeleteRoom, or nil if the homeserver knows of none. Rooms can
+// only be deleted through the Synapse admin API, so without it there is
+// never a deletion to report.
+func (c *matrixClient) GetRoomDeletion(ctx context.Context, roomID string) (*RoomDeletion, error) {
+	if c.adminClient == nil || !c.synapseAdminAPI() {
+		return nil, nil
+	}
+
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return nil, errors.Wrap(err, "invalid room ID")
+	}
+
+	deletion, err := c.adminClient.getRoomDeleteStatus(ctx, roomID)
+	if IsNotFound(err) {
+		return nil, nil
+	}
+
+	return deletion, err
+}
+
+// EventPinnable checks whether an event can be pinned in a room. Events that
+// can't be fetched from the room, including events from other rooms, and
+// events that have been redacted can't be pinned.
+func (c *matrixClient) EventPinnable(ctx context.Context, roomID, eventID string) (bool, error) {
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return false, errors.Wrap(err, "invalid room ID")
+	}
+
+	evt, err := c.client.GetEvent(ctx, id.RoomID(roomID), id.EventID(eventID))
+	switch {
+	case IsNotFound(err), errors.Is(err, mautrix.MForbidden):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+
+	if evt.RoomID != "" && evt.RoomID.String() != roomID {
+		return false, nil
+	}
+	return evt.Unsigned.RedactedBecause == nil, nil
+}
+
+// GetMembership returns the provider user's membership of a room: join,
+// invite, knock, leave or ban. It is empty if the user has never been in the
+// room, or can no longer see it.
+func (c *matrixClient) GetMembership(ctx context.Context, roomID string) (string, error) {
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return "", errors.Wrap(err, "invalid room ID")
+	}
+
+	var content event.MemberEventContent
+	err := c.client.StateEvent(ctx, id.RoomID(roomID), event.StateMember, c.client.UserID.String(), &content)
+	switch {
+	case IsNotFound(err), errors.Is(err, mautrix.MForbidden):
+		return "", nil
+	case err != nil:
+		return "", err
+	}
+	return string(content.Membership), nil
+}
+
+// JoinRoom joins the provider's user to a room. If the user can't join by
+// itself, for example because the room is invite-only, it is added with the
+// admin API when that is available.
+func (c *matrixClient) JoinRoom(ctx context.Context, roomID string) error {
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return errors.Wrap(err, "invalid room ID")
+	}
+
+	_, err := c.client.JoinRoomByID(ctx, id.RoomID(roomID))
+	if err == nil || c.adminClient == nil {
+		return errors.Wrap(err, "failed to join room")
+	}
+
+	return errors.Wrap(c.adminClient.joinRoom(ctx, roomID, c.client.UserID.String()), "failed to add the provider's user to the room")
+}
+
+// GetUserMembership returns the membership of a user in a room, or an empty
+// string if the user has never been in it
+func (c *matrixClient) GetUserMembership(ctx context.Context, roomID, userID string) (string, error) {
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return "", errors.Wrap(err, "invalid room ID")
+	}
+	if err := validateMatrixID(userID, "user"); err != nil {
+		return "", errors.Wrap(err, "invalid user ID")
+	}
+
+	var content event.MemberEventContent
+	err := c.client.StateEvent(ctx, id.RoomID(roomID), event.StateMember, userID, &content)
+	if IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get membership")
+	}
+	return string(content.Membership), nil
+}
+
+// GetMemberships returns the membership of every user that has been in a
+// room, keyed by user ID
+func (c *matrixClient) GetMemberships(ctx context.Context, roomID string) (map[string]string, error) {
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return nil, errors.Wrap(err, "invalid room ID")
+	}
+
+	resp, err := c.client.Members(ctx, id.RoomID(roomID))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get room members")
+	}
+
+	memberships := make(map[string]string, len(resp.Chunk))
+	for _, evt := range resp.Chunk {
+		if evt.StateKey == nil {
+			continue
+		}
+		memberships[*evt.StateKey] = string(evt.Content.AsMember().Membership)
+	}
+	return memberships, nil
+}
+
+// InviteUsers invites users to a room one at a time, returning how many were
+// invited. Rate-limited invites are retried as the ProviderConfig allows. An
+// invite that is still rate limited stops the rest, and is returned as an
+// error that IsRateLimited recognises.
+func (c *matrixClient) InviteUsers(ctx context.Context, roomID string, userIDs []string) (int, error) {
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return 0, errors.Wrap(err, "invalid room ID")
+	}
+
+	for i, userID := range userIDs {
+		if err := validateMatrixID(userID, "user"); err != nil {
+			return i, errors.Wrap(err, "invalid user ID")
+		}
+		if _, err := c.client.InviteUser(ctx, id.RoomID(roomID), &mautrix.ReqInviteUser{UserID: id.UserID(userID)}); err != nil {
+			return i, errors.Wrapf(err, "failed to invite %s", userID)
+		}
+	}
+	return len(userIDs), nil
+}
+
+// SetMembership moves a user to the given membership of a room by inviting,
+// kicking or banning them, lifting a ban first where needed. Users other than
+// the provider's own can only be joined to a room with the admin API.
+func (c *matrixClient) SetMembership(ctx context.Context, roomID, userID, membership, reason string) error {
+	current, err := c.GetUserMembership(ctx, roomID, userID)
+	if err != nil {
+		return err
+	}
+	if current == "" {
+		current = string(event.MembershipLeave)
+	}
+	// An invited user who has joined has accepted the invite
+	if current == membership || (membership == string(event.MembershipInvite) && current == string(event.MembershipJoin)) {
+		return nil
+	}
+
+	roomIDObj := id.RoomID(roomID)
+	userIDObj := id.UserID(userID)
+	self := userIDObj == c.client.UserID
+
+	if current == string(event.MembershipBan) {
+		if _, err := c.client.UnbanUser(ctx, roomIDObj, &mautrix.ReqUnbanUser{UserID: userIDObj, Reason: reason}); err != nil {
+			return errors.Wrap(err, "failed to unban user")
+		}
+		if membership == string(event.MembershipLeave) {
+			return nil
+		}
+	}
+
+	switch event.Membership(membership) {
+	case event.MembershipInvite:
+		_, err = c.client.InviteUser(ctx, roomIDObj, &mautrix.ReqInviteUser{UserID: userIDObj, Reason: reason})
+		return errors.Wrap(err, "failed to invite user")
+	case event.MembershipJoin:
+		if self {
+			return c.JoinRoom(ctx, roomID)
+		}
+		if c.adminClient == nil {
+			return errors.New("joining other users to a room requires admin API access")
+		}
+		return errors.Wrap(c.adminClient.joinRoom(ctx, roomID, userID), "failed to join user to room")
+	case event.MembershipLeave:
+		if self {
+			_, err = c.client.LeaveRoom(ctx, roomIDObj, &mautrix.ReqLeave{Reason: reason})
+			return errors.Wrap(err, "failed to leave room")
+		}
+		_, err = c.client.KickUser(ctx, roomIDObj, &mautrix.ReqKickUser{UserID: userIDObj, Reason: reason})
+		return errors.Wrap(err, "failed to kick user")
+	case event.MembershipBan:
+		_, err = c.client.BanUser(ctx, roomIDObj, &mautrix.ReqBanUser{UserID: userIDObj, Reason: reason})
+		return errors.Wrap(err, "failed to ban user")
+	default:
+		return errors.Errorf("unsupported membership %q", membership)
+	}
+}
+
+// Room maintenance operations
+
+// GetForwardExtremities returns the forward extremities of a room
+func (c *matrixClient) GetForwardExtremities(ctx context.Context, roomID string) (*ForwardExtremities, error) {
+	if c.adminClient == nil {
+		return nil, errors.New("forward extremities require admin API access")
+	}
+
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return nil, errors.Wrap(err, "invalid room ID")
+	}
+
+	return c.adminClient.getForwardExtremities(ctx, roomID)
+}
+
+// DeleteForwardExtremities removes the forward extremities of a room and
+// returns how many were deleted
+func (c *matrixClient) DeleteForwardExtremities(ctx context.Context, roomID string) (int, error) {
+	if c.adminClient == nil {
+		return 0, errors.New("forward extremities require admin API access")
+	}
+
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return 0, errors.Wrap(err, "invalid room ID")
+	}
+
+	return c.adminClient.deleteForwardExtremities(ctx, roomID)
+}
+
+// MakeRoomAdmin gives a local user the highest power level of the room's
+// local members, inviting the user if they aren't in the room. Rooms whose
+// admins have all left can be recovered this way.
+func (c *matrixClient) MakeRoomAdmin(ctx context.Context, roomID, userID string) error {
+	if c.adminClient == nil {
+		return errors.New("making room admins requires admin API access")
+	}
+	if !c.synapseAdminAPI() {
+		return c.unsupportedByServer("making room admins")
+	}
+
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return errors.Wrap(err, "invalid room ID")
+	}
+	if err := validateMatrixID(userID, "user"); err != nil {
+		return errors.Wrap(err, "invalid user ID")
+	}
+
+	return c.adminClient.makeRoomAdmin(ctx, roomID, userID)
+}
+
+// RoomAdmins returns those of userIDs that hold enough power to administer a
+// room: its privileged creators, and users whose level lets them send power
+// levels and other state events. The room's state is read with the admin
+// API, so that rooms the provider's user isn't in can be checked.
+func (c *matrixClient) RoomAdmins(ctx context.Context, roomID string, userIDs []string) ([]string, error) {
+	if c.adminClient == nil {
+		return nil, errors.New("checking room admins requires admin API access")
+	}
+	if !c.synapseAdminAPI() {
+		return nil, c.unsupportedByServer("checking room admins")
+	}
+
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return nil, errors.Wrap(err, "invalid room ID")
+	}
+
+	state, err := c.adminClient.getRoomState(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	var creator string
+	var creators []string
+	var powerLevels *PowerLevelContent
+	for _, evt := range state {
+		if evt.StateKey != "" {
+			continue
+		}
+		switch evt.Type {
+		case event.StateCreate.Type:
+			var content event.CreateEventContent
+			if err := json.Unmarshal(evt.Content, &content); err != nil {
+				return nil, errors.Wrap(err, "failed to decode room create event")
+			}
+			creator = evt.Sender
+			if content.RoomVersion != "" && content.SupportsCreatorPower() {
+				creators = append(creators, evt.Sender)
+				for _, additional := range content.AdditionalCreators {
+					creators = append(creators, additional.String())
+				}
+			}
+		case event.StatePowerLevels.Type:
+			var content event.PowerLevelsEventContent
+			if err := json.Unmarshal(evt.Content, &content); err != nil {
+				return nil, errors.Wrap(err, "failed to decode room power levels")
+			}
+			powerLevels = powerLevelContentFromEvent(&content)
+		}
+	}
+	// Without power levels the creator is the only user with power
+	if powerLevels == nil {
+		powerLevels = &PowerLevelContent{Users: map[string]int{creator: 100}}
+	}
+
+	var admins []string
+	for _, userID := range userIDs {
+		if slices.Contains(creators, userID) || powerLevelLockout(powerLevels, userID) == nil {
+			admins = append(admins, userID)
+		}
+	}
+	return admins, nil
+}
+
+// GetRoomBlocked returns whether a room is blocked from being joined
+func (c *matrixClient) GetRoomBlocked(ctx context.Context, roomID string) (bool, error) {
+	if c.adminClient == nil {
+		return false, errors.New("blocking rooms requires admin API access")
+	}
+	if !c.synapseAdminAPI() {
+		return false, c.unsupportedByServer("blocking rooms")
+	}
+
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return false, errors.Wrap(err, "invalid room ID")
+	}
+
+	return c.adminClient.getRoomBlocked(ctx, roomID)
+}
+
+// BlockRoom blocks a room from being joined by the homeserver's users, or
+// lifts its block. Rooms can be blocked before the homeserver knows them.
+func (c *matrixClient) BlockRoom(ctx context.Context, roomID string, block bool) error {
+	if c.adminClient == nil {
+		return errors.New("blocking rooms requires admin API access")
+	}
+	if !c.synapseAdminAPI() {
+		return c.unsupportedByServer("blocking rooms")
+	}
+
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return errors.Wrap(err, "invalid room ID")
+	}
+
+	return c.adminClient.blockRoom(ctx, roomID, block)
+}
+
+// PurgeHistory starts purging the events of a room sent before upTo, and
+// returns the ID to follow the purge by with GetHistoryPurge. Events sent by
+// local users are only purged if deleteLocalEvents is set. The room itself,
+// and its current state, are kept.
+func (c *matrixClient) PurgeHistory(ctx context.Context, roomID string, upTo time.Time, deleteLocalEvents bool) (string, error) {
+	if c.adminClient == nil {
+		return "", errors.New("purging room history requires admin API access")
+	}
+	if !c.synapseAdminAPI() {
+		return "", c.unsupportedByServer("purging room history")
+	}
+
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return "", errors.Wrap(err, "invalid room ID")
+	}
+
+	return c.adminClient.purgeHistory(ctx, roomID, upTo.UnixMilli(), deleteLocalEvents)
+}
+
+// GetHistoryPurge returns the progress of a purge started by PurgeHistory
+func (c *matrixClient) GetHistoryPurge(ctx context.Context, purgeID string) (*HistoryPurge, error) {
+	if c.adminClient == nil {
+		return nil, errors.New("purging room history requires admin API access")
+	}
+	if !c.synapseAdminAPI() {
+		return nil, c.unsupportedByServer("purging room history")
+	}
+
+	if purgeID == "" {
+		return nil, errors.New("purge ID cannot be empty")
+	}
+
+	return c.adminClient.getPurgeHistoryStatus(ctx, purgeID)
+}
+
+// Media operations
+
+// GetMedia returns a piece of media uploaded to the homeserver, or cached from
+// another homeserver, by the server name and media ID of its mxc:// URI
+func (c *matrixClient) GetMedia(ctx context.Context, serverName, mediaID string) (*Media, error) {
+	if c.adminClient == nil {
+		return nil, errors.New("getting media requires admin API access")
+	}
+	if !c.synapseAdminAPI() {
+		return nil, c.unsupportedByServer("getting media")
+	}
+
+	return c.adminClient.getMedia(ctx, serverName, mediaID)
+}
+
+// QuarantineMedia quarantines a piece of media, so that the homeserver no
+// longer serves it, or lifts its quarantine
+func (c *matrixClient) QuarantineMedia(ctx context.Context, serverName, mediaID string, quarantined bool) error {
+	if c.adminClient == nil {
+		return errors.New("quarantining media requires admin API access")
+	}
+	if !c.synapseAdminAPI() {
+		return c.unsupportedByServer("quarantining media")
+	}
+
+	return c.adminClient.quarantineMedia(ctx, serverName, mediaID, quarantined)
+}
+
+// DeleteMedia deletes a piece of media from the homeserver. Only media
+// uploaded to the homeserver itself can be deleted.
+func (c *matrixClient) DeleteMedia(ctx context.Context, serverName, mediaID string) error {
+	if c.adminClient == nil {
+		return errors.New("deleting media requires admin API access")
+	}
+	if !c.synapseAdminAPI() {
+		return c.unsupportedByServer("deleting media")
+	}
+
+	if _, localServerName, _ := strings.Cut(c.config.UserID, ":"); localServerName != "" && serverName != localServerName {
+		return errors.Errorf("only media uploaded to %s can be deleted, not media from %s", localServerName, serverName)
+	}
+
+	return c.adminClient.deleteMedia(ctx, serverName, mediaID)
+}
+
+// Server notice operations
+
+// SendServerNotice sends a server notice to a user and returns its event ID.
+// Sending a notice again with the same txnID, shortly after, returns the same
+// event rather than sending the notice twice.
+func (c *matrixClient) SendServerNotice(ctx context.Context, txnID, userID, msgType, body string) (string, error) {
+	if c.adminClient == nil {
+		return "", errors.New("sending server notices requires admin API access")
+	}
+	if !c.synapseAdminAPI() {
+		return "", c.unsupportedByServer("sending server notices")
+	}
+
+	if err := validateMatrixID(userID, "user"); err != nil {
+		return "", errors.Wrap(err, "invalid user ID")
+	}
+
+	return c.adminClient.sendServerNotice(ctx, txnID, userID, msgType, body)
+}
+
+// RedactServerNotice redacts a server notice. Only the sender of a notice can
+// redact it in the user's server notices room, so the redaction is sent on
+// behalf of the sender with a short-lived access token.
+func (c *matrixClient) RedactServerNotice(ctx context.Context, eventID, reason string) error {
+	if c.adminClient == nil {
+		return errors.New("redacting server notices requires admin API access")
+	}
+	if !c.synapseAdminAPI() {
+		return c.unsupportedByServer("redacting server notices")
+	}
+
+	notice, err := c.adminClient.fetchEvent(ctx, eventID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get server notice")
+	}
+	if len(notice.Unsigned.RedactedBecause) > 0 {
+		return nil
+	}
+
+	sender, err := c.clientAs(ctx, notice.Sender)
+	if err != nil {
+		return errors.Wrap(err, "failed to act as the server notices user")
+	}
+
+	_, err = sender.RedactEvent(ctx, id.RoomID(notice.RoomID), id.EventID(notice.EventID), mautrix.ReqRedact{Reason: reason})
+	return errors.Wrap(err, "failed to redact server notice")
+}
+
+// Registration token operations
+
+// ListRegistrationTokens lists the tokens that let people register accounts
+// on the homeserver
+func (c *matrixClient) ListRegistrationTokens(ctx context.Context) ([]RegistrationToken, error) {
+	if err := c.registrationTokensSupported(); err != nil {
+		return nil, err
+	}
+
+	return c.adminClient.listRegistrationTokens(ctx)
+}
+
+// GetRegistrationToken returns a registration token and how much it has been
+// used
+func (c *matrixClient) GetRegistrationToken(ctx context.Context, token string) (*RegistrationToken, error) {
+	if err := c.registrationTokensSupported(); err != nil {
+		return nil, err
+	}
+
+	if token == "" {
+		return nil, errors.New("registration token cannot be empty")
+	}
+
+	return c.adminClient.getRegistrationToken(ctx, token)
+}
+
+// CreateRegistrationToken creates a registration token. The homeserver
+// generates the token unless the spec gives one.
+func (c *matrixClient) CreateRegistrationToken(ctx context.Context, spec *RegistrationTokenSpec) (*RegistrationToken, error) {
+	if err := c.registrationTokensSupported(); err != nil {
+		return nil, err
+	}
+
+	return c.adminClient.createRegistrationToken(ctx, spec)
+}
+
+// UpdateRegistrationToken changes how many times a registration token can be
+// used and when it expires. The token itself can't be changed.
+func (c *matrixClient) UpdateRegistrationToken(ctx context.Context, token string, spec *RegistrationTokenSpec) (*RegistrationToken, error) {
+	if err := c.registrationTokensSupported(); err != nil {
+		return nil, err
+	}
+
+	if token == "" {
+		return nil, errors.New("registration token cannot be empty")
+	}
+
+	return c.adminClient.updateRegistrationToken(ctx, token, spec)
+}
+
+// DeleteRegistrationToken deletes a registration token, so that it can no
+// longer be used to register
+func (c *matrixClient) DeleteRegistrationToken(ctx context.Context, token string) error {
+	if err := c.registrationTokensSupported(); err != nil {
+		return err
+	}
+
+	if token == "" {
+		return errors.New("registration token cannot be empty")
+	}
+
+	return c.adminClient.deleteRegistrationToken(ctx, token)
+}
+
+// registrationTokensSupported returns an error if registration tokens can't
+// be managed on the homeserver
+func (c *matrixClient) registrationTokensSupported() error {
+	if c.adminClient == nil {
+		return errors.New("managing registration tokens requires admin API access")
+	}
+	if !c.synapseAdminAPI() {
+		return c.unsupportedByServer("managing registration tokens")
+	}
+	return nil
+}
+
+// Account data operations
+
+// GetAccountData returns the content of a user's account data of the given
+// type. Account data that was never set is reported as not found.
+func (c *matrixClient) GetAccountData(ctx context.Context, userID, dataType string) (json.RawMessage, error) {
+	if err := validateMatrixID(userID, "user"); err != nil {
+		return nil, errors.Wrap(err, "invalid user ID")
+	}
+
+	client, err := c.clientAs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var content json.RawMessage
+	if err := client.GetAccountData(ctx, dataType, &content); err != nil {
+		return nil, errors.Wrap(err, "failed to get account data")
+	}
+
+	return content, nil
+}
+
+// SetAccountData sets a user's account data of the given type to content
+func (c *matrixClient) SetAccountData(ctx context.Context, userID, dataType string, content json.RawMessage) error {
+	if err := validateMatrixID(userID, "user"); err != nil {
+		return errors.Wrap(err, "invalid user ID")
+	}
+
+	client, err := c.clientAs(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := client.SetAccountData(ctx, dataType, content); err != nil {
+		return errors.Wrap(err, "failed to set account data")
+	}
+
+	return nil
+}
+
+// Room tag operations
+
+// GetRoomTags returns the tags a user put on a room, by tag name
+func (c *matrixClient) GetRoomTags(ctx context.Context, userID, roomID string) (map[string]RoomTag, error) {
+	if userID != "" {
+		if err := validateMatrixID(userID, "user"); err != nil {
+			return nil, errors.Wrap(err, "invalid user ID")
+		}
+	}
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return nil, errors.Wrap(err, "invalid room ID")
+	}
+
+	client, err := c.clientAs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.GetTags(ctx, id.RoomID(roomID))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get room tags")
+	}
+
+	tags := make(map[string]RoomTag, len(resp.Tags))
+	for name, meta := range resp.Tags {
+		var tag RoomTag
+		if meta.Order != "" {
+			order, err := meta.Order.Float64()
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid order of room tag %s", name)
+			}
+			tag.Order = &order
+		}
+		tags[string(name)] = tag
+	}
+
+	return tags, nil
+}
+
+// SetRoomTag puts a tag on a room for a user, sorting the room among those
+// with the same tag by order if it is set
+func (c *matrixClient) SetRoomTag(ctx context.Context, userID, roomID, tag string, order *float64) error {
+	if userID != "" {
+		if err := validateMatrixID(userID, "user"); err != nil {
+			return errors.Wrap(err, "invalid user ID")
+		}
+	}
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return errors.Wrap(err, "invalid room ID")
+	}
+	if err := validateRoomTagOrder(order); err != nil {
+		return err
+	}
+
+	client, err := c.clientAs(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	meta := &event.TagMetadata{}
+	if order != nil {
+		meta.Order = json.Number(strconv.FormatFloat(*order, 'f', -1, 64))
+	}
+	if err := client.AddTagWithCustomData(ctx, id.RoomID(roomID), event.RoomTag(tag), meta); err != nil {
+		return errors.Wrap(err, "failed to set room tag")
+	}
+
+	return nil
+}
+
+// DeleteRoomTag removes a tag a user put on a room
+func (c *matrixClient) DeleteRoomTag(ctx context.Context, userID, roomID, tag string) error {
+	if userID != "" {
+		if err := validateMatrixID(userID, "user"); err != nil {
+			return errors.Wrap(err, "invalid user ID")
+		}
+	}
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return errors.Wrap(err, "invalid room ID")
+	}
+
+	client, err := c.clientAs(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := client.RemoveTag(ctx, id.RoomID(roomID), event.RoomTag(tag)); err != nil {
+		return errors.Wrap(err, "failed to delete room tag")
+	}
+
+	return nil
+}
+
+// validateRoomTagOrder checks that a room tag's order is between 0 and 1, as
+// the spec requires
+func validateRoomTagOrder(order *float64) error {
+	if order != nil && (*order < 0 || *order > 1 || math.IsNaN(*order)) {
+		return errors.Errorf("room tag order must be between 0 and 1, got %v", *order)
+	}
+	return nil
+}
+
+const (
+	// loginAsUserValidity is how long the access tokens used to act on
+	// behalf of other users stay valid
+	loginAsUserValidity = 10 * time.Minute
+
+	// loginAsUserRenewal is how long before it expires an access token used
+	// to act on behalf of another user is replaced
+	loginAsUserRenewal = time.Minute
+)
+
+// userToken is an access token of another user, valid until validUntil
+type userToken struct {
+	token      string
+	validUntil time.Time
+}
+
+// clientAs returns a Matrix client that acts as userID. The provider's own
+// user, which an empty userID also stands for, is acted as with its own
+// client, and any other user of the homeserver with a short-lived access
+// token the admin API logs in as them. The token is reused until shortly
+// before it expires, so that polling doesn't log in every time.
+func (c *matrixClient) clientAs(ctx context.Context, userID string) (*mautrix.Client, error) {
+	if userID == "" || userID == c.client.UserID.String() {
+		return c.client, nil
+	}
+	if c.adminClient == nil {
+		return nil, errors.New("acting as other users requires admin API access")
+	}
+	if !c.synapseAdminAPI() {
+		return nil, c.unsupportedByServer("acting as other users")
+	}
+
+	token, err := c.userToken(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to log in as %s", userID)
+	}
+
+	client, err := mautrix.NewClient(c.config.HomeserverURL, id.UserID(userID), token)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create mautrix client")
+	}
+	// The provider's own client would authenticate as the provider when it
+	// logged in with a password
+	client.Client = c.config.userHTTPClient
+	return client, nil
+}
+
+// userToken returns an access token of userID, logging in as them through
+// the admin API unless a token from an earlier login is still valid for long
+// enough
+func (c *matrixClient) userToken(ctx context.Context, userID string) (string, error) {
+	c.userTokensMu.Lock()
+	defer c.userTokensMu.Unlock()
+
+	now := time.Now()
+	if cached, ok := c.userTokens[userID]; ok && now.Add(loginAsUserRenewal).Before(cached.validUntil) {
+		return cached.token, nil
+	}
+
+	validUntil := now.Add(loginAsUserValidity)
+	token, err := c.adminClient.loginAsUser(ctx, userID, validUntil)
+	if err != nil {
+		return "", err
+	}
+
+	if c.userTokens == nil {
+		c.userTokens = make(map[string]userToken)
+	}
+	c.userTokens[userID] = userToken{token: token, validUntil: validUntil}
+	return token, nil
+}
+
+// Knock operations
+
+// GetKnocks returns the user IDs with a pending knock on a room
+func (c *matrixClient) GetKnocks(ctx context.Context, roomID string) ([]string, error) {
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return nil, errors.Wrap(err, "invalid room ID")
+	}
+
+	resp, err := c.client.Members(ctx, id.RoomID(roomID), mautrix.ReqMembers{
+		Membership: event.MembershipKnock,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get room members")
+	}
+
+	var knocks []string
+	for _, evt := range resp.Chunk {
+		if evt.StateKey == nil {
+			continue
+		}
+		if member := evt.Content.AsMember(); member.Membership == event.MembershipKnock {
+			knocks = append(knocks, *evt.StateKey)
+		}
+	}
+
+	return knocks, nil
+}
+
+// AcceptKnock accepts a pending knock by inviting the knocking user
+func (c *matrixClient) AcceptKnock(ctx context.Context, roomID, userID string) error {
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return errors.Wrap(err, "invalid room ID")
+	}
+	if err := validateMatrixID(userID, "user"); err != nil {
+		return errors.Wrap(err, "invalid user ID")
+	}
+
+	_, err := c.client.InviteUser(ctx, id.RoomID(roomID), &mautrix.ReqInviteUser{
+		UserID: id.UserID(userID),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to accept knock")
+	}
+
+	return nil
+}
+
+// mergeCreationContent builds the content of the m.room.create event. Keys
+// supplied by the user take precedence over the homeserver's defaults, but the
+// provider-managed keys in managed are always enforced: a space has to be
+// created with the m.space room type whatever the user asked for. The user's
+// map is never modified.
+func mergeCreationContent(user, managed map[string]interface{}) map[string]interface{} {
+	if len(user) == 0 && len(managed) == 0 {
+		return nil
+	}
+
+	content := make(map[string]interface{}, len(user)+len(managed))
+	for key, value := range user {
+		content[key] = value
+	}
+	for key, value := range managed {
+		content[key] = value
+	}
+	return content
+}
+
+// Space operations
+
+// CreateSpace creates a new Matrix space. A space is a room created with the
+// m.space room type, so it goes through CreateRoom after room-only settings
+// such as encryption have been rejected.
+func (c *matrixClient) CreateSpace(ctx context.Context, spaceSpec *SpaceSpec) (*Space, error) {
+	if err := validateSpaceSpec(spaceSpec); err != nil {
+		return nil, errors.Wrap(err, "invalid space")
+	}
+
+	roomSpec := spaceSpec.RoomSpec
+	roomSpec.CreationContent = mergeCreationContent(spaceSpec.CreationContent, map[string]interface{}{
+		"type": string(event.RoomTypeSpace),
+	})
+
+	room, err := c.CreateRoom(ctx, &roomSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	roomIDObj := id.RoomID(room.RoomID)
+	for _, child := range spaceSpec.Children {
+		_, err := c.client.SendStateEvent(ctx, roomIDObj, event.StateSpaceChild, child.RoomID, c.spaceChildContent(child))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to add child %s", child.RoomID)
+		}
+	}
+
+	return &Space{
+		Room:      *room,
+		SpaceType: string(event.RoomTypeSpace),
+		Children:  spaceSpec.Children,
+	}, nil
+}
+
+// GetSpace retrieves a space and its children
+func (c *matrixClient) GetSpace(ctx context.Context, spaceID string) (*Space, error) {
+	room, err := c.GetRoom(ctx, spaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	children, err := c.spaceChildren(ctx, spaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Space{
+		Room:      *room,
+		SpaceType: room.RoomType,
+		Children:  children,
+	}, nil
+}
+
+// UpdateSpace updates a space's settings like UpdateRoom does, and makes its
+// children match the spec. Children that are no longer in the spec are
+// removed. Nil children leave the space's children alone.
+func (c *matrixClient) UpdateSpace(ctx context.Context, spaceID string, spaceSpec *SpaceSpec) (*Space, error) {
+	if err := validateSpaceSpec(spaceSpec); err != nil {
+		return nil, errors.Wrap(err, "invalid space")
+	}
+
+	if _, err := c.UpdateRoom(ctx, spaceID, &spaceSpec.RoomSpec); err != nil {
+		return nil, err
+	}
+
+	if spaceSpec.Children != nil {
+		if err := c.updateSpaceChildren(ctx, spaceID, spaceSpec.Children); err != nil {
+			return nil, err
+		}
+	}
+
+	return c.GetSpace(ctx, spaceID)
+}
+
+// updateSpaceChildren sends an m.space.child event for each child that is
+// missing or differs, and an empty one for each child that isn't wanted
+func (c *matrixClient) updateSpaceChildren(ctx context.Context, spaceID string, children []SpaceChild) error {
+	current, err := c.spaceChildren(ctx, spaceID)
+	if err != nil {
+		return err
+	}
+
+	roomIDObj := id.RoomID(spaceID)
+	for _, child := range children {
+		desired := c.spaceChildContent(child)
+		i := slices.IndexFunc(current, func(existing SpaceChild) bool { return existing.RoomID == child.RoomID })
+		if i >= 0 && slices.Equal(current[i].Via, desired.Via) && current[i].Order == desired.Order && current[i].Suggested == desired.Suggested {
+			continue
+		}
+		if _, err := c.client.SendStateEvent(ctx, roomIDObj, event.StateSpaceChild, child.RoomID, desired); err != nil {
+			return errors.Wrapf(err, "failed to update child %s", child.RoomID)
+		}
+	}
+
+	for _, child := range current {
+		if slices.ContainsFunc(children, func(wanted SpaceChild) bool { return wanted.RoomID == child.RoomID }) {
+			continue
+		}
+		if _, err := c.client.SendStateEvent(ctx, roomIDObj, event.StateSpaceChild, child.RoomID, &event.SpaceChildEventContent{}); err != nil {
+			return errors.Wrapf(err, "failed to remove child %s", child.RoomID)
+		}
+	}
+
+	return nil
+}
+
+// spaceChildren returns the children of a space, ordered by room ID. A child
+// event without via servers marks a removed child, so it is left out.
+func (c *matrixClient) spaceChildren(ctx context.Context, spaceID string) ([]SpaceChild, error) {
+	state, err := c.GetRoomState(ctx, spaceID, []string{event.StateSpaceChild.Type})
+	if err != nil {
+		return nil, err
+	}
+
+	var children []SpaceChild
+	for _, e := range state {
+		// The content was decoded from JSON, so it can always be encoded
+		raw, _ := json.Marshal(e.Content)
+		var content event.SpaceChildEventContent
+		if err := json.Unmarshal(raw, &content); err != nil || len(content.Via) == 0 {
+			continue
+		}
+		children = append(children, SpaceChild{
+			RoomID:    e.StateKey,
+			Via:       content.Via,
+			Order:     content.Order,
+			Suggested: content.Suggested,
+		})
+	}
+	return children, nil
+}
+
+// spaceChildContent returns the m.space.child content for a child. A child
+// without via servers would not be a child at all, so it defaults to the
+// server of the child's room ID, or to the provider's server for room IDs
+// without one.
+func (c *matrixClient) spaceChildContent(child SpaceChild) *event.SpaceChildEventContent {
+	via := child.Via
+	if len(via) == 0 {
+		serverName := extractDomain(child.RoomID)
+		if serverName == "" {
+			serverName = extractDomain(c.config.UserID)
+		}
+		via = []string{serverName}
+	}
+	return &event.SpaceChildEventContent{
+		Via:       via,
+		Order:     child.Order,
+		Suggested: child.Suggested,
+	}
+}
+
+// DeleteSpace deletes a space. The rooms in it are left alone.
+func (c *matrixClient) DeleteSpace(ctx context.Context, spaceID string) error {
+	return c.DeleteRoom(ctx, spaceID, DeleteRoomOptions{})
+}
+
+// Power level operations
+
+// SetPowerLevels sets power levels in a room. All changes, to any number of
+// users and fields, are sent as a single m.room.power_levels event.
+func (c *matrixClient) SetPowerLevels(ctx context.Context, roomID string, powerLevels *PowerLevelSpec) error {
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return errors.Wrap(err, "invalid room ID")
+	}
+
+	roomIDObj := id.RoomID(roomID)
+
+	// Start from the current power levels, fetched right before sending, so
+	// that anything the spec leaves unset keeps its value on the server
+	content := &event.PowerLevelsEventContent{}
+	if err := c.client.StateEvent(ctx, roomIDObj, event.StatePowerLevels, "", content); err != nil && !IsNotFound(err) {
+		return errors.Wrap(err, "failed to get current power levels")
+	}
+
+	// State events are last-writer-wins, so refuse to overwrite a change made
+	// since the caller last looked rather than silently clobbering it
+	current := powerLevelContentFromEvent(content)
+	if powerLevels.Previous != nil && !reflect.DeepEqual(powerLevels.Previous, current) {
+		return &ConcurrentModificationError{RoomID: roomID, EventType: event.StatePowerLevels.Type}
+	}
+
+	applyPowerLevels(content, powerLevels.PowerLevels)
+
+	// Privileged creators cannot be listed in the power levels, and the
+	// homeserver rejects the event if they are, so leave them out
+	creators, err := c.GetPrivilegedCreators(ctx, roomID)
+	if err != nil && !IsNotFound(err) {
+		return err
+	}
+	for _, creator := range creators {
+		delete(content.Users, id.UserID(creator))
+	}
+
+	// Once sent, power levels that lock the provider out can only be fixed
+	// by someone else, so refuse to send them
+	if err := CheckPowerLevelLockout(current, powerLevelContentFromEvent(content), c.config.UserID, creators); err != nil {
+		return err
+	}
+
+	_, err = c.client.SendStateEvent(ctx, roomIDObj, event.StatePowerLevels, "", content)
+	if err != nil {
+		return errors.Wrap(err, "failed to set power levels")
+	}
+
+	return nil
+}
+
+// ResetPowerLevels replaces the power levels of a room with the defaults of
+// the Matrix spec, or only its user power levels if usersOnly is set. The
+// room's per-event levels are kept either way. The
+// room's creator and the provider's user keep their levels, so that the room
+// can still be managed afterwards. Power levels that are already reset are
+// left as they are.
+func (c *matrixClient) ResetPowerLevels(ctx context.Context, roomID string, usersOnly bool) error {
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return errors.Wrap(err, "invalid room ID")
+	}
+
+	roomIDObj := id.RoomID(roomID)
+	content := &event.PowerLevelsEventContent{}
+	if err := c.client.StateEvent(ctx, roomIDObj, event.StatePowerLevels, "", content); err != nil {
+		return errors.Wrap(err, "failed to get current power levels")
+	}
+	var createContent event.CreateEventContent
+	if err := c.client.StateEvent(ctx, roomIDObj, event.StateCreate, "", &createContent); err != nil {
+		return errors.Wrap(err, "failed to get room create event")
+	}
+
+	// Privileged creators cannot be listed in the power levels, and don't
+	// need to be to keep their power
+	creators := c.privilegedCreators(ctx, roomIDObj, &createContent)
+	users := make(map[id.UserID]int)
+	for _, userID := range []id.UserID{c.roomCreator(ctx, roomIDObj, &createContent), id.UserID(c.config.UserID)} {
+		if level, ok := content.Users[userID]; ok && !slices.Contains(creators, userID.String()) {
+			users[userID] = level
+		}
+	}
+
+	// Spell out the defaults rather than leaving them out, so that the reset
+	// is visible in the room's power levels. Inviting is left to moderators
+	// like banning and kicking, rather than to anyone as the spec's default
+	// would. The room's per-event levels are kept, so that events restricted
+	// beyond state_default, such as m.room.power_levels, stay restricted.
+	// Other state events do fall to state_default.
+	stateDefault := DefaultStateLevel
+	ban := DefaultModeratorLevel
+	kick := DefaultModeratorLevel
+	redact := DefaultModeratorLevel
+	invite := DefaultModeratorLevel
+	reset := &event.PowerLevelsEventContent{
+		Users:           users,
+		Events:          content.Events,
+		StateDefaultPtr: &stateDefault,
+		BanPtr:          &ban,
+		KickPtr:         &kick,
+		RedactPtr:       &redact,
+		InvitePtr:       &invite,
+	}
+	if usersOnly {
+		// Every other field keeps its current value
+		reset = content.Clone()
+		reset.Users = users
+	}
+
+	// Power levels that are already reset are left alone, so that resetting
+	// them again doesn't send another event to the room
+	if reflect.DeepEqual(powerLevelContentFromEvent(reset), powerLevelContentFromEvent(content)) {
+		return nil
+	}
+
+	if err := CheckPowerLevelLockout(powerLevelContentFromEvent(content), powerLevelContentFromEvent(reset), c.config.UserID, creators); err != nil {
+		return err
+	}
+
+	_, err := c.client.SendStateEvent(ctx, roomIDObj, event.StatePowerLevels, "", reset)
+	return errors.Wrap(err, "failed to reset power levels")
+}
+
+// applyPowerLevels overwrites the fields of content that are set in
+// powerLevels. Nil fields leave the existing value alone, while explicit
+// values, including zero, are enforced.
+func applyPowerLevels(content *event.PowerLevelsEventContent, powerLevels *PowerLevelContent) {
+	if powerLevels == nil {
+		return
+	}
+
+	if powerLevels.Users != nil {
+		// Convert user IDs to mautrix format
+		content.Users = make(map[id.UserID]int, len(powerLevels.Users))
+		for userID, level := range powerLevels.Users {
+			content.Users[id.UserID(userID)] = level
+		}
+	}
+	if powerLevels.Events != nil {
+		content.Events = powerLevels.Events
+	}
+	if powerLevels.EventsDefault != nil {
+		content.EventsDefault = *powerLevels.EventsDefault
+	}
+	if powerLevels.StateDefault != nil {
+		content.StateDefaultPtr = powerLevels.StateDefault
+	}
+	if powerLevels.UsersDefault != nil {
+		content.UsersDefault = *powerLevels.UsersDefault
+	}
+	if powerLevels.Ban != nil {
+		content.BanPtr = powerLevels.Ban
+	}
+	if powerLevels.Kick != nil {
+		content.KickPtr = powerLevels.Kick
+	}
+	if powerLevels.Redact != nil {
+		content.RedactPtr = powerLevels.Redact
+	}
+	if powerLevels.Invite != nil {
+		content.InvitePtr = powerLevels.Invite
+	}
+}
+
+// GetPowerLevels retrieves power levels from a room
+func (c *matrixClient) GetPowerLevels(ctx context.Context, roomID string) (*PowerLevelContent, error) {
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return nil, errors.Wrap(err, "invalid room ID")
+	}
+
+	roomIDObj := id.RoomID(roomID)
+	var powerContent event.PowerLevelsEventContent
+	err := c.client.StateEvent(ctx, roomIDObj, event.StatePowerLevels, "", &powerContent)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get power levels")
+	}
+
+	return powerLevelContentFromEvent(&powerContent), nil
+}
+
+// ExportPowerLevels retrieves the power levels of a room with Matrix's
+// defaults filled in for the fields its event leaves out, so that they can
+// be adopted as a complete PowerLevel spec
+func (c *matrixClient) ExportPowerLevels(ctx context.Context, roomID string) (*PowerLevelContent, error) {
+	if err := validateMatrixID(roomID, "room"); err != nil {
+		return nil, errors.Wrap(err, "invalid room ID")
+	}
+
+	var powerContent event.PowerLevelsEventContent
+	err := c.client.StateEvent(ctx, id.RoomID(roomID), event.StatePowerLevels, "", &powerContent)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get power levels")
+	}
+
+	stateDefault := powerContent.StateDefault()
+	ban := powerContent.Ban()
+	kick := powerContent.Kick()
+	redact := powerContent.Redact()
+	invite := powerContent.Invite()
+
+	content := powerLevelContentFromEvent(&powerContent)
+	content.StateDefault = &stateDefault
+	content.Ban = &ban
+	content.Kick = &kick
+	content.Redact = &redact
+	content.Invite = &invite
+	return content, nil
+}
+
+// The levels Matrix uses for the fields a room's power levels leave out.
+const (
+	DefaultUsersLevel     = 0
+	DefaultEventsLevel    = 0
+	DefaultInviteLevel    = 0
+	DefaultStateLevel     = 50
+	DefaultModeratorLevel = 50
+)
+
+// LevelOrDefault returns a power level, or def, the level Matrix uses when a
+// room's power levels leave it out
+func LevelOrDefault(level *int, def int) int {
+	if level == nil {
+		return def
+	}
+	return *level
+}
+
+// CheckPowerLevelLockout returns a *PowerLevelLockoutError if changing the
+// power levels from current to next would leave userID below the level needed
+// to send power levels or other state events. Privileged creators can always
+// send them. A user that is already below it is left for the homeserver to
+// refuse, since the change isn't what locks it out.
+func CheckPowerLevelLockout(current, next *PowerLevelContent, userID string, creators []string) error {
+	if userID == "" || slices.Contains(creators, userID) || powerLevelLockout(current, userID) != nil {
+		return nil
+	}
+	return powerLevelLockout(next, userID)
+}
+
+// powerLevelLockout returns a *PowerLevelLockoutError if userID's level in
+// powerLevels is below the level needed to send power levels or other state
+// events
+func powerLevelLockout(powerLevels *PowerLevelContent, userID string) error {
+
+	level, ok := powerLevels.Users[userID]
+	if !ok {
+		level = LevelOrDefault(powerLevels.UsersDefault, DefaultUsersLevel)
+	}
+
+	stateDefault := LevelOrDefault(powerLevels.StateDefault, DefaultStateLevel)
+	powerLevelsLevel, ok := powerLevels.Events[event.StatePowerLevels.Type]
+	if !ok {
+		powerLevelsLevel = stateDefault
+	}
+
+	switch {
+	case level < powerLevelsLevel:
+		return &PowerLevelLockoutError{UserID: userID, Level: level, Field: "events[" + event.StatePowerLevels.Type + "]", Required: powerLevelsLevel}
+	case level < stateDefault:
+		return &PowerLevelLockoutError{UserID: userID, Level: level, Field: "stateDefault", Required: stateDefault}
+	}
+	return nil
+}
+
+// powerLevelContentFromEvent converts an m.room.power_levels event into a
+// PowerLevelContent
+func powerLevelContentFromEvent(powerContent *event.PowerLevelsEventContent) *PowerLevelContent {
+	// Convert user IDs from mautrix format to our format
+	users := make(map[string]int)
+	for userID, level := range powerContent.Users {
+		users[string(userID)] = level
+	}
+
+	eventsDefault := powerContent.EventsDefault
+	usersDefault := powerContent.UsersDefault
+
+	return &PowerLevelContent{
+		Users:         users,
+		Events:        powerContent.Events,
+		EventsDefault: &eventsDefault,
+		StateDefault:  powerContent.StateDefaultPtr,
+		UsersDefault:  &usersDefault,
+		Ban:           powerContent.BanPtr,
 		Kick:          powerContent.KickPtr,
 		Redact:        powerContent.RedactPtr,
 		Invite:        powerContent.InvitePtr,
-	}, nil
+	}
 }
 
 // Room alias operations
 
-// CreateRoomAlias creates a room alias
+// CreateRoomAlias creates a room alias. An alias that already exists is
+// reported with an AliasConflictError.
 func (c *matrixClient) CreateRoomAlias(ctx context.Context, alias string, roomID string) error {
 	if err := validateMatrixID(alias, "alias"); err != nil {
 		return errors.Wrap(err, "invalid alias")
@@ -441,11 +2733,19 @@ func (c *matrixClient) CreateRoomAlias(ctx context.Context, alias string, roomID
 	roomIDObj := id.RoomID(roomID)
 
 	_, err := c.client.CreateAlias(ctx, aliasID, roomIDObj)
-	if err != nil {
-		return errors.Wrap(err, "failed to create room alias")
+	if err == nil {
+		return nil
 	}
 
-	return nil
+	// Say which room an alias that already exists points at, so the caller
+	// can tell whether it was created by an earlier attempt
+	var httpErr mautrix.HTTPError
+	if errors.As(err, &httpErr) && httpErr.Response != nil && httpErr.Response.StatusCode == http.StatusConflict {
+		if resp, resolveErr := c.client.ResolveAlias(ctx, aliasID); resolveErr == nil {
+			return &AliasConflictError{Alias: alias, RoomID: resp.RoomID.String()}
+		}
+	}
+	return errors.Wrap(err, "failed to create room alias")
 }
 
 // GetRoomAlias retrieves room alias information
@@ -461,8 +2761,9 @@ func (c *matrixClient) GetRoomAlias(ctx context.Context, alias string) (*RoomAli
 	}
 
 	return &RoomAlias{
-		Alias:  alias,
-		RoomID: resp.RoomID.String(),
+		Alias:   alias,
+		RoomID:  resp.RoomID.String(),
+		Servers: resp.Servers,
 	}, nil
 }
 
@@ -0,0 +1,2955 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestClient returns a non-admin client talking to a test homeserver that
+// serves the given state events, keyed by event type. State events sent by the
+// client are stored in the same map. Created rooms get the ID !new:example.com.
+func newTestClient(t *testing.T, state map[string]interface{}) *matrixClient {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/createRoom") {
+			_, _ = w.Write([]byte(`{"room_id":"!new:example.com"}`))
+			return
+		}
+		if idx := strings.Index(r.URL.Path, "/state/"); idx >= 0 {
+			eventType := strings.TrimSuffix(r.URL.Path[idx+len("/state/"):], "/")
+			if r.Method == http.MethodPut {
+				var content map[string]interface{}
+				_ = json.NewDecoder(r.Body).Decode(&content)
+				state[eventType] = content
+				_, _ = w.Write([]byte(`{"event_id":"$event"}`))
+				return
+			}
+			if content, ok := state[eventType]; ok {
+				_ = json.NewEncoder(w).Encode(content)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"errcode":"M_NOT_FOUND","error":"Event not found."}`))
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@bot:example.com",
+	})
+	require.NoError(t, err)
+
+	return c.(*matrixClient)
+}
+
+func TestGetRoomPredecessor(t *testing.T) {
+	c := newTestClient(t, map[string]interface{}{
+		"m.room.create": map[string]interface{}{
+			"creator":      "@alice:example.com",
+			"room_version": "10",
+			"predecessor": map[string]interface{}{
+				"room_id":  "!old:example.com",
+				"event_id": "$tombstone",
+			},
+		},
+	})
+
+	room, err := c.GetRoom(context.Background(), "!new:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "@alice:example.com", room.Creator)
+	assert.Equal(t, "10", room.RoomVersion)
+	require.NotNil(t, room.Predecessor)
+	assert.Equal(t, "!old:example.com", room.Predecessor.RoomID)
+	assert.Equal(t, "$tombstone", room.Predecessor.EventID)
+}
+
+func TestGetRoomWithoutPredecessor(t *testing.T) {
+	c := newTestClient(t, map[string]interface{}{
+		"m.room.create": map[string]interface{}{
+			"creator":      "@alice:example.com",
+			"room_version": "10",
+		},
+	})
+
+	room, err := c.GetRoom(context.Background(), "!room:example.com")
+	require.NoError(t, err)
+	assert.Nil(t, room.Predecessor)
+}
+
+func TestRemoteRoom(t *testing.T) {
+	tests := []struct {
+		name   string
+		roomID string
+		userID string
+		want   bool
+	}{
+		{name: "local", roomID: "!room:example.com", userID: "@bot:example.com"},
+		{name: "remote", roomID: "!room:other.org", userID: "@bot:example.com", want: true},
+		{name: "port", roomID: "!room:example.com:8448", userID: "@bot:example.com", want: true},
+		{name: "no server name", roomID: "!hash", userID: "@bot:example.com"},
+		{name: "no user", roomID: "!room:other.org"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, remote := RemoteRoom(tt.roomID, tt.userID)
+			assert.Equal(t, tt.want, remote)
+		})
+	}
+}
+
+func TestGetRemoteRoomSummary(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/_synapse/admin/"):
+			t.Errorf("admin API used for a remote room: %s", r.URL.Path)
+		case strings.HasSuffix(r.URL.Path, "/hierarchy"):
+			_, _ = w.Write([]byte(`{"rooms":[{"room_id":"!room:other.org","name":"Lobby","topic":"Chat",
+				"canonical_alias":"#lobby:other.org","join_rule":"public","guest_can_join":true,
+				"world_readable":false,"num_joined_members":42,"room_type":"","children_state":[]}]}`))
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"errcode":"M_FORBIDDEN","error":"You are not in the room"}`))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@bot:example.com",
+		AdminMode:     true,
+	})
+	require.NoError(t, err)
+
+	room, err := c.GetRoom(context.Background(), "!room:other.org")
+	require.NoError(t, err)
+	assert.True(t, room.Summary)
+	assert.Equal(t, "Lobby", room.Name)
+	assert.Equal(t, "Chat", room.Topic)
+	assert.Equal(t, "#lobby:other.org", room.Alias)
+	assert.Equal(t, "public", room.JoinRules)
+	assert.Equal(t, "can_join", room.GuestAccess)
+	assert.Equal(t, 42, room.JoinedMembers)
+
+	exists, err := c.RoomExists(context.Background(), "!room:other.org")
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestGetRoomHistoryVisibility(t *testing.T) {
+	c := newTestClient(t, map[string]interface{}{
+		"m.room.history_visibility": map[string]interface{}{"history_visibility": "joined"},
+	})
+
+	room, err := c.GetRoom(context.Background(), "!room:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "joined", room.HistoryVisibility)
+}
+
+func TestCreateRoomPresetHistoryVisibility(t *testing.T) {
+	tests := []struct {
+		name              string
+		preset            string
+		historyVisibility string
+		wantSent          bool
+	}{
+		{
+			name:   "unset history visibility is left to the preset",
+			preset: "private_chat",
+		},
+		{
+			name:              "preset default is not sent again",
+			preset:            "public_chat",
+			historyVisibility: "shared",
+		},
+		{
+			name:              "other history visibility is sent",
+			preset:            "private_chat",
+			historyVisibility: "joined",
+			wantSent:          true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := map[string]interface{}{}
+			c := newTestClient(t, state)
+
+			_, err := c.CreateRoom(context.Background(), &RoomSpec{
+				Preset:            tt.preset,
+				HistoryVisibility: tt.historyVisibility,
+			})
+			require.NoError(t, err)
+
+			_, sent := state["m.room.history_visibility"]
+			assert.Equal(t, tt.wantSent, sent)
+		})
+	}
+}
+
+func TestRemoveJoinRuleAllow(t *testing.T) {
+	state := map[string]interface{}{
+		"m.room.join_rules": map[string]interface{}{
+			"join_rule": "restricted",
+			"allow": []interface{}{
+				map[string]interface{}{"type": "m.room_membership", "room_id": "!space:example.com"},
+				map[string]interface{}{"type": "m.room_membership", "room_id": "!gone:example.com"},
+			},
+		},
+	}
+	c := newTestClient(t, state)
+
+	room, err := c.GetRoom(context.Background(), "!room:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "restricted", room.JoinRules)
+	assert.Equal(t, []string{"!space:example.com", "!gone:example.com"}, room.JoinRuleAllow)
+
+	joinRules, err := c.GetJoinRules(context.Background(), "!room:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "restricted", joinRules)
+
+	err = c.RemoveJoinRuleAllow(context.Background(), "!room:example.com", []string{"!gone:example.com"})
+	require.NoError(t, err)
+
+	room, err = c.GetRoom(context.Background(), "!room:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "restricted", room.JoinRules)
+	assert.Equal(t, []string{"!space:example.com"}, room.JoinRuleAllow)
+}
+
+func TestJoinRulesKeepUnknownFields(t *testing.T) {
+	state := map[string]interface{}{
+		"m.room.join_rules": map[string]interface{}{
+			"join_rule":                   "knock",
+			"org.example.reason_required": true,
+			"allow": []interface{}{
+				map[string]interface{}{"type": "m.room_membership", "room_id": "!space:example.com"},
+				map[string]interface{}{"type": "m.room_membership", "room_id": "!gone:example.com"},
+			},
+		},
+	}
+	c := newTestClient(t, state)
+
+	room, err := c.GetRoom(context.Background(), "!room:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, true, room.JoinRulesContent["org.example.reason_required"])
+
+	_, err = c.UpdateRoom(context.Background(), "!room:example.com", &RoomSpec{JoinRules: "knock_restricted"})
+	require.NoError(t, err)
+
+	err = c.RemoveJoinRuleAllow(context.Background(), "!room:example.com", []string{"!gone:example.com"})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"join_rule":                   "knock_restricted",
+		"org.example.reason_required": true,
+		"allow": []interface{}{
+			map[string]interface{}{"type": "m.room_membership", "room_id": "!space:example.com"},
+		},
+	}, state["m.room.join_rules"])
+}
+
+func TestUpdateJoinRuleAllow(t *testing.T) {
+	state := map[string]interface{}{
+		"m.room.join_rules": map[string]interface{}{
+			"join_rule": "restricted",
+			"allow": []interface{}{
+				map[string]interface{}{"type": "org.example.condition", "key": "value"},
+				map[string]interface{}{"type": "m.room_membership", "room_id": "!old:example.com"},
+			},
+		},
+	}
+	c := newTestClient(t, state)
+
+	_, err := c.UpdateRoom(context.Background(), "!room:example.com", &RoomSpec{
+		JoinRuleAllow: []string{"!space:example.com"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"join_rule": "restricted",
+		"allow": []interface{}{
+			map[string]interface{}{"type": "org.example.condition", "key": "value"},
+			map[string]interface{}{"type": "m.room_membership", "room_id": "!space:example.com"},
+		},
+	}, state["m.room.join_rules"])
+}
+
+func TestUpdatePowerLevelOverrides(t *testing.T) {
+	state := map[string]interface{}{
+		"m.room.power_levels": map[string]interface{}{
+			"users": map[string]interface{}{"@bot:example.com": 100, "@alice:example.com": 50, "@carol:example.com": 10},
+			"ban":   50,
+		},
+	}
+	c := newTestClient(t, state)
+
+	ban := 60
+	spec := &RoomSpec{PowerLevelOverrides: &PowerLevelContent{
+		Users: map[string]int{"@alice:example.com": 100},
+		Ban:   &ban,
+	}}
+	_, err := c.UpdateRoom(context.Background(), "!room:example.com", spec)
+	require.NoError(t, err)
+
+	// Users the overrides leave out keep their levels
+	powerLevels := state["m.room.power_levels"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"@bot:example.com": float64(100), "@alice:example.com": float64(100), "@carol:example.com": float64(10),
+	}, powerLevels["users"])
+	assert.Equal(t, float64(60), powerLevels["ban"])
+
+	// Nothing is sent once the overrides hold
+	powerLevels["unsent"] = true
+	_, err = c.UpdateRoom(context.Background(), "!room:example.com", spec)
+	require.NoError(t, err)
+	assert.Equal(t, true, state["m.room.power_levels"].(map[string]interface{})["unsent"])
+}
+
+func TestGetReplacementRoom(t *testing.T) {
+	tombstones := map[string]string{
+		"!v1:example.com":  "!v2:example.com",
+		"!v2:example.com":  "!v3:example.com",
+		"!old:example.com": "!hidden:example.com",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		roomID, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/_matrix/client/v3/rooms/"), "/")
+		switch {
+		case roomID == "!hidden:example.com" || roomID == "!forbidden:example.com":
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errcode":"M_FORBIDDEN","error":"You are not in the room."}`))
+		case tombstones[roomID] != "":
+			_ = json.NewEncoder(w).Encode(map[string]string{"body": "Upgraded", "replacement_room": tombstones[roomID]})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errcode":"M_NOT_FOUND","error":"Event not found."}`))
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@bot:example.com",
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		roomID  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "not upgraded",
+			roomID: "!current:example.com",
+			want:   "!current:example.com",
+		},
+		{
+			name:   "upgraded",
+			roomID: "!v2:example.com",
+			want:   "!v3:example.com",
+		},
+		{
+			name:   "upgraded twice",
+			roomID: "!v1:example.com",
+			want:   "!v3:example.com",
+		},
+		{
+			name:   "replacement can't be seen into",
+			roomID: "!old:example.com",
+			want:   "!hidden:example.com",
+		},
+		{
+			name:    "tombstone can't be read",
+			roomID:  "!forbidden:example.com",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.GetReplacementRoom(context.Background(), tt.roomID)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGuestAccess(t *testing.T) {
+	state := map[string]interface{}{}
+	c := newTestClient(t, state)
+
+	// The public_chat preset leaves the room without a guest access event
+	room, err := c.GetRoom(context.Background(), "!room:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "forbidden", room.GuestAccess)
+
+	_, err = c.UpdateRoom(context.Background(), "!room:example.com", &RoomSpec{GuestAccess: "forbidden"})
+	require.NoError(t, err)
+	assert.NotContains(t, state, "m.room.guest_access")
+
+	room, err = c.UpdateRoom(context.Background(), "!room:example.com", &RoomSpec{GuestAccess: "can_join"})
+	require.NoError(t, err)
+	assert.Equal(t, "can_join", room.GuestAccess)
+
+	room, err = c.UpdateRoom(context.Background(), "!room:example.com", &RoomSpec{GuestAccess: "forbidden"})
+	require.NoError(t, err)
+	assert.Equal(t, "forbidden", room.GuestAccess)
+	assert.Equal(t, map[string]interface{}{"guest_access": "forbidden"}, state["m.room.guest_access"])
+}
+
+func TestRoomExists(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:   "room is visible",
+			status: http.StatusOK,
+			body:   `{"rooms":[]}`,
+			want:   true,
+		},
+		{
+			name:   "room exists but can't be seen",
+			status: http.StatusForbidden,
+			body:   `{"errcode":"M_FORBIDDEN","error":"You cannot view this room."}`,
+			want:   true,
+		},
+		{
+			name:   "room is unknown",
+			status: http.StatusNotFound,
+			body:   `{"errcode":"M_NOT_FOUND","error":"Unknown room"}`,
+			want:   false,
+		},
+		{
+			name:    "summary isn't supported",
+			status:  http.StatusNotFound,
+			body:    `{"errcode":"M_UNRECOGNIZED","error":"Unrecognized request"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// The admin API isn't asked, even in admin mode
+				assert.Equal(t, "/_matrix/client/v1/rooms/!space:example.com/hierarchy", r.URL.Path)
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			c, err := NewClient(&Config{
+				HomeserverURL: server.URL,
+				AccessToken:   "test_token",
+				UserID:        "@bot:example.com",
+				AdminMode:     true,
+			})
+			require.NoError(t, err)
+
+			exists, err := c.RoomExists(context.Background(), "!space:example.com")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, exists)
+		})
+	}
+}
+
+func TestEventPinnable(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:   "event is in the room",
+			status: http.StatusOK,
+			body:   `{"event_id":"$event","room_id":"!room:example.com","type":"m.room.message","content":{"body":"hi"}}`,
+			want:   true,
+		},
+		{
+			name:   "event has been redacted",
+			status: http.StatusOK,
+			body:   `{"event_id":"$event","room_id":"!room:example.com","type":"m.room.message","content":{},"unsigned":{"redacted_because":{"event_id":"$redaction","type":"m.room.redaction"}}}`,
+			want:   false,
+		},
+		{
+			name:   "event is in another room",
+			status: http.StatusOK,
+			body:   `{"event_id":"$event","room_id":"!other:example.com","type":"m.room.message","content":{}}`,
+			want:   false,
+		},
+		{
+			name:   "event is unknown",
+			status: http.StatusNotFound,
+			body:   `{"errcode":"M_NOT_FOUND","error":"Could not find event $event"}`,
+			want:   false,
+		},
+		{
+			name:   "event can't be seen",
+			status: http.StatusForbidden,
+			body:   `{"errcode":"M_FORBIDDEN","error":"User not in room"}`,
+			want:   false,
+		},
+		{
+			name:    "homeserver error",
+			status:  http.StatusInternalServerError,
+			body:    `{"errcode":"M_UNKNOWN","error":"Internal server error"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/_matrix/client/v3/rooms/!room:example.com/event/$event", r.URL.Path)
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			c, err := NewClient(&Config{
+				HomeserverURL: server.URL,
+				AccessToken:   "test_token",
+				UserID:        "@bot:example.com",
+			})
+			require.NoError(t, err)
+
+			pinnable, err := c.EventPinnable(context.Background(), "!room:example.com", "$event")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, pinnable)
+		})
+	}
+}
+
+func TestPinnedEvents(t *testing.T) {
+	state := map[string]interface{}{
+		"m.room.pinned_events": map[string]interface{}{"pinned": []string{"$a"}},
+	}
+	c := newTestClient(t, state)
+
+	room, err := c.GetRoom(context.Background(), "!room:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"$a"}, room.PinnedEvents)
+
+	room, err = c.UpdateRoom(context.Background(), "!room:example.com", &RoomSpec{PinnedEvents: []string{"$b", "$a"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"$b", "$a"}, room.PinnedEvents)
+
+	room, err = c.UpdateRoom(context.Background(), "!room:example.com", &RoomSpec{PinnedEvents: []string{}})
+	require.NoError(t, err)
+	assert.Empty(t, room.PinnedEvents)
+}
+
+func TestGetMembership(t *testing.T) {
+	state := map[string]interface{}{}
+	c := newTestClient(t, state)
+
+	membership, err := c.GetMembership(context.Background(), "!room:example.com")
+	require.NoError(t, err)
+	assert.Empty(t, membership)
+
+	state["m.room.member/@bot:example.com"] = map[string]interface{}{"membership": "leave"}
+	membership, err = c.GetMembership(context.Background(), "!room:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "leave", membership)
+}
+
+func TestJoinRoom(t *testing.T) {
+	tests := []struct {
+		name      string
+		adminMode bool
+		wantErr   bool
+	}{
+		{
+			name:    "can't join without the admin API",
+			wantErr: true,
+		},
+		{
+			name:      "added with the admin API",
+			adminMode: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var adminBody map[string]interface{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/_matrix/client/v3/rooms/!room:example.com/join":
+					w.WriteHeader(http.StatusForbidden)
+					_, _ = w.Write([]byte(`{"errcode":"M_FORBIDDEN","error":"You are not invited to this room."}`))
+				case "/_synapse/admin/v1/join/!room:example.com":
+					_ = json.NewDecoder(r.Body).Decode(&adminBody)
+					_, _ = w.Write([]byte(`{"room_id":"!room:example.com"}`))
+				default:
+					t.Errorf("unexpected request to %s", r.URL.Path)
+				}
+			}))
+			defer server.Close()
+
+			c, err := NewClient(&Config{
+				HomeserverURL: server.URL,
+				AccessToken:   "test_token",
+				UserID:        "@bot:example.com",
+				AdminMode:     tt.adminMode,
+			})
+			require.NoError(t, err)
+
+			err = c.JoinRoom(context.Background(), "!room:example.com")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, map[string]interface{}{"user_id": "@bot:example.com"}, adminBody)
+		})
+	}
+}
+
+func TestInviteUsersRateLimited(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxRetries  int
+		wantInvited int
+		wantLimited bool
+	}{
+		{
+			name:        "retried until the homeserver lets the invites through",
+			maxRetries:  3,
+			wantInvited: 3,
+		},
+		{
+			name:        "stops at the first invite still rate limited",
+			wantInvited: 0,
+			wantLimited: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int
+			var invited []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/_matrix/client/v3/rooms/!room:example.com/invite" {
+					t.Errorf("unexpected request to %s", r.URL.Path)
+					return
+				}
+				requests++
+				if requests <= 2 {
+					w.WriteHeader(http.StatusTooManyRequests)
+					_, _ = w.Write([]byte(`{"errcode":"M_LIMIT_EXCEEDED","error":"Too Many Requests","retry_after_ms":1}`))
+					return
+				}
+				var body struct {
+					UserID string `json:"user_id"`
+				}
+				_ = json.NewDecoder(r.Body).Decode(&body)
+				invited = append(invited, body.UserID)
+				_, _ = w.Write([]byte(`{}`))
+			}))
+			defer server.Close()
+
+			c, err := NewClient(&Config{
+				HomeserverURL: server.URL,
+				AccessToken:   "test_token",
+				UserID:        "@bot:example.com",
+				MaxRetries:    tt.maxRetries,
+			})
+			require.NoError(t, err)
+
+			users := []string{"@alice:example.com", "@bob:example.com", "@carol:example.com"}
+			n, err := c.InviteUsers(context.Background(), "!room:example.com", users)
+			assert.Equal(t, tt.wantInvited, n)
+			assert.Len(t, invited, n)
+			if tt.wantLimited {
+				assert.True(t, IsRateLimited(err))
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestGetRoomState(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/_matrix/client/v3/rooms/!room:example.com/state", r.URL.Path)
+		_, _ = w.Write([]byte(`[
+			{"type":"m.room.member","state_key":"@alice:example.com","content":{"membership":"join"}},
+			{"type":"m.bridge","state_key":"irc","content":{"protocol":{"id":"irc"}}},
+			{"type":"m.room.server_acl","state_key":"","content":{"allow":["*"],"deny":["evil.example.com"]}},
+			{"type":"m.bridge","state_key":"discord","content":{"protocol":{"id":"discord"}}}
+		]`))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@bot:example.com",
+	})
+	require.NoError(t, err)
+
+	state, err := c.GetRoomState(context.Background(), "!room:example.com", []string{"m.room.server_acl", "m.bridge"})
+	require.NoError(t, err)
+	assert.Equal(t, []StateEvent{
+		{Type: "m.bridge", StateKey: "discord", Content: map[string]interface{}{"protocol": map[string]interface{}{"id": "discord"}}},
+		{Type: "m.bridge", StateKey: "irc", Content: map[string]interface{}{"protocol": map[string]interface{}{"id": "irc"}}},
+		{Type: "m.room.server_acl", Content: map[string]interface{}{"allow": []interface{}{"*"}, "deny": []interface{}{"evil.example.com"}}},
+	}, state)
+}
+
+func TestCreateRoomAliasExists(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"errcode":"M_UNKNOWN","error":"Room alias #general:example.com already exists"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"room_id":"!room:example.com","servers":["example.com"]}`))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@bot:example.com",
+	})
+	require.NoError(t, err)
+
+	err = c.CreateRoomAlias(context.Background(), "#general:example.com", "!room:example.com")
+	var conflict *AliasConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, "!room:example.com", conflict.RoomID)
+}
+
+func TestLockUser(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "/_synapse/admin/v2/users/@alice:example.com", r.URL.Path)
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@bot:example.com",
+		AdminMode:     true,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, c.LockUser(context.Background(), "@alice:example.com", true))
+	assert.Equal(t, map[string]interface{}{"locked": true}, body)
+}
+
+func TestGetUserTimestamps(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/_synapse/admin/v2/users/@alice:example.com", r.URL.Path)
+		_, _ = w.Write([]byte(`{"name":"@alice:example.com","admin":false,"creation_ts":1560432506,"last_seen_ts":1732919539393}`))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@bot:example.com",
+		AdminMode:     true,
+	})
+	require.NoError(t, err)
+
+	user, err := c.GetUser(context.Background(), "@alice:example.com")
+	require.NoError(t, err)
+	require.NotNil(t, user.CreationTime)
+	assert.Equal(t, int64(1560432506), user.CreationTime.Unix())
+	require.NotNil(t, user.LastSeenTime)
+	assert.Equal(t, int64(1732919539393), user.LastSeenTime.UnixMilli())
+}
+
+func TestSetUserAdmin(t *testing.T) {
+	var paths []string
+	v1Allowed := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		paths = append(paths, r.URL.Path)
+
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		assert.Equal(t, map[string]interface{}{"admin": true}, body)
+
+		if r.URL.Path == "/_synapse/admin/v1/users/@alice:example.com/admin" && !v1Allowed {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errcode":"M_FORBIDDEN","error":"Forbidden"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@bot:example.com",
+		AdminMode:     true,
+	})
+	require.NoError(t, err)
+
+	// The v1 toggle is refused, so the v2 PUT is used
+	admin := c.(*matrixClient).adminClient
+	require.NoError(t, admin.setUserAdmin(context.Background(), "@alice:example.com", true))
+	assert.Equal(t, []string{
+		"/_synapse/admin/v1/users/@alice:example.com/admin",
+		"/_synapse/admin/v2/users/@alice:example.com",
+	}, paths)
+
+	// The v2 PUT is tried first from then on
+	paths = nil
+	require.NoError(t, admin.setUserAdmin(context.Background(), "@alice:example.com", true))
+	assert.Equal(t, []string{"/_synapse/admin/v2/users/@alice:example.com"}, paths)
+}
+
+func TestSetUserAdminRefused(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"errcode":"M_FORBIDDEN","error":"Forbidden"}`))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@bot:example.com",
+		AdminMode:     true,
+	})
+	require.NoError(t, err)
+
+	err = c.(*matrixClient).adminClient.setUserAdmin(context.Background(), "@alice:example.com", true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "v1: admin API request failed with status 403")
+	assert.Contains(t, err.Error(), "v2: admin API request failed with status 403")
+}
+
+func TestUpdateUserAdmin(t *testing.T) {
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+
+		if r.URL.Path == "/_synapse/admin/v2/users/@alice:example.com" {
+			_, _ = w.Write([]byte(`{"user_id":"@alice:example.com","displayname":"Alice","admin":false}`))
+			return
+		}
+		assert.Equal(t, "/_synapse/admin/v1/users/@alice:example.com/admin", r.URL.Path)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@bot:example.com",
+		AdminMode:     true,
+	})
+	require.NoError(t, err)
+
+	user, err := c.UpdateUser(context.Background(), "@alice:example.com", &UserSpec{DisplayName: "Alice", Admin: true})
+	require.NoError(t, err)
+	assert.True(t, user.Admin)
+	require.Len(t, bodies, 2)
+	assert.NotContains(t, bodies[0], "admin")
+	assert.Equal(t, map[string]interface{}{"admin": true}, bodies[1])
+}
+
+func TestSetDirectoryNetworkVisibility(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "/_matrix/client/v3/directory/list/appservice/irc/!room:example.com", r.URL.Path)
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "as_token",
+		UserID:        "@bridge:example.com",
+	})
+	require.NoError(t, err)
+
+	err = c.SetDirectoryNetworkVisibility(context.Background(), "irc", "!room:example.com", "public")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"visibility": "public"}, body)
+}
+
+func TestUpdateRoomName(t *testing.T) {
+	tests := []struct {
+		name string
+		spec *RoomSpec
+		want string
+	}{
+		{
+			name: "name unset leaves it alone",
+			spec: &RoomSpec{},
+			want: "Old Name",
+		},
+		{
+			name: "name is changed",
+			spec: &RoomSpec{Name: stringPtr("New Name")},
+			want: "New Name",
+		},
+		{
+			name: "empty name removes it",
+			spec: &RoomSpec{Name: stringPtr("")},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := map[string]interface{}{
+				"m.room.name": map[string]interface{}{"name": "Old Name"},
+			}
+			c := newTestClient(t, state)
+
+			room, err := c.UpdateRoom(context.Background(), "!room:example.com", tt.spec)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, room.Name)
+		})
+	}
+}
+
+func TestUpdateRoomUnchangedNameAndTopic(t *testing.T) {
+	// A name or topic that is sent again replaces these events, losing the
+	// markers
+	state := map[string]interface{}{
+		"m.room.name":  map[string]interface{}{"name": "General", "marker": true},
+		"m.room.topic": map[string]interface{}{"topic": "Welcome", "marker": true},
+	}
+	c := newTestClient(t, state)
+
+	_, err := c.UpdateRoom(context.Background(), "!room:example.com", &RoomSpec{Name: stringPtr("General"), Topic: "Welcome"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "General", "marker": true}, state["m.room.name"])
+	assert.Equal(t, map[string]interface{}{"topic": "Welcome", "marker": true}, state["m.room.topic"])
+
+	// A rich topic differs from the same plain one
+	_, err = c.UpdateRoom(context.Background(), "!room:example.com", &RoomSpec{Name: stringPtr("Lobby"), Topic: "Welcome", RichTopic: true})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "Lobby"}, state["m.room.name"])
+	assert.NotContains(t, state["m.room.topic"], "marker")
+}
+
+func TestGetRoomStateEventCount(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/_synapse/admin/v1/rooms/!room:example.com" {
+			_, _ = w.Write([]byte(`{"room_id":"!room:example.com","name":"Big Room","joined_members":3,"state_events":1234}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"errcode":"M_NOT_FOUND","error":"Event not found."}`))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@admin:example.com",
+		AdminMode:     true,
+	})
+	require.NoError(t, err)
+
+	room, err := c.GetRoom(context.Background(), "!room:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "Big Room", room.Name)
+	assert.Equal(t, 1234, room.StateEvents)
+}
+
+func TestSetMembership(t *testing.T) {
+	tests := []struct {
+		name       string
+		current    string
+		membership string
+		want       []string
+	}{
+		{name: "invite", current: "", membership: "invite", want: []string{"invite"}},
+		{name: "invite accepted", current: "join", membership: "invite"},
+		{name: "kick", current: "join", membership: "leave", want: []string{"kick"}},
+		{name: "revoke invite", current: "invite", membership: "leave", want: []string{"kick"}},
+		{name: "ban", current: "join", membership: "ban", want: []string{"ban"}},
+		{name: "lift ban", current: "ban", membership: "leave", want: []string{"unban"}},
+		{name: "invite banned user", current: "ban", membership: "invite", want: []string{"unban", "invite"}},
+		{name: "already left", current: "", membership: "leave"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			var reasons []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/_matrix/client/v3/rooms/!room:example.com/state/m.room.member/@alice:example.com" && tt.current != "" {
+					_, _ = w.Write([]byte(`{"membership":"` + tt.current + `"}`))
+					return
+				}
+				if r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/_matrix/client/v3/rooms/!room:example.com/") {
+					var body map[string]string
+					_ = json.NewDecoder(r.Body).Decode(&body)
+					calls = append(calls, strings.TrimPrefix(r.URL.Path, "/_matrix/client/v3/rooms/!room:example.com/"))
+					reasons = append(reasons, body["reason"])
+					_, _ = w.Write([]byte(`{}`))
+					return
+				}
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"errcode":"M_NOT_FOUND","error":"Event not found."}`))
+			}))
+			defer server.Close()
+
+			c, err := NewClient(&Config{
+				HomeserverURL: server.URL,
+				AccessToken:   "test_token",
+				UserID:        "@bot:example.com",
+			})
+			require.NoError(t, err)
+
+			require.NoError(t, c.SetMembership(context.Background(), "!room:example.com", "@alice:example.com", tt.membership, "Moderation"))
+			assert.Equal(t, tt.want, calls)
+			for _, reason := range reasons {
+				assert.Equal(t, "Moderation", reason)
+			}
+		})
+	}
+}
+
+func TestSetMembershipJoinRequiresAdmin(t *testing.T) {
+	c := newTestClient(t, map[string]interface{}{})
+	err := c.SetMembership(context.Background(), "!room:example.com", "@alice:example.com", "join", "")
+	assert.Error(t, err)
+}
+
+func TestExportPowerLevels(t *testing.T) {
+	c := newTestClient(t, map[string]interface{}{
+		"m.room.power_levels": map[string]interface{}{
+			"users":  map[string]interface{}{"@alice:example.com": 100},
+			"events": map[string]interface{}{"m.room.name": 50},
+			"kick":   75,
+		},
+	})
+
+	content, err := c.ExportPowerLevels(context.Background(), "!room:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"@alice:example.com": 100}, content.Users)
+	assert.Equal(t, map[string]int{"m.room.name": 50}, content.Events)
+
+	// Fields the event leaves out get Matrix's defaults
+	levels := map[string]*int{
+		"events_default": content.EventsDefault,
+		"state_default":  content.StateDefault,
+		"users_default":  content.UsersDefault,
+		"ban":            content.Ban,
+		"kick":           content.Kick,
+		"redact":         content.Redact,
+		"invite":         content.Invite,
+	}
+	want := map[string]int{
+		"events_default": 0,
+		"state_default":  50,
+		"users_default":  0,
+		"ban":            50,
+		"kick":           75,
+		"redact":         50,
+		"invite":         0,
+	}
+	for field, level := range levels {
+		require.NotNil(t, level, field)
+		assert.Equal(t, want[field], *level, field)
+	}
+}
+
+func TestGetRoomDirectoryVisibility(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_matrix/client/v3/directory/list/room/!room:example.com":
+			_, _ = w.Write([]byte(`{"visibility":"public"}`))
+		case "/_matrix/client/v3/rooms/!room:example.com/state/m.room.join_rules/":
+			_, _ = w.Write([]byte(`{"join_rule":"public"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errcode":"M_NOT_FOUND","error":"Event not found."}`))
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@bot:example.com",
+	})
+	require.NoError(t, err)
+
+	room, err := c.GetRoom(context.Background(), "!room:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "public", room.Visibility)
+	assert.Equal(t, "public", room.JoinRules)
+}
+
+func TestUpdateRoomHistoryVisibilityAndAvatar(t *testing.T) {
+	state := map[string]interface{}{
+		"m.room.history_visibility": map[string]interface{}{"history_visibility": "shared"},
+		"m.room.avatar":             map[string]interface{}{"url": "mxc://example.com/old"},
+	}
+	c := newTestClient(t, state)
+
+	_, err := c.UpdateRoom(context.Background(), "!room:example.com", &RoomSpec{
+		HistoryVisibility: "joined",
+		AvatarURL:         "mxc://example.com/new",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"history_visibility": "joined"}, state["m.room.history_visibility"])
+	assert.Equal(t, map[string]interface{}{"url": "mxc://example.com/new"}, state["m.room.avatar"])
+
+	_, err = c.UpdateRoom(context.Background(), "!room:example.com", &RoomSpec{AvatarURL: "https://example.com/avatar.png"})
+	assert.Error(t, err)
+}
+
+func TestUpdateRoomEncryption(t *testing.T) {
+	var encrypted bool
+	var sends int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/state/m.room.encryption/") {
+			if r.Method == http.MethodPut {
+				sends++
+				encrypted = true
+				_, _ = w.Write([]byte(`{"event_id":"$event"}`))
+				return
+			}
+			if encrypted {
+				_, _ = w.Write([]byte(`{"algorithm":"m.megolm.v1.aes-sha2"}`))
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"errcode":"M_NOT_FOUND","error":"Event not found."}`))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@bot:example.com",
+	})
+	require.NoError(t, err)
+
+	spec := &RoomSpec{EncryptionEnabled: true}
+	for range 3 {
+		room, err := c.UpdateRoom(context.Background(), "!room:example.com", spec)
+		require.NoError(t, err)
+		assert.True(t, room.EncryptionEnabled)
+	}
+	assert.Equal(t, 1, sends, "encryption must only be enabled once")
+}
+
+func TestDevices(t *testing.T) {
+	var renamed map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/_synapse/admin/v2/users/@bot:example.com/devices":
+			_, _ = w.Write([]byte(`{"devices":[{"device_id":"ABCDEF","display_name":"Crossplane provider-matrix","last_seen_ip":"10.0.0.1","last_seen_ts":1700000000000,"user_id":"@bot:example.com"}],"total":1}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/_synapse/admin/v2/users/@bot:example.com/devices/ABCDEF":
+			_ = json.NewDecoder(r.Body).Decode(&renamed)
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errcode":"M_NOT_FOUND","error":"Not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@admin:example.com",
+		AdminMode:     true,
+	})
+	require.NoError(t, err)
+
+	devices, err := c.GetDevices(context.Background(), "@bot:example.com")
+	require.NoError(t, err)
+	require.Len(t, devices, 1)
+	assert.Equal(t, "ABCDEF", devices[0].DeviceID)
+	assert.Equal(t, "Crossplane provider-matrix", devices[0].DisplayName)
+	require.NotNil(t, devices[0].LastSeenTime)
+	assert.Equal(t, int64(1700000000000), devices[0].LastSeenTime.UnixMilli())
+
+	require.NoError(t, c.SetDeviceDisplayName(context.Background(), "@bot:example.com", "ABCDEF", "Alerts bot"))
+	assert.Equal(t, map[string]interface{}{"display_name": "Alerts bot"}, renamed)
+}
+
+func TestAdminAPIPathPrefix(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL:      server.URL,
+		AccessToken:        "test_token",
+		UserID:             "@admin:example.com",
+		AdminMode:          true,
+		AdminAPIPathPrefix: "/internal/",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, c.LockUser(context.Background(), "@alice:example.com", true))
+	assert.Equal(t, "/internal/_synapse/admin/v2/users/@alice:example.com", path)
+}
+
+func TestUpdateSpaceChildren(t *testing.T) {
+	children := map[string]map[string]interface{}{
+		"!keep:example.com":   {"via": []string{"example.com"}, "suggested": true},
+		"!change:example.com": {"via": []string{"example.com"}, "order": "01"},
+		"!remove:example.com": {"via": []string{"example.com"}},
+		"!gone:example.com":   {},
+	}
+	var sent []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		const childPrefix = "/_matrix/client/v3/rooms/!space:example.com/state/m.space.child/"
+		switch {
+		case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, childPrefix):
+			roomID := strings.TrimPrefix(r.URL.Path, childPrefix)
+			var content map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&content)
+			children[roomID] = content
+			sent = append(sent, roomID)
+			_, _ = w.Write([]byte(`{"event_id":"$event"}`))
+		case r.URL.Path == "/_matrix/client/v3/rooms/!space:example.com/state":
+			var state []map[string]interface{}
+			for roomID, content := range children {
+				state = append(state, map[string]interface{}{"type": "m.space.child", "state_key": roomID, "content": content})
+			}
+			_ = json.NewEncoder(w).Encode(state)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errcode":"M_NOT_FOUND","error":"Event not found."}`))
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@bot:example.com",
+	})
+	require.NoError(t, err)
+
+	space, err := c.UpdateSpace(context.Background(), "!space:example.com", &SpaceSpec{Children: []SpaceChild{
+		{RoomID: "!keep:example.com", Via: []string{"example.com"}, Suggested: true},
+		{RoomID: "!change:example.com", Order: "02"},
+		{RoomID: "!new:other.example.com"},
+		{RoomID: v12RoomID},
+	}})
+	require.NoError(t, err)
+
+	// A room ID without a server name is joined through the provider's server
+	assert.ElementsMatch(t, []string{"!change:example.com", "!new:other.example.com", v12RoomID, "!remove:example.com"}, sent)
+	assert.Equal(t, []SpaceChild{
+		{RoomID: v12RoomID, Via: []string{"example.com"}},
+		{RoomID: "!change:example.com", Via: []string{"example.com"}, Order: "02"},
+		{RoomID: "!keep:example.com", Via: []string{"example.com"}, Suggested: true},
+		{RoomID: "!new:other.example.com", Via: []string{"other.example.com"}},
+	}, space.Children)
+}
+
+func TestForwardExtremities(t *testing.T) {
+	var deleted bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/_synapse/admin/v1/rooms/!room:example.com/forward_extremities", r.URL.Path)
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = w.Write([]byte(`{"count":2,"results":[{"event_id":"$a","state_group":1,"depth":10,"received_ts":1}]}`))
+		case http.MethodDelete:
+			deleted = true
+			_, _ = w.Write([]byte(`{"deleted":2}`))
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@admin:example.com",
+		AdminMode:     true,
+	})
+	require.NoError(t, err)
+
+	extremities, err := c.GetForwardExtremities(context.Background(), "!room:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, 2, extremities.Count)
+	require.Len(t, extremities.Results, 1)
+	assert.Equal(t, "$a", extremities.Results[0].EventID)
+
+	n, err := c.DeleteForwardExtremities(context.Background(), "!room:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.True(t, deleted)
+}
+
+func TestRoomAdmins(t *testing.T) {
+	cases := map[string]struct {
+		state string
+		want  []string
+	}{
+		"PowerLevels": {
+			state: `[
+				{"type":"m.room.create","state_key":"","sender":"@creator:example.com","content":{"room_version":"10"}},
+				{"type":"m.room.power_levels","state_key":"","sender":"@creator:example.com","content":{"users":{"@alice:example.com":100,"@bob:example.com":50},"events":{"m.room.power_levels":100}}}
+			]`,
+			want: []string{"@alice:example.com"},
+		},
+		"PrivilegedCreator": {
+			state: `[
+				{"type":"m.room.create","state_key":"","sender":"@bob:example.com","content":{"room_version":"12"}},
+				{"type":"m.room.power_levels","state_key":"","sender":"@bob:example.com","content":{"users":{"@alice:example.com":100}}}
+			]`,
+			want: []string{"@alice:example.com", "@bob:example.com"},
+		},
+		"NoPowerLevels": {
+			state: `[
+				{"type":"m.room.create","state_key":"","sender":"@alice:example.com","content":{"room_version":"10"}}
+			]`,
+			want: []string{"@alice:example.com"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/_synapse/admin/v1/rooms/!room:example.com/state", r.URL.Path)
+				_, _ = w.Write([]byte(`{"state":` + tc.state + `}`))
+			}))
+			defer server.Close()
+
+			c, err := NewClient(&Config{
+				HomeserverURL: server.URL,
+				AccessToken:   "test_token",
+				UserID:        "@admin:example.com",
+				AdminMode:     true,
+			})
+			require.NoError(t, err)
+
+			admins, err := c.RoomAdmins(context.Background(), "!room:example.com", []string{"@alice:example.com", "@bob:example.com"})
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, admins)
+		})
+	}
+}
+
+func TestMakeRoomAdmin(t *testing.T) {
+	var userID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/_synapse/admin/v1/rooms/!room:example.com/make_room_admin", r.URL.Path)
+		var body struct {
+			UserID string `json:"user_id"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		userID = body.UserID
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@admin:example.com",
+		AdminMode:     true,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, c.MakeRoomAdmin(context.Background(), "!room:example.com", "@alice:example.com"))
+	assert.Equal(t, "@alice:example.com", userID)
+
+	// Without admin mode there is no admin API to ask
+	c = newTestClient(t, nil)
+	assert.Error(t, c.MakeRoomAdmin(context.Background(), "!room:example.com", "@alice:example.com"))
+	_, err = c.RoomAdmins(context.Background(), "!room:example.com", []string{"@alice:example.com"})
+	assert.Error(t, err)
+}
+
+func TestBlockRoom(t *testing.T) {
+	blocked := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/_synapse/admin/v1/rooms/!room:example.com/block", r.URL.Path)
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"block": blocked})
+		case http.MethodPut:
+			var body struct {
+				Block bool `json:"block"`
+			}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			blocked = body.Block
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"block": blocked})
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@admin:example.com",
+		AdminMode:     true,
+	})
+	require.NoError(t, err)
+
+	got, err := c.GetRoomBlocked(context.Background(), "!room:example.com")
+	require.NoError(t, err)
+	assert.False(t, got)
+
+	require.NoError(t, c.BlockRoom(context.Background(), "!room:example.com", true))
+	got, err = c.GetRoomBlocked(context.Background(), "!room:example.com")
+	require.NoError(t, err)
+	assert.True(t, got)
+
+	// Without admin mode there is no admin API to ask
+	c = newTestClient(t, nil)
+	_, err = c.GetRoomBlocked(context.Background(), "!room:example.com")
+	assert.Error(t, err)
+	assert.Error(t, c.BlockRoom(context.Background(), "!room:example.com", true))
+}
+
+func TestGetRateLimit(t *testing.T) {
+	override := `{"messages_per_second":10,"burst_count":20}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/_synapse/admin/v1/users/@alice:example.com/override_ratelimit", r.URL.Path)
+		_, _ = w.Write([]byte(override))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@admin:example.com",
+		AdminMode:     true,
+	})
+	require.NoError(t, err)
+
+	rateLimit, err := c.GetRateLimit(context.Background(), "@alice:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, &RateLimit{MessagesPerSecond: 10, BurstCount: 20}, rateLimit)
+
+	override = `{}`
+	rateLimit, err = c.GetRateLimit(context.Background(), "@alice:example.com")
+	require.NoError(t, err)
+	assert.Nil(t, rateLimit)
+
+	_, err = newTestClient(t, nil).GetRateLimit(context.Background(), "@alice:example.com")
+	assert.Error(t, err)
+}
+
+func TestSetAndDeleteRateLimit(t *testing.T) {
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/_synapse/admin/v1/users/@alice:example.com/override_ratelimit", r.URL.Path)
+		methods = append(methods, r.Method)
+		if r.Method == http.MethodPost {
+			body, _ := io.ReadAll(r.Body)
+			assert.JSONEq(t, `{"messages_per_second":0,"burst_count":0}`, string(body))
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@admin:example.com",
+		AdminMode:     true,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, c.SetRateLimit(context.Background(), "@alice:example.com", &RateLimit{}))
+	require.NoError(t, c.DeleteRateLimit(context.Background(), "@alice:example.com"))
+	assert.Equal(t, []string{http.MethodPost, http.MethodDelete}, methods)
+
+	assert.Error(t, newTestClient(t, nil).SetRateLimit(context.Background(), "@alice:example.com", &RateLimit{}))
+	assert.Error(t, newTestClient(t, nil).DeleteRateLimit(context.Background(), "@alice:example.com"))
+}
+
+func TestShadowBanUser(t *testing.T) {
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/_synapse/admin/v2/users/@alice:example.com" {
+			_, _ = w.Write([]byte(`{"name":"@alice:example.com","shadow_banned":true}`))
+			return
+		}
+		assert.Equal(t, "/_synapse/admin/v1/users/@alice:example.com/shadow_ban", r.URL.Path)
+		methods = append(methods, r.Method)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@admin:example.com",
+		AdminMode:     true,
+	})
+	require.NoError(t, err)
+
+	user, err := c.GetUser(context.Background(), "@alice:example.com")
+	require.NoError(t, err)
+	assert.True(t, user.ShadowBanned)
+
+	require.NoError(t, c.ShadowBanUser(context.Background(), "@alice:example.com", true))
+	require.NoError(t, c.ShadowBanUser(context.Background(), "@alice:example.com", false))
+	assert.Equal(t, []string{http.MethodPost, http.MethodDelete}, methods)
+
+	assert.Error(t, newTestClient(t, nil).ShadowBanUser(context.Background(), "@alice:example.com", true))
+}
+
+func TestDeleteDevice(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantErr     bool
+		wantRefused *DeviceDeletionRefusedError
+	}{
+		{
+			name:   "deleted",
+			status: http.StatusOK,
+			body:   `{}`,
+		},
+		{
+			name:        "interactive authentication",
+			status:      http.StatusUnauthorized,
+			body:        `{"flows":[{"stages":["m.login.password"]}],"params":{},"session":"xyz"}`,
+			wantErr:     true,
+			wantRefused: &DeviceDeletionRefusedError{UserID: "@alice:example.com", DeviceID: "LAPTOP"},
+		},
+		{
+			name:        "forbidden",
+			status:      http.StatusForbidden,
+			body:        `{"errcode":"M_FORBIDDEN","error":"You are not a server admin"}`,
+			wantErr:     true,
+			wantRefused: &DeviceDeletionRefusedError{UserID: "@alice:example.com", DeviceID: "LAPTOP", ErrCode: "M_FORBIDDEN"},
+		},
+		{
+			name:    "other error",
+			status:  http.StatusUnauthorized,
+			body:    `{"errcode":"M_UNKNOWN_TOKEN","error":"Invalid access token"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodDelete, r.Method)
+				assert.Equal(t, "/_synapse/admin/v2/users/@alice:example.com/devices/LAPTOP", r.URL.Path)
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			c, err := NewClient(&Config{
+				HomeserverURL: server.URL,
+				AccessToken:   "test_token",
+				UserID:        "@admin:example.com",
+				AdminMode:     true,
+			})
+			require.NoError(t, err)
+
+			err = c.DeleteDevice(context.Background(), "@alice:example.com", "LAPTOP")
+			if !tt.wantErr {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			var refused *DeviceDeletionRefusedError
+			if tt.wantRefused == nil {
+				assert.NotErrorAs(t, err, &refused)
+				return
+			}
+			require.ErrorAs(t, err, &refused)
+			assert.Equal(t, tt.wantRefused, refused)
+		})
+	}
+}
+
+func TestGetConnections(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/_synapse/admin/v1/whois/@alice:example.com", r.URL.Path)
+		_, _ = w.Write([]byte(`{
+			"user_id": "@alice:example.com",
+			"devices": {
+				"LAPTOP": {"sessions": [{"connections": [
+					{"ip": "192.0.2.1", "last_seen": 1700000000000, "user_agent": "Firefox"}
+				]}]},
+				"PHONE": {"sessions": [{"connections": [
+					{"ip": "198.51.100.7", "last_seen": 1700000500000, "user_agent": "Element Android"},
+					{"ip": "203.0.113.9", "user_agent": "Element Android"}
+				]}]}
+			}
+		}`))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@admin:example.com",
+		AdminMode:     true,
+	})
+	require.NoError(t, err)
+
+	connections, err := c.GetConnections(context.Background(), "@alice:example.com")
+	require.NoError(t, err)
+
+	// The most recently seen come first, and those never seen last
+	phoneSeen, laptopSeen := time.UnixMilli(1700000500000), time.UnixMilli(1700000000000)
+	assert.Equal(t, []Connection{
+		{DeviceID: "PHONE", IP: "198.51.100.7", UserAgent: "Element Android", LastSeenTime: &phoneSeen},
+		{DeviceID: "LAPTOP", IP: "192.0.2.1", UserAgent: "Firefox", LastSeenTime: &laptopSeen},
+		{DeviceID: "PHONE", IP: "203.0.113.9", UserAgent: "Element Android"},
+	}, connections)
+
+	_, err = newTestClient(t, nil).GetConnections(context.Background(), "@alice:example.com")
+	assert.Error(t, err)
+}
+
+func TestCreateUserWithSharedSecret(t *testing.T) {
+	var registered map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/_synapse/admin/v1/register", r.URL.Path)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(`{"nonce":"thisisanonce"}`))
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&registered)
+		_, _ = w.Write([]byte(`{"user_id":"@alice:example.com","access_token":"token","device_id":"DEVICE"}`))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL:            server.URL,
+		AccessToken:              "test_token",
+		RegistrationSharedSecret: "secret",
+	})
+	require.NoError(t, err)
+
+	user, err := c.CreateUser(context.Background(), &UserSpec{
+		UserID:      "@alice:example.com",
+		Password:    "password",
+		DisplayName: "Alice",
+		Admin:       true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "@alice:example.com", user.UserID)
+
+	assert.Equal(t, "thisisanonce", registered["nonce"])
+	assert.Equal(t, "alice", registered["username"])
+	assert.Equal(t, "Alice", registered["displayname"])
+	assert.Equal(t, true, registered["admin"])
+	assert.Equal(t, "e0e626c961d627d1d964c85223690f8eb45c1861", registered["mac"])
+}
+
+func TestRegistrationMAC(t *testing.T) {
+	assert.Equal(t, "3f3a2618a5c984dc53fc0dc16c6bb561b61f1c7b",
+		registrationMAC("secret", "nonce", "alice", "password", false, ""))
+	assert.Equal(t, "ab6e95044456a0a4c51aff293f963ee8002c55bf",
+		registrationMAC("secret", "nonce", "bot", "password", false, "bot"))
+}
+
+func TestUpdateRemoteUser(t *testing.T) {
+	c := newTestClient(t, nil)
+
+	_, err := c.UpdateUser(context.Background(), "@alice:remote.example.org", &UserSpec{DisplayName: "Alice"})
+	var remoteErr *RemoteUserError
+	require.ErrorAs(t, err, &remoteErr)
+	assert.Equal(t, "example.com", remoteErr.ServerName)
+}
+
+func TestForwardExtremitiesRequireAdmin(t *testing.T) {
+	c := newTestClient(t, nil)
+
+	_, err := c.GetForwardExtremities(context.Background(), "!room:example.com")
+	assert.Error(t, err)
+
+	_, err = c.DeleteForwardExtremities(context.Background(), "!room:example.com")
+	assert.Error(t, err)
+}
+
+func TestGetRoomAliasServers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.URL.Path, "/directory/room/")
+		_, _ = w.Write([]byte(`{"room_id":"!room:example.com","servers":["example.com","matrix.org"]}`))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@bot:example.com",
+	})
+	require.NoError(t, err)
+
+	alias, err := c.GetRoomAlias(context.Background(), "#general:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "!room:example.com", alias.RoomID)
+	assert.Equal(t, []string{"example.com", "matrix.org"}, alias.Servers)
+}
+
+func TestCreateRoomAliasConflict(t *testing.T) {
+	var created bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/directory/room/"):
+			_, _ = w.Write([]byte(`{"room_id":"!other:example.com","servers":["example.com"]}`))
+		case strings.HasSuffix(r.URL.Path, "/createRoom"):
+			created = true
+			_, _ = w.Write([]byte(`{"room_id":"!new:example.com"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@bot:example.com",
+	})
+	require.NoError(t, err)
+
+	_, err = c.CreateRoom(context.Background(), &RoomSpec{Alias: "general"})
+	require.Error(t, err)
+
+	var conflict *AliasConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, "#general:example.com", conflict.Alias)
+	assert.Equal(t, "!other:example.com", conflict.RoomID)
+	assert.False(t, created)
+}
+
+// v12RoomID is a room ID of room version 12, which has no server name
+const v12RoomID = "!31hneApxJ_1o-63DmFrpeqnkFfWppnzWso1JvH3ogLM"
+
+func TestCreateRoomPowerLevelOverridesCreators(t *testing.T) {
+	tests := []struct {
+		name        string
+		roomVersion string
+		roomID      string
+		wantUsers   map[string]interface{}
+	}{
+		{
+			name:        "room version 10 lists the creator",
+			roomVersion: "10",
+			roomID:      "!new:example.com",
+			wantUsers: map[string]interface{}{
+				"@bot:example.com":   float64(100),
+				"@carol:example.com": float64(100),
+				"@alice:example.com": float64(50),
+			},
+		},
+		{
+			name:        "room version 12 creators are implicit",
+			roomVersion: "12",
+			roomID:      v12RoomID,
+			wantUsers: map[string]interface{}{
+				"@alice:example.com": float64(50),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]interface{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasSuffix(r.URL.Path, "/createRoom") {
+					_ = json.NewDecoder(r.Body).Decode(&body)
+					_ = json.NewEncoder(w).Encode(map[string]string{"room_id": tt.roomID})
+					return
+				}
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"errcode":"M_NOT_FOUND","error":"Not found."}`))
+			}))
+			defer server.Close()
+
+			c, err := NewClient(&Config{
+				HomeserverURL: server.URL,
+				AccessToken:   "test_token",
+				UserID:        "@bot:example.com",
+			})
+			require.NoError(t, err)
+
+			room, err := c.CreateRoom(context.Background(), &RoomSpec{
+				RoomVersion: tt.roomVersion,
+				CreationContent: map[string]interface{}{
+					"additional_creators": []interface{}{"@carol:example.com"},
+				},
+				PowerLevelOverrides: &PowerLevelContent{Users: map[string]int{
+					"@bot:example.com":   100,
+					"@carol:example.com": 100,
+					"@alice:example.com": 50,
+				}},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.roomID, room.RoomID)
+
+			overrides, ok := body["power_level_content_override"].(map[string]interface{})
+			require.True(t, ok)
+			assert.Equal(t, tt.wantUsers, overrides["users"])
+		})
+	}
+}
+
+func TestCreateSpaceCreationContent(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/createRoom") {
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			_, _ = w.Write([]byte(`{"room_id":"!new:example.com"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"errcode":"M_NOT_FOUND","error":"Not found."}`))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@bot:example.com",
+	})
+	require.NoError(t, err)
+
+	userContent := map[string]interface{}{
+		"type":        "org.example.custom",
+		"m.federate":  false,
+		"org.example": "kept",
+	}
+	_, err = c.CreateSpace(context.Background(), &SpaceSpec{RoomSpec: RoomSpec{CreationContent: userContent}})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"type":        "m.space",
+		"m.federate":  false,
+		"org.example": "kept",
+	}, body["creation_content"])
+	assert.Equal(t, "org.example.custom", userContent["type"], "the caller's content must not be modified")
+}
+
+func TestUpdateRoomAltAliases(t *testing.T) {
+	state := map[string]interface{}{
+		"m.room.canonical_alias": map[string]interface{}{
+			"alias":       "#general:example.com",
+			"alt_aliases": []string{"#old:example.com"},
+		},
+	}
+	c := newTestClient(t, state)
+
+	room, err := c.GetRoom(context.Background(), "!room:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "#general:example.com", room.Alias)
+	assert.Equal(t, []string{"#old:example.com"}, room.AltAliases)
+
+	room, err = c.UpdateRoom(context.Background(), "!room:example.com", &RoomSpec{
+		AltAliases: []string{"#chat:example.com", "lobby"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "#general:example.com", room.Alias)
+	assert.Equal(t, []string{"#chat:example.com", "#lobby:example.com"}, room.AltAliases)
+}
+
+func TestCreateRoomCanonicalAlias(t *testing.T) {
+	state := map[string]interface{}{}
+	c := newTestClient(t, state)
+
+	_, err := c.CreateRoom(context.Background(), &RoomSpec{Alias: "#general:example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"alias": "#general:example.com"}, state["m.room.canonical_alias"])
+}
+
+func TestUpdateRoomAliasNotCanonical(t *testing.T) {
+	state := map[string]interface{}{
+		"m.room.canonical_alias": map[string]interface{}{
+			"alias":       "#general:example.com",
+			"alt_aliases": []string{"#old:example.com"},
+		},
+	}
+	c := newTestClient(t, state)
+	notCanonical := false
+
+	// An alias that is to stay out of the canonical alias is removed from it
+	room, err := c.UpdateRoom(context.Background(), "!room:example.com", &RoomSpec{
+		Alias:               "#general:example.com",
+		SetAliasAsCanonical: &notCanonical,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, room.Alias)
+	assert.Equal(t, []string{"#old:example.com"}, room.AltAliases)
+
+	// Another canonical alias is left alone
+	state["m.room.canonical_alias"] = map[string]interface{}{"alias": "#lobby:example.com"}
+	room, err = c.UpdateRoom(context.Background(), "!room:example.com", &RoomSpec{
+		Alias:               "#general:example.com",
+		SetAliasAsCanonical: &notCanonical,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "#lobby:example.com", room.Alias)
+}
+
+func TestUpdateRoomRichTopic(t *testing.T) {
+	state := map[string]interface{}{
+		"m.room.topic": map[string]interface{}{"topic": "Old topic"},
+	}
+	c := newTestClient(t, state)
+
+	room, err := c.UpdateRoom(context.Background(), "!room:example.com", &RoomSpec{
+		Topic:     "Chat about *things*",
+		RichTopic: true,
+		TopicHTML: "Chat about <em>things</em>",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Chat about *things*", room.Topic)
+	assert.Equal(t, "Chat about <em>things</em>", room.TopicHTML)
+	assert.True(t, room.RichTopic)
+
+	// A client unaware of m.topic changes only the legacy topic
+	state["m.room.topic"].(map[string]interface{})["topic"] = "Changed elsewhere"
+	room, err = c.GetRoom(context.Background(), "!room:example.com")
+	require.NoError(t, err)
+	assert.False(t, room.RichTopic)
+
+	room, err = c.UpdateRoom(context.Background(), "!room:example.com", &RoomSpec{Topic: "Plain"})
+	require.NoError(t, err)
+	assert.Equal(t, "Plain", room.Topic)
+	assert.False(t, room.RichTopic)
+	assert.Empty(t, room.TopicHTML)
+}
+
+func TestAliasLocalpart(t *testing.T) {
+	assert.Equal(t, "general", aliasLocalpart("#general:example.com"))
+	assert.Equal(t, "general", aliasLocalpart("general"))
+	assert.Equal(t, "", aliasLocalpart(""))
+}
+
+func TestDeleteRoomPurge(t *testing.T) {
+	for _, purge := range []bool{false, true} {
+		var body map[string]interface{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Method == http.MethodGet && r.URL.Path == "/_synapse/admin/v2/rooms/!room:example.com/delete_status":
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"errcode":"M_NOT_FOUND","error":"delete id not found"}`))
+			case r.Method == http.MethodDelete && r.URL.Path == "/_synapse/admin/v2/rooms/!room:example.com":
+				_ = json.NewDecoder(r.Body).Decode(&body)
+				_, _ = w.Write([]byte(`{"delete_id":"abcdef"}`))
+			default:
+				t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+			}
+		}))
+
+		c, err := NewClient(&Config{
+			HomeserverURL: server.URL,
+			AccessToken:   "test_token",
+			UserID:        "@admin:example.com",
+			AdminMode:     true,
+		})
+		require.NoError(t, err)
+
+		err = c.DeleteRoom(context.Background(), "!room:example.com", DeleteRoomOptions{Purge: purge})
+		require.NoError(t, err)
+		assert.Equal(t, purge, body["purge"])
+		server.Close()
+	}
+}
+
+func TestDeleteRoomInBackground(t *testing.T) {
+	var deletes int
+	status := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/_synapse/admin/v2/rooms/!room:example.com/delete_status":
+			if status == "" {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"errcode":"M_NOT_FOUND","error":"delete id not found"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"results":[{"delete_id":"old","status":"failed","error":"boom"},{"delete_id":"abcdef","status":"` + status + `"}]}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/_synapse/admin/v2/rooms/!room:example.com":
+			deletes++
+			status = RoomDeletionShuttingDown
+			_, _ = w.Write([]byte(`{"delete_id":"abcdef"}`))
+		default:
+			t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@admin:example.com",
+		AdminMode:     true,
+	})
+	require.NoError(t, err)
+
+	deletion, err := c.GetRoomDeletion(context.Background(), "!room:example.com")
+	require.NoError(t, err)
+	assert.Nil(t, deletion)
+
+	require.NoError(t, c.DeleteRoom(context.Background(), "!room:example.com", DeleteRoomOptions{}))
+	assert.Equal(t, 1, deletes)
+
+	// The latest deletion is reported
+	deletion, err = c.GetRoomDeletion(context.Background(), "!room:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, &RoomDeletion{DeleteID: "abcdef", Status: RoomDeletionShuttingDown}, deletion)
+
+	// A deletion in progress isn't started again
+	require.NoError(t, c.DeleteRoom(context.Background(), "!room:example.com", DeleteRoomOptions{}))
+	assert.Equal(t, 1, deletes)
+
+	// A failed deletion is retried
+	status = RoomDeletionFailed
+	require.NoError(t, c.DeleteRoom(context.Background(), "!room:example.com", DeleteRoomOptions{}))
+	assert.Equal(t, 2, deletes)
+
+	status = RoomDeletionComplete
+	deletion, err = c.GetRoomDeletion(context.Background(), "!room:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, RoomDeletionComplete, deletion.Status)
+}
+
+func TestSetPowerLevelsSendsOneEvent(t *testing.T) {
+	var writes int
+	var sent map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut:
+			writes++
+			assert.True(t, strings.HasSuffix(r.URL.Path, "/state/m.room.power_levels/"))
+			_ = json.NewDecoder(r.Body).Decode(&sent)
+			_, _ = w.Write([]byte(`{"event_id":"$event"}`))
+		case strings.HasSuffix(r.URL.Path, "/state/m.room.power_levels/"):
+			_, _ = w.Write([]byte(`{"users":{"@alice:example.com":100},"ban":50}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errcode":"M_NOT_FOUND","error":"Event not found."}`))
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@bot:example.com",
+	})
+	require.NoError(t, err)
+
+	err = c.SetPowerLevels(context.Background(), "!room:example.com", &PowerLevelSpec{
+		RoomID: "!room:example.com",
+		PowerLevels: &PowerLevelContent{
+			Users: map[string]int{
+				"@alice:example.com": 100,
+				"@bob:example.com":   50,
+				"@carol:example.com": 50,
+			},
+			Events: map[string]int{"m.room.name": 50},
+			Ban:    intPtr(75),
+			Kick:   intPtr(60),
+			Invite: intPtr(25),
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, writes)
+	assert.Equal(t, map[string]interface{}{
+		"@alice:example.com": float64(100),
+		"@bob:example.com":   float64(50),
+		"@carol:example.com": float64(50),
+	}, sent["users"])
+	assert.Equal(t, float64(75), sent["ban"])
+	assert.Equal(t, float64(60), sent["kick"])
+	assert.Equal(t, float64(25), sent["invite"])
+}
+
+func TestSetPowerLevelsKeepsUnsetFields(t *testing.T) {
+	zero := 0
+	tests := []struct {
+		name              string
+		eventsDefault     *int
+		wantEventsDefault int
+	}{
+		{
+			name:              "unset events default keeps the room's value",
+			wantEventsDefault: 50,
+		},
+		{
+			name:              "explicit zero clears it",
+			eventsDefault:     &zero,
+			wantEventsDefault: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := map[string]interface{}{
+				"m.room.power_levels": map[string]interface{}{
+					"events_default": 50,
+					"ban":            60,
+					"users":          map[string]int{"@alice:example.com": 100},
+				},
+			}
+			c := newTestClient(t, state)
+
+			err := c.SetPowerLevels(context.Background(), "!room:example.com", &PowerLevelSpec{
+				RoomID:      "!room:example.com",
+				PowerLevels: &PowerLevelContent{EventsDefault: tt.eventsDefault},
+			})
+			require.NoError(t, err)
+
+			levels, err := c.GetPowerLevels(context.Background(), "!room:example.com")
+			require.NoError(t, err)
+			require.NotNil(t, levels.EventsDefault)
+			assert.Equal(t, tt.wantEventsDefault, *levels.EventsDefault)
+			require.NotNil(t, levels.Ban)
+			assert.Equal(t, 60, *levels.Ban)
+			assert.Equal(t, map[string]int{"@alice:example.com": 100}, levels.Users)
+		})
+	}
+}
+
+func TestSetPowerLevelsUsersOnly(t *testing.T) {
+	state := map[string]interface{}{
+		"m.room.power_levels": map[string]interface{}{
+			"users":          map[string]int{"@alice:example.com": 100},
+			"events":         map[string]int{"m.room.name": 50},
+			"events_default": 10,
+			"state_default":  60,
+			"ban":            70,
+			"kick":           60,
+			"redact":         40,
+			"invite":         20,
+		},
+	}
+	c := newTestClient(t, state)
+
+	err := c.SetPowerLevels(context.Background(), "!room:example.com", &PowerLevelSpec{
+		RoomID: "!room:example.com",
+		PowerLevels: &PowerLevelContent{
+			Users: map[string]int{"@alice:example.com": 100, "@bob:example.com": 50},
+		},
+	})
+	require.NoError(t, err)
+
+	levels, err := c.GetPowerLevels(context.Background(), "!room:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"@alice:example.com": 100, "@bob:example.com": 50}, levels.Users)
+	assert.Equal(t, map[string]int{"m.room.name": 50}, levels.Events)
+	assert.Equal(t, intPtr(10), levels.EventsDefault)
+	assert.Equal(t, intPtr(60), levels.StateDefault)
+	assert.Equal(t, intPtr(70), levels.Ban)
+	assert.Equal(t, intPtr(60), levels.Kick)
+	assert.Equal(t, intPtr(40), levels.Redact)
+	assert.Equal(t, intPtr(20), levels.Invite)
+}
+
+func TestSetPowerLevelsConcurrentModification(t *testing.T) {
+	state := map[string]interface{}{
+		"m.room.power_levels": map[string]interface{}{
+			"users": map[string]int{"@alice:example.com": 100},
+		},
+	}
+	c := newTestClient(t, state)
+	ctx := context.Background()
+
+	observed, err := c.GetPowerLevels(ctx, "!room:example.com")
+	require.NoError(t, err)
+
+	// Someone else promotes bob before we send our change
+	state["m.room.power_levels"] = map[string]interface{}{
+		"users": map[string]int{"@alice:example.com": 100, "@bob:example.com": 50},
+	}
+
+	spec := &PowerLevelSpec{
+		RoomID:      "!room:example.com",
+		PowerLevels: &PowerLevelContent{Ban: intPtr(75)},
+		Previous:    observed,
+	}
+	err = c.SetPowerLevels(ctx, "!room:example.com", spec)
+	var conflict *ConcurrentModificationError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, "m.room.power_levels", conflict.EventType)
+
+	// Observing again picks up the change, which is then kept
+	spec.Previous, err = c.GetPowerLevels(ctx, "!room:example.com")
+	require.NoError(t, err)
+	require.NoError(t, c.SetPowerLevels(ctx, "!room:example.com", spec))
+
+	levels, err := c.GetPowerLevels(ctx, "!room:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, 50, levels.Users["@bob:example.com"])
+	assert.Equal(t, 75, *levels.Ban)
+}
+
+func TestSetPowerLevelsSelfLockout(t *testing.T) {
+	tests := []struct {
+		name      string
+		spec      *PowerLevelContent
+		wantField string
+		wantLevel int
+	}{
+		{
+			name:      "users without the provider",
+			spec:      &PowerLevelContent{Users: map[string]int{"@alice:example.com": 100}},
+			wantField: "events[m.room.power_levels]",
+		},
+		{
+			name:      "state default above the provider",
+			spec:      &PowerLevelContent{StateDefault: intPtr(100), Events: map[string]int{"m.room.power_levels": 50}},
+			wantField: "stateDefault",
+			wantLevel: 50,
+		},
+		{
+			name: "provider keeps its level",
+			spec: &PowerLevelContent{Users: map[string]int{"@alice:example.com": 100, "@bot:example.com": 50}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := map[string]interface{}{
+				"m.room.power_levels": map[string]interface{}{
+					"users": map[string]int{"@alice:example.com": 100, "@bot:example.com": 50},
+				},
+			}
+			c := newTestClient(t, state)
+			ctx := context.Background()
+
+			err := c.SetPowerLevels(ctx, "!room:example.com", &PowerLevelSpec{RoomID: "!room:example.com", PowerLevels: tt.spec})
+			levels, getErr := c.GetPowerLevels(ctx, "!room:example.com")
+			require.NoError(t, getErr)
+			if tt.wantField == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			var lockout *PowerLevelLockoutError
+			require.ErrorAs(t, err, &lockout)
+			assert.Equal(t, "@bot:example.com", lockout.UserID)
+			assert.Equal(t, tt.wantLevel, lockout.Level)
+			assert.Equal(t, tt.wantField, lockout.Field)
+			assert.Equal(t, 50, levels.Users["@bot:example.com"], "power levels were sent")
+		})
+	}
+}
+
+func TestResetPowerLevels(t *testing.T) {
+	state := map[string]interface{}{
+		"m.room.create": map[string]interface{}{
+			"creator":      "@alice:example.com",
+			"room_version": "10",
+		},
+		"m.room.power_levels": map[string]interface{}{
+			"users": map[string]int{
+				"@alice:example.com": 100,
+				"@bob:example.com":   75,
+				"@bot:example.com":   90,
+			},
+			"users_default": 10,
+			"events":        map[string]int{"m.room.name": 90, "m.room.power_levels": 100},
+			"state_default": 90,
+			"ban":           90,
+			"invite":        90,
+		},
+	}
+	c := newTestClient(t, state)
+	ctx := context.Background()
+
+	require.NoError(t, c.ResetPowerLevels(ctx, "!room:example.com", false))
+
+	levels, err := c.GetPowerLevels(ctx, "!room:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"@alice:example.com": 100, "@bot:example.com": 90}, levels.Users)
+	assert.Equal(t, map[string]int{"m.room.name": 90, "m.room.power_levels": 100}, levels.Events)
+	assert.Equal(t, intPtr(0), levels.UsersDefault)
+	assert.Equal(t, intPtr(0), levels.EventsDefault)
+	assert.Equal(t, intPtr(50), levels.StateDefault)
+	assert.Equal(t, intPtr(50), levels.Ban)
+	assert.Equal(t, intPtr(50), levels.Kick)
+	assert.Equal(t, intPtr(50), levels.Redact)
+	assert.Equal(t, intPtr(50), levels.Invite)
+}
+
+func TestResetPowerLevelsAlreadyReset(t *testing.T) {
+	state := map[string]interface{}{
+		"m.room.create": map[string]interface{}{
+			"creator":      "@alice:example.com",
+			"room_version": "10",
+		},
+		"m.room.power_levels": map[string]interface{}{
+			"users":  map[string]int{"@alice:example.com": 100, "@bob:example.com": 75},
+			"invite": 90,
+		},
+	}
+	c := newTestClient(t, state)
+	ctx := context.Background()
+
+	require.NoError(t, c.ResetPowerLevels(ctx, "!room:example.com", false))
+
+	// Resetting again sends no event, which would replace the marked content
+	reset := state["m.room.power_levels"].(map[string]interface{})
+	reset["marker"] = true
+	require.NoError(t, c.ResetPowerLevels(ctx, "!room:example.com", false))
+	assert.Equal(t, true, state["m.room.power_levels"].(map[string]interface{})["marker"])
+	require.NoError(t, c.ResetPowerLevels(ctx, "!room:example.com", true))
+	assert.Equal(t, true, state["m.room.power_levels"].(map[string]interface{})["marker"])
+}
+
+func TestResetPowerLevelsUsersOnly(t *testing.T) {
+	state := map[string]interface{}{
+		"m.room.create": map[string]interface{}{
+			"creator":      "@alice:example.com",
+			"room_version": "10",
+		},
+		"m.room.power_levels": map[string]interface{}{
+			"users": map[string]int{
+				"@alice:example.com": 100,
+				"@bob:example.com":   75,
+				"@bot:example.com":   90,
+			},
+			"users_default": 10,
+			"events":        map[string]int{"m.room.name": 90},
+			"state_default": 90,
+			"invite":        90,
+		},
+	}
+	c := newTestClient(t, state)
+	ctx := context.Background()
+
+	require.NoError(t, c.ResetPowerLevels(ctx, "!room:example.com", true))
+
+	levels, err := c.GetPowerLevels(ctx, "!room:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"@alice:example.com": 100, "@bot:example.com": 90}, levels.Users)
+	assert.Equal(t, map[string]int{"m.room.name": 90}, levels.Events)
+	assert.Equal(t, intPtr(10), levels.UsersDefault)
+	assert.Equal(t, intPtr(90), levels.StateDefault)
+	assert.Equal(t, intPtr(90), levels.Invite)
+}
+
+func TestResetPowerLevelsLockout(t *testing.T) {
+	// The provider only has power through users_default, which the reset
+	// lowers
+	state := map[string]interface{}{
+		"m.room.create": map[string]interface{}{
+			"creator":      "@alice:example.com",
+			"room_version": "10",
+		},
+		"m.room.power_levels": map[string]interface{}{
+			"users":         map[string]int{"@alice:example.com": 100},
+			"users_default": 50,
+		},
+	}
+	c := newTestClient(t, state)
+
+	var lockout *PowerLevelLockoutError
+	require.ErrorAs(t, c.ResetPowerLevels(context.Background(), "!room:example.com", false), &lockout)
+	assert.Equal(t, 50, state["m.room.power_levels"].(map[string]interface{})["users_default"])
+}
+
+func TestSyncProfile(t *testing.T) {
+	profile := map[string]string{"displayname": "bot"}
+	var updates []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		field := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		if r.Method == http.MethodPut {
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			profile[field] = body[field]
+			updates = append(updates, field)
+			_, _ = w.Write([]byte(`{}`))
+			return
+		}
+		if value, ok := profile[field]; ok {
+			_ = json.NewEncoder(w).Encode(map[string]string{field: value})
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"errcode":"M_NOT_FOUND","error":"Profile field not found."}`))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL:  server.URL,
+		AccessToken:    "test_token",
+		UserID:         "@bot:example.com",
+		BotDisplayName: "Matrix Provider",
+		BotAvatarURL:   "mxc://example.com/avatar",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, c.SyncProfile(context.Background()))
+	assert.Equal(t, []string{"displayname", "avatar_url"}, updates)
+	assert.Equal(t, "Matrix Provider", profile["displayname"])
+	assert.Equal(t, "mxc://example.com/avatar", profile["avatar_url"])
+
+	// The same profile is only applied once per process
+	require.NoError(t, c.SyncProfile(context.Background()))
+	assert.Len(t, updates, 2)
+}
+
+func TestSyncProfileRequiresUserID(t *testing.T) {
+	c, err := NewClient(&Config{
+		HomeserverURL:  "https://matrix.example.com",
+		AccessToken:    "test_token",
+		BotDisplayName: "Matrix Provider",
+	})
+	require.NoError(t, err)
+	assert.Error(t, c.SyncProfile(context.Background()))
+
+	c, err = NewClient(&Config{
+		HomeserverURL: "https://matrix.example.com",
+		AccessToken:   "test_token",
+	})
+	require.NoError(t, err)
+	assert.NoError(t, c.SyncProfile(context.Background()))
+}
+
+func TestSetPowerLevelsSkipsPrivilegedCreators(t *testing.T) {
+	tests := []struct {
+		name        string
+		roomVersion string
+		roomID      string
+		wantCarol   bool
+	}{
+		{
+			name:        "creators have no implicit power before room version 12",
+			roomVersion: "11",
+			roomID:      "!room:example.com",
+			wantCarol:   true,
+		},
+		{
+			name:        "creators cannot be given a power level from room version 12",
+			roomVersion: "12",
+			roomID:      v12RoomID,
+			wantCarol:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := map[string]interface{}{
+				"m.room.create": map[string]interface{}{
+					"room_version":        tt.roomVersion,
+					"additional_creators": []string{"@carol:example.com"},
+				},
+			}
+			c := newTestClient(t, state)
+			ctx := context.Background()
+
+			err := c.SetPowerLevels(ctx, tt.roomID, &PowerLevelSpec{
+				RoomID: tt.roomID,
+				PowerLevels: &PowerLevelContent{Users: map[string]int{
+					"@alice:example.com": 50,
+					"@carol:example.com": 100,
+				}},
+			})
+			require.NoError(t, err)
+
+			levels, err := c.GetPowerLevels(ctx, tt.roomID)
+			require.NoError(t, err)
+			assert.Equal(t, 50, levels.Users["@alice:example.com"])
+			_, hasCarol := levels.Users["@carol:example.com"]
+			assert.Equal(t, tt.wantCarol, hasCarol)
+
+			room, err := c.GetRoom(ctx, tt.roomID)
+			require.NoError(t, err)
+			if tt.wantCarol {
+				assert.Empty(t, room.PrivilegedCreators)
+			} else {
+				assert.Equal(t, []string{"@carol:example.com"}, room.PrivilegedCreators)
+			}
+		})
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestPurgeHistory(t *testing.T) {
+	var purgeBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/_synapse/admin/v1/purge_history/!room:example.com":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&purgeBody))
+			_, _ = w.Write([]byte(`{"purge_id":"abcdef"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/_synapse/admin/v1/purge_history_status/abcdef":
+			_, _ = w.Write([]byte(`{"status":"failed","error":"some events are still referenced"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/_synapse/admin/v1/purge_history_status/forgotten":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errcode":"M_NOT_FOUND","error":"Purge not found"}`))
+		default:
+			t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@admin:example.com",
+		AdminMode:     true,
+	})
+	require.NoError(t, err)
+
+	purgeID, err := c.PurgeHistory(context.Background(), "!room:example.com", time.UnixMilli(1700000000000), true)
+	require.NoError(t, err)
+	assert.Equal(t, "abcdef", purgeID)
+	assert.Equal(t, map[string]interface{}{"purge_up_to_ts": float64(1700000000000), "delete_local_events": true}, purgeBody)
+
+	purge, err := c.GetHistoryPurge(context.Background(), "abcdef")
+	require.NoError(t, err)
+	assert.Equal(t, &HistoryPurge{Status: HistoryPurgeFailed, Error: "some events are still referenced"}, purge)
+
+	_, err = c.GetHistoryPurge(context.Background(), "forgotten")
+	assert.True(t, IsNotFound(err))
+
+	_, err = c.PurgeHistory(context.Background(), "not-a-room", time.Now(), false)
+	assert.Error(t, err)
+}
+
+func TestParseMXCURI(t *testing.T) {
+	tests := []struct {
+		name           string
+		uri            string
+		wantServerName string
+		wantMediaID    string
+		wantErr        bool
+	}{
+		{name: "valid", uri: "mxc://example.com/AbC_12-3", wantServerName: "example.com", wantMediaID: "AbC_12-3"},
+		{name: "port", uri: "mxc://example.com:8448/abc", wantServerName: "example.com:8448", wantMediaID: "abc"},
+		{name: "other scheme", uri: "https://example.com/abc", wantErr: true},
+		{name: "no media ID", uri: "mxc://example.com/", wantErr: true},
+		{name: "no server name", uri: "mxc:///abc", wantErr: true},
+		{name: "no path", uri: "mxc://example.com", wantErr: true},
+		{name: "nested path", uri: "mxc://example.com/abc/def", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverName, mediaID, err := ParseMXCURI(tt.uri)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantServerName, serverName)
+			assert.Equal(t, tt.wantMediaID, mediaID)
+		})
+	}
+}
+
+func TestMedia(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/_synapse/admin/v1/media/example.com/abc":
+			_, _ = w.Write([]byte(`{"media_info":{"media_id":"abc","media_type":"image/png","media_length":1024,"upload_name":"cat.png","user_id":"@alice:example.com","created_ts":1700000000000,"quarantined_by":"@admin:example.com","safe_from_quarantine":false}}`))
+		case r.Method == http.MethodGet:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errcode":"M_NOT_FOUND","error":"Unknown media"}`))
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@admin:example.com",
+		AdminMode:     true,
+	})
+	require.NoError(t, err)
+
+	media, err := c.GetMedia(context.Background(), "example.com", "abc")
+	require.NoError(t, err)
+	created := time.UnixMilli(1700000000000)
+	assert.Equal(t, &Media{
+		MediaID:       "abc",
+		MediaType:     "image/png",
+		MediaLength:   1024,
+		UploadName:    "cat.png",
+		UserID:        "@alice:example.com",
+		CreationTime:  &created,
+		QuarantinedBy: "@admin:example.com",
+	}, media)
+
+	_, err = c.GetMedia(context.Background(), "example.com", "missing")
+	assert.True(t, IsNotFound(err))
+
+	require.NoError(t, c.QuarantineMedia(context.Background(), "other.org", "xyz", true))
+	require.NoError(t, c.QuarantineMedia(context.Background(), "example.com", "abc", false))
+	require.NoError(t, c.DeleteMedia(context.Background(), "example.com", "abc"))
+
+	// Media cached from other homeservers can't be deleted
+	assert.Error(t, c.DeleteMedia(context.Background(), "other.org", "xyz"))
+
+	assert.Equal(t, []string{
+		"GET /_synapse/admin/v1/media/example.com/abc",
+		"GET /_synapse/admin/v1/media/example.com/missing",
+		"POST /_synapse/admin/v1/media/quarantine/other.org/xyz",
+		"POST /_synapse/admin/v1/media/unquarantine/example.com/abc",
+		"DELETE /_synapse/admin/v1/media/example.com/abc",
+	}, requests)
+}
+
+func TestServerNotice(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+r.Header.Get("Authorization"))
+		switch {
+		case r.URL.Path == "/_synapse/admin/v1/send_server_notice/uid":
+			var notice map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&notice))
+			assert.Equal(t, map[string]interface{}{
+				"user_id": "@alice:example.com",
+				"content": map[string]interface{}{"msgtype": "m.text", "body": "Hello"},
+			}, notice)
+			_, _ = w.Write([]byte(`{"event_id":"$notice"}`))
+		case r.URL.Path == "/_synapse/admin/v1/fetch_event/$notice":
+			_, _ = w.Write([]byte(`{"event":{"event_id":"$notice","room_id":"!notices:example.com","sender":"@notices:example.com","type":"m.room.message","content":{},"unsigned":{}}}`))
+		case r.URL.Path == "/_synapse/admin/v1/fetch_event/$redacted":
+			_, _ = w.Write([]byte(`{"event":{"event_id":"$redacted","room_id":"!notices:example.com","sender":"@notices:example.com","unsigned":{"redacted_because":{"event_id":"$redaction"}}}}`))
+		case r.URL.Path == "/_synapse/admin/v1/users/@notices:example.com/login":
+			_, _ = w.Write([]byte(`{"access_token":"notices_token"}`))
+		case strings.HasPrefix(r.URL.Path, "/_matrix/client/v3/rooms/!notices:example.com/redact/$notice/"):
+			_, _ = w.Write([]byte(`{"event_id":"$redaction"}`))
+		default:
+			t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@admin:example.com",
+		AdminMode:     true,
+	})
+	require.NoError(t, err)
+
+	eventID, err := c.SendServerNotice(context.Background(), "uid", "@alice:example.com", "m.text", "Hello")
+	require.NoError(t, err)
+	assert.Equal(t, "$notice", eventID)
+
+	// The notice is redacted on behalf of its sender
+	require.NoError(t, c.RedactServerNotice(context.Background(), "$notice", ""))
+	require.NoError(t, c.RedactServerNotice(context.Background(), "$redacted", ""))
+
+	require.Len(t, requests, 5)
+	assert.Equal(t, "PUT /_synapse/admin/v1/send_server_notice/uid Bearer test_token", requests[0])
+	assert.Equal(t, "POST /_synapse/admin/v1/users/@notices:example.com/login Bearer test_token", requests[2])
+	assert.True(t, strings.HasSuffix(requests[3], " Bearer notices_token"), requests[3])
+	assert.Equal(t, "GET /_synapse/admin/v1/fetch_event/$redacted Bearer test_token", requests[4])
+}
+
+func TestRegistrationTokens(t *testing.T) {
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost || r.Method == http.MethodPut {
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			bodies = append(bodies, body)
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/_synapse/admin/v1/registration_tokens":
+			_, _ = w.Write([]byte(`{"registration_tokens":[{"token":"abcd","uses_allowed":3,"pending":0,"completed":1,"expiry_time":null},{"token":"pqrs","uses_allowed":null,"pending":1,"completed":0,"expiry_time":1700000000000}]}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/_synapse/admin/v1/registration_tokens/abcd":
+			_, _ = w.Write([]byte(`{"token":"abcd","uses_allowed":3,"pending":0,"completed":1,"expiry_time":null}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/_synapse/admin/v1/registration_tokens/new":
+			_, _ = w.Write([]byte(`{"token":"generated","uses_allowed":null,"pending":0,"completed":0,"expiry_time":1700000000000}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/_synapse/admin/v1/registration_tokens/abcd":
+			_, _ = w.Write([]byte(`{"token":"abcd","uses_allowed":10,"pending":0,"completed":1,"expiry_time":null}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/_synapse/admin/v1/registration_tokens/abcd":
+			_, _ = w.Write([]byte(`{}`))
+		case r.Method == http.MethodGet:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errcode":"M_NOT_FOUND","error":"No such registration token"}`))
+		default:
+			t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@admin:example.com",
+		AdminMode:     true,
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+	expiry := time.UnixMilli(1700000000000)
+
+	tokens, err := c.ListRegistrationTokens(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []RegistrationToken{
+		{Token: "abcd", UsesAllowed: intPtr(3), Completed: 1},
+		{Token: "pqrs", Pending: 1, ExpiryTime: &expiry},
+	}, tokens)
+
+	token, err := c.GetRegistrationToken(ctx, "abcd")
+	require.NoError(t, err)
+	assert.Equal(t, "abcd", token.Token)
+
+	_, err = c.GetRegistrationToken(ctx, "missing")
+	assert.True(t, IsNotFound(err))
+
+	token, err = c.CreateRegistrationToken(ctx, &RegistrationTokenSpec{ExpiryTime: &expiry})
+	require.NoError(t, err)
+	assert.Equal(t, "generated", token.Token)
+
+	token, err = c.UpdateRegistrationToken(ctx, "abcd", &RegistrationTokenSpec{UsesAllowed: intPtr(10)})
+	require.NoError(t, err)
+	assert.Equal(t, intPtr(10), token.UsesAllowed)
+
+	require.NoError(t, c.DeleteRegistrationToken(ctx, "abcd"))
+
+	// Only the fields that are set are sent
+	assert.Equal(t, []map[string]interface{}{
+		{"expiry_time": float64(1700000000000)},
+		{"uses_allowed": float64(10)},
+	}, bodies)
+}
+
+func TestRegistrationTokenValid(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	tests := []struct {
+		name  string
+		token RegistrationToken
+		want  bool
+	}{
+		{name: "unlimited", token: RegistrationToken{Completed: 100}, want: true},
+		{name: "uses left", token: RegistrationToken{UsesAllowed: intPtr(3), Pending: 1, Completed: 1}, want: true},
+		{name: "used up", token: RegistrationToken{UsesAllowed: intPtr(3), Pending: 1, Completed: 2}},
+		{name: "not expired", token: RegistrationToken{ExpiryTime: &future}, want: true},
+		{name: "expired", token: RegistrationToken{ExpiryTime: &past}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.token.Valid(now))
+		})
+	}
+}
+
+func TestAccountData(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+r.Header.Get("Authorization"))
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/_matrix/client/v3/user/@admin:example.com/account_data/m.ignored_user_list":
+			_, _ = w.Write([]byte(`{"ignored_users":{"@spam:example.com":{}}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/_matrix/client/v3/user/@admin:example.com/account_data/com.example.unset":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errcode":"M_NOT_FOUND","error":"Account data not found"}`))
+		case r.URL.Path == "/_synapse/admin/v1/users/@alice:example.com/login":
+			_, _ = w.Write([]byte(`{"access_token":"alice_token"}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/_matrix/client/v3/user/@alice:example.com/account_data/com.example.settings":
+			var content map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&content))
+			assert.Equal(t, map[string]interface{}{"theme": "dark"}, content)
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@admin:example.com",
+		AdminMode:     true,
+	})
+	require.NoError(t, err)
+
+	// The provider's own account data is read with its own access token
+	content, err := c.GetAccountData(context.Background(), "@admin:example.com", "m.ignored_user_list")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"ignored_users":{"@spam:example.com":{}}}`, string(content))
+
+	_, err = c.GetAccountData(context.Background(), "@admin:example.com", "com.example.unset")
+	assert.True(t, IsNotFound(err))
+
+	// Other users' account data is set on their behalf
+	require.NoError(t, c.SetAccountData(context.Background(), "@alice:example.com", "com.example.settings", json.RawMessage(`{"theme":"dark"}`)))
+
+	require.Len(t, requests, 4)
+	assert.Equal(t, "GET /_matrix/client/v3/user/@admin:example.com/account_data/m.ignored_user_list Bearer test_token", requests[0])
+	assert.Equal(t, "POST /_synapse/admin/v1/users/@alice:example.com/login Bearer test_token", requests[2])
+	assert.Equal(t, "PUT /_matrix/client/v3/user/@alice:example.com/account_data/com.example.settings Bearer alice_token", requests[3])
+
+	_, err = c.GetAccountData(context.Background(), "alice", "com.example.settings")
+	assert.ErrorContains(t, err, "invalid user ID")
+}
+
+func TestClientAsWithPasswordLogin(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+r.Header.Get("Authorization"))
+		switch {
+		case r.URL.Path == "/_matrix/client/v3/login":
+			_, _ = w.Write([]byte(`{"access_token":"bot_token","device_id":"PROVIDER","user_id":"@admin:example.com"}`))
+		case r.URL.Path == "/_synapse/admin/v1/users/@alice:example.com/login":
+			_, _ = w.Write([]byte(`{"access_token":"alice_token"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/_matrix/client/v3/user/@alice:example.com/account_data/com.example.settings":
+			_, _ = w.Write([]byte(`{"theme":"dark"}`))
+		default:
+			t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		Username:      "admin",
+		Password:      "secret",
+		AdminMode:     true,
+		ServerType:    ServerTypeSynapse,
+	})
+	require.NoError(t, err)
+
+	for range 2 {
+		content, err := c.GetAccountData(context.Background(), "@alice:example.com", "com.example.settings")
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"theme":"dark"}`, string(content))
+	}
+
+	// The user's account data is read with their own token, not the one the
+	// provider logged in with, and the token is reused until it expires
+	require.Len(t, requests, 4)
+	assert.Equal(t, "POST /_synapse/admin/v1/users/@alice:example.com/login Bearer bot_token", requests[1])
+	assert.Equal(t, "GET /_matrix/client/v3/user/@alice:example.com/account_data/com.example.settings Bearer alice_token", requests[2])
+	assert.Equal(t, "GET /_matrix/client/v3/user/@alice:example.com/account_data/com.example.settings Bearer alice_token", requests[3])
+}
+
+func TestRoomTags(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+r.Header.Get("Authorization"))
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/_matrix/client/v3/user/@admin:example.com/rooms/!room:example.com/tags":
+			_, _ = w.Write([]byte(`{"tags":{"m.favourite":{"order":0.25},"u.work":{}}}`))
+		case r.URL.Path == "/_synapse/admin/v1/users/@alice:example.com/login":
+			_, _ = w.Write([]byte(`{"access_token":"alice_token"}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/_matrix/client/v3/user/@alice:example.com/rooms/!room:example.com/tags/m.lowpriority":
+			var content map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&content))
+			assert.Equal(t, map[string]interface{}{"order": 0.5}, content)
+			_, _ = w.Write([]byte(`{}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/_matrix/client/v3/user/@admin:example.com/rooms/!room:example.com/tags/u.work":
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		HomeserverURL: server.URL,
+		AccessToken:   "test_token",
+		UserID:        "@admin:example.com",
+		AdminMode:     true,
+	})
+	require.NoError(t, err)
+
+	// An empty user ID is the provider's own user
+	tags, err := c.GetRoomTags(context.Background(), "", "!room:example.com")
+	require.NoError(t, err)
+	require.Len(t, tags, 2)
+	require.NotNil(t, tags["m.favourite"].Order)
+	assert.Equal(t, 0.25, *tags["m.favourite"].Order)
+	assert.Nil(t, tags["u.work"].Order)
+
+	// Other users' tags are set on their behalf
+	order := 0.5
+	require.NoError(t, c.SetRoomTag(context.Background(), "@alice:example.com", "!room:example.com", "m.lowpriority", &order))
+
+	require.NoError(t, c.DeleteRoomTag(context.Background(), "@admin:example.com", "!room:example.com", "u.work"))
+
+	require.Len(t, requests, 4)
+	assert.Equal(t, "GET /_matrix/client/v3/user/@admin:example.com/rooms/!room:example.com/tags Bearer test_token", requests[0])
+	assert.Equal(t, "PUT /_matrix/client/v3/user/@alice:example.com/rooms/!room:example.com/tags/m.lowpriority Bearer alice_token", requests[2])
+	assert.Equal(t, "DELETE /_matrix/client/v3/user/@admin:example.com/rooms/!room:example.com/tags/u.work Bearer test_token", requests[3])
+
+	for _, order := range []float64{-0.1, 1.5, math.NaN()} {
+		err = c.SetRoomTag(context.Background(), "", "!room:example.com", "m.favourite", &order)
+		assert.ErrorContains(t, err, "room tag order must be between 0 and 1")
+	}
+	assert.Len(t, requests, 4)
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clients
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/crossplane-contrib/provider-matrix/internal/metrics"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxRateLimitBody bounds how much of a rate-limited response is read to
+// find its retry delay
+const maxRateLimitBody = 64 * 1024
+
+// rateLimitTransport records the retry delays of rate-limited responses, so
+// operators can tune reconcile rates against the homeserver's actual limits.
+type rateLimitTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+		return resp, err
+	}
+
+	if d, ok := retryAfter(resp); ok {
+		metrics.RecordRetryAfter(d)
+	}
+	return resp, nil
+}
+
+// retryAfter returns the retry delay of a rate-limited response. The
+// retry_after_ms field of an M_LIMIT_EXCEEDED error takes precedence over the
+// Retry-After header. The body is left readable for the caller.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp.Body != nil {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxRateLimitBody))
+		_ = resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+
+		var content struct {
+			RetryAfterMS *int64 `json:"retry_after_ms"`
+		}
+		if err == nil && json.Unmarshal(body, &content) == nil && content.RetryAfterMS != nil {
+			return time.Duration(*content.RetryAfterMS) * time.Millisecond, true
+		}
+	}
+
+	return retryAfterHeader(resp.Header)
+}
+
+// retryAfterHeader returns the delay given in seconds by a Retry-After header
+func retryAfterHeader(h http.Header) (time.Duration, bool) {
+	if seconds, err := strconv.Atoi(h.Get("Retry-After")); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	return 0, false
+}
+
+// withRateLimitMetrics returns a copy of an HTTP client that records the
+// retry delays of rate-limited responses
+func withRateLimitMetrics(c *http.Client) *http.Client {
+	switch c.Transport.(type) {
+	case *rateLimitTransport, *retryTransport:
+		// Retries are made through the metrics transport
+		return c
+	}
+
+	next := c.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	wrapped := *c
+	wrapped.Transport = &rateLimitTransport{next: next}
+	return &wrapped
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clients
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		header string
+		want   time.Duration
+		wantOK bool
+	}{
+		{
+			name:   "retry_after_ms in body",
+			body:   `{"errcode":"M_LIMIT_EXCEEDED","error":"Too many requests","retry_after_ms":2500}`,
+			header: "10",
+			want:   2500 * time.Millisecond,
+			wantOK: true,
+		},
+		{
+			name:   "Retry-After header",
+			body:   `{"errcode":"M_LIMIT_EXCEEDED","error":"Too many requests"}`,
+			header: "3",
+			want:   3 * time.Second,
+			wantOK: true,
+		},
+		{
+			name: "no delay given",
+			body: `not json`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: http.StatusTooManyRequests,
+				Header:     http.Header{},
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+			}
+			if tt.header != "" {
+				resp.Header.Set("Retry-After", tt.header)
+			}
+
+			got, ok := retryAfter(resp)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+
+			// The body is still readable by the caller
+			body, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.body, string(body))
+		})
+	}
+}
+
+func TestWithRateLimitMetrics(t *testing.T) {
+	c := &http.Client{Timeout: time.Second}
+
+	wrapped := withRateLimitMetrics(c)
+	assert.Nil(t, c.Transport)
+	assert.Equal(t, time.Second, wrapped.Timeout)
+	assert.IsType(t, &rateLimitTransport{}, wrapped.Transport)
+	assert.Same(t, wrapped, withRateLimitMetrics(wrapped))
+}
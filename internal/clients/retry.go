@@ -0,0 +1,180 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clients
+
+import (
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+const (
+	// DefaultMaxRetries is how often a failed request is retried unless the
+	// ProviderConfig says otherwise
+	DefaultMaxRetries = 3
+
+	// DefaultRetryMaxWait bounds the total time spent waiting between
+	// retries of a request unless the ProviderConfig says otherwise
+	DefaultRetryMaxWait = 30 * time.Second
+
+	// retryBaseDelay is the delay before the first retry of a server error,
+	// doubled for each further retry
+	retryBaseDelay = 500 * time.Millisecond
+)
+
+// retryTransport retries requests the homeserver rate limited or failed with
+// a transient server error. Rate-limited requests wait as long as the
+// homeserver asked, server errors back off exponentially with jitter.
+type retryTransport struct {
+	next       http.RoundTripper
+	maxRetries int
+	maxWait    time.Duration
+
+	// timeout, if positive, bounds each attempt rather than the request as
+	// a whole, so that waiting between retries doesn't make a request to a
+	// working homeserver time out
+	timeout time.Duration
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var waited time.Duration
+	for attempt := 0; ; attempt++ {
+		resp, err := t.attempt(req)
+		if err != nil || attempt >= t.maxRetries || !retryable(req, resp) {
+			return resp, err
+		}
+
+		d, ok := time.Duration(0), false
+		if resp.StatusCode == http.StatusTooManyRequests {
+			d, ok = retryAfter(resp)
+		}
+		if !ok {
+			d = backoff(attempt)
+		}
+		if waited+d > t.maxWait {
+			return resp, nil
+		}
+
+		// A request's body was consumed by the attempt, so it can only be
+		// retried if it can be read again
+		next := req
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return resp, nil
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return resp, nil
+			}
+			next = req.Clone(req.Context())
+			next.Body = body
+		}
+
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+
+		timer := time.NewTimer(d)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+		waited += d
+		req = next
+	}
+}
+
+// attempt makes a single attempt at a request within the transport's timeout.
+// The timeout keeps running while the response body is read, until it is
+// closed.
+func (t *retryTransport) attempt(req *http.Request) (*http.Response, error) {
+	if t.timeout <= 0 {
+		return t.next.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose is a response body that releases the context of its attempt
+// once it is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close implements io.Closer
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// retryable reports whether a response is worth retrying. Rate-limited
+// requests were not handled, so they can always be retried. Server errors can
+// hide a request that succeeded, so only requests that are safe to repeat are
+// retried, which leaves out POSTs such as room creation. Other errors, such
+// as M_FORBIDDEN, will not go away by themselves.
+func retryable(req *http.Request, resp *http.Response) bool {
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return true
+	case resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented:
+		return req.Method != http.MethodPost
+	}
+	return false
+}
+
+// backoff returns the delay before a retry of a server error, doubling with
+// each attempt and jittered so that many clients don't retry in lockstep
+func backoff(attempt int) time.Duration {
+	d := retryBaseDelay << attempt
+	return d/2 + rand.N(d/2+1)
+}
+
+// withRetries returns a copy of an HTTP client that retries rate-limited and
+// transiently failed requests up to maxRetries times. A zero maxWait waits at
+// most DefaultRetryMaxWait. The client's timeout is applied to each attempt
+// instead of to all of them together.
+func withRetries(c *http.Client, maxRetries int, maxWait time.Duration) *http.Client {
+	if _, ok := c.Transport.(*retryTransport); ok || maxRetries <= 0 {
+		return c
+	}
+	if maxWait <= 0 {
+		maxWait = DefaultRetryMaxWait
+	}
+
+	next := c.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	wrapped := *c
+	wrapped.Transport = &retryTransport{next: next, maxRetries: maxRetries, maxWait: maxWait, timeout: c.Timeout}
+	wrapped.Timeout = 0
+	return &wrapped
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clients
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"maunium.net/go/mautrix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newRetryTestClient returns a client for a homeserver that answers each
+// request with the next of responses, and the number of requests made
+func newRetryTestClient(t *testing.T, config *Config, responses ...func(w http.ResponseWriter)) (Client, *int) {
+	t.Helper()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Retried requests are made with their body
+		if r.Method == http.MethodPut {
+			body, _ := io.ReadAll(r.Body)
+			assert.JSONEq(t, `{"locked":true}`, string(body))
+		}
+		i := min(requests, len(responses)-1)
+		requests++
+		responses[i](w)
+	}))
+	t.Cleanup(server.Close)
+
+	config.HomeserverURL = server.URL
+	config.AccessToken = "test_token"
+	config.UserID = "@bot:example.com"
+	c, err := NewClient(config)
+	require.NoError(t, err)
+	return c, &requests
+}
+
+func respond(status int, body string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}
+}
+
+func TestRetryRateLimited(t *testing.T) {
+	c, requests := newRetryTestClient(t, &Config{MaxRetries: 3},
+		respond(http.StatusTooManyRequests, `{"errcode":"M_LIMIT_EXCEEDED","error":"Too many requests","retry_after_ms":10}`),
+		respond(http.StatusOK, `{"room_id":"!room:example.com","servers":["example.com"]}`),
+	)
+
+	start := time.Now()
+	_, err := c.GetRoomAlias(context.Background(), "#room:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, 2, *requests)
+	assert.GreaterOrEqual(t, time.Since(start), 10*time.Millisecond)
+}
+
+func TestRetryServerError(t *testing.T) {
+	c, requests := newRetryTestClient(t, &Config{MaxRetries: 3, AdminMode: true},
+		respond(http.StatusBadGateway, `bad gateway`),
+		respond(http.StatusGatewayTimeout, `gateway timeout`),
+		respond(http.StatusOK, `{}`),
+	)
+
+	err := c.LockUser(context.Background(), "@alice:example.com", true)
+	require.NoError(t, err)
+	assert.Equal(t, 3, *requests)
+}
+
+func TestRetryForbiddenFailsFast(t *testing.T) {
+	c, requests := newRetryTestClient(t, &Config{MaxRetries: 3},
+		respond(http.StatusForbidden, `{"errcode":"M_FORBIDDEN","error":"You don't have permission"}`),
+	)
+
+	_, err := c.GetRoomAlias(context.Background(), "#room:example.com")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, mautrix.MForbidden))
+	assert.Equal(t, 1, *requests)
+}
+
+func TestRetryLimits(t *testing.T) {
+	rateLimited := respond(http.StatusTooManyRequests, `{"errcode":"M_LIMIT_EXCEEDED","error":"Too many requests","retry_after_ms":10}`)
+
+	cases := map[string]struct {
+		config *Config
+		want   int
+	}{
+		"MaxRetries": {
+			config: &Config{MaxRetries: 2},
+			want:   3,
+		},
+		"RetryMaxWait": {
+			config: &Config{MaxRetries: 10, RetryMaxWait: 25 * time.Millisecond},
+			want:   3,
+		},
+		"Disabled": {
+			config: &Config{},
+			want:   1,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c, requests := newRetryTestClient(t, tc.config, rateLimited)
+
+			_, err := c.GetRoomAlias(context.Background(), "#room:example.com")
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, mautrix.MLimitExceeded))
+			assert.Equal(t, tc.want, *requests)
+		})
+	}
+}
+
+func TestRetryTimeoutPerAttempt(t *testing.T) {
+	rateLimited := respond(http.StatusTooManyRequests, `{"errcode":"M_LIMIT_EXCEEDED","error":"Too many requests","retry_after_ms":40}`)
+	ok := respond(http.StatusOK, `{"room_id":"!room:example.com","servers":["example.com"]}`)
+
+	// Waiting between retries takes longer than the timeout, but no attempt
+	// does
+	c, requests := newRetryTestClient(t, &Config{MaxRetries: 3, RequestTimeout: 50 * time.Millisecond}, rateLimited, rateLimited, ok)
+	_, err := c.GetRoomAlias(context.Background(), "#room:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, 3, *requests)
+
+	// An attempt that takes too long still times out
+	slow := func(w http.ResponseWriter) {
+		time.Sleep(200 * time.Millisecond)
+		ok(w)
+	}
+	c, _ = newRetryTestClient(t, &Config{MaxRetries: 3, RequestTimeout: 50 * time.Millisecond}, slow)
+	_, err = c.GetRoomAlias(context.Background(), "#room:example.com")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRetryable(t *testing.T) {
+	cases := map[string]struct {
+		method string
+		status int
+		want   bool
+	}{
+		"RateLimitedPost":   {method: http.MethodPost, status: http.StatusTooManyRequests, want: true},
+		"BadGatewayPut":     {method: http.MethodPut, status: http.StatusBadGateway, want: true},
+		"BadGatewayPost":    {method: http.MethodPost, status: http.StatusBadGateway, want: false},
+		"NotImplemented":    {method: http.MethodGet, status: http.StatusNotImplemented, want: false},
+		"Forbidden":         {method: http.MethodGet, status: http.StatusForbidden, want: false},
+		"GatewayTimeoutGet": {method: http.MethodGet, status: http.StatusGatewayTimeout, want: true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/", nil)
+			assert.Equal(t, tc.want, retryable(req, &http.Response{StatusCode: tc.status}))
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := 0; attempt < 4; attempt++ {
+		d := backoff(attempt)
+		assert.GreaterOrEqual(t, d, (retryBaseDelay<<attempt)/2)
+		assert.LessOrEqual(t, d, retryBaseDelay<<attempt)
+	}
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clients
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+// customTLS reports whether the Config changes how TLS connections to the
+// homeserver are made
+func (c *Config) customTLS() bool {
+	return c.ClientCertificate != "" || c.ClientKey != "" || c.CACertificate != "" || c.InsecureSkipVerify
+}
+
+// configureTLS applies the Config's TLS settings to a transport: the client
+// certificate it presents, the CA certificates it trusts and whether it
+// verifies the homeserver at all
+func configureTLS(transport *http.Transport, config *Config) error {
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	tlsConfig := transport.TLSClientConfig
+
+	if config.ClientCertificate != "" || config.ClientKey != "" {
+		cert, err := tls.X509KeyPair([]byte(config.ClientCertificate), []byte(config.ClientKey))
+		if err != nil {
+			return errors.Wrap(err, "invalid client certificate")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if config.CACertificate != "" {
+		// The CA certificates are added to those already trusted, rather than
+		// replacing them, so that public homeservers can still be reached
+		pool := tlsConfig.RootCAs
+		if pool == nil {
+			var err error
+			if pool, err = x509.SystemCertPool(); err != nil {
+				pool = x509.NewCertPool()
+			}
+		} else {
+			pool = pool.Clone()
+		}
+		if !pool.AppendCertsFromPEM([]byte(config.CACertificate)) {
+			return errors.New("invalid CA certificate: no PEM encoded certificates found")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	tlsConfig.InsecureSkipVerify = config.InsecureSkipVerify
+	return nil
+}
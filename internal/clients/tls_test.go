@@ -0,0 +1,192 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clients
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newClientCertificate returns a self-signed PEM encoded client certificate
+// and its private key
+func newClientCertificate(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "provider-matrix"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return string(cert), string(keyPEM)
+}
+
+func TestClientCertificate(t *testing.T) {
+	cert, key := newClientCertificate(t)
+
+	var presented []string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		presented = append(presented, r.TLS.PeerCertificates[0].Subject.CommonName)
+		switch r.URL.Path {
+		case "/_matrix/client/v3/rooms/!room:example.com/state/m.room.member/@admin:example.com":
+			_, _ = w.Write([]byte(`{"membership":"join"}`))
+		case "/_synapse/admin/v2/users/@alice:example.com":
+			_, _ = w.Write([]byte(`{"user_id":"@alice:example.com"}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert, MinVersion: tls.VersionTLS12}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	newConfig := func() *Config {
+		return &Config{
+			HomeserverURL: server.URL,
+			AccessToken:   "test_token",
+			UserID:        "@admin:example.com",
+			AdminMode:     true,
+			HTTPClient:    server.Client(),
+		}
+	}
+
+	// Both the Matrix client and the admin client present the certificate
+	config := newConfig()
+	config.ClientCertificate, config.ClientKey = cert, key
+	c, err := NewClient(config)
+	require.NoError(t, err)
+
+	membership, err := c.GetMembership(context.Background(), "!room:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "join", membership)
+	user, err := c.GetUser(context.Background(), "@alice:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "@alice:example.com", user.UserID)
+	assert.Equal(t, []string{"provider-matrix", "provider-matrix"}, presented)
+
+	// Without the certificate the homeserver refuses the connection
+	c, err = NewClient(newConfig())
+	require.NoError(t, err)
+	_, err = c.GetUser(context.Background(), "@alice:example.com")
+	assert.Error(t, err)
+
+	config = newConfig()
+	config.ClientCertificate, config.ClientKey = cert, "not a key"
+	_, err = NewClient(config)
+	assert.ErrorContains(t, err, "invalid client certificate")
+}
+
+// recordingLogger is a logging.Logger that keeps the messages it is given
+type recordingLogger struct {
+	messages *[]string
+}
+
+func (l recordingLogger) Info(msg string, keysAndValues ...any) {
+	*l.messages = append(*l.messages, msg)
+}
+func (l recordingLogger) Debug(msg string, keysAndValues ...any) {
+	*l.messages = append(*l.messages, msg)
+}
+func (l recordingLogger) WithValues(keysAndValues ...any) logging.Logger {
+	return l
+}
+
+func TestServerVerification(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"membership":"join"}`))
+	}))
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	defer server.Close()
+
+	ca := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	tests := []struct {
+		name        string
+		ca          string
+		insecure    bool
+		wantErr     string
+		wantWarning bool
+	}{
+		{
+			name:    "unknown CA",
+			wantErr: "certificate",
+		},
+		{
+			name: "trusted CA",
+			ca:   ca,
+		},
+		{
+			name:        "verification disabled",
+			insecure:    true,
+			wantWarning: true,
+		},
+		{
+			name:    "malformed CA",
+			ca:      "not a certificate",
+			wantErr: "invalid CA certificate",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var messages []string
+			c, err := NewClient(&Config{
+				HomeserverURL:      server.URL,
+				AccessToken:        "test_token",
+				UserID:             "@admin:example.com",
+				CACertificate:      tt.ca,
+				InsecureSkipVerify: tt.insecure,
+				Logger:             recordingLogger{messages: &messages},
+			})
+			if err == nil {
+				_, err = c.GetMembership(context.Background(), "!room:example.com")
+			}
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantWarning, len(messages) > 0)
+		})
+	}
+}
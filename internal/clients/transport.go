@@ -0,0 +1,68 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clients
+
+import (
+	"github.com/pkg/errors"
+	"net/http"
+	"net/url"
+)
+
+// customTransport reports whether the Config changes how connections to the
+// homeserver are made. Without such settings the default transport is used,
+// which takes its proxy from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+// environment variables.
+func (c *Config) customTransport() bool {
+	return c.customTLS() || c.ProxyURL != ""
+}
+
+// withTransportConfig returns a copy of an HTTP client whose transport
+// applies the Config's TLS and proxy settings. Both the Matrix and the admin
+// client share the transport, so the settings apply to every request to the
+// homeserver.
+func withTransportConfig(c *http.Client, config *Config) (*http.Client, error) {
+	var transport *http.Transport
+	switch t := c.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return nil, errors.Errorf("cannot configure a %T transport", t)
+	}
+
+	if config.customTLS() {
+		if err := configureTLS(transport, config); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.ProxyURL != "" {
+		proxy, err := url.Parse(config.ProxyURL)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid proxy URL")
+		}
+		if proxy.Scheme != "http" && proxy.Scheme != "https" && proxy.Scheme != "socks5" {
+			return nil, errors.New("proxy URL must use http, https or socks5 scheme")
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	wrapped := *c
+	wrapped.Transport = transport
+	return &wrapped, nil
+}
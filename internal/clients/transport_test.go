@@ -0,0 +1,65 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clients
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProxyURL(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		_, _ = w.Write([]byte(`{"membership":"join"}`))
+	}))
+	defer proxy.Close()
+
+	config := &Config{
+		HomeserverURL: "http://matrix.example.com",
+		AccessToken:   "test_token",
+		UserID:        "@bot:example.com",
+		ProxyURL:      proxy.URL,
+	}
+	c, err := NewClient(config)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, "https://matrix.example.com/_matrix/client/versions", nil)
+	require.NoError(t, err)
+	transport, err := withTransportConfig(&http.Client{}, config)
+	require.NoError(t, err)
+	proxyURL, err := transport.Transport.(*http.Transport).Proxy(req)
+	require.NoError(t, err)
+	assert.Equal(t, proxy.URL, proxyURL.String())
+
+	// Requests to the homeserver go through the proxy
+	membership, err := c.GetMembership(context.Background(), "!room:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "join", membership)
+	assert.Equal(t, []string{
+		"http://matrix.example.com/_matrix/client/v3/rooms/%21room:example.com/state/m.room.member/@bot:example.com",
+	}, proxied)
+
+	for _, invalid := range []string{"ftp://proxy.example.com", "http://[::1"} {
+		_, err = NewClient(&Config{HomeserverURL: "http://matrix.example.com", ProxyURL: invalid})
+		assert.Error(t, err, invalid)
+	}
+}
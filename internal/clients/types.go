@@ -27,6 +27,8 @@ type User struct {
 	AvatarURL    string       `json:"avatar_url,omitempty"`
 	Admin        bool         `json:"admin"`
 	Deactivated  bool         `json:"deactivated"`
+	Locked       bool         `json:"locked"`
+	ShadowBanned bool         `json:"shadow_banned"`
 	CreationTime *time.Time   `json:"creation_ts,omitempty"`
 	LastSeenTime *time.Time   `json:"last_seen_ts,omitempty"`
 	UserType     string       `json:"user_type,omitempty"`
@@ -34,6 +36,12 @@ type User struct {
 	Devices      []Device     `json:"devices,omitempty"`
 }
 
+// RateLimit is a homeserver's rate-limit override for a user
+type RateLimit struct {
+	MessagesPerSecond int `json:"messages_per_second"`
+	BurstCount        int `json:"burst_count"`
+}
+
 // UserSpec represents the parameters for creating/updating a user
 type UserSpec struct {
 	UserID      string       `json:"user_id,omitempty"`
@@ -63,44 +71,252 @@ type Device struct {
 	LastSeenTime *time.Time `json:"last_seen_ts,omitempty"`
 }
 
+// Connection is a connection a user made to the homeserver
+type Connection struct {
+	DeviceID     string     `json:"device_id,omitempty"`
+	IP           string     `json:"ip,omitempty"`
+	UserAgent    string     `json:"user_agent,omitempty"`
+	LastSeenTime *time.Time `json:"last_seen,omitempty"`
+}
+
 // Room represents a Matrix room
 type Room struct {
-	RoomID            string             `json:"room_id"`
-	Name              string             `json:"name,omitempty"`
-	Topic             string             `json:"topic,omitempty"`
-	Alias             string             `json:"canonical_alias,omitempty"`
-	AvatarURL         string             `json:"avatar,omitempty"`
-	Creator           string             `json:"creator,omitempty"`
-	CreationTime      *time.Time         `json:"creation_ts,omitempty"`
-	RoomVersion       string             `json:"room_version,omitempty"`
-	JoinedMembers     int                `json:"joined_members"`
-	InvitedMembers    int                `json:"invited_members"`
-	Visibility        string             `json:"visibility,omitempty"`
-	GuestAccess       string             `json:"guest_access,omitempty"`
-	HistoryVisibility string             `json:"history_visibility,omitempty"`
-	JoinRules         string             `json:"join_rules,omitempty"`
-	EncryptionEnabled bool               `json:"encryption,omitempty"`
+	RoomID            string     `json:"room_id"`
+	Name              string     `json:"name,omitempty"`
+	Topic             string     `json:"topic,omitempty"`
+	TopicHTML         string     `json:"topic_html,omitempty"`
+	RichTopic         bool       `json:"rich_topic,omitempty"`
+	Alias             string     `json:"canonical_alias,omitempty"`
+	AvatarURL         string     `json:"avatar,omitempty"`
+	Creator           string     `json:"creator,omitempty"`
+	CreationTime      *time.Time `json:"creation_ts,omitempty"`
+	RoomVersion       string     `json:"room_version,omitempty"`
+	RoomType          string     `json:"room_type,omitempty"`
+	JoinedMembers     int        `json:"joined_members"`
+	InvitedMembers    int        `json:"invited_members"`
+	Visibility        string     `json:"visibility,omitempty"`
+	GuestAccess       string     `json:"guest_access,omitempty"`
+	HistoryVisibility string     `json:"history_visibility,omitempty"`
+	JoinRules         string     `json:"join_rules,omitempty"`
+
+	// EncryptionEnabled is read from the room's m.room.encryption event.
+	// The admin API reports the algorithm in its place.
+	EncryptionEnabled bool               `json:"-"`
 	PowerLevels       *PowerLevelContent `json:"power_levels,omitempty"`
 	State             []StateEvent       `json:"state,omitempty"`
+	Predecessor       *RoomPredecessor   `json:"predecessor,omitempty"`
+	AltAliases        []string           `json:"alt_aliases,omitempty"`
+
+	// PrivilegedCreators are the room's creators when its version gives
+	// them unlimited power
+	PrivilegedCreators []string `json:"privileged_creators,omitempty"`
+
+	// JoinRuleAllow are the rooms whose members may join under a restricted
+	// join rule
+	JoinRuleAllow []string `json:"join_rule_allow,omitempty"`
+
+	// JoinRulesContent is the raw content of the room's join rules,
+	// including any fields that aren't modelled above
+	JoinRulesContent map[string]interface{} `json:"join_rules_content,omitempty"`
+
+	// PinnedEvents are the IDs of the events pinned in the room
+	PinnedEvents []string `json:"pinned_events,omitempty"`
+
+	// StateEvents is the number of state events in the room. Only the
+	// admin API reports it.
+	StateEvents int `json:"state_events,omitempty"`
+
+	// Blocked is whether the room is blocked from being joined, or nil if
+	// it wasn't observed. Only Synapse's admin API reports it.
+	Blocked *bool `json:"-"`
+
+	// Summary is true when the room's state couldn't be read, because it
+	// is a room on another homeserver the provider hasn't joined, and only
+	// the summary shared over federation was observed. The summary holds
+	// the name, topic, avatar, canonical alias, join rules, guest access,
+	// type, version and member count.
+	Summary bool `json:"-"`
+}
+
+// RoomPredecessor identifies the room that a room was upgraded from
+type RoomPredecessor struct {
+	RoomID  string `json:"room_id"`
+	EventID string `json:"event_id,omitempty"`
+}
+
+// DeleteRoomOptions controls how a room is deleted
+type DeleteRoomOptions struct {
+	// Purge removes the room's history from the database. Without it the
+	// room is shut down but can be recovered.
+	Purge bool
+}
+
+// RoomDeletion is the progress of the deletion of a room
+type RoomDeletion struct {
+	// DeleteID identifies the deletion
+	DeleteID string `json:"delete_id"`
+
+	// Status is scheduled, shutting_down, purging, complete or failed
+	Status string `json:"status"`
+
+	// Error is why the deletion failed
+	Error string `json:"error,omitempty"`
+}
+
+// Room deletion statuses.
+const (
+	RoomDeletionScheduled    = "scheduled"
+	RoomDeletionShuttingDown = "shutting_down"
+	RoomDeletionPurging      = "purging"
+	RoomDeletionComplete     = "complete"
+	RoomDeletionFailed       = "failed"
+)
+
+// HistoryPurge is the progress of a purge of a room's history
+type HistoryPurge struct {
+	// Status is active, complete or failed
+	Status string `json:"status"`
+
+	// Error is why the purge failed
+	Error string `json:"error,omitempty"`
+}
+
+// History purge statuses.
+const (
+	HistoryPurgeActive   = "active"
+	HistoryPurgeComplete = "complete"
+	HistoryPurgeFailed   = "failed"
+)
+
+// Media is a piece of media known to the homeserver, either uploaded to it or
+// cached from another homeserver
+type Media struct {
+	MediaID            string     `json:"media_id"`
+	MediaType          string     `json:"media_type,omitempty"`
+	MediaLength        int64      `json:"media_length,omitempty"`
+	UploadName         string     `json:"upload_name,omitempty"`
+	UserID             string     `json:"user_id,omitempty"`
+	CreationTime       *time.Time `json:"created_ts,omitempty"`
+	QuarantinedBy      string     `json:"quarantined_by,omitempty"`
+	SafeFromQuarantine bool       `json:"safe_from_quarantine,omitempty"`
+}
+
+// RegistrationToken is a token that lets someone register an account on the
+// homeserver
+type RegistrationToken struct {
+	Token string `json:"token"`
+
+	// UsesAllowed is how many accounts the token can register, or nil if
+	// there is no limit
+	UsesAllowed *int `json:"uses_allowed,omitempty"`
+
+	// Pending is how many registrations using the token are under way
+	Pending int `json:"pending"`
+
+	// Completed is how many accounts the token has registered
+	Completed int `json:"completed"`
+
+	// ExpiryTime is when the token stops being valid, or nil if it doesn't
+	// expire
+	ExpiryTime *time.Time `json:"expiry_time,omitempty"`
+}
+
+// Valid reports whether the token can still be used to register an account
+func (t *RegistrationToken) Valid(now time.Time) bool {
+	if t.UsesAllowed != nil && t.Pending+t.Completed >= *t.UsesAllowed {
+		return false
+	}
+	return t.ExpiryTime == nil || now.Before(*t.ExpiryTime)
+}
+
+// RegistrationTokenSpec represents the parameters for creating/updating a
+// registration token. Nil fields are left as they are when updating.
+type RegistrationTokenSpec struct {
+	// Token is the token to create, or empty to have the homeserver
+	// generate one
+	Token       string
+	UsesAllowed *int
+	ExpiryTime  *time.Time
+}
+
+// ServerInfo identifies the software a homeserver runs
+type ServerInfo struct {
+	// Type is the type of homeserver, such as synapse, or the lowercased
+	// name of its software if it isn't one the provider knows
+	Type string
+
+	// Name is the name of the homeserver's software, such as Synapse
+	Name string
+
+	// Version is the version of the homeserver's software
+	Version string
+}
+
+// RoomTag is a tag a user put on a room, such as m.favourite
+type RoomTag struct {
+	// Order is where the room sorts among the rooms with the same tag,
+	// between 0 and 1, or nil if it isn't ordered
+	Order *float64
+}
+
+// ForwardExtremities represents the forward extremities of a room
+type ForwardExtremities struct {
+	Count   int                `json:"count"`
+	Results []ForwardExtremity `json:"results,omitempty"`
+}
+
+// ForwardExtremity represents a single forward extremity of a room
+type ForwardExtremity struct {
+	EventID    string `json:"event_id"`
+	StateGroup int64  `json:"state_group"`
+	Depth      int64  `json:"depth"`
+	ReceivedTS int64  `json:"received_ts"`
 }
 
 // RoomSpec represents the parameters for creating/updating a room
 type RoomSpec struct {
-	Name                string                 `json:"name,omitempty"`
+	Name                *string                `json:"name,omitempty"`
 	Topic               string                 `json:"topic,omitempty"`
+	TopicHTML           string                 `json:"topic_html,omitempty"`
+	RichTopic           bool                   `json:"rich_topic,omitempty"`
 	Alias               string                 `json:"room_alias_name,omitempty"`
+	AltAliases          []string               `json:"alt_aliases,omitempty"`
 	Preset              string                 `json:"preset,omitempty"`
 	Visibility          string                 `json:"visibility,omitempty"`
 	RoomVersion         string                 `json:"room_version,omitempty"`
 	CreationContent     map[string]interface{} `json:"creation_content,omitempty"`
 	InitialState        []StateEvent           `json:"initial_state,omitempty"`
 	Invite              []string               `json:"invite,omitempty"`
+	Invite3PID          []ThirdPartyInvite     `json:"invite_3pid,omitempty"`
 	PowerLevelOverrides *PowerLevelContent     `json:"power_level_content_override,omitempty"`
 	GuestAccess         string                 `json:"guest_access,omitempty"`
 	HistoryVisibility   string                 `json:"history_visibility,omitempty"`
 	JoinRules           string                 `json:"join_rules,omitempty"`
 	EncryptionEnabled   bool                   `json:"encryption,omitempty"`
 	AvatarURL           string                 `json:"avatar_url,omitempty"`
+	PinnedEvents        []string               `json:"pinned_events,omitempty"`
+
+	// JoinRuleAllow are the rooms whose members may join under a restricted
+	// join rule. When nil, the room's allow conditions are left alone.
+	JoinRuleAllow []string `json:"join_rule_allow,omitempty"`
+
+	// SetAliasAsCanonical makes Alias the room's canonical alias when nil or
+	// true. When false, Alias is taken out of the canonical alias if it is
+	// there.
+	SetAliasAsCanonical *bool `json:"set_alias_as_canonical,omitempty"`
+}
+
+// aliasIsCanonical reports whether the spec's alias is to be the room's
+// canonical alias
+func (s *RoomSpec) aliasIsCanonical() bool {
+	return s.SetAliasAsCanonical == nil || *s.SetAliasAsCanonical
+}
+
+// ThirdPartyInvite identifies a user to invite by a third-party identifier
+type ThirdPartyInvite struct {
+	Medium   string `json:"medium"`
+	Address  string `json:"address"`
+	IDServer string `json:"id_server"`
 }
 
 // StateEvent represents a Matrix state event
@@ -127,12 +343,18 @@ type PowerLevelContent struct {
 type PowerLevelSpec struct {
 	RoomID      string             `json:"room_id"`
 	PowerLevels *PowerLevelContent `json:"power_levels"`
+
+	// Previous is the room's power levels as observed when the change was
+	// computed. When set, SetPowerLevels refuses to send if the room's power
+	// levels have changed since.
+	Previous *PowerLevelContent `json:"previous,omitempty"`
 }
 
 // RoomAlias represents a Matrix room alias
 type RoomAlias struct {
-	Alias  string `json:"alias"`
-	RoomID string `json:"room_id"`
+	Alias   string   `json:"alias"`
+	RoomID  string   `json:"room_id"`
+	Servers []string `json:"servers,omitempty"`
 }
 
 // Space represents a Matrix space (special type of room)
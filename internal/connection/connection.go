@@ -0,0 +1,54 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package connection tells an unreachable homeserver and an access token it
+// doesn't accept apart from other failures to connect, so that they don't
+// first show up as a confusing error from whatever the provider did next.
+package connection
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/common"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Connector wraps c so that connecting to a homeserver that can't be reached
+// or doesn't accept the access token sets a ConnectionFailed condition with
+// the Unreachable or AuthenticationFailed reason, which is cleared by the
+// next successful connect.
+func Connector(c managed.ExternalConnector) managed.ExternalConnector {
+	return &connector{ExternalConnector: c}
+}
+
+type connector struct {
+	managed.ExternalConnector
+}
+
+func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	ext, err := c.ExternalConnector.Connect(ctx, mg)
+	switch {
+	case clients.IsAuthenticationFailed(err):
+		mg.SetConditions(common.AuthenticationFailed(err))
+	case clients.IsUnreachable(err):
+		mg.SetConditions(common.Unreachable(err))
+	case err == nil && mg.GetCondition(common.TypeConnectionFailed).Status == corev1.ConditionTrue:
+		mg.SetConditions(common.Connected())
+	}
+	return ext, err
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connection
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/common"
+	"github.com/crossplane-contrib/provider-matrix/apis/room/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"maunium.net/go/mautrix"
+	"net"
+	"net/http"
+	"testing"
+)
+
+func connectWith(err error) managed.ExternalConnector {
+	return Connector(managed.ExternalConnectorFn(func(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+		if err != nil {
+			return nil, err
+		}
+		return &managed.NopClient{}, nil
+	}))
+}
+
+func TestConnect(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus corev1.ConditionStatus
+		wantReason string
+	}{
+		{
+			name: "invalid token",
+			err: errors.Wrap(mautrix.HTTPError{
+				Response:  &http.Response{StatusCode: http.StatusUnauthorized},
+				RespError: &mautrix.RespError{ErrCode: "M_UNKNOWN_TOKEN", Err: "Invalid access token passed."},
+			}, "cannot verify the access token"),
+			wantStatus: corev1.ConditionTrue,
+			wantReason: string(common.ReasonAuthenticationFailed),
+		},
+		{
+			name:       "unreachable",
+			err:        errors.Wrap(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, "cannot verify the access token"),
+			wantStatus: corev1.ConditionTrue,
+			wantReason: string(common.ReasonUnreachable),
+		},
+		{
+			name:       "other error",
+			err:        assert.AnError,
+			wantStatus: corev1.ConditionUnknown,
+		},
+		{
+			name:       "connected",
+			wantStatus: corev1.ConditionUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := &v1alpha1.Room{}
+			_, err := connectWith(tt.err).Connect(context.Background(), cr)
+			assert.ErrorIs(t, err, tt.err)
+
+			cond := cr.GetCondition(common.TypeConnectionFailed)
+			assert.Equal(t, tt.wantStatus, cond.Status)
+			assert.Equal(t, tt.wantReason, string(cond.Reason))
+		})
+	}
+}
+
+func TestConnectedAfterFailure(t *testing.T) {
+	cr := &v1alpha1.Room{}
+	cr.SetConditions(common.Unreachable(assert.AnError))
+
+	_, err := connectWith(nil).Connect(context.Background(), cr)
+	assert.NoError(t, err)
+	assert.Equal(t, corev1.ConditionFalse, cr.GetCondition(common.TypeConnectionFailed).Status)
+	assert.Equal(t, common.ReasonConnected, cr.GetCondition(common.TypeConnectionFailed).Reason)
+}
@@ -0,0 +1,276 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accountdata
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/crossplane-contrib/provider-matrix/apis/accountdata/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane-contrib/provider-matrix/internal/connection"
+	"github.com/crossplane-contrib/provider-matrix/internal/forcedelete"
+	"github.com/crossplane-contrib/provider-matrix/internal/maintenance"
+	"github.com/crossplane-contrib/provider-matrix/internal/metrics"
+	"github.com/crossplane-contrib/provider-matrix/internal/observeonly"
+	"github.com/crossplane-contrib/provider-matrix/internal/permission"
+	"github.com/crossplane-contrib/provider-matrix/internal/provenance"
+	"github.com/crossplane-contrib/provider-matrix/internal/resync"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/controller"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/feature"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/ratelimiter"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	"github.com/pkg/errors"
+	"reflect"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	errNotAccountData = "managed resource is not an AccountData custom resource"
+	errTrackPCUsage   = "cannot track ProviderConfig usage"
+	errGetPC          = "cannot get ProviderConfig"
+	errGetCreds       = "cannot get credentials"
+	errNewClient      = "cannot create new Matrix client"
+	errSyncProfile    = "cannot sync the provider user's profile"
+	errGetAccountData = "cannot get Matrix account data"
+	errSetAccountData = "cannot set Matrix account data"
+	errDecodeContent  = "cannot decode account data content"
+	errEmptyContent   = "account data content cannot be empty, as that is what deleted account data is left as"
+)
+
+// emptyContent is what account data is set to when it is deleted, since
+// Matrix has no way to remove it
+var emptyContent = json.RawMessage(`{}`)
+
+// Setup adds a controller that reconciles AccountData managed resources.
+func Setup(mgr ctrl.Manager, o controller.Options) error {
+	name := managed.ControllerName(v1alpha1.AccountDataKind)
+
+	opts := []managed.ReconcilerOption{
+		managed.WithExternalConnector(maintenance.Connector(v1alpha1.AccountDataGroupKind, connection.Connector(permission.Connector(forcedelete.Connector(&connector{
+			kube:         mgr.GetClient(),
+			usage:        clients.NewProviderConfigUsageTracker(mgr.GetClient()),
+			newServiceFn: clients.CachedClient,
+			logger:       o.Logger.WithValues("controller", name),
+		}, o.Logger.WithValues("controller", name)))))),
+		managed.WithLogger(o.Logger.WithValues("controller", name)),
+		managed.WithPollInterval(o.PollInterval),
+		managed.WithPollIntervalHook(resync.PollIntervalHook),
+		managed.WithRecorder(nil),
+	}
+	if o.Features.Enabled(feature.EnableBetaManagementPolicies) {
+		opts = append(opts, managed.WithManagementPolicies())
+	}
+
+	r := managed.NewReconciler(mgr, resource.ManagedKind(v1alpha1.AccountDataGroupVersionKind), opts...)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(name).
+		WithOptions(o.ForControllerRuntime()).
+		WithEventFilter(resource.DesiredStateChanged()).
+		For(&v1alpha1.AccountData{}).
+		Complete(ratelimiter.NewReconciler(name, maintenance.Reconciler(v1alpha1.AccountDataGroupKind, r), o.GlobalRateLimiter))
+}
+
+// A connector is expected to produce an ExternalClient when its Connect method
+// is called.
+type connector struct {
+	kube         client.Client
+	usage        resource.ModernTracker
+	newServiceFn func(config *clients.Config) (clients.Client, error)
+	logger       logging.Logger
+}
+
+// Connect typically produces an ExternalClient by:
+// 1. Tracking that the managed resource is using a ProviderConfig.
+// 2. Getting the managed resource's ProviderConfig.
+// 3. Getting the credentials specified by the ProviderConfig.
+// 4. Using the credentials to form a client.
+func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	if _, ok := mg.(*v1alpha1.AccountData); !ok {
+		return nil, errors.New(errNotAccountData)
+	}
+
+	modernManaged, ok := mg.(resource.ModernManaged)
+	if !ok {
+		return nil, errors.New("managed resource does not implement ModernManaged")
+	}
+	if err := c.usage.Track(ctx, modernManaged); err != nil {
+		return nil, errors.Wrap(err, errTrackPCUsage)
+	}
+
+	pc, err := clients.GetProviderConfig(ctx, c.kube, mg)
+	if err != nil {
+		return nil, errors.Wrap(err, errGetPC)
+	}
+	resync.Set(pc)
+
+	config, err := clients.GetConfig(ctx, c.kube, mg)
+	if err != nil {
+		return nil, errors.Wrap(err, errGetCreds)
+	}
+	config.Logger = c.logger.WithValues("providerConfig", config.ProviderConfigName)
+
+	service, err := c.newServiceFn(config)
+	if err != nil {
+		return nil, errors.Wrap(err, errNewClient)
+	}
+
+	if err := clients.VerifyConnection(ctx, c.kube, pc, service); err != nil {
+		return nil, err
+	}
+
+	if err := service.SyncProfile(ctx); err != nil {
+		return nil, errors.Wrap(err, errSyncProfile)
+	}
+
+	return &external{service: service}, nil
+}
+
+// An ExternalClient observes, then either creates, updates, or deletes an
+// external resource to ensure it reflects the managed resource's desired state.
+type external struct {
+	service clients.Client
+}
+
+func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
+	cr, ok := mg.(*v1alpha1.AccountData)
+	if !ok {
+		return managed.ExternalObservation{}, errors.New(errNotAccountData)
+	}
+
+	// Account data that was never set isn't found, and deleted account data
+	// is left as an empty object. Neither exists.
+	p := cr.Spec.ForProvider
+	content, err := c.service.GetAccountData(ctx, p.UserID, p.Type)
+	if clients.IsNotFound(err) {
+		return managed.ExternalObservation{
+			ResourceExists: false,
+		}, nil
+	}
+	if err != nil {
+		return managed.ExternalObservation{}, errors.Wrap(err, errGetAccountData)
+	}
+	empty, err := isEmpty(content)
+	if err != nil {
+		return managed.ExternalObservation{}, errors.Wrap(err, errDecodeContent)
+	}
+	if empty {
+		return managed.ExternalObservation{
+			ResourceExists: false,
+		}, nil
+	}
+
+	cr.Status.AtProvider.ManagedBy, cr.Status.AtProvider.AdoptedAt = provenance.Observe(cr, cr.Status.AtProvider.AdoptedAt)
+	cr.Status.SetConditions(xpv1.Available())
+
+	same, err := sameJSON(p.Content.Raw, content)
+	if err != nil {
+		return managed.ExternalObservation{}, errors.Wrap(err, errDecodeContent)
+	}
+	var drift []string
+	if !same {
+		drift = append(drift, "content")
+	}
+	drift = observeonly.Drift(drift, p.ObserveOnlyFields)
+	metrics.RecordDrift(v1alpha1.AccountDataKind, drift)
+
+	return managed.ExternalObservation{
+		ResourceExists:   true,
+		ResourceUpToDate: len(drift) == 0,
+	}, nil
+}
+
+func (c *external) Create(ctx context.Context, mg resource.Managed) (managed.ExternalCreation, error) {
+	cr, ok := mg.(*v1alpha1.AccountData)
+	if !ok {
+		return managed.ExternalCreation{}, errors.New(errNotAccountData)
+	}
+
+	return managed.ExternalCreation{}, c.set(ctx, cr)
+}
+
+func (c *external) Update(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
+	cr, ok := mg.(*v1alpha1.AccountData)
+	if !ok {
+		return managed.ExternalUpdate{}, errors.New(errNotAccountData)
+	}
+
+	return managed.ExternalUpdate{}, c.set(ctx, cr)
+}
+
+// Delete sets the account data to an empty object. Matrix has no way to
+// remove account data, and clients treat an empty object as none.
+func (c *external) Delete(ctx context.Context, mg resource.Managed) (managed.ExternalDelete, error) {
+	cr, ok := mg.(*v1alpha1.AccountData)
+	if !ok {
+		return managed.ExternalDelete{}, errors.New(errNotAccountData)
+	}
+
+	p := cr.Spec.ForProvider
+	err := c.service.SetAccountData(ctx, p.UserID, p.Type, emptyContent)
+	return managed.ExternalDelete{}, errors.Wrap(err, errSetAccountData)
+}
+
+// Disconnect closes the external client.
+func (c *external) Disconnect(ctx context.Context) error {
+	return nil // No special disconnect logic needed
+}
+
+// set sets the account data to the spec's content
+func (c *external) set(ctx context.Context, cr *v1alpha1.AccountData) error {
+	p := cr.Spec.ForProvider
+	empty, err := isEmpty(p.Content.Raw)
+	if err != nil {
+		return errors.Wrap(err, errDecodeContent)
+	}
+	if empty {
+		return errors.New(errEmptyContent)
+	}
+
+	err = c.service.SetAccountData(ctx, p.UserID, p.Type, p.Content.Raw)
+	return errors.Wrap(err, errSetAccountData)
+}
+
+// isEmpty reports whether content is no account data at all: nothing, null
+// or an empty object
+func isEmpty(content []byte) (bool, error) {
+	if len(content) == 0 {
+		return true, nil
+	}
+	var v map[string]interface{}
+	if err := json.Unmarshal(content, &v); err != nil {
+		return false, err
+	}
+	return len(v) == 0, nil
+}
+
+// sameJSON reports whether two JSON documents hold the same value, whatever
+// the order of their keys and their whitespace
+func sameJSON(a, b []byte) (bool, error) {
+	var va, vb interface{}
+	if err := json.Unmarshal(a, &va); err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(b, &vb); err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(va, vb), nil
+}
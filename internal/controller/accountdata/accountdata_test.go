@@ -0,0 +1,146 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accountdata
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/crossplane-contrib/provider-matrix/apis/accountdata/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/apis/common"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"maunium.net/go/mautrix"
+	"testing"
+)
+
+type mockClient struct {
+	clients.Client
+
+	// data is the account data set, by type
+	data map[string]json.RawMessage
+}
+
+func (m *mockClient) GetAccountData(ctx context.Context, userID, dataType string) (json.RawMessage, error) {
+	content, ok := m.data[dataType]
+	if !ok {
+		return nil, mautrix.MNotFound
+	}
+	return content, nil
+}
+
+func (m *mockClient) SetAccountData(ctx context.Context, userID, dataType string, content json.RawMessage) error {
+	m.data[dataType] = content
+	return nil
+}
+
+func newAccountData(content string) *v1alpha1.AccountData {
+	return &v1alpha1.AccountData{Spec: v1alpha1.AccountDataSpec{ForProvider: v1alpha1.AccountDataParameters{
+		UserID:  "@alice:example.com",
+		Type:    "com.example.settings",
+		Content: runtime.RawExtension{Raw: []byte(content)},
+	}}}
+}
+
+func TestObserve(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         map[string]json.RawMessage
+		wantExists   bool
+		wantUpToDate bool
+	}{
+		{
+			name: "never set",
+			data: map[string]json.RawMessage{},
+		},
+		{
+			name: "deleted",
+			data: map[string]json.RawMessage{"com.example.settings": json.RawMessage(`{}`)},
+		},
+		{
+			name:         "same content in another order",
+			data:         map[string]json.RawMessage{"com.example.settings": json.RawMessage(`{ "theme": "dark", "layout": {"compact": true} }`)},
+			wantExists:   true,
+			wantUpToDate: true,
+		},
+		{
+			name:       "different content",
+			data:       map[string]json.RawMessage{"com.example.settings": json.RawMessage(`{"theme":"light"}`)},
+			wantExists: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &external{service: &mockClient{data: tt.data}}
+			cr := newAccountData(`{"layout":{"compact":true},"theme":"dark"}`)
+
+			obs, err := e.Observe(context.Background(), cr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantExists, obs.ResourceExists)
+			assert.Equal(t, tt.wantUpToDate, obs.ResourceUpToDate)
+			if tt.wantExists {
+				assert.Equal(t, common.ManagedByAdopted, cr.Status.AtProvider.ManagedBy)
+			}
+		})
+	}
+}
+
+func TestCreateUpdateDelete(t *testing.T) {
+	m := &mockClient{data: map[string]json.RawMessage{}}
+	e := &external{service: m}
+
+	cr := newAccountData(`{"theme":"dark"}`)
+	_, err := e.Create(context.Background(), cr)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"theme":"dark"}`, string(m.data["com.example.settings"]))
+
+	cr.Spec.ForProvider.Content.Raw = []byte(`{"theme":"light"}`)
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"theme":"light"}`, string(m.data["com.example.settings"]))
+
+	// Account data can't be removed, so it is emptied, which Observe
+	// reports as gone
+	_, err = e.Delete(context.Background(), cr)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{}`, string(m.data["com.example.settings"]))
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceExists)
+}
+
+func TestObserveOnlyFields(t *testing.T) {
+	m := &mockClient{data: map[string]json.RawMessage{"com.example.settings": json.RawMessage(`{"theme":"light"}`)}}
+	e := &external{service: m}
+	cr := newAccountData(`{"theme":"dark"}`)
+	cr.Spec.ForProvider.ObserveOnlyFields = []string{"content"}
+
+	// Content the user's client has changed is only observed
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceExists)
+	assert.True(t, obs.ResourceUpToDate)
+}
+
+func TestCreateEmptyContent(t *testing.T) {
+	e := &external{service: &mockClient{data: map[string]json.RawMessage{}}}
+
+	_, err := e.Create(context.Background(), newAccountData(`{}`))
+	assert.EqualError(t, err, errEmptyContent)
+}
@@ -0,0 +1,263 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package device
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/device/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane-contrib/provider-matrix/internal/connection"
+	"github.com/crossplane-contrib/provider-matrix/internal/forcedelete"
+	"github.com/crossplane-contrib/provider-matrix/internal/maintenance"
+	"github.com/crossplane-contrib/provider-matrix/internal/metrics"
+	"github.com/crossplane-contrib/provider-matrix/internal/observeonly"
+	"github.com/crossplane-contrib/provider-matrix/internal/permission"
+	"github.com/crossplane-contrib/provider-matrix/internal/resync"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/controller"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/feature"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/ratelimiter"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"slices"
+)
+
+const (
+	errNotDevice    = "managed resource is not a Device custom resource"
+	errTrackPCUsage = "cannot track ProviderConfig usage"
+	errGetPC        = "cannot get ProviderConfig"
+	errGetCreds     = "cannot get credentials"
+	errNewClient    = "cannot create new Matrix client"
+	errSyncProfile  = "cannot sync the provider user's profile"
+	errGetDevices   = "cannot list Matrix user devices"
+	errCreateDevice = "cannot create Matrix device: devices are created by their user logging in, so a Device can only manage one that exists"
+	errRenameDevice = "cannot rename Matrix device"
+	errDeleteDevice = "cannot delete Matrix device"
+
+	errDeletionRefused = "cannot delete Matrix device: use the access token of a homeserver admin in a ProviderConfig with adminMode enabled, or sign the device out from one of the user's own clients"
+)
+
+// Setup adds a controller that reconciles Device managed resources.
+func Setup(mgr ctrl.Manager, o controller.Options) error {
+	name := managed.ControllerName(v1alpha1.DeviceKind)
+
+	opts := []managed.ReconcilerOption{
+		managed.WithExternalConnector(maintenance.Connector(v1alpha1.DeviceGroupKind, connection.Connector(permission.Connector(forcedelete.Connector(&connector{
+			kube:         mgr.GetClient(),
+			usage:        clients.NewProviderConfigUsageTracker(mgr.GetClient()),
+			newServiceFn: clients.CachedClient,
+			logger:       o.Logger.WithValues("controller", name),
+		}, o.Logger.WithValues("controller", name)))))),
+		managed.WithLogger(o.Logger.WithValues("controller", name)),
+		managed.WithPollInterval(o.PollInterval),
+		managed.WithPollIntervalHook(resync.PollIntervalHook),
+		managed.WithRecorder(nil),
+	}
+	if o.Features.Enabled(feature.EnableBetaManagementPolicies) {
+		opts = append(opts, managed.WithManagementPolicies())
+	}
+
+	r := managed.NewReconciler(mgr, resource.ManagedKind(v1alpha1.DeviceGroupVersionKind), opts...)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(name).
+		WithOptions(o.ForControllerRuntime()).
+		WithEventFilter(resource.DesiredStateChanged()).
+		For(&v1alpha1.Device{}).
+		Complete(ratelimiter.NewReconciler(name, maintenance.Reconciler(v1alpha1.DeviceGroupKind, r), o.GlobalRateLimiter))
+}
+
+// A connector is expected to produce an ExternalClient when its Connect method
+// is called.
+type connector struct {
+	kube         client.Client
+	usage        resource.ModernTracker
+	newServiceFn func(config *clients.Config) (clients.Client, error)
+	logger       logging.Logger
+}
+
+// Connect typically produces an ExternalClient by:
+// 1. Tracking that the managed resource is using a ProviderConfig.
+// 2. Getting the managed resource's ProviderConfig.
+// 3. Getting the credentials specified by the ProviderConfig.
+// 4. Using the credentials to form a client.
+func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	if _, ok := mg.(*v1alpha1.Device); !ok {
+		return nil, errors.New(errNotDevice)
+	}
+
+	modernManaged, ok := mg.(resource.ModernManaged)
+	if !ok {
+		return nil, errors.New("managed resource does not implement ModernManaged")
+	}
+	if err := c.usage.Track(ctx, modernManaged); err != nil {
+		return nil, errors.Wrap(err, errTrackPCUsage)
+	}
+
+	pc, err := clients.GetProviderConfig(ctx, c.kube, mg)
+	if err != nil {
+		return nil, errors.Wrap(err, errGetPC)
+	}
+	resync.Set(pc)
+
+	config, err := clients.GetConfig(ctx, c.kube, mg)
+	if err != nil {
+		return nil, errors.Wrap(err, errGetCreds)
+	}
+	config.Logger = c.logger.WithValues("providerConfig", config.ProviderConfigName)
+
+	service, err := c.newServiceFn(config)
+	if err != nil {
+		return nil, errors.Wrap(err, errNewClient)
+	}
+
+	if err := clients.VerifyConnection(ctx, c.kube, pc, service); err != nil {
+		return nil, err
+	}
+
+	if err := service.SyncProfile(ctx); err != nil {
+		return nil, errors.Wrap(err, errSyncProfile)
+	}
+
+	return &external{service: service}, nil
+}
+
+// An ExternalClient observes, then either creates, updates, or deletes an
+// external resource to ensure it reflects the managed resource's desired state.
+type external struct {
+	service clients.Client
+}
+
+func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
+	cr, ok := mg.(*v1alpha1.Device)
+	if !ok {
+		return managed.ExternalObservation{}, errors.New(errNotDevice)
+	}
+
+	devices, err := c.service.GetDevices(ctx, cr.Spec.ForProvider.UserID)
+	if err != nil {
+		if clients.IsNotFound(err) {
+			return managed.ExternalObservation{
+				ResourceExists: false,
+			}, nil
+		}
+		return managed.ExternalObservation{}, errors.Wrap(err, errGetDevices)
+	}
+	device := findDevice(devices, cr.Spec.ForProvider.DeviceID)
+	if device == nil {
+		return managed.ExternalObservation{
+			ResourceExists: false,
+		}, nil
+	}
+
+	settled := cr.Status.AtProvider.SettledFields
+	cr.Status.AtProvider = generateDeviceObservation(device)
+	cr.Status.SetConditions(xpv1.Available())
+
+	drift := deviceDrift(cr, device)
+	cr.Status.AtProvider.SettledFields = observeonly.Settle(settled, cr.Spec.ForProvider.ObserveOnlyFields, drift)
+	drift = observeonly.Drift(drift, cr.Status.AtProvider.SettledFields)
+	metrics.RecordDrift(v1alpha1.DeviceKind, drift)
+
+	return managed.ExternalObservation{
+		ResourceExists:   true,
+		ResourceUpToDate: len(drift) == 0,
+	}, nil
+}
+
+func (c *external) Create(ctx context.Context, mg resource.Managed) (managed.ExternalCreation, error) {
+	if _, ok := mg.(*v1alpha1.Device); !ok {
+		return managed.ExternalCreation{}, errors.New(errNotDevice)
+	}
+
+	// The admin API can't log a user in, and a device without the access
+	// token of a login would be of no use to anyone
+	return managed.ExternalCreation{}, errors.New(errCreateDevice)
+}
+
+func (c *external) Update(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
+	cr, ok := mg.(*v1alpha1.Device)
+	if !ok {
+		return managed.ExternalUpdate{}, errors.New(errNotDevice)
+	}
+
+	p := cr.Spec.ForProvider
+	if p.DisplayName == nil || slices.Contains(cr.Status.AtProvider.SettledFields, "displayName") {
+		return managed.ExternalUpdate{}, nil
+	}
+	err := c.service.SetDeviceDisplayName(ctx, p.UserID, p.DeviceID, *p.DisplayName)
+	return managed.ExternalUpdate{}, errors.Wrap(err, errRenameDevice)
+}
+
+func (c *external) Delete(ctx context.Context, mg resource.Managed) (managed.ExternalDelete, error) {
+	cr, ok := mg.(*v1alpha1.Device)
+	if !ok {
+		return managed.ExternalDelete{}, errors.New(errNotDevice)
+	}
+
+	err := c.service.DeleteDevice(ctx, cr.Spec.ForProvider.UserID, cr.Spec.ForProvider.DeviceID)
+	var refused *clients.DeviceDeletionRefusedError
+	switch {
+	case err == nil, clients.IsNotFound(err):
+		return managed.ExternalDelete{}, nil
+	case errors.As(err, &refused):
+		return managed.ExternalDelete{}, errors.Wrap(err, errDeletionRefused)
+	}
+	return managed.ExternalDelete{}, errors.Wrap(err, errDeleteDevice)
+}
+
+// Disconnect closes the external client.
+func (c *external) Disconnect(ctx context.Context) error {
+	return nil // No special disconnect logic needed
+}
+
+// findDevice returns the device with the given ID, or nil if there is none
+func findDevice(devices []clients.Device, deviceID string) *clients.Device {
+	for i := range devices {
+		if devices[i].DeviceID == deviceID {
+			return &devices[i]
+		}
+	}
+	return nil
+}
+
+// generateDeviceObservation returns the observed state of a device
+func generateDeviceObservation(device *clients.Device) v1alpha1.DeviceObservation {
+	obs := v1alpha1.DeviceObservation{
+		DisplayName: device.DisplayName,
+		LastSeenIP:  device.LastSeenIP,
+	}
+	if device.LastSeenTime != nil {
+		lastSeen := metav1.NewTime(*device.LastSeenTime)
+		obs.LastSeenTime = &lastSeen
+	}
+	return obs
+}
+
+// deviceDrift returns the spec fields that differ from the observed device
+func deviceDrift(cr *v1alpha1.Device, device *clients.Device) []string {
+	var drift []string
+	if p := cr.Spec.ForProvider.DisplayName; p != nil && *p != device.DisplayName {
+		drift = append(drift, "displayName")
+	}
+	return drift
+}
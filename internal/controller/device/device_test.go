@@ -0,0 +1,176 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package device
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/device/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+type mockClient struct {
+	clients.Client
+
+	devices   []clients.Device
+	deleteErr error
+}
+
+func (m *mockClient) GetDevices(ctx context.Context, userID string) ([]clients.Device, error) {
+	return m.devices, nil
+}
+
+func (m *mockClient) SetDeviceDisplayName(ctx context.Context, userID, deviceID, displayName string) error {
+	for i := range m.devices {
+		if m.devices[i].DeviceID == deviceID {
+			m.devices[i].DisplayName = displayName
+		}
+	}
+	return nil
+}
+
+func (m *mockClient) DeleteDevice(ctx context.Context, userID, deviceID string) error {
+	if m.deleteErr != nil {
+		return m.deleteErr
+	}
+	m.devices = nil
+	return nil
+}
+
+func newDevice(displayName *string) *v1alpha1.Device {
+	return &v1alpha1.Device{Spec: v1alpha1.DeviceSpec{ForProvider: v1alpha1.DeviceParameters{
+		UserID:      "@alice:example.com",
+		DeviceID:    "LAPTOP",
+		DisplayName: displayName,
+	}}}
+}
+
+func TestObserveMissingDevice(t *testing.T) {
+	e := &external{service: &mockClient{devices: []clients.Device{{DeviceID: "PHONE"}}}}
+
+	obs, err := e.Observe(context.Background(), newDevice(nil))
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceExists)
+
+	_, err = e.Create(context.Background(), newDevice(nil))
+	assert.EqualError(t, err, errCreateDevice)
+}
+
+func TestObserveAndRename(t *testing.T) {
+	laptop, old := "Laptop", "Old laptop"
+
+	tests := []struct {
+		name        string
+		displayName *string
+		want        bool
+	}{
+		{name: "unmanaged", want: true},
+		{name: "matching", displayName: &old, want: true},
+		{name: "renamed", displayName: &laptop},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mockClient{devices: []clients.Device{{DeviceID: "LAPTOP", DisplayName: "Old laptop", LastSeenIP: "192.0.2.1"}}}
+			e := &external{service: m}
+			cr := newDevice(tt.displayName)
+
+			obs, err := e.Observe(context.Background(), cr)
+			require.NoError(t, err)
+			assert.True(t, obs.ResourceExists)
+			assert.Equal(t, tt.want, obs.ResourceUpToDate)
+			assert.Equal(t, "192.0.2.1", cr.Status.AtProvider.LastSeenIP)
+
+			_, err = e.Update(context.Background(), cr)
+			require.NoError(t, err)
+			if tt.displayName != nil {
+				assert.Equal(t, *tt.displayName, m.devices[0].DisplayName)
+			}
+		})
+	}
+}
+
+func TestObserveOnlyFields(t *testing.T) {
+	laptop := "Laptop"
+	m := &mockClient{devices: []clients.Device{{DeviceID: "LAPTOP", DisplayName: "Old laptop"}}}
+	e := &external{service: m}
+	cr := newDevice(&laptop)
+	cr.Spec.ForProvider.ObserveOnlyFields = []string{"displayName"}
+
+	// A device is never created, so it is renamed until it has the name once
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceUpToDate)
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceUpToDate)
+	assert.Equal(t, []string{"displayName"}, cr.Status.AtProvider.SettledFields)
+
+	// Afterwards the user may rename it
+	m.devices[0].DisplayName = "Work laptop"
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceUpToDate)
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, "Work laptop", m.devices[0].DisplayName)
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		name      string
+		deleteErr error
+		wantErr   string
+	}{
+		{name: "deleted"},
+		{
+			name:      "already gone",
+			deleteErr: &clients.APIError{StatusCode: http.StatusNotFound, ErrCode: "M_NOT_FOUND", Body: "{}"},
+		},
+		{
+			name:      "failed",
+			deleteErr: assert.AnError,
+			wantErr:   errDeleteDevice,
+		},
+		{
+			name:      "interactive authentication",
+			deleteErr: &clients.DeviceDeletionRefusedError{UserID: "@alice:example.com", DeviceID: "LAPTOP"},
+			wantErr:   errDeletionRefused,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mockClient{devices: []clients.Device{{DeviceID: "LAPTOP"}}, deleteErr: tt.deleteErr}
+			e := &external{service: m}
+
+			_, err := e.Delete(context.Background(), newDevice(nil))
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,318 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package media
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/media/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane-contrib/provider-matrix/internal/connection"
+	"github.com/crossplane-contrib/provider-matrix/internal/forcedelete"
+	"github.com/crossplane-contrib/provider-matrix/internal/maintenance"
+	"github.com/crossplane-contrib/provider-matrix/internal/metrics"
+	"github.com/crossplane-contrib/provider-matrix/internal/observeonly"
+	"github.com/crossplane-contrib/provider-matrix/internal/permission"
+	"github.com/crossplane-contrib/provider-matrix/internal/resync"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/controller"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/feature"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/ratelimiter"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	errNotMedia     = "managed resource is not a Media custom resource"
+	errTrackPCUsage = "cannot track ProviderConfig usage"
+	errGetPC        = "cannot get ProviderConfig"
+	errGetCreds     = "cannot get credentials"
+	errNewClient    = "cannot create new Matrix client"
+	errSyncProfile  = "cannot sync the provider user's profile"
+	errParseMXCURI  = "cannot parse media URI"
+	errGetMedia     = "cannot get Matrix media"
+	errCreateMedia  = "cannot create Matrix media: media is created by uploading it, so a Media can only manage media the homeserver has"
+	errQuarantine   = "cannot quarantine Matrix media"
+	errProtected    = "cannot quarantine Matrix media: the media is protected from quarantine"
+	errDeleteMedia  = "cannot delete Matrix media"
+)
+
+// Setup adds a controller that reconciles Media managed resources.
+func Setup(mgr ctrl.Manager, o controller.Options) error {
+	name := managed.ControllerName(v1alpha1.MediaKind)
+
+	opts := []managed.ReconcilerOption{
+		managed.WithExternalConnector(maintenance.Connector(v1alpha1.MediaGroupKind, connection.Connector(permission.Connector(forcedelete.Connector(&connector{
+			kube:         mgr.GetClient(),
+			usage:        clients.NewProviderConfigUsageTracker(mgr.GetClient()),
+			newServiceFn: clients.CachedClient,
+			logger:       o.Logger.WithValues("controller", name),
+		}, o.Logger.WithValues("controller", name)))))),
+		// The external name is the media's URI, set once the media has been
+		// found, so it isn't initialized to the resource's name
+		managed.WithInitializers(),
+		managed.WithLogger(o.Logger.WithValues("controller", name)),
+		managed.WithPollInterval(o.PollInterval),
+		managed.WithPollIntervalHook(resync.PollIntervalHook),
+		managed.WithRecorder(nil),
+	}
+	if o.Features.Enabled(feature.EnableBetaManagementPolicies) {
+		opts = append(opts, managed.WithManagementPolicies())
+	}
+
+	r := managed.NewReconciler(mgr, resource.ManagedKind(v1alpha1.MediaGroupVersionKind), opts...)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(name).
+		WithOptions(o.ForControllerRuntime()).
+		WithEventFilter(resource.DesiredStateChanged()).
+		For(&v1alpha1.Media{}).
+		Complete(ratelimiter.NewReconciler(name, maintenance.Reconciler(v1alpha1.MediaGroupKind, r), o.GlobalRateLimiter))
+}
+
+// A connector is expected to produce an ExternalClient when its Connect method
+// is called.
+type connector struct {
+	kube         client.Client
+	usage        resource.ModernTracker
+	newServiceFn func(config *clients.Config) (clients.Client, error)
+	logger       logging.Logger
+}
+
+// Connect typically produces an ExternalClient by:
+// 1. Tracking that the managed resource is using a ProviderConfig.
+// 2. Getting the managed resource's ProviderConfig.
+// 3. Getting the credentials specified by the ProviderConfig.
+// 4. Using the credentials to form a client.
+func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	if _, ok := mg.(*v1alpha1.Media); !ok {
+		return nil, errors.New(errNotMedia)
+	}
+
+	modernManaged, ok := mg.(resource.ModernManaged)
+	if !ok {
+		return nil, errors.New("managed resource does not implement ModernManaged")
+	}
+	if err := c.usage.Track(ctx, modernManaged); err != nil {
+		return nil, errors.Wrap(err, errTrackPCUsage)
+	}
+
+	pc, err := clients.GetProviderConfig(ctx, c.kube, mg)
+	if err != nil {
+		return nil, errors.Wrap(err, errGetPC)
+	}
+	resync.Set(pc)
+
+	config, err := clients.GetConfig(ctx, c.kube, mg)
+	if err != nil {
+		return nil, errors.Wrap(err, errGetCreds)
+	}
+	config.Logger = c.logger.WithValues("providerConfig", config.ProviderConfigName)
+
+	service, err := c.newServiceFn(config)
+	if err != nil {
+		return nil, errors.Wrap(err, errNewClient)
+	}
+
+	if err := clients.VerifyConnection(ctx, c.kube, pc, service); err != nil {
+		return nil, err
+	}
+
+	if err := service.SyncProfile(ctx); err != nil {
+		return nil, errors.Wrap(err, errSyncProfile)
+	}
+
+	return &external{service: service}, nil
+}
+
+// An ExternalClient observes, then either creates, updates, or deletes an
+// external resource to ensure it reflects the managed resource's desired state.
+type external struct {
+	service clients.Client
+}
+
+func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
+	cr, ok := mg.(*v1alpha1.Media)
+	if !ok {
+		return managed.ExternalObservation{}, errors.New(errNotMedia)
+	}
+
+	// Media that is kept on deletion is gone as far as the resource is
+	// concerned once it is being deleted
+	if meta.WasDeleted(cr) && !deleteMediaOnDelete(cr) {
+		return managed.ExternalObservation{
+			ResourceExists: false,
+		}, nil
+	}
+
+	if meta.GetExternalName(cr) == "" {
+		return managed.ExternalObservation{
+			ResourceExists: false,
+		}, nil
+	}
+
+	serverName, mediaID, err := clients.ParseMXCURI(cr.Spec.ForProvider.MXCURI)
+	if err != nil {
+		return managed.ExternalObservation{}, errors.Wrap(err, errParseMXCURI)
+	}
+
+	media, err := c.service.GetMedia(ctx, serverName, mediaID)
+	if err != nil {
+		if clients.IsNotFound(err) {
+			return managed.ExternalObservation{
+				ResourceExists: false,
+			}, nil
+		}
+		return managed.ExternalObservation{}, errors.Wrap(err, errGetMedia)
+	}
+
+	cr.Status.AtProvider = generateMediaObservation(media)
+	cr.Status.SetConditions(xpv1.Available())
+
+	drift := mediaDrift(observeonly.Desired(cr))
+	metrics.RecordDrift(v1alpha1.MediaKind, drift)
+
+	return managed.ExternalObservation{
+		ResourceExists:   true,
+		ResourceUpToDate: len(drift) == 0,
+	}, nil
+}
+
+func (c *external) Create(ctx context.Context, mg resource.Managed) (managed.ExternalCreation, error) {
+	cr, ok := mg.(*v1alpha1.Media)
+	if !ok {
+		return managed.ExternalCreation{}, errors.New(errNotMedia)
+	}
+
+	serverName, mediaID, err := clients.ParseMXCURI(cr.Spec.ForProvider.MXCURI)
+	if err != nil {
+		return managed.ExternalCreation{}, errors.Wrap(err, errParseMXCURI)
+	}
+
+	// Media can't be uploaded through the admin API, so creating a Media only
+	// takes over media the homeserver already has
+	media, err := c.service.GetMedia(ctx, serverName, mediaID)
+	if err != nil {
+		if clients.IsNotFound(err) {
+			return managed.ExternalCreation{}, errors.New(errCreateMedia)
+		}
+		return managed.ExternalCreation{}, errors.Wrap(err, errGetMedia)
+	}
+	cr.Status.AtProvider = generateMediaObservation(media)
+
+	if len(mediaDrift(cr)) > 0 {
+		if err := c.quarantine(ctx, cr, serverName, mediaID); err != nil {
+			return managed.ExternalCreation{}, err
+		}
+	}
+
+	meta.SetExternalName(cr, cr.Spec.ForProvider.MXCURI)
+	return managed.ExternalCreation{}, nil
+}
+
+func (c *external) Update(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
+	cr, ok := mg.(*v1alpha1.Media)
+	if !ok {
+		return managed.ExternalUpdate{}, errors.New(errNotMedia)
+	}
+
+	serverName, mediaID, err := clients.ParseMXCURI(cr.Spec.ForProvider.MXCURI)
+	if err != nil {
+		return managed.ExternalUpdate{}, errors.Wrap(err, errParseMXCURI)
+	}
+
+	return managed.ExternalUpdate{}, c.quarantine(ctx, observeonly.Desired(cr), serverName, mediaID)
+}
+
+func (c *external) Delete(ctx context.Context, mg resource.Managed) (managed.ExternalDelete, error) {
+	cr, ok := mg.(*v1alpha1.Media)
+	if !ok {
+		return managed.ExternalDelete{}, errors.New(errNotMedia)
+	}
+
+	p := cr.Spec.ForProvider
+	if !deleteMediaOnDelete(cr) {
+		return managed.ExternalDelete{}, nil
+	}
+
+	serverName, mediaID, err := clients.ParseMXCURI(p.MXCURI)
+	if err != nil {
+		return managed.ExternalDelete{}, errors.Wrap(err, errParseMXCURI)
+	}
+
+	err = c.service.DeleteMedia(ctx, serverName, mediaID)
+	if err != nil && !clients.IsNotFound(err) {
+		return managed.ExternalDelete{}, errors.Wrap(err, errDeleteMedia)
+	}
+	return managed.ExternalDelete{}, nil
+}
+
+// deleteMediaOnDelete reports whether deleting the resource deletes the media
+func deleteMediaOnDelete(cr *v1alpha1.Media) bool {
+	return cr.Spec.ForProvider.DeleteMediaOnDelete != nil && *cr.Spec.ForProvider.DeleteMediaOnDelete
+}
+
+// Disconnect closes the external client.
+func (c *external) Disconnect(ctx context.Context) error {
+	return nil // No special disconnect logic needed
+}
+
+// quarantine quarantines the media, or lifts its quarantine, as the spec asks.
+// Synapse silently ignores quarantining protected media, which would
+// otherwise be retried forever, so that is reported as an error instead.
+func (c *external) quarantine(ctx context.Context, cr *v1alpha1.Media, serverName, mediaID string) error {
+	quarantined := cr.Spec.ForProvider.Quarantined
+	if quarantined == nil {
+		return nil
+	}
+	if *quarantined && cr.Status.AtProvider.SafeFromQuarantine {
+		return errors.New(errProtected)
+	}
+	return errors.Wrap(c.service.QuarantineMedia(ctx, serverName, mediaID, *quarantined), errQuarantine)
+}
+
+// generateMediaObservation returns the observed state of a piece of media
+func generateMediaObservation(media *clients.Media) v1alpha1.MediaObservation {
+	obs := v1alpha1.MediaObservation{
+		Quarantined:        media.QuarantinedBy != "",
+		QuarantinedBy:      media.QuarantinedBy,
+		SafeFromQuarantine: media.SafeFromQuarantine,
+		MediaType:          media.MediaType,
+		MediaLength:        media.MediaLength,
+		UploadName:         media.UploadName,
+		UserID:             media.UserID,
+	}
+	if media.CreationTime != nil {
+		created := metav1.NewTime(*media.CreationTime)
+		obs.CreationTime = &created
+	}
+	return obs
+}
+
+// mediaDrift returns the spec fields that differ from the observed media
+func mediaDrift(cr *v1alpha1.Media) []string {
+	var drift []string
+	if p := cr.Spec.ForProvider.Quarantined; p != nil && *p != cr.Status.AtProvider.Quarantined {
+		drift = append(drift, "quarantined")
+	}
+	return drift
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package media
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/media/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"net/http"
+	"testing"
+)
+
+type mockClient struct {
+	clients.Client
+
+	media   map[string]*clients.Media
+	deleted []string
+}
+
+func (m *mockClient) GetMedia(ctx context.Context, serverName, mediaID string) (*clients.Media, error) {
+	media, ok := m.media[serverName+"/"+mediaID]
+	if !ok {
+		return nil, &clients.APIError{StatusCode: http.StatusNotFound, ErrCode: "M_NOT_FOUND", Body: "{}"}
+	}
+	return media, nil
+}
+
+func (m *mockClient) QuarantineMedia(ctx context.Context, serverName, mediaID string, quarantined bool) error {
+	media := m.media[serverName+"/"+mediaID]
+	media.QuarantinedBy = ""
+	if quarantined {
+		media.QuarantinedBy = "@admin:example.com"
+	}
+	return nil
+}
+
+func (m *mockClient) DeleteMedia(ctx context.Context, serverName, mediaID string) error {
+	m.deleted = append(m.deleted, serverName+"/"+mediaID)
+	delete(m.media, serverName+"/"+mediaID)
+	return nil
+}
+
+func newMedia(quarantined *bool) *v1alpha1.Media {
+	return &v1alpha1.Media{Spec: v1alpha1.MediaSpec{ForProvider: v1alpha1.MediaParameters{
+		MXCURI:      "mxc://example.com/abc",
+		Quarantined: quarantined,
+	}}}
+}
+
+func TestCreateQuarantines(t *testing.T) {
+	m := &mockClient{media: map[string]*clients.Media{"example.com/abc": {MediaID: "abc"}}}
+	e := &external{service: m}
+	cr := newMedia(boolPtr(true))
+
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceExists)
+
+	_, err = e.Create(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, "mxc://example.com/abc", meta.GetExternalName(cr))
+	assert.Equal(t, "@admin:example.com", m.media["example.com/abc"].QuarantinedBy)
+
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceExists)
+	assert.True(t, obs.ResourceUpToDate)
+	assert.True(t, cr.Status.AtProvider.Quarantined)
+}
+
+func TestObserveOnlyFields(t *testing.T) {
+	m := &mockClient{media: map[string]*clients.Media{"example.com/abc": {MediaID: "abc"}}}
+	e := &external{service: m}
+	cr := newMedia(boolPtr(true))
+	cr.Spec.ForProvider.ObserveOnlyFields = []string{"quarantined"}
+
+	_, err := e.Create(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, "@admin:example.com", m.media["example.com/abc"].QuarantinedBy)
+
+	// Server admins may lift the quarantine afterwards
+	m.media["example.com/abc"].QuarantinedBy = ""
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceUpToDate)
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Empty(t, m.media["example.com/abc"].QuarantinedBy)
+}
+
+func TestCreateMissingMedia(t *testing.T) {
+	e := &external{service: &mockClient{media: map[string]*clients.Media{}}}
+
+	_, err := e.Create(context.Background(), newMedia(nil))
+	assert.EqualError(t, err, errCreateMedia)
+}
+
+func TestObserveAndQuarantine(t *testing.T) {
+	tests := []struct {
+		name          string
+		quarantined   *bool
+		quarantinedBy string
+		safe          bool
+		want          bool
+		wantErr       string
+	}{
+		{name: "unmanaged", want: true},
+		{name: "quarantined", quarantined: boolPtr(true), quarantinedBy: "@mod:example.com", want: true},
+		{name: "quarantine", quarantined: boolPtr(true)},
+		{name: "lift quarantine", quarantined: boolPtr(false), quarantinedBy: "@mod:example.com"},
+		{name: "protected", quarantined: boolPtr(true), safe: true, wantErr: errProtected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			media := &clients.Media{MediaID: "abc", QuarantinedBy: tt.quarantinedBy, SafeFromQuarantine: tt.safe}
+			e := &external{service: &mockClient{media: map[string]*clients.Media{"example.com/abc": media}}}
+			cr := newMedia(tt.quarantined)
+			meta.SetExternalName(cr, "mxc://example.com/abc")
+
+			obs, err := e.Observe(context.Background(), cr)
+			require.NoError(t, err)
+			assert.True(t, obs.ResourceExists)
+			assert.Equal(t, tt.want, obs.ResourceUpToDate)
+
+			_, err = e.Update(context.Background(), cr)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			if tt.quarantined != nil {
+				assert.Equal(t, *tt.quarantined, media.QuarantinedBy != "")
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		name                string
+		deleteMediaOnDelete *bool
+		wantDeleted         []string
+	}{
+		{name: "kept"},
+		{name: "kept explicitly", deleteMediaOnDelete: boolPtr(false)},
+		{name: "deleted", deleteMediaOnDelete: boolPtr(true), wantDeleted: []string{"example.com/abc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mockClient{media: map[string]*clients.Media{"example.com/abc": {MediaID: "abc"}}}
+			e := &external{service: m}
+			cr := newMedia(nil)
+			cr.Spec.ForProvider.DeleteMediaOnDelete = tt.deleteMediaOnDelete
+			meta.SetExternalName(cr, "mxc://example.com/abc")
+			now := metav1.Now()
+			cr.SetDeletionTimestamp(&now)
+
+			// Kept media is gone for the resource without calling Delete
+			obs, err := e.Observe(context.Background(), cr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantDeleted != nil, obs.ResourceExists)
+
+			_, err = e.Delete(context.Background(), cr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantDeleted, m.deleted)
+
+			obs, err = e.Observe(context.Background(), cr)
+			require.NoError(t, err)
+			assert.False(t, obs.ResourceExists)
+		})
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
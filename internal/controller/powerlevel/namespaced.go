@@ -0,0 +1,78 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package powerlevel
+
+import (
+	namespacedv1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/namespaced/powerlevel/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/apis/powerlevel/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane-contrib/provider-matrix/internal/connection"
+	"github.com/crossplane-contrib/provider-matrix/internal/forcedelete"
+	"github.com/crossplane-contrib/provider-matrix/internal/maintenance"
+	"github.com/crossplane-contrib/provider-matrix/internal/namespaced"
+	"github.com/crossplane-contrib/provider-matrix/internal/permission"
+	"github.com/crossplane-contrib/provider-matrix/internal/resync"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/controller"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/feature"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/ratelimiter"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// SetupNamespaced adds a controller that reconciles namespaced PowerLevel managed
+// resources the way PowerLevels are reconciled.
+func SetupNamespaced(mgr ctrl.Manager, o controller.Options) error {
+	name := managed.ControllerName(namespacedv1alpha1.PowerLevelGroupKind.String())
+
+	opts := []managed.ReconcilerOption{
+		managed.WithExternalConnector(maintenance.Connector(namespacedv1alpha1.PowerLevelGroupKind, connection.Connector(permission.Connector(forcedelete.Connector(namespaced.Connector(&connector{
+			kube:         mgr.GetClient(),
+			usage:        clients.NewProviderConfigUsageTracker(mgr.GetClient()),
+			newServiceFn: clients.CachedClient,
+			logger:       o.Logger.WithValues("controller", name),
+		}, toCluster, fromCluster), o.Logger.WithValues("controller", name)))))),
+		managed.WithLogger(o.Logger.WithValues("controller", name)),
+		managed.WithPollInterval(o.PollInterval),
+		managed.WithPollIntervalHook(resync.PollIntervalHook),
+		managed.WithRecorder(nil),
+	}
+	if o.Features.Enabled(feature.EnableBetaManagementPolicies) {
+		opts = append(opts, managed.WithManagementPolicies())
+	}
+
+	r := managed.NewReconciler(mgr, resource.ManagedKind(namespacedv1alpha1.PowerLevelGroupVersionKind), opts...)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(name).
+		WithOptions(o.ForControllerRuntime()).
+		WithEventFilter(resource.DesiredStateChanged()).
+		For(&namespacedv1alpha1.PowerLevel{}).
+		Complete(ratelimiter.NewReconciler(name, maintenance.Reconciler(namespacedv1alpha1.PowerLevelGroupKind, r), o.GlobalRateLimiter))
+}
+
+// toCluster returns the namespaced PowerLevel np as a PowerLevel in its namespace, so
+// that it is reconciled by the connector of PowerLevels
+func toCluster(np *namespacedv1alpha1.PowerLevel) *v1alpha1.PowerLevel {
+	return &v1alpha1.PowerLevel{TypeMeta: np.TypeMeta, ObjectMeta: np.ObjectMeta, Spec: np.Spec, Status: np.Status}
+}
+
+// fromCluster copies what reconciling cp changed back to the namespaced
+// PowerLevel np it was converted from
+func fromCluster(cp *v1alpha1.PowerLevel, np *namespacedv1alpha1.PowerLevel) {
+	np.ObjectMeta, np.Spec, np.Status = cp.ObjectMeta, cp.Spec, cp.Status
+}
@@ -19,53 +19,82 @@ package powerlevel
 import (
 	"context"
 	"github.com/crossplane-contrib/provider-matrix/apis/powerlevel/v1alpha1"
-	apisv1beta1 "github.com/crossplane-contrib/provider-matrix/apis/v1beta1"
 	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane-contrib/provider-matrix/internal/connection"
+	"github.com/crossplane-contrib/provider-matrix/internal/forcedelete"
+	"github.com/crossplane-contrib/provider-matrix/internal/maintenance"
+	"github.com/crossplane-contrib/provider-matrix/internal/membership"
+	"github.com/crossplane-contrib/provider-matrix/internal/metrics"
+	"github.com/crossplane-contrib/provider-matrix/internal/observeonly"
+	"github.com/crossplane-contrib/provider-matrix/internal/permission"
+	"github.com/crossplane-contrib/provider-matrix/internal/provenance"
+	"github.com/crossplane-contrib/provider-matrix/internal/resync"
+	"github.com/crossplane-contrib/provider-matrix/internal/upgrade"
 	"github.com/crossplane/crossplane-runtime/v2/pkg/controller"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/feature"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
 	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
 	"github.com/crossplane/crossplane-runtime/v2/pkg/ratelimiter"
 	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
 	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
 	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
 	"github.com/pkg/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
+	corev1 "k8s.io/api/core/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"time"
+	"slices"
 )
 
 const (
-	errNotPowerLevel  = "managed resource is not a PowerLevel custom resource"
-	errTrackPCUsage   = "cannot track ProviderConfig usage"
-	errGetPC          = "cannot get ProviderConfig"
-	errGetCreds       = "cannot get credentials"
-	errNewClient      = "cannot create new Matrix client"
-	errSetPowerLevels = "cannot set Matrix power levels"
-	errGetPowerLevels = "cannot get Matrix power levels"
+	errNotPowerLevel    = "managed resource is not a PowerLevel custom resource"
+	errTrackPCUsage     = "cannot track ProviderConfig usage"
+	errGetPC            = "cannot get ProviderConfig"
+	errGetCreds         = "cannot get credentials"
+	errNewClient        = "cannot create new Matrix client"
+	errSyncProfile      = "cannot sync the provider user's profile"
+	errSetPowerLevels   = "cannot set Matrix power levels"
+	errGetPowerLevels   = "cannot get Matrix power levels"
+	errResetPowerLevels = "cannot reset Matrix power levels"
+	errGetCreators      = "cannot get Matrix room creators"
+	errExpandRoles      = "cannot expand power level roles"
 )
 
+// defaultRoleLevels are the power levels of the built-in roles, matching the
+// levels clients present as admin, moderator and default
+var defaultRoleLevels = map[string]int{
+	"admin":     100,
+	"moderator": 50,
+	"user":      0,
+}
+
 // Setup adds a controller that reconciles PowerLevel managed resources.
 func Setup(mgr ctrl.Manager, o controller.Options) error {
 	name := managed.ControllerName(v1alpha1.PowerLevelKind)
 
-	r := managed.NewReconciler(mgr,
-		resource.ManagedKind(v1alpha1.PowerLevelGroupVersionKind),
-		managed.WithExternalConnector(&connector{
+	opts := []managed.ReconcilerOption{
+		managed.WithExternalConnector(maintenance.Connector(v1alpha1.PowerLevelGroupKind, connection.Connector(permission.Connector(forcedelete.Connector(&connector{
 			kube:         mgr.GetClient(),
 			usage:        clients.NewProviderConfigUsageTracker(mgr.GetClient()),
-			newServiceFn: clients.NewClient,
-		}),
+			newServiceFn: clients.CachedClient,
+			logger:       o.Logger.WithValues("controller", name),
+		}, o.Logger.WithValues("controller", name)))))),
 		managed.WithLogger(o.Logger.WithValues("controller", name)),
 		managed.WithPollInterval(o.PollInterval),
-		managed.WithRecorder(nil))
+		managed.WithPollIntervalHook(resync.PollIntervalHook),
+		managed.WithRecorder(nil),
+	}
+	if o.Features.Enabled(feature.EnableBetaManagementPolicies) {
+		opts = append(opts, managed.WithManagementPolicies())
+	}
+
+	r := managed.NewReconciler(mgr, resource.ManagedKind(v1alpha1.PowerLevelGroupVersionKind), opts...)
 
 	return ctrl.NewControllerManagedBy(mgr).
 		Named(name).
 		WithOptions(o.ForControllerRuntime()).
 		WithEventFilter(resource.DesiredStateChanged()).
 		For(&v1alpha1.PowerLevel{}).
-		Complete(ratelimiter.NewReconciler(name, r, o.GlobalRateLimiter))
+		Complete(ratelimiter.NewReconciler(name, maintenance.Reconciler(v1alpha1.PowerLevelGroupKind, r), o.GlobalRateLimiter))
 }
 
 // A connector is expected to produce an ExternalClient when its Connect method
@@ -74,6 +103,7 @@ type connector struct {
 	kube         client.Client
 	usage        resource.ModernTracker
 	newServiceFn func(config *clients.Config) (clients.Client, error)
+	logger       logging.Logger
 }
 
 // Connect typically produces an ExternalClient by:
@@ -82,8 +112,7 @@ type connector struct {
 // 3. Getting the credentials specified by the ProviderConfig.
 // 4. Using the credentials to form a client.
 func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
-	cr, ok := mg.(*v1alpha1.PowerLevel)
-	if !ok {
+	if _, ok := mg.(*v1alpha1.PowerLevel); !ok {
 		return nil, errors.New(errNotPowerLevel)
 	}
 
@@ -95,28 +124,49 @@ func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.E
 		return nil, errors.Wrap(err, errTrackPCUsage)
 	}
 
-	pc := &apisv1beta1.ProviderConfig{}
-	if err := c.kube.Get(ctx, types.NamespacedName{Name: cr.GetProviderConfigReference().Name}, pc); err != nil {
+	pc, err := clients.GetProviderConfig(ctx, c.kube, mg)
+	if err != nil {
 		return nil, errors.Wrap(err, errGetPC)
 	}
+	resync.Set(pc)
 
 	config, err := clients.GetConfig(ctx, c.kube, mg)
 	if err != nil {
 		return nil, errors.Wrap(err, errGetCreds)
 	}
+	config.Logger = c.logger.WithValues("providerConfig", config.ProviderConfigName)
 
 	service, err := c.newServiceFn(config)
 	if err != nil {
 		return nil, errors.Wrap(err, errNewClient)
 	}
 
-	return &external{service: service}, nil
+	if err := clients.VerifyConnection(ctx, c.kube, pc, service); err != nil {
+		return nil, err
+	}
+
+	if err := service.SyncProfile(ctx); err != nil {
+		return nil, errors.Wrap(err, errSyncProfile)
+	}
+
+	return &external{service: service, selfUserID: config.UserID}, nil
 }
 
 // An ExternalClient observes, then either creates, updates, or deletes an
 // external resource to ensure it reflects the managed resource's desired state.
 type external struct {
 	service clients.Client
+
+	// selfUserID is the user the provider authenticates as
+	selfUserID string
+
+	// observed holds the power levels seen by Observe, so Update can detect
+	// changes made in the meantime
+	observed *clients.PowerLevelContent
+
+	// roomID is the room whose power levels are managed, as resolved by
+	// Observe
+	roomID string
 }
 
 func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
@@ -125,7 +175,28 @@ func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 		return managed.ExternalObservation{}, errors.New(errNotPowerLevel)
 	}
 
-	roomID := cr.Spec.ForProvider.RoomID
+	// Every room has power levels, so they only stop existing as far as the
+	// resource is concerned once Delete has reset them
+	if meta.WasDeleted(cr) && cr.Status.AtProvider.Reset {
+		return managed.ExternalObservation{
+			ResourceExists: false,
+		}, nil
+	}
+
+	roomID := c.targetRoom(ctx, cr)
+	if ensureJoined(cr) {
+		joined, err := membership.Observe(ctx, c.service, cr, roomID)
+		if err != nil {
+			return managed.ExternalObservation{}, err
+		}
+		// The power levels can't be relied on until Update has rejoined the
+		// room. Every room has power levels, so they are known to exist.
+		if !joined {
+			metrics.RecordDrift(v1alpha1.PowerLevelKind, []string{"ensureJoined"})
+			return managed.ExternalObservation{ResourceExists: true}, nil
+		}
+	}
+
 	powerLevels, err := c.service.GetPowerLevels(ctx, roomID)
 	if err != nil {
 		if clients.IsNotFound(err) {
@@ -136,12 +207,51 @@ func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 		return managed.ExternalObservation{}, errors.Wrap(err, errGetPowerLevels)
 	}
 
+	creators, err := c.service.GetPrivilegedCreators(ctx, roomID)
+	if err != nil {
+		return managed.ExternalObservation{}, errors.Wrap(err, errGetCreators)
+	}
+
+	c.observed = powerLevels
+	adoptedAt := cr.Status.AtProvider.AdoptedAt
 	cr.Status.AtProvider = generatePowerLevelObservation(roomID, powerLevels)
+	cr.Status.AtProvider.ManagedBy, cr.Status.AtProvider.AdoptedAt = provenance.Observe(cr, adoptedAt)
+	cr.Status.AtProvider.PrivilegedCreators = creators
 	cr.Status.SetConditions(xpv1.Available())
 
+	// The join rules only inform the MembershipFrozen condition, so failing
+	// to read them doesn't fail the observation
+	if joinRules, err := c.service.GetJoinRules(ctx, roomID); err == nil {
+		cr.Status.AtProvider.JoinRules = joinRules
+		if invite, highest, frozen := membershipFrozen(joinRules, powerLevels, creators); frozen {
+			cr.Status.SetConditions(v1alpha1.MembershipFrozen(joinRules, invite, highest))
+		} else if cr.Status.GetCondition(v1alpha1.TypeMembershipFrozen).Status == corev1.ConditionTrue {
+			cr.Status.SetConditions(v1alpha1.MembershipOpen())
+		}
+	}
+
+	desired := observeonly.Desired(cr)
+	drift, err := powerLevelDrift(desired, powerLevels)
+	if err != nil {
+		return managed.ExternalObservation{}, err
+	}
+
+	// Power levels that lock the provider out of the room could only be
+	// undone by someone else, so they are withheld rather than applied
+	var lockout *clients.PowerLevelLockoutError
+	if err := c.checkLockout(desired, powerLevels, creators, drift); errors.As(err, &lockout) {
+		cr.Status.SetConditions(v1alpha1.SelfLockoutBlocked(lockout.UserID, lockout.Field, lockout.Level, lockout.Required))
+		drift = nil
+	} else if err != nil {
+		return managed.ExternalObservation{}, err
+	} else if cr.Status.GetCondition(v1alpha1.TypeSelfLockoutBlocked).Status == corev1.ConditionTrue {
+		cr.Status.SetConditions(v1alpha1.SelfLockoutNotBlocked())
+	}
+	metrics.RecordDrift(v1alpha1.PowerLevelKind, drift)
+
 	return managed.ExternalObservation{
 		ResourceExists:   true,
-		ResourceUpToDate: isPowerLevelUpToDate(cr, powerLevels),
+		ResourceUpToDate: len(drift) == 0,
 	}, nil
 }
 
@@ -151,14 +261,25 @@ func (c *external) Create(ctx context.Context, mg resource.Managed) (managed.Ext
 		return managed.ExternalCreation{}, errors.New(errNotPowerLevel)
 	}
 
-	powerLevelSpec := generatePowerLevelSpec(cr)
-	err := c.service.SetPowerLevels(ctx, cr.Spec.ForProvider.RoomID, powerLevelSpec)
+	powerLevelSpec, err := generatePowerLevelSpec(cr)
+	if err != nil {
+		return managed.ExternalCreation{}, err
+	}
+	if c.roomID != "" {
+		powerLevelSpec.RoomID = c.roomID
+	}
+	if ensureJoined(cr) {
+		if err := membership.Ensure(ctx, c.service, cr, powerLevelSpec.RoomID); err != nil {
+			return managed.ExternalCreation{}, err
+		}
+	}
+	err = c.service.SetPowerLevels(ctx, powerLevelSpec.RoomID, powerLevelSpec)
 	if err != nil {
 		return managed.ExternalCreation{}, errors.Wrap(err, errSetPowerLevels)
 	}
 
 	// Use room ID as external name since power levels are bound to a room
-	meta.SetExternalName(cr, cr.Spec.ForProvider.RoomID)
+	meta.SetExternalName(cr, powerLevelSpec.RoomID)
 
 	return managed.ExternalCreation{}, nil
 }
@@ -169,8 +290,20 @@ func (c *external) Update(ctx context.Context, mg resource.Managed) (managed.Ext
 		return managed.ExternalUpdate{}, errors.New(errNotPowerLevel)
 	}
 
-	powerLevelSpec := generatePowerLevelSpec(cr)
-	err := c.service.SetPowerLevels(ctx, cr.Spec.ForProvider.RoomID, powerLevelSpec)
+	powerLevelSpec, err := generatePowerLevelSpec(observeonly.Desired(cr))
+	if err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+	if c.roomID != "" {
+		powerLevelSpec.RoomID = c.roomID
+	}
+	if ensureJoined(cr) {
+		if err := membership.Ensure(ctx, c.service, cr, powerLevelSpec.RoomID); err != nil {
+			return managed.ExternalUpdate{}, err
+		}
+	}
+	powerLevelSpec.Previous = c.observed
+	err = c.service.SetPowerLevels(ctx, powerLevelSpec.RoomID, powerLevelSpec)
 	if err != nil {
 		return managed.ExternalUpdate{}, errors.Wrap(err, errSetPowerLevels)
 	}
@@ -179,8 +312,22 @@ func (c *external) Update(ctx context.Context, mg resource.Managed) (managed.Ext
 }
 
 func (c *external) Delete(ctx context.Context, mg resource.Managed) (managed.ExternalDelete, error) {
-	// Power levels cannot be deleted, only reset to defaults
-	// For now, we'll just mark the resource as successfully deleted
+	cr, ok := mg.(*v1alpha1.PowerLevel)
+	if !ok {
+		return managed.ExternalDelete{}, errors.New(errNotPowerLevel)
+	}
+
+	// Power levels cannot be deleted, only reset to defaults, which revokes
+	// the levels this PowerLevel handed out
+	roomID := cr.Spec.ForProvider.RoomID
+	if c.roomID != "" {
+		roomID = c.roomID
+	}
+	err := c.service.ResetPowerLevels(ctx, roomID, usersOnly(cr))
+	if err != nil && !clients.IsNotFound(err) {
+		return managed.ExternalDelete{}, errors.Wrap(err, errResetPowerLevels)
+	}
+	cr.Status.AtProvider.Reset = true
 	return managed.ExternalDelete{}, nil
 }
 
@@ -189,17 +336,60 @@ func (c *external) Disconnect(ctx context.Context) error {
 	return nil // No special disconnect logic needed
 }
 
+// targetRoom returns the room whose power levels are managed: the spec's
+// room, or the room that replaced it if it was upgraded and upgrades are
+// followed. An upgrade is reported by the RoomUpgraded condition.
+func (c *external) targetRoom(ctx context.Context, cr *v1alpha1.PowerLevel) string {
+	follow := cr.Spec.ForProvider.FollowRoomUpgrades != nil && *cr.Spec.ForProvider.FollowRoomUpgrades
+	c.roomID = upgrade.TargetRoom(ctx, c.service, cr, cr.Spec.ForProvider.RoomID, follow)
+	return c.roomID
+}
+
+// checkLockout returns a *clients.PowerLevelLockoutError if applying the spec
+// to the observed power levels would lock the provider out of the room. Power
+// levels without drift are left as they are, so they can't lock it out.
+func (c *external) checkLockout(cr *v1alpha1.PowerLevel, observed *clients.PowerLevelContent, creators []string, drift []string) error {
+	if len(drift) == 0 {
+		return nil
+	}
+	spec, err := generatePowerLevelSpec(cr)
+	if err != nil {
+		return err
+	}
+	return clients.CheckPowerLevelLockout(observed, appliedPowerLevels(observed, spec.PowerLevels), c.selfUserID, creators)
+}
+
 // Helper functions
 
-func generatePowerLevelSpec(cr *v1alpha1.PowerLevel) *clients.PowerLevelSpec {
+func ensureJoined(cr *v1alpha1.PowerLevel) bool {
+	return cr.Spec.ForProvider.EnsureJoined != nil && *cr.Spec.ForProvider.EnsureJoined
+}
+
+func usersOnly(cr *v1alpha1.PowerLevel) bool {
+	return cr.Spec.ForProvider.UsersOnly != nil && *cr.Spec.ForProvider.UsersOnly
+}
+
+func generatePowerLevelSpec(cr *v1alpha1.PowerLevel) (*clients.PowerLevelSpec, error) {
+	users, err := desiredUsers(cr.Spec.ForProvider)
+	if err != nil {
+		return nil, err
+	}
+
 	spec := &clients.PowerLevelSpec{
 		RoomID: cr.Spec.ForProvider.RoomID,
 		PowerLevels: &clients.PowerLevelContent{
-			Users:  cr.Spec.ForProvider.Users,
-			Events: cr.Spec.ForProvider.Events,
+			Users: users,
 		},
 	}
 
+	// Leaving every other field unset makes SetPowerLevels keep the room's
+	// current values for them
+	if usersOnly(cr) {
+		return spec, nil
+	}
+
+	spec.PowerLevels.Events = cr.Spec.ForProvider.Events
+
 	if cr.Spec.ForProvider.EventsDefault != nil {
 		spec.PowerLevels.EventsDefault = cr.Spec.ForProvider.EventsDefault
 	}
@@ -222,99 +412,173 @@ func generatePowerLevelSpec(cr *v1alpha1.PowerLevel) *clients.PowerLevelSpec {
 		spec.PowerLevels.Invite = cr.Spec.ForProvider.Invite
 	}
 
-	return spec
+	return spec, nil
 }
 
-func generatePowerLevelObservation(roomID string, powerLevels *clients.PowerLevelContent) v1alpha1.PowerLevelObservation {
-	obs := v1alpha1.PowerLevelObservation{
-		RoomID:       roomID,
-		Users:        powerLevels.Users,
-		Events:       powerLevels.Events,
-		LastModified: &metav1.Time{Time: time.Now()},
+// desiredUsers returns the user power levels a PowerLevel asks for, with its
+// roles expanded. It is nil when neither Users nor Roles is set, leaving the
+// room's user power levels alone.
+func desiredUsers(p v1alpha1.PowerLevelParameters) (map[string]int, error) {
+	if p.Users == nil && p.Roles == nil {
+		return nil, nil
 	}
 
-	if powerLevels.EventsDefault != nil {
-		obs.EventsDefault = *powerLevels.EventsDefault
-	}
-	if powerLevels.StateDefault != nil {
-		obs.StateDefault = *powerLevels.StateDefault
-	}
-	if powerLevels.UsersDefault != nil {
-		obs.UsersDefault = *powerLevels.UsersDefault
-	}
-	if powerLevels.Ban != nil {
-		obs.Ban = *powerLevels.Ban
-	}
-	if powerLevels.Kick != nil {
-		obs.Kick = *powerLevels.Kick
-	}
-	if powerLevels.Redact != nil {
-		obs.Redact = *powerLevels.Redact
+	users := make(map[string]int, len(p.Users)+len(p.Roles))
+	for userID, role := range p.Roles {
+		level, ok := p.RoleLevels[role]
+		if !ok {
+			level, ok = defaultRoleLevels[role]
+		}
+		if !ok {
+			return nil, errors.Wrap(errors.Errorf("unknown role %q for user %s", role, userID), errExpandRoles)
+		}
+		users[userID] = level
 	}
-	if powerLevels.Invite != nil {
-		obs.Invite = *powerLevels.Invite
+	for userID, level := range p.Users {
+		users[userID] = level
 	}
+	return users, nil
+}
 
-	return obs
+// generatePowerLevelObservation returns the observed power levels, with
+// Matrix's defaults for the levels the room leaves out
+func generatePowerLevelObservation(roomID string, powerLevels *clients.PowerLevelContent) v1alpha1.PowerLevelObservation {
+	return v1alpha1.PowerLevelObservation{
+		RoomID:        roomID,
+		Users:         powerLevels.Users,
+		Events:        powerLevels.Events,
+		EventsDefault: clients.LevelOrDefault(powerLevels.EventsDefault, clients.DefaultEventsLevel),
+		StateDefault:  clients.LevelOrDefault(powerLevels.StateDefault, clients.DefaultStateLevel),
+		UsersDefault:  clients.LevelOrDefault(powerLevels.UsersDefault, clients.DefaultUsersLevel),
+		Ban:           clients.LevelOrDefault(powerLevels.Ban, clients.DefaultModeratorLevel),
+		Kick:          clients.LevelOrDefault(powerLevels.Kick, clients.DefaultModeratorLevel),
+		Redact:        clients.LevelOrDefault(powerLevels.Redact, clients.DefaultModeratorLevel),
+		Invite:        clients.LevelOrDefault(powerLevels.Invite, clients.DefaultInviteLevel),
+	}
 }
 
 func isPowerLevelUpToDate(cr *v1alpha1.PowerLevel, powerLevels *clients.PowerLevelContent) bool {
-	// Check user power levels
-	if len(cr.Spec.ForProvider.Users) != len(powerLevels.Users) {
-		return false
-	}
-	for userID, level := range cr.Spec.ForProvider.Users {
-		if actualLevel, exists := powerLevels.Users[userID]; !exists || actualLevel != level {
-			return false
-		}
+	drift, err := powerLevelDrift(cr, powerLevels)
+	return err == nil && len(drift) == 0
+}
+
+// powerLevelDrift returns the spec fields that differ from the observed power levels
+func powerLevelDrift(cr *v1alpha1.PowerLevel, powerLevels *clients.PowerLevelContent) ([]string, error) {
+	var drift []string
+	p := cr.Spec.ForProvider
+
+	users, err := desiredUsers(p)
+	if err != nil {
+		return nil, err
 	}
 
-	// Check event power levels
-	if len(cr.Spec.ForProvider.Events) != len(powerLevels.Events) {
-		return false
+	// Check user power levels. Privileged creators cannot be given a power
+	// level, so any entries for them are ignored.
+	if users != nil && !levelsEqual(withoutUsers(users, cr.Status.AtProvider.PrivilegedCreators), powerLevels.Users) {
+		drift = append(drift, "users")
 	}
-	for eventType, level := range cr.Spec.ForProvider.Events {
-		if actualLevel, exists := powerLevels.Events[eventType]; !exists || actualLevel != level {
-			return false
-		}
+
+	if usersOnly(cr) {
+		return drift, nil
 	}
 
-	// Check default levels
-	if cr.Spec.ForProvider.EventsDefault != nil && powerLevels.EventsDefault != nil {
-		if *cr.Spec.ForProvider.EventsDefault != *powerLevels.EventsDefault {
-			return false
+	// Check event power levels
+	if p.Events != nil && !levelsEqual(p.Events, powerLevels.Events) {
+		drift = append(drift, "events")
+	}
+
+	// Check default levels. A level the room leaves out has Matrix's
+	// default, so a spec asking for another level has drifted.
+	defaults := []struct {
+		field    string
+		desired  *int
+		observed int
+	}{
+		{"eventsDefault", p.EventsDefault, clients.LevelOrDefault(powerLevels.EventsDefault, clients.DefaultEventsLevel)},
+		{"stateDefault", p.StateDefault, clients.LevelOrDefault(powerLevels.StateDefault, clients.DefaultStateLevel)},
+		{"usersDefault", p.UsersDefault, clients.LevelOrDefault(powerLevels.UsersDefault, clients.DefaultUsersLevel)},
+		{"ban", p.Ban, clients.LevelOrDefault(powerLevels.Ban, clients.DefaultModeratorLevel)},
+		{"kick", p.Kick, clients.LevelOrDefault(powerLevels.Kick, clients.DefaultModeratorLevel)},
+		{"redact", p.Redact, clients.LevelOrDefault(powerLevels.Redact, clients.DefaultModeratorLevel)},
+		{"invite", p.Invite, clients.LevelOrDefault(powerLevels.Invite, clients.DefaultInviteLevel)},
+	}
+	for _, d := range defaults {
+		if d.desired != nil && *d.desired != d.observed {
+			drift = append(drift, d.field)
 		}
 	}
-	if cr.Spec.ForProvider.StateDefault != nil && powerLevels.StateDefault != nil {
-		if *cr.Spec.ForProvider.StateDefault != *powerLevels.StateDefault {
-			return false
+
+	return drift, nil
+}
+
+// appliedPowerLevels returns the power levels that applying desired to
+// observed results in, the same way SetPowerLevels applies them
+func appliedPowerLevels(observed, desired *clients.PowerLevelContent) *clients.PowerLevelContent {
+	applied := *observed
+	if desired.Users != nil {
+		applied.Users = desired.Users
+	}
+	if desired.Events != nil {
+		applied.Events = desired.Events
+	}
+	fields := []struct {
+		applied **int
+		desired *int
+	}{
+		{&applied.EventsDefault, desired.EventsDefault},
+		{&applied.StateDefault, desired.StateDefault},
+		{&applied.UsersDefault, desired.UsersDefault},
+		{&applied.Ban, desired.Ban},
+		{&applied.Kick, desired.Kick},
+		{&applied.Redact, desired.Redact},
+		{&applied.Invite, desired.Invite},
+	}
+	for _, f := range fields {
+		if f.desired != nil {
+			*f.applied = f.desired
 		}
 	}
-	if cr.Spec.ForProvider.UsersDefault != nil && powerLevels.UsersDefault != nil {
-		if *cr.Spec.ForProvider.UsersDefault != *powerLevels.UsersDefault {
-			return false
-		}
+	return &applied
+}
+
+// levelsEqual reports whether two power level maps hold the same entries
+func levelsEqual(desired, observed map[string]int) bool {
+	if len(desired) != len(observed) {
+		return false
 	}
-	if cr.Spec.ForProvider.Ban != nil && powerLevels.Ban != nil {
-		if *cr.Spec.ForProvider.Ban != *powerLevels.Ban {
+	for key, level := range desired {
+		if actualLevel, exists := observed[key]; !exists || actualLevel != level {
 			return false
 		}
 	}
-	if cr.Spec.ForProvider.Kick != nil && powerLevels.Kick != nil {
-		if *cr.Spec.ForProvider.Kick != *powerLevels.Kick {
-			return false
-		}
+	return true
+}
+
+// membershipFrozen reports whether nobody can invite new members to a room
+// that can only be joined by invitation, returning the room's invite level
+// and the highest power level a user has. Privileged creators can always
+// invite.
+func membershipFrozen(joinRules string, powerLevels *clients.PowerLevelContent, creators []string) (int, int, bool) {
+	invite := clients.LevelOrDefault(powerLevels.Invite, clients.DefaultInviteLevel)
+	highest := clients.LevelOrDefault(powerLevels.UsersDefault, clients.DefaultUsersLevel)
+	for _, level := range powerLevels.Users {
+		highest = max(highest, level)
 	}
-	if cr.Spec.ForProvider.Redact != nil && powerLevels.Redact != nil {
-		if *cr.Spec.ForProvider.Redact != *powerLevels.Redact {
-			return false
-		}
+
+	inviteOnly := joinRules == "invite" || joinRules == "knock"
+	return invite, highest, inviteOnly && len(creators) == 0 && invite > highest
+}
+
+// withoutUsers returns the power levels without the entries for users
+func withoutUsers(levels map[string]int, users []string) map[string]int {
+	if len(users) == 0 {
+		return levels
 	}
-	if cr.Spec.ForProvider.Invite != nil && powerLevels.Invite != nil {
-		if *cr.Spec.ForProvider.Invite != *powerLevels.Invite {
-			return false
+	filtered := make(map[string]int, len(levels))
+	for userID, level := range levels {
+		if !slices.Contains(users, userID) {
+			filtered[userID] = level
 		}
 	}
-
-	return true
+	return filtered
 }
@@ -0,0 +1,643 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package powerlevel
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/common"
+	"github.com/crossplane-contrib/provider-matrix/apis/powerlevel/v1alpha1"
+	roomv1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/room/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"testing"
+	"time"
+)
+
+type mockClient struct {
+	clients.Client
+
+	replacement string
+	observed    string
+	set         string
+	spec        *clients.PowerLevelSpec
+	powerLevels *clients.PowerLevelContent
+	reset       string
+	resetUsers  bool
+
+	membership string
+	joined     string
+}
+
+func (m *mockClient) GetReplacementRoom(ctx context.Context, roomID string) (string, error) {
+	if m.replacement == "" {
+		return roomID, nil
+	}
+	return m.replacement, nil
+}
+
+func (m *mockClient) GetPowerLevels(ctx context.Context, roomID string) (*clients.PowerLevelContent, error) {
+	m.observed = roomID
+	if m.powerLevels != nil {
+		return m.powerLevels, nil
+	}
+	return &clients.PowerLevelContent{Users: map[string]int{"@alice:example.com": 50}}, nil
+}
+
+func (m *mockClient) GetPrivilegedCreators(ctx context.Context, roomID string) ([]string, error) {
+	return nil, nil
+}
+
+func (m *mockClient) GetJoinRules(ctx context.Context, roomID string) (string, error) {
+	return "", errors.New("not in room")
+}
+
+func (m *mockClient) GetMembership(ctx context.Context, roomID string) (string, error) {
+	return m.membership, nil
+}
+
+func (m *mockClient) JoinRoom(ctx context.Context, roomID string) error {
+	m.joined = roomID
+	m.membership = "join"
+	return nil
+}
+
+func (m *mockClient) SetPowerLevels(ctx context.Context, roomID string, powerLevels *clients.PowerLevelSpec) error {
+	m.set = roomID
+	m.spec = powerLevels
+	return nil
+}
+
+func (m *mockClient) ResetPowerLevels(ctx context.Context, roomID string, usersOnly bool) error {
+	m.reset = roomID
+	m.resetUsers = usersOnly
+	return nil
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestPowerLevelDriftUnsetFields(t *testing.T) {
+	observed := &clients.PowerLevelContent{
+		Users:         map[string]int{"@alice:example.com": 100},
+		Events:        map[string]int{"m.room.name": 50},
+		EventsDefault: intPtr(50),
+		Ban:           intPtr(50),
+	}
+
+	tests := []struct {
+		name   string
+		params v1alpha1.PowerLevelParameters
+		want   []string
+	}{
+		{
+			name: "unset fields are not managed",
+		},
+		{
+			name:   "events default matches",
+			params: v1alpha1.PowerLevelParameters{EventsDefault: intPtr(50)},
+		},
+		{
+			name:   "events default cleared to zero",
+			params: v1alpha1.PowerLevelParameters{EventsDefault: intPtr(0)},
+			want:   []string{"eventsDefault"},
+		},
+		{
+			name:   "empty users map is enforced",
+			params: v1alpha1.PowerLevelParameters{Users: map[string]int{}},
+			want:   []string{"users"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := &v1alpha1.PowerLevel{Spec: v1alpha1.PowerLevelSpec{ForProvider: tt.params}}
+			drift, err := powerLevelDrift(cr, observed)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, drift)
+		})
+	}
+}
+
+func TestPowerLevelDriftPrivilegedCreators(t *testing.T) {
+	observed := &clients.PowerLevelContent{
+		Users: map[string]int{"@alice:example.com": 50},
+	}
+	cr := &v1alpha1.PowerLevel{Spec: v1alpha1.PowerLevelSpec{ForProvider: v1alpha1.PowerLevelParameters{
+		Users: map[string]int{"@alice:example.com": 50, "@creator:example.com": 100},
+	}}}
+
+	drift, err := powerLevelDrift(cr, observed)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"users"}, drift)
+
+	cr.Status.AtProvider.PrivilegedCreators = []string{"@creator:example.com"}
+	drift, err = powerLevelDrift(cr, observed)
+	require.NoError(t, err)
+	assert.Empty(t, drift)
+}
+
+func TestDesiredUsers(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  v1alpha1.PowerLevelParameters
+		want    map[string]int
+		wantErr bool
+	}{
+		{
+			name: "user levels not managed",
+		},
+		{
+			name: "built-in roles",
+			params: v1alpha1.PowerLevelParameters{
+				Roles: map[string]string{"@alice:example.com": "admin", "@bob:example.com": "moderator"},
+			},
+			want: map[string]int{"@alice:example.com": 100, "@bob:example.com": 50},
+		},
+		{
+			name: "custom role levels",
+			params: v1alpha1.PowerLevelParameters{
+				Roles:      map[string]string{"@alice:example.com": "helper", "@bob:example.com": "moderator"},
+				RoleLevels: map[string]int{"helper": 25, "moderator": 75},
+			},
+			want: map[string]int{"@alice:example.com": 25, "@bob:example.com": 75},
+		},
+		{
+			name: "numeric levels take precedence",
+			params: v1alpha1.PowerLevelParameters{
+				Users: map[string]int{"@alice:example.com": 90, "@carol:example.com": 10},
+				Roles: map[string]string{"@alice:example.com": "admin"},
+			},
+			want: map[string]int{"@alice:example.com": 90, "@carol:example.com": 10},
+		},
+		{
+			name: "unknown role",
+			params: v1alpha1.PowerLevelParameters{
+				Roles: map[string]string{"@alice:example.com": "owner"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := desiredUsers(tt.params)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPowerLevelDriftRoles(t *testing.T) {
+	observed := &clients.PowerLevelContent{
+		Users: map[string]int{"@alice:example.com": 100, "@bob:example.com": 50},
+	}
+	cr := &v1alpha1.PowerLevel{Spec: v1alpha1.PowerLevelSpec{ForProvider: v1alpha1.PowerLevelParameters{
+		Roles: map[string]string{"@alice:example.com": "admin", "@bob:example.com": "moderator"},
+	}}}
+
+	drift, err := powerLevelDrift(cr, observed)
+	require.NoError(t, err)
+	assert.Empty(t, drift)
+
+	cr.Spec.ForProvider.Roles["@bob:example.com"] = "user"
+	drift, err = powerLevelDrift(cr, observed)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"users"}, drift)
+}
+
+func TestPowerLevelDriftServerDefaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		params   v1alpha1.PowerLevelParameters
+		observed *clients.PowerLevelContent
+		want     []string
+	}{
+		{
+			name:     "ban set, server leaves it out",
+			params:   v1alpha1.PowerLevelParameters{Ban: intPtr(60)},
+			observed: &clients.PowerLevelContent{},
+			want:     []string{"ban"},
+		},
+		{
+			name:     "ban matches the default",
+			params:   v1alpha1.PowerLevelParameters{Ban: intPtr(50)},
+			observed: &clients.PowerLevelContent{},
+		},
+		{
+			name:     "kick and redact set, server leaves them out",
+			params:   v1alpha1.PowerLevelParameters{Kick: intPtr(0), Redact: intPtr(100)},
+			observed: &clients.PowerLevelContent{},
+			want:     []string{"kick", "redact"},
+		},
+		{
+			name:     "state default matches the default",
+			params:   v1alpha1.PowerLevelParameters{StateDefault: intPtr(50)},
+			observed: &clients.PowerLevelContent{},
+		},
+		{
+			name:     "events and users defaults set, server leaves them out",
+			params:   v1alpha1.PowerLevelParameters{EventsDefault: intPtr(10), UsersDefault: intPtr(0)},
+			observed: &clients.PowerLevelContent{},
+			want:     []string{"eventsDefault"},
+		},
+		{
+			name:     "ban unset, server sets it",
+			observed: &clients.PowerLevelContent{Ban: intPtr(75), StateDefault: intPtr(100)},
+		},
+		{
+			name:     "ban set, server sets another",
+			params:   v1alpha1.PowerLevelParameters{Ban: intPtr(50)},
+			observed: &clients.PowerLevelContent{Ban: intPtr(75)},
+			want:     []string{"ban"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := &v1alpha1.PowerLevel{Spec: v1alpha1.PowerLevelSpec{ForProvider: tt.params}}
+			drift, err := powerLevelDrift(cr, tt.observed)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, drift)
+			assert.Equal(t, len(tt.want) == 0, isPowerLevelUpToDate(cr, tt.observed))
+		})
+	}
+}
+
+func TestPowerLevelDriftInviteUnset(t *testing.T) {
+	// The room's power levels leave out invite, so it is Matrix's default of 0
+	observed := &clients.PowerLevelContent{}
+
+	tests := []struct {
+		name   string
+		invite *int
+		want   []string
+	}{
+		{
+			name: "invite not managed",
+		},
+		{
+			name:   "invite matches the default",
+			invite: intPtr(0),
+		},
+		{
+			name:   "invite differs from the default",
+			invite: intPtr(50),
+			want:   []string{"invite"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := &v1alpha1.PowerLevel{Spec: v1alpha1.PowerLevelSpec{ForProvider: v1alpha1.PowerLevelParameters{Invite: tt.invite}}}
+			drift, err := powerLevelDrift(cr, observed)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, drift)
+		})
+	}
+}
+
+func TestMembershipFrozen(t *testing.T) {
+	tests := []struct {
+		name        string
+		joinRules   string
+		powerLevels *clients.PowerLevelContent
+		creators    []string
+		want        bool
+	}{
+		{
+			name:      "moderators can invite",
+			joinRules: "invite",
+			powerLevels: &clients.PowerLevelContent{
+				Users:  map[string]int{"@alice:example.com": 100},
+				Invite: intPtr(50),
+			},
+		},
+		{
+			name:      "invite level above everyone",
+			joinRules: "invite",
+			powerLevels: &clients.PowerLevelContent{
+				Users:  map[string]int{"@alice:example.com": 50},
+				Invite: intPtr(100),
+			},
+			want: true,
+		},
+		{
+			name:      "knocks can't be accepted either",
+			joinRules: "knock",
+			powerLevels: &clients.PowerLevelContent{
+				Users:  map[string]int{"@alice:example.com": 50},
+				Invite: intPtr(100),
+			},
+			want: true,
+		},
+		{
+			name:      "public rooms don't need invites",
+			joinRules: "public",
+			powerLevels: &clients.PowerLevelContent{
+				Users:  map[string]int{"@alice:example.com": 50},
+				Invite: intPtr(100),
+			},
+		},
+		{
+			name:      "privileged creators can always invite",
+			joinRules: "invite",
+			powerLevels: &clients.PowerLevelContent{
+				Users:  map[string]int{"@alice:example.com": 50},
+				Invite: intPtr(100),
+			},
+			creators: []string{"@creator:example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, frozen := membershipFrozen(tt.joinRules, tt.powerLevels, tt.creators)
+			assert.Equal(t, tt.want, frozen)
+		})
+	}
+}
+
+func TestObserveSelfLockout(t *testing.T) {
+	tests := []struct {
+		name   string
+		params v1alpha1.PowerLevelParameters
+		want   bool
+	}{
+		{
+			name:   "users without the provider",
+			params: v1alpha1.PowerLevelParameters{Users: map[string]int{"@alice:example.com": 100}},
+			want:   true,
+		},
+		{
+			name:   "state default above the provider",
+			params: v1alpha1.PowerLevelParameters{StateDefault: intPtr(100)},
+			want:   true,
+		},
+		{
+			name:   "provider keeps its level",
+			params: v1alpha1.PowerLevelParameters{Users: map[string]int{"@alice:example.com": 100, "@bot:example.com": 100}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mockClient{powerLevels: &clients.PowerLevelContent{
+				Users:        map[string]int{"@alice:example.com": 50, "@bot:example.com": 75},
+				StateDefault: intPtr(50),
+			}}
+			e := &external{service: m, selfUserID: "@bot:example.com"}
+			cr := &v1alpha1.PowerLevel{Spec: v1alpha1.PowerLevelSpec{ForProvider: tt.params}}
+			cr.Spec.ForProvider.RoomID = "!room:example.com"
+
+			obs, err := e.Observe(context.Background(), cr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, obs.ResourceUpToDate)
+			blocked := cr.Status.GetCondition(v1alpha1.TypeSelfLockoutBlocked)
+			assert.Equal(t, tt.want, blocked.Status == corev1.ConditionTrue)
+
+			// Once the spec no longer locks the provider out, the condition
+			// is cleared
+			if tt.want {
+				cr.Spec.ForProvider = v1alpha1.PowerLevelParameters{RoomID: "!room:example.com", Users: m.powerLevels.Users}
+				_, err := e.Observe(context.Background(), cr)
+				require.NoError(t, err)
+				assert.Equal(t, corev1.ConditionFalse, cr.Status.GetCondition(v1alpha1.TypeSelfLockoutBlocked).Status)
+			}
+		})
+	}
+}
+
+func TestDeleteResetsPowerLevels(t *testing.T) {
+	follow := true
+	m := &mockClient{replacement: "!new:example.com"}
+	e := &external{service: m}
+	cr := &v1alpha1.PowerLevel{Spec: v1alpha1.PowerLevelSpec{ForProvider: v1alpha1.PowerLevelParameters{
+		RoomID:             "!old:example.com",
+		FollowRoomUpgrades: &follow,
+	}}}
+
+	_, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	_, err = e.Delete(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, "!new:example.com", m.reset)
+	assert.False(t, m.resetUsers)
+
+	// A PowerLevel that manages only users resets only them
+	usersOnly := true
+	cr.Spec.ForProvider.UsersOnly = &usersOnly
+	_, err = e.Delete(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, m.resetUsers)
+}
+
+func TestObserveAfterDelete(t *testing.T) {
+	m := &mockClient{}
+	e := &external{service: m}
+	cr := &v1alpha1.PowerLevel{Spec: v1alpha1.PowerLevelSpec{ForProvider: v1alpha1.PowerLevelParameters{
+		RoomID: "!room:example.com",
+	}}}
+	cr.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+
+	// Until Delete has reset them, the power levels still exist
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceExists)
+
+	_, err = e.Delete(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, "!room:example.com", m.reset)
+
+	// Once they are reset, the finalizer can be removed without resetting
+	// them again
+	m.reset = ""
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceExists)
+	assert.Empty(t, m.reset)
+}
+
+func TestFollowRoomUpgrades(t *testing.T) {
+	tests := []struct {
+		name          string
+		replacement   string
+		follow        *bool
+		wantRoom      string
+		wantCondition corev1.ConditionStatus
+	}{
+		{
+			name:          "room not upgraded",
+			wantRoom:      "!old:example.com",
+			wantCondition: corev1.ConditionUnknown,
+		},
+		{
+			name:          "upgrade reported but not followed",
+			replacement:   "!new:example.com",
+			wantRoom:      "!old:example.com",
+			wantCondition: corev1.ConditionTrue,
+		},
+		{
+			name:          "upgrade followed",
+			replacement:   "!new:example.com",
+			follow:        boolPtr(true),
+			wantRoom:      "!new:example.com",
+			wantCondition: corev1.ConditionTrue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mockClient{replacement: tt.replacement}
+			cr := &v1alpha1.PowerLevel{Spec: v1alpha1.PowerLevelSpec{ForProvider: v1alpha1.PowerLevelParameters{
+				RoomID:             "!old:example.com",
+				FollowRoomUpgrades: tt.follow,
+				Users:              map[string]int{"@alice:example.com": 100},
+			}}}
+
+			e := &external{service: m}
+			obs, err := e.Observe(context.Background(), cr)
+			require.NoError(t, err)
+			assert.False(t, obs.ResourceUpToDate)
+			assert.Equal(t, tt.wantRoom, m.observed)
+			assert.Equal(t, tt.wantRoom, cr.Status.AtProvider.RoomID)
+			assert.Equal(t, tt.wantCondition, cr.Status.GetCondition(common.TypeRoomUpgraded).Status)
+
+			_, err = e.Update(context.Background(), cr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRoom, m.set)
+		})
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestEnsureJoined(t *testing.T) {
+	m := &mockClient{membership: "leave"}
+	cr := &v1alpha1.PowerLevel{Spec: v1alpha1.PowerLevelSpec{ForProvider: v1alpha1.PowerLevelParameters{
+		RoomID:       "!room:example.com",
+		EnsureJoined: boolPtr(true),
+		Users:        map[string]int{"@alice:example.com": 50},
+	}}}
+
+	e := &external{service: m}
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceExists)
+	assert.False(t, obs.ResourceUpToDate)
+	assert.Empty(t, m.observed, "power levels must not be read before rejoining")
+	assert.Equal(t, corev1.ConditionTrue, cr.Status.GetCondition(common.TypeProviderNotJoined).Status)
+
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, "!room:example.com", m.joined)
+	assert.Equal(t, "!room:example.com", m.set)
+
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceUpToDate)
+	assert.Equal(t, corev1.ConditionFalse, cr.Status.GetCondition(common.TypeProviderNotJoined).Status)
+}
+
+func TestUsersOnly(t *testing.T) {
+	m := &mockClient{}
+	cr := &v1alpha1.PowerLevel{Spec: v1alpha1.PowerLevelSpec{ForProvider: v1alpha1.PowerLevelParameters{
+		RoomID:    "!room:example.com",
+		UsersOnly: boolPtr(true),
+		Users:     map[string]int{"@alice:example.com": 50},
+		Events:    map[string]int{"m.room.name": 100},
+		Ban:       intPtr(100),
+		Kick:      intPtr(100),
+	}}}
+
+	e := &external{service: m}
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceUpToDate, "fields other than users must not be compared")
+
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, &clients.PowerLevelContent{
+		Users: map[string]int{"@alice:example.com": 50},
+	}, m.spec.PowerLevels)
+}
+
+func TestObserveOnlyFields(t *testing.T) {
+	m := &mockClient{}
+	cr := &v1alpha1.PowerLevel{Spec: v1alpha1.PowerLevelSpec{ForProvider: v1alpha1.PowerLevelParameters{
+		RoomID:            "!room:example.com",
+		Users:             map[string]int{"@alice:example.com": 100},
+		Kick:              intPtr(75),
+		ObserveOnlyFields: []string{"users", "roles"},
+	}}}
+
+	// The users' power levels are applied when the PowerLevel is created
+	e := &external{service: m}
+	_, err := e.Create(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"@alice:example.com": 100}, m.spec.PowerLevels.Users)
+
+	// Afterwards they are observed, but neither reported as drift nor updated
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceUpToDate)
+	assert.Equal(t, map[string]int{"@alice:example.com": 50}, cr.Status.AtProvider.Users)
+
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, &clients.PowerLevelContent{Kick: intPtr(75)}, m.spec.PowerLevels)
+}
+
+func TestResolveRoomIDRef(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, roomv1alpha1.SchemeBuilder.AddToScheme(scheme))
+
+	room := &roomv1alpha1.Room{ObjectMeta: metav1.ObjectMeta{Name: "general"}}
+	meta.SetExternalName(room, "general")
+	kube := fake.NewClientBuilder().WithScheme(scheme).WithObjects(room).Build()
+
+	cr := &v1alpha1.PowerLevel{Spec: v1alpha1.PowerLevelSpec{ForProvider: v1alpha1.PowerLevelParameters{
+		RoomIDRef: &xpv1.Reference{Name: "general"},
+	}}}
+
+	// The Room hasn't been created yet, so its external name is still its
+	// own name rather than a room ID
+	require.Error(t, cr.ResolveReferences(context.Background(), kube))
+	assert.Empty(t, cr.Spec.ForProvider.RoomID)
+
+	require.NoError(t, kube.Get(context.Background(), types.NamespacedName{Name: "general"}, room))
+	meta.SetExternalName(room, "!general:example.com")
+	require.NoError(t, kube.Update(context.Background(), room))
+
+	require.NoError(t, cr.ResolveReferences(context.Background(), kube))
+	assert.Equal(t, "!general:example.com", cr.Spec.ForProvider.RoomID)
+}
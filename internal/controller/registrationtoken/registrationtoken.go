@@ -0,0 +1,283 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registrationtoken
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/registrationtoken/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane-contrib/provider-matrix/internal/connection"
+	"github.com/crossplane-contrib/provider-matrix/internal/forcedelete"
+	"github.com/crossplane-contrib/provider-matrix/internal/maintenance"
+	"github.com/crossplane-contrib/provider-matrix/internal/metrics"
+	"github.com/crossplane-contrib/provider-matrix/internal/observeonly"
+	"github.com/crossplane-contrib/provider-matrix/internal/permission"
+	"github.com/crossplane-contrib/provider-matrix/internal/resync"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/controller"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/feature"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/ratelimiter"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	"github.com/pkg/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"time"
+)
+
+const (
+	errNotRegistrationToken = "managed resource is not a RegistrationToken custom resource"
+	errTrackPCUsage         = "cannot track ProviderConfig usage"
+	errGetPC                = "cannot get ProviderConfig"
+	errGetCreds             = "cannot get credentials"
+	errNewClient            = "cannot create new Matrix client"
+	errSyncProfile          = "cannot sync the provider user's profile"
+	errGetToken             = "cannot get Matrix registration token"
+	errCreateToken          = "cannot create Matrix registration token"
+	errUpdateToken          = "cannot update Matrix registration token"
+	errDeleteToken          = "cannot delete Matrix registration token"
+	errTokenImmutable       = "cannot update Matrix registration token: the token can't be changed once created; create another RegistrationToken instead"
+)
+
+// keyToken is the connection detail key the token is published under, so
+// that it can be handed to the people who should register with it
+const keyToken = "token"
+
+// Setup adds a controller that reconciles RegistrationToken managed resources.
+func Setup(mgr ctrl.Manager, o controller.Options) error {
+	name := managed.ControllerName(v1alpha1.RegistrationTokenKind)
+
+	opts := []managed.ReconcilerOption{
+		managed.WithExternalConnector(maintenance.Connector(v1alpha1.RegistrationTokenGroupKind, connection.Connector(permission.Connector(forcedelete.Connector(&connector{
+			kube:         mgr.GetClient(),
+			usage:        clients.NewProviderConfigUsageTracker(mgr.GetClient()),
+			newServiceFn: clients.CachedClient,
+			logger:       o.Logger.WithValues("controller", name),
+		}, o.Logger.WithValues("controller", name)))))),
+		// The external name is the token, which the homeserver may generate,
+		// so it isn't initialized to the resource's name
+		managed.WithInitializers(),
+		managed.WithLogger(o.Logger.WithValues("controller", name)),
+		managed.WithPollInterval(o.PollInterval),
+		managed.WithPollIntervalHook(resync.PollIntervalHook),
+		managed.WithRecorder(nil),
+	}
+	if o.Features.Enabled(feature.EnableBetaManagementPolicies) {
+		opts = append(opts, managed.WithManagementPolicies())
+	}
+
+	r := managed.NewReconciler(mgr, resource.ManagedKind(v1alpha1.RegistrationTokenGroupVersionKind), opts...)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(name).
+		WithOptions(o.ForControllerRuntime()).
+		WithEventFilter(resource.DesiredStateChanged()).
+		For(&v1alpha1.RegistrationToken{}).
+		Complete(ratelimiter.NewReconciler(name, maintenance.Reconciler(v1alpha1.RegistrationTokenGroupKind, r), o.GlobalRateLimiter))
+}
+
+// A connector is expected to produce an ExternalClient when its Connect method
+// is called.
+type connector struct {
+	kube         client.Client
+	usage        resource.ModernTracker
+	newServiceFn func(config *clients.Config) (clients.Client, error)
+	logger       logging.Logger
+}
+
+// Connect typically produces an ExternalClient by:
+// 1. Tracking that the managed resource is using a ProviderConfig.
+// 2. Getting the managed resource's ProviderConfig.
+// 3. Getting the credentials specified by the ProviderConfig.
+// 4. Using the credentials to form a client.
+func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	if _, ok := mg.(*v1alpha1.RegistrationToken); !ok {
+		return nil, errors.New(errNotRegistrationToken)
+	}
+
+	modernManaged, ok := mg.(resource.ModernManaged)
+	if !ok {
+		return nil, errors.New("managed resource does not implement ModernManaged")
+	}
+	if err := c.usage.Track(ctx, modernManaged); err != nil {
+		return nil, errors.Wrap(err, errTrackPCUsage)
+	}
+
+	pc, err := clients.GetProviderConfig(ctx, c.kube, mg)
+	if err != nil {
+		return nil, errors.Wrap(err, errGetPC)
+	}
+	resync.Set(pc)
+
+	config, err := clients.GetConfig(ctx, c.kube, mg)
+	if err != nil {
+		return nil, errors.Wrap(err, errGetCreds)
+	}
+	config.Logger = c.logger.WithValues("providerConfig", config.ProviderConfigName)
+
+	service, err := c.newServiceFn(config)
+	if err != nil {
+		return nil, errors.Wrap(err, errNewClient)
+	}
+
+	if err := clients.VerifyConnection(ctx, c.kube, pc, service); err != nil {
+		return nil, err
+	}
+
+	if err := service.SyncProfile(ctx); err != nil {
+		return nil, errors.Wrap(err, errSyncProfile)
+	}
+
+	return &external{service: service}, nil
+}
+
+// An ExternalClient observes, then either creates, updates, or deletes an
+// external resource to ensure it reflects the managed resource's desired state.
+type external struct {
+	service clients.Client
+}
+
+func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
+	cr, ok := mg.(*v1alpha1.RegistrationToken)
+	if !ok {
+		return managed.ExternalObservation{}, errors.New(errNotRegistrationToken)
+	}
+
+	name := meta.GetExternalName(cr)
+	if name == "" {
+		return managed.ExternalObservation{
+			ResourceExists: false,
+		}, nil
+	}
+
+	token, err := c.service.GetRegistrationToken(ctx, name)
+	if err != nil {
+		if clients.IsNotFound(err) {
+			return managed.ExternalObservation{
+				ResourceExists: false,
+			}, nil
+		}
+		return managed.ExternalObservation{}, errors.Wrap(err, errGetToken)
+	}
+
+	cr.Status.AtProvider = v1alpha1.RegistrationTokenObservation{
+		Pending:   token.Pending,
+		Completed: token.Completed,
+		Valid:     token.Valid(time.Now()),
+	}
+	cr.Status.SetConditions(xpv1.Available())
+
+	drift := tokenDrift(observeonly.Desired(cr), token)
+	metrics.RecordDrift(v1alpha1.RegistrationTokenKind, drift)
+
+	return managed.ExternalObservation{
+		ResourceExists:    true,
+		ResourceUpToDate:  len(drift) == 0,
+		ConnectionDetails: managed.ConnectionDetails{keyToken: []byte(token.Token)},
+	}, nil
+}
+
+func (c *external) Create(ctx context.Context, mg resource.Managed) (managed.ExternalCreation, error) {
+	cr, ok := mg.(*v1alpha1.RegistrationToken)
+	if !ok {
+		return managed.ExternalCreation{}, errors.New(errNotRegistrationToken)
+	}
+
+	spec := generateRegistrationTokenSpec(cr)
+	if p := cr.Spec.ForProvider.Token; p != nil {
+		spec.Token = *p
+	}
+
+	token, err := c.service.CreateRegistrationToken(ctx, spec)
+	if err != nil {
+		return managed.ExternalCreation{}, errors.Wrap(err, errCreateToken)
+	}
+
+	meta.SetExternalName(cr, token.Token)
+	return managed.ExternalCreation{
+		ConnectionDetails: managed.ConnectionDetails{keyToken: []byte(token.Token)},
+	}, nil
+}
+
+func (c *external) Update(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
+	cr, ok := mg.(*v1alpha1.RegistrationToken)
+	if !ok {
+		return managed.ExternalUpdate{}, errors.New(errNotRegistrationToken)
+	}
+
+	name := meta.GetExternalName(cr)
+	if p := cr.Spec.ForProvider.Token; p != nil && *p != name {
+		return managed.ExternalUpdate{}, errors.New(errTokenImmutable)
+	}
+
+	_, err := c.service.UpdateRegistrationToken(ctx, name, generateRegistrationTokenSpec(observeonly.Desired(cr)))
+	return managed.ExternalUpdate{}, errors.Wrap(err, errUpdateToken)
+}
+
+func (c *external) Delete(ctx context.Context, mg resource.Managed) (managed.ExternalDelete, error) {
+	cr, ok := mg.(*v1alpha1.RegistrationToken)
+	if !ok {
+		return managed.ExternalDelete{}, errors.New(errNotRegistrationToken)
+	}
+
+	name := meta.GetExternalName(cr)
+	if name == "" {
+		return managed.ExternalDelete{}, nil
+	}
+
+	err := c.service.DeleteRegistrationToken(ctx, name)
+	if err != nil && !clients.IsNotFound(err) {
+		return managed.ExternalDelete{}, errors.Wrap(err, errDeleteToken)
+	}
+	return managed.ExternalDelete{}, nil
+}
+
+// Disconnect closes the external client.
+func (c *external) Disconnect(ctx context.Context) error {
+	return nil // No special disconnect logic needed
+}
+
+// generateRegistrationTokenSpec returns the uses allowed and expiry time the
+// spec asks for
+func generateRegistrationTokenSpec(cr *v1alpha1.RegistrationToken) *clients.RegistrationTokenSpec {
+	p := cr.Spec.ForProvider
+	spec := &clients.RegistrationTokenSpec{UsesAllowed: p.UsesAllowed}
+	if p.ExpiryTime != nil {
+		expiry := p.ExpiryTime.Time
+		spec.ExpiryTime = &expiry
+	}
+	return spec
+}
+
+// tokenDrift returns the spec fields that differ from the observed token
+func tokenDrift(cr *v1alpha1.RegistrationToken, token *clients.RegistrationToken) []string {
+	var drift []string
+	p := cr.Spec.ForProvider
+	if p.Token != nil && *p.Token != token.Token {
+		drift = append(drift, "token")
+	}
+	if p.UsesAllowed != nil && (token.UsesAllowed == nil || *p.UsesAllowed != *token.UsesAllowed) {
+		drift = append(drift, "usesAllowed")
+	}
+	// The homeserver keeps expiry times in milliseconds
+	if p.ExpiryTime != nil && (token.ExpiryTime == nil || p.ExpiryTime.UnixMilli() != token.ExpiryTime.UnixMilli()) {
+		drift = append(drift, "expiryTime")
+	}
+	return drift
+}
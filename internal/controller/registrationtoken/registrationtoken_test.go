@@ -0,0 +1,204 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registrationtoken
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/registrationtoken/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type mockClient struct {
+	clients.Client
+
+	tokens map[string]*clients.RegistrationToken
+}
+
+func (m *mockClient) GetRegistrationToken(ctx context.Context, token string) (*clients.RegistrationToken, error) {
+	t, ok := m.tokens[token]
+	if !ok {
+		return nil, &clients.APIError{StatusCode: http.StatusNotFound, ErrCode: "M_NOT_FOUND", Body: "{}"}
+	}
+	return t, nil
+}
+
+func (m *mockClient) CreateRegistrationToken(ctx context.Context, spec *clients.RegistrationTokenSpec) (*clients.RegistrationToken, error) {
+	token := spec.Token
+	if token == "" {
+		token = "generated"
+	}
+	m.tokens[token] = &clients.RegistrationToken{Token: token, UsesAllowed: spec.UsesAllowed, ExpiryTime: spec.ExpiryTime}
+	return m.tokens[token], nil
+}
+
+func (m *mockClient) UpdateRegistrationToken(ctx context.Context, token string, spec *clients.RegistrationTokenSpec) (*clients.RegistrationToken, error) {
+	t := m.tokens[token]
+	if spec.UsesAllowed != nil {
+		t.UsesAllowed = spec.UsesAllowed
+	}
+	if spec.ExpiryTime != nil {
+		t.ExpiryTime = spec.ExpiryTime
+	}
+	return t, nil
+}
+
+func (m *mockClient) DeleteRegistrationToken(ctx context.Context, token string) error {
+	if _, ok := m.tokens[token]; !ok {
+		return &clients.APIError{StatusCode: http.StatusNotFound, ErrCode: "M_NOT_FOUND", Body: "{}"}
+	}
+	delete(m.tokens, token)
+	return nil
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestCreateGeneratedToken(t *testing.T) {
+	m := &mockClient{tokens: map[string]*clients.RegistrationToken{}}
+	e := &external{service: m}
+	cr := &v1alpha1.RegistrationToken{Spec: v1alpha1.RegistrationTokenSpec{ForProvider: v1alpha1.RegistrationTokenParameters{
+		UsesAllowed: intPtr(5),
+	}}}
+
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceExists)
+
+	creation, err := e.Create(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, "generated", meta.GetExternalName(cr))
+	assert.Equal(t, []byte("generated"), creation.ConnectionDetails[keyToken])
+
+	m.tokens["generated"].Pending, m.tokens["generated"].Completed = 1, 2
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceExists)
+	assert.True(t, obs.ResourceUpToDate)
+	assert.Equal(t, v1alpha1.RegistrationTokenObservation{Pending: 1, Completed: 2, Valid: true}, cr.Status.AtProvider)
+
+	_, err = e.Delete(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Empty(t, m.tokens)
+
+	// A token that is already gone is deleted
+	_, err = e.Delete(context.Background(), cr)
+	require.NoError(t, err)
+}
+
+func TestObserveAndUpdate(t *testing.T) {
+	expiry := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := metav1.NewTime(expiry.Add(24 * time.Hour))
+	same := metav1.NewTime(expiry)
+
+	tests := []struct {
+		name        string
+		params      v1alpha1.RegistrationTokenParameters
+		want        bool
+		wantErr     string
+		wantUses    *int
+		wantExpires time.Time
+	}{
+		{name: "unmanaged", want: true, wantUses: intPtr(5), wantExpires: expiry},
+		{
+			name:        "matching",
+			params:      v1alpha1.RegistrationTokenParameters{Token: stringPtr("invite"), UsesAllowed: intPtr(5), ExpiryTime: &same},
+			want:        true,
+			wantUses:    intPtr(5),
+			wantExpires: expiry,
+		},
+		{
+			name:        "more uses",
+			params:      v1alpha1.RegistrationTokenParameters{UsesAllowed: intPtr(10)},
+			wantUses:    intPtr(10),
+			wantExpires: expiry,
+		},
+		{
+			name:        "expires later",
+			params:      v1alpha1.RegistrationTokenParameters{ExpiryTime: &later},
+			wantUses:    intPtr(5),
+			wantExpires: later.Time,
+		},
+		{
+			name:    "token changed",
+			params:  v1alpha1.RegistrationTokenParameters{Token: stringPtr("other")},
+			wantErr: errTokenImmutable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := &clients.RegistrationToken{Token: "invite", UsesAllowed: intPtr(5), Completed: 5, ExpiryTime: &expiry}
+			e := &external{service: &mockClient{tokens: map[string]*clients.RegistrationToken{"invite": token}}}
+			cr := &v1alpha1.RegistrationToken{Spec: v1alpha1.RegistrationTokenSpec{ForProvider: tt.params}}
+			meta.SetExternalName(cr, "invite")
+
+			obs, err := e.Observe(context.Background(), cr)
+			require.NoError(t, err)
+			assert.True(t, obs.ResourceExists)
+			assert.Equal(t, tt.want, obs.ResourceUpToDate)
+			assert.False(t, cr.Status.AtProvider.Valid, "a token with no uses left is not valid")
+
+			_, err = e.Update(context.Background(), cr)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantUses, token.UsesAllowed)
+			assert.True(t, tt.wantExpires.Equal(*token.ExpiryTime))
+		})
+	}
+}
+
+func TestObserveOnlyFields(t *testing.T) {
+	m := &mockClient{tokens: map[string]*clients.RegistrationToken{}}
+	e := &external{service: m}
+	cr := &v1alpha1.RegistrationToken{Spec: v1alpha1.RegistrationTokenSpec{ForProvider: v1alpha1.RegistrationTokenParameters{
+		UsesAllowed:       intPtr(5),
+		ExpiryTime:        &metav1.Time{Time: time.UnixMilli(1700000000000)},
+		ObserveOnlyFields: []string{"usesAllowed"},
+	}}}
+
+	_, err := e.Create(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, intPtr(5), m.tokens["generated"].UsesAllowed)
+
+	// Server admins may allow more uses, while the expiry time is still
+	// managed
+	m.tokens["generated"].UsesAllowed = intPtr(10)
+	m.tokens["generated"].ExpiryTime = nil
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceUpToDate)
+
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, intPtr(10), m.tokens["generated"].UsesAllowed)
+	assert.Equal(t, int64(1700000000000), m.tokens["generated"].ExpiryTime.UnixMilli())
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -18,21 +18,36 @@ package room
 
 import (
 	"context"
+	"encoding/json"
+	"github.com/crossplane-contrib/provider-matrix/apis/common"
 	"github.com/crossplane-contrib/provider-matrix/apis/room/v1alpha1"
-	apisv1beta1 "github.com/crossplane-contrib/provider-matrix/apis/v1beta1"
 	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane-contrib/provider-matrix/internal/connection"
+	"github.com/crossplane-contrib/provider-matrix/internal/forcedelete"
+	"github.com/crossplane-contrib/provider-matrix/internal/maintenance"
+	"github.com/crossplane-contrib/provider-matrix/internal/membership"
+	"github.com/crossplane-contrib/provider-matrix/internal/metrics"
+	"github.com/crossplane-contrib/provider-matrix/internal/observeonly"
+	"github.com/crossplane-contrib/provider-matrix/internal/permission"
+	"github.com/crossplane-contrib/provider-matrix/internal/provenance"
+	"github.com/crossplane-contrib/provider-matrix/internal/resync"
 	"github.com/crossplane/crossplane-runtime/v2/pkg/controller"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/feature"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
 	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
 	"github.com/crossplane/crossplane-runtime/v2/pkg/ratelimiter"
 	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
 	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
 	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"slices"
+	"strings"
+	"unicode/utf8"
 )
 
 const (
@@ -41,33 +56,82 @@ const (
 	errGetPC        = "cannot get ProviderConfig"
 	errGetCreds     = "cannot get credentials"
 	errNewClient    = "cannot create new Matrix client"
+	errSyncProfile  = "cannot sync the provider user's profile"
 	errCreateRoom   = "cannot create Matrix room"
 	errGetRoom      = "cannot get Matrix room"
+	errGetRoomState = "cannot get Matrix room state"
 	errUpdateRoom   = "cannot update Matrix room"
 	errDeleteRoom   = "cannot delete Matrix room"
+	errGetDeletion  = "cannot get Matrix room deletion"
+	errGetKnocks    = "cannot get pending knocks"
+	errAcceptKnock  = "cannot accept knock"
+
+	errGetForwardExtremities    = "cannot get forward extremities"
+	errDeleteForwardExtremities = "cannot delete forward extremities"
+	errResolveAlias             = "cannot resolve room alias to adopt its room"
+	errRemoveAllowReferences    = "cannot remove dangling join rule allow references"
+	errSetDirectoryNetworks     = "cannot set room visibility in directory networks"
+	errCreationContent          = "cannot decode creation content"
+	errCheckPinnedEvent         = "cannot check pinned event"
+	errGetMemberships           = "cannot get room memberships"
+	errInviteUsers              = "cannot invite users"
+	errGetRoomAdmins            = "cannot check room admins"
+	errMakeRoomAdmin            = "cannot make %s a room admin"
+	errGetRoomBlocked           = "cannot get whether the room is blocked"
+	errBlockRoom                = "cannot block or unblock the room"
+	errOverLength               = "%s is %d bytes long, more than the %d allowed; shorten it or set truncateOverLength"
+)
+
+// Connection detail keys published for a Room, so that compositions can pass
+// the room on to the resources composed alongside it
+const (
+	keyRoomID      = "roomID"
+	keyAlias       = "alias"
+	keyAltAliases  = "altAliases"
+	keyRoomVersion = "roomVersion"
+)
+
+// defaultInviteBatchSize is the most users invited per reconcile when the
+// Room doesn't set InviteBatchSize
+const defaultInviteBatchSize = 20
+
+// Length limits of a room's name and topic, in bytes. The Matrix spec caps
+// names at 255 bytes. Topics are only bound by the 65536-byte limit on a
+// whole event, which a rich topic's plain text and HTML share, so they are
+// kept well below it.
+const (
+	maxNameLength  = 255
+	maxTopicLength = 16384
 )
 
 // Setup adds a controller that reconciles Room managed resources.
 func Setup(mgr ctrl.Manager, o controller.Options) error {
 	name := managed.ControllerName(v1alpha1.RoomKind)
 
-	r := managed.NewReconciler(mgr,
-		resource.ManagedKind(v1alpha1.RoomGroupVersionKind),
-		managed.WithExternalConnector(&connector{
+	opts := []managed.ReconcilerOption{
+		managed.WithExternalConnector(maintenance.Connector(v1alpha1.RoomGroupKind, connection.Connector(permission.Connector(forcedelete.Connector(&connector{
 			kube:         mgr.GetClient(),
 			usage:        clients.NewProviderConfigUsageTracker(mgr.GetClient()),
-			newServiceFn: clients.NewClient,
-		}),
+			newServiceFn: clients.CachedClient,
+			logger:       o.Logger.WithValues("controller", name),
+		}, o.Logger.WithValues("controller", name)))))),
 		managed.WithLogger(o.Logger.WithValues("controller", name)),
 		managed.WithPollInterval(o.PollInterval),
-		managed.WithRecorder(nil))
+		managed.WithPollIntervalHook(resync.PollIntervalHook),
+		managed.WithRecorder(nil),
+	}
+	if o.Features.Enabled(feature.EnableBetaManagementPolicies) {
+		opts = append(opts, managed.WithManagementPolicies())
+	}
+
+	r := managed.NewReconciler(mgr, resource.ManagedKind(v1alpha1.RoomGroupVersionKind), opts...)
 
 	return ctrl.NewControllerManagedBy(mgr).
 		Named(name).
 		WithOptions(o.ForControllerRuntime()).
 		WithEventFilter(resource.DesiredStateChanged()).
 		For(&v1alpha1.Room{}).
-		Complete(ratelimiter.NewReconciler(name, r, o.GlobalRateLimiter))
+		Complete(ratelimiter.NewReconciler(name, maintenance.Reconciler(v1alpha1.RoomGroupKind, r), o.GlobalRateLimiter))
 }
 
 // A connector is expected to produce an ExternalClient when its Connect method
@@ -76,6 +140,7 @@ type connector struct {
 	kube         client.Client
 	usage        resource.ModernTracker
 	newServiceFn func(config *clients.Config) (clients.Client, error)
+	logger       logging.Logger
 }
 
 // Connect typically produces an ExternalClient by:
@@ -84,8 +149,7 @@ type connector struct {
 // 3. Getting the credentials specified by the ProviderConfig.
 // 4. Using the credentials to form a client.
 func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
-	cr, ok := mg.(*v1alpha1.Room)
-	if !ok {
+	if _, ok := mg.(*v1alpha1.Room); !ok {
 		return nil, errors.New(errNotRoom)
 	}
 
@@ -97,28 +161,41 @@ func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.E
 		return nil, errors.Wrap(err, errTrackPCUsage)
 	}
 
-	pc := &apisv1beta1.ProviderConfig{}
-	if err := c.kube.Get(ctx, types.NamespacedName{Name: cr.GetProviderConfigReference().Name}, pc); err != nil {
+	pc, err := clients.GetProviderConfig(ctx, c.kube, mg)
+	if err != nil {
 		return nil, errors.Wrap(err, errGetPC)
 	}
+	resync.Set(pc)
 
 	config, err := clients.GetConfig(ctx, c.kube, mg)
 	if err != nil {
 		return nil, errors.Wrap(err, errGetCreds)
 	}
+	config.Logger = c.logger.WithValues("providerConfig", config.ProviderConfigName)
 
 	service, err := c.newServiceFn(config)
 	if err != nil {
 		return nil, errors.Wrap(err, errNewClient)
 	}
 
-	return &external{service: service}, nil
+	if err := clients.VerifyConnection(ctx, c.kube, pc, service); err != nil {
+		return nil, err
+	}
+
+	if err := service.SyncProfile(ctx); err != nil {
+		return nil, errors.Wrap(err, errSyncProfile)
+	}
+
+	return &external{service: service, selfUserID: config.UserID}, nil
 }
 
 // An ExternalClient observes, then either creates, updates, or deletes an
 // external resource to ensure it reflects the managed resource's desired state.
 type external struct {
 	service clients.Client
+
+	// selfUserID is the user the provider authenticates as
+	selfUserID string
 }
 
 func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
@@ -127,11 +204,46 @@ func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 		return managed.ExternalObservation{}, errors.New(errNotRoom)
 	}
 
-	roomID := meta.GetExternalName(cr)
+	// Until the room has been created its external name is not a room ID.
+	// An existing room its alias points at is adopted instead, if asked to.
+	roomID := v1alpha1.RoomID()(cr)
+	adopted := false
 	if roomID == "" {
-		return managed.ExternalObservation{
-			ResourceExists: false,
-		}, nil
+		existing, err := c.existingRoom(ctx, cr)
+		if err != nil {
+			return managed.ExternalObservation{}, err
+		}
+		if existing == "" {
+			return managed.ExternalObservation{
+				ResourceExists: false,
+			}, nil
+		}
+		roomID, adopted = existing, true
+		meta.SetExternalName(cr, roomID)
+	}
+
+	// Rooms created on another homeserver can be managed through their
+	// state, but the admin API of the provider's homeserver doesn't know
+	// them
+	serverName, remote := clients.RemoteRoom(roomID, c.selfUserID)
+	if remote {
+		cr.Status.SetConditions(common.RemoteRoom(roomID, serverName))
+	} else if cr.Status.GetCondition(common.TypeRemoteRoom).Status == corev1.ConditionTrue {
+		cr.Status.SetConditions(common.LocalRoom())
+	}
+
+	// The homeserver deletes rooms in the background. The room is gone once
+	// its deletion completes, even if its history is kept.
+	if meta.WasDeleted(cr) {
+		deletion, err := c.service.GetRoomDeletion(ctx, roomID)
+		if err != nil {
+			return managed.ExternalObservation{}, errors.Wrap(err, errGetDeletion)
+		}
+		if deletion != nil && deletion.Status == clients.RoomDeletionComplete {
+			return managed.ExternalObservation{
+				ResourceExists: false,
+			}, nil
+		}
 	}
 
 	room, err := c.service.GetRoom(ctx, roomID)
@@ -144,30 +256,215 @@ func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 		return managed.ExternalObservation{}, errors.Wrap(err, errGetRoom)
 	}
 
+	// Only the room's summary could be observed, so the rest of its state
+	// is neither compared nor read
+	if room.Summary {
+		drift := summaryDrift(roomDrift(cr, room, c.selfUserID))
+		if ensureJoined(cr) {
+			if _, err := membership.Observe(ctx, c.service, cr, roomID); err != nil {
+				return managed.ExternalObservation{}, err
+			}
+			drift = append(drift, "ensureJoined")
+		}
+		drift = observeonly.Drift(drift, cr.Spec.ForProvider.ObserveOnlyFields)
+		metrics.RecordDrift(v1alpha1.RoomKind, drift)
+
+		adoptedAt := cr.Status.AtProvider.AdoptedAt
+		cr.Status.AtProvider = generateRoomObservation(room)
+		_, _, cr.Status.AtProvider.TruncatedFields, _ = fitLength(cr)
+		cr.Status.AtProvider.ManagedBy, cr.Status.AtProvider.AdoptedAt = provenance.Observe(cr, adoptedAt)
+		cr.Status.SetConditions(xpv1.Available())
+		return managed.ExternalObservation{
+			ResourceExists:          true,
+			ResourceUpToDate:        len(drift) == 0,
+			ResourceLateInitialized: adopted,
+		}, nil
+	}
+
+	if types := cr.Spec.ForProvider.ObservedStateTypes; len(types) > 0 {
+		room.State, err = c.service.GetRoomState(ctx, roomID, types)
+		if err != nil {
+			return managed.ExternalObservation{}, errors.Wrap(err, errGetRoomState)
+		}
+	}
+	// Only Synapse's admin API blocks rooms, and it doesn't know rooms of
+	// other homeservers. Elsewhere the block is left unobserved, and so
+	// unmanaged.
+	if cr.Spec.ForProvider.Blocked != nil && !remote {
+		blocked, err := c.service.GetRoomBlocked(ctx, roomID)
+		switch {
+		case errors.Is(err, clients.ErrUnsupportedByServer):
+		case err != nil:
+			return managed.ExternalObservation{}, errors.Wrap(err, errGetRoomBlocked)
+		default:
+			room.Blocked = &blocked
+		}
+	}
+
+	networks, adoptedAt := cr.Status.AtProvider.DirectoryNetworks, cr.Status.AtProvider.AdoptedAt
 	cr.Status.AtProvider = generateRoomObservation(room)
+	cr.Status.AtProvider.DirectoryNetworks = networks
+	_, _, cr.Status.AtProvider.TruncatedFields, _ = fitLength(cr)
+	cr.Status.AtProvider.ManagedBy, cr.Status.AtProvider.AdoptedAt = provenance.Observe(cr, adoptedAt)
+
+	drift := roomDrift(cr, room, c.selfUserID)
+	if ensureJoined(cr) {
+		joined, err := membership.Observe(ctx, c.service, cr, roomID)
+		if err != nil {
+			return managed.ExternalObservation{}, err
+		}
+		if !joined {
+			drift = append(drift, "ensureJoined")
+		}
+	}
+	if p := cr.Spec.ForProvider.DirectoryNetworks; p != nil && !sameElements(p, networks) {
+		drift = append(drift, "directoryNetworks")
+	}
+	if invite := cr.Spec.ForProvider.Invite; len(invite) > 0 {
+		memberships, err := c.service.GetMemberships(ctx, roomID)
+		if err != nil {
+			return managed.ExternalObservation{}, errors.Wrap(err, errGetMemberships)
+		}
+		total := len(uniqueUsers(invite))
+		pending := pendingInvites(invite, memberships)
+		cr.Status.AtProvider.InviteProgress = &v1alpha1.InviteProgress{Invited: total - len(pending), Total: total}
+		if len(pending) > 0 {
+			drift = append(drift, "invite")
+		}
+	}
+	if len(cr.Spec.ForProvider.KnockAutoAccept) > 0 {
+		knocks, err := c.service.GetKnocks(ctx, roomID)
+		if err != nil {
+			return managed.ExternalObservation{}, errors.Wrap(err, errGetKnocks)
+		}
+		cr.Status.AtProvider.PendingKnocks = knocks
+		if len(knocksToAccept(cr.Spec.ForProvider.KnockAutoAccept, knocks)) > 0 {
+			drift = append(drift, "knockAutoAccept")
+		}
+	}
+	if grantAdminTo := uniqueUsers(cr.Spec.ForProvider.GrantAdminTo); len(grantAdminTo) > 0 {
+		admins, err := c.service.RoomAdmins(ctx, roomID, grantAdminTo)
+		if err != nil {
+			return managed.ExternalObservation{}, errors.Wrap(err, errGetRoomAdmins)
+		}
+		cr.Status.AtProvider.Admins = admins
+		if len(admins) < len(grantAdminTo) {
+			drift = append(drift, "grantAdminTo")
+		}
+	}
+	// Forward extremities are only reported by the admin API, which doesn't
+	// know rooms of other homeservers
+	if threshold := cr.Spec.ForProvider.ForwardExtremitiesThreshold; threshold != nil && !remote {
+		extremities, err := c.service.GetForwardExtremities(ctx, roomID)
+		if err != nil {
+			return managed.ExternalObservation{}, errors.Wrap(err, errGetForwardExtremities)
+		}
+		cr.Status.AtProvider.ForwardExtremities = &extremities.Count
+		if extremities.Count > *threshold {
+			drift = append(drift, "forwardExtremitiesThreshold")
+		}
+	}
+
+	dangling := c.danglingAllowReferences(ctx, room)
+	cr.Status.AtProvider.DanglingAllowReferences = dangling
+	if len(dangling) > 0 {
+		cr.Status.SetConditions(v1alpha1.DanglingAllowReference(dangling))
+		if removeDanglingAllowReferences(cr) {
+			drift = append(drift, "removeDanglingAllowReferences")
+		}
+	} else if cr.Status.GetCondition(v1alpha1.TypeDanglingAllowReference).Status == corev1.ConditionTrue {
+		cr.Status.SetConditions(v1alpha1.AllowReferencesResolved())
+	}
+
+	if p := cr.Spec.ForProvider.PinnedEvents; p != nil {
+		invalid, err := c.invalidPinnedEvents(ctx, roomID, p)
+		if err != nil {
+			return managed.ExternalObservation{}, err
+		}
+		cr.Status.AtProvider.InvalidPinnedEvents = invalid
+		if len(invalid) > 0 {
+			cr.Status.SetConditions(v1alpha1.InvalidPinnedEvent(invalid))
+		} else if cr.Status.GetCondition(v1alpha1.TypeInvalidPinnedEvent).Status == corev1.ConditionTrue {
+			cr.Status.SetConditions(v1alpha1.PinnedEventsValid())
+		}
+		if !slices.Equal(pinnableEvents(p, invalid), room.PinnedEvents) {
+			drift = append(drift, "pinnedEvents")
+		}
+	}
+	if encryptionConflict(cr, room) {
+		cr.Status.SetConditions(v1alpha1.EncryptionConflict())
+	} else if cr.Status.GetCondition(v1alpha1.TypeEncryptionConflict).Status == corev1.ConditionTrue {
+		cr.Status.SetConditions(v1alpha1.EncryptionAsDesired())
+	}
+	if joinRules, exposed := historyExposed(cr, room); exposed {
+		cr.Status.SetConditions(v1alpha1.WorldReadableHistory(joinRules))
+	} else if cr.Status.GetCondition(v1alpha1.TypeWorldReadableHistory).Status == corev1.ConditionTrue {
+		cr.Status.SetConditions(v1alpha1.HistoryNotExposed())
+	}
+	// Observe-only fields found drifted outside roomDrift aren't drift either
+	drift = observeonly.Drift(drift, cr.Spec.ForProvider.ObserveOnlyFields)
+	metrics.RecordDrift(v1alpha1.RoomKind, drift)
+
 	cr.Status.SetConditions(xpv1.Available())
 
 	return managed.ExternalObservation{
-		ResourceExists:   true,
-		ResourceUpToDate: isRoomUpToDate(cr, room),
+		ResourceExists:          true,
+		ResourceUpToDate:        len(drift) == 0,
+		ResourceLateInitialized: adopted,
+		ConnectionDetails:       roomConnectionDetails(room),
 	}, nil
 }
 
+// existingRoom returns the ID of the room the Room's alias points at, if it
+// should be adopted rather than created, and empty if there is none.
+func (c *external) existingRoom(ctx context.Context, cr *v1alpha1.Room) (string, error) {
+	if !adoptExisting(cr) || cr.Spec.ForProvider.Alias == nil {
+		return "", nil
+	}
+
+	alias, err := c.service.GetRoomAlias(ctx, *cr.Spec.ForProvider.Alias)
+	if clients.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, errResolveAlias)
+	}
+	return alias.RoomID, nil
+}
+
 func (c *external) Create(ctx context.Context, mg resource.Managed) (managed.ExternalCreation, error) {
 	cr, ok := mg.(*v1alpha1.Room)
 	if !ok {
 		return managed.ExternalCreation{}, errors.New(errNotRoom)
 	}
 
-	roomSpec := generateRoomSpec(cr)
+	roomSpec, err := generateRoomSpec(cr)
+	if err != nil {
+		return managed.ExternalCreation{}, err
+	}
 	room, err := c.service.CreateRoom(ctx, roomSpec)
 	if err != nil {
+		var conflict *clients.AliasConflictError
+		if errors.As(err, &conflict) {
+			cr.Status.SetConditions(v1alpha1.AliasConflict(conflict.Alias, conflict.RoomID))
+		}
 		return managed.ExternalCreation{}, errors.Wrap(err, errCreateRoom)
 	}
+	if cr.Status.GetCondition(v1alpha1.TypeAliasConflict).Status == corev1.ConditionTrue {
+		cr.Status.SetConditions(v1alpha1.AliasAvailable())
+	}
 
 	meta.SetExternalName(cr, room.RoomID)
 
-	return managed.ExternalCreation{}, nil
+	if err := c.syncDirectoryNetworks(ctx, cr, cr.Spec.ForProvider.DirectoryNetworks, room.RoomID); err != nil {
+		return managed.ExternalCreation{}, err
+	}
+
+	if err := c.grantAdmin(ctx, cr, room.RoomID); err != nil {
+		return managed.ExternalCreation{}, err
+	}
+
+	return managed.ExternalCreation{ConnectionDetails: roomConnectionDetails(room)}, nil
 }
 
 func (c *external) Update(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
@@ -176,13 +473,73 @@ func (c *external) Update(ctx context.Context, mg resource.Managed) (managed.Ext
 		return managed.ExternalUpdate{}, errors.New(errNotRoom)
 	}
 
+	// Admins are granted first, as the provider's user may need the power,
+	// or the invite, to rejoin and update a room that has lost its admins
 	roomID := meta.GetExternalName(cr)
-	roomSpec := generateRoomSpec(cr)
-	_, err := c.service.UpdateRoom(ctx, roomID, roomSpec)
+	desired := observeonly.Desired(cr)
+	if err := c.grantAdmin(ctx, desired, roomID); err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+	if p := desired.Spec.ForProvider.Blocked; p != nil && cr.Status.AtProvider.Blocked != nil && *p != *cr.Status.AtProvider.Blocked {
+		if err := c.service.BlockRoom(ctx, roomID, *p); err != nil {
+			return managed.ExternalUpdate{}, errors.Wrap(err, errBlockRoom)
+		}
+	}
+	if ensureJoined(desired) {
+		if err := membership.Ensure(ctx, c.service, cr, roomID); err != nil {
+			return managed.ExternalUpdate{}, err
+		}
+	}
+	roomSpec, err := generateRoomSpec(desired)
+	if err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+	room, err := c.service.UpdateRoom(ctx, roomID, roomSpec)
 	if err != nil {
 		return managed.ExternalUpdate{}, errors.Wrap(err, errUpdateRoom)
 	}
 
+	if err := c.syncDirectoryNetworks(ctx, cr, desired.Spec.ForProvider.DirectoryNetworks, roomID); err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+
+	if removeDanglingAllowReferences(cr) {
+		if dangling := c.danglingAllowReferences(ctx, room); len(dangling) > 0 {
+			if err := c.service.RemoveJoinRuleAllow(ctx, roomID, dangling); err != nil {
+				return managed.ExternalUpdate{}, errors.Wrap(err, errRemoveAllowReferences)
+			}
+		}
+	}
+
+	if accept := desired.Spec.ForProvider.KnockAutoAccept; len(accept) > 0 {
+		knocks, err := c.service.GetKnocks(ctx, roomID)
+		if err != nil {
+			return managed.ExternalUpdate{}, errors.Wrap(err, errGetKnocks)
+		}
+		for _, userID := range knocksToAccept(accept, knocks) {
+			if err := c.service.AcceptKnock(ctx, roomID, userID); err != nil {
+				return managed.ExternalUpdate{}, errors.Wrap(err, errAcceptKnock)
+			}
+		}
+	}
+
+	if err := c.inviteUsers(ctx, cr, desired.Spec.ForProvider.Invite, roomID); err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+
+	_, remote := clients.RemoteRoom(roomID, c.selfUserID)
+	if threshold := cr.Spec.ForProvider.ForwardExtremitiesThreshold; threshold != nil && !remote {
+		extremities, err := c.service.GetForwardExtremities(ctx, roomID)
+		if err != nil {
+			return managed.ExternalUpdate{}, errors.Wrap(err, errGetForwardExtremities)
+		}
+		if extremities.Count > *threshold {
+			if _, err := c.service.DeleteForwardExtremities(ctx, roomID); err != nil {
+				return managed.ExternalUpdate{}, errors.Wrap(err, errDeleteForwardExtremities)
+			}
+		}
+	}
+
 	return managed.ExternalUpdate{}, nil
 }
 
@@ -197,7 +554,12 @@ func (c *external) Delete(ctx context.Context, mg resource.Managed) (managed.Ext
 		return managed.ExternalDelete{}, nil
 	}
 
-	return managed.ExternalDelete{}, errors.Wrap(c.service.DeleteRoom(ctx, roomID), errDeleteRoom)
+	opts := clients.DeleteRoomOptions{}
+	if cr.Spec.ForProvider.PurgeOnDelete != nil {
+		opts.Purge = *cr.Spec.ForProvider.PurgeOnDelete
+	}
+
+	return managed.ExternalDelete{}, errors.Wrap(c.service.DeleteRoom(ctx, roomID, opts), errDeleteRoom)
 }
 
 // Disconnect closes the external client.
@@ -207,18 +569,28 @@ func (c *external) Disconnect(ctx context.Context) error {
 
 // Helper functions
 
-func generateRoomSpec(cr *v1alpha1.Room) *clients.RoomSpec {
+func generateRoomSpec(cr *v1alpha1.Room) (*clients.RoomSpec, error) {
 	spec := &clients.RoomSpec{}
 
-	if cr.Spec.ForProvider.Name != nil {
-		spec.Name = *cr.Spec.ForProvider.Name
+	name, topic, _, err := fitLength(cr)
+	if err != nil {
+		return nil, err
+	}
+	spec.Name = name
+	if topic != nil {
+		spec.Topic = *topic
 	}
-	if cr.Spec.ForProvider.Topic != nil {
-		spec.Topic = *cr.Spec.ForProvider.Topic
+	if cr.Spec.ForProvider.RichTopic != nil {
+		spec.RichTopic = *cr.Spec.ForProvider.RichTopic
+	}
+	if cr.Spec.ForProvider.TopicHTML != nil {
+		spec.TopicHTML = *cr.Spec.ForProvider.TopicHTML
 	}
 	if cr.Spec.ForProvider.Alias != nil {
 		spec.Alias = *cr.Spec.ForProvider.Alias
 	}
+	spec.AltAliases = cr.Spec.ForProvider.AltAliases
+	spec.SetAliasAsCanonical = cr.Spec.ForProvider.SetAliasAsCanonical
 	if cr.Spec.ForProvider.Preset != nil {
 		spec.Preset = *cr.Spec.ForProvider.Preset
 	}
@@ -229,13 +601,19 @@ func generateRoomSpec(cr *v1alpha1.Room) *clients.RoomSpec {
 		spec.RoomVersion = *cr.Spec.ForProvider.RoomVersion
 	}
 
-	// Convert CreationContent from RawExtension to map
-	if cr.Spec.ForProvider.CreationContent != nil {
-		// For now, skip conversion and leave as nil - CreationContent is rarely used
-		// TODO: Implement proper RawExtension to map conversion if needed
-		spec.CreationContent = nil
+	if cr.Spec.ForProvider.CreationContent != nil && len(cr.Spec.ForProvider.CreationContent.Raw) > 0 {
+		if err := json.Unmarshal(cr.Spec.ForProvider.CreationContent.Raw, &spec.CreationContent); err != nil {
+			return nil, errors.Wrap(err, errCreationContent)
+		}
+	}
+
+	for _, invite := range cr.Spec.ForProvider.Invite3PID {
+		spec.Invite3PID = append(spec.Invite3PID, clients.ThirdPartyInvite{
+			Medium:   invite.Medium,
+			Address:  invite.Address,
+			IDServer: invite.IDServer,
+		})
 	}
-	spec.Invite = cr.Spec.ForProvider.Invite
 
 	// Convert initial state
 	for _, state := range cr.Spec.ForProvider.InitialState {
@@ -272,14 +650,19 @@ func generateRoomSpec(cr *v1alpha1.Room) *clients.RoomSpec {
 	if cr.Spec.ForProvider.JoinRules != nil {
 		spec.JoinRules = *cr.Spec.ForProvider.JoinRules
 	}
+	spec.JoinRuleAllow = joinRuleAllow(cr)
 	if cr.Spec.ForProvider.EncryptionEnabled != nil {
 		spec.EncryptionEnabled = *cr.Spec.ForProvider.EncryptionEnabled
 	}
 	if cr.Spec.ForProvider.AvatarURL != nil {
 		spec.AvatarURL = *cr.Spec.ForProvider.AvatarURL
 	}
+	// Observe has found which events can't be pinned
+	if cr.Spec.ForProvider.PinnedEvents != nil {
+		spec.PinnedEvents = pinnableEvents(cr.Spec.ForProvider.PinnedEvents, cr.Status.AtProvider.InvalidPinnedEvents)
+	}
 
-	return spec
+	return spec, nil
 }
 
 func generateRoomObservation(room *clients.Room) v1alpha1.RoomObservation {
@@ -287,31 +670,45 @@ func generateRoomObservation(room *clients.Room) v1alpha1.RoomObservation {
 		RoomID:            room.RoomID,
 		Name:              room.Name,
 		Topic:             room.Topic,
+		RichTopic:         room.RichTopic,
+		TopicHTML:         room.TopicHTML,
 		Alias:             room.Alias,
+		AltAliases:        room.AltAliases,
 		AvatarURL:         room.AvatarURL,
 		Creator:           room.Creator,
 		RoomVersion:       room.RoomVersion,
 		JoinedMembers:     room.JoinedMembers,
 		InvitedMembers:    room.InvitedMembers,
+		StateEventCount:   room.StateEvents,
 		Visibility:        room.Visibility,
 		GuestAccess:       room.GuestAccess,
 		HistoryVisibility: room.HistoryVisibility,
 		JoinRules:         room.JoinRules,
+		PubliclyJoinable:  room.Visibility == "public" && room.JoinRules == "public",
 		EncryptionEnabled: room.EncryptionEnabled,
+		JoinRuleAllow:     room.JoinRuleAllow,
+		PinnedEvents:      room.PinnedEvents,
+		Blocked:           room.Blocked,
 	}
 
 	if room.CreationTime != nil {
 		obs.CreationTime = &metav1.Time{Time: *room.CreationTime}
 	}
 
-	// Convert state events
+	if room.Predecessor != nil {
+		obs.Predecessor = &v1alpha1.RoomPredecessor{
+			RoomID:  room.Predecessor.RoomID,
+			EventID: room.Predecessor.EventID,
+		}
+	}
+
 	for _, state := range room.State {
-		// For now, skip Content conversion - State events are rarely observed
-		// TODO: Implement proper map to RawExtension conversion if needed
+		// The content was decoded from JSON, so it can always be encoded
+		content, _ := json.Marshal(state.Content)
 		obs.State = append(obs.State, v1alpha1.StateEvent{
 			Type:     state.Type,
 			StateKey: state.StateKey,
-			Content:  runtime.RawExtension{}, // Empty content for now
+			Content:  runtime.RawExtension{Raw: content},
 		})
 	}
 
@@ -328,51 +725,446 @@ func generateRoomObservation(room *clients.Room) v1alpha1.RoomObservation {
 			Redact:        room.PowerLevels.Redact,
 			Invite:        room.PowerLevels.Invite,
 		}
+
+		obs.EffectivePowerLevels = make(map[string]int, len(room.PowerLevels.Users))
+		for userID, level := range room.PowerLevels.Users {
+			if !slices.Contains(room.PrivilegedCreators, userID) {
+				obs.EffectivePowerLevels[userID] = level
+			}
+		}
 	}
+	obs.PrivilegedCreators = room.PrivilegedCreators
 
 	return obs
 }
 
-func isRoomUpToDate(cr *v1alpha1.Room, room *clients.Room) bool {
-	// Check name
-	if cr.Spec.ForProvider.Name != nil && *cr.Spec.ForProvider.Name != room.Name {
-		return false
+// syncDirectoryNetworks lists the room in the directory of each of the
+// desired networks and unlists it from networks it was previously listed in,
+// recording the result in the status
+func (c *external) syncDirectoryNetworks(ctx context.Context, cr *v1alpha1.Room, desired []string, roomID string) error {
+	if desired == nil {
+		return nil
 	}
 
-	// Check topic
-	if cr.Spec.ForProvider.Topic != nil && *cr.Spec.ForProvider.Topic != room.Topic {
-		return false
+	for _, networkID := range cr.Status.AtProvider.DirectoryNetworks {
+		if slices.Contains(desired, networkID) {
+			continue
+		}
+		if err := c.service.SetDirectoryNetworkVisibility(ctx, networkID, roomID, "private"); err != nil {
+			return errors.Wrap(err, errSetDirectoryNetworks)
+		}
 	}
 
-	// Check alias
-	if cr.Spec.ForProvider.Alias != nil && *cr.Spec.ForProvider.Alias != room.Alias {
-		return false
+	listed := []string{}
+	for _, networkID := range desired {
+		if err := c.service.SetDirectoryNetworkVisibility(ctx, networkID, roomID, "public"); err != nil {
+			cr.Status.AtProvider.DirectoryNetworks = listed
+			return errors.Wrap(err, errSetDirectoryNetworks)
+		}
+		listed = append(listed, networkID)
 	}
+	cr.Status.AtProvider.DirectoryNetworks = listed
+	return nil
+}
 
-	// Check guest access
-	if cr.Spec.ForProvider.GuestAccess != nil && *cr.Spec.ForProvider.GuestAccess != room.GuestAccess {
-		return false
+// danglingAllowReferences returns the rooms referenced by a room's join rule
+// allow conditions that no longer exist. Rooms that couldn't be checked are
+// taken to exist, so that a failed check never removes a valid reference.
+func (c *external) danglingAllowReferences(ctx context.Context, room *clients.Room) []string {
+	var dangling []string
+	for _, roomID := range room.JoinRuleAllow {
+		if exists, err := c.service.RoomExists(ctx, roomID); err == nil && !exists {
+			dangling = append(dangling, roomID)
+		}
 	}
+	return dangling
+}
 
-	// Check history visibility
-	if cr.Spec.ForProvider.HistoryVisibility != nil && *cr.Spec.ForProvider.HistoryVisibility != room.HistoryVisibility {
-		return false
+// invalidPinnedEvents returns the events to pin that can't be pinned in the
+// room
+func (c *external) invalidPinnedEvents(ctx context.Context, roomID string, eventIDs []string) ([]string, error) {
+	var invalid []string
+	for _, eventID := range eventIDs {
+		pinnable, err := c.service.EventPinnable(ctx, roomID, eventID)
+		if err != nil {
+			return nil, errors.Wrap(err, errCheckPinnedEvent)
+		}
+		if !pinnable {
+			invalid = append(invalid, eventID)
+		}
 	}
+	return invalid, nil
+}
 
-	// Check join rules
-	if cr.Spec.ForProvider.JoinRules != nil && *cr.Spec.ForProvider.JoinRules != room.JoinRules {
-		return false
+// pinnableEvents returns the events to pin, leaving out those that can't be
+func pinnableEvents(eventIDs, invalid []string) []string {
+	pinnable := make([]string, 0, len(eventIDs))
+	for _, eventID := range eventIDs {
+		if !slices.Contains(invalid, eventID) {
+			pinnable = append(pinnable, eventID)
+		}
 	}
+	return pinnable
+}
 
-	// Check encryption
-	if cr.Spec.ForProvider.EncryptionEnabled != nil && *cr.Spec.ForProvider.EncryptionEnabled != room.EncryptionEnabled {
-		return false
+// fitLength returns the name and topic to give the room, truncated to their
+// limits if the Room allows it, and the fields it truncated. An over-length
+// field the Room doesn't allow truncating is an error, returned along with
+// the fields as they are.
+func fitLength(cr *v1alpha1.Room) (name, topic *string, truncated []string, err error) {
+	p := cr.Spec.ForProvider
+	name, topic = p.Name, p.Topic
+
+	fields := []struct {
+		field string
+		value **string
+		max   int
+	}{
+		{field: "name", value: &name, max: maxNameLength},
+		{field: "topic", value: &topic, max: maxTopicLength},
+	}
+	for _, f := range fields {
+		v := *f.value
+		if v == nil || len(*v) <= f.max {
+			continue
+		}
+		if !truncateOverLength(cr) {
+			return p.Name, p.Topic, nil, errors.Errorf(errOverLength, f.field, len(*v), f.max)
+		}
+		short := truncateUTF8(*v, f.max)
+		*f.value = &short
+		truncated = append(truncated, f.field)
+	}
+	return name, topic, truncated, nil
+}
+
+// truncateUTF8 shortens s to at most n bytes without splitting a character
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+func truncateOverLength(cr *v1alpha1.Room) bool {
+	return cr.Spec.ForProvider.TruncateOverLength != nil && *cr.Spec.ForProvider.TruncateOverLength
+}
+
+func ensureJoined(cr *v1alpha1.Room) bool {
+	return cr.Spec.ForProvider.EnsureJoined != nil && *cr.Spec.ForProvider.EnsureJoined
+}
+
+func adoptExisting(cr *v1alpha1.Room) bool {
+	return cr.Spec.ForProvider.AdoptExisting != nil && *cr.Spec.ForProvider.AdoptExisting
+}
+
+func setAliasAsCanonical(cr *v1alpha1.Room) bool {
+	return cr.Spec.ForProvider.SetAliasAsCanonical == nil || *cr.Spec.ForProvider.SetAliasAsCanonical
+}
+
+// joinRuleAllow returns the IDs of the rooms the spec's allow conditions
+// name, nil if they aren't managed. Space references have been resolved to
+// room IDs before the Room is observed.
+func joinRuleAllow(cr *v1alpha1.Room) []string {
+	if cr.Spec.ForProvider.JoinRuleAllow == nil {
+		return nil
+	}
+	roomIDs := []string{}
+	for _, allow := range cr.Spec.ForProvider.JoinRuleAllow {
+		if allow.RoomID != nil {
+			roomIDs = append(roomIDs, *allow.RoomID)
+		}
+	}
+	return roomIDs
+}
+
+// removeDanglingAllowReferences reports whether dangling allow references are
+// removed. Allow conditions the spec lists are kept, dangling or not.
+func removeDanglingAllowReferences(cr *v1alpha1.Room) bool {
+	return cr.Spec.ForProvider.JoinRuleAllow == nil &&
+		cr.Spec.ForProvider.RemoveDanglingAllowReferences != nil && *cr.Spec.ForProvider.RemoveDanglingAllowReferences
+}
+
+// historyExposed reports whether the room's history is world readable although
+// its join rule doesn't let everyone join, returning that join rule. Settings
+// the spec manages are checked as desired rather than as observed, so the
+// misconfiguration is reported before it is applied.
+func historyExposed(cr *v1alpha1.Room, room *clients.Room) (string, bool) {
+	historyVisibility, joinRules := room.HistoryVisibility, room.JoinRules
+	if p := cr.Spec.ForProvider.HistoryVisibility; p != nil {
+		historyVisibility = *p
+	}
+	if p := cr.Spec.ForProvider.JoinRules; p != nil {
+		joinRules = *p
+	}
+	return joinRules, historyVisibility == "world_readable" && joinRules != "public"
+}
+
+// encryptionConflict reports whether the spec asks for an encrypted room to be
+// unencrypted, which Matrix doesn't allow
+func encryptionConflict(cr *v1alpha1.Room, room *clients.Room) bool {
+	p := observeonly.Desired(cr).Spec.ForProvider.EncryptionEnabled
+	return p != nil && !*p && room.EncryptionEnabled
+}
+
+// roomConnectionDetails returns the connection details published for a room.
+// Aliases are only included once the room has them.
+func roomConnectionDetails(room *clients.Room) managed.ConnectionDetails {
+	details := managed.ConnectionDetails{
+		keyRoomID: []byte(room.RoomID),
+	}
+	if room.Alias != "" {
+		details[keyAlias] = []byte(room.Alias)
+	}
+	if len(room.AltAliases) > 0 {
+		details[keyAltAliases] = []byte(strings.Join(room.AltAliases, ","))
+	}
+	if room.RoomVersion != "" {
+		details[keyRoomVersion] = []byte(room.RoomVersion)
+	}
+	return details
+}
+
+func isRoomUpToDate(cr *v1alpha1.Room, room *clients.Room, userID string) bool {
+	return len(roomDrift(cr, room, userID)) == 0
+}
+
+// roomDrift returns the spec fields that differ from the observed room. Bare
+// alias names in the spec are qualified with the server name of userID, the
+// provider's user, as they are when they are set.
+func roomDrift(cr *v1alpha1.Room, room *clients.Room, userID string) []string {
+	var drift []string
+	cr = observeonly.Desired(cr)
+	p := cr.Spec.ForProvider
+
+	// A truncated name or topic is compared as it was written
+	name, topic, _, _ := fitLength(cr)
+	if name != nil && *name != room.Name {
+		drift = append(drift, "name")
+	}
+	if topic != nil && *topic != room.Topic {
+		drift = append(drift, "topic")
+	}
+	// The m.topic block is written along with the topic, so it is only
+	// managed when the topic is
+	if p.Topic != nil && p.RichTopic != nil {
+		if *p.RichTopic != room.RichTopic {
+			drift = append(drift, "richTopic")
+		}
+		if *p.RichTopic && p.TopicHTML != nil && *p.TopicHTML != room.TopicHTML {
+			drift = append(drift, "topicHTML")
+		}
+	}
+	if p.Alias != nil && (*p.Alias == room.Alias) != setAliasAsCanonical(cr) {
+		drift = append(drift, "alias")
+	}
+	if p.AltAliases != nil && !sameElements(fullAliases(p.AltAliases, userID), room.AltAliases) {
+		drift = append(drift, "altAliases")
+	}
+	if p.GuestAccess != nil && *p.GuestAccess != room.GuestAccess {
+		drift = append(drift, "guestAccess")
+	}
+	if p.HistoryVisibility != nil && *p.HistoryVisibility != room.HistoryVisibility {
+		drift = append(drift, "historyVisibility")
+	}
+	if p.JoinRules != nil && *p.JoinRules != room.JoinRules {
+		drift = append(drift, "joinRules")
+	}
+	if allow := joinRuleAllow(cr); allow != nil && !sameElements(allow, room.JoinRuleAllow) {
+		drift = append(drift, "joinRuleAllow")
+	}
+	// Encryption can only be turned on. Asking to turn it off is reported
+	// by the EncryptionConflict condition instead, as no update can fix it.
+	if p.EncryptionEnabled != nil && *p.EncryptionEnabled && !room.EncryptionEnabled {
+		drift = append(drift, "encryptionEnabled")
+	}
+	if p.AvatarURL != nil && *p.AvatarURL != room.AvatarURL {
+		drift = append(drift, "avatarURL")
+	}
+	if p.Blocked != nil && room.Blocked != nil && *p.Blocked != *room.Blocked {
+		drift = append(drift, "blocked")
+	}
+	if p.PowerLevelOverrides != nil && room.PowerLevels != nil && powerLevelOverridesDrifted(p.PowerLevelOverrides, room) {
+		drift = append(drift, "powerLevelOverrides")
+	}
+
+	return drift
+}
+
+// powerLevelOverridesDrifted reports whether the room's power levels differ
+// from its overrides. Only the users, events and levels the overrides set are
+// compared, so that users given a level since, or levels the overrides leave
+// to the homeserver, don't count as drift. Privileged creators are left out,
+// as they can't be given a power level.
+func powerLevelOverridesDrifted(overrides *v1alpha1.PowerLevelContent, room *clients.Room) bool {
+	observed := room.PowerLevels
+	usersDefault := clients.LevelOrDefault(observed.UsersDefault, clients.DefaultUsersLevel)
+	for userID, level := range overrides.Users {
+		if slices.Contains(room.PrivilegedCreators, userID) {
+			continue
+		}
+		current, ok := observed.Users[userID]
+		if !ok {
+			current = usersDefault
+		}
+		if current != level {
+			return true
+		}
+	}
+	for eventType, level := range overrides.Events {
+		if current, ok := observed.Events[eventType]; !ok || current != level {
+			return true
+		}
+	}
+
+	levels := []struct {
+		desired  *int
+		observed *int
+		def      int
+	}{
+		{overrides.EventsDefault, observed.EventsDefault, clients.DefaultEventsLevel},
+		{overrides.StateDefault, observed.StateDefault, clients.DefaultStateLevel},
+		{overrides.UsersDefault, observed.UsersDefault, clients.DefaultUsersLevel},
+		{overrides.Ban, observed.Ban, clients.DefaultModeratorLevel},
+		{overrides.Kick, observed.Kick, clients.DefaultModeratorLevel},
+		{overrides.Redact, observed.Redact, clients.DefaultModeratorLevel},
+		{overrides.Invite, observed.Invite, clients.DefaultInviteLevel},
+	}
+	for _, l := range levels {
+		if l.desired != nil && *l.desired != clients.LevelOrDefault(l.observed, l.def) {
+			return true
+		}
 	}
+	return false
+}
+
+// summaryFields are the spec fields that can be compared with the summary of
+// a room on another homeserver
+var summaryFields = []string{"name", "topic", "alias", "avatarURL", "guestAccess", "joinRules"}
+
+// summaryDrift returns the drift in the fields a room's summary holds
+func summaryDrift(drift []string) []string {
+	return slices.DeleteFunc(drift, func(field string) bool {
+		return !slices.Contains(summaryFields, field)
+	})
+}
 
-	// Check avatar URL
-	if cr.Spec.ForProvider.AvatarURL != nil && *cr.Spec.ForProvider.AvatarURL != room.AvatarURL {
+// fullAliases returns aliases with bare alias names qualified with the server
+// name of userID
+func fullAliases(aliases []string, userID string) []string {
+	full := make([]string, len(aliases))
+	for i, alias := range aliases {
+		full[i] = clients.FullAlias(alias, userID)
+	}
+	return full
+}
+
+// sameElements reports whether two string slices hold the same elements,
+// ignoring order
+func sameElements(a, b []string) bool {
+	if len(a) != len(b) {
 		return false
 	}
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
 
-	return true
+// inviteUsers invites the next batch of the users of invite that have never
+// been in the room. Invites the homeserver still rate limits after the
+// client's retries are left for the next reconcile, rather than failing it.
+func (c *external) inviteUsers(ctx context.Context, cr *v1alpha1.Room, invite []string, roomID string) error {
+	if len(invite) == 0 {
+		return nil
+	}
+	memberships, err := c.service.GetMemberships(ctx, roomID)
+	if err != nil {
+		return errors.Wrap(err, errGetMemberships)
+	}
+	pending := pendingInvites(invite, memberships)
+	if len(pending) == 0 {
+		return nil
+	}
+
+	batchSize := defaultInviteBatchSize
+	if p := cr.Spec.ForProvider.InviteBatchSize; p != nil && *p > 0 {
+		batchSize = *p
+	}
+	batch := pending[:min(batchSize, len(pending))]
+
+	invited, err := c.service.InviteUsers(ctx, roomID, batch)
+	if progress := cr.Status.AtProvider.InviteProgress; progress != nil {
+		progress.Invited += invited
+	}
+	if err != nil && !clients.IsRateLimited(err) {
+		return errors.Wrap(err, errInviteUsers)
+	}
+	return nil
+}
+
+// grantAdmin gives admin power to the users of GrantAdminTo that don't hold
+// it yet
+func (c *external) grantAdmin(ctx context.Context, cr *v1alpha1.Room, roomID string) error {
+	users := uniqueUsers(cr.Spec.ForProvider.GrantAdminTo)
+	if len(users) == 0 {
+		return nil
+	}
+	admins, err := c.service.RoomAdmins(ctx, roomID, users)
+	if err != nil {
+		return errors.Wrap(err, errGetRoomAdmins)
+	}
+	for _, userID := range users {
+		if slices.Contains(admins, userID) {
+			continue
+		}
+		if err := c.service.MakeRoomAdmin(ctx, roomID, userID); err != nil {
+			return errors.Wrapf(err, errMakeRoomAdmin, userID)
+		}
+	}
+	return nil
+}
+
+// pendingInvites returns the users of invite that have no membership of the
+// room, so that users that left or were banned aren't invited again
+func pendingInvites(invite []string, memberships map[string]string) []string {
+	var pending []string
+	for _, userID := range uniqueUsers(invite) {
+		if _, ok := memberships[userID]; !ok {
+			pending = append(pending, userID)
+		}
+	}
+	return pending
+}
+
+// uniqueUsers returns users without repeats, in the order they first appear
+func uniqueUsers(users []string) []string {
+	seen := make(map[string]bool, len(users))
+	var unique []string
+	for _, userID := range users {
+		if !seen[userID] {
+			seen[userID] = true
+			unique = append(unique, userID)
+		}
+	}
+	return unique
+}
+
+// knocksToAccept returns the pending knocks that come from auto-accepted users
+func knocksToAccept(autoAccept, knocks []string) []string {
+	allowed := make(map[string]bool, len(autoAccept))
+	for _, userID := range autoAccept {
+		allowed[userID] = true
+	}
+
+	var accept []string
+	for _, userID := range knocks {
+		if allowed[userID] {
+			accept = append(accept, userID)
+		}
+	}
+	return accept
 }
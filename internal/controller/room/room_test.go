@@ -0,0 +1,1309 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package room
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/common"
+	"github.com/crossplane-contrib/provider-matrix/apis/room/v1alpha1"
+	spacev1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/space/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"maunium.net/go/mautrix"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// mockClient implements clients.Client, overriding only the methods a test
+// needs. Calling any other method panics.
+type mockClient struct {
+	clients.Client
+
+	room     *clients.Room
+	knocks   []string
+	accepted []string
+
+	extremities        int
+	extremitiesDeleted bool
+
+	createErr error
+
+	deleteOpts *clients.DeleteRoomOptions
+	deletion   *clients.RoomDeletion
+
+	existing     []string
+	unchecked    []string
+	removedAllow []string
+
+	networkVisibility map[string]string
+
+	pinnable []string
+	updated  *clients.RoomSpec
+
+	membership string
+	joined     bool
+
+	state []clients.StateEvent
+
+	memberships map[string]string
+	rateLimited int
+
+	aliases map[string]string
+
+	admins    []string
+	adminsErr error
+
+	blocked    bool
+	blockedErr error
+}
+
+func (m *mockClient) GetRoomAlias(ctx context.Context, alias string) (*clients.RoomAlias, error) {
+	roomID, ok := m.aliases[alias]
+	if !ok {
+		return nil, mautrix.MNotFound
+	}
+	return &clients.RoomAlias{Alias: alias, RoomID: roomID}, nil
+}
+
+func (m *mockClient) GetMemberships(ctx context.Context, roomID string) (map[string]string, error) {
+	return m.memberships, nil
+}
+
+// InviteUsers rate limits the first rateLimited invites, as the homeserver
+// would once the client's retries are used up
+func (m *mockClient) InviteUsers(ctx context.Context, roomID string, userIDs []string) (int, error) {
+	for i, userID := range userIDs {
+		if m.rateLimited > 0 {
+			m.rateLimited--
+			return i, mautrix.MLimitExceeded
+		}
+		if m.memberships == nil {
+			m.memberships = make(map[string]string)
+		}
+		m.memberships[userID] = "invite"
+	}
+	return len(userIDs), nil
+}
+
+func (m *mockClient) GetRoomState(ctx context.Context, roomID string, eventTypes []string) ([]clients.StateEvent, error) {
+	return m.state, nil
+}
+
+func (m *mockClient) GetMembership(ctx context.Context, roomID string) (string, error) {
+	return m.membership, nil
+}
+
+func (m *mockClient) JoinRoom(ctx context.Context, roomID string) error {
+	m.joined = true
+	m.membership = "join"
+	return nil
+}
+
+func (m *mockClient) SetDirectoryNetworkVisibility(ctx context.Context, networkID, roomID, visibility string) error {
+	if m.networkVisibility == nil {
+		m.networkVisibility = map[string]string{}
+	}
+	m.networkVisibility[networkID] = visibility
+	return nil
+}
+
+func (m *mockClient) RoomExists(ctx context.Context, roomID string) (bool, error) {
+	if slices.Contains(m.unchecked, roomID) {
+		return false, errors.New("boom")
+	}
+	return slices.Contains(m.existing, roomID), nil
+}
+
+func (m *mockClient) EventPinnable(ctx context.Context, roomID, eventID string) (bool, error) {
+	return slices.Contains(m.pinnable, eventID), nil
+}
+
+func (m *mockClient) RemoveJoinRuleAllow(ctx context.Context, roomID string, allowRoomIDs []string) error {
+	m.removedAllow = allowRoomIDs
+	return nil
+}
+
+func (m *mockClient) DeleteRoom(ctx context.Context, roomID string, opts clients.DeleteRoomOptions) error {
+	m.deleteOpts = &opts
+	return nil
+}
+
+func (m *mockClient) GetRoomDeletion(ctx context.Context, roomID string) (*clients.RoomDeletion, error) {
+	return m.deletion, nil
+}
+
+func (m *mockClient) CreateRoom(ctx context.Context, room *clients.RoomSpec) (*clients.Room, error) {
+	if m.createErr != nil {
+		return nil, m.createErr
+	}
+	return m.room, nil
+}
+
+func (m *mockClient) GetRoom(ctx context.Context, roomID string) (*clients.Room, error) {
+	return m.room, nil
+}
+
+func (m *mockClient) UpdateRoom(ctx context.Context, roomID string, room *clients.RoomSpec) (*clients.Room, error) {
+	m.updated = room
+	return m.room, nil
+}
+
+func (m *mockClient) GetKnocks(ctx context.Context, roomID string) ([]string, error) {
+	return m.knocks, nil
+}
+
+func (m *mockClient) AcceptKnock(ctx context.Context, roomID, userID string) error {
+	m.accepted = append(m.accepted, userID)
+	return nil
+}
+
+func (m *mockClient) GetForwardExtremities(ctx context.Context, roomID string) (*clients.ForwardExtremities, error) {
+	return &clients.ForwardExtremities{Count: m.extremities}, nil
+}
+
+func (m *mockClient) DeleteForwardExtremities(ctx context.Context, roomID string) (int, error) {
+	m.extremitiesDeleted = true
+	return m.extremities, nil
+}
+
+func (m *mockClient) RoomAdmins(ctx context.Context, roomID string, userIDs []string) ([]string, error) {
+	if m.adminsErr != nil {
+		return nil, m.adminsErr
+	}
+	var admins []string
+	for _, userID := range userIDs {
+		if slices.Contains(m.admins, userID) {
+			admins = append(admins, userID)
+		}
+	}
+	return admins, nil
+}
+
+func (m *mockClient) MakeRoomAdmin(ctx context.Context, roomID, userID string) error {
+	m.admins = append(m.admins, userID)
+	return nil
+}
+
+func (m *mockClient) GetRoomBlocked(ctx context.Context, roomID string) (bool, error) {
+	return m.blocked, m.blockedErr
+}
+
+func (m *mockClient) BlockRoom(ctx context.Context, roomID string, block bool) error {
+	m.blocked = block
+	return nil
+}
+
+func newRoom(roomID string, params v1alpha1.RoomParameters) *v1alpha1.Room {
+	cr := &v1alpha1.Room{
+		Spec: v1alpha1.RoomSpec{ForProvider: params},
+	}
+	meta.SetExternalName(cr, roomID)
+	return cr
+}
+
+func TestKnocksToAccept(t *testing.T) {
+	tests := []struct {
+		name       string
+		autoAccept []string
+		knocks     []string
+		want       []string
+	}{
+		{
+			name:       "no knocks",
+			autoAccept: []string{"@alice:example.com"},
+			want:       nil,
+		},
+		{
+			name:       "only listed users are accepted",
+			autoAccept: []string{"@alice:example.com"},
+			knocks:     []string{"@alice:example.com", "@mallory:example.com"},
+			want:       []string{"@alice:example.com"},
+		},
+		{
+			name:   "nobody listed",
+			knocks: []string{"@alice:example.com"},
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, knocksToAccept(tt.autoAccept, tt.knocks))
+		})
+	}
+}
+
+func TestObserveKnockAutoAccept(t *testing.T) {
+	m := &mockClient{
+		room:   &clients.Room{RoomID: "!room:example.com", JoinRules: "knock"},
+		knocks: []string{"@alice:example.com", "@mallory:example.com"},
+	}
+	cr := newRoom("!room:example.com", v1alpha1.RoomParameters{
+		KnockAutoAccept: []string{"@alice:example.com"},
+	})
+
+	e := &external{service: m}
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceExists)
+	assert.False(t, obs.ResourceUpToDate)
+	assert.Equal(t, m.knocks, cr.Status.AtProvider.PendingKnocks)
+
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"@alice:example.com"}, m.accepted)
+}
+
+func TestGenerateRoomObservationPredecessor(t *testing.T) {
+	obs := generateRoomObservation(&clients.Room{
+		RoomID: "!new:example.com",
+		Predecessor: &clients.RoomPredecessor{
+			RoomID:  "!old:example.com",
+			EventID: "$tombstone",
+		},
+	})
+
+	require.NotNil(t, obs.Predecessor)
+	assert.Equal(t, "!old:example.com", obs.Predecessor.RoomID)
+	assert.Equal(t, "$tombstone", obs.Predecessor.EventID)
+
+	obs = generateRoomObservation(&clients.Room{RoomID: "!room:example.com"})
+	assert.Nil(t, obs.Predecessor)
+}
+
+func TestGenerateRoomObservationPubliclyJoinable(t *testing.T) {
+	tests := []struct {
+		name       string
+		visibility string
+		joinRules  string
+		want       bool
+	}{
+		{name: "published public room", visibility: "public", joinRules: "public", want: true},
+		{name: "unpublished public room", visibility: "private", joinRules: "public"},
+		{name: "published invite-only room", visibility: "public", joinRules: "invite"},
+		{name: "published knock room", visibility: "public", joinRules: "knock"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obs := generateRoomObservation(&clients.Room{
+				RoomID:     "!room:example.com",
+				Visibility: tt.visibility,
+				JoinRules:  tt.joinRules,
+			})
+			assert.Equal(t, tt.want, obs.PubliclyJoinable)
+		})
+	}
+}
+
+func TestIsRoomUpToDateName(t *testing.T) {
+	empty, set := "", "General"
+
+	tests := []struct {
+		name     string
+		specName *string
+		roomName string
+		want     bool
+	}{
+		{
+			name:     "name not managed",
+			roomName: "General",
+			want:     true,
+		},
+		{
+			name:     "name matches",
+			specName: &set,
+			roomName: "General",
+			want:     true,
+		},
+		{
+			name:     "name should be removed",
+			specName: &empty,
+			roomName: "General",
+			want:     false,
+		},
+		{
+			name:     "name already removed",
+			specName: &empty,
+			want:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := newRoom("!room:example.com", v1alpha1.RoomParameters{Name: tt.specName})
+			got := isRoomUpToDate(cr, &clients.Room{RoomID: "!room:example.com", Name: tt.roomName}, "@bot:example.com")
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGenerateRoomSpecEmptyName(t *testing.T) {
+	empty := ""
+	spec, err := generateRoomSpec(newRoom("!room:example.com", v1alpha1.RoomParameters{Name: &empty}))
+	require.NoError(t, err)
+	require.NotNil(t, spec.Name)
+	assert.Equal(t, "", *spec.Name)
+
+	spec, err = generateRoomSpec(newRoom("!room:example.com", v1alpha1.RoomParameters{}))
+	require.NoError(t, err)
+	assert.Nil(t, spec.Name)
+}
+
+func TestTruncateOverLength(t *testing.T) {
+	// A name whose 255th byte falls inside a three-byte character
+	name := strings.Repeat("a", 254) + "€"
+	topic := strings.Repeat("t", maxTopicLength+1)
+	truncate := true
+
+	tests := []struct {
+		name          string
+		truncate      *bool
+		wantErr       bool
+		wantName      string
+		wantTopic     string
+		wantTruncated []string
+	}{
+		{
+			name:    "refused by default",
+			wantErr: true,
+		},
+		{
+			name:          "truncated",
+			truncate:      &truncate,
+			wantName:      strings.Repeat("a", 254),
+			wantTopic:     topic[:maxTopicLength],
+			wantTruncated: []string{"name", "topic"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := newRoom("!room:example.com", v1alpha1.RoomParameters{
+				Name:               &name,
+				Topic:              &topic,
+				TruncateOverLength: tt.truncate,
+			})
+
+			spec, err := generateRoomSpec(cr)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantName, *spec.Name)
+			assert.Equal(t, tt.wantTopic, spec.Topic)
+
+			// The truncated fields are what the room is compared with
+			m := &mockClient{room: &clients.Room{RoomID: "!room:example.com", Name: tt.wantName, Topic: tt.wantTopic}}
+			obs, err := (&external{service: m}).Observe(context.Background(), cr)
+			require.NoError(t, err)
+			assert.True(t, obs.ResourceUpToDate)
+			assert.Equal(t, tt.wantTruncated, cr.Status.AtProvider.TruncatedFields)
+		})
+	}
+}
+
+func TestGenerateRoomSpecCreationContent(t *testing.T) {
+	spec, err := generateRoomSpec(newRoom("", v1alpha1.RoomParameters{
+		CreationContent: &runtime.RawExtension{Raw: []byte(`{"m.federate":false,"type":"org.example.custom"}`)},
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"m.federate": false, "type": "org.example.custom"}, spec.CreationContent)
+
+	_, err = generateRoomSpec(newRoom("", v1alpha1.RoomParameters{
+		CreationContent: &runtime.RawExtension{Raw: []byte(`[]`)},
+	}))
+	assert.Error(t, err)
+}
+
+func TestRoomDrift(t *testing.T) {
+	name, topic := "General", "Chat"
+	cr := newRoom("!room:example.com", v1alpha1.RoomParameters{Name: &name, Topic: &topic})
+
+	drift := roomDrift(cr, &clients.Room{RoomID: "!room:example.com", Name: "General", Topic: "Old topic"}, "@bot:example.com")
+	assert.Equal(t, []string{"topic"}, drift)
+
+	drift = roomDrift(cr, &clients.Room{RoomID: "!room:example.com", Name: "General", Topic: "Chat"}, "@bot:example.com")
+	assert.Empty(t, drift)
+}
+
+func TestRoomDriftRichTopic(t *testing.T) {
+	topic, html, rich := "Chat", "<b>Chat</b>", true
+
+	cr := newRoom("!room:example.com", v1alpha1.RoomParameters{RichTopic: &rich, TopicHTML: &html})
+	assert.Empty(t, roomDrift(cr, &clients.Room{Topic: "Chat"}, "@bot:example.com"))
+
+	cr = newRoom("!room:example.com", v1alpha1.RoomParameters{Topic: &topic, RichTopic: &rich, TopicHTML: &html})
+	drift := roomDrift(cr, &clients.Room{Topic: "Chat"}, "@bot:example.com")
+	assert.Equal(t, []string{"richTopic", "topicHTML"}, drift)
+
+	drift = roomDrift(cr, &clients.Room{Topic: "Chat", RichTopic: true, TopicHTML: "<b>Chat</b>"}, "@bot:example.com")
+	assert.Empty(t, drift)
+}
+
+func TestRoomDriftCanonicalAlias(t *testing.T) {
+	alias, notCanonical := "#general:example.com", false
+
+	cr := newRoom("!room:example.com", v1alpha1.RoomParameters{Alias: &alias})
+	assert.Equal(t, []string{"alias"}, roomDrift(cr, &clients.Room{}, "@bot:example.com"))
+	assert.Empty(t, roomDrift(cr, &clients.Room{Alias: "#general:example.com"}, "@bot:example.com"))
+
+	cr = newRoom("!room:example.com", v1alpha1.RoomParameters{Alias: &alias, SetAliasAsCanonical: &notCanonical})
+	assert.Equal(t, []string{"alias"}, roomDrift(cr, &clients.Room{Alias: "#general:example.com"}, "@bot:example.com"))
+	assert.Empty(t, roomDrift(cr, &clients.Room{}, "@bot:example.com"))
+	assert.Empty(t, roomDrift(cr, &clients.Room{Alias: "#lobby:example.com"}, "@bot:example.com"))
+}
+
+func TestObserveConnectionDetails(t *testing.T) {
+	m := &mockClient{
+		room: &clients.Room{
+			RoomID:      "!room:example.com",
+			Alias:       "#general:example.com",
+			AltAliases:  []string{"#chat:example.com", "#lobby:example.com"},
+			RoomVersion: "11",
+		},
+	}
+
+	e := &external{service: m}
+	obs, err := e.Observe(context.Background(), newRoom("!room:example.com", v1alpha1.RoomParameters{}))
+	require.NoError(t, err)
+	assert.Equal(t, managed.ConnectionDetails{
+		"roomID":      []byte("!room:example.com"),
+		"alias":       []byte("#general:example.com"),
+		"altAliases":  []byte("#chat:example.com,#lobby:example.com"),
+		"roomVersion": []byte("11"),
+	}, obs.ConnectionDetails)
+
+	m.room = &clients.Room{RoomID: "!new:example.com"}
+	creation, err := e.Create(context.Background(), newRoom("", v1alpha1.RoomParameters{}))
+	require.NoError(t, err)
+	assert.Equal(t, managed.ConnectionDetails{"roomID": []byte("!new:example.com")}, creation.ConnectionDetails)
+}
+
+func TestRoomDriftPresetHistoryVisibility(t *testing.T) {
+	preset, shared := "public_chat", "shared"
+	room := &clients.Room{RoomID: "!room:example.com", HistoryVisibility: "shared"}
+
+	// Created with only a preset
+	cr := newRoom("!room:example.com", v1alpha1.RoomParameters{Preset: &preset})
+	assert.Empty(t, roomDrift(cr, room, "@bot:example.com"))
+
+	// Created with a preset and the API server's default history visibility
+	cr = newRoom("!room:example.com", v1alpha1.RoomParameters{Preset: &preset, HistoryVisibility: &shared})
+	assert.Empty(t, roomDrift(cr, room, "@bot:example.com"))
+}
+
+func TestRoomDriftEncryption(t *testing.T) {
+	enabled, disabled := true, false
+	plain := &clients.Room{RoomID: "!room:example.com"}
+	encrypted := &clients.Room{RoomID: "!room:example.com", EncryptionEnabled: true}
+
+	cr := newRoom("!room:example.com", v1alpha1.RoomParameters{EncryptionEnabled: &enabled})
+	assert.Equal(t, []string{"encryptionEnabled"}, roomDrift(cr, plain, "@bot:example.com"))
+	assert.Empty(t, roomDrift(cr, encrypted, "@bot:example.com"))
+
+	cr = newRoom("!room:example.com", v1alpha1.RoomParameters{EncryptionEnabled: &disabled})
+	assert.Empty(t, roomDrift(cr, plain, "@bot:example.com"))
+	assert.Empty(t, roomDrift(cr, encrypted, "@bot:example.com"))
+}
+
+func TestEncryptionConflict(t *testing.T) {
+	disabled := false
+	name := "General"
+	m := &mockClient{room: &clients.Room{RoomID: "!room:example.com", Name: "Old", EncryptionEnabled: true}}
+	cr := newRoom("!room:example.com", v1alpha1.RoomParameters{Name: &name, EncryptionEnabled: &disabled})
+	e := &external{service: m}
+
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceUpToDate)
+	cond := cr.Status.GetCondition(v1alpha1.TypeEncryptionConflict)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, v1alpha1.ReasonEncryptionCannotBeDisabled, cond.Reason)
+
+	// The rest of the spec is still applied
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	require.NotNil(t, m.updated)
+	assert.Equal(t, &name, m.updated.Name)
+
+	// Only the conflict is left, which no update can fix
+	m.room.Name = "General"
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceUpToDate)
+
+	cr.Spec.ForProvider.EncryptionEnabled = nil
+	_, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, corev1.ConditionFalse, cr.Status.GetCondition(v1alpha1.TypeEncryptionConflict).Status)
+}
+
+func TestRoomDriftPresetGuestAccess(t *testing.T) {
+	preset, forbidden := "public_chat", "forbidden"
+	room := &clients.Room{RoomID: "!room:example.com", GuestAccess: "forbidden"}
+
+	// Created with only a preset
+	cr := newRoom("!room:example.com", v1alpha1.RoomParameters{Preset: &preset})
+	assert.Empty(t, roomDrift(cr, room, "@bot:example.com"))
+
+	// Created with a preset and the API server's default guest access
+	cr = newRoom("!room:example.com", v1alpha1.RoomParameters{Preset: &preset, GuestAccess: &forbidden})
+	assert.Empty(t, roomDrift(cr, room, "@bot:example.com"))
+
+	// Guests allowed since
+	room.GuestAccess = "can_join"
+	assert.Equal(t, []string{"guestAccess"}, roomDrift(cr, room, "@bot:example.com"))
+}
+
+func TestObserveDanglingAllowReferences(t *testing.T) {
+	remove := true
+	m := &mockClient{
+		room: &clients.Room{
+			RoomID:        "!room:example.com",
+			JoinRules:     "restricted",
+			JoinRuleAllow: []string{"!space:example.com", "!gone:example.com", "!unchecked:other.org"},
+		},
+		existing:  []string{"!space:example.com"},
+		unchecked: []string{"!unchecked:other.org"},
+	}
+	cr := newRoom("!room:example.com", v1alpha1.RoomParameters{})
+
+	e := &external{service: m}
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceUpToDate)
+	assert.Equal(t, []string{"!gone:example.com"}, cr.Status.AtProvider.DanglingAllowReferences)
+	cond := cr.Status.GetCondition(v1alpha1.TypeDanglingAllowReference)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Contains(t, cond.Message, "!gone:example.com")
+
+	cr.Spec.ForProvider.RemoveDanglingAllowReferences = &remove
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceUpToDate)
+
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"!gone:example.com"}, m.removedAllow)
+
+	// A room that couldn't be checked is kept
+	m.room.JoinRuleAllow = []string{"!space:example.com", "!unchecked:other.org"}
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceUpToDate)
+	assert.Equal(t, corev1.ConditionFalse, cr.Status.GetCondition(v1alpha1.TypeDanglingAllowReference).Status)
+}
+
+func TestObserveWorldReadableHistory(t *testing.T) {
+	worldReadable := "world_readable"
+	public := "public"
+	m := &mockClient{
+		room: &clients.Room{
+			RoomID:            "!room:example.com",
+			JoinRules:         "invite",
+			HistoryVisibility: "shared",
+		},
+	}
+	cr := newRoom("!room:example.com", v1alpha1.RoomParameters{HistoryVisibility: &worldReadable})
+
+	e := &external{service: m}
+	_, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	cond := cr.Status.GetCondition(v1alpha1.TypeWorldReadableHistory)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Contains(t, cond.Message, "join rule is invite")
+
+	cr.Spec.ForProvider.JoinRules = &public
+	_, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, corev1.ConditionFalse, cr.Status.GetCondition(v1alpha1.TypeWorldReadableHistory).Status)
+
+	other := newRoom("!room:example.com", v1alpha1.RoomParameters{})
+	_, err = e.Observe(context.Background(), other)
+	require.NoError(t, err)
+	assert.Equal(t, corev1.ConditionUnknown, other.Status.GetCondition(v1alpha1.TypeWorldReadableHistory).Status)
+}
+
+func TestObserveRemoteRoom(t *testing.T) {
+	name := "Lobby"
+	encrypted := true
+	threshold := 10
+	m := &mockClient{
+		room: &clients.Room{RoomID: "!room:other.org", Name: "Lobby", Summary: true},
+	}
+	cr := newRoom("!room:other.org", v1alpha1.RoomParameters{
+		Name:                        &name,
+		EncryptionEnabled:           &encrypted,
+		PinnedEvents:                []string{"$a"},
+		ForwardExtremitiesThreshold: &threshold,
+	})
+
+	// Only the summary is compared, and nothing else is read
+	e := &external{service: m, selfUserID: "@bot:example.com"}
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceExists)
+	assert.True(t, obs.ResourceUpToDate)
+	cond := cr.Status.GetCondition(common.TypeRemoteRoom)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Contains(t, cond.Message, "other.org")
+
+	name = "Hall"
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceUpToDate)
+
+	local := newRoom("!room:example.com", v1alpha1.RoomParameters{})
+	m.room = &clients.Room{RoomID: "!room:example.com"}
+	_, err = e.Observe(context.Background(), local)
+	require.NoError(t, err)
+	assert.Equal(t, corev1.ConditionUnknown, local.Status.GetCondition(common.TypeRemoteRoom).Status)
+}
+
+func TestObserveAdoptExisting(t *testing.T) {
+	adopt, ignore := true, false
+
+	tests := []struct {
+		name          string
+		alias         string
+		adoptExisting *bool
+		wantRoomID    string
+	}{
+		{name: "not asked to adopt", alias: "#lobby:example.com"},
+		{name: "asked not to adopt", alias: "#lobby:example.com", adoptExisting: &ignore},
+		{name: "alias unused", alias: "#new:example.com", adoptExisting: &adopt},
+		{name: "alias in use", alias: "#lobby:example.com", adoptExisting: &adopt, wantRoomID: "!lobby:example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mockClient{
+				room:    &clients.Room{RoomID: "!lobby:example.com", Alias: "#lobby:example.com"},
+				aliases: map[string]string{"#lobby:example.com": "!lobby:example.com"},
+			}
+			e := &external{service: m, selfUserID: "@bot:example.com"}
+
+			// The external name defaults to the Room's own name until the
+			// room is created
+			cr := newRoom("lobby", v1alpha1.RoomParameters{Alias: &tt.alias, AdoptExisting: tt.adoptExisting})
+
+			obs, err := e.Observe(context.Background(), cr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRoomID != "", obs.ResourceExists)
+			assert.Equal(t, tt.wantRoomID != "", obs.ResourceLateInitialized)
+			if tt.wantRoomID == "" {
+				assert.Equal(t, "lobby", meta.GetExternalName(cr))
+				return
+			}
+			assert.Equal(t, tt.wantRoomID, meta.GetExternalName(cr))
+			assert.Equal(t, common.ManagedByAdopted, cr.Status.AtProvider.ManagedBy)
+
+			// Once adopted the room is observed by its ID
+			obs, err = e.Observe(context.Background(), cr)
+			require.NoError(t, err)
+			assert.True(t, obs.ResourceExists)
+			assert.False(t, obs.ResourceLateInitialized)
+		})
+	}
+}
+
+func TestObservePinnedEvents(t *testing.T) {
+	m := &mockClient{
+		room:     &clients.Room{RoomID: "!room:example.com", PinnedEvents: []string{"$old"}},
+		pinnable: []string{"$a", "$b"},
+	}
+	cr := newRoom("!room:example.com", v1alpha1.RoomParameters{
+		PinnedEvents: []string{"$a", "$elsewhere", "$b"},
+	})
+
+	e := &external{service: m}
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceUpToDate)
+	assert.Equal(t, []string{"$elsewhere"}, cr.Status.AtProvider.InvalidPinnedEvents)
+	cond := cr.Status.GetCondition(v1alpha1.TypeInvalidPinnedEvent)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Contains(t, cond.Message, "$elsewhere")
+
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	require.NotNil(t, m.updated)
+	assert.Equal(t, []string{"$a", "$b"}, m.updated.PinnedEvents)
+
+	// The invalid event alone doesn't keep the room out of date
+	m.room.PinnedEvents = []string{"$a", "$b"}
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceUpToDate)
+
+	cr.Spec.ForProvider.PinnedEvents = []string{"$a", "$b"}
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceUpToDate)
+	assert.Empty(t, cr.Status.AtProvider.InvalidPinnedEvents)
+	assert.Equal(t, corev1.ConditionFalse, cr.Status.GetCondition(v1alpha1.TypeInvalidPinnedEvent).Status)
+}
+
+func TestEnsureJoined(t *testing.T) {
+	ensure := true
+	m := &mockClient{room: &clients.Room{RoomID: "!room:example.com"}, membership: "ban"}
+	cr := newRoom("!room:example.com", v1alpha1.RoomParameters{EnsureJoined: &ensure})
+
+	e := &external{service: m}
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceUpToDate)
+	cond := cr.Status.GetCondition(common.TypeProviderNotJoined)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Contains(t, cond.Message, "membership: ban")
+
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, m.joined)
+
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceUpToDate)
+	assert.Equal(t, corev1.ConditionFalse, cr.Status.GetCondition(common.TypeProviderNotJoined).Status)
+}
+
+func TestObserveRoomState(t *testing.T) {
+	m := &mockClient{
+		room: &clients.Room{RoomID: "!room:example.com"},
+		state: []clients.StateEvent{{
+			Type:    "m.room.server_acl",
+			Content: map[string]interface{}{"allow": []interface{}{"*"}, "deny": []interface{}{"evil.example.com"}},
+		}},
+	}
+	cr := newRoom("!room:example.com", v1alpha1.RoomParameters{ObservedStateTypes: []string{"m.room.server_acl"}})
+
+	e := &external{service: m}
+	_, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+
+	acl := cr.Status.AtProvider.FindState("m.room.server_acl", "")
+	require.NotNil(t, acl)
+	var content struct {
+		Deny []string `json:"deny"`
+	}
+	require.NoError(t, acl.DecodeContent(&content))
+	assert.Equal(t, []string{"evil.example.com"}, content.Deny)
+	assert.Nil(t, cr.Status.AtProvider.FindState("m.room.server_acl", "other"))
+}
+
+func TestDirectoryNetworks(t *testing.T) {
+	m := &mockClient{room: &clients.Room{RoomID: "!room:example.com"}}
+	cr := newRoom("!room:example.com", v1alpha1.RoomParameters{
+		DirectoryNetworks: []string{"irc", "slack"},
+	})
+
+	e := &external{service: m}
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceUpToDate)
+
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"irc": "public", "slack": "public"}, m.networkVisibility)
+	assert.Equal(t, []string{"irc", "slack"}, cr.Status.AtProvider.DirectoryNetworks)
+
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceUpToDate)
+
+	cr.Spec.ForProvider.DirectoryNetworks = []string{"slack"}
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"irc": "private", "slack": "public"}, m.networkVisibility)
+	assert.Equal(t, []string{"slack"}, cr.Status.AtProvider.DirectoryNetworks)
+}
+
+func TestForwardExtremitiesThreshold(t *testing.T) {
+	tests := []struct {
+		name        string
+		extremities int
+		wantUpdate  bool
+	}{
+		{
+			name:        "below threshold",
+			extremities: 5,
+		},
+		{
+			name:        "above threshold",
+			extremities: 25,
+			wantUpdate:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			threshold := 10
+			m := &mockClient{
+				room:        &clients.Room{RoomID: "!room:example.com"},
+				extremities: tt.extremities,
+			}
+			cr := newRoom("!room:example.com", v1alpha1.RoomParameters{
+				ForwardExtremitiesThreshold: &threshold,
+			})
+
+			e := &external{service: m}
+			obs, err := e.Observe(context.Background(), cr)
+			require.NoError(t, err)
+			assert.Equal(t, !tt.wantUpdate, obs.ResourceUpToDate)
+			require.NotNil(t, cr.Status.AtProvider.ForwardExtremities)
+			assert.Equal(t, tt.extremities, *cr.Status.AtProvider.ForwardExtremities)
+
+			_, err = e.Update(context.Background(), cr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantUpdate, m.extremitiesDeleted)
+		})
+	}
+}
+
+func TestGrantAdminTo(t *testing.T) {
+	m := &mockClient{
+		room:   &clients.Room{RoomID: "!room:example.com"},
+		admins: []string{"@alice:example.com"},
+	}
+	cr := newRoom("!room:example.com", v1alpha1.RoomParameters{
+		GrantAdminTo: []string{"@alice:example.com", "@bot:example.com", "@bot:example.com"},
+	})
+	e := &external{service: m}
+
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceUpToDate)
+	assert.Equal(t, []string{"@alice:example.com"}, cr.Status.AtProvider.Admins)
+
+	// Only the user lacking power is made an admin
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"@alice:example.com", "@bot:example.com"}, m.admins)
+
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceUpToDate)
+	assert.Equal(t, []string{"@alice:example.com", "@bot:example.com"}, cr.Status.AtProvider.Admins)
+}
+
+func TestGrantAdminToUnsupported(t *testing.T) {
+	m := &mockClient{
+		room:      &clients.Room{RoomID: "!room:example.com"},
+		adminsErr: clients.ErrUnsupportedByServer,
+	}
+	cr := newRoom("!room:example.com", v1alpha1.RoomParameters{
+		GrantAdminTo: []string{"@bot:example.com"},
+	})
+	e := &external{service: m}
+
+	_, err := e.Observe(context.Background(), cr)
+	assert.ErrorIs(t, err, clients.ErrUnsupportedByServer)
+
+	_, err = e.Update(context.Background(), cr)
+	assert.ErrorIs(t, err, clients.ErrUnsupportedByServer)
+}
+
+func TestBlocked(t *testing.T) {
+	blocked, unblocked := true, false
+	tests := []struct {
+		name        string
+		blockedErr  error
+		wantBlocked *bool
+		wantUpdate  bool
+	}{
+		{
+			name:        "block differs",
+			wantBlocked: &unblocked,
+			wantUpdate:  true,
+		},
+		{
+			name:       "not Synapse",
+			blockedErr: clients.ErrUnsupportedByServer,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mockClient{
+				room:       &clients.Room{RoomID: "!room:example.com"},
+				blockedErr: tt.blockedErr,
+			}
+			cr := newRoom("!room:example.com", v1alpha1.RoomParameters{
+				Blocked: &blocked,
+			})
+			e := &external{service: m}
+
+			obs, err := e.Observe(context.Background(), cr)
+			require.NoError(t, err)
+			assert.Equal(t, !tt.wantUpdate, obs.ResourceUpToDate)
+			assert.Equal(t, tt.wantBlocked, cr.Status.AtProvider.Blocked)
+
+			_, err = e.Update(context.Background(), cr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantUpdate, m.blocked)
+		})
+	}
+}
+
+func TestInviteRateLimited(t *testing.T) {
+	batchSize := 2
+	m := &mockClient{
+		room:        &clients.Room{RoomID: "!room:example.com"},
+		memberships: map[string]string{"@dave:example.com": "leave"},
+		rateLimited: 2,
+	}
+	cr := newRoom("!room:example.com", v1alpha1.RoomParameters{
+		Invite:          []string{"@alice:example.com", "@bob:example.com", "@alice:example.com", "@carol:example.com", "@dave:example.com"},
+		InviteBatchSize: &batchSize,
+	})
+	e := &external{service: m}
+
+	// The first two reconciles are rate limited, then each invites a batch
+	// until every user that was never in the room has been invited
+	want := []v1alpha1.InviteProgress{
+		{Invited: 1, Total: 4},
+		{Invited: 1, Total: 4},
+		{Invited: 1, Total: 4},
+		{Invited: 3, Total: 4},
+		{Invited: 4, Total: 4},
+	}
+	for i, progress := range want {
+		obs, err := e.Observe(context.Background(), cr)
+		require.NoError(t, err)
+		require.NotNil(t, cr.Status.AtProvider.InviteProgress)
+		assert.Equal(t, progress, *cr.Status.AtProvider.InviteProgress, "reconcile %d", i)
+		if obs.ResourceUpToDate {
+			assert.Equal(t, len(want)-1, i)
+			break
+		}
+		_, err = e.Update(context.Background(), cr)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, "leave", m.memberships["@dave:example.com"])
+	assert.Len(t, m.memberships, 4)
+}
+
+func TestCreateAliasConflict(t *testing.T) {
+	alias := "general"
+	m := &mockClient{
+		createErr: &clients.AliasConflictError{Alias: "#general:example.com", RoomID: "!other:example.com"},
+	}
+	cr := newRoom("", v1alpha1.RoomParameters{Alias: &alias})
+
+	e := &external{service: m}
+	_, err := e.Create(context.Background(), cr)
+	require.Error(t, err)
+
+	cond := cr.Status.GetCondition(v1alpha1.TypeAliasConflict)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Contains(t, cond.Message, "!other:example.com")
+
+	m.createErr = nil
+	m.room = &clients.Room{RoomID: "!new:example.com"}
+	_, err = e.Create(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, corev1.ConditionFalse, cr.Status.GetCondition(v1alpha1.TypeAliasConflict).Status)
+}
+
+func TestOmittedFieldsAreUnmanaged(t *testing.T) {
+	name := "General"
+	cr := newRoom("!room:example.com", v1alpha1.RoomParameters{Name: &name})
+	room := &clients.Room{RoomID: "!room:example.com", Name: "General", Topic: "Set by a room admin"}
+
+	assert.Empty(t, roomDrift(cr, room, "@bot:example.com"))
+
+	spec, err := generateRoomSpec(cr)
+	require.NoError(t, err)
+	assert.Empty(t, spec.Topic)
+}
+
+func TestObserveOnlyFields(t *testing.T) {
+	name, topic := "General", "Welcome"
+	cr := newRoom("!room:example.com", v1alpha1.RoomParameters{
+		Name:              &name,
+		Topic:             &topic,
+		ObserveOnlyFields: []string{"topic"},
+	})
+	m := &mockClient{room: &clients.Room{RoomID: "!room:example.com", Name: "Old name", Topic: "Set by a room admin"}}
+	e := &external{service: m}
+
+	// The topic is used to create the room
+	spec, err := generateRoomSpec(cr)
+	require.NoError(t, err)
+	assert.Equal(t, "Welcome", spec.Topic)
+
+	// Afterwards it is observed, but neither reported as drift nor updated
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceUpToDate)
+	assert.Equal(t, "Set by a room admin", cr.Status.AtProvider.Topic)
+	assert.Equal(t, []string{"name"}, roomDrift(cr, m.room, "@bot:example.com"))
+
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	require.NotNil(t, m.updated)
+	assert.Equal(t, &name, m.updated.Name)
+	assert.Empty(t, m.updated.Topic)
+	assert.Equal(t, &topic, cr.Spec.ForProvider.Topic)
+}
+
+func TestObserveOnlyFieldsBeyondRoomState(t *testing.T) {
+	enabled := true
+	cr := newRoom("!room:example.com", v1alpha1.RoomParameters{
+		Blocked:           &enabled,
+		GrantAdminTo:      []string{"@alice:example.com"},
+		KnockAutoAccept:   []string{"@bob:example.com"},
+		DirectoryNetworks: []string{"irc"},
+		Invite:            []string{"@carol:example.com"},
+		EnsureJoined:      &enabled,
+		ObserveOnlyFields: []string{"blocked", "grantAdminTo", "knockAutoAccept", "directoryNetworks", "invite", "ensureJoined"},
+	})
+	m := &mockClient{
+		room:              &clients.Room{RoomID: "!room:example.com"},
+		knocks:            []string{"@bob:example.com"},
+		networkVisibility: map[string]string{},
+		membership:        "leave",
+	}
+	e := &external{service: m}
+
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceUpToDate)
+	assert.Equal(t, []string{"@bob:example.com"}, cr.Status.AtProvider.PendingKnocks)
+
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, m.blocked)
+	assert.Empty(t, m.admins)
+	assert.Empty(t, m.accepted)
+	assert.Empty(t, m.networkVisibility)
+	assert.Empty(t, m.memberships)
+	assert.False(t, m.joined)
+}
+
+func TestRoomDriftAltAliases(t *testing.T) {
+	cr := newRoom("!room:example.com", v1alpha1.RoomParameters{
+		AltAliases: []string{"#b:example.com", "#a:example.com"},
+	})
+
+	drift := roomDrift(cr, &clients.Room{AltAliases: []string{"#a:example.com", "#b:example.com"}}, "@bot:example.com")
+	assert.Empty(t, drift)
+
+	drift = roomDrift(cr, &clients.Room{AltAliases: []string{"#a:example.com"}}, "@bot:example.com")
+	assert.Equal(t, []string{"altAliases"}, drift)
+
+	// Bare alias names are qualified with the provider's homeserver
+	cr.Spec.ForProvider.AltAliases = []string{"a", "#b:example.com"}
+	drift = roomDrift(cr, &clients.Room{AltAliases: []string{"#a:example.com", "#b:example.com"}}, "@bot:example.com")
+	assert.Empty(t, drift)
+}
+
+func TestRoomDriftJoinRuleAllow(t *testing.T) {
+	space, other := "!space:example.com", "!other:example.com"
+	cr := newRoom("!room:example.com", v1alpha1.RoomParameters{
+		JoinRuleAllow: []v1alpha1.JoinRuleAllowCondition{{RoomID: &space}, {RoomID: &other}},
+	})
+
+	drift := roomDrift(cr, &clients.Room{JoinRuleAllow: []string{other, space}}, "@bot:example.com")
+	assert.Empty(t, drift)
+
+	drift = roomDrift(cr, &clients.Room{JoinRuleAllow: []string{space}}, "@bot:example.com")
+	assert.Equal(t, []string{"joinRuleAllow"}, drift)
+
+	spec, err := generateRoomSpec(cr)
+	require.NoError(t, err)
+	assert.Equal(t, []string{space, other}, spec.JoinRuleAllow)
+
+	// Allow conditions are left alone unless the spec lists them
+	spec, err = generateRoomSpec(newRoom("!room:example.com", v1alpha1.RoomParameters{}))
+	require.NoError(t, err)
+	assert.Nil(t, spec.JoinRuleAllow)
+}
+
+func TestRoomDriftPowerLevelOverrides(t *testing.T) {
+	ban, kick, usersDefault := 60, 50, 0
+	cr := newRoom("!room:example.com", v1alpha1.RoomParameters{
+		PowerLevelOverrides: &v1alpha1.PowerLevelContent{
+			Users: map[string]int{"@alice:example.com": 100, "@bob:example.com": 0},
+			Ban:   &ban,
+			Kick:  &kick,
+		},
+	})
+
+	tests := []struct {
+		name        string
+		powerLevels *clients.PowerLevelContent
+		want        []string
+	}{
+		{
+			name: "matching",
+			powerLevels: &clients.PowerLevelContent{
+				Users:        map[string]int{"@alice:example.com": 100, "@carol:example.com": 50},
+				UsersDefault: &usersDefault,
+				Ban:          &ban,
+			},
+		},
+		{
+			name: "user changed",
+			powerLevels: &clients.PowerLevelContent{
+				Users: map[string]int{"@alice:example.com": 50},
+				Ban:   &ban,
+			},
+			want: []string{"powerLevelOverrides"},
+		},
+		{
+			name: "level reset to its default",
+			powerLevels: &clients.PowerLevelContent{
+				Users: map[string]int{"@alice:example.com": 100},
+			},
+			want: []string{"powerLevelOverrides"},
+		},
+		{
+			name: "power levels not observed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, roomDrift(cr, &clients.Room{PowerLevels: tt.powerLevels}, "@bot:example.com"))
+		})
+	}
+}
+
+func TestResolveJoinRuleAllowSpaceRef(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, spacev1alpha1.SchemeBuilder.AddToScheme(scheme))
+
+	space := &spacev1alpha1.Space{ObjectMeta: metav1.ObjectMeta{Name: "community"}}
+	kube := fake.NewClientBuilder().WithScheme(scheme).WithObjects(space).Build()
+
+	cr := newRoom("", v1alpha1.RoomParameters{
+		JoinRuleAllow: []v1alpha1.JoinRuleAllowCondition{{SpaceRef: &xpv1.Reference{Name: "community"}}},
+	})
+
+	// The Space hasn't been created yet, so the Room has to wait for it
+	err := cr.ResolveReferences(context.Background(), kube)
+	require.Error(t, err)
+	assert.Nil(t, cr.Spec.ForProvider.JoinRuleAllow[0].RoomID)
+
+	setSpaceID := func(spaceID string) {
+		require.NoError(t, kube.Get(context.Background(), types.NamespacedName{Name: "community"}, space))
+		space.Status.AtProvider.SpaceID = spaceID
+		require.NoError(t, kube.Update(context.Background(), space))
+	}
+
+	setSpaceID("!space:example.com")
+	require.NoError(t, cr.ResolveReferences(context.Background(), kube))
+	assert.Equal(t, "!space:example.com", *cr.Spec.ForProvider.JoinRuleAllow[0].RoomID)
+
+	// A recreated Space is followed to its new room
+	setSpaceID("!recreated:example.com")
+	require.NoError(t, cr.ResolveReferences(context.Background(), kube))
+	assert.Equal(t, "!recreated:example.com", *cr.Spec.ForProvider.JoinRuleAllow[0].RoomID)
+}
+
+func TestDeletePurgeOnDelete(t *testing.T) {
+	purge := true
+	tests := []struct {
+		name   string
+		params v1alpha1.RoomParameters
+		want   bool
+	}{
+		{
+			name: "history is kept by default",
+			want: false,
+		},
+		{
+			name:   "history is purged when requested",
+			params: v1alpha1.RoomParameters{PurgeOnDelete: &purge},
+			want:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mockClient{}
+			e := &external{service: m}
+
+			_, err := e.Delete(context.Background(), newRoom("!room:example.com", tt.params))
+			require.NoError(t, err)
+			require.NotNil(t, m.deleteOpts)
+			assert.Equal(t, tt.want, m.deleteOpts.Purge)
+		})
+	}
+}
+
+func TestObserveDeletedRoom(t *testing.T) {
+	tests := []struct {
+		name     string
+		deletion *clients.RoomDeletion
+		want     bool
+	}{
+		{
+			name: "not yet deleted",
+			want: true,
+		},
+		{
+			name:     "deletion in progress",
+			deletion: &clients.RoomDeletion{Status: clients.RoomDeletionPurging},
+			want:     true,
+		},
+		{
+			name:     "deletion failed",
+			deletion: &clients.RoomDeletion{Status: clients.RoomDeletionFailed, Error: "boom"},
+			want:     true,
+		},
+		{
+			name:     "deletion complete",
+			deletion: &clients.RoomDeletion{Status: clients.RoomDeletionComplete},
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A shut down room whose history is kept is still known to the
+			// homeserver
+			m := &mockClient{
+				room:     &clients.Room{RoomID: "!room:example.com"},
+				deletion: tt.deletion,
+			}
+			e := &external{service: m}
+
+			cr := newRoom("!room:example.com", v1alpha1.RoomParameters{})
+			now := metav1.Now()
+			cr.SetDeletionTimestamp(&now)
+
+			obs, err := e.Observe(context.Background(), cr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, obs.ResourceExists)
+		})
+	}
+}
@@ -18,21 +18,30 @@ package roomalias
 
 import (
 	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/common"
 	"github.com/crossplane-contrib/provider-matrix/apis/roomalias/v1alpha1"
-	apisv1beta1 "github.com/crossplane-contrib/provider-matrix/apis/v1beta1"
 	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane-contrib/provider-matrix/internal/connection"
+	"github.com/crossplane-contrib/provider-matrix/internal/forcedelete"
+	"github.com/crossplane-contrib/provider-matrix/internal/maintenance"
+	"github.com/crossplane-contrib/provider-matrix/internal/metrics"
+	"github.com/crossplane-contrib/provider-matrix/internal/observeonly"
+	"github.com/crossplane-contrib/provider-matrix/internal/permission"
+	"github.com/crossplane-contrib/provider-matrix/internal/provenance"
+	"github.com/crossplane-contrib/provider-matrix/internal/resync"
+	"github.com/crossplane-contrib/provider-matrix/internal/upgrade"
 	"github.com/crossplane/crossplane-runtime/v2/pkg/controller"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/feature"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
 	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
 	"github.com/crossplane/crossplane-runtime/v2/pkg/ratelimiter"
 	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
 	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
 	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
 	"github.com/pkg/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
+	corev1 "k8s.io/api/core/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"time"
 )
 
 const (
@@ -41,6 +50,7 @@ const (
 	errGetPC           = "cannot get ProviderConfig"
 	errGetCreds        = "cannot get credentials"
 	errNewClient       = "cannot create new Matrix client"
+	errSyncProfile     = "cannot sync the provider user's profile"
 	errCreateRoomAlias = "cannot create Matrix room alias"
 	errGetRoomAlias    = "cannot get Matrix room alias"
 	errDeleteRoomAlias = "cannot delete Matrix room alias"
@@ -50,23 +60,30 @@ const (
 func Setup(mgr ctrl.Manager, o controller.Options) error {
 	name := managed.ControllerName(v1alpha1.RoomAliasKind)
 
-	r := managed.NewReconciler(mgr,
-		resource.ManagedKind(v1alpha1.RoomAliasGroupVersionKind),
-		managed.WithExternalConnector(&connector{
+	opts := []managed.ReconcilerOption{
+		managed.WithExternalConnector(maintenance.Connector(v1alpha1.RoomAliasGroupKind, connection.Connector(permission.Connector(forcedelete.Connector(&connector{
 			kube:         mgr.GetClient(),
 			usage:        clients.NewProviderConfigUsageTracker(mgr.GetClient()),
-			newServiceFn: clients.NewClient,
-		}),
+			newServiceFn: clients.CachedClient,
+			logger:       o.Logger.WithValues("controller", name),
+		}, o.Logger.WithValues("controller", name)))))),
 		managed.WithLogger(o.Logger.WithValues("controller", name)),
 		managed.WithPollInterval(o.PollInterval),
-		managed.WithRecorder(nil))
+		managed.WithPollIntervalHook(resync.PollIntervalHook),
+		managed.WithRecorder(nil),
+	}
+	if o.Features.Enabled(feature.EnableBetaManagementPolicies) {
+		opts = append(opts, managed.WithManagementPolicies())
+	}
+
+	r := managed.NewReconciler(mgr, resource.ManagedKind(v1alpha1.RoomAliasGroupVersionKind), opts...)
 
 	return ctrl.NewControllerManagedBy(mgr).
 		Named(name).
 		WithOptions(o.ForControllerRuntime()).
 		WithEventFilter(resource.DesiredStateChanged()).
 		For(&v1alpha1.RoomAlias{}).
-		Complete(ratelimiter.NewReconciler(name, r, o.GlobalRateLimiter))
+		Complete(ratelimiter.NewReconciler(name, maintenance.Reconciler(v1alpha1.RoomAliasGroupKind, r), o.GlobalRateLimiter))
 }
 
 // A connector is expected to produce an ExternalClient when its Connect method
@@ -75,6 +92,7 @@ type connector struct {
 	kube         client.Client
 	usage        resource.ModernTracker
 	newServiceFn func(config *clients.Config) (clients.Client, error)
+	logger       logging.Logger
 }
 
 // Connect typically produces an ExternalClient by:
@@ -83,8 +101,7 @@ type connector struct {
 // 3. Getting the credentials specified by the ProviderConfig.
 // 4. Using the credentials to form a client.
 func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
-	cr, ok := mg.(*v1alpha1.RoomAlias)
-	if !ok {
+	if _, ok := mg.(*v1alpha1.RoomAlias); !ok {
 		return nil, errors.New(errNotRoomAlias)
 	}
 
@@ -96,28 +113,44 @@ func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.E
 		return nil, errors.Wrap(err, errTrackPCUsage)
 	}
 
-	pc := &apisv1beta1.ProviderConfig{}
-	if err := c.kube.Get(ctx, types.NamespacedName{Name: cr.GetProviderConfigReference().Name}, pc); err != nil {
+	pc, err := clients.GetProviderConfig(ctx, c.kube, mg)
+	if err != nil {
 		return nil, errors.Wrap(err, errGetPC)
 	}
+	resync.Set(pc)
 
 	config, err := clients.GetConfig(ctx, c.kube, mg)
 	if err != nil {
 		return nil, errors.Wrap(err, errGetCreds)
 	}
+	config.Logger = c.logger.WithValues("providerConfig", config.ProviderConfigName)
 
 	service, err := c.newServiceFn(config)
 	if err != nil {
 		return nil, errors.Wrap(err, errNewClient)
 	}
 
-	return &external{service: service}, nil
+	if err := clients.VerifyConnection(ctx, c.kube, pc, service); err != nil {
+		return nil, err
+	}
+
+	if err := service.SyncProfile(ctx); err != nil {
+		return nil, errors.Wrap(err, errSyncProfile)
+	}
+
+	return &external{service: service, selfUserID: config.UserID}, nil
 }
 
 // An ExternalClient observes, then either creates, updates, or deletes an
 // external resource to ensure it reflects the managed resource's desired state.
 type external struct {
 	service clients.Client
+
+	// selfUserID is the user the provider authenticates as
+	selfUserID string
+
+	// roomID is the room the alias should point at, as resolved by Observe
+	roomID string
 }
 
 func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
@@ -126,6 +159,16 @@ func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 		return managed.ExternalObservation{}, errors.New(errNotRoomAlias)
 	}
 
+	roomID := c.targetRoom(ctx, cr)
+	// Aliases can point at rooms created on other homeservers, which are
+	// reported since only the state they share over federation can be
+	// observed
+	if serverName, remote := clients.RemoteRoom(roomID, c.selfUserID); remote {
+		cr.Status.SetConditions(common.RemoteRoom(roomID, serverName))
+	} else if cr.Status.GetCondition(common.TypeRemoteRoom).Status == corev1.ConditionTrue {
+		cr.Status.SetConditions(common.LocalRoom())
+	}
+
 	alias := cr.Spec.ForProvider.Alias
 	roomAlias, err := c.service.GetRoomAlias(ctx, alias)
 	if err != nil {
@@ -137,12 +180,19 @@ func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 		return managed.ExternalObservation{}, errors.Wrap(err, errGetRoomAlias)
 	}
 
+	adoptedAt := cr.Status.AtProvider.AdoptedAt
 	cr.Status.AtProvider = generateRoomAliasObservation(roomAlias)
+	cr.Status.AtProvider.ManagedBy, cr.Status.AtProvider.AdoptedAt = provenance.Observe(cr, adoptedAt)
 	cr.Status.SetConditions(xpv1.Available())
 
+	// The room the alias points at is read from forProvider to target
+	// updates, so an observe-only one is dropped from the drift instead
+	drift := observeonly.Drift(roomAliasDrift(roomID, roomAlias), cr.Spec.ForProvider.ObserveOnlyFields)
+	metrics.RecordDrift(v1alpha1.RoomAliasKind, drift)
+
 	return managed.ExternalObservation{
 		ResourceExists:   true,
-		ResourceUpToDate: isRoomAliasUpToDate(cr, roomAlias),
+		ResourceUpToDate: len(drift) == 0,
 	}, nil
 }
 
@@ -153,9 +203,15 @@ func (c *external) Create(ctx context.Context, mg resource.Managed) (managed.Ext
 	}
 
 	alias := cr.Spec.ForProvider.Alias
-	roomID := cr.Spec.ForProvider.RoomID
+	roomID := c.targetRoomID(cr)
 
 	err := c.service.CreateRoomAlias(ctx, alias, roomID)
+	var conflict *clients.AliasConflictError
+	if errors.As(err, &conflict) && conflict.RoomID == roomID {
+		// An earlier Create succeeded but its result was never recorded,
+		// for example because the provider restarted
+		err = nil
+	}
 	if err != nil {
 		return managed.ExternalCreation{}, errors.Wrap(err, errCreateRoomAlias)
 	}
@@ -174,7 +230,7 @@ func (c *external) Update(ctx context.Context, mg resource.Managed) (managed.Ext
 	}
 
 	alias := cr.Spec.ForProvider.Alias
-	roomID := cr.Spec.ForProvider.RoomID
+	roomID := c.targetRoomID(cr)
 
 	// Delete existing alias
 	err := c.service.DeleteRoomAlias(ctx, alias)
@@ -214,26 +270,51 @@ func (c *external) Disconnect(ctx context.Context) error {
 	return nil // No special disconnect logic needed
 }
 
+// targetRoom returns the room the alias should point at: the spec's room, or
+// the room that replaced it if it was upgraded and upgrades are followed. An
+// upgrade is reported by the RoomUpgraded condition.
+func (c *external) targetRoom(ctx context.Context, cr *v1alpha1.RoomAlias) string {
+	follow := cr.Spec.ForProvider.FollowRoomUpgrades != nil && *cr.Spec.ForProvider.FollowRoomUpgrades
+	c.roomID = upgrade.TargetRoom(ctx, c.service, cr, cr.Spec.ForProvider.RoomID, follow)
+	return c.roomID
+}
+
+// targetRoomID returns the room resolved by Observe, falling back to the
+// spec's room
+func (c *external) targetRoomID(cr *v1alpha1.RoomAlias) string {
+	if c.roomID != "" {
+		return c.roomID
+	}
+	return cr.Spec.ForProvider.RoomID
+}
+
 // Helper functions
 
 func generateRoomAliasObservation(roomAlias *clients.RoomAlias) v1alpha1.RoomAliasObservation {
 	obs := v1alpha1.RoomAliasObservation{
-		Alias:        roomAlias.Alias,
-		RoomID:       roomAlias.RoomID,
-		IsCanonical:  false, // This would need to be determined by checking room state
-		IsPublished:  true,  // Assume published if alias exists
-		CreationTime: &metav1.Time{Time: time.Now()},
-		Servers:      []string{}, // Would need to be extracted from resolve response
+		Alias:       roomAlias.Alias,
+		RoomID:      roomAlias.RoomID,
+		IsCanonical: false, // This would need to be determined by checking room state
+		IsPublished: true,  // Assume published if alias exists
+		Servers:     roomAlias.Servers,
 	}
 
 	return obs
 }
 
 func isRoomAliasUpToDate(cr *v1alpha1.RoomAlias, roomAlias *clients.RoomAlias) bool {
+	return len(roomAliasDrift(cr.Spec.ForProvider.RoomID, roomAlias)) == 0
+}
+
+// roomAliasDrift returns the spec fields that differ from the observed alias,
+// given the room the alias should point at
+func roomAliasDrift(roomID string, roomAlias *clients.RoomAlias) []string {
+	var drift []string
+
 	// Check if the alias points to the correct room
-	if cr.Spec.ForProvider.RoomID != roomAlias.RoomID {
-		return false
+	if roomID != roomAlias.RoomID {
+		drift = append(drift, "roomID")
 	}
 
-	return true
+	return drift
 }
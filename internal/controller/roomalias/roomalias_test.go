@@ -0,0 +1,221 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package roomalias
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/common"
+	namespacedroomv1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/namespaced/room/v1alpha1"
+	namespacedv1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/namespaced/roomalias/v1alpha1"
+	roomv1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/room/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/apis/roomalias/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"maunium.net/go/mautrix"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"testing"
+	"time"
+)
+
+type mockClient struct {
+	clients.Client
+
+	// aliases maps the aliases that exist to the rooms they point at
+	aliases map[string]string
+	// servers are the servers resolving an alias reports
+	servers []string
+}
+
+func (m *mockClient) CreateRoomAlias(ctx context.Context, alias string, roomID string) error {
+	if existing, ok := m.aliases[alias]; ok {
+		return &clients.AliasConflictError{Alias: alias, RoomID: existing}
+	}
+	m.aliases[alias] = roomID
+	return nil
+}
+
+func (m *mockClient) GetRoomAlias(ctx context.Context, alias string) (*clients.RoomAlias, error) {
+	roomID, ok := m.aliases[alias]
+	if !ok {
+		return nil, mautrix.MNotFound
+	}
+	return &clients.RoomAlias{Alias: alias, RoomID: roomID, Servers: m.servers}, nil
+}
+
+func (m *mockClient) GetReplacementRoom(ctx context.Context, roomID string) (string, error) {
+	return roomID, nil
+}
+
+func TestObserveProvenance(t *testing.T) {
+	m := &mockClient{aliases: map[string]string{"#general:example.com": "!room:example.com"}}
+	newAlias := func() *v1alpha1.RoomAlias {
+		return &v1alpha1.RoomAlias{Spec: v1alpha1.RoomAliasSpec{ForProvider: v1alpha1.RoomAliasParameters{
+			Alias:  "#general:example.com",
+			RoomID: "!room:example.com",
+		}}}
+	}
+	e := &external{service: m}
+
+	adopted := newAlias()
+	_, err := e.Observe(context.Background(), adopted)
+	require.NoError(t, err)
+	assert.Equal(t, common.ManagedByAdopted, adopted.Status.AtProvider.ManagedBy)
+	adoptedAt := adopted.Status.AtProvider.AdoptedAt
+	require.NotNil(t, adoptedAt)
+
+	_, err = e.Observe(context.Background(), adopted)
+	require.NoError(t, err)
+	assert.Same(t, adoptedAt, adopted.Status.AtProvider.AdoptedAt)
+
+	created := newAlias()
+	meta.SetExternalCreateSucceeded(created, time.Now())
+	_, err = e.Observe(context.Background(), created)
+	require.NoError(t, err)
+	assert.Equal(t, common.ManagedByCreated, created.Status.AtProvider.ManagedBy)
+	assert.Nil(t, created.Status.AtProvider.AdoptedAt)
+}
+
+func TestObserveServers(t *testing.T) {
+	m := &mockClient{
+		aliases: map[string]string{"#general:example.com": "!room:example.com"},
+		servers: []string{"example.com", "matrix.org"},
+	}
+	cr := &v1alpha1.RoomAlias{Spec: v1alpha1.RoomAliasSpec{ForProvider: v1alpha1.RoomAliasParameters{
+		Alias:  "#general:example.com",
+		RoomID: "!room:example.com",
+	}}}
+
+	_, err := (&external{service: m}).Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"example.com", "matrix.org"}, cr.Status.AtProvider.Servers)
+}
+
+func TestObserveOnlyRoomID(t *testing.T) {
+	m := &mockClient{aliases: map[string]string{"#general:example.com": "!other:example.com"}}
+	cr := &v1alpha1.RoomAlias{Spec: v1alpha1.RoomAliasSpec{ForProvider: v1alpha1.RoomAliasParameters{
+		Alias:  "#general:example.com",
+		RoomID: "!room:example.com",
+	}}}
+	e := &external{service: m}
+
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceUpToDate)
+
+	// An alias pointed elsewhere by room admins is only observed
+	cr.Spec.ForProvider.ObserveOnlyFields = []string{"roomID"}
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceUpToDate)
+	assert.Equal(t, "!other:example.com", cr.Status.AtProvider.RoomID)
+}
+
+func TestCreateAfterCrash(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		wantErr  bool
+	}{
+		{
+			name:     "alias created by an earlier attempt",
+			existing: "!room:example.com",
+		},
+		{
+			name:     "alias points at another room",
+			existing: "!other:example.com",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The alias was created, but the provider crashed before the
+			// external name was recorded
+			m := &mockClient{aliases: map[string]string{"#general:example.com": tt.existing}}
+			cr := &v1alpha1.RoomAlias{Spec: v1alpha1.RoomAliasSpec{ForProvider: v1alpha1.RoomAliasParameters{
+				Alias:  "#general:example.com",
+				RoomID: "!room:example.com",
+			}}}
+
+			e := &external{service: m}
+			_, err := e.Create(context.Background(), cr)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Empty(t, meta.GetExternalName(cr))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "#general:example.com", meta.GetExternalName(cr))
+		})
+	}
+}
+
+func TestResolveRoomIDSelector(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, roomv1alpha1.SchemeBuilder.AddToScheme(scheme))
+
+	newRoom := func(name, roomID string) *roomv1alpha1.Room {
+		room := &roomv1alpha1.Room{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"team": name}}}
+		meta.SetExternalName(room, roomID)
+		return room
+	}
+	kube := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newRoom("general", "!general:example.com"),
+		newRoom("random", "!random:example.com"),
+	).Build()
+
+	cr := &v1alpha1.RoomAlias{Spec: v1alpha1.RoomAliasSpec{ForProvider: v1alpha1.RoomAliasParameters{
+		Alias:          "#random:example.com",
+		RoomIDSelector: &xpv1.Selector{MatchLabels: map[string]string{"team": "random"}},
+	}}}
+
+	require.NoError(t, cr.ResolveReferences(context.Background(), kube))
+	assert.Equal(t, "!random:example.com", cr.Spec.ForProvider.RoomID)
+	assert.Equal(t, "random", cr.Spec.ForProvider.RoomIDRef.Name)
+}
+
+func TestResolveNamespacedRoomIDSelector(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, namespacedroomv1alpha1.SchemeBuilder.AddToScheme(scheme))
+
+	newRoom := func(namespace, roomID string) *namespacedroomv1alpha1.Room {
+		room := &namespacedroomv1alpha1.Room{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "general", Labels: map[string]string{"team": "general"}}}
+		meta.SetExternalName(room, roomID)
+		return room
+	}
+	kube := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newRoom("team-a", "!a:example.com"),
+		newRoom("team-b", "!b:example.com"),
+	).Build()
+
+	cr := &namespacedv1alpha1.RoomAlias{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-b", Name: "general"},
+		Spec: v1alpha1.RoomAliasSpec{ForProvider: v1alpha1.RoomAliasParameters{
+			Alias:          "#general:example.com",
+			RoomIDSelector: &xpv1.Selector{MatchLabels: map[string]string{"team": "general"}},
+		}},
+	}
+
+	// Only the Room in the alias's own namespace is selected
+	require.NoError(t, cr.ResolveReferences(context.Background(), kube))
+	assert.Equal(t, "!b:example.com", cr.Spec.ForProvider.RoomID)
+}
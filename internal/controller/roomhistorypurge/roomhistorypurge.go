@@ -0,0 +1,287 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package roomhistorypurge
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/roomhistorypurge/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane-contrib/provider-matrix/internal/connection"
+	"github.com/crossplane-contrib/provider-matrix/internal/forcedelete"
+	"github.com/crossplane-contrib/provider-matrix/internal/maintenance"
+	"github.com/crossplane-contrib/provider-matrix/internal/metrics"
+	"github.com/crossplane-contrib/provider-matrix/internal/permission"
+	"github.com/crossplane-contrib/provider-matrix/internal/resync"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/controller"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/feature"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/ratelimiter"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	"github.com/pkg/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strconv"
+	"time"
+)
+
+const (
+	errNotRoomHistoryPurge = "managed resource is not a RoomHistoryPurge custom resource"
+	errTrackPCUsage        = "cannot track ProviderConfig usage"
+	errGetPC               = "cannot get ProviderConfig"
+	errGetCreds            = "cannot get credentials"
+	errNewClient           = "cannot create new Matrix client"
+	errSyncProfile         = "cannot sync the provider user's profile"
+	errGetPurge            = "cannot get Matrix room history purge"
+	errPurgeHistory        = "cannot purge Matrix room history"
+	errNoPurgeUpToTs       = "purgeUpToTs is required"
+)
+
+// annotationPurgedUpToTs records the time the latest purge purged events up
+// to. It is kept in an annotation rather than the status because changes
+// Create makes to the status are lost when the external name is persisted.
+const annotationPurgedUpToTs = "roomhistorypurge.matrix.crossplane.io/purged-up-to-ts"
+
+// Setup adds a controller that reconciles RoomHistoryPurge managed resources.
+func Setup(mgr ctrl.Manager, o controller.Options) error {
+	name := managed.ControllerName(v1alpha1.RoomHistoryPurgeKind)
+
+	opts := []managed.ReconcilerOption{
+		managed.WithExternalConnector(maintenance.Connector(v1alpha1.RoomHistoryPurgeGroupKind, connection.Connector(permission.Connector(forcedelete.Connector(&connector{
+			kube:         mgr.GetClient(),
+			usage:        clients.NewProviderConfigUsageTracker(mgr.GetClient()),
+			newServiceFn: clients.CachedClient,
+			logger:       o.Logger.WithValues("controller", name),
+		}, o.Logger.WithValues("controller", name)))))),
+		// The external name is the ID of the purge, which only the homeserver
+		// can give, so it isn't initialized to the resource's name
+		managed.WithInitializers(),
+		managed.WithLogger(o.Logger.WithValues("controller", name)),
+		managed.WithPollInterval(o.PollInterval),
+		managed.WithPollIntervalHook(resync.PollIntervalHook),
+		managed.WithRecorder(nil),
+	}
+	if o.Features.Enabled(feature.EnableBetaManagementPolicies) {
+		opts = append(opts, managed.WithManagementPolicies())
+	}
+
+	r := managed.NewReconciler(mgr, resource.ManagedKind(v1alpha1.RoomHistoryPurgeGroupVersionKind), opts...)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(name).
+		WithOptions(o.ForControllerRuntime()).
+		WithEventFilter(resource.DesiredStateChanged()).
+		For(&v1alpha1.RoomHistoryPurge{}).
+		Complete(ratelimiter.NewReconciler(name, maintenance.Reconciler(v1alpha1.RoomHistoryPurgeGroupKind, r), o.GlobalRateLimiter))
+}
+
+// A connector is expected to produce an ExternalClient when its Connect method
+// is called.
+type connector struct {
+	kube         client.Client
+	usage        resource.ModernTracker
+	newServiceFn func(config *clients.Config) (clients.Client, error)
+	logger       logging.Logger
+}
+
+// Connect typically produces an ExternalClient by:
+// 1. Tracking that the managed resource is using a ProviderConfig.
+// 2. Getting the managed resource's ProviderConfig.
+// 3. Getting the credentials specified by the ProviderConfig.
+// 4. Using the credentials to form a client.
+func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	if _, ok := mg.(*v1alpha1.RoomHistoryPurge); !ok {
+		return nil, errors.New(errNotRoomHistoryPurge)
+	}
+
+	modernManaged, ok := mg.(resource.ModernManaged)
+	if !ok {
+		return nil, errors.New("managed resource does not implement ModernManaged")
+	}
+	if err := c.usage.Track(ctx, modernManaged); err != nil {
+		return nil, errors.Wrap(err, errTrackPCUsage)
+	}
+
+	pc, err := clients.GetProviderConfig(ctx, c.kube, mg)
+	if err != nil {
+		return nil, errors.Wrap(err, errGetPC)
+	}
+	resync.Set(pc)
+
+	config, err := clients.GetConfig(ctx, c.kube, mg)
+	if err != nil {
+		return nil, errors.Wrap(err, errGetCreds)
+	}
+	config.Logger = c.logger.WithValues("providerConfig", config.ProviderConfigName)
+
+	service, err := c.newServiceFn(config)
+	if err != nil {
+		return nil, errors.Wrap(err, errNewClient)
+	}
+
+	if err := clients.VerifyConnection(ctx, c.kube, pc, service); err != nil {
+		return nil, err
+	}
+
+	if err := service.SyncProfile(ctx); err != nil {
+		return nil, errors.Wrap(err, errSyncProfile)
+	}
+
+	return &external{service: service}, nil
+}
+
+// An ExternalClient observes, then either creates, updates, or deletes an
+// external resource to ensure it reflects the managed resource's desired state.
+type external struct {
+	service clients.Client
+}
+
+func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
+	cr, ok := mg.(*v1alpha1.RoomHistoryPurge)
+	if !ok {
+		return managed.ExternalObservation{}, errors.New(errNotRoomHistoryPurge)
+	}
+
+	// Purged history can't be restored, so there is nothing left to delete
+	// once the resource is being deleted, even while a purge still runs
+	if meta.WasDeleted(cr) {
+		return managed.ExternalObservation{
+			ResourceExists: false,
+		}, nil
+	}
+
+	purgeID := meta.GetExternalName(cr)
+	if purgeID == "" {
+		return managed.ExternalObservation{
+			ResourceExists: false,
+		}, nil
+	}
+
+	purge, err := c.service.GetHistoryPurge(ctx, purgeID)
+	switch {
+	case clients.IsNotFound(err):
+		// Synapse forgets purges a while after they finish, and when it
+		// restarts. A purge that was cut short by a restart is started again.
+		if cr.Status.AtProvider.Status != clients.HistoryPurgeComplete {
+			return managed.ExternalObservation{
+				ResourceExists: false,
+			}, nil
+		}
+		purge = &clients.HistoryPurge{Status: clients.HistoryPurgeComplete}
+	case err != nil:
+		return managed.ExternalObservation{}, errors.Wrap(err, errGetPurge)
+	}
+
+	cr.Status.AtProvider.PurgeID = purgeID
+	cr.Status.AtProvider.Status = purge.Status
+	cr.Status.AtProvider.Error = purge.Error
+	cr.Status.AtProvider.PurgedUpToTs = purgedUpToTs(cr)
+
+	// A purge up to another time is started once the current one is over,
+	// by reporting the purge as missing so that it is created again
+	drift := purgeDrift(cr)
+	metrics.RecordDrift(v1alpha1.RoomHistoryPurgeKind, drift)
+	if len(drift) > 0 && purge.Status != clients.HistoryPurgeActive {
+		return managed.ExternalObservation{
+			ResourceExists: false,
+		}, nil
+	}
+
+	switch purge.Status {
+	case clients.HistoryPurgeComplete:
+		cr.Status.SetConditions(xpv1.Available())
+	case clients.HistoryPurgeFailed:
+		cr.Status.SetConditions(xpv1.Unavailable().WithMessage("purge failed: " + purge.Error))
+	default:
+		cr.Status.SetConditions(xpv1.Creating())
+	}
+
+	return managed.ExternalObservation{
+		ResourceExists:   true,
+		ResourceUpToDate: true,
+	}, nil
+}
+
+func (c *external) Create(ctx context.Context, mg resource.Managed) (managed.ExternalCreation, error) {
+	cr, ok := mg.(*v1alpha1.RoomHistoryPurge)
+	if !ok {
+		return managed.ExternalCreation{}, errors.New(errNotRoomHistoryPurge)
+	}
+
+	p := cr.Spec.ForProvider
+	if p.PurgeUpToTs == nil {
+		return managed.ExternalCreation{}, errors.New(errNoPurgeUpToTs)
+	}
+	deleteLocalEvents := p.DeleteLocalEvents != nil && *p.DeleteLocalEvents
+
+	purgeID, err := c.service.PurgeHistory(ctx, p.RoomID, time.UnixMilli(*p.PurgeUpToTs), deleteLocalEvents)
+	if err != nil {
+		return managed.ExternalCreation{}, errors.Wrap(err, errPurgeHistory)
+	}
+
+	meta.SetExternalName(cr, purgeID)
+	meta.AddAnnotations(cr, map[string]string{
+		annotationPurgedUpToTs: strconv.FormatInt(*p.PurgeUpToTs, 10),
+	})
+
+	return managed.ExternalCreation{}, nil
+}
+
+func (c *external) Update(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
+	if _, ok := mg.(*v1alpha1.RoomHistoryPurge); !ok {
+		return managed.ExternalUpdate{}, errors.New(errNotRoomHistoryPurge)
+	}
+
+	// A purge can't be changed once started. Purging up to another time
+	// starts a new purge through Create.
+	return managed.ExternalUpdate{}, nil
+}
+
+func (c *external) Delete(ctx context.Context, mg resource.Managed) (managed.ExternalDelete, error) {
+	if _, ok := mg.(*v1alpha1.RoomHistoryPurge); !ok {
+		return managed.ExternalDelete{}, errors.New(errNotRoomHistoryPurge)
+	}
+
+	// Purged history can't be restored, so there is nothing to delete
+	return managed.ExternalDelete{}, nil
+}
+
+// Disconnect closes the external client.
+func (c *external) Disconnect(ctx context.Context) error {
+	return nil // No special disconnect logic needed
+}
+
+// purgedUpToTs returns the time the latest purge purged events up to, or nil
+// if it isn't known
+func purgedUpToTs(cr *v1alpha1.RoomHistoryPurge) *int64 {
+	ts, err := strconv.ParseInt(cr.GetAnnotations()[annotationPurgedUpToTs], 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &ts
+}
+
+// purgeDrift returns the spec fields that differ from the latest purge
+func purgeDrift(cr *v1alpha1.RoomHistoryPurge) []string {
+	want, purged := cr.Spec.ForProvider.PurgeUpToTs, cr.Status.AtProvider.PurgedUpToTs
+	if want != nil && (purged == nil || *want != *purged) {
+		return []string{"purgeUpToTs"}
+	}
+	return nil
+}
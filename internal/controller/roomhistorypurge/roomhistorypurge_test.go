@@ -0,0 +1,190 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package roomhistorypurge
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/roomhistorypurge/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type mockClient struct {
+	clients.Client
+
+	purges  map[string]*clients.HistoryPurge
+	purged  []time.Time
+	nextIDs []string
+}
+
+func (m *mockClient) PurgeHistory(ctx context.Context, roomID string, upTo time.Time, deleteLocalEvents bool) (string, error) {
+	purgeID := m.nextIDs[0]
+	m.nextIDs = m.nextIDs[1:]
+	m.purged = append(m.purged, upTo)
+	m.purges[purgeID] = &clients.HistoryPurge{Status: clients.HistoryPurgeActive}
+	return purgeID, nil
+}
+
+func (m *mockClient) GetHistoryPurge(ctx context.Context, purgeID string) (*clients.HistoryPurge, error) {
+	purge, ok := m.purges[purgeID]
+	if !ok {
+		return nil, &clients.APIError{StatusCode: http.StatusNotFound, ErrCode: "M_NOT_FOUND", Body: "{}"}
+	}
+	return purge, nil
+}
+
+func newRoomHistoryPurge(purgeUpToTs int64) *v1alpha1.RoomHistoryPurge {
+	return &v1alpha1.RoomHistoryPurge{Spec: v1alpha1.RoomHistoryPurgeSpec{ForProvider: v1alpha1.RoomHistoryPurgeParameters{
+		RoomID:      "!room:example.com",
+		PurgeUpToTs: &purgeUpToTs,
+	}}}
+}
+
+func TestPurge(t *testing.T) {
+	m := &mockClient{purges: map[string]*clients.HistoryPurge{}, nextIDs: []string{"first", "second"}}
+	e := &external{service: m}
+	cr := newRoomHistoryPurge(1700000000000)
+
+	// The reconciler drops the changes Create makes to the status
+	create := func() {
+		t.Helper()
+		status := cr.Status.DeepCopy()
+		_, err := e.Create(context.Background(), cr)
+		require.NoError(t, err)
+		cr.Status = *status
+	}
+
+	// Nothing is purged until the resource is created
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceExists)
+
+	create()
+	assert.Equal(t, "first", meta.GetExternalName(cr))
+	assert.Equal(t, []time.Time{time.UnixMilli(1700000000000)}, m.purged)
+
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceExists)
+	assert.Equal(t, clients.HistoryPurgeActive, cr.Status.AtProvider.Status)
+	assert.Equal(t, xpv1.ReasonCreating, cr.Status.GetCondition(xpv1.TypeReady).Reason)
+
+	// A later time isn't purged until the running purge is over
+	*cr.Spec.ForProvider.PurgeUpToTs = 1800000000000
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceExists)
+
+	m.purges["first"].Status = clients.HistoryPurgeComplete
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceExists)
+
+	create()
+	assert.Equal(t, "second", meta.GetExternalName(cr))
+
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceExists)
+	assert.Equal(t, int64(1800000000000), *cr.Status.AtProvider.PurgedUpToTs)
+
+	// A finished purge that the homeserver has forgotten stays complete
+	m.purges["second"].Status = clients.HistoryPurgeComplete
+	_, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	delete(m.purges, "second")
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceExists)
+	assert.True(t, obs.ResourceUpToDate)
+	assert.Equal(t, corev1.ConditionTrue, cr.Status.GetCondition(xpv1.TypeReady).Status)
+	assert.Len(t, m.purged, 2)
+}
+
+func TestObserveDeletedPurge(t *testing.T) {
+	for _, status := range []string{clients.HistoryPurgeActive, clients.HistoryPurgeComplete} {
+		t.Run(status, func(t *testing.T) {
+			// A complete purge the homeserver has forgotten is deleted too
+			m := &mockClient{purges: map[string]*clients.HistoryPurge{}}
+			if status == clients.HistoryPurgeActive {
+				m.purges["abcdef"] = &clients.HistoryPurge{Status: status}
+			}
+			e := &external{service: m}
+			cr := newRoomHistoryPurge(1700000000000)
+			meta.SetExternalName(cr, "abcdef")
+			meta.AddAnnotations(cr, map[string]string{annotationPurgedUpToTs: "1700000000000"})
+			cr.Status.AtProvider.Status = status
+			now := metav1.Now()
+			cr.SetDeletionTimestamp(&now)
+
+			obs, err := e.Observe(context.Background(), cr)
+			require.NoError(t, err)
+			assert.False(t, obs.ResourceExists)
+		})
+	}
+}
+
+func TestObservePurge(t *testing.T) {
+	tests := []struct {
+		name       string
+		purge      *clients.HistoryPurge
+		lastStatus string
+		wantExists bool
+		wantReady  corev1.ConditionStatus
+	}{
+		{
+			name:       "failed",
+			purge:      &clients.HistoryPurge{Status: clients.HistoryPurgeFailed, Error: "boom"},
+			lastStatus: clients.HistoryPurgeActive,
+			wantExists: true,
+			wantReady:  corev1.ConditionFalse,
+		},
+		{
+			name:       "interrupted by a restart",
+			lastStatus: clients.HistoryPurgeActive,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mockClient{purges: map[string]*clients.HistoryPurge{}}
+			if tt.purge != nil {
+				m.purges["abcdef"] = tt.purge
+			}
+			e := &external{service: m}
+			cr := newRoomHistoryPurge(1700000000000)
+			meta.SetExternalName(cr, "abcdef")
+			meta.AddAnnotations(cr, map[string]string{annotationPurgedUpToTs: "1700000000000"})
+			cr.Status.AtProvider.Status = tt.lastStatus
+
+			obs, err := e.Observe(context.Background(), cr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantExists, obs.ResourceExists)
+			if tt.wantExists {
+				assert.Equal(t, tt.wantReady, cr.Status.GetCondition(xpv1.TypeReady).Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,349 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package roommembership
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/common"
+	"github.com/crossplane-contrib/provider-matrix/apis/roommembership/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane-contrib/provider-matrix/internal/connection"
+	"github.com/crossplane-contrib/provider-matrix/internal/forcedelete"
+	"github.com/crossplane-contrib/provider-matrix/internal/maintenance"
+	"github.com/crossplane-contrib/provider-matrix/internal/metrics"
+	"github.com/crossplane-contrib/provider-matrix/internal/observeonly"
+	"github.com/crossplane-contrib/provider-matrix/internal/permission"
+	"github.com/crossplane-contrib/provider-matrix/internal/provenance"
+	"github.com/crossplane-contrib/provider-matrix/internal/resync"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/controller"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/feature"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/ratelimiter"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"maps"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"slices"
+)
+
+const (
+	errNotRoomMembership = "managed resource is not a RoomMembership custom resource"
+	errTrackPCUsage      = "cannot track ProviderConfig usage"
+	errGetPC             = "cannot get ProviderConfig"
+	errGetCreds          = "cannot get credentials"
+	errNewClient         = "cannot create new Matrix client"
+	errSyncProfile       = "cannot sync the provider user's profile"
+	errGetMembership     = "cannot get Matrix room membership"
+	errSetMembership     = "cannot set Matrix room membership"
+	errGetPowerLevels    = "cannot get Matrix power levels"
+	errSetPowerLevel     = "cannot set the user's Matrix power level"
+)
+
+// Setup adds a controller that reconciles RoomMembership managed resources.
+func Setup(mgr ctrl.Manager, o controller.Options) error {
+	name := managed.ControllerName(v1alpha1.RoomMembershipKind)
+
+	opts := []managed.ReconcilerOption{
+		managed.WithExternalConnector(maintenance.Connector(v1alpha1.RoomMembershipGroupKind, connection.Connector(permission.Connector(forcedelete.Connector(&connector{
+			kube:         mgr.GetClient(),
+			usage:        clients.NewProviderConfigUsageTracker(mgr.GetClient()),
+			newServiceFn: clients.CachedClient,
+			logger:       o.Logger.WithValues("controller", name),
+		}, o.Logger.WithValues("controller", name)))))),
+		managed.WithLogger(o.Logger.WithValues("controller", name)),
+		managed.WithPollInterval(o.PollInterval),
+		managed.WithPollIntervalHook(resync.PollIntervalHook),
+		managed.WithRecorder(nil),
+	}
+	if o.Features.Enabled(feature.EnableBetaManagementPolicies) {
+		opts = append(opts, managed.WithManagementPolicies())
+	}
+
+	r := managed.NewReconciler(mgr, resource.ManagedKind(v1alpha1.RoomMembershipGroupVersionKind), opts...)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(name).
+		WithOptions(o.ForControllerRuntime()).
+		WithEventFilter(resource.DesiredStateChanged()).
+		For(&v1alpha1.RoomMembership{}).
+		Complete(ratelimiter.NewReconciler(name, maintenance.Reconciler(v1alpha1.RoomMembershipGroupKind, r), o.GlobalRateLimiter))
+}
+
+// A connector is expected to produce an ExternalClient when its Connect method
+// is called.
+type connector struct {
+	kube         client.Client
+	usage        resource.ModernTracker
+	newServiceFn func(config *clients.Config) (clients.Client, error)
+	logger       logging.Logger
+}
+
+// Connect typically produces an ExternalClient by:
+// 1. Tracking that the managed resource is using a ProviderConfig.
+// 2. Getting the managed resource's ProviderConfig.
+// 3. Getting the credentials specified by the ProviderConfig.
+// 4. Using the credentials to form a client.
+func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	if _, ok := mg.(*v1alpha1.RoomMembership); !ok {
+		return nil, errors.New(errNotRoomMembership)
+	}
+
+	modernManaged, ok := mg.(resource.ModernManaged)
+	if !ok {
+		return nil, errors.New("managed resource does not implement ModernManaged")
+	}
+	if err := c.usage.Track(ctx, modernManaged); err != nil {
+		return nil, errors.Wrap(err, errTrackPCUsage)
+	}
+
+	pc, err := clients.GetProviderConfig(ctx, c.kube, mg)
+	if err != nil {
+		return nil, errors.Wrap(err, errGetPC)
+	}
+	resync.Set(pc)
+
+	config, err := clients.GetConfig(ctx, c.kube, mg)
+	if err != nil {
+		return nil, errors.Wrap(err, errGetCreds)
+	}
+	config.Logger = c.logger.WithValues("providerConfig", config.ProviderConfigName)
+
+	service, err := c.newServiceFn(config)
+	if err != nil {
+		return nil, errors.Wrap(err, errNewClient)
+	}
+
+	if err := clients.VerifyConnection(ctx, c.kube, pc, service); err != nil {
+		return nil, err
+	}
+
+	if err := service.SyncProfile(ctx); err != nil {
+		return nil, errors.Wrap(err, errSyncProfile)
+	}
+
+	return &external{service: service, selfUserID: config.UserID}, nil
+}
+
+// An ExternalClient observes, then either creates, updates, or deletes an
+// external resource to ensure it reflects the managed resource's desired state.
+type external struct {
+	service clients.Client
+
+	// selfUserID is the user the provider authenticates as
+	selfUserID string
+
+	// observed are the room's power levels as last observed, so that Update
+	// can refuse to overwrite changes made since
+	observed *clients.PowerLevelContent
+}
+
+func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
+	cr, ok := mg.(*v1alpha1.RoomMembership)
+	if !ok {
+		return managed.ExternalObservation{}, errors.New(errNotRoomMembership)
+	}
+
+	// A membership of a room created on another homeserver can't be read or
+	// forced through the admin API of the provider's homeserver, only by a
+	// provider that is in the room, which is reported
+	if serverName, remote := clients.RemoteRoom(cr.Spec.ForProvider.RoomID, c.selfUserID); remote {
+		cr.Status.SetConditions(common.RemoteRoom(cr.Spec.ForProvider.RoomID, serverName))
+	} else if cr.Status.GetCondition(common.TypeRemoteRoom).Status == corev1.ConditionTrue {
+		cr.Status.SetConditions(common.LocalRoom())
+	}
+
+	membership, err := c.service.GetUserMembership(ctx, cr.Spec.ForProvider.RoomID, cr.Spec.ForProvider.UserID)
+	if err != nil {
+		return managed.ExternalObservation{}, errors.Wrap(err, errGetMembership)
+	}
+	cr.Status.AtProvider.Membership = membership
+	cr.Status.AtProvider.ManagedBy, cr.Status.AtProvider.AdoptedAt = provenance.Observe(cr, cr.Status.AtProvider.AdoptedAt)
+
+	// A user always has some membership of a room, so it only stops
+	// existing once Delete has removed the user, if it is to remove them
+	if meta.WasDeleted(cr) {
+		return managed.ExternalObservation{
+			ResourceExists: leaveOnDelete(cr) && inRoom(membership),
+		}, nil
+	}
+
+	// The power level is only given once the user has joined, and until
+	// then is reported as pending
+	cr.Status.AtProvider.PowerLevel, cr.Status.AtProvider.PendingPowerLevel = nil, nil
+	if desired := cr.Spec.ForProvider.PowerLevel; desired != nil {
+		c.observed, err = c.service.GetPowerLevels(ctx, cr.Spec.ForProvider.RoomID)
+		if err != nil {
+			return managed.ExternalObservation{}, errors.Wrap(err, errGetPowerLevels)
+		}
+		level := userPowerLevel(c.observed, cr.Spec.ForProvider.UserID)
+		cr.Status.AtProvider.PowerLevel = &level
+		if membership != v1alpha1.MembershipJoin {
+			cr.Status.AtProvider.PendingPowerLevel = desired
+		}
+	}
+
+	cr.Status.SetConditions(xpv1.Available())
+
+	drift := append(membershipDrift(cr, membership), powerLevelDrift(cr)...)
+	settleObserveOnlyFields(cr, drift)
+	drift = observeonly.Drift(drift, cr.Status.AtProvider.SettledFields)
+	metrics.RecordDrift(v1alpha1.RoomMembershipKind, drift)
+
+	return managed.ExternalObservation{
+		ResourceExists:   true,
+		ResourceUpToDate: len(drift) == 0,
+	}, nil
+}
+
+func (c *external) Create(ctx context.Context, mg resource.Managed) (managed.ExternalCreation, error) {
+	cr, ok := mg.(*v1alpha1.RoomMembership)
+	if !ok {
+		return managed.ExternalCreation{}, errors.New(errNotRoomMembership)
+	}
+
+	// Observe always finds a membership, so Create isn't normally called
+	return managed.ExternalCreation{}, errors.Wrap(c.setMembership(ctx, cr, cr.Spec.ForProvider.Membership), errSetMembership)
+}
+
+func (c *external) Update(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
+	cr, ok := mg.(*v1alpha1.RoomMembership)
+	if !ok {
+		return managed.ExternalUpdate{}, errors.New(errNotRoomMembership)
+	}
+
+	if len(observeonly.Drift(membershipDrift(cr, cr.Status.AtProvider.Membership), cr.Status.AtProvider.SettledFields)) > 0 {
+		if err := c.setMembership(ctx, cr, cr.Spec.ForProvider.Membership); err != nil {
+			return managed.ExternalUpdate{}, errors.Wrap(err, errSetMembership)
+		}
+	}
+	if len(observeonly.Drift(powerLevelDrift(cr), cr.Status.AtProvider.SettledFields)) > 0 {
+		if err := c.setPowerLevel(ctx, cr); err != nil {
+			return managed.ExternalUpdate{}, errors.Wrap(err, errSetPowerLevel)
+		}
+	}
+
+	return managed.ExternalUpdate{}, nil
+}
+
+func (c *external) Delete(ctx context.Context, mg resource.Managed) (managed.ExternalDelete, error) {
+	cr, ok := mg.(*v1alpha1.RoomMembership)
+	if !ok {
+		return managed.ExternalDelete{}, errors.New(errNotRoomMembership)
+	}
+
+	if !leaveOnDelete(cr) {
+		return managed.ExternalDelete{}, nil
+	}
+
+	return managed.ExternalDelete{}, errors.Wrap(c.setMembership(ctx, cr, v1alpha1.MembershipLeave), errSetMembership)
+}
+
+// Disconnect closes the external client.
+func (c *external) Disconnect(ctx context.Context) error {
+	return nil // No special disconnect logic needed
+}
+
+// setMembership moves the user to the given membership of the room
+func (c *external) setMembership(ctx context.Context, cr *v1alpha1.RoomMembership, membership string) error {
+	reason := ""
+	if cr.Spec.ForProvider.Reason != nil {
+		reason = *cr.Spec.ForProvider.Reason
+	}
+	return c.service.SetMembership(ctx, cr.Spec.ForProvider.RoomID, cr.Spec.ForProvider.UserID, membership, reason)
+}
+
+// setPowerLevel gives the user their desired power level, keeping the levels
+// of the room's other users
+func (c *external) setPowerLevel(ctx context.Context, cr *v1alpha1.RoomMembership) error {
+	users := maps.Clone(c.observed.Users)
+	if users == nil {
+		users = map[string]int{}
+	}
+	users[cr.Spec.ForProvider.UserID] = *cr.Spec.ForProvider.PowerLevel
+
+	return c.service.SetPowerLevels(ctx, cr.Spec.ForProvider.RoomID, &clients.PowerLevelSpec{
+		RoomID:      cr.Spec.ForProvider.RoomID,
+		PowerLevels: &clients.PowerLevelContent{Users: users},
+		Previous:    c.observed,
+	})
+}
+
+// leaveOnDelete reports whether the user should be removed from the room
+// when the RoomMembership is deleted
+func leaveOnDelete(cr *v1alpha1.RoomMembership) bool {
+	return cr.Spec.ForProvider.LeaveOnDelete != nil && *cr.Spec.ForProvider.LeaveOnDelete
+}
+
+// inRoom reports whether a membership ties the user to the room in a way
+// leaving would undo
+func inRoom(membership string) bool {
+	return membership != "" && membership != v1alpha1.MembershipLeave
+}
+
+// membershipDrift returns the spec fields that differ from the user's
+// observed membership
+func membershipDrift(cr *v1alpha1.RoomMembership, membership string) []string {
+	desired := cr.Spec.ForProvider.Membership
+	if membership == "" {
+		membership = v1alpha1.MembershipLeave
+	}
+
+	// An invited user who has joined has accepted the invite
+	if membership == desired || (desired == v1alpha1.MembershipInvite && membership == v1alpha1.MembershipJoin) {
+		return nil
+	}
+	return []string{"membership"}
+}
+
+// powerLevelDrift returns the power level if the user has joined the room
+// without having the desired one. Users who haven't joined yet are given it
+// once they do.
+func powerLevelDrift(cr *v1alpha1.RoomMembership) []string {
+	desired, observed := cr.Spec.ForProvider.PowerLevel, cr.Status.AtProvider.PowerLevel
+	if desired == nil || observed == nil || cr.Status.AtProvider.Membership != v1alpha1.MembershipJoin || *desired == *observed {
+		return nil
+	}
+	return []string{"powerLevel"}
+}
+
+// settleObserveOnlyFields records the observe-only fields the membership has
+// reached. A membership is never created, so they are applied until then,
+// rather than only on creation. A power level is only reached once the user
+// has joined and been given it.
+func settleObserveOnlyFields(cr *v1alpha1.RoomMembership, drift []string) {
+	if cr.Status.AtProvider.Membership != v1alpha1.MembershipJoin || cr.Status.AtProvider.PowerLevel == nil {
+		drift = append(slices.Clone(drift), "powerLevel")
+	}
+	cr.Status.AtProvider.SettledFields = observeonly.Settle(cr.Status.AtProvider.SettledFields, cr.Spec.ForProvider.ObserveOnlyFields, drift)
+}
+
+// userPowerLevel returns a user's level in a room's power levels, which is
+// the users default if the user isn't listed
+func userPowerLevel(powerLevels *clients.PowerLevelContent, userID string) int {
+	if level, ok := powerLevels.Users[userID]; ok {
+		return level
+	}
+	if powerLevels.UsersDefault != nil {
+		return *powerLevels.UsersDefault
+	}
+	return 0
+}
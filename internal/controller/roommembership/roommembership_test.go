@@ -0,0 +1,228 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package roommembership
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/roommembership/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+type mockClient struct {
+	clients.Client
+
+	membership  string
+	reasons     []string
+	powerLevels *clients.PowerLevelContent
+}
+
+func (m *mockClient) GetUserMembership(ctx context.Context, roomID, userID string) (string, error) {
+	return m.membership, nil
+}
+
+func (m *mockClient) SetMembership(ctx context.Context, roomID, userID, membership, reason string) error {
+	m.membership = membership
+	m.reasons = append(m.reasons, reason)
+	return nil
+}
+
+func (m *mockClient) GetPowerLevels(ctx context.Context, roomID string) (*clients.PowerLevelContent, error) {
+	return m.powerLevels, nil
+}
+
+func (m *mockClient) SetPowerLevels(ctx context.Context, roomID string, powerLevels *clients.PowerLevelSpec) error {
+	m.powerLevels = &clients.PowerLevelContent{Users: powerLevels.PowerLevels.Users, UsersDefault: m.powerLevels.UsersDefault}
+	return nil
+}
+
+func newRoomMembership(params v1alpha1.RoomMembershipParameters) *v1alpha1.RoomMembership {
+	params.RoomID = "!room:example.com"
+	params.UserID = "@alice:example.com"
+	return &v1alpha1.RoomMembership{Spec: v1alpha1.RoomMembershipSpec{ForProvider: params}}
+}
+
+func TestMembershipDrift(t *testing.T) {
+	tests := []struct {
+		name     string
+		desired  string
+		observed string
+		want     bool
+	}{
+		{name: "invited", desired: "invite", observed: "invite", want: true},
+		{name: "invite accepted", desired: "invite", observed: "join", want: true},
+		{name: "never in the room", desired: "leave", observed: "", want: true},
+		{name: "invite not sent", desired: "invite", observed: ""},
+		{name: "ban lifted", desired: "ban", observed: "leave"},
+		{name: "kicked", desired: "join", observed: "leave"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := newRoomMembership(v1alpha1.RoomMembershipParameters{Membership: tt.desired})
+			assert.Equal(t, tt.want, len(membershipDrift(cr, tt.observed)) == 0)
+		})
+	}
+}
+
+func TestBanAndDelete(t *testing.T) {
+	reason := "Spam"
+	leave := true
+	m := &mockClient{membership: "join"}
+	cr := newRoomMembership(v1alpha1.RoomMembershipParameters{
+		Membership:    "ban",
+		Reason:        &reason,
+		LeaveOnDelete: &leave,
+	})
+	e := &external{service: m}
+
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceExists)
+	assert.False(t, obs.ResourceUpToDate)
+	assert.Equal(t, "join", cr.Status.AtProvider.Membership)
+
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, "ban", m.membership)
+
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceUpToDate)
+
+	// Deleting lifts the ban
+	now := metav1.Now()
+	cr.SetDeletionTimestamp(&now)
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceExists)
+
+	_, err = e.Delete(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, "leave", m.membership)
+	assert.Equal(t, []string{"Spam", "Spam"}, m.reasons)
+
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceExists)
+}
+
+func TestDeleteKeepsMembership(t *testing.T) {
+	m := &mockClient{membership: "join"}
+	cr := newRoomMembership(v1alpha1.RoomMembershipParameters{Membership: "join"})
+	now := metav1.Now()
+	cr.SetDeletionTimestamp(&now)
+	e := &external{service: m}
+
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceExists)
+
+	_, err = e.Delete(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, "join", m.membership)
+	assert.Empty(t, m.reasons)
+}
+
+func TestPowerLevelAfterInvite(t *testing.T) {
+	moderator, usersDefault := 50, 0
+	m := &mockClient{
+		membership:  "invite",
+		powerLevels: &clients.PowerLevelContent{Users: map[string]int{"@bot:example.com": 100}, UsersDefault: &usersDefault},
+	}
+	cr := newRoomMembership(v1alpha1.RoomMembershipParameters{Membership: "invite", PowerLevel: &moderator})
+	e := &external{service: m}
+
+	// An invited user is given their power level once they join
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceUpToDate)
+	assert.Equal(t, 0, *cr.Status.AtProvider.PowerLevel)
+	assert.Equal(t, &moderator, cr.Status.AtProvider.PendingPowerLevel)
+
+	m.membership = "join"
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceUpToDate)
+	assert.Nil(t, cr.Status.AtProvider.PendingPowerLevel)
+
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"@bot:example.com": 100, "@alice:example.com": 50}, m.powerLevels.Users)
+	assert.Equal(t, "join", m.membership)
+	assert.Empty(t, m.reasons)
+
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceUpToDate)
+	assert.Equal(t, 50, *cr.Status.AtProvider.PowerLevel)
+}
+
+func TestObserveOnlyFields(t *testing.T) {
+	moderator, usersDefault := 50, 0
+	m := &mockClient{powerLevels: &clients.PowerLevelContent{UsersDefault: &usersDefault}}
+	cr := newRoomMembership(v1alpha1.RoomMembershipParameters{
+		Membership:        "invite",
+		PowerLevel:        &moderator,
+		ObserveOnlyFields: []string{"membership", "powerLevel"},
+	})
+	e := &external{service: m}
+
+	// Until the user has been invited and given their power level once,
+	// both are applied
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceUpToDate)
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, "invite", m.membership)
+
+	m.membership = "join"
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceUpToDate)
+	assert.Equal(t, []string{"membership"}, cr.Status.AtProvider.SettledFields)
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceUpToDate)
+	assert.Equal(t, []string{"membership", "powerLevel"}, cr.Status.AtProvider.SettledFields)
+
+	// Afterwards the user may leave, or be demoted, without being invited
+	// or promoted again
+	m.membership = "leave"
+	m.powerLevels.Users = nil
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceUpToDate)
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, "leave", m.membership)
+	assert.Len(t, m.reasons, 1)
+
+	// A field that is managed again is applied again
+	cr.Spec.ForProvider.ObserveOnlyFields = []string{"powerLevel"}
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceUpToDate)
+	assert.Equal(t, []string{"powerLevel"}, cr.Status.AtProvider.SettledFields)
+}
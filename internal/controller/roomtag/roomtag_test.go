@@ -0,0 +1,161 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package roomtag
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/common"
+	"github.com/crossplane-contrib/provider-matrix/apis/roomtag/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maunium.net/go/mautrix"
+	"testing"
+)
+
+type mockClient struct {
+	clients.Client
+
+	// tags are the tags on the room, by name
+	tags map[string]clients.RoomTag
+}
+
+func (m *mockClient) GetRoomTags(ctx context.Context, userID, roomID string) (map[string]clients.RoomTag, error) {
+	return m.tags, nil
+}
+
+func (m *mockClient) SetRoomTag(ctx context.Context, userID, roomID, tag string, order *float64) error {
+	m.tags[tag] = clients.RoomTag{Order: order}
+	return nil
+}
+
+func (m *mockClient) DeleteRoomTag(ctx context.Context, userID, roomID, tag string) error {
+	if _, ok := m.tags[tag]; !ok {
+		return mautrix.MNotFound
+	}
+	delete(m.tags, tag)
+	return nil
+}
+
+func ptr(f float64) *float64 {
+	return &f
+}
+
+func newRoomTag(order *float64) *v1alpha1.RoomTag {
+	return &v1alpha1.RoomTag{Spec: v1alpha1.RoomTagSpec{ForProvider: v1alpha1.RoomTagParameters{
+		RoomID: "!room:example.com",
+		Tag:    "m.favourite",
+		Order:  order,
+	}}}
+}
+
+func TestObserve(t *testing.T) {
+	tests := []struct {
+		name         string
+		order        *float64
+		tags         map[string]clients.RoomTag
+		wantExists   bool
+		wantUpToDate bool
+	}{
+		{
+			name: "not tagged",
+			tags: map[string]clients.RoomTag{"m.lowpriority": {}},
+		},
+		{
+			name:         "same order",
+			order:        ptr(0.5),
+			tags:         map[string]clients.RoomTag{"m.favourite": {Order: ptr(0.5)}},
+			wantExists:   true,
+			wantUpToDate: true,
+		},
+		{
+			name:       "different order",
+			order:      ptr(0.5),
+			tags:       map[string]clients.RoomTag{"m.favourite": {Order: ptr(0.25)}},
+			wantExists: true,
+		},
+		{
+			name:       "order to set",
+			order:      ptr(0.5),
+			tags:       map[string]clients.RoomTag{"m.favourite": {}},
+			wantExists: true,
+		},
+		{
+			name:       "order to unset",
+			tags:       map[string]clients.RoomTag{"m.favourite": {Order: ptr(0.5)}},
+			wantExists: true,
+		},
+		{
+			name:         "unordered",
+			tags:         map[string]clients.RoomTag{"m.favourite": {}},
+			wantExists:   true,
+			wantUpToDate: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &external{service: &mockClient{tags: tt.tags}}
+			cr := newRoomTag(tt.order)
+
+			obs, err := e.Observe(context.Background(), cr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantExists, obs.ResourceExists)
+			assert.Equal(t, tt.wantUpToDate, obs.ResourceUpToDate)
+			if tt.wantExists {
+				assert.Equal(t, common.ManagedByAdopted, cr.Status.AtProvider.ManagedBy)
+				assert.Equal(t, tt.tags["m.favourite"].Order, cr.Status.AtProvider.Order)
+			}
+		})
+	}
+}
+
+func TestObserveOnlyFields(t *testing.T) {
+	e := &external{service: &mockClient{tags: map[string]clients.RoomTag{"m.favourite": {Order: ptr(0.25)}}}}
+	cr := newRoomTag(ptr(0.5))
+	cr.Spec.ForProvider.ObserveOnlyFields = []string{"order"}
+
+	// The user may have reordered their favourites since the tag was created
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceExists)
+	assert.True(t, obs.ResourceUpToDate)
+	assert.Equal(t, ptr(0.25), cr.Status.AtProvider.Order)
+}
+
+func TestCreateUpdateDelete(t *testing.T) {
+	m := &mockClient{tags: map[string]clients.RoomTag{}}
+	e := &external{service: m}
+
+	cr := newRoomTag(ptr(0.5))
+	_, err := e.Create(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, ptr(0.5), m.tags["m.favourite"].Order)
+
+	cr.Spec.ForProvider.Order = nil
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Nil(t, m.tags["m.favourite"].Order)
+
+	_, err = e.Delete(context.Background(), cr)
+	require.NoError(t, err)
+	assert.NotContains(t, m.tags, "m.favourite")
+
+	// A tag that is already gone is deleted
+	_, err = e.Delete(context.Background(), cr)
+	require.NoError(t, err)
+}
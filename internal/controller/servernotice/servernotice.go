@@ -0,0 +1,240 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servernotice
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/servernotice/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane-contrib/provider-matrix/internal/connection"
+	"github.com/crossplane-contrib/provider-matrix/internal/forcedelete"
+	"github.com/crossplane-contrib/provider-matrix/internal/maintenance"
+	"github.com/crossplane-contrib/provider-matrix/internal/permission"
+	"github.com/crossplane-contrib/provider-matrix/internal/resync"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/controller"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/feature"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/ratelimiter"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	"github.com/pkg/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	errNotServerNotice = "managed resource is not a ServerNotice custom resource"
+	errTrackPCUsage    = "cannot track ProviderConfig usage"
+	errGetPC           = "cannot get ProviderConfig"
+	errGetCreds        = "cannot get credentials"
+	errNewClient       = "cannot create new Matrix client"
+	errSyncProfile     = "cannot sync the provider user's profile"
+	errSendNotice      = "cannot send Matrix server notice"
+	errRedactNotice    = "cannot redact Matrix server notice"
+)
+
+// defaultMsgType is the message type notices are sent as if none is given
+const defaultMsgType = "m.text"
+
+// Setup adds a controller that reconciles ServerNotice managed resources.
+func Setup(mgr ctrl.Manager, o controller.Options) error {
+	name := managed.ControllerName(v1alpha1.ServerNoticeKind)
+
+	opts := []managed.ReconcilerOption{
+		managed.WithExternalConnector(maintenance.Connector(v1alpha1.ServerNoticeGroupKind, connection.Connector(permission.Connector(forcedelete.Connector(&connector{
+			kube:         mgr.GetClient(),
+			usage:        clients.NewProviderConfigUsageTracker(mgr.GetClient()),
+			newServiceFn: clients.CachedClient,
+			logger:       o.Logger.WithValues("controller", name),
+		}, o.Logger.WithValues("controller", name)))))),
+		// The external name is the event ID of the notice, which only the
+		// homeserver can give, so it isn't initialized to the resource's name
+		managed.WithInitializers(),
+		managed.WithLogger(o.Logger.WithValues("controller", name)),
+		managed.WithPollInterval(o.PollInterval),
+		managed.WithPollIntervalHook(resync.PollIntervalHook),
+		managed.WithRecorder(nil),
+	}
+	if o.Features.Enabled(feature.EnableBetaManagementPolicies) {
+		opts = append(opts, managed.WithManagementPolicies())
+	}
+
+	r := managed.NewReconciler(mgr, resource.ManagedKind(v1alpha1.ServerNoticeGroupVersionKind), opts...)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(name).
+		WithOptions(o.ForControllerRuntime()).
+		WithEventFilter(resource.DesiredStateChanged()).
+		For(&v1alpha1.ServerNotice{}).
+		Complete(ratelimiter.NewReconciler(name, maintenance.Reconciler(v1alpha1.ServerNoticeGroupKind, r), o.GlobalRateLimiter))
+}
+
+// A connector is expected to produce an ExternalClient when its Connect method
+// is called.
+type connector struct {
+	kube         client.Client
+	usage        resource.ModernTracker
+	newServiceFn func(config *clients.Config) (clients.Client, error)
+	logger       logging.Logger
+}
+
+// Connect typically produces an ExternalClient by:
+// 1. Tracking that the managed resource is using a ProviderConfig.
+// 2. Getting the managed resource's ProviderConfig.
+// 3. Getting the credentials specified by the ProviderConfig.
+// 4. Using the credentials to form a client.
+func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	if _, ok := mg.(*v1alpha1.ServerNotice); !ok {
+		return nil, errors.New(errNotServerNotice)
+	}
+
+	modernManaged, ok := mg.(resource.ModernManaged)
+	if !ok {
+		return nil, errors.New("managed resource does not implement ModernManaged")
+	}
+	if err := c.usage.Track(ctx, modernManaged); err != nil {
+		return nil, errors.Wrap(err, errTrackPCUsage)
+	}
+
+	pc, err := clients.GetProviderConfig(ctx, c.kube, mg)
+	if err != nil {
+		return nil, errors.Wrap(err, errGetPC)
+	}
+	resync.Set(pc)
+
+	config, err := clients.GetConfig(ctx, c.kube, mg)
+	if err != nil {
+		return nil, errors.Wrap(err, errGetCreds)
+	}
+	config.Logger = c.logger.WithValues("providerConfig", config.ProviderConfigName)
+
+	service, err := c.newServiceFn(config)
+	if err != nil {
+		return nil, errors.Wrap(err, errNewClient)
+	}
+
+	if err := clients.VerifyConnection(ctx, c.kube, pc, service); err != nil {
+		return nil, err
+	}
+
+	if err := service.SyncProfile(ctx); err != nil {
+		return nil, errors.Wrap(err, errSyncProfile)
+	}
+
+	return &external{service: service}, nil
+}
+
+// An ExternalClient observes, then either creates, updates, or deletes an
+// external resource to ensure it reflects the managed resource's desired state.
+type external struct {
+	service clients.Client
+}
+
+func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
+	cr, ok := mg.(*v1alpha1.ServerNotice)
+	if !ok {
+		return managed.ExternalObservation{}, errors.New(errNotServerNotice)
+	}
+
+	// A notice is sent once and can't be changed, so there is nothing to
+	// observe beyond whether it has been sent
+	eventID := meta.GetExternalName(cr)
+	if eventID == "" {
+		return managed.ExternalObservation{
+			ResourceExists: false,
+		}, nil
+	}
+
+	// A notice that is kept on deletion, or has been redacted, is gone as
+	// far as the resource is concerned once it is being deleted
+	if meta.WasDeleted(cr) && (!redactOnDelete(cr) || cr.Status.AtProvider.Redacted) {
+		return managed.ExternalObservation{
+			ResourceExists: false,
+		}, nil
+	}
+
+	cr.Status.AtProvider.EventID = eventID
+	cr.Status.SetConditions(xpv1.Available())
+
+	return managed.ExternalObservation{
+		ResourceExists:   true,
+		ResourceUpToDate: true,
+	}, nil
+}
+
+func (c *external) Create(ctx context.Context, mg resource.Managed) (managed.ExternalCreation, error) {
+	cr, ok := mg.(*v1alpha1.ServerNotice)
+	if !ok {
+		return managed.ExternalCreation{}, errors.New(errNotServerNotice)
+	}
+
+	p := cr.Spec.ForProvider
+	msgType := p.Content.MsgType
+	if msgType == "" {
+		msgType = defaultMsgType
+	}
+
+	// The resource's UID is the transaction ID, so that a notice sent again
+	// because the external name couldn't be recorded isn't delivered twice
+	eventID, err := c.service.SendServerNotice(ctx, string(cr.GetUID()), p.UserID, msgType, p.Content.Body)
+	if err != nil {
+		return managed.ExternalCreation{}, errors.Wrap(err, errSendNotice)
+	}
+
+	meta.SetExternalName(cr, eventID)
+	return managed.ExternalCreation{}, nil
+}
+
+func (c *external) Update(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
+	if _, ok := mg.(*v1alpha1.ServerNotice); !ok {
+		return managed.ExternalUpdate{}, errors.New(errNotServerNotice)
+	}
+
+	// A notice can't be edited once sent
+	return managed.ExternalUpdate{}, nil
+}
+
+func (c *external) Delete(ctx context.Context, mg resource.Managed) (managed.ExternalDelete, error) {
+	cr, ok := mg.(*v1alpha1.ServerNotice)
+	if !ok {
+		return managed.ExternalDelete{}, errors.New(errNotServerNotice)
+	}
+
+	eventID := meta.GetExternalName(cr)
+	if !redactOnDelete(cr) || eventID == "" {
+		return managed.ExternalDelete{}, nil
+	}
+
+	err := c.service.RedactServerNotice(ctx, eventID, "")
+	if err != nil && !clients.IsNotFound(err) {
+		return managed.ExternalDelete{}, errors.Wrap(err, errRedactNotice)
+	}
+	cr.Status.AtProvider.Redacted = true
+	return managed.ExternalDelete{}, nil
+}
+
+// redactOnDelete reports whether deleting the resource redacts the notice
+func redactOnDelete(cr *v1alpha1.ServerNotice) bool {
+	return cr.Spec.ForProvider.RedactOnDelete != nil && *cr.Spec.ForProvider.RedactOnDelete
+}
+
+// Disconnect closes the external client.
+func (c *external) Disconnect(ctx context.Context) error {
+	return nil // No special disconnect logic needed
+}
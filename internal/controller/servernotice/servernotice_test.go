@@ -0,0 +1,171 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servernotice
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/servernotice/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"net/http"
+	"testing"
+)
+
+type mockClient struct {
+	clients.Client
+
+	sent      []string
+	redacted  []string
+	redactErr error
+}
+
+func (m *mockClient) SendServerNotice(ctx context.Context, txnID, userID, msgType, body string) (string, error) {
+	m.sent = append(m.sent, msgType+": "+body)
+	return "$notice", nil
+}
+
+func (m *mockClient) RedactServerNotice(ctx context.Context, eventID, reason string) error {
+	if m.redactErr != nil {
+		return m.redactErr
+	}
+	m.redacted = append(m.redacted, eventID)
+	return nil
+}
+
+func newServerNotice(redactOnDelete *bool) *v1alpha1.ServerNotice {
+	return &v1alpha1.ServerNotice{Spec: v1alpha1.ServerNoticeSpec{ForProvider: v1alpha1.ServerNoticeParameters{
+		UserID:         "@alice:example.com",
+		Content:        v1alpha1.ServerNoticeContent{Body: "Scheduled maintenance tonight"},
+		RedactOnDelete: redactOnDelete,
+	}}}
+}
+
+func TestSendOnce(t *testing.T) {
+	m := &mockClient{}
+	e := &external{service: m}
+	cr := newServerNotice(nil)
+
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceExists)
+
+	_, err = e.Create(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, "$notice", meta.GetExternalName(cr))
+
+	// Editing the notice afterwards sends nothing
+	cr.Spec.ForProvider.Content.Body = "Maintenance postponed"
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceExists)
+	assert.True(t, obs.ResourceUpToDate)
+	assert.Equal(t, "$notice", cr.Status.AtProvider.EventID)
+
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"m.text: Scheduled maintenance tonight"}, m.sent)
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		name           string
+		redactOnDelete *bool
+		eventID        string
+		redactErr      error
+		wantRedacted   []string
+		wantErr        string
+	}{
+		{name: "kept", eventID: "$notice"},
+		{name: "redacted", redactOnDelete: boolPtr(true), eventID: "$notice", wantRedacted: []string{"$notice"}},
+		{name: "never sent", redactOnDelete: boolPtr(true)},
+		{
+			name:           "already gone",
+			redactOnDelete: boolPtr(true),
+			eventID:        "$notice",
+			redactErr:      &clients.APIError{StatusCode: http.StatusNotFound, ErrCode: "M_NOT_FOUND", Body: "{}"},
+		},
+		{
+			name:           "failed",
+			redactOnDelete: boolPtr(true),
+			eventID:        "$notice",
+			redactErr:      assert.AnError,
+			wantErr:        errRedactNotice,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mockClient{redactErr: tt.redactErr}
+			e := &external{service: m}
+			cr := newServerNotice(tt.redactOnDelete)
+			meta.SetExternalName(cr, tt.eventID)
+
+			_, err := e.Delete(context.Background(), cr)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRedacted, m.redacted)
+		})
+	}
+}
+
+func TestObserveDeleted(t *testing.T) {
+	tests := []struct {
+		name           string
+		redactOnDelete *bool
+		wantRedacted   []string
+	}{
+		{name: "kept"},
+		{name: "redacted", redactOnDelete: boolPtr(true), wantRedacted: []string{"$notice"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mockClient{}
+			e := &external{service: m}
+			cr := newServerNotice(tt.redactOnDelete)
+			meta.SetExternalName(cr, "$notice")
+			now := metav1.Now()
+			cr.SetDeletionTimestamp(&now)
+
+			// A notice that is to be redacted exists until Delete has
+			// redacted it
+			obs, err := e.Observe(context.Background(), cr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRedacted != nil, obs.ResourceExists)
+
+			if obs.ResourceExists {
+				_, err = e.Delete(context.Background(), cr)
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantRedacted, m.redacted)
+
+			obs, err = e.Observe(context.Background(), cr)
+			require.NoError(t, err)
+			assert.False(t, obs.ResourceExists)
+		})
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,465 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package space
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/crossplane-contrib/provider-matrix/apis/space/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane-contrib/provider-matrix/internal/connection"
+	"github.com/crossplane-contrib/provider-matrix/internal/forcedelete"
+	"github.com/crossplane-contrib/provider-matrix/internal/maintenance"
+	"github.com/crossplane-contrib/provider-matrix/internal/metrics"
+	"github.com/crossplane-contrib/provider-matrix/internal/observeonly"
+	"github.com/crossplane-contrib/provider-matrix/internal/permission"
+	"github.com/crossplane-contrib/provider-matrix/internal/provenance"
+	"github.com/crossplane-contrib/provider-matrix/internal/resync"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/controller"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/feature"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/ratelimiter"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"slices"
+)
+
+const (
+	errNotSpace        = "managed resource is not a Space custom resource"
+	errTrackPCUsage    = "cannot track ProviderConfig usage"
+	errGetPC           = "cannot get ProviderConfig"
+	errGetCreds        = "cannot get credentials"
+	errNewClient       = "cannot create new Matrix client"
+	errSyncProfile     = "cannot sync the provider user's profile"
+	errCreateSpace     = "cannot create Matrix space"
+	errGetSpace        = "cannot get Matrix space"
+	errUpdateSpace     = "cannot update Matrix space"
+	errDeleteSpace     = "cannot delete Matrix space"
+	errGetDeletion     = "cannot get Matrix space deletion"
+	errInitialState    = "cannot decode initial state"
+	errCreationContent = "cannot decode creation content"
+)
+
+// Setup adds a controller that reconciles Space managed resources.
+func Setup(mgr ctrl.Manager, o controller.Options) error {
+	name := managed.ControllerName(v1alpha1.SpaceKind)
+
+	opts := []managed.ReconcilerOption{
+		managed.WithExternalConnector(maintenance.Connector(v1alpha1.SpaceGroupKind, connection.Connector(permission.Connector(forcedelete.Connector(&connector{
+			kube:         mgr.GetClient(),
+			usage:        clients.NewProviderConfigUsageTracker(mgr.GetClient()),
+			newServiceFn: clients.CachedClient,
+			logger:       o.Logger.WithValues("controller", name),
+		}, o.Logger.WithValues("controller", name)))))),
+		managed.WithLogger(o.Logger.WithValues("controller", name)),
+		managed.WithPollInterval(o.PollInterval),
+		managed.WithPollIntervalHook(resync.PollIntervalHook),
+		managed.WithRecorder(nil),
+	}
+	if o.Features.Enabled(feature.EnableBetaManagementPolicies) {
+		opts = append(opts, managed.WithManagementPolicies())
+	}
+
+	r := managed.NewReconciler(mgr, resource.ManagedKind(v1alpha1.SpaceGroupVersionKind), opts...)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(name).
+		WithOptions(o.ForControllerRuntime()).
+		WithEventFilter(resource.DesiredStateChanged()).
+		For(&v1alpha1.Space{}).
+		Complete(ratelimiter.NewReconciler(name, maintenance.Reconciler(v1alpha1.SpaceGroupKind, r), o.GlobalRateLimiter))
+}
+
+// A connector is expected to produce an ExternalClient when its Connect method
+// is called.
+type connector struct {
+	kube         client.Client
+	usage        resource.ModernTracker
+	newServiceFn func(config *clients.Config) (clients.Client, error)
+	logger       logging.Logger
+}
+
+// Connect typically produces an ExternalClient by:
+// 1. Tracking that the managed resource is using a ProviderConfig.
+// 2. Getting the managed resource's ProviderConfig.
+// 3. Getting the credentials specified by the ProviderConfig.
+// 4. Using the credentials to form a client.
+func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	if _, ok := mg.(*v1alpha1.Space); !ok {
+		return nil, errors.New(errNotSpace)
+	}
+
+	modernManaged, ok := mg.(resource.ModernManaged)
+	if !ok {
+		return nil, errors.New("managed resource does not implement ModernManaged")
+	}
+	if err := c.usage.Track(ctx, modernManaged); err != nil {
+		return nil, errors.Wrap(err, errTrackPCUsage)
+	}
+
+	pc, err := clients.GetProviderConfig(ctx, c.kube, mg)
+	if err != nil {
+		return nil, errors.Wrap(err, errGetPC)
+	}
+	resync.Set(pc)
+
+	config, err := clients.GetConfig(ctx, c.kube, mg)
+	if err != nil {
+		return nil, errors.Wrap(err, errGetCreds)
+	}
+	config.Logger = c.logger.WithValues("providerConfig", config.ProviderConfigName)
+
+	service, err := c.newServiceFn(config)
+	if err != nil {
+		return nil, errors.Wrap(err, errNewClient)
+	}
+
+	if err := clients.VerifyConnection(ctx, c.kube, pc, service); err != nil {
+		return nil, err
+	}
+
+	if err := service.SyncProfile(ctx); err != nil {
+		return nil, errors.Wrap(err, errSyncProfile)
+	}
+
+	return &external{service: service}, nil
+}
+
+// An ExternalClient observes, then either creates, updates, or deletes an
+// external resource to ensure it reflects the managed resource's desired state.
+type external struct {
+	service clients.Client
+}
+
+func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
+	cr, ok := mg.(*v1alpha1.Space)
+	if !ok {
+		return managed.ExternalObservation{}, errors.New(errNotSpace)
+	}
+
+	spaceID := meta.GetExternalName(cr)
+	if spaceID == "" {
+		return managed.ExternalObservation{
+			ResourceExists: false,
+		}, nil
+	}
+
+	// Spaces are deleted like rooms, in the background. The space is gone
+	// once its deletion completes, even if the homeserver still knows it.
+	if meta.WasDeleted(cr) {
+		deletion, err := c.service.GetRoomDeletion(ctx, spaceID)
+		if err != nil {
+			return managed.ExternalObservation{}, errors.Wrap(err, errGetDeletion)
+		}
+		if deletion != nil && deletion.Status == clients.RoomDeletionComplete {
+			return managed.ExternalObservation{
+				ResourceExists: false,
+			}, nil
+		}
+	}
+
+	space, err := c.service.GetSpace(ctx, spaceID)
+	if err != nil {
+		if clients.IsNotFound(err) {
+			return managed.ExternalObservation{
+				ResourceExists: false,
+			}, nil
+		}
+		return managed.ExternalObservation{}, errors.Wrap(err, errGetSpace)
+	}
+
+	adoptedAt := cr.Status.AtProvider.AdoptedAt
+	cr.Status.AtProvider = generateSpaceObservation(space)
+	cr.Status.AtProvider.ManagedBy, cr.Status.AtProvider.AdoptedAt = provenance.Observe(cr, adoptedAt)
+
+	drift := spaceDrift(observeonly.Desired(cr), space)
+	metrics.RecordDrift(v1alpha1.SpaceKind, drift)
+
+	cr.Status.SetConditions(xpv1.Available())
+
+	return managed.ExternalObservation{
+		ResourceExists:   true,
+		ResourceUpToDate: len(drift) == 0,
+	}, nil
+}
+
+func (c *external) Create(ctx context.Context, mg resource.Managed) (managed.ExternalCreation, error) {
+	cr, ok := mg.(*v1alpha1.Space)
+	if !ok {
+		return managed.ExternalCreation{}, errors.New(errNotSpace)
+	}
+
+	spaceSpec, err := generateSpaceSpec(cr)
+	if err != nil {
+		return managed.ExternalCreation{}, err
+	}
+	space, err := c.service.CreateSpace(ctx, spaceSpec)
+	if err != nil {
+		return managed.ExternalCreation{}, errors.Wrap(err, errCreateSpace)
+	}
+
+	meta.SetExternalName(cr, space.RoomID)
+
+	return managed.ExternalCreation{}, nil
+}
+
+func (c *external) Update(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
+	cr, ok := mg.(*v1alpha1.Space)
+	if !ok {
+		return managed.ExternalUpdate{}, errors.New(errNotSpace)
+	}
+
+	spaceSpec, err := generateSpaceSpec(observeonly.Desired(cr))
+	if err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+	if _, err := c.service.UpdateSpace(ctx, meta.GetExternalName(cr), spaceSpec); err != nil {
+		return managed.ExternalUpdate{}, errors.Wrap(err, errUpdateSpace)
+	}
+
+	return managed.ExternalUpdate{}, nil
+}
+
+func (c *external) Delete(ctx context.Context, mg resource.Managed) (managed.ExternalDelete, error) {
+	cr, ok := mg.(*v1alpha1.Space)
+	if !ok {
+		return managed.ExternalDelete{}, errors.New(errNotSpace)
+	}
+
+	spaceID := meta.GetExternalName(cr)
+	if spaceID == "" {
+		return managed.ExternalDelete{}, nil
+	}
+
+	return managed.ExternalDelete{}, errors.Wrap(c.service.DeleteSpace(ctx, spaceID), errDeleteSpace)
+}
+
+// Disconnect closes the external client.
+func (c *external) Disconnect(ctx context.Context) error {
+	return nil // No special disconnect logic needed
+}
+
+// Helper functions
+
+func generateSpaceSpec(cr *v1alpha1.Space) (*clients.SpaceSpec, error) {
+	p := cr.Spec.ForProvider
+	spec := &clients.SpaceSpec{}
+
+	spec.Name = p.Name
+	if p.Topic != nil {
+		spec.Topic = *p.Topic
+	}
+	if p.Alias != nil {
+		spec.Alias = *p.Alias
+	}
+	if p.Visibility != nil {
+		spec.Visibility = *p.Visibility
+	}
+	if p.RoomVersion != nil {
+		spec.RoomVersion = *p.RoomVersion
+	}
+
+	if p.CreationContent != nil && len(p.CreationContent.Raw) > 0 {
+		if err := json.Unmarshal(p.CreationContent.Raw, &spec.CreationContent); err != nil {
+			return nil, errors.Wrap(err, errCreationContent)
+		}
+	}
+	spec.Invite = p.Invite
+
+	for _, state := range p.InitialState {
+		var content map[string]interface{}
+		if len(state.Content.Raw) > 0 {
+			if err := json.Unmarshal(state.Content.Raw, &content); err != nil {
+				return nil, errors.Wrapf(err, "%s: %s", errInitialState, state.Type)
+			}
+		}
+		spec.InitialState = append(spec.InitialState, clients.StateEvent{
+			Type:     state.Type,
+			StateKey: state.StateKey,
+			Content:  content,
+		})
+	}
+
+	if p.PowerLevelOverrides != nil {
+		spec.PowerLevelOverrides = &clients.PowerLevelContent{
+			Users:         p.PowerLevelOverrides.Users,
+			Events:        p.PowerLevelOverrides.Events,
+			EventsDefault: p.PowerLevelOverrides.EventsDefault,
+			StateDefault:  p.PowerLevelOverrides.StateDefault,
+			UsersDefault:  p.PowerLevelOverrides.UsersDefault,
+			Ban:           p.PowerLevelOverrides.Ban,
+			Kick:          p.PowerLevelOverrides.Kick,
+			Redact:        p.PowerLevelOverrides.Redact,
+			Invite:        p.PowerLevelOverrides.Invite,
+		}
+	}
+
+	if p.GuestAccess != nil {
+		spec.GuestAccess = *p.GuestAccess
+	}
+	if p.HistoryVisibility != nil {
+		spec.HistoryVisibility = *p.HistoryVisibility
+	}
+	if p.JoinRules != nil {
+		spec.JoinRules = *p.JoinRules
+	}
+	if p.AvatarURL != nil {
+		spec.AvatarURL = *p.AvatarURL
+	}
+
+	// Nil children leave the space's children alone, while an empty list
+	// removes them all
+	if p.Children != nil {
+		spec.Children = make([]clients.SpaceChild, 0, len(p.Children))
+		for _, child := range p.Children {
+			spec.Children = append(spec.Children, generateSpaceChild(child))
+		}
+	}
+
+	return spec, nil
+}
+
+// generateSpaceChild converts a child in the spec into the client's form,
+// applying the API defaults for unset fields
+func generateSpaceChild(child v1alpha1.SpaceChild) clients.SpaceChild {
+	c := clients.SpaceChild{
+		RoomID: child.RoomID,
+		Via:    child.Via,
+	}
+	if child.Order != nil {
+		c.Order = *child.Order
+	}
+	if child.Suggested != nil {
+		c.Suggested = *child.Suggested
+	}
+	return c
+}
+
+func generateSpaceObservation(space *clients.Space) v1alpha1.SpaceObservation {
+	obs := v1alpha1.SpaceObservation{
+		SpaceID:           space.RoomID,
+		Name:              space.Name,
+		Topic:             space.Topic,
+		Alias:             space.Alias,
+		AvatarURL:         space.AvatarURL,
+		Creator:           space.Creator,
+		RoomVersion:       space.RoomVersion,
+		JoinedMembers:     space.JoinedMembers,
+		InvitedMembers:    space.InvitedMembers,
+		Visibility:        space.Visibility,
+		GuestAccess:       space.GuestAccess,
+		HistoryVisibility: space.HistoryVisibility,
+		JoinRules:         space.JoinRules,
+	}
+
+	if space.CreationTime != nil {
+		obs.CreationTime = &metav1.Time{Time: *space.CreationTime}
+	}
+
+	for _, child := range space.Children {
+		order, suggested := child.Order, child.Suggested
+		obs.Children = append(obs.Children, v1alpha1.SpaceChild{
+			RoomID:    child.RoomID,
+			Via:       child.Via,
+			Order:     &order,
+			Suggested: &suggested,
+		})
+	}
+
+	if space.PowerLevels != nil {
+		obs.PowerLevels = &v1alpha1.PowerLevelContent{
+			Users:         space.PowerLevels.Users,
+			Events:        space.PowerLevels.Events,
+			EventsDefault: space.PowerLevels.EventsDefault,
+			StateDefault:  space.PowerLevels.StateDefault,
+			UsersDefault:  space.PowerLevels.UsersDefault,
+			Ban:           space.PowerLevels.Ban,
+			Kick:          space.PowerLevels.Kick,
+			Redact:        space.PowerLevels.Redact,
+			Invite:        space.PowerLevels.Invite,
+		}
+	}
+
+	return obs
+}
+
+func isSpaceUpToDate(cr *v1alpha1.Space, space *clients.Space) bool {
+	return len(spaceDrift(cr, space)) == 0
+}
+
+// spaceDrift returns the spec fields that differ from the observed space
+func spaceDrift(cr *v1alpha1.Space, space *clients.Space) []string {
+	var drift []string
+	p := cr.Spec.ForProvider
+
+	if p.Name != nil && *p.Name != space.Name {
+		drift = append(drift, "name")
+	}
+	if p.Topic != nil && *p.Topic != space.Topic {
+		drift = append(drift, "topic")
+	}
+	if p.Alias != nil && *p.Alias != space.Alias {
+		drift = append(drift, "alias")
+	}
+	if p.GuestAccess != nil && *p.GuestAccess != space.GuestAccess {
+		drift = append(drift, "guestAccess")
+	}
+	if p.HistoryVisibility != nil && *p.HistoryVisibility != space.HistoryVisibility {
+		drift = append(drift, "historyVisibility")
+	}
+	if p.JoinRules != nil && *p.JoinRules != space.JoinRules {
+		drift = append(drift, "joinRules")
+	}
+	if p.AvatarURL != nil && *p.AvatarURL != space.AvatarURL {
+		drift = append(drift, "avatarURL")
+	}
+	if p.Children != nil && !childrenEqual(p.Children, space.Children) {
+		drift = append(drift, "children")
+	}
+
+	return drift
+}
+
+// childrenEqual reports whether a space has exactly the children in the
+// spec. Via servers are only compared when the spec lists them, since the
+// provider picks them otherwise.
+func childrenEqual(desired []v1alpha1.SpaceChild, observed []clients.SpaceChild) bool {
+	if len(desired) != len(observed) {
+		return false
+	}
+	for _, d := range desired {
+		want := generateSpaceChild(d)
+		i := slices.IndexFunc(observed, func(o clients.SpaceChild) bool { return o.RoomID == want.RoomID })
+		if i < 0 {
+			return false
+		}
+		got := observed[i]
+		if want.Via != nil && !slices.Equal(want.Via, got.Via) {
+			return false
+		}
+		if want.Order != got.Order || want.Suggested != got.Suggested {
+			return false
+		}
+	}
+	return true
+}
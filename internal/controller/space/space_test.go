@@ -0,0 +1,233 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package space
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/space/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane-contrib/provider-matrix/internal/observeonly"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"testing"
+	"time"
+)
+
+type mockClient struct {
+	clients.Client
+
+	space    *clients.Space
+	created  *clients.SpaceSpec
+	updated  *clients.SpaceSpec
+	deleted  string
+	deletion *clients.RoomDeletion
+}
+
+func (m *mockClient) CreateSpace(ctx context.Context, spec *clients.SpaceSpec) (*clients.Space, error) {
+	m.created = spec
+	return &clients.Space{Room: clients.Room{RoomID: "!space:example.com"}}, nil
+}
+
+func (m *mockClient) GetSpace(ctx context.Context, spaceID string) (*clients.Space, error) {
+	return m.space, nil
+}
+
+func (m *mockClient) UpdateSpace(ctx context.Context, spaceID string, spec *clients.SpaceSpec) (*clients.Space, error) {
+	m.updated = spec
+	return m.space, nil
+}
+
+func (m *mockClient) DeleteSpace(ctx context.Context, spaceID string) error {
+	m.deleted = spaceID
+	return nil
+}
+
+func (m *mockClient) GetRoomDeletion(ctx context.Context, roomID string) (*clients.RoomDeletion, error) {
+	return m.deletion, nil
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestSpaceChildrenDrift(t *testing.T) {
+	observed := []clients.SpaceChild{
+		{RoomID: "!a:example.com", Via: []string{"example.com"}, Order: "01", Suggested: true},
+		{RoomID: "!b:example.com", Via: []string{"example.com"}},
+	}
+
+	tests := []struct {
+		name     string
+		children []v1alpha1.SpaceChild
+		want     bool
+	}{
+		{
+			name: "children match",
+			children: []v1alpha1.SpaceChild{
+				{RoomID: "!b:example.com"},
+				{RoomID: "!a:example.com", Order: strPtr("01"), Suggested: boolPtr(true)},
+			},
+			want: true,
+		},
+		{
+			name: "child added",
+			children: []v1alpha1.SpaceChild{
+				{RoomID: "!a:example.com", Order: strPtr("01"), Suggested: boolPtr(true)},
+				{RoomID: "!b:example.com"},
+				{RoomID: "!c:example.com"},
+			},
+		},
+		{
+			name: "child removed",
+			children: []v1alpha1.SpaceChild{
+				{RoomID: "!a:example.com", Order: strPtr("01"), Suggested: boolPtr(true)},
+			},
+		},
+		{
+			name: "order changed",
+			children: []v1alpha1.SpaceChild{
+				{RoomID: "!a:example.com", Order: strPtr("02"), Suggested: boolPtr(true)},
+				{RoomID: "!b:example.com"},
+			},
+		},
+		{
+			name: "via changed",
+			children: []v1alpha1.SpaceChild{
+				{RoomID: "!a:example.com", Via: []string{"other.example.com"}, Order: strPtr("01"), Suggested: boolPtr(true)},
+				{RoomID: "!b:example.com"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := &v1alpha1.Space{Spec: v1alpha1.SpaceSpec{ForProvider: v1alpha1.SpaceParameters{Children: tt.children}}}
+			got := isSpaceUpToDate(cr, &clients.Space{Children: observed})
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	// Children that aren't managed never drift
+	cr := &v1alpha1.Space{}
+	assert.True(t, isSpaceUpToDate(cr, &clients.Space{Children: observed}))
+}
+
+func TestSpaceLifecycle(t *testing.T) {
+	m := &mockClient{}
+	cr := &v1alpha1.Space{Spec: v1alpha1.SpaceSpec{ForProvider: v1alpha1.SpaceParameters{
+		Name:     strPtr("Company"),
+		Children: []v1alpha1.SpaceChild{{RoomID: "!a:example.com", Suggested: boolPtr(true)}},
+		InitialState: []v1alpha1.StateEvent{{
+			Type:    "m.room.server_acl",
+			Content: runtime.RawExtension{Raw: []byte(`{"allow":["*"]}`)},
+		}},
+	}}}
+	e := &external{service: m}
+
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceExists)
+
+	_, err = e.Create(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, "!space:example.com", meta.GetExternalName(cr))
+	assert.Equal(t, []clients.SpaceChild{{RoomID: "!a:example.com", Suggested: true}}, m.created.Children)
+	assert.Equal(t, map[string]interface{}{"allow": []interface{}{"*"}}, m.created.InitialState[0].Content)
+
+	// The child was removed outside of Kubernetes
+	m.space = &clients.Space{Room: clients.Room{RoomID: "!space:example.com", Name: "Company"}}
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceExists)
+	assert.False(t, obs.ResourceUpToDate)
+	assert.Equal(t, "!space:example.com", cr.Status.AtProvider.SpaceID)
+
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, []clients.SpaceChild{{RoomID: "!a:example.com", Suggested: true}}, m.updated.Children)
+
+	m.space.Children = []clients.SpaceChild{{RoomID: "!a:example.com", Via: []string{"example.com"}, Suggested: true}}
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceUpToDate)
+	require.Len(t, cr.Status.AtProvider.Children, 1)
+	assert.Equal(t, "!a:example.com", cr.Status.AtProvider.Children[0].RoomID)
+
+	_, err = e.Delete(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, "!space:example.com", m.deleted)
+}
+
+func TestObserveDeletedSpace(t *testing.T) {
+	m := &mockClient{space: &clients.Space{Room: clients.Room{RoomID: "!space:example.com"}}}
+	cr := &v1alpha1.Space{}
+	meta.SetExternalName(cr, "!space:example.com")
+	cr.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+	e := &external{service: m}
+
+	_, err := e.Delete(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, "!space:example.com", m.deleted)
+
+	// The deletion is still running, so the space still exists
+	m.deletion = &clients.RoomDeletion{DeleteID: "abc", Status: clients.RoomDeletionPurging}
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceExists)
+
+	// Once it completes the space is gone, even though the homeserver still
+	// returns it
+	m.deletion.Status = clients.RoomDeletionComplete
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceExists)
+}
+
+func TestObserveOnlyFields(t *testing.T) {
+	m := &mockClient{space: &clients.Space{Room: clients.Room{RoomID: "!space:example.com", Name: "Renamed", Topic: "Old topic"}}}
+	cr := &v1alpha1.Space{Spec: v1alpha1.SpaceSpec{ForProvider: v1alpha1.SpaceParameters{
+		Name:              strPtr("Company"),
+		Topic:             strPtr("Everything company"),
+		Children:          []v1alpha1.SpaceChild{{RoomID: "!a:example.com"}},
+		ObserveOnlyFields: []string{"name", "children"},
+	}}}
+	meta.SetExternalName(cr, "!space:example.com")
+	e := &external{service: m}
+
+	// The name and children are observed, but neither reported as drift nor
+	// updated
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceUpToDate)
+	assert.Equal(t, "Renamed", cr.Status.AtProvider.Name)
+	assert.Equal(t, []string{"topic"}, spaceDrift(observeonly.Desired(cr), m.space))
+
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	require.NotNil(t, m.updated)
+	assert.Nil(t, m.updated.Name)
+	assert.Nil(t, m.updated.Children)
+	assert.Equal(t, "Everything company", m.updated.Topic)
+	assert.Equal(t, strPtr("Company"), cr.Spec.ForProvider.Name)
+}
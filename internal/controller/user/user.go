@@ -21,17 +21,30 @@ import (
 	"github.com/crossplane-contrib/provider-matrix/apis/user/v1alpha1"
 	apisv1beta1 "github.com/crossplane-contrib/provider-matrix/apis/v1beta1"
 	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane-contrib/provider-matrix/internal/connection"
+	"github.com/crossplane-contrib/provider-matrix/internal/forcedelete"
+	"github.com/crossplane-contrib/provider-matrix/internal/maintenance"
+	"github.com/crossplane-contrib/provider-matrix/internal/metrics"
+	"github.com/crossplane-contrib/provider-matrix/internal/observeonly"
+	"github.com/crossplane-contrib/provider-matrix/internal/permission"
+	"github.com/crossplane-contrib/provider-matrix/internal/provenance"
+	"github.com/crossplane-contrib/provider-matrix/internal/resync"
 	"github.com/crossplane/crossplane-runtime/v2/pkg/controller"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/feature"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
 	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
 	"github.com/crossplane/crossplane-runtime/v2/pkg/ratelimiter"
 	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
 	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
 	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"slices"
+	"strings"
+	"time"
 )
 
 const (
@@ -40,33 +53,46 @@ const (
 	errGetPC          = "cannot get ProviderConfig"
 	errGetCreds       = "cannot get credentials"
 	errNewClient      = "cannot create new Matrix client"
+	errSyncProfile    = "cannot sync the provider user's profile"
 	errCreateUser     = "cannot create Matrix user"
 	errGetUser        = "cannot get Matrix user"
 	errUpdateUser     = "cannot update Matrix user"
 	errDeactivateUser = "cannot deactivate Matrix user"
+	errLockUser       = "cannot lock Matrix user"
+	errGetDevices     = "cannot get the Matrix user's devices"
+	errRenameDevice   = "cannot rename the Matrix user's device"
+	errShadowBan      = "cannot change the Matrix user's shadow-ban"
+	errResolveUserID  = "cannot resolve the user ID of a localpart external name without a userID in the spec or the ProviderConfig"
 )
 
 // Setup adds a controller that reconciles User managed resources.
 func Setup(mgr ctrl.Manager, o controller.Options) error {
 	name := managed.ControllerName(v1alpha1.UserKind)
 
-	r := managed.NewReconciler(mgr,
-		resource.ManagedKind(v1alpha1.UserGroupVersionKind),
-		managed.WithExternalConnector(&connector{
+	opts := []managed.ReconcilerOption{
+		managed.WithExternalConnector(maintenance.Connector(v1alpha1.UserGroupKind, connection.Connector(permission.Connector(forcedelete.Connector(&connector{
 			kube:         mgr.GetClient(),
 			usage:        clients.NewProviderConfigUsageTracker(mgr.GetClient()),
-			newServiceFn: clients.NewClient,
-		}),
+			newServiceFn: clients.CachedClient,
+			logger:       o.Logger.WithValues("controller", name),
+		}, o.Logger.WithValues("controller", name)))))),
 		managed.WithLogger(o.Logger.WithValues("controller", name)),
 		managed.WithPollInterval(o.PollInterval),
-		managed.WithRecorder(nil))
+		managed.WithPollIntervalHook(resync.PollIntervalHook),
+		managed.WithRecorder(nil),
+	}
+	if o.Features.Enabled(feature.EnableBetaManagementPolicies) {
+		opts = append(opts, managed.WithManagementPolicies())
+	}
+
+	r := managed.NewReconciler(mgr, resource.ManagedKind(v1alpha1.UserGroupVersionKind), opts...)
 
 	return ctrl.NewControllerManagedBy(mgr).
 		Named(name).
 		WithOptions(o.ForControllerRuntime()).
 		WithEventFilter(resource.DesiredStateChanged()).
 		For(&v1alpha1.User{}).
-		Complete(ratelimiter.NewReconciler(name, r, o.GlobalRateLimiter))
+		Complete(ratelimiter.NewReconciler(name, maintenance.Reconciler(v1alpha1.UserGroupKind, r), o.GlobalRateLimiter))
 }
 
 // A connector is expected to produce an ExternalClient when its Connect method
@@ -75,6 +101,7 @@ type connector struct {
 	kube         client.Client
 	usage        resource.ModernTracker
 	newServiceFn func(config *clients.Config) (clients.Client, error)
+	logger       logging.Logger
 }
 
 // Connect typically produces an ExternalClient by:
@@ -83,8 +110,7 @@ type connector struct {
 // 3. Getting the credentials specified by the ProviderConfig.
 // 4. Using the credentials to form a client.
 func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
-	cr, ok := mg.(*v1alpha1.User)
-	if !ok {
+	if _, ok := mg.(*v1alpha1.User); !ok {
 		return nil, errors.New(errNotUser)
 	}
 
@@ -96,28 +122,62 @@ func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.E
 		return nil, errors.Wrap(err, errTrackPCUsage)
 	}
 
-	pc := &apisv1beta1.ProviderConfig{}
-	if err := c.kube.Get(ctx, types.NamespacedName{Name: cr.GetProviderConfigReference().Name}, pc); err != nil {
+	pc, err := clients.GetProviderConfig(ctx, c.kube, mg)
+	if err != nil {
 		return nil, errors.Wrap(err, errGetPC)
 	}
+	resync.Set(pc)
 
 	config, err := clients.GetConfig(ctx, c.kube, mg)
 	if err != nil {
 		return nil, errors.Wrap(err, errGetCreds)
 	}
+	config.Logger = c.logger.WithValues("providerConfig", config.ProviderConfigName)
 
 	service, err := c.newServiceFn(config)
 	if err != nil {
 		return nil, errors.Wrap(err, errNewClient)
 	}
 
-	return &external{service: service}, nil
+	if err := clients.VerifyConnection(ctx, c.kube, pc, service); err != nil {
+		return nil, err
+	}
+
+	if err := service.SyncProfile(ctx); err != nil {
+		return nil, errors.Wrap(err, errSyncProfile)
+	}
+
+	spec := pc.GetProviderConfigSpec()
+	externalNameFormat := apisv1beta1.UserExternalNameUserID
+	if spec.UserExternalNameFormat != nil {
+		externalNameFormat = *spec.UserExternalNameFormat
+	}
+
+	return &external{
+		service:                   service,
+		logger:                    c.logger,
+		selfUserID:                config.UserID,
+		externalNameFormat:        externalNameFormat,
+		caseInsensitiveLocalparts: spec.CaseInsensitiveLocalparts != nil && *spec.CaseInsensitiveLocalparts,
+	}, nil
 }
 
 // An ExternalClient observes, then either creates, updates, or deletes an
 // external resource to ensure it reflects the managed resource's desired state.
 type external struct {
 	service clients.Client
+	logger  logging.Logger
+
+	// selfUserID is the user the provider authenticates as
+	selfUserID string
+
+	// externalNameFormat is the form of external name given to created
+	// users
+	externalNameFormat string
+
+	// caseInsensitiveLocalparts looks users up by their lowercased localpart
+	// when they aren't found as given
+	caseInsensitiveLocalparts bool
 }
 
 func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
@@ -126,7 +186,10 @@ func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 		return managed.ExternalObservation{}, errors.New(errNotUser)
 	}
 
-	userID := meta.GetExternalName(cr)
+	userID, err := c.userID(cr)
+	if err != nil {
+		return managed.ExternalObservation{}, err
+	}
 	if userID == "" {
 		return managed.ExternalObservation{
 			ResourceExists: false,
@@ -134,6 +197,15 @@ func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 	}
 
 	user, err := c.service.GetUser(ctx, userID)
+	lateInitialized := false
+	if lower := lowercaseLocalpart(userID); clients.IsNotFound(err) && c.caseInsensitiveLocalparts && lower != userID {
+		// The homeserver may have lowercased the localpart at registration
+		user, err = c.service.GetUser(ctx, lower)
+		if err == nil {
+			meta.SetExternalName(cr, c.externalName(user.UserID))
+			lateInitialized = true
+		}
+	}
 	if err != nil {
 		if clients.IsNotFound(err) {
 			return managed.ExternalObservation{
@@ -143,12 +215,77 @@ func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 		return managed.ExternalObservation{}, errors.Wrap(err, errGetUser)
 	}
 
+	// Deactivated users are kept by the homeserver, so a deleted User is
+	// gone once Delete has deactivated its user
+	if meta.WasDeleted(cr) && user.Deactivated {
+		return managed.ExternalObservation{
+			ResourceExists: false,
+		}, nil
+	}
+
+	if len(cr.Spec.ForProvider.DeviceDisplayNames) > 0 {
+		user.Devices, err = c.service.GetDevices(ctx, user.UserID)
+		if err != nil {
+			return managed.ExternalObservation{}, errors.Wrap(err, errGetDevices)
+		}
+	}
+
+	adoptedAt := cr.Status.AtProvider.AdoptedAt
 	cr.Status.AtProvider = generateUserObservation(user)
+	cr.Status.AtProvider.ManagedBy, cr.Status.AtProvider.AdoptedAt = provenance.Observe(cr, adoptedAt)
 	cr.Status.SetConditions(xpv1.Available())
 
+	// The override is informational and needs admin API access, so failing
+	// to read it doesn't fail the observation
+	if rateLimit, err := c.service.GetRateLimit(ctx, userID); err == nil && rateLimit != nil {
+		cr.Status.AtProvider.RateLimit = &v1alpha1.RateLimit{
+			MessagesPerSecond: rateLimit.MessagesPerSecond,
+			BurstCount:        rateLimit.BurstCount,
+		}
+	}
+
+	// Connections are only observed on request, and failing to list them
+	// leaves the rest of the observation standing
+	if reportConnections(cr) {
+		connections, err := c.service.GetConnections(ctx, userID)
+		if err != nil {
+			c.logger.Info("Cannot observe the user's connections", "user", userID, "error", err)
+		}
+		cr.Status.AtProvider.Connections = generateConnections(connections)
+	}
+
+	if user.Deactivated {
+		cr.Status.SetConditions(v1alpha1.UserDeactivated())
+	} else if cr.Status.GetCondition(v1alpha1.TypeDeactivated).Status == corev1.ConditionTrue {
+		cr.Status.SetConditions(v1alpha1.UserActive())
+	}
+
+	desired := observeonly.Desired(cr)
+	drift := userDrift(desired, user)
+	if len(devicesToRename(desired.Spec.ForProvider.DeviceDisplayNames, cr.Status.AtProvider.Devices)) > 0 {
+		drift = append(drift, "deviceDisplayNames")
+	}
+	if c.isSelfDemotion(desired, user.UserID) && slices.Contains(drift, "admin") {
+		drift = withoutField(drift, "admin")
+		cr.Status.SetConditions(v1alpha1.AdminDemotionBlocked())
+	} else if cr.Status.GetCondition(v1alpha1.TypeAdminDemotionBlocked).Status == corev1.ConditionTrue {
+		cr.Status.SetConditions(v1alpha1.AdminDemotionNotBlocked())
+	}
+
+	// Only the homeserver that owns a user can change it, so drift on a
+	// remote user is reported rather than retried forever
+	if serverName, remote := c.isRemoteUser(user.UserID); remote && len(drift) > 0 {
+		cr.Status.SetConditions(v1alpha1.RemoteUserImmutable(serverName, drift))
+		drift = nil
+	} else if cr.Status.GetCondition(v1alpha1.TypeRemoteUserImmutable).Status == corev1.ConditionTrue {
+		cr.Status.SetConditions(v1alpha1.RemoteUserMutable())
+	}
+	metrics.RecordDrift(v1alpha1.UserKind, drift)
+
 	return managed.ExternalObservation{
-		ResourceExists:   true,
-		ResourceUpToDate: isUserUpToDate(cr, user),
+		ResourceExists:          true,
+		ResourceUpToDate:        len(drift) == 0,
+		ResourceLateInitialized: lateInitialized,
 	}, nil
 }
 
@@ -164,7 +301,7 @@ func (c *external) Create(ctx context.Context, mg resource.Managed) (managed.Ext
 		return managed.ExternalCreation{}, errors.Wrap(err, errCreateUser)
 	}
 
-	meta.SetExternalName(cr, user.UserID)
+	meta.SetExternalName(cr, c.externalName(user.UserID))
 
 	return managed.ExternalCreation{}, nil
 }
@@ -175,13 +312,42 @@ func (c *external) Update(ctx context.Context, mg resource.Managed) (managed.Ext
 		return managed.ExternalUpdate{}, errors.New(errNotUser)
 	}
 
-	userID := meta.GetExternalName(cr)
-	userSpec := generateUserSpec(cr)
-	_, err := c.service.UpdateUser(ctx, userID, userSpec)
+	userID, err := c.userID(cr)
+	if err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+	desired := observeonly.Desired(cr)
+	userSpec := generateUserSpec(desired)
+	// The users PUT always sends admin and deactivated, so unmanaged ones
+	// keep their observed values
+	if desired.Spec.ForProvider.Admin == nil {
+		userSpec.Admin = cr.Status.AtProvider.Admin
+	}
+	if desired.Spec.ForProvider.Deactivated == nil {
+		userSpec.Deactivated = cr.Status.AtProvider.Deactivated
+	}
+	if c.isSelfDemotion(desired, userID) && cr.Status.AtProvider.Admin {
+		// Never remove the provider's own admin privileges
+		userSpec.Admin = true
+	}
+	_, err = c.service.UpdateUser(ctx, userID, userSpec)
 	if err != nil {
 		return managed.ExternalUpdate{}, errors.Wrap(err, errUpdateUser)
 	}
 
+	if p := desired.Spec.ForProvider.ShadowBanned; p != nil && *p != cr.Status.AtProvider.ShadowBanned {
+		if err := c.service.ShadowBanUser(ctx, userID, *p); err != nil {
+			return managed.ExternalUpdate{}, errors.Wrap(err, errShadowBan)
+		}
+	}
+
+	for _, deviceID := range devicesToRename(desired.Spec.ForProvider.DeviceDisplayNames, cr.Status.AtProvider.Devices) {
+		displayName := desired.Spec.ForProvider.DeviceDisplayNames[deviceID]
+		if err := c.service.SetDeviceDisplayName(ctx, userID, deviceID, displayName); err != nil {
+			return managed.ExternalUpdate{}, errors.Wrapf(err, "%s %s", errRenameDevice, deviceID)
+		}
+	}
+
 	return managed.ExternalUpdate{}, nil
 }
 
@@ -191,11 +357,32 @@ func (c *external) Delete(ctx context.Context, mg resource.Managed) (managed.Ext
 		return managed.ExternalDelete{}, errors.New(errNotUser)
 	}
 
-	userID := meta.GetExternalName(cr)
+	userID, err := c.userID(cr)
+	if err != nil {
+		return managed.ExternalDelete{}, err
+	}
 	if userID == "" {
 		return managed.ExternalDelete{}, nil
 	}
 
+	// With a grace period the account is only locked until the period has
+	// passed since the User was deleted
+	if grace := cr.Spec.ForProvider.DeactivationGracePeriod; grace != nil {
+		deactivateAt := time.Now().Add(grace.Duration)
+		if deleted := cr.GetDeletionTimestamp(); deleted != nil {
+			deactivateAt = deleted.Add(grace.Duration)
+		}
+		if time.Now().Before(deactivateAt) {
+			if !cr.Status.AtProvider.Locked {
+				if err := c.service.LockUser(ctx, userID, true); err != nil {
+					return managed.ExternalDelete{}, errors.Wrap(err, errLockUser)
+				}
+			}
+			cr.Status.SetConditions(v1alpha1.DeactivationPending(deactivateAt))
+			return managed.ExternalDelete{}, nil
+		}
+	}
+
 	return managed.ExternalDelete{}, errors.Wrap(c.service.DeactivateUser(ctx, userID), errDeactivateUser)
 }
 
@@ -204,8 +391,81 @@ func (c *external) Disconnect(ctx context.Context) error {
 	return nil // No special disconnect logic needed
 }
 
+// userID returns the full user ID named by a user's external name, which may
+// be a full user ID or a localpart. A localpart is qualified with the server
+// name of the spec's userID, or else of the provider's own user.
+func (c *external) userID(cr *v1alpha1.User) (string, error) {
+	name := meta.GetExternalName(cr)
+	if name == "" || strings.HasPrefix(name, "@") {
+		return name, nil
+	}
+
+	serverName := ""
+	if cr.Spec.ForProvider.UserID != nil {
+		_, serverName, _ = strings.Cut(*cr.Spec.ForProvider.UserID, ":")
+	}
+	if serverName == "" {
+		_, serverName, _ = strings.Cut(c.selfUserID, ":")
+	}
+	if serverName == "" {
+		return "", errors.New(errResolveUserID)
+	}
+	return "@" + name + ":" + serverName, nil
+}
+
+// externalName returns the external name to give a created user
+func (c *external) externalName(userID string) string {
+	if c.externalNameFormat != apisv1beta1.UserExternalNameLocalpart {
+		return userID
+	}
+	localpart, _, _ := strings.Cut(strings.TrimPrefix(userID, "@"), ":")
+	return localpart
+}
+
+// lowercaseLocalpart returns userID with its localpart lowercased. The server
+// name is left alone.
+func lowercaseLocalpart(userID string) string {
+	localpart, serverName, found := strings.Cut(userID, ":")
+	if !found {
+		return strings.ToLower(userID)
+	}
+	return strings.ToLower(localpart) + ":" + serverName
+}
+
+// isRemoteUser returns the server name of the provider's own user, and
+// whether userID belongs to another homeserver. Users are assumed to be local
+// when the provider's server name isn't known.
+func (c *external) isRemoteUser(userID string) (string, bool) {
+	_, serverName, _ := strings.Cut(c.selfUserID, ":")
+	_, userServerName, _ := strings.Cut(userID, ":")
+	return serverName, serverName != "" && userServerName != serverName
+}
+
+// isSelfDemotion reports whether the spec would remove admin privileges from
+// the provider's own user without an explicit override
+func (c *external) isSelfDemotion(cr *v1alpha1.User, userID string) bool {
+	if c.selfUserID == "" || userID != c.selfUserID {
+		return false
+	}
+	if cr.Spec.ForProvider.AllowSelfDemotion != nil && *cr.Spec.ForProvider.AllowSelfDemotion {
+		return false
+	}
+	return cr.Spec.ForProvider.Admin == nil || !*cr.Spec.ForProvider.Admin
+}
+
 // Helper functions
 
+// withoutField returns fields with the given field removed
+func withoutField(fields []string, field string) []string {
+	var out []string
+	for _, f := range fields {
+		if f != field {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
 func generateUserSpec(cr *v1alpha1.User) *clients.UserSpec {
 	spec := &clients.UserSpec{}
 
@@ -256,12 +516,14 @@ func generateUserSpec(cr *v1alpha1.User) *clients.UserSpec {
 
 func generateUserObservation(user *clients.User) v1alpha1.UserObservation {
 	obs := v1alpha1.UserObservation{
-		UserID:      user.UserID,
-		DisplayName: user.DisplayName,
-		AvatarURL:   user.AvatarURL,
-		Admin:       user.Admin,
-		Deactivated: user.Deactivated,
-		UserType:    user.UserType,
+		UserID:       user.UserID,
+		DisplayName:  user.DisplayName,
+		AvatarURL:    user.AvatarURL,
+		Admin:        user.Admin,
+		Deactivated:  user.Deactivated,
+		Locked:       user.Locked,
+		ShadowBanned: user.ShadowBanned,
+		UserType:     user.UserType,
 	}
 
 	if user.CreationTime != nil {
@@ -297,31 +559,82 @@ func generateUserObservation(user *clients.User) v1alpha1.UserObservation {
 	return obs
 }
 
-func isUserUpToDate(cr *v1alpha1.User, user *clients.User) bool {
-	// Check display name
-	if cr.Spec.ForProvider.DisplayName != nil && *cr.Spec.ForProvider.DisplayName != user.DisplayName {
-		return false
+// generateConnections converts a user's connections for the observation
+func generateConnections(connections []clients.Connection) []v1alpha1.Connection {
+	var obs []v1alpha1.Connection
+	for _, connection := range connections {
+		connObs := v1alpha1.Connection{
+			DeviceID:  connection.DeviceID,
+			IP:        connection.IP,
+			UserAgent: connection.UserAgent,
+		}
+		if connection.LastSeenTime != nil {
+			connObs.LastSeenTime = &metav1.Time{Time: *connection.LastSeenTime}
+		}
+		obs = append(obs, connObs)
 	}
+	return obs
+}
 
-	// Check avatar URL
-	if cr.Spec.ForProvider.AvatarURL != nil && *cr.Spec.ForProvider.AvatarURL != user.AvatarURL {
-		return false
-	}
+func reportConnections(cr *v1alpha1.User) bool {
+	return cr.Spec.ForProvider.ReportConnections != nil && *cr.Spec.ForProvider.ReportConnections
+}
 
-	// Check admin status
-	if cr.Spec.ForProvider.Admin != nil && *cr.Spec.ForProvider.Admin != user.Admin {
-		return false
+func isUserUpToDate(cr *v1alpha1.User, user *clients.User) bool {
+	return len(userDrift(cr, user)) == 0
+}
+
+// userDrift returns the spec fields that differ from the observed user
+func userDrift(cr *v1alpha1.User, user *clients.User) []string {
+	var drift []string
+	p := cr.Spec.ForProvider
+
+	if p.DisplayName != nil && !displayNameMatches(p, user.DisplayName) {
+		drift = append(drift, "displayName")
+	}
+	if p.AvatarURL != nil && *p.AvatarURL != user.AvatarURL {
+		drift = append(drift, "avatarURL")
+	}
+	if p.Admin != nil && *p.Admin != user.Admin {
+		drift = append(drift, "admin")
+	}
+	if p.Deactivated != nil && *p.Deactivated != user.Deactivated {
+		drift = append(drift, "deactivated")
+	}
+	if p.UserType != nil && *p.UserType != user.UserType {
+		drift = append(drift, "userType")
+	}
+	if p.ShadowBanned != nil && *p.ShadowBanned != user.ShadowBanned {
+		drift = append(drift, "shadowBanned")
 	}
 
-	// Check deactivated status
-	if cr.Spec.ForProvider.Deactivated != nil && *cr.Spec.ForProvider.Deactivated != user.Deactivated {
-		return false
+	return drift
+}
+
+// devicesToRename returns the IDs of the user's devices whose display names
+// differ from the desired ones, in order. Devices the user doesn't have can't
+// be renamed, so they are skipped.
+func devicesToRename(desired map[string]string, devices []v1alpha1.Device) []string {
+	var rename []string
+	for _, device := range devices {
+		if name, ok := desired[device.DeviceID]; ok && name != device.DisplayName {
+			rename = append(rename, device.DeviceID)
+		}
 	}
+	slices.Sort(rename)
+	return rename
+}
 
-	// Check user type
-	if cr.Spec.ForProvider.UserType != nil && *cr.Spec.ForProvider.UserType != user.UserType {
-		return false
+// displayNameMatches reports whether the observed display name matches the
+// desired one, normalizing both if requested
+func displayNameMatches(p v1alpha1.UserParameters, observed string) bool {
+	if p.NormalizeDisplayName != nil && *p.NormalizeDisplayName {
+		return normalizeDisplayName(*p.DisplayName) == normalizeDisplayName(observed)
 	}
+	return *p.DisplayName == observed
+}
 
-	return true
+// normalizeDisplayName trims a display name and collapses runs of whitespace
+func normalizeDisplayName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
 }
@@ -17,14 +17,106 @@ limitations under the License.
 package user
 
 import (
+	"context"
 	"github.com/crossplane-contrib/provider-matrix/apis/user/v1alpha1"
+	apisv1beta1 "github.com/crossplane-contrib/provider-matrix/apis/v1beta1"
 	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane-contrib/provider-matrix/internal/observeonly"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"net/http"
+	"slices"
 	"testing"
 	"time"
 )
 
+type mockClient struct {
+	clients.Client
+
+	user      *clients.User
+	updated   *clients.UserSpec
+	rateLimit *clients.RateLimit
+	requested string
+	missing   []string
+
+	locked       bool
+	deactivated  bool
+	shadowBanned *bool
+
+	devices []clients.Device
+	renamed map[string]string
+
+	connections    []clients.Connection
+	connectionsErr error
+	whoisRequests  int
+}
+
+func (m *mockClient) GetDevices(ctx context.Context, userID string) ([]clients.Device, error) {
+	return m.devices, nil
+}
+
+func (m *mockClient) SetDeviceDisplayName(ctx context.Context, userID, deviceID, displayName string) error {
+	if m.renamed == nil {
+		m.renamed = map[string]string{}
+	}
+	m.renamed[deviceID] = displayName
+	for i := range m.devices {
+		if m.devices[i].DeviceID == deviceID {
+			m.devices[i].DisplayName = displayName
+		}
+	}
+	return nil
+}
+
+func (m *mockClient) GetConnections(ctx context.Context, userID string) ([]clients.Connection, error) {
+	m.whoisRequests++
+	return m.connections, m.connectionsErr
+}
+
+func (m *mockClient) LockUser(ctx context.Context, userID string, locked bool) error {
+	m.locked = locked
+	return nil
+}
+
+func (m *mockClient) ShadowBanUser(ctx context.Context, userID string, shadowBanned bool) error {
+	m.shadowBanned = &shadowBanned
+	return nil
+}
+
+func (m *mockClient) DeactivateUser(ctx context.Context, userID string) error {
+	m.deactivated = true
+	return nil
+}
+
+func (m *mockClient) CreateUser(ctx context.Context, user *clients.UserSpec) (*clients.User, error) {
+	return m.user, nil
+}
+
+func (m *mockClient) GetUser(ctx context.Context, userID string) (*clients.User, error) {
+	m.requested = userID
+	if slices.Contains(m.missing, userID) {
+		return nil, &clients.APIError{StatusCode: http.StatusNotFound, ErrCode: "M_NOT_FOUND", Body: `{"errcode":"M_NOT_FOUND","error":"User not found"}`}
+	}
+	return m.user, nil
+}
+
+func (m *mockClient) GetRateLimit(ctx context.Context, userID string) (*clients.RateLimit, error) {
+	if m.rateLimit == nil {
+		return nil, errors.New("rate-limit overrides require admin API access")
+	}
+	return m.rateLimit, nil
+}
+
+func (m *mockClient) UpdateUser(ctx context.Context, userID string, user *clients.UserSpec) (*clients.User, error) {
+	m.updated = user
+	return m.user, nil
+}
+
 func TestGenerateUserSpec(t *testing.T) {
 	tests := []struct {
 		name string
@@ -122,6 +214,7 @@ func TestGenerateUserObservation(t *testing.T) {
 	assert.Equal(t, "Alice Wonderland", obs.DisplayName)
 	assert.Equal(t, false, obs.Admin)
 	assert.Equal(t, false, obs.Deactivated)
+	assert.Equal(t, false, obs.Locked)
 	assert.Equal(t, "regular", obs.UserType)
 	assert.NotNil(t, obs.CreationTime)
 	assert.Len(t, obs.ExternalIDs, 1)
@@ -169,6 +262,65 @@ func TestIsUserUpToDate(t *testing.T) {
 			},
 			want: false,
 		},
+		{
+			name: "trailing whitespace drifts without normalization",
+			cr: &v1alpha1.User{
+				Spec: v1alpha1.UserSpec{
+					ForProvider: v1alpha1.UserParameters{
+						DisplayName: stringPtr("Alice "),
+					},
+				},
+			},
+			user: &clients.User{
+				DisplayName: "Alice",
+			},
+			want: false,
+		},
+		{
+			name: "trailing whitespace with normalization",
+			cr: &v1alpha1.User{
+				Spec: v1alpha1.UserSpec{
+					ForProvider: v1alpha1.UserParameters{
+						DisplayName:          stringPtr("Alice "),
+						NormalizeDisplayName: boolPtr(true),
+					},
+				},
+			},
+			user: &clients.User{
+				DisplayName: "Alice",
+			},
+			want: true,
+		},
+		{
+			name: "inner whitespace with normalization",
+			cr: &v1alpha1.User{
+				Spec: v1alpha1.UserSpec{
+					ForProvider: v1alpha1.UserParameters{
+						DisplayName:          stringPtr(" Alice   Smith"),
+						NormalizeDisplayName: boolPtr(true),
+					},
+				},
+			},
+			user: &clients.User{
+				DisplayName: "Alice Smith",
+			},
+			want: true,
+		},
+		{
+			name: "renamed with normalization",
+			cr: &v1alpha1.User{
+				Spec: v1alpha1.UserSpec{
+					ForProvider: v1alpha1.UserParameters{
+						DisplayName:          stringPtr("Alice Updated "),
+						NormalizeDisplayName: boolPtr(true),
+					},
+				},
+			},
+			user: &clients.User{
+				DisplayName: "Alice",
+			},
+			want: false,
+		},
 		{
 			name: "admin status differs",
 			cr: &v1alpha1.User{
@@ -195,6 +347,451 @@ func TestIsUserUpToDate(t *testing.T) {
 	}
 }
 
+func TestSelfDemotionGuard(t *testing.T) {
+	tests := []struct {
+		name         string
+		userID       string
+		params       v1alpha1.UserParameters
+		wantUpToDate bool
+		wantBlocked  bool
+		wantAdmin    bool
+	}{
+		{
+			name:         "provider user demotion is blocked",
+			userID:       "@bot:example.com",
+			params:       v1alpha1.UserParameters{Admin: boolPtr(false), DisplayName: stringPtr("Bot")},
+			wantUpToDate: false,
+			wantBlocked:  true,
+			wantAdmin:    true,
+		},
+		{
+			name:         "provider user demotion with override",
+			userID:       "@bot:example.com",
+			params:       v1alpha1.UserParameters{Admin: boolPtr(false), AllowSelfDemotion: boolPtr(true)},
+			wantUpToDate: false,
+			wantAdmin:    false,
+		},
+		{
+			name:         "other user demotion",
+			userID:       "@alice:example.com",
+			params:       v1alpha1.UserParameters{Admin: boolPtr(false)},
+			wantUpToDate: false,
+			wantAdmin:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mockClient{user: &clients.User{UserID: tt.userID, DisplayName: "Old", Admin: true}}
+			cr := &v1alpha1.User{Spec: v1alpha1.UserSpec{ForProvider: tt.params}}
+			meta.SetExternalName(cr, tt.userID)
+
+			e := &external{service: m, selfUserID: "@bot:example.com"}
+			obs, err := e.Observe(context.Background(), cr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantUpToDate, obs.ResourceUpToDate)
+
+			blocked := cr.Status.GetCondition(v1alpha1.TypeAdminDemotionBlocked).Status == corev1.ConditionTrue
+			assert.Equal(t, tt.wantBlocked, blocked)
+
+			_, err = e.Update(context.Background(), cr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAdmin, m.updated.Admin)
+		})
+	}
+}
+
+func TestSelfDemotionGuardOnlyAdminDrift(t *testing.T) {
+	m := &mockClient{user: &clients.User{UserID: "@bot:example.com", Admin: true}}
+	cr := &v1alpha1.User{Spec: v1alpha1.UserSpec{ForProvider: v1alpha1.UserParameters{Admin: boolPtr(false)}}}
+	meta.SetExternalName(cr, "@bot:example.com")
+
+	e := &external{service: m, selfUserID: "@bot:example.com"}
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceUpToDate)
+}
+
+func TestObserveDeactivatedCondition(t *testing.T) {
+	m := &mockClient{user: &clients.User{UserID: "@alice:example.com", Deactivated: true}}
+	cr := &v1alpha1.User{}
+	meta.SetExternalName(cr, "@alice:example.com")
+
+	e := &external{service: m}
+	_, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, cr.Status.AtProvider.Deactivated)
+	assert.Equal(t, corev1.ConditionTrue, cr.Status.GetCondition(v1alpha1.TypeDeactivated).Status)
+
+	m.user.Deactivated = false
+	_, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, corev1.ConditionFalse, cr.Status.GetCondition(v1alpha1.TypeDeactivated).Status)
+}
+
+func TestObserveRateLimit(t *testing.T) {
+	m := &mockClient{user: &clients.User{UserID: "@alice:example.com"}}
+	cr := &v1alpha1.User{}
+	meta.SetExternalName(cr, "@alice:example.com")
+
+	e := &external{service: m}
+	_, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Nil(t, cr.Status.AtProvider.RateLimit)
+
+	m.rateLimit = &clients.RateLimit{MessagesPerSecond: 0, BurstCount: 0}
+	_, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, &v1alpha1.RateLimit{}, cr.Status.AtProvider.RateLimit)
+}
+
+func TestShadowBan(t *testing.T) {
+	shadowBanned := true
+	m := &mockClient{user: &clients.User{UserID: "@alice:example.com"}}
+	cr := &v1alpha1.User{Spec: v1alpha1.UserSpec{ForProvider: v1alpha1.UserParameters{ShadowBanned: &shadowBanned}}}
+	meta.SetExternalName(cr, "@alice:example.com")
+	e := &external{service: m}
+
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceUpToDate)
+	assert.False(t, cr.Status.AtProvider.ShadowBanned)
+
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	require.NotNil(t, m.shadowBanned)
+	assert.True(t, *m.shadowBanned)
+
+	m.user.ShadowBanned = true
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceUpToDate)
+	assert.True(t, cr.Status.AtProvider.ShadowBanned)
+
+	// A User that doesn't manage the shadow-ban leaves it alone
+	cr.Spec.ForProvider.ShadowBanned = nil
+	m.user.ShadowBanned = false
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceUpToDate)
+}
+
+func TestObserveConnections(t *testing.T) {
+	lastSeen := time.UnixMilli(1700000000000)
+	m := &mockClient{
+		user:        &clients.User{UserID: "@alice:example.com"},
+		connections: []clients.Connection{{DeviceID: "PHONE", IP: "192.0.2.1", UserAgent: "Element", LastSeenTime: &lastSeen}},
+	}
+	cr := &v1alpha1.User{}
+	meta.SetExternalName(cr, "@alice:example.com")
+	e := &external{service: m, logger: logging.NewNopLogger()}
+
+	// Connections aren't fetched unless asked for
+	_, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Zero(t, m.whoisRequests)
+	assert.Empty(t, cr.Status.AtProvider.Connections)
+
+	report := true
+	cr.Spec.ForProvider.ReportConnections = &report
+	_, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, []v1alpha1.Connection{{
+		DeviceID:     "PHONE",
+		IP:           "192.0.2.1",
+		UserAgent:    "Element",
+		LastSeenTime: &metav1.Time{Time: lastSeen},
+	}}, cr.Status.AtProvider.Connections)
+
+	// Failing to list them doesn't fail the observation
+	m.connections, m.connectionsErr = nil, errors.New("listing connections requires admin API access")
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceExists)
+	assert.Equal(t, "@alice:example.com", cr.Status.AtProvider.UserID)
+	assert.Empty(t, cr.Status.AtProvider.Connections)
+}
+
+func TestExternalNameFormat(t *testing.T) {
+	tests := []struct {
+		name               string
+		externalNameFormat string
+		wantExternalName   string
+	}{
+		{
+			name:               "full user ID",
+			externalNameFormat: apisv1beta1.UserExternalNameUserID,
+			wantExternalName:   "@alice:example.com",
+		},
+		{
+			name:               "localpart",
+			externalNameFormat: apisv1beta1.UserExternalNameLocalpart,
+			wantExternalName:   "alice",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mockClient{user: &clients.User{UserID: "@alice:example.com"}}
+			cr := &v1alpha1.User{Spec: v1alpha1.UserSpec{ForProvider: v1alpha1.UserParameters{Localpart: stringPtr("alice")}}}
+
+			e := &external{service: m, selfUserID: "@bot:example.com", externalNameFormat: tt.externalNameFormat}
+			_, err := e.Create(context.Background(), cr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantExternalName, meta.GetExternalName(cr))
+
+			obs, err := e.Observe(context.Background(), cr)
+			require.NoError(t, err)
+			assert.True(t, obs.ResourceExists)
+			assert.Equal(t, "@alice:example.com", m.requested)
+		})
+	}
+}
+
+func TestLocalpartExternalNameServerName(t *testing.T) {
+	m := &mockClient{user: &clients.User{UserID: "@alice:other.example.com"}}
+	cr := &v1alpha1.User{Spec: v1alpha1.UserSpec{ForProvider: v1alpha1.UserParameters{UserID: stringPtr("@alice:other.example.com")}}}
+	meta.SetExternalName(cr, "alice")
+
+	e := &external{service: m, selfUserID: "@bot:example.com"}
+	_, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, "@alice:other.example.com", m.requested)
+
+	// Without a server name to qualify the localpart with
+	e = &external{service: m}
+	_, err = e.Observe(context.Background(), &v1alpha1.User{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{meta.AnnotationKeyExternalName: "alice"}}})
+	assert.EqualError(t, err, errResolveUserID)
+}
+
+func TestObserveRemoteUser(t *testing.T) {
+	m := &mockClient{user: &clients.User{UserID: "@alice:remote.example.org", DisplayName: "Alice"}}
+	cr := &v1alpha1.User{Spec: v1alpha1.UserSpec{ForProvider: v1alpha1.UserParameters{DisplayName: stringPtr("Alice Updated")}}}
+	meta.SetExternalName(cr, "@alice:remote.example.org")
+
+	e := &external{service: m, selfUserID: "@bot:example.com"}
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceUpToDate)
+	cond := cr.Status.GetCondition(v1alpha1.TypeRemoteUserImmutable)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Contains(t, cond.Message, "displayName")
+
+	// A local user with the same drift is updated
+	m.user.UserID = "@alice:example.com"
+	meta.SetExternalName(cr, "@alice:example.com")
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceUpToDate)
+	assert.Equal(t, corev1.ConditionFalse, cr.Status.GetCondition(v1alpha1.TypeRemoteUserImmutable).Status)
+}
+
+func TestCaseInsensitiveLocalparts(t *testing.T) {
+	tests := []struct {
+		name             string
+		caseInsensitive  bool
+		wantExists       bool
+		wantExternalName string
+	}{
+		{
+			name:             "localparts are case-sensitive",
+			wantExternalName: "@Alice:example.com",
+		},
+		{
+			name:             "lowercased localpart is found",
+			caseInsensitive:  true,
+			wantExists:       true,
+			wantExternalName: "@alice:example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mockClient{
+				user:    &clients.User{UserID: "@alice:example.com"},
+				missing: []string{"@Alice:example.com"},
+			}
+			cr := &v1alpha1.User{}
+			meta.SetExternalName(cr, "@Alice:example.com")
+
+			e := &external{service: m, selfUserID: "@bot:example.com", caseInsensitiveLocalparts: tt.caseInsensitive}
+			obs, err := e.Observe(context.Background(), cr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantExists, obs.ResourceExists)
+			assert.Equal(t, tt.wantExists, obs.ResourceLateInitialized)
+			assert.Equal(t, tt.wantExternalName, meta.GetExternalName(cr))
+		})
+	}
+}
+
+func TestLowercaseLocalpart(t *testing.T) {
+	assert.Equal(t, "@alice:Example.com", lowercaseLocalpart("@Alice:Example.com"))
+	assert.Equal(t, "@alice:example.com", lowercaseLocalpart("@alice:example.com"))
+}
+
+func TestDeactivationGracePeriod(t *testing.T) {
+	tests := []struct {
+		name            string
+		deletedAgo      time.Duration
+		alreadyLocked   bool
+		wantLocked      bool
+		wantDeactivated bool
+	}{
+		{
+			name:       "grace period started",
+			wantLocked: true,
+		},
+		{
+			name:          "already locked",
+			deletedAgo:    30 * time.Minute,
+			alreadyLocked: true,
+		},
+		{
+			name:            "grace period over",
+			deletedAgo:      2 * time.Hour,
+			wantDeactivated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mockClient{}
+			cr := &v1alpha1.User{Spec: v1alpha1.UserSpec{ForProvider: v1alpha1.UserParameters{
+				DeactivationGracePeriod: &metav1.Duration{Duration: time.Hour},
+			}}}
+			meta.SetExternalName(cr, "@alice:example.com")
+			deleted := metav1.NewTime(time.Now().Add(-tt.deletedAgo))
+			cr.SetDeletionTimestamp(&deleted)
+			cr.Status.AtProvider.Locked = tt.alreadyLocked
+
+			e := &external{service: m}
+			_, err := e.Delete(context.Background(), cr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantLocked, m.locked)
+			assert.Equal(t, tt.wantDeactivated, m.deactivated)
+
+			pending := cr.Status.GetCondition(v1alpha1.TypeDeactivationPending).Status == corev1.ConditionTrue
+			assert.Equal(t, !tt.wantDeactivated, pending)
+		})
+	}
+}
+
+func TestDeactivationGracePeriodLifecycle(t *testing.T) {
+	m := &mockClient{user: &clients.User{UserID: "@alice:example.com"}}
+	cr := &v1alpha1.User{Spec: v1alpha1.UserSpec{ForProvider: v1alpha1.UserParameters{
+		DeactivationGracePeriod: &metav1.Duration{Duration: time.Hour},
+	}}}
+	meta.SetExternalName(cr, "@alice:example.com")
+	deleted := metav1.Now()
+	cr.SetDeletionTimestamp(&deleted)
+	e := &external{service: m}
+
+	// The user is locked for the grace period
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceExists)
+	_, err = e.Delete(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, m.locked)
+	assert.False(t, m.deactivated)
+	m.user.Locked = true
+
+	// Until it is over, the locked user is neither locked again nor
+	// deactivated
+	m.locked = false
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceExists)
+	_, err = e.Delete(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, m.locked)
+	assert.False(t, m.deactivated)
+
+	// Once it is over the user is deactivated
+	deleted = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	cr.SetDeletionTimestamp(&deleted)
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceExists)
+	_, err = e.Delete(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, m.deactivated)
+	m.user.Deactivated = true
+
+	// The homeserver still returns the deactivated user, but the User is
+	// gone, which releases its finalizer
+	m.deactivated = false
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceExists)
+	assert.False(t, m.deactivated)
+}
+
+func TestDeviceDisplayNames(t *testing.T) {
+	m := &mockClient{
+		user: &clients.User{UserID: "@bot:example.com"},
+		devices: []clients.Device{
+			{DeviceID: "ABCDEF", DisplayName: "Crossplane provider-matrix"},
+			{DeviceID: "PHONE", DisplayName: "Phone"},
+		},
+	}
+	cr := &v1alpha1.User{Spec: v1alpha1.UserSpec{ForProvider: v1alpha1.UserParameters{
+		DeviceDisplayNames: map[string]string{
+			"ABCDEF":  "Alerts bot",
+			"MISSING": "Gone",
+		},
+	}}}
+	meta.SetExternalName(cr, "@bot:example.com")
+
+	e := &external{service: m}
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceUpToDate)
+	assert.Len(t, cr.Status.AtProvider.Devices, 2)
+
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"ABCDEF": "Alerts bot"}, m.renamed)
+
+	obs, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.True(t, obs.ResourceUpToDate)
+}
+
+func TestObserveOnlyFields(t *testing.T) {
+	m := &mockClient{user: &clients.User{UserID: "@alice:example.com", DisplayName: "Ally", Admin: true}}
+	cr := &v1alpha1.User{Spec: v1alpha1.UserSpec{ForProvider: v1alpha1.UserParameters{
+		DisplayName:       stringPtr("Alice"),
+		AvatarURL:         stringPtr("mxc://example.com/alice"),
+		Admin:             boolPtr(false),
+		ShadowBanned:      boolPtr(true),
+		ObserveOnlyFields: []string{"displayName", "admin", "shadowBanned"},
+	}}}
+	meta.SetExternalName(cr, "@alice:example.com")
+	e := &external{service: m}
+
+	// The display name is used to create the user
+	assert.Equal(t, "Alice", generateUserSpec(cr).DisplayName)
+
+	// Afterwards it is observed, but neither reported as drift nor updated
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceUpToDate)
+	assert.Equal(t, "Ally", cr.Status.AtProvider.DisplayName)
+	assert.Equal(t, []string{"avatarURL"}, userDrift(observeonly.Desired(cr), m.user))
+
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	require.NotNil(t, m.updated)
+	// The display name is left out, and the admin flag, which is always
+	// sent, keeps its observed value
+	assert.Empty(t, m.updated.DisplayName)
+	assert.True(t, m.updated.Admin)
+	assert.Equal(t, "mxc://example.com/alice", m.updated.AvatarURL)
+	assert.Nil(t, m.shadowBanned)
+	assert.Equal(t, "Alice", *cr.Spec.ForProvider.DisplayName)
+}
+
 // Helper functions for creating pointers
 func stringPtr(s string) *string {
 	return &s
@@ -0,0 +1,243 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package userratelimit
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/userratelimit/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane-contrib/provider-matrix/internal/connection"
+	"github.com/crossplane-contrib/provider-matrix/internal/forcedelete"
+	"github.com/crossplane-contrib/provider-matrix/internal/maintenance"
+	"github.com/crossplane-contrib/provider-matrix/internal/metrics"
+	"github.com/crossplane-contrib/provider-matrix/internal/observeonly"
+	"github.com/crossplane-contrib/provider-matrix/internal/permission"
+	"github.com/crossplane-contrib/provider-matrix/internal/provenance"
+	"github.com/crossplane-contrib/provider-matrix/internal/resync"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/controller"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/feature"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/ratelimiter"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	"github.com/pkg/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	errNotUserRateLimit = "managed resource is not a UserRateLimit custom resource"
+	errTrackPCUsage     = "cannot track ProviderConfig usage"
+	errGetPC            = "cannot get ProviderConfig"
+	errGetCreds         = "cannot get credentials"
+	errNewClient        = "cannot create new Matrix client"
+	errSyncProfile      = "cannot sync the provider user's profile"
+	errGetRateLimit     = "cannot get Matrix user rate-limit override"
+	errSetRateLimit     = "cannot set Matrix user rate-limit override"
+	errDeleteRateLimit  = "cannot delete Matrix user rate-limit override"
+)
+
+// Setup adds a controller that reconciles UserRateLimit managed resources.
+func Setup(mgr ctrl.Manager, o controller.Options) error {
+	name := managed.ControllerName(v1alpha1.UserRateLimitKind)
+
+	opts := []managed.ReconcilerOption{
+		managed.WithExternalConnector(maintenance.Connector(v1alpha1.UserRateLimitGroupKind, connection.Connector(permission.Connector(forcedelete.Connector(&connector{
+			kube:         mgr.GetClient(),
+			usage:        clients.NewProviderConfigUsageTracker(mgr.GetClient()),
+			newServiceFn: clients.CachedClient,
+			logger:       o.Logger.WithValues("controller", name),
+		}, o.Logger.WithValues("controller", name)))))),
+		managed.WithLogger(o.Logger.WithValues("controller", name)),
+		managed.WithPollInterval(o.PollInterval),
+		managed.WithPollIntervalHook(resync.PollIntervalHook),
+		managed.WithRecorder(nil),
+	}
+	if o.Features.Enabled(feature.EnableBetaManagementPolicies) {
+		opts = append(opts, managed.WithManagementPolicies())
+	}
+
+	r := managed.NewReconciler(mgr, resource.ManagedKind(v1alpha1.UserRateLimitGroupVersionKind), opts...)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(name).
+		WithOptions(o.ForControllerRuntime()).
+		WithEventFilter(resource.DesiredStateChanged()).
+		For(&v1alpha1.UserRateLimit{}).
+		Complete(ratelimiter.NewReconciler(name, maintenance.Reconciler(v1alpha1.UserRateLimitGroupKind, r), o.GlobalRateLimiter))
+}
+
+// A connector is expected to produce an ExternalClient when its Connect method
+// is called.
+type connector struct {
+	kube         client.Client
+	usage        resource.ModernTracker
+	newServiceFn func(config *clients.Config) (clients.Client, error)
+	logger       logging.Logger
+}
+
+// Connect typically produces an ExternalClient by:
+// 1. Tracking that the managed resource is using a ProviderConfig.
+// 2. Getting the managed resource's ProviderConfig.
+// 3. Getting the credentials specified by the ProviderConfig.
+// 4. Using the credentials to form a client.
+func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	if _, ok := mg.(*v1alpha1.UserRateLimit); !ok {
+		return nil, errors.New(errNotUserRateLimit)
+	}
+
+	modernManaged, ok := mg.(resource.ModernManaged)
+	if !ok {
+		return nil, errors.New("managed resource does not implement ModernManaged")
+	}
+	if err := c.usage.Track(ctx, modernManaged); err != nil {
+		return nil, errors.Wrap(err, errTrackPCUsage)
+	}
+
+	pc, err := clients.GetProviderConfig(ctx, c.kube, mg)
+	if err != nil {
+		return nil, errors.Wrap(err, errGetPC)
+	}
+	resync.Set(pc)
+
+	config, err := clients.GetConfig(ctx, c.kube, mg)
+	if err != nil {
+		return nil, errors.Wrap(err, errGetCreds)
+	}
+	config.Logger = c.logger.WithValues("providerConfig", config.ProviderConfigName)
+
+	service, err := c.newServiceFn(config)
+	if err != nil {
+		return nil, errors.Wrap(err, errNewClient)
+	}
+
+	if err := clients.VerifyConnection(ctx, c.kube, pc, service); err != nil {
+		return nil, err
+	}
+
+	if err := service.SyncProfile(ctx); err != nil {
+		return nil, errors.Wrap(err, errSyncProfile)
+	}
+
+	return &external{service: service}, nil
+}
+
+// An ExternalClient observes, then either creates, updates, or deletes an
+// external resource to ensure it reflects the managed resource's desired state.
+type external struct {
+	service clients.Client
+}
+
+func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
+	cr, ok := mg.(*v1alpha1.UserRateLimit)
+	if !ok {
+		return managed.ExternalObservation{}, errors.New(errNotUserRateLimit)
+	}
+
+	// Synapse returns an empty override for a user who has none, which is
+	// the override not existing
+	rateLimit, err := c.service.GetRateLimit(ctx, cr.Spec.ForProvider.UserID)
+	if err != nil {
+		return managed.ExternalObservation{}, errors.Wrap(err, errGetRateLimit)
+	}
+	if rateLimit == nil {
+		return managed.ExternalObservation{
+			ResourceExists: false,
+		}, nil
+	}
+
+	cr.Status.AtProvider.MessagesPerSecond = rateLimit.MessagesPerSecond
+	cr.Status.AtProvider.BurstCount = rateLimit.BurstCount
+	cr.Status.AtProvider.ManagedBy, cr.Status.AtProvider.AdoptedAt = provenance.Observe(cr, cr.Status.AtProvider.AdoptedAt)
+	cr.Status.SetConditions(xpv1.Available())
+
+	drift := rateLimitDrift(observeonly.Desired(cr), rateLimit)
+	metrics.RecordDrift(v1alpha1.UserRateLimitKind, drift)
+
+	return managed.ExternalObservation{
+		ResourceExists:   true,
+		ResourceUpToDate: len(drift) == 0,
+	}, nil
+}
+
+func (c *external) Create(ctx context.Context, mg resource.Managed) (managed.ExternalCreation, error) {
+	cr, ok := mg.(*v1alpha1.UserRateLimit)
+	if !ok {
+		return managed.ExternalCreation{}, errors.New(errNotUserRateLimit)
+	}
+
+	err := c.service.SetRateLimit(ctx, cr.Spec.ForProvider.UserID, desiredRateLimit(cr, &clients.RateLimit{}))
+	return managed.ExternalCreation{}, errors.Wrap(err, errSetRateLimit)
+}
+
+func (c *external) Update(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
+	cr, ok := mg.(*v1alpha1.UserRateLimit)
+	if !ok {
+		return managed.ExternalUpdate{}, errors.New(errNotUserRateLimit)
+	}
+
+	observed := &clients.RateLimit{
+		MessagesPerSecond: cr.Status.AtProvider.MessagesPerSecond,
+		BurstCount:        cr.Status.AtProvider.BurstCount,
+	}
+	err := c.service.SetRateLimit(ctx, cr.Spec.ForProvider.UserID, desiredRateLimit(observeonly.Desired(cr), observed))
+	return managed.ExternalUpdate{}, errors.Wrap(err, errSetRateLimit)
+}
+
+func (c *external) Delete(ctx context.Context, mg resource.Managed) (managed.ExternalDelete, error) {
+	cr, ok := mg.(*v1alpha1.UserRateLimit)
+	if !ok {
+		return managed.ExternalDelete{}, errors.New(errNotUserRateLimit)
+	}
+
+	err := c.service.DeleteRateLimit(ctx, cr.Spec.ForProvider.UserID)
+	return managed.ExternalDelete{}, errors.Wrap(err, errDeleteRateLimit)
+}
+
+// Disconnect closes the external client.
+func (c *external) Disconnect(ctx context.Context) error {
+	return nil // No special disconnect logic needed
+}
+
+// desiredRateLimit returns the override to set, keeping the observed values
+// of the fields the spec leaves out
+func desiredRateLimit(cr *v1alpha1.UserRateLimit, observed *clients.RateLimit) *clients.RateLimit {
+	desired := *observed
+	if p := cr.Spec.ForProvider.MessagesPerSecond; p != nil {
+		desired.MessagesPerSecond = *p
+	}
+	if p := cr.Spec.ForProvider.BurstCount; p != nil {
+		desired.BurstCount = *p
+	}
+	return &desired
+}
+
+// rateLimitDrift returns the spec fields that differ from the observed
+// override
+func rateLimitDrift(cr *v1alpha1.UserRateLimit, rateLimit *clients.RateLimit) []string {
+	var drift []string
+	p := cr.Spec.ForProvider
+
+	if p.MessagesPerSecond != nil && *p.MessagesPerSecond != rateLimit.MessagesPerSecond {
+		drift = append(drift, "messagesPerSecond")
+	}
+	if p.BurstCount != nil && *p.BurstCount != rateLimit.BurstCount {
+		drift = append(drift, "burstCount")
+	}
+	return drift
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package userratelimit
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/common"
+	"github.com/crossplane-contrib/provider-matrix/apis/userratelimit/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type mockClient struct {
+	clients.Client
+
+	rateLimit *clients.RateLimit
+}
+
+func (m *mockClient) GetRateLimit(ctx context.Context, userID string) (*clients.RateLimit, error) {
+	return m.rateLimit, nil
+}
+
+func (m *mockClient) SetRateLimit(ctx context.Context, userID string, rateLimit *clients.RateLimit) error {
+	m.rateLimit = rateLimit
+	return nil
+}
+
+func (m *mockClient) DeleteRateLimit(ctx context.Context, userID string) error {
+	m.rateLimit = nil
+	return nil
+}
+
+func newUserRateLimit(params v1alpha1.UserRateLimitParameters) *v1alpha1.UserRateLimit {
+	params.UserID = "@alice:example.com"
+	return &v1alpha1.UserRateLimit{Spec: v1alpha1.UserRateLimitSpec{ForProvider: params}}
+}
+
+func TestObserveWithoutOverride(t *testing.T) {
+	e := &external{service: &mockClient{}}
+
+	obs, err := e.Observe(context.Background(), newUserRateLimit(v1alpha1.UserRateLimitParameters{}))
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceExists)
+}
+
+func TestObserveDrift(t *testing.T) {
+	zero, ten := 0, 10
+
+	tests := []struct {
+		name   string
+		params v1alpha1.UserRateLimitParameters
+		want   bool
+	}{
+		{name: "unmanaged", want: true},
+		{name: "matching", params: v1alpha1.UserRateLimitParameters{MessagesPerSecond: &zero, BurstCount: &ten}, want: true},
+		{name: "messages per second", params: v1alpha1.UserRateLimitParameters{MessagesPerSecond: &ten}},
+		{name: "burst count", params: v1alpha1.UserRateLimitParameters{BurstCount: &zero}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &external{service: &mockClient{rateLimit: &clients.RateLimit{MessagesPerSecond: 0, BurstCount: 10}}}
+			cr := newUserRateLimit(tt.params)
+
+			obs, err := e.Observe(context.Background(), cr)
+			require.NoError(t, err)
+			assert.True(t, obs.ResourceExists)
+			assert.Equal(t, tt.want, obs.ResourceUpToDate)
+			assert.Equal(t, 10, cr.Status.AtProvider.BurstCount)
+			assert.Equal(t, common.ManagedByAdopted, cr.Status.AtProvider.ManagedBy)
+		})
+	}
+}
+
+func TestCreateUpdateAndDelete(t *testing.T) {
+	five := 5
+	m := &mockClient{}
+	e := &external{service: m}
+	cr := newUserRateLimit(v1alpha1.UserRateLimitParameters{MessagesPerSecond: &five})
+
+	// Fields the spec leaves out are exempt from the limit when created
+	_, err := e.Create(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, &clients.RateLimit{MessagesPerSecond: 5}, m.rateLimit)
+
+	// and keep their current value when updated
+	m.rateLimit = &clients.RateLimit{MessagesPerSecond: 1, BurstCount: 20}
+	_, err = e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, &clients.RateLimit{MessagesPerSecond: 5, BurstCount: 20}, m.rateLimit)
+
+	_, err = e.Delete(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Nil(t, m.rateLimit)
+}
+
+func TestObserveOnlyFields(t *testing.T) {
+	five, ten := 5, 10
+	m := &mockClient{}
+	e := &external{service: m}
+	cr := newUserRateLimit(v1alpha1.UserRateLimitParameters{
+		MessagesPerSecond: &five,
+		BurstCount:        &ten,
+		ObserveOnlyFields: []string{"burstCount"},
+	})
+
+	_, err := e.Create(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, &clients.RateLimit{MessagesPerSecond: 5, BurstCount: 10}, m.rateLimit)
+
+	// Server admins may raise the burst count, while the messages per
+	// second are still managed
+	m.rateLimit = &clients.RateLimit{MessagesPerSecond: 1, BurstCount: 20}
+	obs, err := e.Observe(context.Background(), cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceUpToDate)
+	_, err = e.Update(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, &clients.RateLimit{MessagesPerSecond: 5, BurstCount: 20}, m.rateLimit)
+}
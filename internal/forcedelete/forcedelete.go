@@ -0,0 +1,132 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package forcedelete lets managed resources be deleted once their homeserver
+// has become unreachable, instead of their finalizer blocking forever.
+package forcedelete
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"sync"
+)
+
+var (
+	mu          sync.Mutex
+	maxAttempts int
+	attempts    = map[types.UID]int{}
+)
+
+// SetMaxAttempts sets how many times the homeserver of a deleted resource
+// may be found unreachable before the resource is treated as gone. Zero, the
+// default, never gives up.
+func SetMaxAttempts(n int) {
+	mu.Lock()
+	defer mu.Unlock()
+	maxAttempts = n
+}
+
+// Connector wraps c so that the resources it connects to can be deleted after
+// their homeserver has been unreachable for the configured number of attempts.
+func Connector(c managed.ExternalConnector, log logging.Logger) managed.ExternalConnector {
+	return &connector{ExternalConnector: c, log: log}
+}
+
+type connector struct {
+	managed.ExternalConnector
+	log logging.Logger
+}
+
+func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	ext, err := c.ExternalConnector.Connect(ctx, mg)
+	if err != nil {
+		if c.giveUp(mg, err) {
+			// NopClient reports the external resource as missing, which
+			// lets the finalizer be removed
+			return &managed.NopClient{}, nil
+		}
+		return nil, err
+	}
+	return &external{ExternalClient: ext, connector: c}, nil
+}
+
+// giveUp reports whether mg should be treated as deleted because err shows
+// its homeserver has been unreachable too many times while deleting it.
+func (c *connector) giveUp(mg resource.Managed, err error) bool {
+	if !meta.WasDeleted(mg) || !clients.IsUnreachable(err) {
+		return false
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if maxAttempts <= 0 {
+		return false
+	}
+
+	attempts[mg.GetUID()]++
+	if attempts[mg.GetUID()] < maxAttempts {
+		return false
+	}
+
+	delete(attempts, mg.GetUID())
+	c.log.Info("Homeserver is unreachable, removing finalizer without deleting the external resource",
+		"name", mg.GetName(), "namespace", mg.GetNamespace(), "attempts", maxAttempts, "error", err.Error())
+	return true
+}
+
+// reset forgets the failed attempts at reaching the homeserver of mg once it
+// has been reached again, so that a later outage starts counting afresh and
+// resources whose deletion succeeds don't stay counted. A successful Connect
+// doesn't count, as it may not reach the homeserver at all.
+func reset(mg resource.Managed) {
+	if !meta.WasDeleted(mg) {
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	delete(attempts, mg.GetUID())
+}
+
+type external struct {
+	managed.ExternalClient
+	connector *connector
+}
+
+func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
+	obs, err := e.ExternalClient.Observe(ctx, mg)
+	if err == nil {
+		reset(mg)
+	} else if e.connector.giveUp(mg, err) {
+		return managed.ExternalObservation{ResourceExists: false}, nil
+	}
+	return obs, err
+}
+
+func (e *external) Delete(ctx context.Context, mg resource.Managed) (managed.ExternalDelete, error) {
+	del, err := e.ExternalClient.Delete(ctx, mg)
+	if err == nil {
+		reset(mg)
+	} else if e.connector.giveUp(mg, err) {
+		return managed.ExternalDelete{}, nil
+	}
+	return del, err
+}
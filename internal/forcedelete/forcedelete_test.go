@@ -0,0 +1,139 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package forcedelete
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/room/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"net"
+	"testing"
+)
+
+var (
+	now            = metav1.Now()
+	errUnreachable = errors.Wrap(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, "failed to get room")
+)
+
+func newConnector(observeErr error) managed.ExternalConnector {
+	return Connector(managed.ExternalConnectorFn(func(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+		return &managed.ExternalClientFns{
+			ObserveFn: func(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
+				return managed.ExternalObservation{ResourceExists: true}, observeErr
+			},
+		}, nil
+	}), logging.NewNopLogger())
+}
+
+func observe(t *testing.T, c managed.ExternalConnector, mg resource.Managed) (managed.ExternalObservation, error) {
+	t.Helper()
+	ext, err := c.Connect(context.Background(), mg)
+	require.NoError(t, err)
+	return ext.Observe(context.Background(), mg)
+}
+
+func TestGiveUpAfterMaxAttempts(t *testing.T) {
+	SetMaxAttempts(3)
+	defer SetMaxAttempts(0)
+
+	cr := &v1alpha1.Room{}
+	cr.SetUID("deleted-room")
+	cr.SetDeletionTimestamp(&now)
+	c := newConnector(errUnreachable)
+
+	for i := 0; i < 2; i++ {
+		_, err := observe(t, c, cr)
+		require.Error(t, err)
+	}
+
+	obs, err := observe(t, c, cr)
+	require.NoError(t, err)
+	assert.False(t, obs.ResourceExists)
+}
+
+func TestResetOnceReachable(t *testing.T) {
+	SetMaxAttempts(2)
+	defer SetMaxAttempts(0)
+
+	cr := &v1alpha1.Room{}
+	cr.SetUID("recovered-room")
+	cr.SetDeletionTimestamp(&now)
+
+	_, err := observe(t, newConnector(errUnreachable), cr)
+	require.Error(t, err)
+	assert.Equal(t, 1, attempts[cr.GetUID()])
+
+	// The homeserver is reachable again, so the failed attempt is forgotten
+	_, err = observe(t, newConnector(nil), cr)
+	require.NoError(t, err)
+	assert.NotContains(t, attempts, cr.GetUID())
+
+	// A later outage starts counting afresh
+	_, err = observe(t, newConnector(errUnreachable), cr)
+	assert.Error(t, err)
+}
+
+func TestKeepTryingOtherwise(t *testing.T) {
+	SetMaxAttempts(1)
+	defer SetMaxAttempts(0)
+
+	deleted := &v1alpha1.Room{}
+	deleted.SetUID("deleted-room")
+	deleted.SetDeletionTimestamp(&now)
+
+	live := &v1alpha1.Room{}
+	live.SetUID("live-room")
+
+	tests := []struct {
+		name string
+		cr   *v1alpha1.Room
+		err  error
+	}{
+		{
+			name: "resource not being deleted",
+			cr:   live,
+			err:  errUnreachable,
+		},
+		{
+			name: "homeserver reachable but failing",
+			cr:   deleted,
+			err:  errors.New("M_FORBIDDEN"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := observe(t, newConnector(tt.err), tt.cr)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestDisabledByDefault(t *testing.T) {
+	cr := &v1alpha1.Room{}
+	cr.SetUID("deleted-room")
+	cr.SetDeletionTimestamp(&now)
+
+	_, err := observe(t, newConnector(errUnreachable), cr)
+	assert.Error(t, err)
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package maintenance backs off from homeservers that say they are
+// unavailable, such as during maintenance, instead of retrying them as
+// eagerly as after any other error.
+package maintenance
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/common"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sync"
+	"time"
+)
+
+const (
+	// minBackoff is how long to wait before reconciling a resource again
+	// when its homeserver did not say how long it would be unavailable
+	minBackoff = time.Minute
+
+	// maxBackoff bounds how long a homeserver can ask to be left alone
+	maxBackoff = 30 * time.Minute
+)
+
+var (
+	mu       sync.Mutex
+	backoffs = map[key]time.Duration{}
+)
+
+// key identifies a resource of a kind. Resources of different kinds, which
+// are reconciled by different controllers, can have the same name.
+type key struct {
+	kind schema.GroupKind
+	types.NamespacedName
+}
+
+// Connector wraps c, which connects to resources of the given kind, so that
+// errors showing the homeserver is unavailable set a ServerUnavailable
+// condition and schedule a longer backoff, which the Reconciler wrapper for
+// the same kind applies.
+func Connector(kind schema.GroupKind, c managed.ExternalConnector) managed.ExternalConnector {
+	return &connector{ExternalConnector: c, kind: kind}
+}
+
+// Reconciler wraps r, which reconciles resources of the given kind, so that
+// resources whose homeserver said it was unavailable are requeued after a
+// longer backoff than other errors get.
+func Reconciler(kind schema.GroupKind, r reconcile.Reconciler) reconcile.Reconciler {
+	return reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+		result, err := r.Reconcile(ctx, req)
+
+		k := key{kind: kind, NamespacedName: req.NamespacedName}
+		mu.Lock()
+		backoff, ok := backoffs[k]
+		delete(backoffs, k)
+		mu.Unlock()
+
+		if ok && err == nil {
+			return reconcile.Result{RequeueAfter: backoff}, nil
+		}
+		return result, err
+	})
+}
+
+type connector struct {
+	managed.ExternalConnector
+	kind schema.GroupKind
+}
+
+func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	ext, err := c.ExternalConnector.Connect(ctx, mg)
+	if err != nil {
+		return nil, check(c.kind, mg, err)
+	}
+	return &external{ExternalClient: ext, kind: c.kind}, nil
+}
+
+// check schedules a backoff for mg, a resource of the given kind, if err
+// shows its homeserver is unavailable. It returns err unchanged.
+func check(kind schema.GroupKind, mg resource.Managed, err error) error {
+	retryAfter, ok := clients.IsServerUnavailable(err)
+	if !ok {
+		return err
+	}
+
+	backoff := min(max(retryAfter, minBackoff), maxBackoff)
+	mu.Lock()
+	backoffs[key{kind: kind, NamespacedName: types.NamespacedName{Namespace: mg.GetNamespace(), Name: mg.GetName()}}] = backoff
+	mu.Unlock()
+
+	mg.SetConditions(common.ServerUnavailable(backoff))
+	return err
+}
+
+type external struct {
+	managed.ExternalClient
+	kind schema.GroupKind
+}
+
+func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
+	obs, err := e.ExternalClient.Observe(ctx, mg)
+	if err != nil {
+		return obs, check(e.kind, mg, err)
+	}
+	if mg.GetCondition(common.TypeServerUnavailable).Status == corev1.ConditionTrue {
+		mg.SetConditions(common.ServerAvailable())
+	}
+	return obs, nil
+}
+
+func (e *external) Create(ctx context.Context, mg resource.Managed) (managed.ExternalCreation, error) {
+	cre, err := e.ExternalClient.Create(ctx, mg)
+	return cre, check(e.kind, mg, err)
+}
+
+func (e *external) Update(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
+	upd, err := e.ExternalClient.Update(ctx, mg)
+	return upd, check(e.kind, mg, err)
+}
+
+func (e *external) Delete(ctx context.Context, mg resource.Managed) (managed.ExternalDelete, error) {
+	del, err := e.ExternalClient.Delete(ctx, mg)
+	return del, check(e.kind, mg, err)
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package maintenance
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/common"
+	"github.com/crossplane-contrib/provider-matrix/apis/room/v1alpha1"
+	spacev1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/space/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"maunium.net/go/mautrix"
+	"net/http"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"testing"
+	"time"
+)
+
+func unavailable(retryAfter string) error {
+	resp := &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{}}
+	if retryAfter != "" {
+		resp.Header.Set("Retry-After", retryAfter)
+	}
+	return errors.Wrap(mautrix.HTTPError{Response: resp}, "failed to get room")
+}
+
+func newConnector(observeErr error) managed.ExternalConnector {
+	return Connector(v1alpha1.RoomGroupKind, managed.ExternalConnectorFn(func(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+		return &managed.ExternalClientFns{
+			ObserveFn: func(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
+				return managed.ExternalObservation{ResourceExists: true}, observeErr
+			},
+		}, nil
+	}))
+}
+
+// reconcileRoom observes cr through c from a reconciler wrapped by Reconciler
+func reconcileRoom(t *testing.T, c managed.ExternalConnector, cr *v1alpha1.Room) (reconcile.Result, error) {
+	t.Helper()
+	r := Reconciler(v1alpha1.RoomGroupKind, reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+		ext, err := c.Connect(ctx, cr)
+		require.NoError(t, err)
+		if _, err := ext.Observe(ctx, cr); err != nil {
+			return reconcile.Result{Requeue: true}, nil
+		}
+		return reconcile.Result{RequeueAfter: time.Hour}, nil
+	}))
+	return r.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: cr.GetName()}})
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want time.Duration
+	}{
+		{
+			name: "retry-after given",
+			err:  unavailable("300"),
+			want: 5 * time.Minute,
+		},
+		{
+			name: "no retry-after",
+			err:  unavailable(""),
+			want: minBackoff,
+		},
+		{
+			name: "retry-after too short",
+			err:  unavailable("1"),
+			want: minBackoff,
+		},
+		{
+			name: "retry-after too long",
+			err:  unavailable("86400"),
+			want: maxBackoff,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := &v1alpha1.Room{}
+			cr.SetName("maintenance-room")
+
+			result, err := reconcileRoom(t, newConnector(tt.err), cr)
+			require.NoError(t, err)
+			assert.Equal(t, reconcile.Result{RequeueAfter: tt.want}, result)
+			assert.Equal(t, corev1.ConditionTrue, cr.GetCondition(common.TypeServerUnavailable).Status)
+		})
+	}
+}
+
+func TestOtherErrorsKeepNormalBackoff(t *testing.T) {
+	cr := &v1alpha1.Room{}
+	cr.SetName("failing-room")
+
+	result, err := reconcileRoom(t, newConnector(errors.New("M_FORBIDDEN")), cr)
+	require.NoError(t, err)
+	assert.Equal(t, reconcile.Result{Requeue: true}, result)
+	assert.Equal(t, corev1.ConditionUnknown, cr.GetCondition(common.TypeServerUnavailable).Status)
+}
+
+func TestServerAvailableAgain(t *testing.T) {
+	cr := &v1alpha1.Room{}
+	cr.SetName("recovered-room")
+
+	_, err := reconcileRoom(t, newConnector(unavailable("")), cr)
+	require.NoError(t, err)
+
+	result, err := reconcileRoom(t, newConnector(nil), cr)
+	require.NoError(t, err)
+	assert.Equal(t, reconcile.Result{RequeueAfter: time.Hour}, result)
+
+	cond := cr.GetCondition(common.TypeServerUnavailable)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+	assert.Equal(t, common.ReasonServerAvailable, cond.Reason)
+}
+
+func TestBackoffPerKind(t *testing.T) {
+	cr := &v1alpha1.Room{}
+	cr.SetName("general")
+
+	ext, err := newConnector(unavailable("300")).Connect(context.Background(), cr)
+	require.NoError(t, err)
+	_, err = ext.Observe(context.Background(), cr)
+	require.Error(t, err)
+
+	// A Space of the same name is reconciled meanwhile, and keeps its own
+	// schedule rather than taking the Room's backoff
+	requeue := func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+		return reconcile.Result{RequeueAfter: time.Hour}, nil
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "general"}}
+	result, err := Reconciler(spacev1alpha1.SpaceGroupKind, reconcile.Func(requeue)).Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, reconcile.Result{RequeueAfter: time.Hour}, result)
+
+	result, err = Reconciler(v1alpha1.RoomGroupKind, reconcile.Func(requeue)).Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, reconcile.Result{RequeueAfter: 5 * time.Minute}, result)
+}
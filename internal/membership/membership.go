@@ -0,0 +1,60 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package membership keeps the provider's user joined to the rooms whose
+// state it manages, as state changes can only be sent by room members.
+package membership
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/common"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	errGetMembership = "cannot get the provider's membership of the room"
+	errJoinRoom      = "cannot join the provider's user to the room"
+)
+
+// Observe reports whether the provider's user is joined to a room. When it
+// isn't, the ProviderNotJoined condition is set on cr.
+func Observe(ctx context.Context, service clients.Client, cr resource.Conditioned, roomID string) (bool, error) {
+	membership, err := service.GetMembership(ctx, roomID)
+	if err != nil {
+		return false, errors.Wrap(err, errGetMembership)
+	}
+
+	if membership != "join" {
+		cr.SetConditions(common.ProviderNotJoined(roomID, membership))
+		return false, nil
+	}
+	if cr.GetCondition(common.TypeProviderNotJoined).Status == corev1.ConditionTrue {
+		cr.SetConditions(common.ProviderJoined())
+	}
+	return true, nil
+}
+
+// Ensure joins the provider's user to a room if Observe found it isn't
+// joined. The ProviderNotJoined condition is cleared by the next Observe.
+func Ensure(ctx context.Context, service clients.Client, cr resource.Conditioned, roomID string) error {
+	if cr.GetCondition(common.TypeProviderNotJoined).Status != corev1.ConditionTrue {
+		return nil
+	}
+	return errors.Wrap(service.JoinRoom(ctx, roomID), errJoinRoom)
+}
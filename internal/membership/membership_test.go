@@ -0,0 +1,73 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package membership
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/common"
+	"github.com/crossplane-contrib/provider-matrix/apis/room/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"testing"
+)
+
+type mockClient struct {
+	clients.Client
+
+	membership string
+	joined     []string
+}
+
+func (m *mockClient) GetMembership(ctx context.Context, roomID string) (string, error) {
+	return m.membership, nil
+}
+
+func (m *mockClient) JoinRoom(ctx context.Context, roomID string) error {
+	m.joined = append(m.joined, roomID)
+	m.membership = "join"
+	return nil
+}
+
+func TestObserveAndEnsure(t *testing.T) {
+	m := &mockClient{membership: "join"}
+	cr := &v1alpha1.Room{}
+
+	joined, err := Observe(context.Background(), m, cr, "!room:example.com")
+	require.NoError(t, err)
+	assert.True(t, joined)
+	require.NoError(t, Ensure(context.Background(), m, cr, "!room:example.com"))
+	assert.Empty(t, m.joined)
+
+	// Kicked from the room
+	m.membership = "leave"
+	joined, err = Observe(context.Background(), m, cr, "!room:example.com")
+	require.NoError(t, err)
+	assert.False(t, joined)
+	cond := cr.GetCondition(common.TypeProviderNotJoined)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Contains(t, cond.Message, "membership: leave")
+
+	require.NoError(t, Ensure(context.Background(), m, cr, "!room:example.com"))
+	assert.Equal(t, []string{"!room:example.com"}, m.joined)
+
+	joined, err = Observe(context.Background(), m, cr, "!room:example.com")
+	require.NoError(t, err)
+	assert.True(t, joined)
+	assert.Equal(t, corev1.ConditionFalse, cr.GetCondition(common.TypeProviderNotJoined).Status)
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics contains the Prometheus metrics exported by the provider.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"time"
+)
+
+var driftTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "provider_matrix_drift_total",
+	Help: "Number of times a managed resource was observed to have drifted from its desired state, by kind and field.",
+}, []string{"kind", "field"})
+
+var retryAfterSeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Name:    "provider_matrix_rate_limit_retry_after_seconds",
+	Help:    "Delays the homeserver asked the provider to wait before retrying rate-limited requests.",
+	Buckets: []float64{0.1, 0.5, 1, 2, 5, 10, 30, 60, 300},
+})
+
+func init() {
+	metrics.Registry.MustRegister(driftTotal, retryAfterSeconds)
+}
+
+// RecordDrift increments the drift counter for each drifted field of a resource kind
+func RecordDrift(kind string, fields []string) {
+	for _, field := range fields {
+		driftTotal.WithLabelValues(kind, field).Inc()
+	}
+}
+
+// RecordRetryAfter records the retry delay of a rate-limited request
+func RecordRetryAfter(d time.Duration) {
+	retryAfterSeconds.Observe(d.Seconds())
+}
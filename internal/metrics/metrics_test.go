@@ -0,0 +1,58 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordDrift(t *testing.T) {
+	before := testutil.ToFloat64(driftTotal.WithLabelValues("Room", "topic"))
+
+	RecordDrift("Room", []string{"topic", "name"})
+	RecordDrift("Room", []string{"topic"})
+	RecordDrift("Room", nil)
+
+	assert.Equal(t, before+2, testutil.ToFloat64(driftTotal.WithLabelValues("Room", "topic")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(driftTotal.WithLabelValues("Room", "name")))
+}
+
+func TestRecordRetryAfter(t *testing.T) {
+	RecordRetryAfter(1500 * time.Millisecond)
+
+	expected := `
+# HELP provider_matrix_rate_limit_retry_after_seconds Delays the homeserver asked the provider to wait before retrying rate-limited requests.
+# TYPE provider_matrix_rate_limit_retry_after_seconds histogram
+provider_matrix_rate_limit_retry_after_seconds_bucket{le="0.1"} 0
+provider_matrix_rate_limit_retry_after_seconds_bucket{le="0.5"} 0
+provider_matrix_rate_limit_retry_after_seconds_bucket{le="1"} 0
+provider_matrix_rate_limit_retry_after_seconds_bucket{le="2"} 1
+provider_matrix_rate_limit_retry_after_seconds_bucket{le="5"} 1
+provider_matrix_rate_limit_retry_after_seconds_bucket{le="10"} 1
+provider_matrix_rate_limit_retry_after_seconds_bucket{le="30"} 1
+provider_matrix_rate_limit_retry_after_seconds_bucket{le="60"} 1
+provider_matrix_rate_limit_retry_after_seconds_bucket{le="300"} 1
+provider_matrix_rate_limit_retry_after_seconds_bucket{le="+Inf"} 1
+provider_matrix_rate_limit_retry_after_seconds_sum 1.5
+provider_matrix_rate_limit_retry_after_seconds_count 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(retryAfterSeconds, strings.NewReader(expected)))
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package namespaced reconciles the namespaced kinds of managed resources
+// with the connectors of their cluster scoped kinds, whose spec and status
+// they share, so that both kinds of a resource behave the same.
+package namespaced
+
+import (
+	"context"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	"github.com/pkg/errors"
+)
+
+// Connector wraps c, which connects to resources of the cluster scoped kind
+// C, so that it connects to resources of the namespaced kind N instead. A
+// resource is converted to C by toCluster, keeping its namespace so that the
+// ProviderConfig of its namespace is used, before c or the client it returns
+// is called with it, and whatever they changed is copied back to the
+// resource by fromCluster.
+func Connector[N, C resource.Managed](c managed.ExternalConnector, toCluster func(N) C, fromCluster func(C, N)) managed.ExternalConnector {
+	return &connector[N, C]{ExternalConnector: c, convert: converter[N, C]{toCluster: toCluster, fromCluster: fromCluster}}
+}
+
+type converter[N, C resource.Managed] struct {
+	toCluster   func(N) C
+	fromCluster func(C, N)
+}
+
+// call calls fn with mg converted to the cluster scoped kind, and copies
+// back whatever it changed
+func (cv converter[N, C]) call(mg resource.Managed, fn func(C) error) error {
+	n, ok := mg.(N)
+	if !ok {
+		return errors.Errorf("managed resource is not a %T", n)
+	}
+	cr := cv.toCluster(n)
+	err := fn(cr)
+	cv.fromCluster(cr, n)
+	return err
+}
+
+type connector[N, C resource.Managed] struct {
+	managed.ExternalConnector
+	convert converter[N, C]
+}
+
+func (c *connector[N, C]) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	var ext managed.ExternalClient
+	err := c.convert.call(mg, func(cr C) error {
+		var err error
+		ext, err = c.ExternalConnector.Connect(ctx, cr)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &external[N, C]{ExternalClient: ext, convert: c.convert}, nil
+}
+
+type external[N, C resource.Managed] struct {
+	managed.ExternalClient
+	convert converter[N, C]
+}
+
+func (e *external[N, C]) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
+	var obs managed.ExternalObservation
+	err := e.convert.call(mg, func(cr C) error {
+		var err error
+		obs, err = e.ExternalClient.Observe(ctx, cr)
+		return err
+	})
+	return obs, err
+}
+
+func (e *external[N, C]) Create(ctx context.Context, mg resource.Managed) (managed.ExternalCreation, error) {
+	var cre managed.ExternalCreation
+	err := e.convert.call(mg, func(cr C) error {
+		var err error
+		cre, err = e.ExternalClient.Create(ctx, cr)
+		return err
+	})
+	return cre, err
+}
+
+func (e *external[N, C]) Update(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
+	var upd managed.ExternalUpdate
+	err := e.convert.call(mg, func(cr C) error {
+		var err error
+		upd, err = e.ExternalClient.Update(ctx, cr)
+		return err
+	})
+	return upd, err
+}
+
+func (e *external[N, C]) Delete(ctx context.Context, mg resource.Managed) (managed.ExternalDelete, error) {
+	var del managed.ExternalDelete
+	err := e.convert.call(mg, func(cr C) error {
+		var err error
+		del, err = e.ExternalClient.Delete(ctx, cr)
+		return err
+	})
+	return del, err
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package namespaced
+
+import (
+	"context"
+	namespacedv1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/namespaced/room/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/apis/room/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func toCluster(nr *namespacedv1alpha1.Room) *v1alpha1.Room {
+	return &v1alpha1.Room{TypeMeta: nr.TypeMeta, ObjectMeta: nr.ObjectMeta, Spec: nr.Spec, Status: nr.Status}
+}
+
+func fromCluster(cr *v1alpha1.Room, nr *namespacedv1alpha1.Room) {
+	nr.ObjectMeta, nr.Spec, nr.Status = cr.ObjectMeta, cr.Spec, cr.Status
+}
+
+func TestConnector(t *testing.T) {
+	var connected, observed, created *v1alpha1.Room
+	c := Connector(managed.ExternalConnectorFn(func(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+		connected = mg.(*v1alpha1.Room)
+		return &managed.ExternalClientFns{
+			ObserveFn: func(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
+				observed = mg.(*v1alpha1.Room)
+				observed.Status.AtProvider.Name = "General"
+				observed.SetConditions(xpv1.Available())
+				return managed.ExternalObservation{ResourceExists: false}, nil
+			},
+			CreateFn: func(ctx context.Context, mg resource.Managed) (managed.ExternalCreation, error) {
+				created = mg.(*v1alpha1.Room)
+				meta.SetExternalName(created, "!room:example.com")
+				return managed.ExternalCreation{}, nil
+			},
+		}, nil
+	}), toCluster, fromCluster)
+
+	name := "General"
+	nr := &namespacedv1alpha1.Room{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "general"}}
+	nr.Spec.ForProvider.Name = &name
+
+	ext, err := c.Connect(context.Background(), nr)
+	require.NoError(t, err)
+	assert.Equal(t, "team-a", connected.GetNamespace(), "the ProviderConfig of the resource's namespace is used")
+
+	_, err = ext.Observe(context.Background(), nr)
+	require.NoError(t, err)
+	assert.Equal(t, &name, observed.Spec.ForProvider.Name)
+	assert.Equal(t, "General", nr.Status.AtProvider.Name)
+	assert.Equal(t, corev1.ConditionTrue, nr.GetCondition(xpv1.TypeReady).Status)
+
+	_, err = ext.Create(context.Background(), nr)
+	require.NoError(t, err)
+	assert.Equal(t, "!room:example.com", meta.GetExternalName(nr))
+
+	_, err = c.Connect(context.Background(), &v1alpha1.Room{})
+	assert.Error(t, err, "only the namespaced kind is connected to")
+}
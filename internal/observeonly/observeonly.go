@@ -0,0 +1,87 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package observeonly leaves individual fields of a managed resource to be
+// managed elsewhere. The fields listed in a resource's observeOnlyFields are
+// used to create its external resource, but afterwards they are only
+// observed, never updated or reported as drift.
+package observeonly
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Desired returns a copy of mg whose spec.forProvider leaves the fields
+// listed in its observeOnlyFields unset, or mg itself if there are none.
+// Fields are named as in JSON. Unset fields are neither compared nor updated
+// by the controllers, so the copy is what an update should apply.
+func Desired[T runtime.Object](mg T) T {
+	fields := observeOnlyFields(forProvider(mg))
+	if len(fields) == 0 {
+		return mg
+	}
+
+	desired := mg.DeepCopyObject().(T)
+	p := forProvider(desired)
+	for i := range p.NumField() {
+		name, _, _ := strings.Cut(p.Type().Field(i).Tag.Get("json"), ",")
+		if slices.Contains(fields, name) {
+			p.Field(i).SetZero()
+		}
+	}
+	return desired
+}
+
+// Drift returns drift without the given observe-only fields, for drift
+// found by comparisons Desired can't leave out
+func Drift(drift, fields []string) []string {
+	return slices.DeleteFunc(drift, func(field string) bool {
+		return slices.Contains(fields, field)
+	})
+}
+
+// Settle returns the observe-only fields of a resource that is never
+// created, and so applies them until it first reaches them: those already
+// settled and those no longer drifting. Settled fields that aren't
+// observe-only any more are forgotten, so that they are applied again.
+func Settle(settled, fields, drift []string) []string {
+	var s []string
+	for _, field := range fields {
+		if slices.Contains(settled, field) || !slices.Contains(drift, field) {
+			s = append(s, field)
+		}
+	}
+	return s
+}
+
+// forProvider returns the spec.forProvider of a managed resource
+func forProvider(mg runtime.Object) reflect.Value {
+	return reflect.ValueOf(mg).Elem().FieldByName("Spec").FieldByName("ForProvider")
+}
+
+// observeOnlyFields returns the observeOnlyFields of a forProvider, or nil
+// if it has none
+func observeOnlyFields(p reflect.Value) []string {
+	f := p.FieldByName("ObserveOnlyFields")
+	if !f.IsValid() {
+		return nil
+	}
+	fields, _ := f.Interface().([]string)
+	return fields
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package observeonly
+
+import (
+	"github.com/crossplane-contrib/provider-matrix/apis/room/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDesired(t *testing.T) {
+	name, topic := "General", "Welcome"
+	cr := &v1alpha1.Room{Spec: v1alpha1.RoomSpec{ForProvider: v1alpha1.RoomParameters{
+		Name:              &name,
+		Topic:             &topic,
+		Invite:            []string{"@alice:example.com"},
+		ObserveOnlyFields: []string{"topic", "invite"},
+	}}}
+
+	desired := Desired(cr)
+	assert.Equal(t, &name, desired.Spec.ForProvider.Name)
+	assert.Nil(t, desired.Spec.ForProvider.Topic)
+	assert.Nil(t, desired.Spec.ForProvider.Invite)
+
+	// The resource itself is left alone
+	assert.Equal(t, &topic, cr.Spec.ForProvider.Topic)
+	assert.Equal(t, []string{"@alice:example.com"}, cr.Spec.ForProvider.Invite)
+
+	// Without observe-only fields there is nothing to copy
+	cr.Spec.ForProvider.ObserveOnlyFields = nil
+	assert.Same(t, cr, Desired(cr))
+}
+
+func TestDrift(t *testing.T) {
+	assert.Equal(t, []string{"name"}, Drift([]string{"name", "topic"}, []string{"topic", "invite"}))
+	assert.Empty(t, Drift(nil, []string{"topic"}))
+}
+
+func TestSettle(t *testing.T) {
+	// Fields are settled once they stop drifting, and stay settled
+	settled := Settle(nil, []string{"membership", "powerLevel"}, []string{"powerLevel"})
+	assert.Equal(t, []string{"membership"}, settled)
+	settled = Settle(settled, []string{"membership", "powerLevel"}, []string{"membership"})
+	assert.Equal(t, []string{"membership", "powerLevel"}, settled)
+
+	// Fields that are managed again are forgotten
+	assert.Equal(t, []string{"powerLevel"}, Settle(settled, []string{"powerLevel"}, nil))
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package permission tells changes the homeserver refused because the
+// provider's user isn't allowed to make them apart from other failures, so
+// that a missing power level doesn't look like a transient error.
+package permission
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/common"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// errInsufficientPower prefixes errors the homeserver refused a change with
+// for lack of permission
+const errInsufficientPower = "insufficient power"
+
+// Connector wraps c so that creates and updates the homeserver refuses as
+// forbidden set a Forbidden condition with the InsufficientPower reason, which
+// is cleared by the next change the homeserver accepts.
+func Connector(c managed.ExternalConnector) managed.ExternalConnector {
+	return &connector{ExternalConnector: c}
+}
+
+type connector struct {
+	managed.ExternalConnector
+}
+
+func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	ext, err := c.ExternalConnector.Connect(ctx, mg)
+	if err != nil {
+		return nil, err
+	}
+	return &external{ExternalClient: ext}, nil
+}
+
+// check sets mg's Forbidden condition from the result of a change to it. A
+// forbidden error is returned prefixed with its cause: the managed reconciler
+// drops conditions set while creating a resource when it records that the
+// create failed, so the error is all that is left to show for it.
+func check(mg resource.Managed, err error) error {
+	if clients.IsForbidden(err) {
+		mg.SetConditions(common.InsufficientPower(err))
+		return errors.Wrap(err, errInsufficientPower)
+	}
+	if err == nil && mg.GetCondition(common.TypeForbidden).Status == corev1.ConditionTrue {
+		mg.SetConditions(common.Permitted())
+	}
+	return err
+}
+
+type external struct {
+	managed.ExternalClient
+}
+
+func (e *external) Create(ctx context.Context, mg resource.Managed) (managed.ExternalCreation, error) {
+	cre, err := e.ExternalClient.Create(ctx, mg)
+	return cre, check(mg, err)
+}
+
+func (e *external) Update(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
+	upd, err := e.ExternalClient.Update(ctx, mg)
+	return upd, check(mg, err)
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package permission
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/common"
+	"github.com/crossplane-contrib/provider-matrix/apis/room/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"maunium.net/go/mautrix"
+	"net/http"
+	"testing"
+)
+
+func forbidden() error {
+	return errors.Wrap(mautrix.HTTPError{
+		Response:  &http.Response{StatusCode: http.StatusForbidden},
+		RespError: &mautrix.RespError{ErrCode: "M_FORBIDDEN", Err: "You don't have permission to post that to the room"},
+	}, "cannot update Matrix room")
+}
+
+func connect(t *testing.T, err error) managed.ExternalClient {
+	t.Helper()
+	c := Connector(managed.ExternalConnectorFn(func(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+		return &managed.ExternalClientFns{
+			CreateFn: func(ctx context.Context, mg resource.Managed) (managed.ExternalCreation, error) {
+				return managed.ExternalCreation{}, err
+			},
+			UpdateFn: func(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
+				return managed.ExternalUpdate{}, err
+			},
+		}, nil
+	}))
+	ext, connectErr := c.Connect(context.Background(), &v1alpha1.Room{})
+	require.NoError(t, connectErr)
+	return ext
+}
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus corev1.ConditionStatus
+		wantReason string
+	}{
+		{
+			name:       "forbidden",
+			err:        forbidden(),
+			wantStatus: corev1.ConditionTrue,
+			wantReason: string(common.ReasonInsufficientPower),
+		},
+		{
+			name:       "admin API forbidden",
+			err:        &clients.APIError{StatusCode: http.StatusForbidden, ErrCode: "M_FORBIDDEN"},
+			wantStatus: corev1.ConditionTrue,
+			wantReason: string(common.ReasonInsufficientPower),
+		},
+		{
+			name:       "other error",
+			err:        assert.AnError,
+			wantStatus: corev1.ConditionUnknown,
+		},
+		{
+			name:       "success",
+			wantStatus: corev1.ConditionUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := &v1alpha1.Room{}
+			_, err := connect(t, tt.err).Update(context.Background(), cr)
+			assert.ErrorIs(t, err, tt.err)
+
+			cond := cr.GetCondition(common.TypeForbidden)
+			assert.Equal(t, tt.wantStatus, cond.Status)
+			assert.Equal(t, tt.wantReason, string(cond.Reason))
+		})
+	}
+}
+
+func TestForbiddenErrorNamesCause(t *testing.T) {
+	_, err := connect(t, forbidden()).Create(context.Background(), &v1alpha1.Room{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "insufficient power: cannot update Matrix room")
+	assert.True(t, clients.IsForbidden(err))
+}
+
+func TestPermittedAfterForbidden(t *testing.T) {
+	cr := &v1alpha1.Room{}
+	cr.SetConditions(common.InsufficientPower(forbidden()))
+
+	_, err := connect(t, nil).Update(context.Background(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, corev1.ConditionFalse, cr.GetCondition(common.TypeForbidden).Status)
+	assert.Equal(t, common.ReasonPermitted, cr.GetCondition(common.TypeForbidden).Reason)
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package provenance records whether the provider created the external
+// resources it manages or adopted them.
+package provenance
+
+import (
+	"github.com/crossplane-contrib/provider-matrix/apis/common"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Observe returns how the provider came to manage the existing external
+// resource of o, and when it adopted it if it did. The managed reconciler
+// annotates resources whose external resource it created, so this is known
+// even though Observe rebuilds the status. An adopted resource keeps the
+// adoptedAt it was last observed with, recording when the provider first
+// observed it.
+func Observe(o metav1.Object, adoptedAt *metav1.Time) (string, *metav1.Time) {
+	if !meta.GetExternalCreateSucceeded(o).IsZero() {
+		return common.ManagedByCreated, nil
+	}
+	if adoptedAt == nil {
+		now := metav1.Now()
+		adoptedAt = &now
+	}
+	return common.ManagedByAdopted, adoptedAt
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provenance
+
+import (
+	"github.com/crossplane-contrib/provider-matrix/apis/common"
+	"github.com/crossplane-contrib/provider-matrix/apis/room/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+	"time"
+)
+
+func TestObserve(t *testing.T) {
+	adopted := &v1alpha1.Room{}
+	managedBy, adoptedAt := Observe(adopted, nil)
+	assert.Equal(t, common.ManagedByAdopted, managedBy)
+	assert.NotNil(t, adoptedAt)
+
+	// The time of adoption is kept
+	earlier := metav1.NewTime(time.Now().Add(-time.Hour))
+	_, adoptedAt = Observe(adopted, &earlier)
+	assert.Equal(t, &earlier, adoptedAt)
+
+	created := &v1alpha1.Room{}
+	meta.SetExternalCreateSucceeded(created, time.Now())
+	managedBy, adoptedAt = Observe(created, nil)
+	assert.Equal(t, common.ManagedByCreated, managedBy)
+	assert.Nil(t, adoptedAt)
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package resync schedules how often resources are re-observed per
+// ProviderConfig, so that slow homeservers can be checked for drift less
+// often than the provider-wide poll interval.
+package resync
+
+import (
+	"github.com/crossplane-contrib/provider-matrix/apis/v1beta1"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	"sync"
+	"time"
+)
+
+var (
+	mu        sync.Mutex
+	intervals = map[string]time.Duration{}
+)
+
+// Set records the resync interval of pc, or clears it if pc doesn't set one
+// so that its resources are polled at the provider-wide interval again.
+func Set(pc v1beta1.ProviderConfigObject) {
+	name := clients.ProviderConfigName(pc.GetNamespace(), pc.GetName())
+	interval := pc.GetProviderConfigSpec().ResyncInterval
+
+	mu.Lock()
+	defer mu.Unlock()
+	if interval == nil || interval.Duration <= 0 {
+		delete(intervals, name)
+		return
+	}
+	intervals[name] = interval.Duration
+}
+
+// PollIntervalHook returns the resync interval of the ProviderConfig mg uses,
+// or pollInterval if it doesn't set one.
+func PollIntervalHook(mg resource.Managed, pollInterval time.Duration) time.Duration {
+	pcr, ok := mg.(resource.TypedProviderConfigReferencer)
+	if !ok || pcr.GetProviderConfigReference() == nil {
+		return pollInterval
+	}
+
+	ref := pcr.GetProviderConfigReference()
+	name := clients.ProviderConfigName(clients.ProviderConfigNamespace(mg, ref), ref.Name)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if interval, ok := intervals[name]; ok {
+		return interval
+	}
+	return pollInterval
+}
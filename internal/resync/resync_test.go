@@ -0,0 +1,67 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resync
+
+import (
+	namespacedroomv1alpha1 "github.com/crossplane-contrib/provider-matrix/apis/namespaced/room/v1alpha1"
+	namespacedv1beta1 "github.com/crossplane-contrib/provider-matrix/apis/namespaced/v1beta1"
+	"github.com/crossplane-contrib/provider-matrix/apis/room/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/apis/v1beta1"
+	xpv1 "github.com/crossplane/crossplane/apis/v2/core/v2"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+	"time"
+)
+
+func TestPollIntervalHook(t *testing.T) {
+	slow := &v1alpha1.Room{}
+	slow.SetProviderConfigReference(&xpv1.ProviderConfigReference{Name: "slow"})
+	fast := &v1alpha1.Room{}
+	fast.SetProviderConfigReference(&xpv1.ProviderConfigReference{Name: "fast"})
+
+	pc := &v1beta1.ProviderConfig{ObjectMeta: metav1.ObjectMeta{Name: "slow"}}
+	pc.Spec.ResyncInterval = &metav1.Duration{Duration: 10 * time.Minute}
+	Set(pc)
+
+	assert.Equal(t, 10*time.Minute, PollIntervalHook(slow, time.Minute))
+	assert.Equal(t, time.Minute, PollIntervalHook(fast, time.Minute))
+
+	pc.Spec.ResyncInterval = nil
+	Set(pc)
+	assert.Equal(t, time.Minute, PollIntervalHook(slow, time.Minute))
+}
+
+func TestPollIntervalHookNamespaced(t *testing.T) {
+	pc := &namespacedv1beta1.ProviderConfig{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "default"}}
+	pc.Spec.ResyncInterval = &metav1.Duration{Duration: 10 * time.Minute}
+	Set(pc)
+
+	local := &namespacedroomv1alpha1.Room{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a"}}
+	local.SetProviderConfigReference(&xpv1.ProviderConfigReference{Kind: "ProviderConfig", Name: "default"})
+	assert.Equal(t, 10*time.Minute, PollIntervalHook(local, time.Minute))
+
+	// The namespaced ProviderConfig isn't mistaken for the cluster scoped
+	// one of the same name, nor for that of another namespace
+	cluster := &namespacedroomv1alpha1.Room{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a"}}
+	cluster.SetProviderConfigReference(&xpv1.ProviderConfigReference{Kind: "ClusterProviderConfig", Name: "default"})
+	assert.Equal(t, time.Minute, PollIntervalHook(cluster, time.Minute))
+
+	other := &namespacedroomv1alpha1.Room{ObjectMeta: metav1.ObjectMeta{Namespace: "team-b"}}
+	other.SetProviderConfigReference(&xpv1.ProviderConfigReference{Kind: "ProviderConfig", Name: "default"})
+	assert.Equal(t, time.Minute, PollIntervalHook(other, time.Minute))
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package upgrade detects when a room a resource refers to has been upgraded,
+// and optionally follows the upgrade to the room that replaced it.
+package upgrade
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/common"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/resource"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// TargetRoom returns the room a resource should act on: roomID, or the room
+// that replaced it if it was upgraded and follow is set. An upgrade is
+// reported on cr by the RoomUpgraded condition.
+func TargetRoom(ctx context.Context, service clients.Client, cr resource.Conditioned, roomID string, follow bool) string {
+	// Upgrades are only detected on a best-effort basis, so a room whose
+	// tombstone can't be read is treated as not upgraded
+	replacement, err := service.GetReplacementRoom(ctx, roomID)
+	if err != nil || replacement == roomID {
+		if cr.GetCondition(common.TypeRoomUpgraded).Status == corev1.ConditionTrue {
+			cr.SetConditions(common.RoomNotUpgraded())
+		}
+		return roomID
+	}
+
+	cr.SetConditions(common.RoomUpgraded(roomID, replacement, follow))
+	if follow {
+		return replacement
+	}
+	return roomID
+}
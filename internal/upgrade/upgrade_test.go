@@ -0,0 +1,62 @@
+/*
+Copyright 2025 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upgrade
+
+import (
+	"context"
+	"github.com/crossplane-contrib/provider-matrix/apis/common"
+	"github.com/crossplane-contrib/provider-matrix/apis/powerlevel/v1alpha1"
+	"github.com/crossplane-contrib/provider-matrix/internal/clients"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"testing"
+)
+
+type mockClient struct {
+	clients.Client
+
+	replacement string
+	err         error
+}
+
+func (m *mockClient) GetReplacementRoom(ctx context.Context, roomID string) (string, error) {
+	if m.replacement == "" {
+		return roomID, m.err
+	}
+	return m.replacement, m.err
+}
+
+func TestTargetRoom(t *testing.T) {
+	m := &mockClient{}
+	cr := &v1alpha1.PowerLevel{}
+
+	assert.Equal(t, "!old:example.com", TargetRoom(context.Background(), m, cr, "!old:example.com", true))
+	assert.Equal(t, corev1.ConditionUnknown, cr.GetCondition(common.TypeRoomUpgraded).Status)
+
+	// Upgraded, but upgrades aren't followed
+	m.replacement = "!new:example.com"
+	assert.Equal(t, "!old:example.com", TargetRoom(context.Background(), m, cr, "!old:example.com", false))
+	assert.Equal(t, corev1.ConditionTrue, cr.GetCondition(common.TypeRoomUpgraded).Status)
+
+	assert.Equal(t, "!new:example.com", TargetRoom(context.Background(), m, cr, "!old:example.com", true))
+
+	// A tombstone that can't be read is taken as no upgrade
+	m.replacement, m.err = "", errors.New("boom")
+	assert.Equal(t, "!old:example.com", TargetRoom(context.Background(), m, cr, "!old:example.com", true))
+	assert.Equal(t, corev1.ConditionFalse, cr.GetCondition(common.TypeRoomUpgraded).Status)
+}
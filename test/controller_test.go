@@ -25,6 +25,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"maunium.net/go/mautrix"
+	"net/http"
 	"testing"
 )
 
@@ -305,14 +307,19 @@ func TestIsNotFound(t *testing.T) {
 			expect: false,
 		},
 		{
-			name:   "404 in message",
-			err:    errors.New("404 not found"),
+			name:   "admin API not found",
+			err:    &clients.APIError{StatusCode: http.StatusNotFound, ErrCode: "M_NOT_FOUND"},
+			expect: true,
+		},
+		{
+			name:   "Matrix API not found",
+			err:    mautrix.HTTPError{RespError: &mautrix.RespError{ErrCode: "M_NOT_FOUND"}},
 			expect: true,
 		},
 		{
 			name:   "not found in message",
 			err:    errors.New("resource not found"),
-			expect: true,
+			expect: false,
 		},
 		{
 			name:   "generic error",
@@ -250,6 +250,13 @@ func stringPtr(s string) *string {
 	return &s
 }
 
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
 func timePtr(t time.Time) *metav1.Time {
 	return &metav1.Time{Time: t}
 }
@@ -82,8 +82,8 @@ func (m *MockFieldUpdateClient) UpdateRoom(ctx context.Context, roomID string, s
 	}
 
 	// Field-level update: only update specified fields
-	if spec.Name != "" {
-		room.Name = spec.Name
+	if spec.Name != nil {
+		room.Name = *spec.Name
 	}
 	if spec.Topic != "" {
 		room.Topic = spec.Topic
@@ -226,7 +226,7 @@ func TestFieldLevelRoomUpdate_NameAndVisibility(t *testing.T) {
 
 	// Update name and visibility, not topic
 	updated, err := client.UpdateRoom(context.Background(), roomID, &clients.RoomSpec{
-		Name:       "New Name",
+		Name:       stringPtr("New Name"),
 		Visibility: "private",
 	})
 	require.NoError(t, err)
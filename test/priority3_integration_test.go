@@ -143,7 +143,7 @@ func (m *MockRealServerClient) CreateRoom(ctx context.Context, room *clients.Roo
 	defer m.mu.Unlock()
 
 	// Generate a room ID from the alias or name
-	roomID := "!" + generateRoomID(room.Alias, room.Name) + ":example.com"
+	roomID := "!" + generateRoomID(room.Alias, stringValue(room.Name)) + ":example.com"
 
 	if _, exists := m.rooms[roomID]; exists {
 		return nil, errors.New("room already exists")
@@ -151,7 +151,7 @@ func (m *MockRealServerClient) CreateRoom(ctx context.Context, room *clients.Roo
 
 	newRoom := &clients.Room{
 		RoomID:     roomID,
-		Name:       room.Name,
+		Name:       stringValue(room.Name),
 		Topic:      room.Topic,
 		Visibility: room.Visibility,
 	}
@@ -183,8 +183,8 @@ func (m *MockRealServerClient) UpdateRoom(ctx context.Context, roomID string, ro
 		return nil, errors.New("room not found")
 	}
 
-	if room.Name != "" {
-		existing.Name = room.Name
+	if room.Name != nil {
+		existing.Name = *room.Name
 	}
 	if room.Topic != "" {
 		existing.Topic = room.Topic
@@ -331,7 +331,7 @@ func TestRealServerRoomIntegration(t *testing.T) {
 
 	// Create room
 	room, err := client.CreateRoom(context.Background(), &clients.RoomSpec{
-		Name:       "General",
+		Name:       stringPtr("General"),
 		Topic:      "General discussion",
 		Visibility: "public",
 	})
@@ -340,7 +340,7 @@ func TestRealServerRoomIntegration(t *testing.T) {
 
 	// Update room
 	updated, err := client.UpdateRoom(context.Background(), room.RoomID, &clients.RoomSpec{
-		Name: "General Chat",
+		Name: stringPtr("General Chat"),
 	})
 	require.NoError(t, err)
 	assert.Equal(t, "General Chat", updated.Name)
@@ -402,7 +402,7 @@ func TestConcurrentRoomOperations(t *testing.T) {
 
 	// Create initial room
 	room, err := client.CreateRoom(context.Background(), &clients.RoomSpec{
-		Name: "Test Room",
+		Name: stringPtr("Test Room"),
 	})
 	require.NoError(t, err)
 
@@ -457,7 +457,7 @@ func TestConcurrentUserAndRoomCreation(t *testing.T) {
 		go func(id int) {
 			defer wg.Done()
 			_, err := client.CreateRoom(context.Background(), &clients.RoomSpec{
-				Name: fmt.Sprintf("Room %d", id),
+				Name: stringPtr(fmt.Sprintf("Room %d", id)),
 			})
 			if err != nil {
 				roomErrors <- err
@@ -522,7 +522,7 @@ func TestLoadRoomCreation(t *testing.T) {
 	startTime := time.Now()
 	for i := 0; i < numRooms; i++ {
 		_, err := client.CreateRoom(context.Background(), &clients.RoomSpec{
-			Name: fmt.Sprintf("Load Room %d", i),
+			Name: stringPtr(fmt.Sprintf("Load Room %d", i)),
 		})
 		require.NoError(t, err)
 	}
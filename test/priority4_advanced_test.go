@@ -37,7 +37,7 @@ func (m *MockAdvancedRoomClient) CreateRoom(ctx context.Context, room *clients.R
 	if m.createRoomFn != nil {
 		return m.createRoomFn(ctx, room)
 	}
-	return &clients.Room{Name: room.Name}, nil
+	return &clients.Room{Name: stringValue(room.Name)}, nil
 }
 
 // Stub implementations
@@ -99,18 +99,18 @@ func TestRoomCreationWithInitialState(t *testing.T) {
 				for _, event := range room.InitialState {
 					if event.Type == "m.room.encryption" {
 						return &clients.Room{
-							Name:              room.Name,
+							Name:              stringValue(room.Name),
 							EncryptionEnabled: true,
 						}, nil
 					}
 				}
 			}
-			return &clients.Room{Name: room.Name}, nil
+			return &clients.Room{Name: stringValue(room.Name)}, nil
 		},
 	}
 
 	room, err := mockClient.CreateRoom(context.Background(), &clients.RoomSpec{
-		Name: "Encrypted Room",
+		Name: stringPtr("Encrypted Room"),
 		InitialState: []clients.StateEvent{
 			{
 				Type:     "m.room.encryption",
@@ -132,17 +132,17 @@ func TestRoomCreationWithPowerLevelOverrides(t *testing.T) {
 		createRoomFn: func(ctx context.Context, room *clients.RoomSpec) (*clients.Room, error) {
 			if room.PowerLevelOverrides != nil {
 				return &clients.Room{
-					Name:        room.Name,
+					Name:        stringValue(room.Name),
 					PowerLevels: room.PowerLevelOverrides,
 				}, nil
 			}
-			return &clients.Room{Name: room.Name}, nil
+			return &clients.Room{Name: stringValue(room.Name)}, nil
 		},
 	}
 
 	levelFifty := 50
 	room, err := mockClient.CreateRoom(context.Background(), &clients.RoomSpec{
-		Name: "Admin Room",
+		Name: stringPtr("Admin Room"),
 		PowerLevelOverrides: &clients.PowerLevelContent{
 			UsersDefault: &levelFifty,
 		},
@@ -159,16 +159,16 @@ func TestRoomCreationWithCreationContent(t *testing.T) {
 			if room.CreationContent != nil {
 				if federated, ok := room.CreationContent["m.federate"].(bool); ok && !federated {
 					return &clients.Room{
-						Name: room.Name,
+						Name: stringValue(room.Name),
 					}, nil
 				}
 			}
-			return &clients.Room{Name: room.Name}, nil
+			return &clients.Room{Name: stringValue(room.Name)}, nil
 		},
 	}
 
 	room, err := mockClient.CreateRoom(context.Background(), &clients.RoomSpec{
-		Name: "Private Room",
+		Name: stringPtr("Private Room"),
 		CreationContent: map[string]interface{}{
 			"m.federate": false,
 		},
@@ -593,7 +593,7 @@ func TestUpdateRoomPreset(t *testing.T) {
 			if room.Preset != "" {
 				return &clients.Room{
 					RoomID: roomID,
-					Name:   room.Name,
+					Name:   stringValue(room.Name),
 				}, nil
 			}
 			return &clients.Room{RoomID: roomID}, nil
@@ -602,7 +602,7 @@ func TestUpdateRoomPreset(t *testing.T) {
 
 	updated, err := mockClient.UpdateRoom(context.Background(), "!room:example.com", &clients.RoomSpec{
 		Preset: "public_chat",
-		Name:   "Public Chat Room",
+		Name:   stringPtr("Public Chat Room"),
 	})
 
 	require.NoError(t, err)
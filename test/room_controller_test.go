@@ -40,7 +40,7 @@ func (m *MockRoomClient) CreateRoom(ctx context.Context, room *clients.RoomSpec)
 	if m.createRoomFn != nil {
 		return m.createRoomFn(ctx, room)
 	}
-	return &clients.Room{RoomID: room.Name}, nil
+	return &clients.Room{RoomID: stringValue(room.Name)}, nil
 }
 
 func (m *MockRoomClient) GetRoom(ctx context.Context, roomID string) (*clients.Room, error) {
@@ -123,7 +123,7 @@ func TestRoomCreate(t *testing.T) {
 		createRoomFn: func(ctx context.Context, room *clients.RoomSpec) (*clients.Room, error) {
 			return &clients.Room{
 				RoomID:            "!test:example.com",
-				Name:              room.Name,
+				Name:              stringValue(room.Name),
 				Topic:             room.Topic,
 				EncryptionEnabled: room.EncryptionEnabled,
 			}, nil
@@ -131,7 +131,7 @@ func TestRoomCreate(t *testing.T) {
 	}
 
 	spec := &clients.RoomSpec{
-		Name:              "Test Room",
+		Name:              stringPtr("Test Room"),
 		Topic:             "A test room",
 		Preset:            "private_chat",
 		EncryptionEnabled: true,
@@ -154,7 +154,7 @@ func TestRoomCreateError(t *testing.T) {
 	}
 
 	spec := &clients.RoomSpec{
-		Name: "Bad Room",
+		Name: stringPtr("Bad Room"),
 	}
 
 	_, err := mockClient.CreateRoom(context.Background(), spec)
@@ -184,14 +184,14 @@ func TestRoomUpdate(t *testing.T) {
 		updateRoomFn: func(ctx context.Context, roomID string, room *clients.RoomSpec) (*clients.Room, error) {
 			return &clients.Room{
 				RoomID: roomID,
-				Name:   room.Name,
+				Name:   stringValue(room.Name),
 				Topic:  room.Topic,
 			}, nil
 		},
 	}
 
 	spec := &clients.RoomSpec{
-		Name:  "Updated Room",
+		Name:  stringPtr("Updated Room"),
 		Topic: "Updated topic",
 	}
 
@@ -121,14 +121,14 @@ func TestSpaceCreate(t *testing.T) {
 		createRoomFn: func(ctx context.Context, room *clients.RoomSpec) (*clients.Room, error) {
 			return &clients.Room{
 				RoomID: "!space:example.com",
-				Name:   room.Name,
+				Name:   stringValue(room.Name),
 				Topic:  room.Topic,
 			}, nil
 		},
 	}
 
 	spec := &clients.RoomSpec{
-		Name:  "Engineering",
+		Name:  stringPtr("Engineering"),
 		Topic: "Engineering organization space",
 	}
 
@@ -162,14 +162,14 @@ func TestSpaceUpdate(t *testing.T) {
 		updateRoomFn: func(ctx context.Context, roomID string, room *clients.RoomSpec) (*clients.Room, error) {
 			return &clients.Room{
 				RoomID: roomID,
-				Name:   room.Name,
+				Name:   stringValue(room.Name),
 				Topic:  room.Topic,
 			}, nil
 		},
 	}
 
 	spec := &clients.RoomSpec{
-		Name:  "Updated Space",
+		Name:  stringPtr("Updated Space"),
 		Topic: "Updated topic",
 	}
 